@@ -0,0 +1,83 @@
+// FilePath: build.rs
+
+//! Embed build metadata (git commit, build date, compiler version) so that
+//! `lazytables --version --verbose` reports exactly what was built.
+//! Packagers can set LAZYTABLES_GIT_COMMIT / LAZYTABLES_BUILD_DATE directly,
+//! e.g. when building from a source tarball without a .git directory.
+
+use std::process::Command;
+use std::time::{SystemTime, UNIX_EPOCH};
+
+fn main() {
+    let commit = std::env::var("LAZYTABLES_GIT_COMMIT")
+        .ok()
+        .or_else(git_commit)
+        .unwrap_or_else(|| "unknown".to_string());
+
+    let build_date = std::env::var("LAZYTABLES_BUILD_DATE")
+        .ok()
+        .unwrap_or_else(build_date);
+
+    let rustc = std::env::var("RUSTC").unwrap_or_else(|_| "rustc".to_string());
+    let rustc_version =
+        command_output(&rustc, &["--version"]).unwrap_or_else(|| "unknown".to_string());
+
+    println!("cargo:rustc-env=LAZYTABLES_GIT_COMMIT={commit}");
+    println!("cargo:rustc-env=LAZYTABLES_BUILD_DATE={build_date}");
+    println!("cargo:rustc-env=LAZYTABLES_RUSTC_VERSION={rustc_version}");
+    println!(
+        "cargo:rustc-env=LAZYTABLES_TARGET={}",
+        std::env::var("TARGET").unwrap_or_default()
+    );
+
+    println!("cargo:rerun-if-env-changed=LAZYTABLES_GIT_COMMIT");
+    println!("cargo:rerun-if-env-changed=LAZYTABLES_BUILD_DATE");
+    println!("cargo:rerun-if-env-changed=SOURCE_DATE_EPOCH");
+    println!("cargo:rerun-if-changed=.git/HEAD");
+    println!("cargo:rerun-if-changed=.git/refs");
+}
+
+/// Short commit hash, with a "-dirty" suffix for uncommitted changes
+fn git_commit() -> Option<String> {
+    let hash = command_output("git", &["rev-parse", "--short=10", "HEAD"])?;
+    let dirty = command_output("git", &["status", "--porcelain", "--untracked-files=no"])
+        .map(|status| !status.is_empty())
+        .unwrap_or(false);
+    Some(if dirty { format!("{hash}-dirty") } else { hash })
+}
+
+/// Build date as YYYY-MM-DD (UTC), honouring SOURCE_DATE_EPOCH for reproducible builds
+fn build_date() -> String {
+    let secs = std::env::var("SOURCE_DATE_EPOCH")
+        .ok()
+        .and_then(|epoch| epoch.parse::<u64>().ok())
+        .unwrap_or_else(|| {
+            SystemTime::now()
+                .duration_since(UNIX_EPOCH)
+                .map(|d| d.as_secs())
+                .unwrap_or(0)
+        });
+
+    // Civil date from days since the epoch (Howard Hinnant's algorithm)
+    let days = (secs / 86_400) as i64 + 719_468;
+    let era = days.div_euclid(146_097);
+    let day_of_era = days.rem_euclid(146_097);
+    let year_of_era =
+        (day_of_era - day_of_era / 1460 + day_of_era / 36_524 - day_of_era / 146_096) / 365;
+    let day_of_year = day_of_era - (365 * year_of_era + year_of_era / 4 - year_of_era / 100);
+    let mp = (5 * day_of_year + 2) / 153;
+    let day = day_of_year - (153 * mp + 2) / 5 + 1;
+    let month = if mp < 10 { mp + 3 } else { mp - 9 };
+    let year = year_of_era + era * 400 + i64::from(month <= 2);
+
+    format!("{year:04}-{month:02}-{day:02}")
+}
+
+fn command_output(program: &str, args: &[&str]) -> Option<String> {
+    let output = Command::new(program).args(args).output().ok()?;
+    if !output.status.success() {
+        return None;
+    }
+    let text = String::from_utf8(output.stdout).ok()?;
+    Some(text.trim().to_string())
+}
@@ -0,0 +1,195 @@
+// FilePath: src/app/debounce.rs
+
+//! Debounced background fetches
+//!
+//! Holding j in a list would otherwise start a fetch for every row passed,
+//! flooding the server and letting slow responses for rows long gone land
+//! after the one for the current row. A fetch only starts once the selection
+//! has rested for a moment, and every result carries the generation it was
+//! scheduled under so anything superseded in the meantime is dropped.
+//! Table previews are read the same way, one fetch per table, so a preview
+//! from before the table was opened again or the connection switched never
+//! lands in a tab.
+
+#![forbid(unsafe_code)]
+
+use std::collections::HashMap;
+use std::future::Future;
+use std::sync::{
+    atomic::{AtomicU64, Ordering},
+    Arc,
+};
+use std::time::Duration;
+
+/// How long a selection has to rest before its fetch starts
+pub const SELECTION_DEBOUNCE: Duration = Duration::from_millis(200);
+
+/// Debounces one kind of fetch, such as the details pane metadata
+#[derive(Debug, Clone, Default)]
+pub struct DebouncedFetch {
+    generation: Arc<AtomicU64>,
+}
+
+impl DebouncedFetch {
+    pub fn new() -> Self {
+        Self::default()
+    }
+
+    /// Supersede any earlier fetch and start `fetch` after `delay` unless
+    /// another one is scheduled first. `fetch` gets the generation to tag its
+    /// result with
+    pub fn schedule<F, Fut>(&self, delay: Duration, fetch: F) -> u64
+    where
+        F: FnOnce(u64) -> Fut + Send + 'static,
+        Fut: Future<Output = ()> + Send + 'static,
+    {
+        let generation = self.cancel();
+        let current = Arc::clone(&self.generation);
+        tokio::spawn(async move {
+            tokio::time::sleep(delay).await;
+            if current.load(Ordering::SeqCst) == generation {
+                fetch(generation).await;
+            }
+        });
+        generation
+    }
+
+    /// Drop the pending fetch and any result still in flight
+    pub fn cancel(&self) -> u64 {
+        self.generation.fetch_add(1, Ordering::SeqCst) + 1
+    }
+
+    /// Whether a result tagged with `generation` is still wanted
+    pub fn is_current(&self, generation: u64) -> bool {
+        self.generation.load(Ordering::SeqCst) == generation
+    }
+}
+
+/// Fetches keyed by what they read, such as each table's preview: a fetch
+/// supersedes only the earlier one for its key
+#[derive(Debug, Default)]
+pub struct KeyedFetches {
+    fetches: HashMap<String, DebouncedFetch>,
+}
+
+impl KeyedFetches {
+    pub fn new() -> Self {
+        Self::default()
+    }
+
+    /// Supersede any earlier fetch for `key` and start `fetch` after `delay`
+    pub fn schedule<F, Fut>(&mut self, key: &str, delay: Duration, fetch: F) -> u64
+    where
+        F: FnOnce(u64) -> Fut + Send + 'static,
+        Fut: Future<Output = ()> + Send + 'static,
+    {
+        self.fetches
+            .entry(key.to_string())
+            .or_default()
+            .schedule(delay, fetch)
+    }
+
+    /// Take the result for `key` tagged with `generation` if it's still
+    /// wanted; once taken, the key has nothing in flight
+    pub fn take(&mut self, key: &str, generation: u64) -> bool {
+        let current = self
+            .fetches
+            .get(key)
+            .is_some_and(|fetch| fetch.is_current(generation));
+        if current {
+            self.fetches.remove(key);
+        }
+        current
+    }
+
+    /// Whether a result is still wanted for some key
+    pub fn is_pending(&self) -> bool {
+        !self.fetches.is_empty()
+    }
+
+    /// Drop every pending fetch and any result still in flight
+    pub fn cancel_all(&mut self) {
+        for (_, fetch) in self.fetches.drain() {
+            fetch.cancel();
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use tokio::sync::mpsc;
+
+    #[tokio::test]
+    async fn test_only_the_last_selection_is_fetched() {
+        let debounce = DebouncedFetch::new();
+        let (tx, mut rx) = mpsc::unbounded_channel();
+
+        for row in 0..5 {
+            let tx = tx.clone();
+            debounce.schedule(Duration::from_millis(50), move |generation| async move {
+                let _ = tx.send((generation, row));
+            });
+            tokio::time::sleep(Duration::from_millis(5)).await;
+        }
+        drop(tx);
+
+        let (generation, row) = rx.recv().await.unwrap();
+        assert_eq!(row, 4);
+        assert!(debounce.is_current(generation));
+        assert!(rx.recv().await.is_none());
+    }
+
+    #[tokio::test]
+    async fn test_cancel_drops_in_flight_result() {
+        let debounce = DebouncedFetch::new();
+        let (tx, mut rx) = mpsc::unbounded_channel();
+
+        debounce.schedule(Duration::ZERO, move |generation| async move {
+            tokio::time::sleep(Duration::from_millis(50)).await;
+            let _ = tx.send(generation);
+        });
+        tokio::time::sleep(Duration::from_millis(10)).await;
+        debounce.cancel();
+
+        let generation = rx.recv().await.unwrap();
+        assert!(!debounce.is_current(generation));
+    }
+
+    #[tokio::test]
+    async fn test_stale_preview_is_dropped() {
+        let mut previews = KeyedFetches::new();
+        let (tx, mut rx) = mpsc::unbounded_channel();
+
+        for table in ["orders", "orders", "users"] {
+            let tx = tx.clone();
+            previews.schedule(table, Duration::ZERO, move |generation| async move {
+                tokio::time::sleep(Duration::from_millis(10)).await;
+                let _ = tx.send((table, generation));
+            });
+            tokio::time::sleep(Duration::from_millis(2)).await;
+        }
+        drop(tx);
+
+        let mut taken = Vec::new();
+        while let Some((table, generation)) = rx.recv().await {
+            if previews.take(table, generation) {
+                taken.push(table);
+            }
+        }
+        // The first orders preview was superseded by opening it again
+        assert_eq!(taken, vec!["orders", "users"]);
+        assert!(!previews.is_pending());
+
+        // A connection switch drops whatever is still in flight
+        let (tx, mut rx) = mpsc::unbounded_channel();
+        previews.schedule("orders", Duration::ZERO, move |generation| async move {
+            tokio::time::sleep(Duration::from_millis(10)).await;
+            let _ = tx.send(generation);
+        });
+        tokio::time::sleep(Duration::from_millis(2)).await;
+        previews.cancel_all();
+        let generation = rx.recv().await.unwrap();
+        assert!(!previews.take("orders", generation));
+    }
+}
@@ -5,15 +5,27 @@
 #![forbid(unsafe_code)]
 
 use crate::{
-    app::{App, ConnectionEvent, TestConnectionEvent},
+    app::{App, ColumnsWarmedEvent, ConnectionEvent, PingEvent, TestConnectionEvent},
     core::error::Result,
+    database::server_role::ServerRole,
+    ui::components::operation,
 };
 use crossterm::event::{KeyCode, KeyEvent, KeyModifiers};
+use std::time::Duration;
 
 /// Handle Connections pane keys - DIRECT KEY BINDINGS (no insert mode)
 pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
     // Search mode active - handle search input
     if app.state.ui.connections_search_active {
+        if let Some(edit) = super::readline_edit(app, &key) {
+            for _ in 0..edit.erased(&app.state.ui.connections_search_query) {
+                app.state.ui.backspace_connections_search();
+            }
+            app.state
+                .ui
+                .update_filtered_connections(&app.state.db.connections.connections);
+            return Ok(());
+        }
         match key.code {
             KeyCode::Esc => {
                 app.state.ui.exit_connections_search();
@@ -36,69 +48,7 @@ pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
                     return Ok(()); // No connection selected
                 };
 
-                // Don't start new connection if one is already in progress
-                if app.state.connecting_in_progress.is_some() {
-                    app.state
-                        .toast_manager
-                        .warning("Connection attempt already in progress");
-                    return Ok(());
-                }
-
-                // Mark connection as in progress
-                app.state.connecting_in_progress = Some(selected_index);
-                app.state.connecting_animation_frame = 0;
-                app.state.connection_start_time = Some(std::time::Instant::now());
-
-                // Set status to connecting immediately
-                if let Some(conn) = app.state.db.connections.connections.get_mut(selected_index) {
-                    conn.status = crate::database::ConnectionStatus::Connecting;
-                    app.state
-                        .toast_manager
-                        .info(format!("Connecting to {}...", conn.name));
-                }
-
-                // Clone necessary data for background task
-                let connection_config =
-                    app.state.db.connections.connections[selected_index].clone();
-                let connection_manager = app.state.connection_manager.clone();
-                let tx = app.connection_events_tx.clone();
-
-                // Spawn connection task in background
-                tokio::spawn(async move {
-                    // Attempt to establish connection
-                    match connection_manager.connect(&connection_config).await {
-                        Ok(_) => {
-                            // Connection succeeded, now get database objects
-                            match connection_manager
-                                .list_database_objects(&connection_config.id)
-                                .await
-                            {
-                                Ok(objects) => {
-                                    // Send success event
-                                    let _ = tx.send(ConnectionEvent::Success {
-                                        connection_index: selected_index,
-                                        objects,
-                                    });
-                                }
-                                Err(e) => {
-                                    // Connection succeeded but listing objects failed
-                                    let _ = tx.send(ConnectionEvent::Failed {
-                                        connection_index: selected_index,
-                                        error: format!("Failed to load database objects: {}", e),
-                                    });
-                                }
-                            }
-                        }
-                        Err(e) => {
-                            // Connection failed
-                            let _ = tx.send(ConnectionEvent::Failed {
-                                connection_index: selected_index,
-                                error: e.to_string(),
-                            });
-                        }
-                    }
-                });
-
+                connect_to_connection(app, selected_index);
                 app.state.ui.exit_connections_search();
             }
             KeyCode::Down => {
@@ -124,6 +74,10 @@ pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
 
     // Normal mode - direct key bindings
     match key.code {
+        // Esc - Stop the connection attempt in progress
+        KeyCode::Esc => {
+            cancel_connecting(app);
+        }
         // 'a' - Add new connection
         KeyCode::Char('a') => {
             app.state.open_add_connection_modal();
@@ -134,18 +88,27 @@ pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
         }
         // 'd' - Delete selected connection
         KeyCode::Char('d') => {
-            if !app.state.db.connections.connections.is_empty() {
-                let index = app.state.ui.selected_connection;
+            let selected = app.state.ui.selected_connection;
+            if let Some(connection) = app.state.db.connections.connections.get(selected) {
+                let closing = if connection.is_connected() {
+                    " It is open and will be disconnected first."
+                } else {
+                    ""
+                };
                 app.state.ui.confirmation_modal = Some(crate::ui::ConfirmationModal {
                     title: "Delete Connection".to_string(),
                     message: format!(
-                        "Are you sure you want to delete the connection '{}'?",
-                        app.state.db.connections.connections[index].name
+                        "Are you sure you want to delete the connection '{}'?{closing}",
+                        connection.name
                     ),
-                    action: crate::ui::ConfirmationAction::DeleteConnection(index),
+                    action: crate::ui::ConfirmationAction::DeleteConnection(connection.id.clone()),
                 });
             }
         }
+        // 't' - Test the selected connection without connecting to it
+        KeyCode::Char('t') => {
+            test_selected_connection(app);
+        }
         // Enter or Space - Connect to selected database
         KeyCode::Enter | KeyCode::Char(' ') => {
             // Get selected connection index
@@ -159,90 +122,58 @@ pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
                 return Ok(()); // No connection selected
             };
 
-            // Don't start new connection if one is already in progress
-            if app.state.connecting_in_progress.is_some() {
-                app.state
-                    .toast_manager
-                    .warning("Connection attempt already in progress");
-                return Ok(());
-            }
-
-            // Mark connection as in progress
-            app.state.connecting_in_progress = Some(selected_index);
-            app.state.connecting_animation_frame = 0;
-            app.state.connection_start_time = Some(std::time::Instant::now());
-
-            // Set status to connecting immediately (for visual feedback)
-            if let Some(conn) = app.state.db.connections.connections.get_mut(selected_index) {
-                conn.status = crate::database::ConnectionStatus::Connecting;
-                app.state
-                    .toast_manager
-                    .info(format!("Connecting to {}...", conn.name));
-            }
-
-            // Clone necessary data for background task
-            let connection_config = app.state.db.connections.connections[selected_index].clone();
-            let connection_manager = app.state.connection_manager.clone();
-            let tx = app.connection_events_tx.clone();
-
-            // Spawn connection task in background
-            tokio::spawn(async move {
-                // Attempt to establish connection
-                match connection_manager.connect(&connection_config).await {
-                    Ok(_) => {
-                        // Connection succeeded, now get database objects
-                        match connection_manager
-                            .list_database_objects(&connection_config.id)
-                            .await
-                        {
-                            Ok(objects) => {
-                                // Send success event
-                                let _ = tx.send(ConnectionEvent::Success {
-                                    connection_index: selected_index,
-                                    objects,
-                                });
-                            }
-                            Err(e) => {
-                                // Connection succeeded but listing objects failed
-                                let _ = tx.send(ConnectionEvent::Failed {
-                                    connection_index: selected_index,
-                                    error: format!("Failed to load database objects: {}", e),
-                                });
-                            }
-                        }
-                    }
-                    Err(e) => {
-                        // Connection failed
-                        let _ = tx.send(ConnectionEvent::Failed {
-                            connection_index: selected_index,
-                            error: e.to_string(),
-                        });
-                    }
-                }
-            });
+            connect_to_connection(app, selected_index);
         }
         // 'r' - Refresh connections list
         KeyCode::Char('r') => {
             app.state.toast_manager.info("Connections refreshed");
         }
+        // 'y' - Copy a command line opening the connection in the database's
+        // own client, the password left as a placeholder
+        KeyCode::Char('y') => {
+            let selected = app.state.ui.selected_connection;
+            let command = app
+                .state
+                .db
+                .connections
+                .connections
+                .get(selected)
+                .and_then(crate::database::dsn::client_command);
+            if let Some(command) = command {
+                match app.state.clipboard.copy(&command) {
+                    Ok(backend) => {
+                        app.state
+                            .toast_manager
+                            .success(super::query_results::copied_message(
+                                "Connection command",
+                                backend,
+                            ))
+                    }
+                    Err(e) => app
+                        .state
+                        .toast_manager
+                        .error(format!("Failed to copy connection command: {e}")),
+                }
+            }
+        }
+        // 'o' - Open the connection in the database's own client, back here
+        // when it exits
+        KeyCode::Char('o') => {
+            let selected = app.state.ui.selected_connection;
+            app.pending_client = app.state.db.connections.connections.get(selected).cloned();
+        }
+        // 'n' - Watch LISTEN/NOTIFY notifications (PostgreSQL)
+        KeyCode::Char('n') => {
+            super::notifications::open(app);
+        }
         // 'x' - Disconnect from current database
         KeyCode::Char('x') => {
             let selected = app.state.ui.selected_connection;
             if let Some(connection) = app.state.db.connections.connections.get(selected).cloned() {
-                let connection_id = connection.id.clone();
-                let connection_name = connection.name.clone();
-
-                // Disconnect from the database
-                let _ = app
-                    .state
-                    .connection_manager
-                    .disconnect(&connection_id)
-                    .await;
-                app.state.disconnect_from_database().await;
-
+                disconnect(app, selected).await;
                 app.state
                     .toast_manager
-                    .info(format!("Disconnected from {}", connection_name));
+                    .info(format!("Disconnected from {}", connection.name));
             }
         }
         // '/' - Enter search mode
@@ -265,10 +196,336 @@ pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
     Ok(())
 }
 
+/// Connect to the connection at the given index in a background task; the
+/// result arrives as a ConnectionEvent
+pub(crate) fn connect_to_connection(app: &mut App, selected_index: usize) {
+    // Don't start new connection if one is already in progress
+    if app.state.connecting_in_progress.is_some() {
+        app.state
+            .toast_manager
+            .warning("Connection attempt already in progress");
+        return;
+    }
+
+    // Mark connection as in progress
+    app.state.connecting_in_progress = Some(selected_index);
+    app.state.spinner.start(operation::CONNECTING);
+    app.metadata_fetch.cancel();
+    app.table_previews.cancel_all();
+    app.completion_warmup.cancel();
+    app.state.connection_start_time = Some(std::time::Instant::now());
+
+    // Set status to connecting immediately (for visual feedback)
+    if let Some(conn) = app.state.db.connections.connections.get_mut(selected_index) {
+        conn.status = crate::database::ConnectionStatus::Connecting;
+        app.state
+            .toast_manager
+            .info(format!("Connecting to {}...", conn.name));
+    }
+
+    // Clone necessary data for background task
+    let connection_config = app.state.db.connections.connections[selected_index].clone();
+    let connection_manager = app.state.connection_manager.clone();
+    let retry = app.state.connect_retry;
+    let include_system = app.state.ui.show_system_objects;
+    let tx = app.connection_events_tx.clone();
+
+    // Spawn connection task in background
+    app.connection_task_handle = Some(tokio::spawn(async move {
+        // Attempt to establish connection, retrying while the server is unreachable
+        let on_retry = |attempt: u32, error: &crate::core::error::LazyTablesError| {
+            let _ = tx.send(ConnectionEvent::Retrying {
+                connection_index: selected_index,
+                attempt,
+                error: error.to_string(),
+            });
+        };
+        match connection_manager
+            .connect_with_retry(&connection_config, retry, on_retry)
+            .await
+        {
+            Ok(_) => {
+                // Connection succeeded, now get database objects
+                match connection_manager
+                    .list_database_objects(&connection_config.id, include_system)
+                    .await
+                {
+                    Ok(objects) => {
+                        let role = read_server_role(&connection_manager, &connection_config).await;
+                        // Send success event
+                        let _ = tx.send(ConnectionEvent::Success {
+                            connection_index: selected_index,
+                            objects,
+                            role,
+                        });
+                    }
+                    Err(e) => {
+                        // Connection succeeded but listing objects failed
+                        let _ = tx.send(ConnectionEvent::Failed {
+                            connection_index: selected_index,
+                            error: format!("Failed to load database objects: {}", e),
+                        });
+                    }
+                }
+            }
+            Err(e) => {
+                // Connection failed
+                let _ = tx.send(ConnectionEvent::Failed {
+                    connection_index: selected_index,
+                    error: e.to_string(),
+                });
+            }
+        }
+    }));
+}
+
+/// Whether the server just connected to takes writes. A server that can't
+/// be asked is taken to, so a failed check never blocks anything
+async fn read_server_role(
+    connection_manager: &crate::database::ConnectionManager,
+    config: &crate::database::ConnectionConfig,
+) -> ServerRole {
+    let Some(query) = ServerRole::query(&config.database_type) else {
+        return ServerRole::Primary;
+    };
+    match connection_manager
+        .execute_metadata_query(&config.id, "server_role", query)
+        .await
+    {
+        Ok((_, rows)) => rows
+            .first()
+            .and_then(|row| row.first())
+            .map(|value| ServerRole::from_value(&config.database_type, value))
+            .unwrap_or_default(),
+        Err(e) => {
+            crate::log_warn!("Couldn't tell whether {} is read-only: {}", config.name, e);
+            ServerRole::Primary
+        }
+    }
+}
+
+/// Close the connection at `index` of the list. Closing the selected one
+/// also clears the panes showing its objects
+async fn disconnect(app: &mut App, index: usize) {
+    let Some(connection_id) = app
+        .state
+        .db
+        .connections
+        .connections
+        .get(index)
+        .map(|connection| connection.id.clone())
+    else {
+        return;
+    };
+    let _ = app
+        .state
+        .connection_manager
+        .disconnect(&connection_id)
+        .await;
+
+    if index != app.state.ui.selected_connection {
+        app.state.db.connections.connections[index].status =
+            crate::database::ConnectionStatus::Disconnected;
+        app.state
+            .session_events
+            .emit(crate::app::session_events::SessionEvent::ConnectionClosed { connection_id });
+        return;
+    }
+    app.metadata_fetch.cancel();
+    app.table_previews.cancel_all();
+    app.completion_warmup.cancel();
+    app.state.query_editor.clear_table_columns();
+    super::notifications::stop_listening(app);
+    app.state.disconnect_from_database().await;
+}
+
+/// Delete the saved connection with this ID, disconnecting it first when
+/// it's open. The selection stays on the same connection where it can
+pub(crate) async fn delete_connection(app: &mut App, connection_id: &str) {
+    let Some(index) = app
+        .state
+        .db
+        .connections
+        .connections
+        .iter()
+        .position(|connection| connection.id == connection_id)
+    else {
+        app.state
+            .toast_manager
+            .warning("The connection was already deleted");
+        return;
+    };
+    let connection = app.state.db.connections.connections[index].clone();
+    if connection.is_connected() {
+        disconnect(app, index).await;
+    }
+
+    if let Err(e) = app
+        .state
+        .db
+        .connections
+        .remove_connection(connection_id)
+        .await
+    {
+        app.state
+            .toast_manager
+            .error(format!("Failed to delete connection: {e}"));
+        return;
+    }
+    let ui = &mut app.state.ui;
+    if index < ui.selected_connection {
+        ui.selected_connection -= 1;
+    }
+    ui.update_connection_selection(app.state.db.connections.connections.len());
+    app.state
+        .toast_manager
+        .success(format!("Deleted connection '{}'", connection.name));
+}
+
+/// Give up on the connection attempt in progress, e.g. on timeout
+pub(crate) fn stop_connecting(app: &mut App) {
+    if let Some(handle) = app.connection_task_handle.take() {
+        handle.abort();
+    }
+    // Drop events the aborted attempt sent before it stopped
+    while app.connection_events_rx.try_recv().is_ok() {}
+
+    app.state.connecting_in_progress = None;
+    app.state.connection_start_time = None;
+    app.state.connect_attempt = None;
+    app.state.pending_jump = None;
+    app.state.spinner.stop(operation::CONNECTING);
+}
+
+/// Cancel the connection attempt in progress at the user's request
+pub(crate) fn cancel_connecting(app: &mut App) {
+    let Some(connecting_index) = app.state.connecting_in_progress else {
+        return;
+    };
+    stop_connecting(app);
+
+    if let Some(conn) = app
+        .state
+        .db
+        .connections
+        .connections
+        .get_mut(connecting_index)
+    {
+        conn.status = crate::database::ConnectionStatus::Disconnected;
+        app.state
+            .toast_manager
+            .warning(format!("Stopped connecting to {}", conn.name));
+    }
+}
+
+/// Fetch the columns of the tables recently opened on a freshly connected
+/// database in the background, so the first column suggestions in the query
+/// editor don't wait on the server. Tables are fetched one after another to
+/// keep a single metadata query in flight, and the warmup stops as soon as
+/// another database is connected or this one disconnected
+pub(crate) fn warm_completion_cache(app: &mut App, connection_index: usize) {
+    app.state.query_editor.clear_table_columns();
+
+    let Some(connection_id) = app
+        .state
+        .db
+        .connections
+        .connections
+        .get(connection_index)
+        .map(|connection| connection.id.clone())
+    else {
+        app.completion_warmup.cancel();
+        return;
+    };
+    let Some(objects) = &app.state.db.database_objects else {
+        app.completion_warmup.cancel();
+        return;
+    };
+
+    // Recent tables that still exist, paired with the name suggestions use
+    let tables: Vec<(String, String)> = app
+        .state
+        .ui
+        .recent_tables_for(&connection_id)
+        .iter()
+        .filter_map(|recent| {
+            objects
+                .tables
+                .iter()
+                .find(|t| t.qualified_name() == *recent || t.name == *recent)
+                .map(|t| (recent.clone(), t.name.clone()))
+        })
+        .collect();
+    if tables.is_empty() {
+        app.completion_warmup.cancel();
+        return;
+    }
+
+    let connection_manager = app.state.connection_manager.clone();
+    let warmup = app.completion_warmup.clone();
+    let tx = app.warmup_events_tx.clone();
+    app.completion_warmup
+        .schedule(Duration::ZERO, move |generation| async move {
+            for (table_name, suggestion_name) in tables {
+                if !warmup.is_current(generation) {
+                    break;
+                }
+                match connection_manager
+                    .get_table_columns(&connection_id, &table_name)
+                    .await
+                {
+                    Ok(columns) => {
+                        let _ = tx.send(ColumnsWarmedEvent {
+                            generation,
+                            table: suggestion_name,
+                            columns: columns.into_iter().map(|c| c.name).collect(),
+                        });
+                    }
+                    Err(e) => {
+                        crate::log_debug!("Failed to warm columns of '{}': {}", table_name, e)
+                    }
+                }
+            }
+        });
+}
+
+/// Save the connection form. When the form was opened by the first-run
+/// wizard, connect to the new connection straight away
+async fn save_connection_from_modal(app: &mut App) {
+    let from_wizard = app.state.first_run_wizard.is_some();
+
+    if let Err(error) = app.state.save_connection_from_modal().await {
+        app.state
+            .toast_manager
+            .error(format!("Failed to save connection: {}", &error));
+        app.state.connection_modal_state.error_message = Some(error);
+        return;
+    }
+
+    app.state
+        .toast_manager
+        .success("Connection saved successfully");
+
+    if from_wizard {
+        if let Some(index) = app.state.db.connections.connections.len().checked_sub(1) {
+            app.state.ui.selected_connection = index;
+            app.state.clamp_connection_selection();
+            connect_to_connection(app, index);
+        }
+    }
+}
+
 /// Handle connection modal key event
 pub(crate) async fn handle_connection_modal(app: &mut App, key: KeyEvent) -> Result<()> {
     use crate::ui::components::{ConnectionField, PasswordStorageType};
 
+    if app.state.connection_modal_state.is_text_field() {
+        if let Some(edit) = super::readline_edit(app, &key) {
+            app.state.connection_modal_state.handle_readline(edit);
+            return Ok(());
+        }
+    }
+
     match key.code {
         // PRIORITY 0: Abort test connection (Ctrl+C - highest priority)
         KeyCode::Char('c')
@@ -289,16 +546,7 @@ pub(crate) async fn handle_connection_modal(app: &mut App, key: KeyEvent) -> Res
         }
         KeyCode::Char('s') if !app.state.connection_modal_state.is_text_field() => {
             // Save shortcut - works from any field except text input fields
-            if let Err(error) = app.state.save_connection_from_modal().await {
-                app.state
-                    .toast_manager
-                    .error(format!("Failed to save connection: {}", &error));
-                app.state.connection_modal_state.error_message = Some(error);
-            } else {
-                app.state
-                    .toast_manager
-                    .success("Connection saved successfully");
-            }
+            save_connection_from_modal(app).await;
         }
         KeyCode::Char('c') if !app.state.connection_modal_state.is_text_field() => {
             // Cancel shortcut - works from any field except text input fields
@@ -319,9 +567,18 @@ pub(crate) async fn handle_connection_modal(app: &mut App, key: KeyEvent) -> Res
             }
         }
         KeyCode::Tab => {
-            // Tab for next field navigation
-            app.state.connection_modal_state.focused_field =
-                app.state.connection_modal_state.get_smart_next_field();
+            // Tab completes a SQLite file path, then moves to the next field
+            match app.state.connection_modal_state.complete_path() {
+                Some(completion) => {
+                    if let Some(hint) = completion.hint() {
+                        app.state.toast_manager.info(hint);
+                    }
+                }
+                None => {
+                    app.state.connection_modal_state.focused_field =
+                        app.state.connection_modal_state.get_smart_next_field();
+                }
+            }
         }
         KeyCode::BackTab => {
             // Shift+Tab for previous field navigation
@@ -441,16 +698,7 @@ pub(crate) async fn handle_connection_modal(app: &mut App, key: KeyEvent) -> Res
                 }
                 ConnectionField::Save => {
                     // Activate Save button
-                    if let Err(error) = app.state.save_connection_from_modal().await {
-                        app.state
-                            .toast_manager
-                            .error(format!("Failed to save connection: {}", &error));
-                        app.state.connection_modal_state.error_message = Some(error);
-                    } else {
-                        app.state
-                            .toast_manager
-                            .success("Connection saved successfully");
-                    }
+                    save_connection_from_modal(app).await;
                 }
                 ConnectionField::Cancel => {
                     // Activate Cancel button
@@ -519,7 +767,7 @@ async fn test_connection_from_modal(app: &mut App) {
     // Set status to testing and start timer
     app.state.connection_modal_state.test_status = Some(TestConnectionStatus::Testing);
     app.state.test_connection_in_progress = true;
-    app.state.test_animation_frame = 0;
+    app.state.spinner.start(operation::TESTING_CONNECTION);
     app.state.test_start_time = Some(std::time::Instant::now());
 
     // Try to create a connection config (no uniqueness check needed for testing)
@@ -540,6 +788,34 @@ async fn test_connection_from_modal(app: &mut App) {
         }
     };
 
+    spawn_test_connection(app, config);
+}
+
+/// Test the saved connection selected in the Connections pane, without
+/// connecting to it
+fn test_selected_connection(app: &mut App) {
+    if app.state.test_connection_in_progress {
+        app.state.toast_manager.warning("Test already in progress");
+        return;
+    }
+    let selected = app.state.ui.selected_connection;
+    let Some(config) = app.state.db.connections.connections.get(selected).cloned() else {
+        return;
+    };
+
+    app.state
+        .toast_manager
+        .info(format!("Testing connection to {}...", config.name));
+    app.state.test_connection_in_progress = true;
+    app.state.spinner.start(operation::TESTING_CONNECTION);
+    app.state.test_start_time = Some(std::time::Instant::now());
+    spawn_test_connection(app, config);
+}
+
+/// Log in with `config` and run a test query in the background, closing the
+/// connection again either way; the result comes back as a
+/// `TestConnectionEvent`
+fn spawn_test_connection(app: &mut App, config: crate::database::ConnectionConfig) {
     // Clone sender for background task
     let tx = app.test_connection_events_tx.clone();
 
@@ -556,9 +832,13 @@ async fn test_connection_from_modal(app: &mut App) {
                 match conn.connect().await {
                     Ok(()) => {
                         // Connection succeeded, now test it
-                        conn.test_connection()
+                        let tested = conn
+                            .test_connection()
                             .await
-                            .map(|_| "Connection successful!".to_string())
+                            .map(|_| "Connection successful!".to_string());
+                        // Close the pool so the test leaves no connection open
+                        let _ = conn.disconnect().await;
+                        tested
                     }
                     Err(e) => {
                         // Parse error into structured ConnectionError
@@ -579,9 +859,13 @@ async fn test_connection_from_modal(app: &mut App) {
                 match conn.connect().await {
                     Ok(()) => {
                         // Connection succeeded, now test it
-                        conn.test_connection()
+                        let tested = conn
+                            .test_connection()
                             .await
-                            .map(|_| "Connection successful!".to_string())
+                            .map(|_| "Connection successful!".to_string());
+                        // Close the pool so the test leaves no connection open
+                        let _ = conn.disconnect().await;
+                        tested
                     }
                     Err(e) => {
                         // Parse error into structured ConnectionError
@@ -602,9 +886,13 @@ async fn test_connection_from_modal(app: &mut App) {
                 match conn.connect().await {
                     Ok(()) => {
                         // Connection succeeded, now test it
-                        conn.test_connection()
+                        let tested = conn
+                            .test_connection()
                             .await
-                            .map(|_| "Connection successful!".to_string())
+                            .map(|_| "Connection successful!".to_string());
+                        // Close the pool so the test leaves no connection open
+                        let _ = conn.disconnect().await;
+                        tested
                     }
                     Err(e) => {
                         // Parse error into structured ConnectionError
@@ -647,7 +935,7 @@ async fn test_connection_from_modal(app: &mut App) {
 }
 
 /// Abort ongoing test connection
-fn abort_test_connection(app: &mut App) {
+pub(crate) fn abort_test_connection(app: &mut App) {
     use crate::ui::components::TestConnectionStatus;
 
     // Only abort if test is actually in progress
@@ -668,8 +956,56 @@ fn abort_test_connection(app: &mut App) {
     // Clear all test-related state
     app.state.test_connection_in_progress = false;
     app.state.test_start_time = None;
-    app.state.test_animation_frame = 0;
+    app.state.spinner.stop(operation::TESTING_CONNECTION);
 
     // Notify user
     app.state.toast_manager.warning("Connection test aborted");
 }
+
+/// Ping every saved connection's server in the background, a few at a time,
+/// marking each in the Connections pane as its answer comes in
+pub(crate) fn ping_saved_connections(app: &mut App) {
+    use crate::database::reachability::{self, Reachability, PING_CONCURRENCY, PING_TIMEOUT};
+    use futures::StreamExt;
+
+    let connections = app.state.db.connections.connections.clone();
+    if connections.is_empty() {
+        return;
+    }
+    for connection in &connections {
+        app.state
+            .reachability
+            .insert(connection.id.clone(), Reachability::Pinging);
+    }
+    app.state.spinner.start(operation::PINGING_CONNECTIONS);
+
+    let tx = app.ping_events_tx.clone();
+    app.ping_task_handle = Some(tokio::spawn(async move {
+        futures::stream::iter(connections)
+            .for_each_concurrent(PING_CONCURRENCY, |connection| {
+                let tx = tx.clone();
+                async move {
+                    let reachability = reachability::ping(&connection, PING_TIMEOUT).await;
+                    let _ = tx.send(PingEvent::Pinged {
+                        connection_id: connection.id,
+                        reachability,
+                    });
+                }
+            })
+            .await;
+        let _ = tx.send(PingEvent::Done);
+    }));
+}
+
+/// Stop the startup ping, leaving the connections not answered yet unmarked
+pub(crate) fn stop_pinging(app: &mut App) {
+    use crate::database::reachability::Reachability;
+
+    if let Some(handle) = app.ping_task_handle.take() {
+        handle.abort();
+    }
+    app.state
+        .reachability
+        .retain(|_, reachability| *reachability != Reachability::Pinging);
+    app.state.spinner.stop(operation::PINGING_CONNECTIONS);
+}
@@ -8,11 +8,16 @@ use crate::{
     app::{App, FocusedPane},
     commands::CommandId,
     core::error::Result,
+    state::view::{AppView, OverlayView},
 };
 use crossterm::event::{KeyCode, KeyEvent, KeyModifiers};
 
 /// Handle global keys that work everywhere
 pub(crate) fn handle(app: &mut App, key: KeyEvent) -> Result<Option<()>> {
+    if !is_global_key(&key, is_typing(app)) {
+        return Ok(None);
+    }
+
     match (key.modifiers, key.code) {
         // Help - toggle with '?'
         (KeyModifiers::NONE, KeyCode::Char('?')) => {
@@ -24,32 +29,56 @@ pub(crate) fn handle(app: &mut App, key: KeyEvent) -> Result<Option<()>> {
             app.state.ui.toggle_debug_view();
             Ok(Some(()))
         }
+        // Background tasks - toggle with Ctrl+T
+        (KeyModifiers::CONTROL, KeyCode::Char('t'))
+            if app.state.ui.is_in_main() || app.state.ui.current_view.is_tasks() =>
+        {
+            super::tasks::toggle(app);
+            Ok(Some(()))
+        }
+        // Open the folder of the last export written to a file
+        (KeyModifiers::ALT, KeyCode::Char('o'))
+            if app.state.ui.is_in_main() && app.export_dir.is_some() =>
+        {
+            if let Some(dir) = &app.export_dir {
+                if let Err(e) = crate::io::opener::open_folder(dir) {
+                    app.state.toast_manager.error(e);
+                }
+            }
+            Ok(Some(()))
+        }
+        // Cancel the running query on the server
+        _ if app.state.keymap.is_cancel_query(&key) && app.state.fetch_progress.is_some() => {
+            super::query_editor::cancel_query(app);
+            Ok(Some(()))
+        }
+        // Stop fetching the running query, keeping the rows loaded so far
+        (KeyModifiers::CONTROL, KeyCode::Char('c')) if app.state.fetch_progress.is_some() => {
+            if let Some(progress) = &app.state.fetch_progress {
+                progress.request_stop();
+            }
+            app.state
+                .toast_manager
+                .info("Stopping fetch, keeping rows loaded so far");
+            Ok(Some(()))
+        }
         // Quit application - 'q' (only if not in edit modes)
         (KeyModifiers::NONE, KeyCode::Char('q')) if can_quit(app) => {
+            let mut message =
+                "Are you sure you want to exit?\n\nAll active database connections will be closed."
+                    .to_string();
+            if app.state.query_editor.is_modified() {
+                message.push_str("\nUnsaved changes in the query editor will be lost.");
+            }
             app.state.ui.confirmation_modal = Some(crate::ui::ConfirmationModal {
                 title: "Exit LazyTables".to_string(),
-                message: "Are you sure you want to exit?\n\nAll active database connections will be closed.".to_string(),
+                message,
                 action: crate::ui::ConfirmationAction::ExitApplication,
             });
             Ok(Some(()))
         }
         // Number keys 1-6 for direct pane navigation (only in main view)
-        // BUT NOT when editing a table cell - numbers should go into the edit buffer
         (KeyModifiers::NONE, KeyCode::Char(c @ '1'..='6')) if app.state.ui.is_in_main() => {
-            // Check if we're in table viewer edit mode
-            let in_table_edit_mode = app.state.ui.focused_pane == FocusedPane::TabularOutput
-                && app
-                    .state
-                    .table_viewer_state
-                    .current_tab()
-                    .map(|tab| tab.in_edit_mode)
-                    .unwrap_or(false);
-
-            // Don't intercept number keys if in edit mode - let them pass through
-            if in_table_edit_mode {
-                return Ok(None); // Not handled, will be passed to table viewer edit handler
-            }
-
             if let Some(pane) = FocusedPane::from_number(c.to_digit(10).unwrap() as u8) {
                 // Check if the target pane is enabled before navigating to it
                 let is_enabled = match pane {
@@ -69,22 +98,15 @@ pub(crate) fn handle(app: &mut App, key: KeyEvent) -> Result<Option<()>> {
             }
             Ok(Some(()))
         }
-        // Tab/Shift+Tab for pane cycling
-        // Skip Tab in query editor insert mode (Tab inserts tab character there)
-        (KeyModifiers::NONE, KeyCode::Tab)
-            if app.state.ui.is_in_main()
-                && !(app.state.ui.focused_pane == FocusedPane::QueryWindow
-                    && app.state.query_editor.is_insert_mode()) =>
-        {
+        // Tab/Shift+Tab (or the keys bound in [keybindings.global]) for
+        // pane cycling. Skip them in query editor insert mode (Tab inserts
+        // tab character there)
+        _ if app.state.keymap.is_next_pane(&key) && can_cycle_focus(app) => {
             app.state.cycle_focus_forward();
             app.state.ui.cancel_pending_gg();
             Ok(Some(()))
         }
-        (KeyModifiers::SHIFT, KeyCode::BackTab)
-            if app.state.ui.is_in_main()
-                && !(app.state.ui.focused_pane == FocusedPane::QueryWindow
-                    && app.state.query_editor.is_insert_mode()) =>
-        {
+        _ if app.state.keymap.is_previous_pane(&key) && can_cycle_focus(app) => {
             app.state.cycle_focus_backward();
             app.state.ui.cancel_pending_gg();
             Ok(Some(()))
@@ -110,33 +132,84 @@ pub(crate) fn handle(app: &mut App, key: KeyEvent) -> Result<Option<()>> {
     }
 }
 
+/// Whether the global bindings see `key`. While text is typed, a key that
+/// types a character is text: only keys with Ctrl or Alt stay global
+fn is_global_key(key: &KeyEvent, typing: bool) -> bool {
+    let types_char = matches!(key.code, KeyCode::Char(_))
+        && key.modifiers.difference(KeyModifiers::SHIFT).is_empty();
+    !(typing && types_char)
+}
+
+/// Whether the focused input takes typed text: a search, prompt or file
+/// name being typed, a cell being edited, the query editor in insert or
+/// command mode, a connection form text field or an overlay's filter
+pub(crate) fn is_typing(app: &App) -> bool {
+    let state = &app.state;
+    match &state.ui.current_view {
+        AppView::Main => {
+            let editing_cell = state.ui.focused_pane == FocusedPane::TabularOutput
+                && state.table_viewer_state.current_tab().is_some_and(|tab| {
+                    tab.in_edit_mode || tab.in_search_mode || tab.column_jump.is_some()
+                });
+            let editing_query = state.ui.focused_pane == FocusedPane::QueryWindow
+                && (state.query_editor.is_insert_mode() || state.query_editor.is_in_command_mode());
+            state.ui.connections_search_active
+                || state.ui.tables_search_active
+                || state.ui.column_search_active
+                || state.ui.database_prompt.is_some()
+                || state.ui.sql_files_search_active
+                || state.ui.sql_files_rename_mode
+                || state.ui.sql_files_create_mode
+                || editing_cell
+                || editing_query
+        }
+        AppView::Overlay(OverlayView::ConnectionForm(_)) => {
+            state.connection_modal_state.is_text_field()
+        }
+        AppView::Overlay(OverlayView::Help) => state.ui.help_filter_active,
+        AppView::Overlay(OverlayView::Notifications) => state.notifications.editing_channels,
+        // Typing filters the history
+        AppView::Overlay(OverlayView::QueryHistory) => true,
+        AppView::Overlay(_) => false,
+    }
+}
+
+/// Whether a focus key moves focus: not in query editor insert mode, where
+/// Tab inserts a tab
+fn can_cycle_focus(app: &App) -> bool {
+    app.state.ui.is_in_main()
+        && !(app.state.ui.focused_pane == FocusedPane::QueryWindow
+            && app.state.query_editor.is_insert_mode())
+}
+
 /// Check if quit action is allowed (not in edit/insert modes)
 pub(crate) fn can_quit(app: &App) -> bool {
-    if !app.state.ui.is_in_main() {
-        return false;
-    }
-    // Check for active edit/search modes
-    if app.state.ui.connections_search_active
-        || app.state.ui.tables_search_active
-        || app.state.ui.sql_files_search_active
-        || app.state.ui.sql_files_rename_mode
-        || app.state.ui.sql_files_create_mode
-    {
-        return false;
-    }
-    // Check table viewer edit mode
-    if app.state.ui.focused_pane == FocusedPane::TabularOutput {
-        if let Some(tab) = app.state.table_viewer_state.current_tab() {
-            if tab.in_edit_mode || tab.in_search_mode {
-                return false;
-            }
+    app.state.ui.is_in_main() && !is_typing(app)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_typed_characters_are_not_global_keys() {
+        let typed = |c| KeyEvent::new(KeyCode::Char(c), KeyModifiers::NONE);
+        let ctrl = |c| KeyEvent::new(KeyCode::Char(c), KeyModifiers::CONTROL);
+
+        // 'd', digits, 'q' and '?' typed into the query editor are text
+        for c in ['d', '1', '6', 'q', '?'] {
+            assert!(!is_global_key(&typed(c), true));
+            assert!(is_global_key(&typed(c), false));
         }
+        let shifted = KeyEvent::new(KeyCode::Char('Q'), KeyModifiers::SHIFT);
+        assert!(!is_global_key(&shifted, true));
+
+        // Keys with Ctrl or Alt, and keys that type nothing, stay global
+        assert!(is_global_key(&ctrl('b'), true));
+        assert!(is_global_key(&ctrl('c'), true));
+        let alt = KeyEvent::new(KeyCode::Char('n'), KeyModifiers::ALT);
+        assert!(is_global_key(&alt, true));
+        let tab = KeyEvent::new(KeyCode::Tab, KeyModifiers::NONE);
+        assert!(is_global_key(&tab, true));
     }
-    // Check query editor insert mode
-    if app.state.ui.focused_pane == FocusedPane::QueryWindow
-        && app.state.query_editor.is_insert_mode()
-    {
-        return false;
-    }
-    true
 }
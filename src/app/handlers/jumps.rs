@@ -0,0 +1,66 @@
+// FilePath: src/app/handlers/jumps.rs
+//
+// Going back and forward through the tables opened (Ctrl+O / Ctrl+I)
+
+#![forbid(unsafe_code)]
+
+use crate::{app::App, core::error::Result};
+use crossterm::event::{KeyCode, KeyEvent, KeyModifiers};
+
+/// Whether the key goes back (false) or forward (true) through the tables
+/// opened. Most terminals send Ctrl+I as Tab, so Alt+I goes forward too
+pub(crate) fn direction(key: &KeyEvent) -> Option<bool> {
+    match (key.modifiers, key.code) {
+        (KeyModifiers::CONTROL, KeyCode::Char('o')) => Some(false),
+        (KeyModifiers::CONTROL | KeyModifiers::ALT, KeyCode::Char('i')) => Some(true),
+        _ => None,
+    }
+}
+
+/// Select the previous (or next) table opened again, connecting to its
+/// connection first if needed
+pub(crate) async fn jump(app: &mut App, forward: bool) -> Result<()> {
+    if app.state.connecting_in_progress.is_some() {
+        app.state
+            .toast_manager
+            .warning("Connection attempt already in progress");
+        return Ok(());
+    }
+    let jump = if forward {
+        app.state.ui.jumps.forward()
+    } else {
+        app.state.ui.jumps.back()
+    };
+    let Some(jump) = jump.cloned() else {
+        app.state.toast_manager.info(if forward {
+            "No newer table to go forward to"
+        } else {
+            "No older table to go back to"
+        });
+        return Ok(());
+    };
+
+    let connections = &app.state.db.connections.connections;
+    let Some(index) = connections
+        .iter()
+        .position(|connection| connection.id == jump.connection_id)
+    else {
+        app.state.toast_manager.error(format!(
+            "The connection {} was opened on is gone",
+            jump.table_name
+        ));
+        return Ok(());
+    };
+    // The tables list is of the selected connection
+    let listed = index == app.state.ui.selected_connection && connections[index].is_connected();
+
+    if listed {
+        app.state.restore_jump(&jump).await;
+    } else {
+        app.state.ui.selected_connection = index;
+        app.state.ui.connections_list_state.select(Some(index));
+        app.state.pending_jump = Some(jump);
+        super::connections::connect_to_connection(app, index);
+    }
+    Ok(())
+}
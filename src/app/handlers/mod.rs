@@ -8,8 +8,29 @@
 pub mod connections;
 pub mod details;
 pub mod global;
+pub mod jumps;
+pub mod notifications;
+pub mod object_tree;
 pub mod overlays;
+pub mod pins;
 pub mod query_editor;
+pub mod query_history;
 pub mod query_results;
+pub mod save_cell;
 pub mod sql_files;
 pub mod tables;
+pub mod tasks;
+pub mod undo_log;
+
+use crate::{app::App, ui::components::readline::Edit};
+use crossterm::event::KeyEvent;
+
+/// The readline edit a key stands for in a text input, unless
+/// `readline_keys` is off
+pub(crate) fn readline_edit(app: &App, key: &KeyEvent) -> Option<Edit> {
+    if app.state.ui.readline_keys {
+        Edit::from_key(key)
+    } else {
+        None
+    }
+}
@@ -0,0 +1,147 @@
+// FilePath: src/app/handlers/notifications.rs
+//
+// Event handlers for the Postgres LISTEN/NOTIFY watcher
+
+#![forbid(unsafe_code)]
+
+use crate::{
+    app::{App, NotificationEvent, OverlayView},
+    core::error::Result,
+    database::{postgres::PostgresConnection, Connection, ConnectionConfig, DatabaseType},
+    ui::components::Notification,
+};
+use crossterm::event::{KeyCode, KeyEvent};
+use tokio::sync::mpsc::UnboundedSender;
+
+/// Open the watcher for the selected connection, starting with the channel
+/// dialog unless a listener is already running
+pub(crate) fn open(app: &mut App) {
+    let Some(connection) = app.state.get_selected_connection() else {
+        app.state.toast_manager.warning("No connection selected");
+        return;
+    };
+    if connection.database_type != DatabaseType::PostgreSQL {
+        app.state
+            .toast_manager
+            .warning("LISTEN/NOTIFY is only available for PostgreSQL");
+        return;
+    }
+    if !connection.is_connected() {
+        app.state
+            .toast_manager
+            .warning("Connect to the database before listening");
+        return;
+    }
+
+    if !app.state.notifications.is_listening() {
+        app.state.notifications.open_channel_dialog();
+    }
+    app.state.ui.show_overlay(OverlayView::Notifications);
+}
+
+/// Handle watcher keys
+pub(crate) fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
+    if app.state.notifications.editing_channels {
+        if let Some(edit) = super::readline_edit(app, &key) {
+            for _ in 0..edit.erased(&app.state.notifications.channel_input) {
+                app.state.notifications.channel_input.pop();
+            }
+            return Ok(());
+        }
+        match key.code {
+            KeyCode::Enter => start_listening(app),
+            KeyCode::Backspace => {
+                app.state.notifications.channel_input.pop();
+            }
+            KeyCode::Char(c) => app.state.notifications.channel_input.push(c),
+            _ => {}
+        }
+        return Ok(());
+    }
+
+    match key.code {
+        KeyCode::Char('c') => app.state.notifications.open_channel_dialog(),
+        KeyCode::Char('s') => {
+            if app.state.notifications.is_listening() {
+                stop_listening(app);
+                app.state.toast_manager.info("Stopped listening");
+            }
+        }
+        KeyCode::Char('x') => {
+            app.state.notifications.entries.clear();
+            app.state.notifications.scroll_offset = 0;
+        }
+        KeyCode::Char('k') | KeyCode::Up => app.state.notifications.scroll_up(1),
+        KeyCode::Char('j') | KeyCode::Down => app.state.notifications.scroll_down(1),
+        KeyCode::PageUp => app.state.notifications.scroll_up(10),
+        KeyCode::PageDown => app.state.notifications.scroll_down(10),
+        KeyCode::Char('G') => app.state.notifications.scroll_offset = 0,
+        _ => {}
+    }
+    Ok(())
+}
+
+/// Replace any running listener with one on the channels from the dialog
+fn start_listening(app: &mut App) {
+    let channels = app.state.notifications.parsed_channels();
+    if channels.is_empty() {
+        app.state
+            .toast_manager
+            .warning("Enter at least one channel name");
+        return;
+    }
+    let Some(config) = app.state.get_selected_connection().cloned() else {
+        return;
+    };
+
+    stop_listening(app);
+
+    let tx = app.notification_events_tx.clone();
+    let listen_channels = channels.clone();
+    app.notification_task_handle = Some(tokio::spawn(async move {
+        if let Err(e) = listen(config, listen_channels, &tx).await {
+            let _ = tx.send(NotificationEvent::Failed(e.to_string()));
+        }
+    }));
+
+    let connection_name = app
+        .state
+        .get_selected_connection()
+        .map(|connection| connection.name.clone())
+        .unwrap_or_default();
+    crate::log_info!(
+        "Listening on {} for connection '{}'",
+        channels.join(", "),
+        connection_name
+    );
+    app.state.notifications.start(connection_name, channels);
+}
+
+/// Forward notifications on the channels until the connection fails
+async fn listen(
+    config: ConnectionConfig,
+    channels: Vec<String>,
+    tx: &UnboundedSender<NotificationEvent>,
+) -> Result<()> {
+    // The listener needs a connection of its own, so it doesn't share the
+    // pool the panels query through
+    let mut conn = PostgresConnection::new(config);
+    conn.connect().await?;
+    let mut listener = conn.listen(&channels).await?;
+    loop {
+        let notification = listener.recv().await?;
+        let _ = tx.send(NotificationEvent::Received(Notification {
+            received_at: chrono::Local::now(),
+            channel: notification.channel().to_string(),
+            payload: notification.payload().to_string(),
+        }));
+    }
+}
+
+/// Stop the running listener, if any
+pub(crate) fn stop_listening(app: &mut App) {
+    if let Some(handle) = app.notification_task_handle.take() {
+        handle.abort();
+    }
+    app.state.notifications.stop(None);
+}
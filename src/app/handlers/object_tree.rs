@@ -0,0 +1,155 @@
+// FilePath: src/app/handlers/object_tree.rs
+//
+// Event handler for the object tree, shown in place of the Connections and
+// Tables panes with `[ui] sidebar_mode = "tree"`
+
+#![forbid(unsafe_code)]
+
+use crate::{
+    app::App,
+    core::error::Result,
+    database::ConnectionStatus,
+    ui::components::{ObjectTree, TreeNode, TreeRow},
+};
+use crossterm::event::{KeyCode, KeyEvent};
+
+/// Handle object tree keys
+pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
+    let rows = app.state.object_tree_rows();
+    let selected = app
+        .state
+        .object_tree
+        .selected
+        .min(rows.len().saturating_sub(1));
+    let Some(row) = rows.get(selected).cloned() else {
+        // No connections yet: keys like `a` still add one
+        return super::connections::handle(app, key).await;
+    };
+
+    match key.code {
+        // j/k or arrow keys - Move through the tree
+        KeyCode::Char('j') | KeyCode::Down => select(app, &rows, selected + 1),
+        KeyCode::Char('k') | KeyCode::Up => select(app, &rows, selected.saturating_sub(1)),
+        // g/G - First and last row
+        KeyCode::Char('g') => select(app, &rows, 0),
+        KeyCode::Char('G') => select(app, &rows, rows.len().saturating_sub(1)),
+        // h or Left - Collapse the node, or go to the one holding it
+        KeyCode::Char('h') | KeyCode::Left => {
+            if row.expanded == Some(true) {
+                app.state.object_tree.toggle(&row);
+            } else if let Some(parent) = ObjectTree::parent(&rows, selected) {
+                select(app, &rows, parent);
+            }
+        }
+        // l or Right - Expand the node
+        KeyCode::Char('l') | KeyCode::Right => {
+            if row.expanded == Some(false) {
+                activate(app, &row).await;
+            }
+        }
+        // Enter or Space - Connect, expand or collapse, or open the table
+        KeyCode::Enter | KeyCode::Char(' ') => {
+            select(app, &rows, selected);
+            activate(app, &row).await;
+        }
+        // Prompts of the Tables pane show in that pane, hidden here
+        KeyCode::Char('/' | 'c' | 'u') => {}
+        _ => match row.node {
+            TreeNode::Connection { .. } => super::connections::handle(app, key).await?,
+            _ => super::tables::handle(app, key).await?,
+        },
+    }
+    Ok(())
+}
+
+/// Select a row, and with it its connection or table in the panes' state
+fn select(app: &mut App, rows: &[TreeRow], index: usize) {
+    app.state.object_tree.select(index, rows.len());
+    let Some(row) = rows.get(app.state.object_tree.selected) else {
+        return;
+    };
+    match &row.node {
+        TreeNode::Connection { index, .. } => {
+            if app.state.ui.selected_connection != *index {
+                app.state.ui.select_connection(*index);
+            }
+        }
+        TreeNode::Object {
+            name,
+            schema,
+            group,
+            ..
+        } => {
+            // The Tables pane only selects objects of an expanded group
+            if !app.state.ui.is_object_group_expanded(group.label()) {
+                app.state.ui.toggle_object_group_expansion(group.label());
+                app.state
+                    .ui
+                    .build_selectable_table_items(&app.state.db.database_objects);
+            }
+            let previous_table = app.state.ui.get_selected_table_name();
+            app.state.ui.select_table_object(name, schema.as_deref());
+            if app.state.ui.get_selected_table_name() != previous_table {
+                super::tables::selection_changed(app);
+            }
+        }
+        _ => {}
+    }
+}
+
+/// Act on the selected row: connect a connection or list its objects,
+/// expand or collapse a node, open a table
+async fn activate(app: &mut App, row: &TreeRow) {
+    match &row.node {
+        TreeNode::Connection { index, id } => {
+            let status = app
+                .state
+                .db
+                .connections
+                .connections
+                .get(*index)
+                .map(|connection| connection.status.clone());
+            match status {
+                Some(ConnectionStatus::Connected) if app.state.object_tree.lists(id) => {
+                    app.state.object_tree.toggle(row);
+                }
+                // Connected, but another connection's objects are listed
+                Some(ConnectionStatus::Connected) => {
+                    app.state.ui.select_connection(*index);
+                    app.table_previews.cancel_all();
+                    app.state.connect_to_selected_database().await;
+                }
+                Some(ConnectionStatus::Connecting) | None => {}
+                Some(_) => {
+                    app.state.ui.select_connection(*index);
+                    super::connections::connect_to_connection(app, *index);
+                }
+            }
+        }
+        TreeNode::Schema { .. } | TreeNode::Group { .. } => {
+            app.state.object_tree.toggle(row);
+            load_routines(app).await;
+        }
+        TreeNode::Object { .. } => {
+            super::tables::open_selected_table(app).await;
+        }
+        TreeNode::Routine(_) | TreeNode::Note(_) => {}
+    }
+}
+
+/// Read the functions of a schema whose Functions group was just expanded
+async fn load_routines(app: &mut App) {
+    let rows = app.state.object_tree_rows();
+    let Some((connection_id, schema)) = app.state.object_tree.routines_to_load(&rows) else {
+        return;
+    };
+    let routines = app
+        .state
+        .connection_manager
+        .list_routines(&connection_id, &schema)
+        .await
+        .map_err(|e| e.to_string());
+    app.state
+        .object_tree
+        .set_routines(connection_id, schema, routines);
+}
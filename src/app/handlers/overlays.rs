@@ -5,10 +5,19 @@
 #![forbid(unsafe_code)]
 
 use crate::{
-    app::{App, AppView, HelpMode, OverlayView},
+    app::{App, AppView, ExportEvent, HelpMode, OverlayView},
     core::error::Result,
+    database::{result::PROGRESS_EVERY_ROWS, RowSink},
+    io::{clipboard::LARGE_COPY_BYTES, export::ResultWriter, opener},
+    ui::components::{
+        export_progress, operation,
+        path_input::{self, PathKind},
+        ExportDestination, ExportField, ExportRows,
+    },
 };
-use crossterm::event::{KeyCode, KeyEvent};
+use crossterm::event::{KeyCode, KeyEvent, KeyModifiers};
+use std::io::{BufWriter, Write};
+use std::path::PathBuf;
 
 /// Handle overlay keys (connection form, table creator/editor, debug view)
 pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
@@ -20,6 +29,10 @@ pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
             AppView::Overlay(OverlayView::Help)
         )
     {
+        // Esc anywhere in the first-run wizard skips it
+        app.state.first_run_wizard = None;
+        // Closing the notifications watcher leaves the listener running
+        app.state.notifications.editing_channels = false;
         app.state.ui.return_to_main();
         return Ok(());
     }
@@ -31,10 +44,32 @@ pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
         }
         AppView::Overlay(OverlayView::DebugView) => handle_debug_view(app, key),
         AppView::Overlay(OverlayView::Help) => handle_help(app, key),
+        AppView::Overlay(OverlayView::Welcome) => handle_welcome(app, key),
+        AppView::Overlay(OverlayView::Notifications) => super::notifications::handle(app, key),
+        AppView::Overlay(OverlayView::Tasks) => super::tasks::handle(app, key),
+        AppView::Overlay(OverlayView::Pins) => super::pins::handle(app, key),
+        AppView::Overlay(OverlayView::UndoLog) => super::undo_log::handle(app, key),
+        AppView::Overlay(OverlayView::QueryHistory) => super::query_history::handle(app, key),
         _ => Ok(()),
     }
 }
 
+/// Handle the first-run wizard's driver selection keys
+pub(crate) fn handle_welcome(app: &mut App, key: KeyEvent) -> Result<()> {
+    let Some(wizard) = app.state.first_run_wizard.as_mut() else {
+        app.state.ui.return_to_main();
+        return Ok(());
+    };
+
+    match key.code {
+        KeyCode::Char('j') | KeyCode::Down | KeyCode::Tab => wizard.next_driver(),
+        KeyCode::Char('k') | KeyCode::Up | KeyCode::BackTab => wizard.previous_driver(),
+        KeyCode::Enter => app.state.continue_first_run_wizard(),
+        _ => {}
+    }
+    Ok(())
+}
+
 /// Handle debug view keys
 pub(crate) fn handle_debug_view(app: &mut App, key: KeyEvent) -> Result<()> {
     let debug_messages = crate::logging::get_debug_messages();
@@ -75,17 +110,28 @@ pub(crate) fn handle_debug_view(app: &mut App, key: KeyEvent) -> Result<()> {
 
 /// Handle help overlay keys
 pub(crate) fn handle_help(app: &mut App, key: KeyEvent) -> Result<()> {
+    if app.state.ui.help_filter_active {
+        return handle_help_filter(app, key);
+    }
+    let searching = !app.state.ui.help_filter.is_empty();
     match key.code {
         // Close help modal with '?' key only (ESC is disabled for help modal)
         KeyCode::Char('?') => {
             app.state.ui.help_mode = HelpMode::None;
             app.state.ui.return_to_main();
         }
+        // Search the commands of every scope; the matches scroll like the
+        // left pane
+        KeyCode::Char('/') => {
+            app.state.ui.help_filter_active = true;
+            app.state.ui.help_pane_focus = crate::state::ui::HelpPaneFocus::Left;
+        }
+        KeyCode::Esc if searching => clear_help_filter(app),
         // Switch between left and right help panes
-        KeyCode::Left | KeyCode::Right | KeyCode::Char('h') | KeyCode::Char('l') => {
+        KeyCode::Left | KeyCode::Right | KeyCode::Char('h') | KeyCode::Char('l') if !searching => {
             app.state.ui.toggle_help_pane_focus();
         }
-        KeyCode::Tab => {
+        KeyCode::Tab if !searching => {
             app.state.ui.toggle_help_pane_focus();
         }
         // Scroll up in focused help pane
@@ -108,6 +154,40 @@ pub(crate) fn handle_help(app: &mut App, key: KeyEvent) -> Result<()> {
     Ok(())
 }
 
+/// Handle keys typed into the help filter
+fn handle_help_filter(app: &mut App, key: KeyEvent) -> Result<()> {
+    if let Some(edit) = super::readline_edit(app, &key) {
+        for _ in 0..edit.erased(&app.state.ui.help_filter) {
+            app.state.ui.help_filter.pop();
+        }
+        app.state.ui.help_left_scroll_offset = 0;
+        return Ok(());
+    }
+    match key.code {
+        KeyCode::Esc => clear_help_filter(app),
+        KeyCode::Enter => app.state.ui.help_filter_active = false,
+        KeyCode::Up => app.state.ui.help_scroll_up(),
+        KeyCode::Down => app.state.ui.help_scroll_down(100),
+        KeyCode::Backspace => {
+            app.state.ui.help_filter.pop();
+            app.state.ui.help_left_scroll_offset = 0;
+        }
+        KeyCode::Char(c) => {
+            app.state.ui.help_filter.push(c);
+            app.state.ui.help_left_scroll_offset = 0;
+        }
+        _ => {}
+    }
+    Ok(())
+}
+
+/// Back to the two help columns
+fn clear_help_filter(app: &mut App) {
+    app.state.ui.help_filter.clear();
+    app.state.ui.help_filter_active = false;
+    app.state.ui.help_left_scroll_offset = 0;
+}
+
 /// Handle confirmation modal keys
 pub(crate) async fn handle_confirmation_modal(app: &mut App, key: KeyEvent) -> Result<()> {
     if let Some(modal) = &app.state.ui.confirmation_modal {
@@ -115,28 +195,9 @@ pub(crate) async fn handle_confirmation_modal(app: &mut App, key: KeyEvent) -> R
             KeyCode::Enter | KeyCode::Char('y') | KeyCode::Char('Y') => {
                 // Execute the confirmed action
                 match &modal.action {
-                    crate::ui::ConfirmationAction::DeleteConnection(index) => {
-                        let index = *index;
-                        if let Some(connection) = app.state.db.connections.connections.get(index) {
-                            let conn_id = connection.id.clone();
-                            if let Err(e) =
-                                app.state.db.connections.remove_connection(&conn_id).await
-                            {
-                                app.state
-                                    .toast_manager
-                                    .error(format!("Failed to delete connection: {e}"));
-                            } else {
-                                app.state
-                                    .toast_manager
-                                    .success("Connection deleted successfully");
-                                if app.state.ui.selected_connection
-                                    >= app.state.db.connections.connections.len()
-                                    && app.state.ui.selected_connection > 0
-                                {
-                                    app.state.ui.selected_connection -= 1;
-                                }
-                            }
-                        }
+                    crate::ui::ConfirmationAction::DeleteConnection(connection_id) => {
+                        let connection_id = connection_id.clone();
+                        super::connections::delete_connection(app, &connection_id).await;
                     }
                     crate::ui::ConfirmationAction::DeleteSqlFile(index) => {
                         let index = *index;
@@ -151,12 +212,64 @@ pub(crate) async fn handle_confirmation_modal(app: &mut App, key: KeyEvent) -> R
                             .ui
                             .update_sql_file_selection(app.state.saved_sql_files.len());
                     }
+                    crate::ui::ConfirmationAction::LoadSqlFile(filename) => {
+                        let filename = filename.clone();
+                        let result = app.state.load_query_file(&filename);
+                        super::sql_files::report_load_result(app, result);
+                    }
+                    crate::ui::ConfirmationAction::SaveSqlFileAs(filename) => {
+                        let filename = filename.clone();
+                        super::query_editor::save_named(app, &filename).await;
+                    }
                     crate::ui::ConfirmationAction::ExitApplication => {
                         app.should_quit = true;
                     }
                     crate::ui::ConfirmationAction::QuitQueryEditor => {
                         // Just close the confirmation, stay in main view
                     }
+                    crate::ui::ConfirmationAction::RunStatement(query) => {
+                        let query = query.clone();
+                        match app.state.get_selected_connection() {
+                            Some(connection) if connection.is_connected() => {
+                                let connection_id = connection.id.clone();
+                                super::query_editor::run_query(app, connection_id, query);
+                            }
+                            _ => app.state.toast_manager.error("Not connected to database"),
+                        }
+                    }
+                    crate::ui::ConfirmationAction::RunOnOtherConnection { script } => {
+                        let script = *script;
+                        app.state.last_query_connection = app
+                            .state
+                            .get_selected_connection()
+                            .map(|connection| connection.id.clone());
+                        app.state.ui.confirmation_modal = None;
+                        if script {
+                            super::query_editor::run_script(app);
+                        } else {
+                            super::query_editor::run_query_at_cursor(app).await;
+                        }
+                        return Ok(());
+                    }
+                    crate::ui::ConfirmationAction::RunScript { script, statements } => {
+                        let (script, statements) = (script.clone(), statements.clone());
+                        match app.state.get_selected_connection() {
+                            Some(connection) if connection.is_connected() => {
+                                let connection_id = connection.id.clone();
+                                super::query_editor::run_statements(
+                                    app,
+                                    connection_id,
+                                    script,
+                                    statements,
+                                );
+                            }
+                            _ => app.state.toast_manager.error("Not connected to database"),
+                        }
+                    }
+                    crate::ui::ConfirmationAction::ApplyUndo(index) => {
+                        let index = *index;
+                        app.state.apply_undo(index).await;
+                    }
                     _ => {}
                 }
                 app.state.ui.confirmation_modal = None;
@@ -225,3 +338,409 @@ pub(crate) async fn handle_set_null_confirmation(app: &mut App, key: KeyEvent) -
     }
     Ok(())
 }
+
+/// Close the column statistics popup on any key
+pub(crate) fn handle_column_stats(app: &mut App, _key: KeyEvent) -> Result<()> {
+    app.state.table_viewer_state.column_stats = None;
+    Ok(())
+}
+
+/// Handle the quick filter form: Tab moves between fields, Up/Down pick the
+/// column and operator, Enter applies the filter and reloads the preview
+pub(crate) async fn handle_filter_form(app: &mut App, key: KeyEvent) -> Result<()> {
+    let edit = super::readline_edit(app, &key);
+    let Some(form) = app.state.table_viewer_state.filter_form.as_mut() else {
+        return Ok(());
+    };
+    if let Some(edit) = edit {
+        for _ in 0..edit.erased(&form.value) {
+            form.backspace();
+        }
+        return Ok(());
+    }
+    match key.code {
+        KeyCode::Esc => app.state.table_viewer_state.filter_form = None,
+        KeyCode::Tab => form.next_field(),
+        KeyCode::BackTab => form.prev_field(),
+        KeyCode::Down => form.cycle(true),
+        KeyCode::Up => form.cycle(false),
+        KeyCode::Backspace => form.backspace(),
+        KeyCode::Enter => match form.filter() {
+            Ok(filter) => {
+                app.state.table_viewer_state.filter_form = None;
+                if let Some(tab) = app.state.table_viewer_state.current_tab_mut() {
+                    tab.add_filter(filter);
+                }
+                let tab_idx = app.state.table_viewer_state.active_tab;
+                if let Err(e) = app.state.load_table_data(tab_idx).await {
+                    app.state
+                        .toast_manager
+                        .error(format!("Failed to filter rows: {e}"));
+                }
+            }
+            Err(e) => app.state.toast_manager.warning(e),
+        },
+        KeyCode::Char(c) => {
+            if form.field == crate::ui::components::FilterField::Value {
+                form.input(c);
+            } else if matches!(c, 'j' | 'l') {
+                form.cycle(true);
+            } else if matches!(c, 'k' | 'h') {
+                form.cycle(false);
+            }
+        }
+        _ => {}
+    }
+    Ok(())
+}
+
+/// Handle the export form: Tab moves between fields, Up/Down pick the
+/// format and destination, Enter writes the rows
+pub(crate) fn handle_export_form(app: &mut App, key: KeyEvent) -> Result<()> {
+    let edit = super::readline_edit(app, &key);
+    let Some(form) = app.state.table_viewer_state.export_form.as_mut() else {
+        return Ok(());
+    };
+    if let Some(edit) = edit {
+        if form.field == ExportField::Path {
+            for _ in 0..edit.erased(&form.path) {
+                form.backspace();
+            }
+        }
+        return Ok(());
+    }
+    match key.code {
+        KeyCode::Esc => app.state.table_viewer_state.export_form = None,
+        KeyCode::Tab if form.field == ExportField::Path => {
+            match path_input::complete(&form.path).filter(|c| c.text != form.path) {
+                Some(completion) => {
+                    form.complete_path(completion.text.clone());
+                    if let Some(hint) = completion.hint() {
+                        app.state.toast_manager.info(hint);
+                    }
+                }
+                None => form.next_field(),
+            }
+        }
+        KeyCode::Tab => form.next_field(),
+        KeyCode::BackTab => form.prev_field(),
+        KeyCode::Down => form.cycle(true),
+        KeyCode::Up => form.cycle(false),
+        KeyCode::Backspace => form.backspace(),
+        KeyCode::Enter => export(app),
+        KeyCode::Char(c) => {
+            if form.field == ExportField::Path {
+                form.input(c);
+            } else if matches!(c, 'j' | 'l') {
+                form.cycle(true);
+            } else if matches!(c, 'k' | 'h') {
+                form.cycle(false);
+            }
+        }
+        _ => {}
+    }
+    Ok(())
+}
+
+/// Write the current tab's rows, or its structure, as the export form
+/// says. A large copy to the clipboard waits for Enter again, and a file
+/// goes in a directory that exists
+fn export(app: &mut App) {
+    let Some(form) = app.state.table_viewer_state.export_form.as_ref() else {
+        return;
+    };
+    let path = match form.destination {
+        ExportDestination::Clipboard => None,
+        ExportDestination::File => match path_input::validate(&form.path, PathKind::Save) {
+            Ok(path) => Some(path),
+            Err(e) => {
+                app.state.toast_manager.error(e);
+                return;
+            }
+        },
+    };
+    if form.exports_filtered() {
+        export_filtered(app, path);
+        return;
+    }
+    let state = &mut app.state.table_viewer_state;
+    let (Some(form), Some(tab)) = (state.export_form.as_mut(), state.tabs.get(state.active_tab))
+    else {
+        return;
+    };
+    let export = if form.structure {
+        tab.export_structure(form.format())
+            .map(|output| (output, 0))
+    } else {
+        tab.export(form.format())
+    };
+    let (output, shortened) = match export {
+        Ok(export) => export,
+        Err(e) => {
+            app.state
+                .toast_manager
+                .error(format!("Failed to export rows: {e}"));
+            return;
+        }
+    };
+    let what = if form.structure {
+        format!(
+            "Structure of {} ({})",
+            tab.table_name,
+            form.format().extension()
+        )
+    } else {
+        format!(
+            "{}{} row{} ({})",
+            tab.rows.len(),
+            if form.rows.is_some() { " loaded" } else { "" },
+            if tab.rows.len() == 1 { "" } else { "s" },
+            form.format().extension()
+        )
+    };
+
+    let result = match path {
+        None => {
+            if output.len() > LARGE_COPY_BYTES && form.confirm_large != Some(output.len()) {
+                form.confirm_large = Some(output.len());
+                return;
+            }
+            app.state
+                .clipboard
+                .copy(&String::from_utf8_lossy(&output))
+                .map(|backend| super::query_results::copied_message(&what, backend))
+        }
+        Some(path) => std::fs::write(&path, &output)
+            .map(|()| {
+                app.export_dir = Some(opener::containing_folder(&path).to_path_buf());
+                format!(
+                    "{what} exported to {}; Alt+O opens its folder",
+                    path.display()
+                )
+            })
+            .map_err(|e| format!("Failed to write {}: {e}", path.display())),
+    };
+    match result {
+        Ok(message) => {
+            app.state.table_viewer_state.export_form = None;
+            if let Some(connection) = app.state.get_selected_connection() {
+                app.state
+                    .connection_manager
+                    .session_stats()
+                    .record_export(&connection.id, output.len() as u64);
+            }
+            if shortened > 0 {
+                app.state.toast_manager.warning(format!(
+                    "{message}; {shortened} long values are cut short as in the grid"
+                ));
+            } else {
+                app.state.toast_manager.success(message);
+            }
+        }
+        Err(e) => app.state.toast_manager.error(e),
+    }
+}
+
+/// Read every row passing the current preview's filters again, without a
+/// LIMIT, and stream it into the export form's format in the background.
+/// Progress, against the preview's row count, and the result come back
+/// through the export events drained in `App::tick`
+fn export_filtered(app: &mut App, path: Option<PathBuf>) {
+    if app.export_task_handle.is_some() {
+        app.state
+            .toast_manager
+            .warning("An export is already running; x in the tasks overlay stops it");
+        return;
+    }
+    let Some(connection) = app
+        .state
+        .db
+        .connections
+        .connections
+        .get(app.state.ui.selected_connection)
+    else {
+        app.state
+            .toast_manager
+            .error("No active database connection");
+        return;
+    };
+    let state = &mut app.state.table_viewer_state;
+    let (Some(form), Some(tab)) = (state.export_form.take(), state.tabs.get(state.active_tab))
+    else {
+        return;
+    };
+    let query = crate::database::preview::export_query(
+        &connection.database_type,
+        &tab.table_name,
+        &tab.filters,
+        tab.sort.as_ref(),
+    );
+    let connection_id = connection.id.clone();
+    let format = form.format();
+    let (total, estimated) = (tab.total_rows, tab.total_rows_estimated);
+
+    let manager = app.state.connection_manager.clone();
+    let tx = app.export_events_tx.clone();
+    let progress_tx = tx.clone();
+    let on_progress = move |rows| {
+        let _ = progress_tx.send(ExportEvent::Progress(export_progress(
+            rows, total, estimated,
+        )));
+    };
+    app.export_path = path.clone();
+    app.state.spinner.start(operation::EXPORTING_ROWS);
+    app.export_task_handle = Some(tokio::spawn(async move {
+        let event = match path {
+            Some(path) => {
+                let written = match std::fs::File::create(&path) {
+                    Ok(file) => {
+                        let mut sink =
+                            ExportSink::new(format.writer(BufWriter::new(file)), on_progress);
+                        let result = manager
+                            .stream_raw_query(&connection_id, &query, &mut sink)
+                            .await;
+                        result.and_then(|rows| Ok((rows, sink.finish()?)))
+                    }
+                    Err(e) => Err(e.into()),
+                };
+                match written {
+                    Ok((rows, _)) => {
+                        let bytes = std::fs::metadata(&path).map_or(0, |meta| meta.len());
+                        manager.session_stats().record_export(&connection_id, bytes);
+                        ExportEvent::Written { rows, path }
+                    }
+                    Err(e) => {
+                        // Don't leave a truncated export behind
+                        let _ = std::fs::remove_file(&path);
+                        ExportEvent::Failed(format!("Failed to export to {}: {e}", path.display()))
+                    }
+                }
+            }
+            None => {
+                let mut sink = ExportSink::new(format.writer(Vec::new()), on_progress);
+                let result = manager
+                    .stream_raw_query(&connection_id, &query, &mut sink)
+                    .await;
+                match result.and_then(|rows| Ok((rows, sink.finish()?))) {
+                    Ok((rows, out)) => ExportEvent::Formatted {
+                        rows,
+                        text: String::from_utf8_lossy(&out).into_owned(),
+                        connection_id,
+                    },
+                    Err(e) => ExportEvent::Failed(format!("Failed to export rows: {e}")),
+                }
+            }
+        };
+        let _ = tx.send(event);
+    }));
+    app.state.toast_manager.info(format!(
+        "Exporting {} ({}); x in the tasks overlay stops it",
+        ExportRows::Filtered.label(),
+        format.extension()
+    ));
+}
+
+/// Stop the running export of a filtered preview, removing the part of the
+/// file written so far
+pub(crate) fn stop_filtered_export(app: &mut App) {
+    if let Some(handle) = app.export_task_handle.take() {
+        handle.abort();
+    }
+    if let Some(path) = app.export_path.take() {
+        let _ = std::fs::remove_file(path);
+    }
+    app.state.spinner.stop(operation::EXPORTING_ROWS);
+    app.state.toast_manager.info("Export stopped");
+}
+
+/// Row sink writing an export of the first result set, reporting the rows
+/// written every `PROGRESS_EVERY_ROWS` rows
+struct ExportSink<W: Write + Send, F: FnMut(usize) + Send> {
+    writer: Option<ResultWriter<W>>,
+    first_set_done: bool,
+    rows: usize,
+    on_progress: F,
+}
+
+impl<W: Write + Send, F: FnMut(usize) + Send> ExportSink<W, F> {
+    fn new(writer: ResultWriter<W>, on_progress: F) -> Self {
+        Self {
+            writer: Some(writer),
+            first_set_done: false,
+            rows: 0,
+            on_progress,
+        }
+    }
+
+    fn writer(&mut self) -> &mut ResultWriter<W> {
+        self.writer
+            .as_mut()
+            .expect("export writer used after finish")
+    }
+
+    /// End the output, returning what it was written to
+    fn finish(&mut self) -> Result<W> {
+        let writer = self.writer.take().expect("export finished twice");
+        Ok(writer.finish()?)
+    }
+}
+
+impl<W: Write + Send, F: FnMut(usize) + Send> RowSink for ExportSink<W, F> {
+    fn columns(&mut self, columns: &[String]) -> Result<()> {
+        Ok(self.writer().begin(columns)?)
+    }
+
+    fn row(&mut self, row: Vec<String>) -> Result<()> {
+        self.writer().write_row(&row)?;
+        self.rows += 1;
+        if self.rows % PROGRESS_EVERY_ROWS == 0 {
+            (self.on_progress)(self.rows);
+        }
+        Ok(())
+    }
+
+    fn next_result_set(&mut self) -> Result<()> {
+        self.first_set_done = true;
+        Ok(())
+    }
+
+    fn is_full(&self) -> bool {
+        self.first_set_done
+    }
+}
+
+/// Handle keys of the insert-row form: Tab and the arrows move between
+/// fields, Ctrl+N leaves a column to its default or NULL, Enter inserts
+pub(crate) async fn handle_insert_row_form(app: &mut App, key: KeyEvent) -> Result<()> {
+    let edit = super::readline_edit(app, &key);
+    let Some(form) = app.state.table_viewer_state.insert_form.as_mut() else {
+        return Ok(());
+    };
+    if let Some(edit) = edit {
+        let value = form
+            .fields
+            .get(form.selected)
+            .map(|field| field.value.as_str());
+        for _ in 0..value.map_or(0, |value| edit.erased(value)) {
+            form.backspace();
+        }
+        return Ok(());
+    }
+    match key.code {
+        KeyCode::Esc => app.state.table_viewer_state.insert_form = None,
+        KeyCode::Tab | KeyCode::Down => form.next_field(),
+        KeyCode::BackTab | KeyCode::Up => form.prev_field(),
+        KeyCode::Backspace => form.backspace(),
+        KeyCode::Char('n') if key.modifiers == KeyModifiers::CONTROL => {
+            if !form.toggle_skip() {
+                app.state
+                    .toast_manager
+                    .warning("The column is NOT NULL without a default; enter a value");
+            }
+        }
+        KeyCode::Enter => app.state.insert_row().await,
+        KeyCode::Char(c) => form.input(c),
+        _ => {}
+    }
+    Ok(())
+}
@@ -0,0 +1,101 @@
+// FilePath: src/app/handlers/pins.rs
+//
+// Event handlers for pinning results and the pinned results picker
+
+#![forbid(unsafe_code)]
+
+use crate::{
+    app::{App, OverlayView},
+    core::error::Result,
+};
+use crossterm::event::{KeyCode, KeyEvent};
+
+/// Start naming a pin of the current tab's rows
+pub(crate) fn start_naming(app: &mut App) {
+    let pinnable = app
+        .state
+        .table_viewer_state
+        .current_tab()
+        .is_some_and(|tab| !tab.columns.is_empty());
+    if !pinnable {
+        app.state.toast_manager.info("No result to pin");
+        return;
+    }
+    let pins = &mut app.state.table_viewer_state.pins;
+    pins.naming = Some(pins.default_name());
+}
+
+/// Handle the prompt naming a pin
+pub(crate) fn handle_naming(app: &mut App, key: KeyEvent) -> Result<()> {
+    let edit = super::readline_edit(app, &key);
+    let state = &mut app.state.table_viewer_state;
+    let Some(name) = state.pins.naming.as_mut() else {
+        return Ok(());
+    };
+    if let Some(edit) = edit {
+        for _ in 0..edit.erased(name) {
+            name.pop();
+        }
+        return Ok(());
+    }
+    match key.code {
+        KeyCode::Esc => state.pins.naming = None,
+        KeyCode::Backspace => {
+            name.pop();
+        }
+        KeyCode::Char(c) => name.push(c),
+        KeyCode::Enter => {
+            let name = name.trim().to_string();
+            if name.is_empty() {
+                app.state.toast_manager.warning("Enter a name for the pin");
+                return Ok(());
+            }
+            state.pins.naming = None;
+            let Some(tab) = state.tabs.get(state.active_tab) else {
+                return Ok(());
+            };
+            let rows = tab.rows.len();
+            let replaced = state.pins.pin(name.clone(), tab);
+            app.state.toast_manager.success(format!(
+                "{} \"{name}\" with {rows} row{}; ' lists pins",
+                if replaced { "Re-pinned" } else { "Pinned" },
+                if rows == 1 { "" } else { "s" }
+            ));
+        }
+        _ => {}
+    }
+    Ok(())
+}
+
+/// Open the pinned results picker
+pub(crate) fn open(app: &mut App) {
+    let pins = &mut app.state.table_viewer_state.pins;
+    pins.selected = pins.selected.min(pins.pins().len().saturating_sub(1));
+    app.state.ui.show_overlay(OverlayView::Pins);
+}
+
+/// Handle picker keys
+pub(crate) fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
+    let pins = &mut app.state.table_viewer_state.pins;
+    let count = pins.pins().len();
+    match key.code {
+        KeyCode::Char('j') | KeyCode::Down if pins.selected + 1 < count => pins.selected += 1,
+        KeyCode::Char('k') | KeyCode::Up => pins.selected = pins.selected.saturating_sub(1),
+        KeyCode::Char('d') => {
+            if let Some(pin) = pins.remove(pins.selected) {
+                app.state
+                    .toast_manager
+                    .info(format!("Dropped pin \"{}\"", pin.name));
+            }
+        }
+        KeyCode::Enter => {
+            let selected = pins.selected;
+            if app.state.table_viewer_state.open_pin(selected) {
+                app.state.ui.return_to_main();
+                app.state.ui.focused_pane = crate::app::FocusedPane::TabularOutput;
+            }
+        }
+        _ => {}
+    }
+    Ok(())
+}
@@ -4,8 +4,18 @@
 
 #![forbid(unsafe_code)]
 
-use crate::{app::App, core::error::Result};
+use crate::{
+    app::{App, OverlayView, QueryEvent},
+    core::error::Result,
+    database::{
+        script::StatementFailure, statement_guard::GuardAction, statement_timeout, transaction,
+        update_preview, ProgressCollector,
+    },
+    state::ui::sql_file_name,
+    ui::{ConfirmationAction, ConfirmationModal},
+};
 use crossterm::event::{KeyCode, KeyEvent, KeyModifiers};
+use std::sync::atomic::Ordering;
 
 /// Handle Query Editor pane keys - ONLY PANE WITH VIM INSERT MODE
 pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
@@ -22,20 +32,12 @@ pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
     // Normal mode - vim keybindings
     match key.code {
         // Shift+E - Execute query at cursor (PRIMARY binding, vim-style)
-        KeyCode::Char('E') => {
-            if let Err(e) = app.state.execute_query_at_cursor().await {
-                app.state
-                    .toast_manager
-                    .error(format!("Query execution failed: {e}"));
-            }
-        }
+        KeyCode::Char('E') => run_query_at_cursor(app).await,
+        // Shift+R - Run every statement in the editor, one after another
+        KeyCode::Char('R') => run_script(app),
         // Ctrl+Enter - Execute query at cursor (SECONDARY binding, familiar to SQL tool users)
         KeyCode::Enter if key.modifiers.contains(KeyModifiers::CONTROL) => {
-            if let Err(e) = app.state.execute_query_at_cursor().await {
-                app.state
-                    .toast_manager
-                    .error(format!("Query execution failed: {e}"));
-            }
+            run_query_at_cursor(app).await
         }
         // 'i' - Enter insert mode at cursor
         KeyCode::Char('i') => {
@@ -101,6 +103,12 @@ pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
         KeyCode::Char(':') => {
             app.state.query_editor.enter_command_mode();
         }
+        // Ctrl+r - Pick a query run before
+        KeyCode::Char('r') if key.modifiers == KeyModifiers::CONTROL => {
+            super::query_history::open(app).await;
+        }
+        // Ctrl+s - Save the query, asking for a name the first time
+        KeyCode::Char('s') if key.modifiers == KeyModifiers::CONTROL => save(app).await,
         // Ctrl+d and Ctrl+u for page scrolling - TODO: implement scroll methods
         // KeyCode::Char('d') if key.modifiers == KeyModifiers::CONTROL => {
         //     app.state.query_editor.scroll_half_page_down();
@@ -113,8 +121,334 @@ pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
     Ok(())
 }
 
+/// Run the statement at the cursor, an UPDATE after previewing the rows it
+/// changes when `preview_updates` is on. A destructive statement is first
+/// confirmed or blocked as the connection's guard policy says
+pub(crate) async fn run_query_at_cursor(app: &mut App) {
+    let Some((connection_id, query)) = app.state.query_at_cursor() else {
+        return;
+    };
+    if !confirm_connection_change(app, &connection_id, false) {
+        return;
+    }
+    if !transaction::is_read_only(&query) {
+        if let Err(e) = app.state.check_writable() {
+            app.state.toast_manager.error(e);
+            return;
+        }
+    }
+    let preview = app.state.preview_updates && update_preview::is_update(&query);
+    let verdict = app
+        .state
+        .get_selected_connection()
+        .and_then(|connection| app.state.statement_guard.check(&connection.name, &query));
+    if let Some(verdict) = verdict {
+        match verdict.action {
+            GuardAction::Allow => {}
+            GuardAction::Block => {
+                app.state
+                    .toast_manager
+                    .error(format!("Statement blocked by {}", verdict.policy()));
+                return;
+            }
+            // The UPDATE preview asks anyway
+            GuardAction::Confirm if preview => {}
+            GuardAction::Confirm => {
+                app.state.ui.confirmation_modal = Some(crate::ui::ConfirmationModal {
+                    title: format!("Confirm {}", verdict.statement.label()),
+                    message: format!("Held back by {}. Run it anyway?", verdict.policy()),
+                    action: crate::ui::ConfirmationAction::RunStatement(query),
+                });
+                return;
+            }
+        }
+    }
+    if preview {
+        app.state.preview_update(&connection_id, query).await;
+        return;
+    }
+    run_query(app, connection_id, query);
+}
+
+/// Run every statement in the query editor, one after another, each like
+/// `run_query_at_cursor` runs one but without the UPDATE preview. Statements
+/// that write need a writable connection, and one a statement guard holds
+/// back asks before the script starts
+pub(crate) fn run_script(app: &mut App) {
+    let Some((connection_id, script, statements)) = app.state.script_to_run() else {
+        return;
+    };
+    if !confirm_connection_change(app, &connection_id, true) {
+        return;
+    }
+    if statements
+        .iter()
+        .any(|statement| !transaction::is_read_only(statement))
+    {
+        if let Err(e) = app.state.check_writable() {
+            app.state.toast_manager.error(e);
+            return;
+        }
+    }
+    let connection_name = app
+        .state
+        .get_selected_connection()
+        .map(|connection| connection.name.clone())
+        .unwrap_or_default();
+    let count = statements.len();
+    let held: Vec<_> = statements
+        .iter()
+        .enumerate()
+        .filter_map(|(index, statement)| {
+            let verdict = app
+                .state
+                .statement_guard
+                .check(&connection_name, statement)?;
+            (verdict.action != GuardAction::Allow).then_some((index + 1, verdict))
+        })
+        .collect();
+    if let Some((position, verdict)) = held
+        .iter()
+        .find(|(_, verdict)| verdict.action == GuardAction::Block)
+    {
+        app.state.toast_manager.error(format!(
+            "Statement {position}/{count} blocked by {}",
+            verdict.policy()
+        ));
+        return;
+    }
+    if let Some((position, verdict)) = held.first() {
+        app.state.ui.confirmation_modal = Some(ConfirmationModal {
+            title: format!("Confirm {}", verdict.statement.label()),
+            message: format!(
+                "Statement {position}/{count} is held back by {}. Run the script anyway?",
+                verdict.policy()
+            ),
+            action: ConfirmationAction::RunScript { script, statements },
+        });
+        return;
+    }
+    run_statements(app, connection_id, script, statements);
+}
+
+/// Whether the statement, or script when `script`, may run on
+/// `connection_id` right away: it's where the last one ran, or the first
+/// one. Otherwise asks once whether to run it there, naming both
+/// connections, so switching connections doesn't send a query to the wrong
+/// environment unnoticed
+fn confirm_connection_change(app: &mut App, connection_id: &str, script: bool) -> bool {
+    let connections = &app.state.db.connections.connections;
+    let label = |id: &str| {
+        connections
+            .iter()
+            .find(|connection| connection.id == id)
+            .map(|connection| connection.target_label())
+    };
+    let previous = match app.state.last_query_connection.as_deref() {
+        Some(previous) if previous != connection_id => label(previous),
+        _ => None,
+    };
+    let Some(previous) = previous else {
+        app.state.last_query_connection = Some(connection_id.to_string());
+        return true;
+    };
+    let target = label(connection_id).unwrap_or_default();
+    app.state.ui.confirmation_modal = Some(ConfirmationModal {
+        title: "Run on another connection".to_string(),
+        message: format!("The last query ran on {previous}.\n\nRun this one on {target}?"),
+        action: ConfirmationAction::RunOnOtherConnection { script },
+    });
+    false
+}
+
+/// Run a statement in the background. Progress and the result come back
+/// through the query events drained in `App::tick`, so the UI stays
+/// responsive, the cancel key can cancel the statement and Ctrl+C can stop
+/// fetching while rows arrive. A read-only statement that lost its
+/// connection runs once more after reconnecting. Only one runs at a time
+pub(crate) fn run_query(app: &mut App, connection_id: String, query: String) {
+    if app.query_task_handle.is_some() {
+        app.state.toast_manager.warning(format!(
+            "A query is already running ({} cancels it)",
+            app.state.keymap.cancel_query_key()
+        ));
+        return;
+    }
+    let Some(config) = app
+        .state
+        .db
+        .connections
+        .connections
+        .iter()
+        .find(|connection| connection.id == connection_id)
+        .cloned()
+    else {
+        app.state
+            .toast_manager
+            .error("No active database connection");
+        return;
+    };
+    let stop = app.state.start_query(&query);
+    app.query_connection_id = Some(connection_id);
+
+    let manager = app.state.connection_manager.clone();
+    let limits = app.state.result_limits;
+    let tx = app.query_events_tx.clone();
+    app.query_task_handle = Some(tokio::spawn(async move {
+        let new_collector = || {
+            let progress_tx = tx.clone();
+            ProgressCollector::new(limits, stop.clone(), move |rows| {
+                let _ = progress_tx.send(QueryEvent::Progress(rows));
+            })
+        };
+        let (result, reconnected) = manager
+            .stream_query_reconnecting(&config, &query, new_collector)
+            .await;
+        let result = result
+            .map(|collector| collector.finish())
+            .map_err(|e| e.to_string());
+        let _ = tx.send(QueryEvent::Finished {
+            query,
+            result,
+            reconnected,
+        });
+    }));
+}
+
+/// Run the statements of `script` in the background one after another, like
+/// `run_query` runs one, on the session connection so a transaction opened
+/// by one holds the next. The script ends at the first statement failing,
+/// or once fetching was stopped, keeping the results of those before
+pub(crate) fn run_statements(
+    app: &mut App,
+    connection_id: String,
+    script: String,
+    statements: Vec<String>,
+) {
+    if app.query_task_handle.is_some() {
+        app.state.toast_manager.warning(format!(
+            "A query is already running ({} cancels it)",
+            app.state.keymap.cancel_query_key()
+        ));
+        return;
+    }
+    let Some(config) = app
+        .state
+        .db
+        .connections
+        .connections
+        .iter()
+        .find(|connection| connection.id == connection_id)
+        .cloned()
+    else {
+        app.state
+            .toast_manager
+            .error("No active database connection");
+        return;
+    };
+    let stop = app.state.start_query(&script);
+    app.query_connection_id = Some(connection_id);
+
+    let manager = app.state.connection_manager.clone();
+    let limits = app.state.result_limits;
+    let tx = app.query_events_tx.clone();
+    app.query_task_handle = Some(tokio::spawn(async move {
+        let count = statements.len();
+        let mut results = Vec::new();
+        let mut failure = None;
+        let mut reconnected = false;
+        for (index, statement) in statements.iter().enumerate() {
+            if stop.load(Ordering::SeqCst) {
+                break;
+            }
+            let start = std::time::Instant::now();
+            // Rows count per statement, so the cancel key cancels the one running
+            let _ = tx.send(QueryEvent::Progress(0));
+            let new_collector = || {
+                let progress_tx = tx.clone();
+                ProgressCollector::new(limits, stop.clone(), move |rows| {
+                    let _ = progress_tx.send(QueryEvent::Progress(rows));
+                })
+            };
+            let (result, again) = manager
+                .stream_query_reconnecting(&config, statement, new_collector)
+                .await;
+            reconnected |= again;
+            match result {
+                Ok(collector) => {
+                    let mut result = collector.finish();
+                    let more_results = std::mem::take(&mut result.more_results);
+                    results.extend(std::iter::once(result).chain(more_results).map(|mut set| {
+                        set.statement = Some((index + 1, count));
+                        set
+                    }));
+                }
+                Err(e) => {
+                    failure = Some(StatementFailure {
+                        position: index + 1,
+                        statement: statement.clone(),
+                        error: e.to_string(),
+                        elapsed: start.elapsed(),
+                    });
+                    break;
+                }
+            }
+        }
+        let _ = tx.send(QueryEvent::ScriptFinished {
+            script,
+            results,
+            statements,
+            failure,
+            reconnected,
+        });
+    }));
+}
+
+/// Cancel the running statement: the server is asked to stop it, and it ends
+/// with "Query cancelled". Once rows arrive, or where the server can't be
+/// asked (SQLite), only fetching stops, keeping the rows loaded so far
+pub(crate) fn cancel_query(app: &mut App) {
+    let Some(connection_id) = app.query_connection_id.clone() else {
+        return;
+    };
+    let Some(progress) = app.state.fetch_progress.as_mut() else {
+        return;
+    };
+    if progress.is_cancelling() {
+        return;
+    }
+    let manager = app.state.connection_manager.clone();
+    if progress.rows() > 0 || !manager.can_cancel(&connection_id) {
+        progress.request_stop();
+        app.state
+            .toast_manager
+            .info("Stopping fetch, keeping rows loaded so far");
+        return;
+    }
+
+    progress.mark_cancelling();
+    app.state.toast_manager.info("Cancelling query");
+    let tx = app.query_events_tx.clone();
+    tokio::spawn(async move {
+        let failure = match manager.cancel_query(&connection_id).await {
+            Ok(true) => return,
+            Ok(false) => "the statement hasn't reached the server yet".to_string(),
+            Err(e) => e.to_string(),
+        };
+        let _ = tx.send(QueryEvent::CancelFailed(failure));
+    });
+}
+
 /// Handle query editor insert mode
 async fn handle_insert_mode(app: &mut App, key: KeyEvent) -> Result<()> {
+    if let Some(edit) = super::readline_edit(app, &key) {
+        app.state.query_editor.readline(edit);
+        if app.state.query_content != app.state.query_editor.get_content() {
+            app.state.query_content = app.state.query_editor.get_content().to_string();
+            app.state.ui.query_modified = true;
+        }
+        return Ok(());
+    }
     match key.code {
         // Esc - Exit insert mode
         KeyCode::Esc => {
@@ -130,6 +464,8 @@ async fn handle_insert_mode(app: &mut App, key: KeyEvent) -> Result<()> {
             app.state.query_content = app.state.query_editor.get_content().to_string();
             app.state.ui.query_modified = true;
         }
+        // Ctrl+s - Save the query, asking for a name the first time
+        KeyCode::Char('s') if key.modifiers == KeyModifiers::CONTROL => save(app).await,
         // Ctrl+p - Navigate suggestions up (vim-style) - MUST come before Char(c) pattern
         KeyCode::Char('p') if key.modifiers == KeyModifiers::CONTROL => {
             if app.state.query_editor.are_suggestions_active() {
@@ -189,6 +525,12 @@ async fn handle_insert_mode(app: &mut App, key: KeyEvent) -> Result<()> {
 
 /// Handle query editor command mode (vim : commands)
 async fn handle_command_mode(app: &mut App, key: KeyEvent) -> Result<()> {
+    if let Some(edit) = super::readline_edit(app, &key) {
+        for _ in 0..edit.erased(app.state.query_editor.get_command_buffer()) {
+            app.state.query_editor.backspace_command_buffer();
+        }
+        return Ok(());
+    }
     match key.code {
         // Esc - Exit command mode
         KeyCode::Esc => {
@@ -246,12 +588,15 @@ async fn handle_command_mode(app: &mut App, key: KeyEvent) -> Result<()> {
                             .success("File saved and editor cleared");
                     }
                 }
-                cmd if cmd.starts_with(":w ") => {
-                    // Save with filename - future enhancement
-                    app.state
-                        .toast_manager
-                        .warning("Save with filename not yet implemented");
+                ":stats" => app.state.ui.show_overlay(OverlayView::SessionStats),
+                cmd if cmd == ":readonly" || cmd.starts_with(":readonly ") => {
+                    set_read_only(app, cmd[":readonly".len()..].trim());
+                }
+                cmd if cmd == ":timeout" || cmd.starts_with(":timeout ") => {
+                    set_statement_timeout(app, cmd[":timeout".len()..].trim()).await;
                 }
+                cmd if cmd.starts_with(":w ") => save_as(app, &cmd[":w ".len()..], false).await,
+                cmd if cmd.starts_with(":w! ") => save_as(app, &cmd[":w! ".len()..], true).await,
                 _ => {
                     app.state
                         .toast_manager
@@ -267,3 +612,166 @@ async fn handle_command_mode(app: &mut App, key: KeyEvent) -> Result<()> {
     }
     Ok(())
 }
+
+/// Save the query to the loaded file; without one, command mode opens on
+/// `:w ` for a name
+async fn save(app: &mut App) {
+    if app.state.ui.current_sql_file.is_some() {
+        if let Err(e) = app.state.save_sql_file_with_connection().await {
+            app.state
+                .toast_manager
+                .error(format!("Failed to save file: {e}"));
+        } else {
+            app.state.toast_manager.success("File saved successfully");
+        }
+        return;
+    }
+    app.state.query_editor.set_insert_mode(false);
+    app.state.query_editor.enter_command_mode();
+    for c in "w ".chars() {
+        app.state.query_editor.add_to_command_buffer(c);
+    }
+    app.state
+        .toast_manager
+        .info("Name the query and press Enter to save it");
+}
+
+/// `:w <name>` saves the query as a named SQL file, asking before it
+/// replaces another file; `:w! <name>` replaces without asking
+async fn save_as(app: &mut App, name: &str, force: bool) {
+    let name = match sql_file_name(name) {
+        Ok(name) => name,
+        Err(e) => {
+            app.state.toast_manager.error(e);
+            return;
+        }
+    };
+    let replaces = app.state.saved_sql_files.contains(&name)
+        && app.state.ui.current_sql_file.as_ref() != Some(&name);
+    if replaces && !force {
+        app.state.ui.confirmation_modal = Some(ConfirmationModal {
+            title: "Replace SQL File".to_string(),
+            message: format!(
+                "'{name}' already exists.\n\nReplace it with the query in the editor?"
+            ),
+            action: ConfirmationAction::SaveSqlFileAs(name),
+        });
+        return;
+    }
+    save_named(app, &name).await;
+}
+
+/// Save the query as `name` and toast the outcome
+pub(crate) async fn save_named(app: &mut App, name: &str) {
+    if let Err(e) = app.state.save_sql_file_as(name).await {
+        app.state
+            .toast_manager
+            .error(format!("Failed to save file: {e}"));
+    } else {
+        app.state.toast_manager.success(format!("Saved '{name}'"));
+    }
+}
+
+/// `:timeout <seconds>` sets the server-side statement timeout of the
+/// connection's session for the statements run after it, 0 lifting it;
+/// `:timeout` alone goes back to the configured one
+async fn set_statement_timeout(app: &mut App, seconds: &str) {
+    if app.query_task_handle.is_some() {
+        app.state
+            .toast_manager
+            .warning("Wait for the running query to finish");
+        return;
+    }
+    let Some(connection) = app
+        .state
+        .get_selected_connection()
+        .filter(|connection| connection.is_connected())
+        .cloned()
+    else {
+        app.state.toast_manager.error("Not connected to database");
+        return;
+    };
+    let target = if seconds.is_empty() {
+        app.state.statement_timeouts.configured(&connection)
+    } else {
+        match seconds.parse::<u64>() {
+            Ok(seconds) => seconds,
+            Err(_) => {
+                app.state
+                    .toast_manager
+                    .error("Usage: :timeout <seconds>, 0 for none");
+                return;
+            }
+        }
+    };
+    let Some(sql) = statement_timeout::set_sql(&connection.database_type, target) else {
+        app.state.toast_manager.info(format!(
+            "{} has no statement timeout",
+            connection.database_type.display_name()
+        ));
+        return;
+    };
+
+    if let Err(e) = app
+        .state
+        .connection_manager
+        .execute_raw_query(&connection.id, &sql)
+        .await
+    {
+        app.state
+            .toast_manager
+            .error(format!("Failed to set the statement timeout: {e}"));
+        return;
+    }
+    if seconds.is_empty() {
+        app.state.statement_timeouts.clear_override(&connection.id);
+    } else {
+        app.state
+            .statement_timeouts
+            .set_override(&connection.id, target);
+    }
+    match app.state.statement_timeouts.effective(&connection) {
+        Some(seconds) => app
+            .state
+            .toast_manager
+            .success(format!("Statements time out after {seconds}s")),
+        None => app
+            .state
+            .toast_manager
+            .success("Statements run without a timeout"),
+    }
+}
+
+/// `:readonly on|off` turns the read-only guard on or off for the
+/// connection's session; `:readonly` alone goes back to guarding it by its
+/// server's role
+fn set_read_only(app: &mut App, setting: &str) {
+    let Some(connection) = app
+        .state
+        .get_selected_connection()
+        .filter(|connection| connection.is_connected())
+        .cloned()
+    else {
+        app.state.toast_manager.error("Not connected to database");
+        return;
+    };
+    let guard = &mut app.state.read_only_guard;
+    match setting {
+        "" => guard.clear_override(&connection.id),
+        "on" => guard.set_override(&connection.id, true),
+        "off" => guard.set_override(&connection.id, false),
+        _ => {
+            app.state.toast_manager.error("Usage: :readonly [on|off]");
+            return;
+        }
+    }
+    if guard.is_guarded(&connection.id) {
+        app.state
+            .toast_manager
+            .info(format!("Writes to {} are blocked", connection.name));
+    } else {
+        app.state
+            .toast_manager
+            .info(format!("Writes to {} are allowed", connection.name));
+    }
+}
@@ -0,0 +1,66 @@
+// FilePath: src/app/handlers/query_history.rs
+//
+// Event handlers for the query history picker
+
+#![forbid(unsafe_code)]
+
+use crate::{
+    app::{App, OverlayView},
+    core::error::Result,
+    ui::components::QueryHistoryPicker,
+};
+use crossterm::event::{KeyCode, KeyEvent, KeyModifiers};
+
+/// Read the history and open the picker on its newest entry
+pub(crate) async fn open(app: &mut App) {
+    let Some(history) = app.state.query_history.as_ref() else {
+        app.state
+            .toast_manager
+            .error("Query history isn't available; see the log for why");
+        return;
+    };
+    let limit = app.state.query_history_limit as i64;
+    match history.get_history(None, Some(limit)).await {
+        Ok(entries) => {
+            app.state.query_history_picker = Some(QueryHistoryPicker::new(entries));
+            app.state.ui.show_overlay(OverlayView::QueryHistory);
+        }
+        Err(e) => app
+            .state
+            .toast_manager
+            .error(format!("Failed to read query history: {e}")),
+    }
+}
+
+/// Handle picker keys: typing filters, Enter loads the selected query into
+/// the editor
+pub(crate) fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
+    let Some(picker) = app.state.query_history_picker.as_mut() else {
+        app.state.ui.return_to_main();
+        return Ok(());
+    };
+    match key.code {
+        KeyCode::Down => picker.move_down(),
+        KeyCode::Up => picker.move_up(),
+        KeyCode::Char('n') if key.modifiers == KeyModifiers::CONTROL => picker.move_down(),
+        KeyCode::Char('p') if key.modifiers == KeyModifiers::CONTROL => picker.move_up(),
+        KeyCode::Backspace => picker.pop_filter(),
+        KeyCode::Char(c) if !key.modifiers.contains(KeyModifiers::CONTROL) => picker.push_filter(c),
+        KeyCode::Enter => {
+            let Some(query) = picker
+                .selected_entry()
+                .map(|entry| entry.query_text.clone())
+            else {
+                return Ok(());
+            };
+            app.state.query_history_picker = None;
+            app.state.ui.return_to_main();
+            app.state.query_editor.set_content(query.clone());
+            app.state.query_content = query;
+            app.state.ui.query_modified = true;
+            app.state.toast_manager.info("Query loaded; E runs it");
+        }
+        _ => {}
+    }
+    Ok(())
+}
@@ -4,7 +4,10 @@
 
 #![forbid(unsafe_code)]
 
-use crate::{app::App, core::error::Result};
+use crate::{
+    app::App, core::error::Result, database::transaction, io::clipboard::ClipboardBackend,
+    ui::components::QueryWatch,
+};
 use crossterm::event::{KeyCode, KeyEvent, KeyModifiers};
 
 /// Handle Query Results pane keys - has its own edit mode
@@ -17,13 +20,245 @@ pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
         if tab.in_search_mode {
             return handle_search_mode(app, key).await;
         }
+        if tab.column_jump.is_some() {
+            handle_column_jump(app, key);
+            return Ok(());
+        }
+    }
+
+    // Navigating a watched result holds off its refresh
+    if let Some(watch) = app
+        .state
+        .table_viewer_state
+        .current_tab_mut()
+        .and_then(|tab| tab.watch.as_mut())
+    {
+        watch.hold(std::time::Instant::now());
+    }
+
+    // 'J' - Switch a JSON document or query plan result between its view
+    // and the grid
+    if let Some(tab) = app.state.table_viewer_state.current_tab_mut() {
+        if key.code == KeyCode::Char('J') && (tab.json_view.is_some() || tab.plan_view.is_some()) {
+            tab.show_raw_grid = !tab.show_raw_grid;
+            return Ok(());
+        }
+    }
+    if handle_json_view(app, key) || handle_plan_view(app, key) {
+        return Ok(());
+    }
+
+    // A pin keeps its rows as they were pinned: nothing reloads or edits them
+    let pinned = app
+        .state
+        .table_viewer_state
+        .current_tab()
+        .is_some_and(|tab| tab.pin.is_some());
+    if pinned && changes_rows(&key) {
+        app.state
+            .toast_manager
+            .info("Pinned rows stay as they were; run the query again to change them");
+        return Ok(());
     }
 
     // Normal navigation mode
     match key.code {
+        // 'w' - Toggle auto-refresh of a query result
+        KeyCode::Char('w') => toggle_watch(app),
+        // Space - Mark/unmark the cell for an IN clause, or finish a range
+        KeyCode::Char(' ') => {
+            if let Some(tab) = app.state.table_viewer_state.current_tab_mut() {
+                tab.toggle_mark();
+            }
+        }
+        // 'v' - Start/finish marking a range of cells in the column
+        KeyCode::Char('v') => {
+            if let Some(tab) = app.state.table_viewer_state.current_tab_mut() {
+                tab.toggle_mark_range();
+            }
+        }
+        // Esc - Clear marked cells
+        KeyCode::Esc => {
+            if let Some(tab) = app.state.table_viewer_state.current_tab_mut() {
+                tab.clear_marks();
+            }
+        }
+        // 'W' - Copy a WHERE ... IN clause of the marked cells
+        KeyCode::Char('W') => {
+            if let Some(clause) = marked_in_clause(app) {
+                match app.state.clipboard.copy(&clause) {
+                    Ok(backend) => app
+                        .state
+                        .toast_manager
+                        .success(copied_message("IN clause", backend)),
+                    Err(e) => app
+                        .state
+                        .toast_manager
+                        .error(format!("Failed to copy IN clause: {e}")),
+                }
+            }
+        }
+        // 'I' - Insert a WHERE ... IN clause of the marked cells into the query editor
+        KeyCode::Char('I') => {
+            if let Some(clause) = marked_in_clause(app) {
+                app.state.insert_into_query_editor(&clause);
+                app.state
+                    .toast_manager
+                    .success("IN clause inserted into the query editor");
+            }
+        }
+        // '[' / ']' - Previous/next result set of a multi-statement query
+        KeyCode::Char('[') | KeyCode::Char(']') => {
+            if let Some(tab) = app.state.table_viewer_state.current_tab_mut() {
+                tab.cycle_result_set(key.code == KeyCode::Char(']'));
+            }
+        }
+        // 's' - Show statistics of the selected column over the loaded rows
+        KeyCode::Char('s') => {
+            let stats = app
+                .state
+                .table_viewer_state
+                .current_tab()
+                .filter(|tab| {
+                    tab.view_mode == crate::ui::components::table_viewer::TableViewMode::Data
+                })
+                .and_then(|tab| tab.selected_column_stats());
+            match stats {
+                Some(stats) => app.state.table_viewer_state.column_stats = Some(stats),
+                None => app.state.toast_manager.info("No column to summarize"),
+            }
+        }
+        // Enter on a column search match - Open that table's structure
+        KeyCode::Enter
+            if app
+                .state
+                .table_viewer_state
+                .current_tab()
+                .is_some_and(|tab| tab.column_search) =>
+        {
+            app.state.open_column_search_match().await;
+        }
+        // Enter on a partition in the structure view - Preview its rows
+        KeyCode::Enter
+            if app
+                .state
+                .table_viewer_state
+                .current_tab()
+                .is_some_and(|tab| {
+                    tab.view_mode == crate::ui::components::table_viewer::TableViewMode::Schema
+                        && tab.selected_partition().is_some()
+                }) =>
+        {
+            app.state.preview_selected_partition().await;
+        }
+        // 'n'/'p' - Fetch the next/previous page of a table preview
+        KeyCode::Char('n') | KeyCode::Char('p')
+            if app
+                .state
+                .table_viewer_state
+                .current_tab()
+                .is_some_and(|tab| {
+                    tab.is_table_preview()
+                        && tab.view_mode == crate::ui::components::table_viewer::TableViewMode::Data
+                }) =>
+        {
+            fetch_preview_page(app, key.code == KeyCode::Char('n')).await;
+        }
+        // 'f' - Add a quick filter to a table preview
+        KeyCode::Char('f') => {
+            if !app.state.table_viewer_state.open_filter_form() {
+                app.state
+                    .toast_manager
+                    .info("Filters apply to table previews; add a WHERE clause to the query");
+            }
+        }
+        // 'm' - Pin the tab's rows under a name for the rest of the session
+        KeyCode::Char('m') => super::pins::start_naming(app),
+        // "'" - Pick a pinned result to open
+        KeyCode::Char('\'') => super::pins::open(app),
+        // 'B' - Save a binary cell to a file
+        KeyCode::Char('B') => super::save_cell::open(app),
+        // 'U' - List the session's grid changes to undo one
+        KeyCode::Char('U') => super::undo_log::open(app),
+        // 'e' - Export the tab's rows to the clipboard or a file
+        KeyCode::Char('e') => {
+            if !app.state.table_viewer_state.open_export_form() {
+                app.state.toast_manager.info("No rows to export");
+            }
+        }
+        // 'F' - Clear the quick filters of a table preview
+        KeyCode::Char('F') => {
+            let cleared = app
+                .state
+                .table_viewer_state
+                .current_tab_mut()
+                .is_some_and(|tab| tab.clear_filters());
+            if cleared {
+                let tab_idx = app.state.table_viewer_state.active_tab;
+                match app.state.load_table_data(tab_idx).await {
+                    Ok(()) => app.state.toast_manager.info("Filters cleared"),
+                    Err(e) => app
+                        .state
+                        .toast_manager
+                        .error(format!("Failed to reload rows: {e}")),
+                }
+            }
+        }
+        // 'a' - Insert a row into the table of a preview
+        KeyCode::Char('a') => app.state.open_insert_row_form(false).await,
+        // 'A' - Insert a copy of the selected row, primary key cleared
+        KeyCode::Char('A') => app.state.open_insert_row_form(true).await,
+        // 'o' - Sort a table preview by the selected column on the server
+        KeyCode::Char('o') => {
+            let sort = app
+                .state
+                .table_viewer_state
+                .current_tab_mut()
+                .filter(|tab| tab.is_table_preview())
+                .and_then(|tab| tab.toggle_sort())
+                .map(|sort| {
+                    let order = if sort.descending {
+                        "descending"
+                    } else {
+                        "ascending"
+                    };
+                    format!("Sorted by {} {order}", sort.column)
+                });
+            let Some(message) = sort else {
+                app.state
+                    .toast_manager
+                    .info("Sorting applies to table previews; add an ORDER BY to the query");
+                return Ok(());
+            };
+            let tab_idx = app.state.table_viewer_state.active_tab;
+            match app.state.load_table_data(tab_idx).await {
+                Ok(()) => app.state.toast_manager.info(message),
+                Err(e) => app
+                    .state
+                    .toast_manager
+                    .error(format!("Failed to sort rows: {e}")),
+            }
+        }
+        // 'p'/'P' - Select the next/previous partition in the structure view
+        KeyCode::Char('p') | KeyCode::Char('P') => {
+            if let Some(tab) = app.state.table_viewer_state.current_tab_mut() {
+                if tab.view_mode == crate::ui::components::table_viewer::TableViewMode::Schema {
+                    if key.code == KeyCode::Char('p') {
+                        tab.select_next_partition();
+                    } else {
+                        tab.select_prev_partition();
+                    }
+                }
+            }
+        }
         // 'i' or Enter - Start editing current cell
         KeyCode::Char('i') | KeyCode::Enter => {
-            if let Some(tab) = app.state.table_viewer_state.current_tab_mut() {
+            // A cell cut short in the preview is edited in full, or not at all
+            if let Err(e) = app.state.load_full_cell_values(false).await {
+                app.state
+                    .toast_manager
+                    .error(format!("Cannot edit cell: {e}"));
+            } else if let Some(tab) = app.state.table_viewer_state.current_tab_mut() {
                 tab.start_edit();
             }
         }
@@ -101,9 +336,15 @@ pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
 
             if should_copy_cell {
                 // 'yc' sequence detected - copy cell to clipboard
-                match app.state.table_viewer_state.copy_cell() {
-                    Ok(()) => {
-                        app.state.toast_manager.success("Cell copied to clipboard");
+                let copied = match app.state.load_full_cell_values(false).await {
+                    Ok(()) => app.state.table_viewer_state.copy_cell(&app.state.clipboard),
+                    Err(e) => Err(e),
+                };
+                match copied {
+                    Ok(backend) => {
+                        app.state
+                            .toast_manager
+                            .success(copied_message("Cell", backend));
                     }
                     Err(e) => {
                         app.state
@@ -143,8 +384,57 @@ pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
                 }
                 // Reset the last press
                 app.state.table_viewer_state.last_d_press = None;
+            } else if let Some(tab) = app.state.table_viewer_state.current_tab_mut() {
+                // 'c' alone - Jump to a column by typing part of its name
+                if tab.view_mode == crate::ui::components::table_viewer::TableViewMode::Data {
+                    tab.start_column_jump();
+                }
+            }
+        }
+        // Ctrl+Y - Copy every loaded row as TSV with a header
+        KeyCode::Char('y') if key.modifiers == KeyModifiers::CONTROL => {
+            let copied = match app.state.load_all_full_cell_values().await {
+                Ok(()) => app
+                    .state
+                    .table_viewer_state
+                    .copy_rows_tsv(&app.state.clipboard),
+                Err(e) => Err(e),
+            };
+            match copied {
+                Ok((rows, backend)) => {
+                    let what = format!("{rows} row{} (TSV)", if rows == 1 { "" } else { "s" });
+                    app.state
+                        .toast_manager
+                        .success(copied_message(&what, backend));
+                }
+                Err(e) => {
+                    app.state
+                        .toast_manager
+                        .error(format!("Failed to copy rows: {e}"));
+                }
+            }
+        }
+        // 'Y' - Copy current row as TSV
+        KeyCode::Char('Y') => {
+            let copied = match app.state.load_full_cell_values(true).await {
+                Ok(()) => app
+                    .state
+                    .table_viewer_state
+                    .copy_row_tsv(&app.state.clipboard),
+                Err(e) => Err(e),
+            };
+            match copied {
+                Ok(backend) => {
+                    app.state
+                        .toast_manager
+                        .success(copied_message("Row (TSV)", backend));
+                }
+                Err(e) => {
+                    app.state
+                        .toast_manager
+                        .error(format!("Failed to copy row: {e}"));
+                }
             }
-            // If 'c' pressed without prior 'd' or 'y', do nothing (ignore)
         }
         // 'y' - Copy current row (double-tap within 500ms)
         KeyCode::Char('y') => {
@@ -158,11 +448,18 @@ pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
 
             if should_copy {
                 // Double-tap detected - copy row to clipboard
-                match app.state.table_viewer_state.copy_row_csv() {
-                    Ok(()) => {
+                let copied = match app.state.load_full_cell_values(true).await {
+                    Ok(()) => app
+                        .state
+                        .table_viewer_state
+                        .copy_row_csv(&app.state.clipboard),
+                    Err(e) => Err(e),
+                };
+                match copied {
+                    Ok(backend) => {
                         app.state
                             .toast_manager
-                            .success("Row copied to clipboard (CSV format)");
+                            .success(copied_message("Row (CSV format)", backend));
                     }
                     Err(e) => {
                         app.state
@@ -175,9 +472,9 @@ pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
             } else {
                 // First 'y' press - record timestamp
                 app.state.table_viewer_state.last_y_press = Some(now);
-                app.state
-                    .toast_manager
-                    .info("Press 'y' again to copy row, or 'c' to copy cell");
+                app.state.toast_manager.info(
+                    "Press 'y' again to copy row, 'j' to copy it as JSON, or 'c' to copy cell",
+                );
             }
         }
         // '/' - Enter search mode
@@ -232,6 +529,35 @@ pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
         KeyCode::Char('h') | KeyCode::Left => {
             app.state.move_left();
         }
+        // 'yj' - Copy the row as a JSON object
+        KeyCode::Char('j')
+            if app
+                .state
+                .table_viewer_state
+                .last_y_press
+                .is_some_and(|last_press| last_press.elapsed().as_millis() < 500) =>
+        {
+            let copied = match app.state.load_full_cell_values(true).await {
+                Ok(()) => app
+                    .state
+                    .table_viewer_state
+                    .copy_row_json(&app.state.clipboard),
+                Err(e) => Err(e),
+            };
+            match copied {
+                Ok(backend) => {
+                    app.state
+                        .toast_manager
+                        .success(copied_message("Row (JSON object)", backend));
+                }
+                Err(e) => {
+                    app.state
+                        .toast_manager
+                        .error(format!("Failed to copy row: {e}"));
+                }
+            }
+            app.state.table_viewer_state.last_y_press = None;
+        }
         KeyCode::Char('j') | KeyCode::Down => {
             app.state.move_down();
         }
@@ -314,9 +640,142 @@ pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
     Ok(())
 }
 
+/// Load the page after or before the one a table preview shows
+async fn fetch_preview_page(app: &mut App, forward: bool) {
+    let Some(tab) = app.state.table_viewer_state.current_tab_mut() else {
+        return;
+    };
+    let moved = if forward {
+        tab.next_page()
+    } else {
+        tab.prev_page()
+    };
+    if !moved {
+        let edge = if forward { "last" } else { "first" };
+        app.state
+            .toast_manager
+            .info(format!("Already on the {edge} page"));
+        return;
+    }
+    let tab_idx = app.state.table_viewer_state.active_tab;
+    if let Err(e) = app.state.load_table_data(tab_idx).await {
+        app.state
+            .toast_manager
+            .error(format!("Failed to load page: {e}"));
+    }
+}
+
+/// Move through and fold a JSON document result. Returns whether the key was
+/// handled; other keys work as in the grid
+fn handle_json_view(app: &mut App, key: KeyEvent) -> bool {
+    let pending_gg = app.state.ui.pending_gg_command;
+    let Some(view) = app
+        .state
+        .table_viewer_state
+        .current_tab_mut()
+        .filter(|tab| tab.shows_json())
+        .and_then(|tab| tab.json_view.as_mut())
+    else {
+        return false;
+    };
+
+    let half_page = (view.viewport_height / 2).max(1);
+    match key.code {
+        KeyCode::Char('j') | KeyCode::Down => view.move_down(1),
+        KeyCode::Char('k') | KeyCode::Up => view.move_up(1),
+        KeyCode::Char('d') if key.modifiers == KeyModifiers::CONTROL => view.move_down(half_page),
+        KeyCode::Char('u') if key.modifiers == KeyModifiers::CONTROL => view.move_up(half_page),
+        KeyCode::Enter | KeyCode::Char(' ') => view.toggle_fold(),
+        KeyCode::Char('G') => view.jump_to_bottom(),
+        KeyCode::Char('g') => {
+            if pending_gg {
+                view.jump_to_top();
+            }
+            app.state.ui.pending_gg_command = !pending_gg;
+            return true;
+        }
+        _ => return false,
+    }
+    app.state.ui.cancel_pending_gg();
+    true
+}
+
+/// Move through a query plan result. Returns whether the key was handled;
+/// other keys work as in the grid
+fn handle_plan_view(app: &mut App, key: KeyEvent) -> bool {
+    let pending_gg = app.state.ui.pending_gg_command;
+    let Some(view) = app
+        .state
+        .table_viewer_state
+        .current_tab_mut()
+        .filter(|tab| tab.shows_plan())
+        .and_then(|tab| tab.plan_view.as_mut())
+    else {
+        return false;
+    };
+
+    let half_page = (view.viewport_height / 2).max(1);
+    match key.code {
+        KeyCode::Char('j') | KeyCode::Down => view.move_down(1),
+        KeyCode::Char('k') | KeyCode::Up => view.move_up(1),
+        KeyCode::Char('d') if key.modifiers == KeyModifiers::CONTROL => view.move_down(half_page),
+        KeyCode::Char('u') if key.modifiers == KeyModifiers::CONTROL => view.move_up(half_page),
+        KeyCode::Enter => view.jump_to_hottest(),
+        KeyCode::Char('G') => view.jump_to_bottom(),
+        KeyCode::Char('g') => {
+            if pending_gg {
+                view.jump_to_top();
+            }
+            app.state.ui.pending_gg_command = !pending_gg;
+            return true;
+        }
+        _ => return false,
+    }
+    app.state.ui.cancel_pending_gg();
+    true
+}
+
+/// Start or stop re-running the current query result's query
+fn toggle_watch(app: &mut App) {
+    let interval = app.state.watch_interval;
+    let Some(tab) = app.state.table_viewer_state.current_tab_mut() else {
+        return;
+    };
+    let (Some(query), Some(_)) = (tab.query.as_deref(), tab.query_connection.as_ref()) else {
+        app.state
+            .toast_manager
+            .warning("Only query results can be watched");
+        return;
+    };
+    // Every refresh runs the query again, so it must not change anything
+    if tab.watch.is_none() && !transaction::is_read_only(query) {
+        app.state.toast_manager.warning(
+            "Only read-only queries (SELECT, SHOW, EXPLAIN...) can be watched; this one would run its changes on every refresh",
+        );
+        return;
+    }
+
+    if tab.watch.take().is_some() {
+        app.state.toast_manager.info("Stopped watching query");
+    } else {
+        tab.watch = Some(QueryWatch::new(interval, std::time::Instant::now()));
+        app.state.toast_manager.info(format!(
+            "Watching query, refreshing every {}s",
+            interval.as_secs()
+        ));
+    }
+}
+
 /// Handle table viewer edit mode keys
 async fn handle_edit_mode(app: &mut App, key: KeyEvent) -> Result<()> {
+    let edit = super::readline_edit(app, &key);
     if let Some(tab) = app.state.table_viewer_state.current_tab_mut() {
+        if let Some(edit) = edit {
+            for _ in 0..edit.erased(&tab.edit_buffer) {
+                tab.edit_buffer.pop();
+            }
+            return Ok(());
+        }
         match key.code {
             KeyCode::Esc | KeyCode::Enter => {
                 // Save edit
@@ -348,7 +807,18 @@ async fn handle_edit_mode(app: &mut App, key: KeyEvent) -> Result<()> {
 
 /// Handle table viewer search mode keys
 async fn handle_search_mode(app: &mut App, key: KeyEvent) -> Result<()> {
+    let edit = super::readline_edit(app, &key);
     if let Some(tab) = app.state.table_viewer_state.current_tab_mut() {
+        if let Some(edit) = edit {
+            let erased = edit.erased(&tab.search_query);
+            if erased > 0 {
+                for _ in 0..erased {
+                    tab.search_query.pop();
+                }
+                tab.update_search(&tab.search_query.clone());
+            }
+            return Ok(());
+        }
         match key.code {
             KeyCode::Esc => {
                 tab.cancel_search();
@@ -375,3 +845,77 @@ async fn handle_search_mode(app: &mut App, key: KeyEvent) -> Result<()> {
     }
     Ok(())
 }
+
+/// Handle the keys typing the name of a column to jump to
+fn handle_column_jump(app: &mut App, key: KeyEvent) {
+    let edit = super::readline_edit(app, &key);
+    let Some(tab) = app.state.table_viewer_state.current_tab_mut() else {
+        return;
+    };
+    let Some(mut query) = tab.column_jump.as_ref().map(|jump| jump.query.clone()) else {
+        return;
+    };
+    if let Some(edit) = edit {
+        for _ in 0..edit.erased(&query) {
+            query.pop();
+        }
+        tab.update_column_jump(query);
+        return;
+    }
+    match key.code {
+        KeyCode::Enter => tab.finish_column_jump(true),
+        KeyCode::Esc => tab.finish_column_jump(false),
+        KeyCode::Backspace => {
+            query.pop();
+            tab.update_column_jump(query);
+        }
+        KeyCode::Char(c) if !key.modifiers.contains(KeyModifiers::CONTROL) => {
+            query.push(c);
+            tab.update_column_jump(query);
+        }
+        _ => {}
+    }
+}
+
+/// Toast text for a successful copy. OSC52 can't confirm delivery, so say
+/// the value went to the terminal rather than claiming it is on the clipboard
+/// `column IN (...)` over the marked cells (or the selected cell), quoted for
+/// the connected database
+fn marked_in_clause(app: &mut App) -> Option<String> {
+    let database_type = app
+        .state
+        .get_selected_connection()
+        .map(|connection| connection.database_type.clone())
+        .unwrap_or(crate::database::DatabaseType::PostgreSQL);
+    let clause = app
+        .state
+        .table_viewer_state
+        .current_tab()
+        .filter(|tab| tab.view_mode == crate::ui::components::table_viewer::TableViewMode::Data)
+        .and_then(|tab| tab.marked_values())
+        .and_then(|(column, values)| {
+            crate::database::in_clause(&database_type, &column.name, &column.data_type, &values)
+        });
+    if clause.is_none() {
+        app.state
+            .toast_manager
+            .info("No cells to build an IN clause from");
+    }
+    clause
+}
+
+/// Whether the key reloads, pages, edits or watches the tab's rows. Paging,
+/// filters and sorting of table previews check for a preview themselves
+fn changes_rows(key: &KeyEvent) -> bool {
+    matches!(
+        key.code,
+        KeyCode::Char('d' | 'u' | 'i' | 'a' | 'A' | 'r' | 'w' | 'F') | KeyCode::Enter
+    )
+}
+
+pub(crate) fn copied_message(what: &str, backend: ClipboardBackend) -> String {
+    match backend {
+        ClipboardBackend::Native => format!("{what} copied to clipboard"),
+        ClipboardBackend::Osc52 => format!("{what} sent to the terminal clipboard (OSC52)"),
+    }
+}
@@ -0,0 +1,175 @@
+// FilePath: src/app/handlers/save_cell.rs
+//
+// Event handlers for saving a binary cell to a file
+
+#![forbid(unsafe_code)]
+
+use crate::{
+    app::App,
+    core::error::Result,
+    database::{literal::is_binary_type, preview, NULL_CELL},
+    io::blob,
+    ui::components::{
+        path_input::{self, PathKind},
+        SaveCellForm,
+    },
+};
+use crossterm::event::{KeyCode, KeyEvent};
+
+/// Open the prompt saving the selected cell, when it's a binary one of a
+/// table preview that can be read again by primary key
+pub(crate) fn open(app: &mut App) {
+    let Some(tab) = app.state.table_viewer_state.current_tab() else {
+        return;
+    };
+    let (row, col) = (tab.selected_row, tab.selected_col);
+    let Some(column) = tab.columns.get(col) else {
+        return;
+    };
+    if tab.rows.get(row).is_none() {
+        return;
+    }
+    if !is_binary_type(&column.data_type) {
+        app.state
+            .toast_manager
+            .info("Only bytea and blob cells are saved to a file; y copies others");
+        return;
+    }
+    if !tab.is_table_preview() || tab.primary_key_columns.is_empty() {
+        app.state
+            .toast_manager
+            .warning("Only a table preview with a primary key can read the cell in full");
+        return;
+    }
+    if tab.get_cell_value(row, col) == NULL_CELL {
+        app.state.toast_manager.info("The cell is NULL");
+        return;
+    }
+
+    let key = tab
+        .key_cells(row)
+        .into_iter()
+        .map(|cell| (cell.column, cell.data_type, cell.value.unwrap_or_default()))
+        .collect();
+    let form = SaveCellForm::new(&tab.table_name, &column.name, key);
+    app.state.table_viewer_state.save_cell_form = Some(form);
+}
+
+/// Handle the prompt: typing edits the path, Tab completes it, Enter saves
+pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
+    let edit = super::readline_edit(app, &key);
+    let Some(form) = app.state.table_viewer_state.save_cell_form.as_mut() else {
+        return Ok(());
+    };
+    if let Some(edit) = edit {
+        for _ in 0..edit.erased(&form.path) {
+            form.path.pop();
+        }
+        return Ok(());
+    }
+    match key.code {
+        KeyCode::Esc => app.state.table_viewer_state.save_cell_form = None,
+        KeyCode::Tab => {
+            if let Some(completion) = path_input::complete(&form.path) {
+                form.path = completion.text.clone();
+                if let Some(hint) = completion.hint() {
+                    app.state.toast_manager.info(hint);
+                }
+            }
+        }
+        KeyCode::Backspace => {
+            form.path.pop();
+        }
+        KeyCode::Char(c) => form.path.push(c),
+        KeyCode::Enter => save(app).await,
+        _ => {}
+    }
+    Ok(())
+}
+
+/// Read the cell in full as hex and write its bytes to the typed path,
+/// reporting the size and SHA-256. The prompt stays open on an error
+async fn save(app: &mut App) {
+    let Some(form) = app.state.table_viewer_state.save_cell_form.clone() else {
+        return;
+    };
+    let path = match path_input::validate(&form.path, PathKind::Save) {
+        Ok(path) => path,
+        Err(e) => {
+            app.state.toast_manager.error(e);
+            return;
+        }
+    };
+    let Some(connection) = app
+        .state
+        .get_selected_connection()
+        .filter(|connection| connection.is_connected())
+        .cloned()
+    else {
+        app.state.toast_manager.error("Not connected to database");
+        return;
+    };
+
+    let key: Vec<(&str, &str, &str)> = form
+        .key
+        .iter()
+        .map(|(column, data_type, value)| (column.as_str(), data_type.as_str(), value.as_str()))
+        .collect();
+    let query = preview::hex_value_query(
+        &connection.database_type,
+        &form.table_name,
+        &form.column,
+        &key,
+    );
+    let hex = match app
+        .state
+        .connection_manager
+        .execute_metadata_query(&connection.id, "save_cell", &query)
+        .await
+    {
+        Ok((_, rows)) => rows
+            .into_iter()
+            .next()
+            .and_then(|row| row.into_iter().next()),
+        Err(e) => {
+            app.state
+                .toast_manager
+                .error(format!("Failed to read the cell: {e}"));
+            return;
+        }
+    };
+    let hex = match hex.as_deref() {
+        None => {
+            app.state
+                .toast_manager
+                .error("Row no longer exists; reload the table");
+            return;
+        }
+        Some("NULL") => {
+            app.state.toast_manager.info("The cell is NULL now");
+            return;
+        }
+        Some(hex) => hex.to_string(),
+    };
+
+    match blob::save_hex(&path, &hex) {
+        Ok(saved) => {
+            app.state.table_viewer_state.save_cell_form = None;
+            crate::log_info!(
+                "Saved {}.{} to {}: {} bytes, SHA-256 {}",
+                form.table_name,
+                form.column,
+                path.display(),
+                saved.bytes,
+                saved.sha256
+            );
+            app.state.toast_manager.success(format!(
+                "Saved {} bytes to {} · SHA-256 {}",
+                saved.bytes,
+                path.display(),
+                saved.sha256
+            ));
+        }
+        Err(e) => app.state.toast_manager.error(e),
+    }
+}
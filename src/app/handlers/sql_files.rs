@@ -4,7 +4,7 @@
 
 #![forbid(unsafe_code)]
 
-use crate::{app::App, core::error::Result};
+use crate::{app::App, core::error::Result, state::ui::sql_file_name};
 use crossterm::event::{KeyCode, KeyEvent};
 
 /// Handle SQL Files pane keys - DIRECT KEY BINDINGS
@@ -24,13 +24,7 @@ pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
     match key.code {
         // Enter - Load selected SQL file
         KeyCode::Enter => {
-            if let Err(e) = app.state.load_selected_sql_file() {
-                app.state
-                    .toast_manager
-                    .error(format!("Failed to load SQL file: {e}"));
-            } else {
-                app.state.toast_manager.success("SQL file loaded");
-            }
+            load_selected_file(app);
         }
         // 'n' - Create new file
         KeyCode::Char('n') => {
@@ -75,8 +69,66 @@ pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
     Ok(())
 }
 
+/// Load the selected SQL file into the query editor, asking first when that
+/// would discard unsaved changes
+fn load_selected_file(app: &mut App) {
+    if app.state.query_editor.is_modified() {
+        if let Some(filename) = app.state.get_selected_sql_file() {
+            app.state.ui.confirmation_modal = Some(crate::ui::ConfirmationModal {
+                title: "Unsaved Changes".to_string(),
+                message: format!(
+                    "The query editor has unsaved changes.\n\nDiscard them and load '{filename}'?"
+                ),
+                action: crate::ui::ConfirmationAction::LoadSqlFile(filename),
+            });
+            return;
+        }
+    }
+
+    let result = app.state.load_selected_sql_file();
+    report_load_result(app, result);
+}
+
+/// Show a toast for the outcome of loading a SQL file
+pub(crate) fn report_load_result(
+    app: &mut App,
+    result: std::result::Result<(), Box<dyn std::error::Error>>,
+) {
+    if let Err(e) = result {
+        app.state
+            .toast_manager
+            .error(format!("Failed to load SQL file: {e}"));
+    } else {
+        app.state.toast_manager.success("SQL file loaded");
+    }
+}
+
+/// The name typed for a new or renamed file, or None with a toast when
+/// it's unsafe or taken
+fn new_file_name(app: &mut App, typed: &str) -> Option<String> {
+    match sql_file_name(typed) {
+        Ok(name) if app.state.saved_sql_files.contains(&name) => {
+            app.state
+                .toast_manager
+                .error(format!("'{name}' already exists"));
+            None
+        }
+        Ok(name) => Some(name),
+        Err(e) => {
+            app.state.toast_manager.error(e);
+            None
+        }
+    }
+}
+
 /// Handle SQL files search mode
 async fn handle_search_mode(app: &mut App, key: KeyEvent) -> Result<()> {
+    if let Some(edit) = super::readline_edit(app, &key) {
+        for _ in 0..edit.erased(&app.state.ui.sql_files_search_query) {
+            app.state.ui.backspace_sql_files_search();
+        }
+        return Ok(());
+    }
     match key.code {
         KeyCode::Esc => {
             app.state.ui.exit_sql_files_search();
@@ -85,13 +137,7 @@ async fn handle_search_mode(app: &mut App, key: KeyEvent) -> Result<()> {
             app.state.ui.backspace_sql_files_search();
         }
         KeyCode::Enter => {
-            if let Err(e) = app.state.load_selected_sql_file() {
-                app.state
-                    .toast_manager
-                    .error(format!("Failed to load SQL file: {e}"));
-            } else {
-                app.state.toast_manager.success("SQL file loaded");
-            }
+            load_selected_file(app);
             app.state.ui.exit_sql_files_search();
         }
         KeyCode::Down | KeyCode::Char('j') => {
@@ -110,6 +156,12 @@ async fn handle_search_mode(app: &mut App, key: KeyEvent) -> Result<()> {
 
 /// Handle SQL files rename mode
 async fn handle_rename_mode(app: &mut App, key: KeyEvent) -> Result<()> {
+    if let Some(edit) = super::readline_edit(app, &key) {
+        for _ in 0..edit.erased(&app.state.ui.sql_files_rename_buffer) {
+            app.state.ui.backspace_sql_files_rename();
+        }
+        return Ok(());
+    }
     match key.code {
         KeyCode::Esc => {
             app.state.ui.exit_sql_files_rename();
@@ -118,8 +170,8 @@ async fn handle_rename_mode(app: &mut App, key: KeyEvent) -> Result<()> {
             app.state.ui.backspace_sql_files_rename();
         }
         KeyCode::Enter => {
-            let new_name = app.state.ui.sql_files_rename_buffer.clone();
-            if !new_name.is_empty() {
+            let typed = app.state.ui.sql_files_rename_buffer.clone();
+            if let Some(new_name) = new_file_name(app, &typed) {
                 let filtered_files = app.state.get_filtered_sql_files();
                 let selected_index = app.state.get_filtered_sql_file_selection();
                 if let Some(old_name) = filtered_files.get(selected_index) {
@@ -148,6 +200,12 @@ async fn handle_rename_mode(app: &mut App, key: KeyEvent) -> Result<()> {
 
 /// Handle SQL files create mode
 async fn handle_create_mode(app: &mut App, key: KeyEvent) -> Result<()> {
+    if let Some(edit) = super::readline_edit(app, &key) {
+        for _ in 0..edit.erased(&app.state.ui.sql_files_create_buffer) {
+            app.state.ui.backspace_sql_files_create();
+        }
+        return Ok(());
+    }
     match key.code {
         KeyCode::Esc => {
             app.state.ui.exit_sql_files_create();
@@ -156,8 +214,8 @@ async fn handle_create_mode(app: &mut App, key: KeyEvent) -> Result<()> {
             app.state.ui.backspace_sql_files_create();
         }
         KeyCode::Enter => {
-            let filename = app.state.ui.sql_files_create_buffer.clone();
-            if !filename.is_empty() {
+            let typed = app.state.ui.sql_files_create_buffer.clone();
+            if let Some(filename) = new_file_name(app, &typed) {
                 if let Err(e) = app.state.create_sql_file(&filename).await {
                     app.state
                         .toast_manager
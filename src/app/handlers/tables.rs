@@ -4,13 +4,135 @@
 
 #![forbid(unsafe_code)]
 
-use crate::{app::App, core::error::Result};
+use crate::{
+    app::{debounce::SELECTION_DEBOUNCE, App, MetadataEvent, PreviewEvent},
+    core::error::Result,
+    database::DatabaseType,
+    state::DatabaseState,
+    ui::components::TableViewerState,
+};
 use crossterm::event::{KeyCode, KeyEvent, KeyModifiers};
+use std::time::Duration;
 
 /// Handle Tables pane keys - DIRECT KEY BINDINGS
 pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
+    let previous_table = app.state.ui.get_selected_table_name();
+    handle_key(app, key).await?;
+    // Enter opens the table, which loads its metadata directly
+    if key.code != KeyCode::Enter && app.state.ui.get_selected_table_name() != previous_table {
+        selection_changed(app);
+    }
+    Ok(())
+}
+
+/// Show the newly selected table in the details pane once the selection has
+/// rested, so scrolling through the list doesn't fetch every table passed
+pub(crate) fn selection_changed(app: &mut App) {
+    app.state.emit_table_changed();
+
+    let Some(table_name) = app.state.ui.get_selected_table_name() else {
+        app.metadata_fetch.cancel();
+        return;
+    };
+    let Some(connection_id) = app
+        .state
+        .get_selected_connection()
+        .filter(|connection| connection.is_connected())
+        .map(|connection| connection.id.clone())
+    else {
+        app.metadata_fetch.cancel();
+        return;
+    };
+
+    let connection_manager = app.state.connection_manager.clone();
+    let tx = app.metadata_events_tx.clone();
+    let generation =
+        app.metadata_fetch
+            .schedule(SELECTION_DEBOUNCE, move |generation| async move {
+                let result = connection_manager
+                    .get_table_metadata(&connection_id, &table_name)
+                    .await
+                    .map_err(|e| e.to_string());
+                let _ = tx.send(MetadataEvent { generation, result });
+            });
+    app.metadata_wanted = Some(generation);
+}
+
+/// Open the selected table, reading its first page in the background so the
+/// interface stays responsive; opening it again supersedes the read
+pub(crate) async fn open_selected_table(app: &mut App) {
+    // Opening loads the metadata itself
+    app.metadata_fetch.cancel();
+    let Some(tab) = app.state.start_table_preview().await else {
+        return;
+    };
+
+    let table_name = tab.table_name.clone();
+    let mut db = DatabaseState {
+        connections: app.state.db.connections.clone(),
+        ..Default::default()
+    };
+    let selected_connection = app.state.ui.selected_connection;
+    let connection_manager = app.state.connection_manager.clone();
+    let tx = app.preview_events_tx.clone();
+    app.table_previews
+        .schedule(&table_name, Duration::ZERO, move |generation| async move {
+            let mut viewer = TableViewerState::new();
+            viewer.tabs.push(tab);
+            let result = db
+                .load_table_data(&mut viewer, selected_connection, 0, &connection_manager)
+                .await
+                .map(|()| viewer.tabs.remove(0));
+            let _ = tx.send(PreviewEvent {
+                generation,
+                table_name,
+                result,
+            });
+        });
+}
+
+/// Dispatch a Tables pane key
+async fn handle_key(app: &mut App, key: KeyEvent) -> Result<()> {
+    // Column search prompt open
+    if app.state.ui.column_search_active {
+        if let Some(edit) = super::readline_edit(app, &key) {
+            for _ in 0..edit.erased(&app.state.ui.column_search_query) {
+                app.state.ui.column_search_query.pop();
+            }
+            return Ok(());
+        }
+        match key.code {
+            KeyCode::Esc => {
+                app.state.ui.column_search_active = false;
+            }
+            KeyCode::Backspace => {
+                app.state.ui.column_search_query.pop();
+            }
+            KeyCode::Enter => {
+                app.state.ui.column_search_active = false;
+                app.state.search_columns().await;
+            }
+            KeyCode::Char(c) => {
+                app.state.ui.column_search_query.push(c);
+            }
+            _ => {}
+        }
+        return Ok(());
+    }
+
+    // Database name prompt open
+    if app.state.ui.database_prompt.is_some() {
+        return handle_database_prompt(app, key).await;
+    }
+
     // Search mode active
     if app.state.ui.tables_search_active {
+        if let Some(edit) = super::readline_edit(app, &key) {
+            for _ in 0..edit.erased(&app.state.ui.tables_search_query) {
+                app.state.ui.backspace_tables_search();
+            }
+            return Ok(());
+        }
         match key.code {
             KeyCode::Esc => {
                 app.state.ui.exit_tables_search();
@@ -19,7 +141,7 @@ pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
                 app.state.ui.backspace_tables_search();
             }
             KeyCode::Enter => {
-                app.state.open_table_for_viewing().await;
+                open_selected_table(app).await;
                 app.state.ui.exit_tables_search();
             }
             KeyCode::Down => {
@@ -40,10 +162,11 @@ pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
     match key.code {
         // Enter or Space - Open table for viewing
         KeyCode::Enter | KeyCode::Char(' ') => {
-            app.state.open_table_for_viewing().await;
+            open_selected_table(app).await;
         }
         // 'r' - Refresh tables list
         KeyCode::Char('r') => {
+            app.table_previews.cancel_all();
             app.state.connect_to_selected_database().await;
             app.state.toast_manager.info("Tables refreshed");
         }
@@ -51,6 +174,34 @@ pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
         KeyCode::Char('/') => {
             app.state.ui.enter_tables_search();
         }
+        // '.' - Show or hide system schemas and tables
+        KeyCode::Char('.') => {
+            app.metadata_fetch.cancel();
+            app.state.toggle_system_objects().await;
+        }
+        // 'i' - Inspect the table's columns, keys and indexes
+        KeyCode::Char('i') => {
+            app.state.inspect_selected_table().await;
+        }
+        // 'c' - Find a column name in every table
+        KeyCode::Char('c') => {
+            app.state.ui.column_search_active = true;
+            app.state.ui.column_search_query.clear();
+        }
+        // 'u' - Type the name of a database to use
+        KeyCode::Char('u') if key.modifiers == KeyModifiers::NONE => {
+            let switchable = app
+                .state
+                .get_selected_connection()
+                .is_some_and(|connection| connection.database_type != DatabaseType::SQLite);
+            if switchable {
+                app.state.ui.database_prompt = Some(String::new());
+            } else {
+                app.state.toast_manager.info(
+                    "A SQLite connection has a single database; edit it to open another file",
+                );
+            }
+        }
         // j/k - Navigate
         KeyCode::Char('j') | KeyCode::Down => {
             app.state.ui.table_search_selection_down();
@@ -116,3 +267,37 @@ pub(crate) async fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
     }
     Ok(())
 }
+
+/// Handle the prompt for a database name, connecting to it on Enter
+async fn handle_database_prompt(app: &mut App, key: KeyEvent) -> Result<()> {
+    let edit = super::readline_edit(app, &key);
+    let Some(name) = app.state.ui.database_prompt.as_mut() else {
+        return Ok(());
+    };
+    if let Some(edit) = edit {
+        for _ in 0..edit.erased(name) {
+            name.pop();
+        }
+        return Ok(());
+    }
+    match key.code {
+        KeyCode::Esc => app.state.ui.database_prompt = None,
+        KeyCode::Backspace => {
+            name.pop();
+        }
+        KeyCode::Char(c) => name.push(c),
+        KeyCode::Enter => {
+            let name = name.trim().to_string();
+            if name.is_empty() {
+                app.state.toast_manager.warning("Enter a database name");
+                return Ok(());
+            }
+            app.state.ui.database_prompt = None;
+            app.metadata_fetch.cancel();
+            app.table_previews.cancel_all();
+            app.state.use_database(&name).await;
+        }
+        _ => {}
+    }
+    Ok(())
+}
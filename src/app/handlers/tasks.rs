@@ -0,0 +1,63 @@
+// FilePath: src/app/handlers/tasks.rs
+//
+// Event handlers for the background tasks overlay
+
+#![forbid(unsafe_code)]
+
+use crate::{
+    app::{App, OverlayView},
+    core::error::Result,
+    ui::components::operation,
+};
+use crossterm::event::{KeyCode, KeyEvent};
+
+/// Open the overlay, or close it when it's showing
+pub(crate) fn toggle(app: &mut App) {
+    if app.state.ui.current_view.is_tasks() {
+        app.state.ui.return_to_main();
+    } else {
+        app.state.ui.tasks_selected = 0;
+        app.state.ui.show_overlay(OverlayView::Tasks);
+    }
+}
+
+/// Handle overlay keys
+pub(crate) fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
+    let count = app.state.spinner.tasks().len();
+    let selected = &mut app.state.ui.tasks_selected;
+    match key.code {
+        KeyCode::Char('j') | KeyCode::Down if *selected + 1 < count => *selected += 1,
+        KeyCode::Char('k') | KeyCode::Up => *selected = selected.saturating_sub(1),
+        KeyCode::Char('x') => {
+            let label = app
+                .state
+                .spinner
+                .tasks()
+                .get(*selected)
+                .map(|(label, _)| label.to_string());
+            if let Some(label) = label {
+                stop(app, &label);
+            }
+        }
+        _ => {}
+    }
+    // The list shrinks as operations finish
+    let count = app.state.spinner.tasks().len();
+    app.state.ui.tasks_selected = app.state.ui.tasks_selected.min(count.saturating_sub(1));
+    Ok(())
+}
+
+/// Stop a running operation the way its own key does
+fn stop(app: &mut App, label: &str) {
+    match label {
+        operation::CONNECTING => super::connections::cancel_connecting(app),
+        operation::TESTING_CONNECTION => super::connections::abort_test_connection(app),
+        operation::RUNNING_QUERY => super::query_editor::cancel_query(app),
+        operation::EXPORTING_ROWS => super::overlays::stop_filtered_export(app),
+        operation::PINGING_CONNECTIONS => super::connections::stop_pinging(app),
+        _ => app
+            .state
+            .toast_manager
+            .warning(format!("{label} can't be stopped")),
+    }
+}
@@ -0,0 +1,54 @@
+// FilePath: src/app/handlers/undo_log.rs
+//
+// Event handlers for the undo log overlay
+
+#![forbid(unsafe_code)]
+
+use crate::{
+    app::{App, OverlayView},
+    core::error::Result,
+    ui::{ConfirmationAction, ConfirmationModal},
+};
+use crossterm::event::{KeyCode, KeyEvent};
+
+/// Open the undo log on its newest entry
+pub(crate) fn open(app: &mut App) {
+    let log = &mut app.state.undo_log;
+    log.selected = log.entries().len().saturating_sub(1);
+    app.state.ui.show_overlay(OverlayView::UndoLog);
+}
+
+/// Handle undo log keys; the list runs newest first, so j moves back in time
+pub(crate) fn handle(app: &mut App, key: KeyEvent) -> Result<()> {
+    let log = &mut app.state.undo_log;
+    match key.code {
+        KeyCode::Char('j') | KeyCode::Down => log.selected = log.selected.saturating_sub(1),
+        KeyCode::Char('k') | KeyCode::Up if log.selected + 1 < log.entries().len() => {
+            log.selected += 1
+        }
+        KeyCode::Enter => {
+            let selected = log.selected;
+            let Some(entry) = log.get(selected) else {
+                return Ok(());
+            };
+            if entry.applied {
+                app.state
+                    .toast_manager
+                    .info("That change has been undone already");
+                return Ok(());
+            }
+            let message = format!(
+                "Undo \"{}\" by running\n\n{}",
+                entry.description, entry.inverse
+            );
+            app.state.ui.return_to_main();
+            app.state.ui.confirmation_modal = Some(ConfirmationModal {
+                title: "Undo change".to_string(),
+                message,
+                action: ConfirmationAction::ApplyUndo(selected),
+            });
+        }
+        _ => {}
+    }
+    Ok(())
+}
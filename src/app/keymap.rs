@@ -0,0 +1,519 @@
+// FilePath: src/app/keymap.rs
+
+//! Remappable keys of each pane's main actions
+//!
+//! Every pane names its main actions and their default keys. An action bound
+//! to another key under `[keybindings.<pane>]` runs on that key instead: the
+//! pane is handed the default key, and pressing the default key itself does
+//! nothing there anymore. The same bindings make the key hints under each
+//! pane, so they show the keys actually in use.
+//!
+//! `[keybindings.global]` binds the keys moving focus between panes and the
+//! one cancelling the running query, and `focus_order` the panes the focus
+//! keys cycle through.
+
+#![forbid(unsafe_code)]
+
+use crate::{app::FocusedPane, config::KeybindingsConfig};
+use crossterm::event::{KeyCode, KeyEvent, KeyModifiers};
+use std::{collections::BTreeMap, fmt};
+
+/// A key, with Ctrl or Alt held or not
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub struct KeySpec {
+    pub code: KeyCode,
+    pub modifiers: KeyModifiers,
+}
+
+impl KeySpec {
+    const fn plain(code: KeyCode) -> Self {
+        Self {
+            code,
+            modifiers: KeyModifiers::NONE,
+        }
+    }
+
+    const fn char(c: char) -> Self {
+        Self::plain(KeyCode::Char(c))
+    }
+
+    const fn ctrl(c: char) -> Self {
+        Self {
+            code: KeyCode::Char(c),
+            modifiers: KeyModifiers::CONTROL,
+        }
+    }
+
+    /// Parse a key as written in the config: `a`, `E`, `enter`, `f5`,
+    /// `ctrl+r` or `alt+enter`
+    pub fn parse(spec: &str) -> Option<Self> {
+        // Terminals report Shift+Tab as a key of its own
+        if spec.trim().eq_ignore_ascii_case("shift+tab") {
+            return Some(Self::plain(KeyCode::BackTab));
+        }
+        let (prefix, name) = spec.trim().rsplit_once('+').unwrap_or(("", spec.trim()));
+        let mut modifiers = KeyModifiers::NONE;
+        for modifier in prefix.split('+').filter(|part| !part.is_empty()) {
+            modifiers |= match modifier.to_lowercase().as_str() {
+                "ctrl" | "control" => KeyModifiers::CONTROL,
+                "alt" => KeyModifiers::ALT,
+                _ => return None,
+            };
+        }
+
+        let mut chars = name.chars();
+        let code = match (chars.next(), chars.next()) {
+            // Terminals report Ctrl with a letter in lower case
+            (Some(c), None) if modifiers.contains(KeyModifiers::CONTROL) => {
+                KeyCode::Char(c.to_ascii_lowercase())
+            }
+            (Some(c), None) => KeyCode::Char(c),
+            _ => match name.to_lowercase().as_str() {
+                "enter" | "return" => KeyCode::Enter,
+                "tab" => KeyCode::Tab,
+                "backtab" => KeyCode::BackTab,
+                "esc" | "escape" => KeyCode::Esc,
+                "space" => KeyCode::Char(' '),
+                "backspace" => KeyCode::Backspace,
+                "delete" | "del" => KeyCode::Delete,
+                "insert" => KeyCode::Insert,
+                "home" => KeyCode::Home,
+                "end" => KeyCode::End,
+                "pageup" => KeyCode::PageUp,
+                "pagedown" => KeyCode::PageDown,
+                "up" => KeyCode::Up,
+                "down" => KeyCode::Down,
+                "left" => KeyCode::Left,
+                "right" => KeyCode::Right,
+                name => KeyCode::F(name.strip_prefix('f')?.parse().ok()?),
+            },
+        };
+        Some(Self { code, modifiers })
+    }
+
+    /// Whether `key` is this key. Shift only makes a letter upper case, so
+    /// it is ignored
+    pub fn matches(&self, key: &KeyEvent) -> bool {
+        key.code == self.code && key.modifiers.difference(KeyModifiers::SHIFT) == self.modifiers
+    }
+
+    fn event(&self) -> KeyEvent {
+        KeyEvent::new(self.code, self.modifiers)
+    }
+}
+
+impl fmt::Display for KeySpec {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        if self.modifiers.contains(KeyModifiers::CONTROL) {
+            write!(f, "ctrl+")?;
+        }
+        if self.modifiers.contains(KeyModifiers::ALT) {
+            write!(f, "alt+")?;
+        }
+        match self.code {
+            KeyCode::Char(' ') => write!(f, "space"),
+            KeyCode::BackTab => write!(f, "shift+tab"),
+            KeyCode::Char(c) => write!(f, "{c}"),
+            KeyCode::F(n) => write!(f, "f{n}"),
+            KeyCode::PageUp => write!(f, "pageup"),
+            KeyCode::PageDown => write!(f, "pagedown"),
+            code => write!(f, "{}", format!("{code:?}").to_lowercase()),
+        }
+    }
+}
+
+/// One of a pane's main actions
+#[derive(Debug)]
+pub struct Action {
+    /// Name of the action in the config, also shown in the key hints
+    pub name: &'static str,
+    /// The key the pane handles the action on
+    pub key: KeySpec,
+}
+
+const fn action(name: &'static str, key: KeySpec) -> Action {
+    Action { name, key }
+}
+
+const CONNECTIONS: &[Action] = &[
+    action("add", KeySpec::char('a')),
+    action("edit", KeySpec::char('e')),
+    action("delete", KeySpec::char('d')),
+    action("connect", KeySpec::plain(KeyCode::Enter)),
+];
+
+const TABLES: &[Action] = &[
+    action("open", KeySpec::plain(KeyCode::Enter)),
+    action("search", KeySpec::char('/')),
+    action("inspect", KeySpec::char('i')),
+    action("columns", KeySpec::char('c')),
+    action("refresh", KeySpec::char('r')),
+];
+
+const DETAILS: &[Action] = &[
+    action("down", KeySpec::char('j')),
+    action("up", KeySpec::char('k')),
+    action("bottom", KeySpec::char('G')),
+];
+
+const RESULTS: &[Action] = &[
+    action("edit", KeySpec::char('i')),
+    action("filter", KeySpec::char('f')),
+    action("sort", KeySpec::char('o')),
+    action("insert", KeySpec::char('a')),
+];
+
+const SQL_FILES: &[Action] = &[
+    action("open", KeySpec::plain(KeyCode::Enter)),
+    action("new", KeySpec::char('n')),
+    action("rename", KeySpec::char('r')),
+    action("delete", KeySpec::char('d')),
+];
+
+const QUERY_EDITOR: &[Action] = &[
+    action("insert", KeySpec::char('i')),
+    action("run", KeySpec::char('E')),
+    action("command", KeySpec::char(':')),
+    action("history", KeySpec::ctrl('r')),
+    action("save", KeySpec::ctrl('s')),
+];
+
+/// The pane's main actions, in the order of its key hints
+pub fn actions(pane: FocusedPane) -> &'static [Action] {
+    match pane {
+        FocusedPane::Connections => CONNECTIONS,
+        FocusedPane::Tables => TABLES,
+        FocusedPane::Details => DETAILS,
+        FocusedPane::TabularOutput => RESULTS,
+        FocusedPane::SqlFiles => SQL_FILES,
+        FocusedPane::QueryWindow => QUERY_EDITOR,
+    }
+}
+
+/// Name of the pane's `[keybindings.<pane>]` table
+pub fn section(pane: FocusedPane) -> &'static str {
+    match pane {
+        FocusedPane::Connections => "connections",
+        FocusedPane::Tables => "tables",
+        FocusedPane::Details => "details",
+        FocusedPane::TabularOutput => "results",
+        FocusedPane::SqlFiles => "sql_files",
+        FocusedPane::QueryWindow => "query_editor",
+    }
+}
+
+/// Panes in the order Tab cycles through them unless `focus_order` says
+/// otherwise
+const FOCUS_ORDER: [FocusedPane; 6] = [
+    FocusedPane::Connections,
+    FocusedPane::Tables,
+    FocusedPane::Details,
+    FocusedPane::TabularOutput,
+    FocusedPane::SqlFiles,
+    FocusedPane::QueryWindow,
+];
+
+/// An action bound away from its default key
+#[derive(Debug, Clone)]
+struct Binding {
+    pane: FocusedPane,
+    action: &'static Action,
+    key: KeySpec,
+}
+
+/// The keys of every pane's main actions, and of moving between panes
+#[derive(Debug, Clone)]
+pub struct Keymap {
+    bindings: Vec<Binding>,
+    next_pane: KeySpec,
+    previous_pane: KeySpec,
+    cancel_query: KeySpec,
+    /// Panes the focus keys cycle through, in order
+    focus_order: Vec<FocusedPane>,
+}
+
+impl Default for Keymap {
+    fn default() -> Self {
+        Self {
+            bindings: Vec::new(),
+            next_pane: KeySpec::plain(KeyCode::Tab),
+            previous_pane: KeySpec::plain(KeyCode::BackTab),
+            cancel_query: KeySpec::ctrl('x'),
+            focus_order: FOCUS_ORDER.to_vec(),
+        }
+    }
+}
+
+impl Keymap {
+    /// Keymap with the bindings of `[keybindings.<pane>]`, and what's wrong
+    /// with each binding left out
+    pub fn from_config(config: &KeybindingsConfig) -> (Self, Vec<String>) {
+        let panes: [(FocusedPane, &BTreeMap<String, String>); 6] = [
+            (FocusedPane::Connections, &config.connections),
+            (FocusedPane::Tables, &config.tables),
+            (FocusedPane::Details, &config.details),
+            (FocusedPane::TabularOutput, &config.results),
+            (FocusedPane::SqlFiles, &config.sql_files),
+            (FocusedPane::QueryWindow, &config.query_editor),
+        ];
+        let mut keymap = Self::default();
+        let mut problems = Vec::new();
+        for (pane, bound) in panes {
+            let section = section(pane);
+            for (name, spec) in bound {
+                let Some(action) = actions(pane).iter().find(|action| action.name == name) else {
+                    problems.push(format!("[keybindings.{section}] has no action {name:?}"));
+                    continue;
+                };
+                let Some(key) = KeySpec::parse(spec) else {
+                    problems.push(format!(
+                        "[keybindings.{section}] {name}: unknown key {spec:?}"
+                    ));
+                    continue;
+                };
+                if let Some(taken) = keymap.binding(pane, &key) {
+                    problems.push(format!(
+                        "[keybindings.{section}] {name}: {key} is already bound to {}",
+                        taken.action.name
+                    ));
+                    continue;
+                }
+                if key != action.key {
+                    keymap.bindings.push(Binding { pane, action, key });
+                }
+            }
+        }
+        keymap.bind_global_keys(config, &mut problems);
+        keymap.order_focus(config, &mut problems);
+        (keymap, problems)
+    }
+
+    /// Apply `[keybindings.global]`
+    fn bind_global_keys(&mut self, config: &KeybindingsConfig, problems: &mut Vec<String>) {
+        for (name, spec) in &config.global {
+            let Some(key) = KeySpec::parse(spec) else {
+                problems.push(format!("[keybindings.global] {name}: unknown key {spec:?}"));
+                continue;
+            };
+            let bound = [
+                ("next_pane", self.next_pane),
+                ("previous_pane", self.previous_pane),
+                ("cancel_query", self.cancel_query),
+            ];
+            if !bound.iter().any(|(action, _)| action == name) {
+                problems.push(format!("[keybindings.global] has no action {name:?}"));
+                continue;
+            }
+            if bound
+                .iter()
+                .any(|(action, other)| action != name && *other == key)
+            {
+                problems.push(format!(
+                    "[keybindings.global] {name}: {key} is already bound"
+                ));
+                continue;
+            }
+            match name.as_str() {
+                "next_pane" => self.next_pane = key,
+                "previous_pane" => self.previous_pane = key,
+                _ => self.cancel_query = key,
+            }
+        }
+    }
+
+    /// Apply `focus_order`, keeping the default order when it names no pane
+    fn order_focus(&mut self, config: &KeybindingsConfig, problems: &mut Vec<String>) {
+        let mut order = Vec::new();
+        for name in &config.focus_order {
+            match FOCUS_ORDER
+                .into_iter()
+                .find(|pane| section(*pane) == name.as_str())
+            {
+                Some(pane) if order.contains(&pane) => {
+                    problems.push(format!("[keybindings] focus_order lists {name:?} twice"));
+                }
+                Some(pane) => order.push(pane),
+                None => problems.push(format!("[keybindings] focus_order has no pane {name:?}")),
+            }
+        }
+        if !order.is_empty() {
+            self.focus_order = order;
+        }
+    }
+
+    /// Whether `key` moves focus to the next pane
+    pub fn is_next_pane(&self, key: &KeyEvent) -> bool {
+        self.next_pane.matches(key)
+    }
+
+    /// Whether `key` moves focus to the previous pane
+    pub fn is_previous_pane(&self, key: &KeyEvent) -> bool {
+        self.previous_pane.matches(key)
+    }
+
+    /// Whether `key` cancels the running query
+    pub fn is_cancel_query(&self, key: &KeyEvent) -> bool {
+        self.cancel_query.matches(key)
+    }
+
+    /// The key cancelling the running query
+    pub fn cancel_query_key(&self) -> KeySpec {
+        self.cancel_query
+    }
+
+    /// Panes the focus keys cycle through, in order
+    pub fn focus_order(&self) -> &[FocusedPane] {
+        &self.focus_order
+    }
+
+    fn binding(&self, pane: FocusedPane, key: &KeySpec) -> Option<&Binding> {
+        self.bindings
+            .iter()
+            .find(|binding| binding.pane == pane && binding.key == *key)
+    }
+
+    /// The key the pane handles `key` as: the default key of the action
+    /// bound to it, `key` itself, or None for the default key of an action
+    /// bound elsewhere
+    pub fn translate(&self, pane: FocusedPane, key: KeyEvent) -> Option<KeyEvent> {
+        let mut bound = self.bindings.iter().filter(|binding| binding.pane == pane);
+        if let Some(binding) = bound.clone().find(|binding| binding.key.matches(&key)) {
+            return Some(binding.action.key.event());
+        }
+        if bound.any(|binding| binding.action.key.matches(&key)) {
+            return None;
+        }
+        Some(key)
+    }
+
+    /// Key of each of the pane's main actions, for its key hints
+    pub fn hints(&self, pane: FocusedPane) -> Vec<(KeySpec, &'static str)> {
+        actions(pane)
+            .iter()
+            .map(|action| {
+                let key = self
+                    .bindings
+                    .iter()
+                    .find(|binding| binding.pane == pane && std::ptr::eq(binding.action, action))
+                    .map_or(action.key, |binding| binding.key);
+                (key, action.name)
+            })
+            .collect()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn config(connections: &[(&str, &str)]) -> KeybindingsConfig {
+        KeybindingsConfig {
+            connections: connections
+                .iter()
+                .map(|(name, key)| (name.to_string(), key.to_string()))
+                .collect(),
+            ..KeybindingsConfig::default()
+        }
+    }
+
+    #[test]
+    fn test_parse_keys() {
+        assert_eq!(KeySpec::parse("a"), Some(KeySpec::char('a')));
+        assert_eq!(
+            KeySpec::parse("Enter"),
+            Some(KeySpec::plain(KeyCode::Enter))
+        );
+        assert_eq!(KeySpec::parse("f5"), Some(KeySpec::plain(KeyCode::F(5))));
+        let ctrl_r = KeySpec::parse("ctrl+R").unwrap();
+        assert_eq!(ctrl_r.code, KeyCode::Char('r'));
+        assert_eq!(ctrl_r.modifiers, KeyModifiers::CONTROL);
+        assert_eq!(ctrl_r.to_string(), "ctrl+r");
+        assert_eq!(KeySpec::parse("hyper+a"), None);
+        assert_eq!(KeySpec::parse("enterr"), None);
+        assert!(KeySpec::char('E').matches(&KeyEvent::new(KeyCode::Char('E'), KeyModifiers::SHIFT)));
+    }
+
+    #[test]
+    fn test_remapped_keys_stand_in_for_the_defaults() {
+        let (keymap, problems) = Keymap::from_config(&config(&[("add", "n"), ("edit", "ctrl+e")]));
+        assert!(problems.is_empty());
+        let pane = FocusedPane::Connections;
+        let press = |code| KeyEvent::new(code, KeyModifiers::NONE);
+
+        let add = keymap.translate(pane, press(KeyCode::Char('n'))).unwrap();
+        assert_eq!(add.code, KeyCode::Char('a'));
+        assert_eq!(keymap.translate(pane, press(KeyCode::Char('a'))), None);
+        let edit = keymap
+            .translate(
+                pane,
+                KeyEvent::new(KeyCode::Char('e'), KeyModifiers::CONTROL),
+            )
+            .unwrap();
+        assert_eq!(edit, press(KeyCode::Char('e')));
+        assert_eq!(keymap.translate(pane, press(KeyCode::Char('e'))), None);
+        // Other panes keep their keys
+        let tables = keymap.translate(FocusedPane::Tables, press(KeyCode::Char('n')));
+        assert_eq!(tables, Some(press(KeyCode::Char('n'))));
+
+        let hints: Vec<String> = keymap
+            .hints(pane)
+            .iter()
+            .map(|(key, name)| format!("{key} {name}"))
+            .collect();
+        assert_eq!(hints, ["n add", "ctrl+e edit", "d delete", "enter connect"]);
+    }
+
+    #[test]
+    fn test_focus_keys_and_order() {
+        let mut config = KeybindingsConfig {
+            focus_order: [
+                "connections",
+                "query_editor",
+                "results",
+                "query_editor",
+                "logs",
+            ]
+            .map(String::from)
+            .to_vec(),
+            ..KeybindingsConfig::default()
+        };
+        config
+            .global
+            .insert("next_pane".to_string(), "ctrl+n".to_string());
+        config
+            .global
+            .insert("previous_pane".to_string(), "ctrl+n".to_string());
+        config
+            .global
+            .insert("cancel_query".to_string(), "ctrl+k".to_string());
+        let (keymap, problems) = Keymap::from_config(&config);
+
+        assert_eq!(problems.len(), 3);
+        assert_eq!(
+            keymap.focus_order(),
+            [
+                FocusedPane::Connections,
+                FocusedPane::QueryWindow,
+                FocusedPane::TabularOutput
+            ]
+        );
+        assert!(keymap.is_next_pane(&KeyEvent::new(KeyCode::Char('n'), KeyModifiers::CONTROL)));
+        assert!(!keymap.is_next_pane(&KeyEvent::new(KeyCode::Tab, KeyModifiers::NONE)));
+        assert!(keymap.is_previous_pane(&KeyEvent::new(KeyCode::BackTab, KeyModifiers::SHIFT)));
+        assert!(keymap.is_cancel_query(&KeyEvent::new(KeyCode::Char('k'), KeyModifiers::CONTROL)));
+        assert_eq!(keymap.cancel_query_key().to_string(), "ctrl+k");
+        assert_eq!(
+            KeySpec::parse("Shift+Tab"),
+            Some(KeySpec::plain(KeyCode::BackTab))
+        );
+        assert_eq!(KeySpec::plain(KeyCode::BackTab).to_string(), "shift+tab");
+    }
+
+    #[test]
+    fn test_bad_bindings_are_reported() {
+        let (keymap, problems) =
+            Keymap::from_config(&config(&[("add", "x"), ("delete", "x"), ("launch", "l")]));
+        assert_eq!(problems.len(), 2);
+        assert_eq!(keymap.bindings.len(), 1);
+    }
+}
@@ -4,21 +4,27 @@
 
 use crate::{
     commands::{CommandAction, CommandContext, CommandId, CommandRegistry, CommandResult},
-    config::Config,
-    core::error::Result,
+    config::{Config, SidebarMode},
+    core::{error::Result, metrics},
     event::{Event, EventHandler},
-    ui::UI,
+    ui::{components::operation, UI},
 };
 use crossterm::event::KeyEvent;
 use ratatui::{DefaultTerminal, Frame};
+use std::path::PathBuf;
 use std::time::Duration;
 
+mod debounce;
 pub mod handlers;
+pub mod keymap;
+pub mod session_events;
+mod signals;
 pub mod state;
 
 pub use state::{
     AppState, AppView, ConnectionFormMode, FocusedPane, HelpMode, OverlayView, TextInputMode,
 };
+use session_events::SessionEvent;
 
 /// Connection event sent from background tasks to main event loop
 #[derive(Debug)]
@@ -26,11 +32,19 @@ enum ConnectionEvent {
     Success {
         connection_index: usize,
         objects: crate::database::DatabaseObjectList,
+        /// Whether the server takes writes
+        role: crate::database::server_role::ServerRole,
     },
     Failed {
         connection_index: usize,
         error: String,
     },
+    /// An attempt failed and the next one starts after the backoff
+    Retrying {
+        connection_index: usize,
+        attempt: u32,
+        error: String,
+    },
 }
 
 /// Test connection event sent from background tasks to main event loop
@@ -40,6 +54,99 @@ enum TestConnectionEvent {
     Failed(String),
 }
 
+/// LISTEN/NOTIFY watcher event sent from the listener task to main event loop
+#[derive(Debug)]
+enum NotificationEvent {
+    Received(crate::ui::components::Notification),
+    Failed(String),
+}
+
+/// Query editor statement event sent from the fetching task to main event loop
+#[derive(Debug)]
+enum QueryEvent {
+    /// Rows fetched so far
+    Progress(usize),
+    /// The server couldn't be asked to cancel the statement
+    CancelFailed(String),
+    Finished {
+        query: String,
+        result: std::result::Result<crate::database::QueryResult, String>,
+        /// The connection was lost and opened again to run the query
+        reconnected: bool,
+    },
+    /// A script run statement by statement ended
+    ScriptFinished {
+        script: String,
+        /// Result sets of the statements that ran, in order
+        results: Vec<crate::database::QueryResult>,
+        statements: Vec<String>,
+        failure: Option<crate::database::script::StatementFailure>,
+        reconnected: bool,
+    },
+}
+
+/// Progress and end of an export of every row passing a table preview's
+/// filters
+#[derive(Debug)]
+enum ExportEvent {
+    /// Rows written so far, formatted for the tasks segment
+    Progress(String),
+    /// Rows written to the file
+    Written { rows: usize, path: PathBuf },
+    /// Rows formatted for the clipboard, from the connection with the ID
+    Formatted {
+        rows: usize,
+        text: String,
+        connection_id: String,
+    },
+    Failed(String),
+}
+
+/// Result of the background refresh of a watched query result, for the
+/// watch with the id
+#[derive(Debug)]
+struct WatchEvent {
+    watch_id: u64,
+    result: std::result::Result<crate::database::QueryResult, String>,
+}
+
+/// Startup ping of the saved connections
+#[derive(Debug)]
+enum PingEvent {
+    Pinged {
+        connection_id: String,
+        reachability: crate::database::reachability::Reachability,
+    },
+    /// Every connection has been pinged
+    Done,
+}
+
+/// Details pane metadata fetched in the background after a table selection
+/// change, tagged with the generation it was scheduled under
+#[derive(Debug)]
+struct MetadataEvent {
+    generation: u64,
+    result: std::result::Result<crate::database::TableMetadata, String>,
+}
+
+/// First page of a table read in the background after opening it, tagged
+/// with the generation of that table's fetch it was read under
+#[derive(Debug)]
+struct PreviewEvent {
+    generation: u64,
+    table_name: String,
+    result: std::result::Result<crate::ui::components::TableTab, String>,
+}
+
+/// Columns of a recently used table fetched ahead of time for query editor
+/// suggestions, tagged with the warmup generation it belongs to
+#[derive(Debug)]
+struct ColumnsWarmedEvent {
+    generation: u64,
+    table: String,
+    columns: Vec<String>,
+}
+
 /// Main application structure
 pub struct App {
     /// Application state
@@ -60,18 +167,142 @@ pub struct App {
     connection_events_rx: tokio::sync::mpsc::UnboundedReceiver<ConnectionEvent>,
     /// Channel sender for connection events (cloned for background tasks)
     connection_events_tx: tokio::sync::mpsc::UnboundedSender<ConnectionEvent>,
+    /// Task handle for the ongoing connection attempt (aborted by Esc)
+    connection_task_handle: Option<tokio::task::JoinHandle<()>>,
     /// Channel receiver for test connection completion events
     test_connection_events_rx: tokio::sync::mpsc::UnboundedReceiver<TestConnectionEvent>,
     /// Channel sender for test connection events (cloned for background tasks)
     test_connection_events_tx: tokio::sync::mpsc::UnboundedSender<TestConnectionEvent>,
     /// Task handle for ongoing test connection (for abort capability)
     test_connection_task_handle: Option<tokio::task::JoinHandle<()>>,
+    /// Task handle for the LISTEN/NOTIFY listener (aborted to stop listening)
+    notification_task_handle: Option<tokio::task::JoinHandle<()>>,
+    /// Channel receiver for received notifications
+    notification_events_rx: tokio::sync::mpsc::UnboundedReceiver<NotificationEvent>,
+    /// Channel sender for notification events (cloned for the listener task)
+    notification_events_tx: tokio::sync::mpsc::UnboundedSender<NotificationEvent>,
+    /// Task handle for the running query editor statement
+    query_task_handle: Option<tokio::task::JoinHandle<()>>,
+    /// Connection the running query editor statement runs on, for cancelling it
+    query_connection_id: Option<String>,
+    /// Channel receiver for query progress and results
+    query_events_rx: tokio::sync::mpsc::UnboundedReceiver<QueryEvent>,
+    /// Channel sender for query events (cloned for the fetching task)
+    query_events_tx: tokio::sync::mpsc::UnboundedSender<QueryEvent>,
+    /// Task handle for the running export of a filtered table preview
+    export_task_handle: Option<tokio::task::JoinHandle<()>>,
+    /// File the running export writes, removed when it's stopped
+    export_path: Option<PathBuf>,
+    /// Folder of the last export written to a file, opened with Alt+O
+    export_dir: Option<PathBuf>,
+    /// Channel receiver for export progress and finished exports
+    export_events_rx: tokio::sync::mpsc::UnboundedReceiver<ExportEvent>,
+    /// Channel sender for export events (cloned for the exporting task)
+    export_events_tx: tokio::sync::mpsc::UnboundedSender<ExportEvent>,
+    /// Channel receiver for refreshed watched query results
+    watch_events_rx: tokio::sync::mpsc::UnboundedReceiver<WatchEvent>,
+    /// Channel sender for watch events (cloned for the refresh tasks)
+    watch_events_tx: tokio::sync::mpsc::UnboundedSender<WatchEvent>,
+    /// Task handle for the startup ping of the saved connections
+    ping_task_handle: Option<tokio::task::JoinHandle<()>>,
+    /// Channel receiver for ping results
+    ping_events_rx: tokio::sync::mpsc::UnboundedReceiver<PingEvent>,
+    /// Channel sender for ping events (cloned for the pinging task)
+    ping_events_tx: tokio::sync::mpsc::UnboundedSender<PingEvent>,
+    /// Debounce for the details pane metadata fetched while navigating tables
+    metadata_fetch: debounce::DebouncedFetch,
+    /// Generation of the metadata fetch whose result hasn't arrived yet
+    metadata_wanted: Option<u64>,
+    /// Status bar clock text at the last tick, to redraw when it changes
+    clock_text: String,
+    /// Connection to open in its database's client once the current key is
+    /// handled, which needs the terminal
+    pending_client: Option<crate::database::ConnectionConfig>,
+    /// Channel receiver for background metadata fetches
+    metadata_events_rx: tokio::sync::mpsc::UnboundedReceiver<MetadataEvent>,
+    /// Channel sender for metadata events (cloned for background tasks)
+    metadata_events_tx: tokio::sync::mpsc::UnboundedSender<MetadataEvent>,
+    /// First page reads of opened tables, one per table, dropped when
+    /// switching connections or databases
+    table_previews: debounce::KeyedFetches,
+    /// Channel receiver for table previews
+    preview_events_rx: tokio::sync::mpsc::UnboundedReceiver<PreviewEvent>,
+    /// Channel sender for preview events (cloned for background tasks)
+    preview_events_tx: tokio::sync::mpsc::UnboundedSender<PreviewEvent>,
+    /// Warmup of the suggestion columns for the connected database, cancelled
+    /// when switching databases
+    completion_warmup: debounce::DebouncedFetch,
+    /// Channel receiver for warmed suggestion columns
+    warmup_events_rx: tokio::sync::mpsc::UnboundedReceiver<ColumnsWarmedEvent>,
+    /// Channel sender for warmup events (cloned for the warmup task)
+    warmup_events_tx: tokio::sync::mpsc::UnboundedSender<ColumnsWarmedEvent>,
 }
 
 impl App {
     /// Create a new application instance
     pub async fn new(config: Config) -> Result<Self> {
-        let state = AppState::new().await;
+        let mut state = AppState::new().await;
+        state.result_limits = config.app.result_limits();
+        state.clipboard = crate::io::clipboard::Clipboard::new(config.app.clipboard);
+        // A zero interval would re-run the query on every tick
+        state.watch_interval =
+            std::time::Duration::from_secs(config.app.watch_interval_secs.max(1));
+        state.connect_retry = config.app.connect_retry();
+        state.ui.show_system_objects = config.app.show_system_objects;
+        state.preview_updates = config.app.preview_updates;
+        state.table_viewer_state.rows_per_page = config.app.preview_page_rows.max(1);
+        state.statement_guard = config.statement_guard.clone();
+        state.ui.show_key_hints = config.app.key_hints;
+        state.ui.auto_advance_focus = config.app.auto_advance_focus;
+        state.ui.readline_keys = config.app.readline_keys;
+        state.ui.sidebar_mode = config.ui.sidebar_mode;
+        state.spinner.set_still(config.ui.accessibility_mode);
+        state.ui.show_clock = config.app.show_clock;
+        state.ui.clock_format = config.app.clock_format();
+        let (keymap, problems) = keymap::Keymap::from_config(&config.keybindings);
+        for problem in &problems {
+            crate::log_warn!("Ignoring key binding: {}", problem);
+        }
+        state.keymap = keymap;
+        state
+            .connection_manager
+            .set_statement_savepoints(config.app.statement_savepoints);
+        state
+            .connection_manager
+            .set_preview_cell_chars(config.app.preview_cell_chars);
+        state
+            .connection_manager
+            .set_statement_timeout(config.app.statement_timeout_secs);
+        state.statement_timeouts = crate::database::statement_timeout::StatementTimeouts::new(
+            config.app.statement_timeout_secs,
+        );
+        state.read_only_guard =
+            crate::database::server_role::ReadOnlyGuard::new(config.app.guard_read_only_servers);
+        state.query_history_limit = config.app.query_history_limit;
+
+        state.connection_manager.set_trace_sql(config.logging.trace_sql);
+
+        // Open the SQL audit log if one is configured
+        if let Some(path) = &config.logging.query_log {
+            match crate::database::QueryAuditLog::open(path, config.logging.query_log_verbose) {
+                Ok(audit_log) => {
+                    crate::log_info!("Recording executed statements to {:?}", audit_log.path());
+                    state.connection_manager.set_audit_log(audit_log);
+                }
+                Err(e) => crate::log_warn!("Failed to open query log {:?}: {}", path, e),
+            }
+        }
+
+        // Say why saved connections are missing, or on first launch guide
+        // the user through their first connection
+        if let Some(error) = state.db.connections_error.take() {
+            state.toast_manager.error(error);
+        } else if !Config::connections_path().exists()
+            && state.db.connections.connections.is_empty()
+        {
+            state.open_first_run_wizard();
+        }
+
         let event_handler = EventHandler::new(Duration::from_millis(250));
         let ui = UI::new(&config)?;
         let command_registry = CommandRegistry::new();
@@ -83,6 +314,31 @@ impl App {
         let (test_connection_events_tx, test_connection_events_rx) =
             tokio::sync::mpsc::unbounded_channel();
 
+        // Create channel for LISTEN/NOTIFY notifications
+        let (notification_events_tx, notification_events_rx) =
+            tokio::sync::mpsc::unbounded_channel();
+
+        // Create channel for query editor statements
+        let (query_events_tx, query_events_rx) = tokio::sync::mpsc::unbounded_channel();
+
+        // Create channel for filtered preview exports
+        let (export_events_tx, export_events_rx) = tokio::sync::mpsc::unbounded_channel();
+
+        // Create channel for watched query refreshes
+        let (watch_events_tx, watch_events_rx) = tokio::sync::mpsc::unbounded_channel();
+
+        // Create channel for the startup ping of saved connections
+        let (ping_events_tx, ping_events_rx) = tokio::sync::mpsc::unbounded_channel();
+
+        // Create channel for background metadata fetches
+        let (metadata_events_tx, metadata_events_rx) = tokio::sync::mpsc::unbounded_channel();
+
+        // Create channel for table previews
+        let (preview_events_tx, preview_events_rx) = tokio::sync::mpsc::unbounded_channel();
+
+        // Create channel for suggestion column warmup
+        let (warmup_events_tx, warmup_events_rx) = tokio::sync::mpsc::unbounded_channel();
+
         Ok(Self {
             state,
             event_handler,
@@ -93,9 +349,39 @@ impl App {
             tick_counter: 0,
             connection_events_rx,
             connection_events_tx,
+            connection_task_handle: None,
             test_connection_events_rx,
             test_connection_events_tx,
             test_connection_task_handle: None,
+            notification_task_handle: None,
+            notification_events_rx,
+            notification_events_tx,
+            query_task_handle: None,
+            query_connection_id: None,
+            query_events_rx,
+            query_events_tx,
+            export_task_handle: None,
+            export_path: None,
+            export_dir: None,
+            export_events_rx,
+            export_events_tx,
+            watch_events_rx,
+            watch_events_tx,
+            ping_task_handle: None,
+            ping_events_rx,
+            ping_events_tx,
+            metadata_fetch: debounce::DebouncedFetch::new(),
+            metadata_wanted: None,
+            clock_text: String::new(),
+            pending_client: None,
+            metadata_events_rx,
+            metadata_events_tx,
+            table_previews: debounce::KeyedFetches::new(),
+            preview_events_rx,
+            preview_events_tx,
+            completion_warmup: debounce::DebouncedFetch::new(),
+            warmup_events_rx,
+            warmup_events_tx,
         })
     }
 
@@ -106,43 +392,237 @@ impl App {
             eprintln!("Warning: Failed to initialize application database: {}", e);
             eprintln!("Some features may not work correctly.");
         }
+        self.state.initialize_query_history().await;
 
         self.event_handler.start()?;
+        if self.config.app.ping_on_startup {
+            handlers::connections::ping_saved_connections(self);
+        }
+        let mut signals = signals::ShutdownSignals::new();
+
+        let result = self.event_loop(&mut terminal, &mut signals).await;
+        self.shutdown().await;
+        result
+    }
 
+    /// Draw and dispatch events until the user quits or a termination signal
+    /// arrives. A long-running query editor statement runs in a task that
+    /// `shutdown` aborts, which cancels it
+    async fn event_loop(
+        &mut self,
+        terminal: &mut DefaultTerminal,
+        signals: &mut signals::ShutdownSignals,
+    ) -> Result<()> {
+        let mut redraw = true;
         while !self.should_quit {
             // Draw UI
-            terminal.draw(|frame| self.draw(frame))?;
+            if redraw {
+                let started = std::time::Instant::now();
+                terminal.draw(|frame| self.draw(frame))?;
+                metrics::observe(metrics::Timing::Render, started.elapsed());
+            }
 
             // Handle events
-            if let Some(event) = self.event_handler.next()? {
-                self.handle_event(event).await?;
+            let event = self.event_handler.next()?;
+            redraw = false;
+            tokio::select! {
+                biased;
+                signal = signals.recv() => {
+                    crate::log_info!("Received {}, shutting down", signal);
+                    self.should_quit = true;
+                }
+                result = async {
+                    match event {
+                        Some(event) => self.handle_event(event).await,
+                        None => Ok(false),
+                    }
+                } => redraw = result?,
+            }
+            if let Some(config) = self.pending_client.take() {
+                self.run_client(terminal, &config).await?;
+                redraw = true;
+            }
+            self.event_handler.set_ticking(self.needs_ticks());
+        }
+
+        Ok(())
+    }
+
+    /// Hand the terminal to the connection's database client until it
+    /// exits. The password goes in the client's environment only, and a
+    /// client that isn't installed is reported
+    async fn run_client(
+        &mut self,
+        terminal: &mut DefaultTerminal,
+        config: &crate::database::ConnectionConfig,
+    ) -> Result<()> {
+        use crate::database::dsn;
+
+        let Some((program, args)) = dsn::client_args(config) else {
+            self.state.toast_manager.warning(format!(
+                "No client to open {} connections in",
+                config.database_type.display_name()
+            ));
+            return Ok(());
+        };
+        let mut command = tokio::process::Command::new(program);
+        command.args(&args);
+        if let Some(variable) = dsn::password_variable(&config.database_type) {
+            match config.resolve_password(None) {
+                Ok(password) if !password.is_empty() => {
+                    command.env(variable, password);
+                }
+                _ => {}
             }
         }
 
+        self.event_handler.pause();
+        crate::terminal::restore()?;
+        println!(
+            "Opening {} in {program}; exit it to return to LazyTables",
+            config.name
+        );
+        // Ctrl-C and Ctrl-\ reach every process in the terminal's foreground
+        // group; listening for them keeps them from stopping LazyTables too
+        let interrupts = signals::ClientSignals::new();
+        let status = match command.spawn() {
+            Ok(mut child) => child.wait().await,
+            Err(e) => Err(e),
+        };
+        drop(interrupts);
+        *terminal = crate::terminal::init()?;
+        self.event_handler.resume();
+
+        match status {
+            Ok(status) if status.success() => {}
+            Ok(status) => self
+                .state
+                .toast_manager
+                .warning(format!("{program} exited with {status}")),
+            Err(e) if e.kind() == std::io::ErrorKind::NotFound => self
+                .state
+                .toast_manager
+                .error(format!("{program} isn't installed or isn't on the PATH")),
+            Err(e) => self
+                .state
+                .toast_manager
+                .error(format!("Failed to run {program}: {e}")),
+        }
         Ok(())
     }
 
+    /// Whether ticks are needed: for the clock, or to follow work in flight
+    /// and what's on screen for a while (toasts, watched results). Without
+    /// them nothing is redrawn until the next key
+    fn needs_ticks(&self) -> bool {
+        self.state.ui.show_clock
+            || self.animating()
+            || self.connection_task_handle.is_some()
+            || self.test_connection_task_handle.is_some()
+            || self.notification_task_handle.is_some()
+            || self.query_task_handle.is_some()
+            || self.export_task_handle.is_some()
+            || self.ping_task_handle.is_some()
+            || self
+                .metadata_wanted
+                .is_some_and(|generation| self.metadata_fetch.is_current(generation))
+            || self.table_previews.is_pending()
+    }
+
+    /// Whether something on screen moves with time: the spinner, fading
+    /// toasts, elapsed times and watch countdowns. Every tick redraws then
+    fn animating(&self) -> bool {
+        self.state.spinner.is_animating()
+            || self.state.toast_manager.has_toasts()
+            || self.state.fetch_progress.is_some()
+            || self.state.connecting_in_progress.is_some()
+            || self.state.test_connection_in_progress
+            || self
+                .state
+                .table_viewer_state
+                .tabs
+                .iter()
+                .any(|tab| tab.watch.is_some())
+    }
+
+    /// Whether the status bar clock reads differently than when last drawn;
+    /// a clock without seconds only needs redrawing once a minute
+    fn clock_changed(&mut self) -> bool {
+        if !self.state.ui.show_clock {
+            return false;
+        }
+        let text = chrono::Local::now()
+            .format(&self.state.ui.clock_format)
+            .to_string();
+        if text == self.clock_text {
+            return false;
+        }
+        self.clock_text = text;
+        true
+    }
+
+    /// Shutdown path shared by quitting and termination signals
+    async fn shutdown(&mut self) {
+        // Stop background work before closing the pools it uses
+        if let Some(handle) = self.connection_task_handle.take() {
+            handle.abort();
+        }
+        if let Some(handle) = self.test_connection_task_handle.take() {
+            handle.abort();
+        }
+        if let Some(handle) = self.notification_task_handle.take() {
+            handle.abort();
+        }
+        if let Some(handle) = self.query_task_handle.take() {
+            handle.abort();
+        }
+        if let Some(handle) = self.ping_task_handle.take() {
+            handle.abort();
+        }
+        if let Some(handle) = self.export_task_handle.take() {
+            handle.abort();
+            // Don't leave a truncated export behind
+            if let Some(path) = self.export_path.take() {
+                let _ = std::fs::remove_file(path);
+            }
+        }
+
+        self.state.shutdown().await;
+        crate::logging::flush();
+    }
+
     /// Draw the user interface
     fn draw(&mut self, frame: &mut Frame) {
+        let started = std::time::Instant::now();
         self.ui.draw(frame, &mut self.state);
+        self.state.debug_view.record_render(started);
     }
 
-    /// Handle application events
-    async fn handle_event(&mut self, event: Event) -> Result<()> {
-        match event {
-            Event::Key(key_event) => self.handle_key_event(key_event).await?,
+    /// Handle application events. Returns whether the screen needs redrawing
+    async fn handle_event(&mut self, event: Event) -> Result<bool> {
+        let redraw = match event {
+            Event::Key(key_event) => {
+                self.handle_key_event(key_event).await?;
+                true
+            }
             Event::Mouse(_) => {
                 // Mouse events will be handled in future
+                false
             }
             Event::Resize(_, _) => {
                 // Terminal resize is handled automatically by ratatui
+                true
             }
             Event::Tick => {
                 // Handle periodic updates
-                self.tick().await?;
+                let changed = self.tick().await?;
+                let clock_changed = self.clock_changed();
+                changed || clock_changed
             }
-        }
-        Ok(())
+        };
+        // Let the panes and status bar follow what the event changed
+        let dispatched = self.state.dispatch_session_events();
+        Ok(redraw || dispatched)
     }
 
     /// Execute a command by ID
@@ -198,8 +678,8 @@ impl App {
             }
             CommandAction::ExecuteQuery(query) => {
                 // Note: Query execution is handled directly by key handlers (handle_query_editor_keys)
-                // via execute_query_at_cursor(). This action is kept for command system compatibility
-                // but actual execution happens in the async key handler.
+                // via run_query_at_cursor(). This action is kept for command system compatibility
+                // but actual execution happens in a task started by the key handler.
                 self.state.toast_manager.info(format!(
                     "Query submitted: {}",
                     query
@@ -217,8 +697,8 @@ impl App {
                 connection_name,
             } => {
                 // Note: Query execution is handled directly by key handlers (handle_query_editor_keys)
-                // via execute_query_at_cursor(). This action is kept for command system compatibility
-                // but actual execution happens in the async key handler.
+                // via run_query_at_cursor(). This action is kept for command system compatibility
+                // but actual execution happens in a task started by the key handler.
                 self.state.toast_manager.info(format!(
                     "Query submitted to {} ({})",
                     connection_name,
@@ -247,6 +727,24 @@ impl App {
 
     /// Handle application keyboard events
     async fn handle_key_event(&mut self, key: KeyEvent) -> Result<()> {
+        // 0. The quick filter, insert-row and export forms and the pin name
+        // and save-cell prompts take every key, digits and Tab included
+        if self.state.table_viewer_state.filter_form.is_some() {
+            return handlers::overlays::handle_filter_form(self, key).await;
+        }
+        if self.state.table_viewer_state.insert_form.is_some() {
+            return handlers::overlays::handle_insert_row_form(self, key).await;
+        }
+        if self.state.table_viewer_state.export_form.is_some() {
+            return handlers::overlays::handle_export_form(self, key);
+        }
+        if self.state.table_viewer_state.pins.naming.is_some() {
+            return handlers::pins::handle_naming(self, key);
+        }
+        if self.state.table_viewer_state.save_cell_form.is_some() {
+            return handlers::save_cell::handle(self, key).await;
+        }
+
         // 1. Handle global keys first (work everywhere)
         if handlers::global::handle(self, key)?.is_some() {
             return Ok(());
@@ -277,8 +775,32 @@ impl App {
             return handlers::overlays::handle_set_null_confirmation(self, key).await;
         }
 
-        // 5. Route to focused pane handler (main view)
-        match self.state.ui.focused_pane {
+        // 4c. Close the column statistics popup
+        if self.state.table_viewer_state.column_stats.is_some() {
+            return handlers::overlays::handle_column_stats(self, key);
+        }
+
+        // 5. Route to focused pane handler (main view). Unless text is being
+        // typed, a key bound in the keymap stands in for its action's default
+        let pane = self.state.ui.focused_pane;
+        let typing = handlers::global::is_typing(self);
+        if let Some(forward) = handlers::jumps::direction(&key).filter(|_| !typing) {
+            return handlers::jumps::jump(self, forward).await;
+        }
+        let key = if typing {
+            Some(key)
+        } else {
+            self.state.keymap.translate(pane, key)
+        };
+        let Some(key) = key else {
+            return Ok(());
+        };
+        match pane {
+            FocusedPane::Connections | FocusedPane::Tables
+                if self.state.ui.sidebar_mode == SidebarMode::Tree =>
+            {
+                handlers::object_tree::handle(self, key).await
+            }
             FocusedPane::Connections => handlers::connections::handle(self, key).await,
             FocusedPane::Tables => handlers::tables::handle(self, key).await,
             FocusedPane::Details => handlers::details::handle(self, key),
@@ -288,16 +810,18 @@ impl App {
         }
     }
 
-    /// Handle periodic updates
-    async fn tick(&mut self) -> Result<()> {
+    /// Handle periodic updates. Returns whether anything drawn may have
+    /// changed, so ticks with nothing to show don't redraw
+    async fn tick(&mut self) -> Result<bool> {
         // Increment tick counter
         self.tick_counter = self.tick_counter.wrapping_add(1);
+        let mut changed = self.animating();
+
+        // Animate the busy indicator every tick (250ms interval)
+        self.state.spinner.tick();
 
         // Handle ongoing connection attempt
         if let Some(connecting_index) = self.state.connecting_in_progress {
-            // Animate loading dots every tick (250ms interval)
-            self.state.connecting_animation_frame = (self.state.connecting_animation_frame + 1) % 3;
-
             // Check for timeout
             if let Some(start_time) = self.state.connection_start_time {
                 let elapsed = start_time.elapsed().as_secs();
@@ -316,10 +840,9 @@ impl App {
                         ));
                         self.state.toast_manager.error("Connection timeout");
                     }
-                    self.state.connecting_in_progress = None;
-                    self.state.connection_start_time = None;
+                    handlers::connections::stop_connecting(self);
                     // Don't process events if we just timed out
-                    return Ok(());
+                    return Ok(true);
                 }
             }
 
@@ -329,6 +852,7 @@ impl App {
                     ConnectionEvent::Success {
                         connection_index,
                         objects,
+                        role,
                     } => {
                         // Connection succeeded! Update state
                         if let Some(conn) = self
@@ -339,6 +863,7 @@ impl App {
                             .get_mut(connection_index)
                         {
                             conn.status = crate::database::ConnectionStatus::Connected;
+                            self.state.read_only_guard.set_role(&conn.id, role);
                         }
 
                         // Update database state
@@ -346,13 +871,7 @@ impl App {
                         self.state.db.tables = objects
                             .tables
                             .iter()
-                            .map(|t| {
-                                if t.schema.as_deref() == Some("public") || t.schema.is_none() {
-                                    t.name.clone()
-                                } else {
-                                    t.qualified_name()
-                                }
-                            })
+                            .map(|t| objects.open_name(t))
                             .collect();
 
                         // Update UI
@@ -361,6 +880,18 @@ impl App {
                             .build_selectable_table_items(&self.state.db.database_objects);
                         self.state.update_table_selection();
 
+                        // Select the table a jump back or forward was for
+                        if let Some(jump) = self.state.pending_jump.take() {
+                            self.state.restore_jump(&jump).await;
+                        }
+
+                        // Go on to the tables, unless focus has moved elsewhere
+                        if self.state.ui.auto_advance_focus
+                            && self.state.ui.focused_pane == FocusedPane::Connections
+                        {
+                            self.state.ui.focused_pane = FocusedPane::Tables;
+                        }
+
                         // Show success message
                         if let Some(conn) =
                             self.state.db.connections.connections.get(connection_index)
@@ -368,6 +899,11 @@ impl App {
                             self.state
                                 .toast_manager
                                 .success(format!("Connected to {}", conn.name));
+                            self.state
+                                .session_events
+                                .emit(SessionEvent::ConnectionOpened {
+                                    connection_id: conn.id.clone(),
+                                });
 
                             // Update active connection in app state database
                             let _ = self
@@ -384,16 +920,27 @@ impl App {
                         // Refresh SQL files
                         self.state.refresh_sql_files().await;
 
+                        // Fetch suggestion columns for recently used tables
+                        handlers::connections::warm_completion_cache(self, connection_index);
+
                         // Clear in-progress flag and start time
+                        self.connection_task_handle = None;
                         self.state.connecting_in_progress = None;
                         self.state.connection_start_time = None;
+                        self.state.connect_attempt = None;
+                        self.state.spinner.stop(operation::CONNECTING);
                     }
                     ConnectionEvent::Failed {
                         connection_index,
                         error,
                     } => {
-                        // Connection failed
-                        if let Some(conn) = self
+                        // Connection failed; drivers may echo credentials
+                        let error = crate::core::redact::scrub_secrets(&error).into_owned();
+                        if crate::database::MissingObject::from_error(&error)
+                            == Some(crate::database::MissingObject::Database)
+                        {
+                            self.state.forget_missing_database(connection_index).await;
+                        } else if let Some(conn) = self
                             .state
                             .db
                             .connections
@@ -405,8 +952,28 @@ impl App {
                                 .toast_manager
                                 .error(format!("Connection failed: {}", error));
                         }
+                        self.connection_task_handle = None;
                         self.state.connecting_in_progress = None;
                         self.state.connection_start_time = None;
+                        self.state.connect_attempt = None;
+                        self.state.pending_jump = None;
+                        self.state.spinner.stop(operation::CONNECTING);
+                    }
+                    ConnectionEvent::Retrying {
+                        connection_index,
+                        attempt,
+                        error,
+                    } => {
+                        crate::log_debug!(
+                            "Retrying connection {}: attempt {} after: {}",
+                            connection_index,
+                            attempt,
+                            error
+                        );
+                        // Every attempt gets the full connection timeout
+                        self.state.connection_start_time = Some(std::time::Instant::now());
+                        self.state.connect_attempt =
+                            Some((attempt, self.state.connect_retry.attempts()));
                     }
                 }
             }
@@ -414,9 +981,6 @@ impl App {
 
         // Handle ongoing test connection attempt
         if self.state.test_connection_in_progress {
-            // Animate loading dots every tick (250ms interval)
-            self.state.test_animation_frame = (self.state.test_animation_frame + 1) % 3;
-
             // Check for timeout
             if let Some(start_time) = self.state.test_start_time {
                 let elapsed = start_time.elapsed().as_secs();
@@ -431,8 +995,9 @@ impl App {
                     self.state.test_connection_in_progress = false;
                     self.state.test_start_time = None;
                     self.test_connection_task_handle = None;
+                    self.state.spinner.stop(operation::TESTING_CONNECTION);
                     self.state.toast_manager.error("Test connection timeout");
-                    return Ok(());
+                    return Ok(true);
                 }
             }
 
@@ -449,6 +1014,7 @@ impl App {
                             .success("Test connection successful");
                     }
                     TestConnectionEvent::Failed(error) => {
+                        let error = crate::core::redact::scrub_secrets(&error).into_owned();
                         self.state.connection_modal_state.test_status =
                             Some(TestConnectionStatus::Failed(error.clone()));
                         self.state
@@ -461,12 +1027,264 @@ impl App {
                 self.state.test_connection_in_progress = false;
                 self.state.test_start_time = None;
                 self.test_connection_task_handle = None;
+                self.state.spinner.stop(operation::TESTING_CONNECTION);
             }
         }
 
+        // Collect LISTEN/NOTIFY notifications
+        while let Ok(event) = self.notification_events_rx.try_recv() {
+            changed = true;
+            match event {
+                NotificationEvent::Received(notification) => {
+                    metrics::count_notification();
+                    self.state.notifications.push(notification)
+                }
+                NotificationEvent::Failed(error) => {
+                    crate::log_warn!("Notification listener stopped: {}", error);
+                    self.notification_task_handle = None;
+                    self.state.notifications.stop(Some(error.clone()));
+                    self.state
+                        .toast_manager
+                        .error(format!("Stopped listening: {error}"));
+                }
+            }
+        }
+
+        // Follow the running query editor statement
+        while let Ok(event) = self.query_events_rx.try_recv() {
+            changed = true;
+            match event {
+                QueryEvent::Progress(rows) => self.state.update_fetch_progress(rows),
+                QueryEvent::CancelFailed(e) => self
+                    .state
+                    .toast_manager
+                    .error(format!("Failed to cancel the query: {e}")),
+                QueryEvent::Finished {
+                    query,
+                    result,
+                    reconnected,
+                } => {
+                    self.query_task_handle = None;
+                    let connection_id = self.query_connection_id.take();
+                    if result.is_ok() {
+                        let elapsed = self.state.fetch_progress.as_ref().map(|p| p.elapsed());
+                        self.state.record_query_history(&query, elapsed).await;
+                    }
+                    self.state.finish_query(connection_id, query, result);
+                    if reconnected {
+                        self.state
+                            .toast_manager
+                            .info("Connection was re-established and the query ran again");
+                    }
+                }
+                QueryEvent::ScriptFinished {
+                    script,
+                    results,
+                    statements,
+                    failure,
+                    reconnected,
+                } => {
+                    self.query_task_handle = None;
+                    let connection_id = self.query_connection_id.take();
+                    if failure.is_none() {
+                        let elapsed = self.state.fetch_progress.as_ref().map(|p| p.elapsed());
+                        self.state.record_query_history(&script, elapsed).await;
+                    }
+                    self.state
+                        .finish_script(connection_id, script, results, statements, failure);
+                    if reconnected {
+                        self.state
+                            .toast_manager
+                            .info("Connection was re-established and a statement ran again");
+                    }
+                }
+            }
+        }
+
+        // Report the progress and end of exports of filtered previews
+        while let Ok(event) = self.export_events_rx.try_recv() {
+            changed = true;
+            if let ExportEvent::Progress(progress) = event {
+                self.state
+                    .spinner
+                    .set_progress(operation::EXPORTING_ROWS, progress);
+                continue;
+            }
+            self.export_task_handle = None;
+            self.export_path = None;
+            self.state.spinner.stop(operation::EXPORTING_ROWS);
+            let what = |rows: usize| {
+                format!(
+                    "{rows} filtered row{} (server-side)",
+                    if rows == 1 { "" } else { "s" }
+                )
+            };
+            match event {
+                ExportEvent::Written { rows, path } => {
+                    self.export_dir =
+                        Some(crate::io::opener::containing_folder(&path).to_path_buf());
+                    self.state.toast_manager.success(format!(
+                        "{} exported to {}; Alt+O opens its folder",
+                        what(rows),
+                        path.display()
+                    ))
+                }
+                ExportEvent::Formatted {
+                    rows,
+                    text,
+                    connection_id,
+                } => {
+                    match self.state.clipboard.copy(&text) {
+                        Ok(backend) => {
+                            self.state
+                                .connection_manager
+                                .session_stats()
+                                .record_export(&connection_id, text.len() as u64);
+                            self.state.toast_manager.success(
+                                handlers::query_results::copied_message(&what(rows), backend),
+                            )
+                        }
+                        Err(e) => self.state.toast_manager.error(e),
+                    }
+                }
+                ExportEvent::Failed(e) => self.state.toast_manager.error(e),
+                ExportEvent::Progress(_) => {}
+            }
+        }
+
+        // Mark saved connections as the startup ping finds them
+        while let Ok(event) = self.ping_events_rx.try_recv() {
+            changed = true;
+            match event {
+                PingEvent::Pinged {
+                    connection_id,
+                    reachability,
+                } => {
+                    self.state.reachability.insert(connection_id, reachability);
+                }
+                PingEvent::Done => {
+                    self.ping_task_handle = None;
+                    self.state.spinner.stop(operation::PINGING_CONNECTIONS);
+                }
+            }
+        }
+
+        // Apply metadata fetched for the current table selection; results for
+        // selections that have since changed are dropped
+        while let Ok(event) = self.metadata_events_rx.try_recv() {
+            if !self.metadata_fetch.is_current(event.generation) {
+                continue;
+            }
+            changed = true;
+            self.metadata_wanted = None;
+            match event.result {
+                Ok(metadata) => self.state.db.current_table_metadata = Some(metadata),
+                Err(e) => match crate::database::MissingObject::from_error(&e) {
+                    // Dropped by someone else since the tables list was loaded
+                    Some(crate::database::MissingObject::Database) => {
+                        let connection_index = self.state.ui.selected_connection;
+                        self.state.forget_missing_database(connection_index).await;
+                    }
+                    Some(crate::database::MissingObject::Table) => {
+                        if let Some(table_name) = self.state.ui.get_selected_table_name() {
+                            self.state.forget_missing_table(&table_name).await;
+                        }
+                    }
+                    None => crate::log_debug!("Failed to load table metadata: {}", e),
+                },
+            }
+        }
+
+        // Show the first page of opened tables; a read superseded by opening
+        // the table again or by switching connections or databases is dropped
+        while let Ok(event) = self.preview_events_rx.try_recv() {
+            if !self
+                .table_previews
+                .take(&event.table_name, event.generation)
+            {
+                continue;
+            }
+            changed = true;
+            self.state
+                .table_preview_loaded(&event.table_name, event.result)
+                .await;
+        }
+
+        // Feed warmed columns to the query editor suggestions; columns from a
+        // database that has since been switched away from are dropped
+        while let Ok(event) = self.warmup_events_rx.try_recv() {
+            if self.completion_warmup.is_current(event.generation) {
+                changed = true;
+                self.state
+                    .query_editor
+                    .set_table_columns(event.table, event.columns);
+            }
+        }
+
+        // Show refreshed watched query results, and re-run those that are due
+        while let Ok(event) = self.watch_events_rx.try_recv() {
+            changed = true;
+            self.state
+                .finish_watch_refresh(event.watch_id, event.result);
+        }
+        self.refresh_watched_queries();
+
         // Periodic connection health checks removed to reduce CPU/battery usage when idle
         // Connections are checked lazily when operations are performed on them
 
-        Ok(())
+        Ok(changed || self.animating())
+    }
+
+    /// Re-run the query of each watched result that is due in the
+    /// background, on the connection it came from; the refreshed rows come
+    /// back through the watch events drained in `tick`
+    fn refresh_watched_queries(&mut self) {
+        for (watch_id, connection_id, query) in self.state.due_query_watches() {
+            let manager = self.state.connection_manager.clone();
+            let limits = self.state.result_limits;
+            let tx = self.watch_events_tx.clone();
+            tokio::spawn(async move {
+                let result = manager
+                    .execute_limited_query(&connection_id, &query, limits)
+                    .await
+                    .map_err(|e| e.to_string());
+                let _ = tx.send(WatchEvent { watch_id, result });
+            });
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crossterm::event::{KeyCode, KeyModifiers};
+
+    /// An app on the main view. Test builds keep config, data and state in
+    /// a temporary home directory (see `Config::data_dir`), not the user's
+    async fn app() -> App {
+        let mut app = App::new(Config::default()).await.unwrap();
+        // Without saved connections the first-run wizard is showing
+        app.state.first_run_wizard = None;
+        app.state.ui.return_to_main();
+        app
+    }
+
+    #[tokio::test]
+    async fn test_query_editor_types_global_keys() {
+        let mut app = app().await;
+        app.state.ui.focused_pane = FocusedPane::QueryWindow;
+
+        for c in "idq?1".chars() {
+            app.handle_key_event(KeyEvent::new(KeyCode::Char(c), KeyModifiers::NONE))
+                .await
+                .unwrap();
+        }
+
+        // `i` entered insert mode; the rest were typed rather than quitting,
+        // opening the help or switching panes
+        assert_eq!(app.state.query_editor.get_content(), "dq?1");
+        assert!(!app.should_quit);
+        assert!(app.state.ui.is_in_main());
+        assert_eq!(app.state.ui.focused_pane, FocusedPane::QueryWindow);
     }
 }
@@ -0,0 +1,158 @@
+// FilePath: src/app/session_events.rs
+
+//! Session events
+//!
+//! What happens to the session — a connection opened or closed, the database
+//! or table changed, a query started or finished — as a small set of typed
+//! events. The code making the change emits one; after each key press or
+//! background event, `AppState::dispatch_session_events` hands every event to
+//! each subscribing pane and the status bar in turn. A pane reacting to a
+//! table change subscribes once instead of being reset from every place the
+//! table can change.
+
+#![forbid(unsafe_code)]
+
+use std::time::{Duration, Instant};
+
+/// Something that happened to the session
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub enum SessionEvent {
+    /// A connection was opened and its objects listed
+    ConnectionOpened { connection_id: String },
+    /// A connection was closed
+    ConnectionClosed { connection_id: String },
+    /// An open connection now uses another database
+    DatabaseChanged {
+        connection_id: String,
+        database: String,
+    },
+    /// Another table is selected in the Tables pane; None when none is
+    TableChanged { table: Option<String> },
+    /// A query editor statement started running
+    QueryStarted,
+    /// The running query editor statement finished
+    QueryFinished {
+        /// Rows returned; None when it failed
+        rows: Option<usize>,
+    },
+}
+
+/// A pane or bar reacting to session events
+pub trait SessionSubscriber {
+    fn on_session_event(&mut self, event: &SessionEvent);
+}
+
+/// Events emitted since the last dispatch
+#[derive(Debug, Default)]
+pub struct SessionEvents {
+    pending: Vec<SessionEvent>,
+}
+
+impl SessionEvents {
+    pub fn emit(&mut self, event: SessionEvent) {
+        self.pending.push(event);
+    }
+
+    /// The events emitted since the last call, oldest first
+    pub fn take(&mut self) -> Vec<SessionEvent> {
+        std::mem::take(&mut self.pending)
+    }
+}
+
+/// How the last query editor statement went, for the status bar
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub struct LastQuery {
+    pub elapsed: Duration,
+    /// Rows returned; None when it failed
+    pub rows: Option<usize>,
+}
+
+/// Status bar's view of the session's queries
+#[derive(Debug, Default)]
+pub struct SessionActivity {
+    started: Option<Instant>,
+    last_query: Option<LastQuery>,
+}
+
+impl SessionActivity {
+    pub fn last_query(&self) -> Option<LastQuery> {
+        self.last_query
+    }
+
+    /// Summary of the last query, e.g. `last query 12ms, 42 rows`
+    pub fn status_text(&self) -> Option<String> {
+        let last = self.last_query?;
+        let outcome = match last.rows {
+            Some(1) => "1 row".to_string(),
+            Some(rows) => format!("{rows} rows"),
+            None => "failed".to_string(),
+        };
+        Some(format!(
+            "last query {}ms, {outcome}",
+            last.elapsed.as_millis()
+        ))
+    }
+}
+
+impl SessionSubscriber for SessionActivity {
+    fn on_session_event(&mut self, event: &SessionEvent) {
+        match event {
+            SessionEvent::QueryStarted => self.started = Some(Instant::now()),
+            SessionEvent::QueryFinished { rows } => {
+                self.last_query = self.started.take().map(|started| LastQuery {
+                    elapsed: started.elapsed(),
+                    rows: *rows,
+                });
+            }
+            // A query of another connection or database says nothing here
+            SessionEvent::ConnectionOpened { .. }
+            | SessionEvent::ConnectionClosed { .. }
+            | SessionEvent::DatabaseChanged { .. } => {
+                self.started = None;
+                self.last_query = None;
+            }
+            SessionEvent::TableChanged { .. } => {}
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_events_are_taken_in_order() {
+        let mut events = SessionEvents::default();
+        events.emit(SessionEvent::QueryStarted);
+        events.emit(SessionEvent::QueryFinished { rows: Some(2) });
+        assert_eq!(
+            events.take(),
+            [
+                SessionEvent::QueryStarted,
+                SessionEvent::QueryFinished { rows: Some(2) }
+            ]
+        );
+        assert!(events.take().is_empty());
+    }
+
+    #[test]
+    fn test_activity_follows_queries_and_connections() {
+        let mut activity = SessionActivity::default();
+        activity.on_session_event(&SessionEvent::QueryFinished { rows: Some(3) });
+        assert_eq!(activity.last_query(), None);
+
+        activity.on_session_event(&SessionEvent::QueryStarted);
+        activity.on_session_event(&SessionEvent::QueryFinished { rows: Some(1) });
+        let text = activity.status_text().unwrap();
+        assert!(text.starts_with("last query ") && text.ends_with("ms, 1 row"));
+
+        activity.on_session_event(&SessionEvent::QueryStarted);
+        activity.on_session_event(&SessionEvent::QueryFinished { rows: None });
+        assert!(activity.status_text().unwrap().ends_with("failed"));
+
+        activity.on_session_event(&SessionEvent::ConnectionClosed {
+            connection_id: "local".to_string(),
+        });
+        assert_eq!(activity.status_text(), None);
+    }
+}
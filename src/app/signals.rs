@@ -0,0 +1,107 @@
+// FilePath: src/app/signals.rs
+
+//! Termination signals
+//!
+//! SIGTERM and SIGHUP (closing the terminal window) go through the same
+//! shutdown path as quitting, so pools are closed and session state is saved
+//! instead of the process dying mid-statement. Ctrl-C needs no handler: the
+//! terminal is in raw mode, so it arrives as a key event. Only while a
+//! database client runs in the terminal is it a signal, and then it's the
+//! client's.
+
+#![forbid(unsafe_code)]
+
+#[cfg(unix)]
+use tokio::signal::unix::{signal, Signal, SignalKind};
+
+/// Listener for the signals that should shut the application down
+pub struct ShutdownSignals {
+    #[cfg(unix)]
+    terminate: Option<Signal>,
+    #[cfg(unix)]
+    hangup: Option<Signal>,
+}
+
+impl ShutdownSignals {
+    /// Register the signal handlers. A handler that can't be registered is
+    /// logged and skipped; the signal then keeps its default behaviour
+    pub fn new() -> Self {
+        Self {
+            #[cfg(unix)]
+            terminate: register(SignalKind::terminate(), "SIGTERM"),
+            #[cfg(unix)]
+            hangup: register(SignalKind::hangup(), "SIGHUP"),
+        }
+    }
+
+    /// Wait for the next termination signal, returning its name
+    #[cfg(unix)]
+    pub async fn recv(&mut self) -> &'static str {
+        tokio::select! {
+            Some(()) = recv_optional(&mut self.terminate) => "SIGTERM",
+            Some(()) = recv_optional(&mut self.hangup) => "SIGHUP",
+            else => std::future::pending().await,
+        }
+    }
+
+    /// Wait for the next termination signal, returning its name
+    #[cfg(not(unix))]
+    pub async fn recv(&mut self) -> &'static str {
+        std::future::pending().await
+    }
+}
+
+impl Default for ShutdownSignals {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+/// Listener for Ctrl-C and Ctrl-\ while a program run in the terminal has
+/// it. The terminal sends them to LazyTables as well as to the program, and
+/// with a listener registered they no longer stop it. The handler stays
+/// installed after the listener is dropped, which raw mode makes moot
+pub struct ClientSignals {
+    #[cfg(unix)]
+    _interrupt: Option<Signal>,
+    #[cfg(unix)]
+    _quit: Option<Signal>,
+}
+
+impl ClientSignals {
+    /// Register the listeners
+    pub fn new() -> Self {
+        Self {
+            #[cfg(unix)]
+            _interrupt: register(SignalKind::interrupt(), "SIGINT"),
+            #[cfg(unix)]
+            _quit: register(SignalKind::quit(), "SIGQUIT"),
+        }
+    }
+}
+
+impl Default for ClientSignals {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+#[cfg(unix)]
+fn register(kind: SignalKind, name: &str) -> Option<Signal> {
+    match signal(kind) {
+        Ok(signal) => Some(signal),
+        Err(e) => {
+            crate::log_warn!("Failed to install {} handler: {}", name, e);
+            None
+        }
+    }
+}
+
+/// Receive from a signal that may not be registered; never resolves if not
+#[cfg(unix)]
+async fn recv_optional(signal: &mut Option<Signal>) -> Option<()> {
+    match signal {
+        Some(signal) => signal.recv().await,
+        None => std::future::pending().await,
+    }
+}
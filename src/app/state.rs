@@ -3,12 +3,21 @@
 #![forbid(unsafe_code)]
 
 use crate::{
-    config::Config,
-    database::{AppStateDb, ConnectionConfig, ConnectionManager, ConnectionStatus},
+    app::session_events::{SessionActivity, SessionEvent, SessionEvents, SessionSubscriber},
+    config::{Config, SidebarMode},
+    database::{
+        cancel, partition_preview_sql, reachability::Reachability, script,
+        server_role::ReadOnlyGuard, statement_timeout::StatementTimeouts,
+        transaction::SAVEPOINT_RECOVERED, update_preview, AppStateDb, ConnectRetry,
+        ConnectionConfig, ConnectionManager, ConnectionStatus, MissingObject, QueryHistoryManager,
+        QueryResult, ResultLimits, UndoEntry, UndoLog,
+    },
+    io::clipboard::Clipboard,
     state::{ui::UIState, DatabaseState},
     ui::components::{
-        ConnectionModalState, ConnectionMode, DebugView, QueryEditor, TableViewerState,
-        ToastManager,
+        operation, ConnectionModalState, ConnectionMode, DebugView, FetchProgress, FirstRunWizard,
+        InsertRowForm, NotificationsView, ObjectTree, QueryEditor, QueryHistoryPicker, Spinner,
+        TableTab, TableViewerState, ToastManager, TreeRow,
     },
 };
 
@@ -16,6 +25,9 @@ use crate::{
 pub use crate::state::ui::{FocusedPane, HelpMode, HelpPaneFocus};
 pub use crate::state::view::{AppView, ConnectionFormMode, OverlayView, TextInputMode};
 
+/// Rows shown when previewing one partition of a partitioned table
+const PARTITION_PREVIEW_ROWS: usize = 100;
+
 /// Query editor movement directions
 #[derive(Debug, Clone, Copy)]
 pub enum QueryEditorMovement {
@@ -54,18 +66,64 @@ pub struct AppState {
     pub connection_manager: ConnectionManager,
     /// Connection attempt in progress (stores connection index being attempted)
     pub connecting_in_progress: Option<usize>,
-    /// Animation frame counter for loading dots (0-2)
-    pub connecting_animation_frame: u8,
     /// Connection attempt start time for timeout tracking
     pub connection_start_time: Option<std::time::Instant>,
     /// Connection timeout in seconds
     pub connection_timeout_seconds: u64,
+    /// Retries of a failed connection attempt and the backoff between them
+    pub connect_retry: ConnectRetry,
+    /// Attempt number and total attempts while a connection is being retried
+    pub connect_attempt: Option<(u32, u32)>,
     /// Test connection in progress (modal test button)
     pub test_connection_in_progress: bool,
-    /// Animation frame counter for test connection loading dots (0-2)
-    pub test_animation_frame: u8,
+    /// Busy indicator for connecting, testing and running queries
+    pub spinner: Spinner,
     /// Test connection start time for timeout tracking
     pub test_start_time: Option<std::time::Instant>,
+    /// First-run setup wizard, active until its connection is saved or it is skipped
+    pub first_run_wizard: Option<FirstRunWizard>,
+    /// Row and cell size caps for query editor results
+    pub result_limits: ResultLimits,
+    /// Rows fetched so far by the running query editor statement
+    pub fetch_progress: Option<FetchProgress>,
+    /// Time between runs of a watched query result
+    pub watch_interval: std::time::Duration,
+    /// Clipboard used by the copy commands
+    pub clipboard: Clipboard,
+    /// LISTEN/NOTIFY watcher
+    pub notifications: NotificationsView,
+    /// Preview the rows an UPDATE changes and ask before running it
+    pub preview_updates: bool,
+    /// Connection the query editor last ran a statement on. Running one on
+    /// another connection is confirmed first
+    pub last_query_connection: Option<String>,
+    /// Policies for destructive statements run from the query editor
+    pub statement_guard: crate::database::statement_guard::StatementGuardConfig,
+    /// What the startup ping found for each saved connection, by ID
+    pub reachability: std::collections::HashMap<String, Reachability>,
+    /// Keys of each pane's main actions
+    pub keymap: crate::app::keymap::Keymap,
+    /// Table to select again once the connection being made for a jump
+    /// back or forward succeeds
+    pub pending_jump: Option<crate::state::jumps::Jump>,
+    /// Session events emitted since the last dispatch
+    pub session_events: SessionEvents,
+    /// How the last query went, for the status bar
+    pub session_activity: SessionActivity,
+    /// Selection and expanded nodes of the object tree sidebar
+    pub object_tree: ObjectTree,
+    /// Statements reversing the session's edits, deletes and inserts
+    pub undo_log: UndoLog,
+    /// Server-side statement timeouts of the session's connections
+    pub statement_timeouts: StatementTimeouts,
+    /// Which connections are replicas or read-only, and refuse writes
+    pub read_only_guard: ReadOnlyGuard,
+    /// Statements run from the query editor, once its database is open
+    pub query_history: Option<QueryHistoryManager>,
+    /// `[app] query_history_limit`
+    pub query_history_limit: usize,
+    /// The open `Ctrl+R` history picker
+    pub query_history_picker: Option<QueryHistoryPicker>,
 }
 
 impl AppState {
@@ -100,12 +158,34 @@ impl AppState {
             app_state_db: AppStateDb::new(),
             connection_manager: ConnectionManager::new(),
             connecting_in_progress: None,
-            connecting_animation_frame: 0,
             connection_start_time: None,
             connection_timeout_seconds: 30, // 30 seconds timeout
+            connect_retry: ConnectRetry::default(),
+            connect_attempt: None,
             test_connection_in_progress: false,
-            test_animation_frame: 0,
+            spinner: Spinner::new(),
             test_start_time: None,
+            first_run_wizard: None,
+            result_limits: ResultLimits::default(),
+            fetch_progress: None,
+            watch_interval: std::time::Duration::from_secs(5),
+            clipboard: Clipboard::default(),
+            notifications: NotificationsView::new(),
+            preview_updates: false,
+            last_query_connection: None,
+            statement_guard: Default::default(),
+            reachability: std::collections::HashMap::new(),
+            keymap: crate::app::keymap::Keymap::default(),
+            pending_jump: None,
+            session_events: SessionEvents::default(),
+            session_activity: SessionActivity::default(),
+            object_tree: ObjectTree::default(),
+            undo_log: UndoLog::default(),
+            statement_timeouts: StatementTimeouts::default(),
+            read_only_guard: ReadOnlyGuard::default(),
+            query_history: None,
+            query_history_limit: 100,
+            query_history_picker: None,
         }
     }
 
@@ -120,6 +200,67 @@ impl AppState {
         }
     }
 
+    /// Open the query history database; without it nothing is recorded
+    pub async fn initialize_query_history(&mut self) {
+        let opened = match QueryHistoryManager::new() {
+            Ok(mut history) => history.initialize().await.map(|()| history),
+            Err(e) => Err(e),
+        };
+        match opened {
+            Ok(history) => self.query_history = Some(history),
+            Err(e) => crate::log_warn!("Query history is off: {}", e),
+        }
+    }
+
+    /// Add a statement the query editor ran successfully to the history,
+    /// dropping the oldest entries past `query_history_limit`
+    pub async fn record_query_history(&self, query: &str, elapsed: Option<std::time::Duration>) {
+        let (Some(history), Some(connection)) =
+            (self.query_history.as_ref(), self.get_selected_connection())
+        else {
+            return;
+        };
+        let recorded = history
+            .add_query(
+                query.trim(),
+                Some(&connection.name),
+                connection.database_type.clone(),
+                connection.database.as_deref(),
+                elapsed.map(|elapsed| elapsed.as_millis() as i64),
+                true,
+                None,
+            )
+            .await;
+        let result = match recorded {
+            Ok(_) => history
+                .clear_old_history(self.query_history_limit as i64)
+                .await
+                .map(|_| ()),
+            Err(e) => Err(e),
+        };
+        if let Err(e) = result {
+            crate::log_warn!("Failed to record the query in the history: {}", e);
+        }
+    }
+
+    /// Ordered shutdown shared by quitting and termination signals: roll back
+    /// open transactions, save the session, then close every pool
+    pub async fn shutdown(&mut self) {
+        self.connection_manager.rollback_open_transactions().await;
+
+        // Overlays aren't restored on launch (and the edit form can't be serialized)
+        self.ui.return_to_main();
+        if let Err(e) = self.ui.save() {
+            crate::log_warn!("Failed to save UI state: {}", e);
+        }
+
+        if let Err(e) = self.connection_manager.disconnect_all().await {
+            crate::log_warn!("Failed to close connections: {}", e);
+        }
+        crate::log_info!("{}", self.connection_manager.session_stats().summary());
+        self.app_state_db.close().await;
+    }
+
     /// Get elapsed connection time in seconds
     pub fn get_connection_elapsed_seconds(&self) -> u64 {
         if let Some(start_time) = self.connection_start_time {
@@ -129,36 +270,34 @@ impl AppState {
         }
     }
 
-    /// Cycle focus to the next pane
+    /// Cycle focus to the next pane of the keymap's focus order
     pub fn cycle_focus_forward(&mut self) {
-        let sql_panes_enabled = self.are_sql_panes_enabled();
-        let query_editor_enabled = self.is_query_editor_enabled();
-        let tables_enabled = self.is_tables_pane_enabled();
-        let details_enabled = self.is_details_pane_enabled();
-        let query_results_enabled = self.is_query_results_pane_enabled();
-        self.ui.cycle_focus_forward(
-            sql_panes_enabled,
-            query_editor_enabled,
-            tables_enabled,
-            details_enabled,
-            query_results_enabled,
-        );
+        self.cycle_focus(true);
     }
 
-    /// Cycle focus to the previous pane
+    /// Cycle focus to the previous pane of the keymap's focus order
     pub fn cycle_focus_backward(&mut self) {
-        let sql_panes_enabled = self.are_sql_panes_enabled();
-        let query_editor_enabled = self.is_query_editor_enabled();
-        let tables_enabled = self.is_tables_pane_enabled();
-        let details_enabled = self.is_details_pane_enabled();
-        let query_results_enabled = self.is_query_results_pane_enabled();
-        self.ui.cycle_focus_backward(
-            sql_panes_enabled,
-            query_editor_enabled,
-            tables_enabled,
-            details_enabled,
-            query_results_enabled,
-        );
+        self.cycle_focus(false);
+    }
+
+    fn cycle_focus(&mut self, forward: bool) {
+        let enabled = [
+            (FocusedPane::Tables, self.is_tables_pane_enabled()),
+            (FocusedPane::Details, self.is_details_pane_enabled()),
+            (
+                FocusedPane::TabularOutput,
+                self.is_query_results_pane_enabled(),
+            ),
+            (FocusedPane::QueryWindow, self.is_query_editor_enabled()),
+            (FocusedPane::SqlFiles, self.are_sql_panes_enabled()),
+        ];
+        self.ui
+            .cycle_focus(self.keymap.focus_order(), forward, |pane| {
+                enabled
+                    .iter()
+                    .find(|(listed, _)| *listed == pane)
+                    .map_or(true, |(_, enabled)| *enabled)
+            });
     }
 
     /// Move focus left (Ctrl+h)
@@ -378,8 +517,25 @@ impl AppState {
         self.connection_modal_state = ConnectionModalState::new(); // Reset state
     }
 
+    /// Start the first-run wizard at its driver selection step
+    pub fn open_first_run_wizard(&mut self) {
+        self.first_run_wizard = Some(FirstRunWizard::new());
+        self.ui.show_overlay(OverlayView::Welcome);
+    }
+
+    /// Continue the first-run wizard into the connection form with the
+    /// chosen driver preselected
+    pub fn continue_first_run_wizard(&mut self) {
+        if let Some(wizard) = &self.first_run_wizard {
+            let driver = wizard.selected_driver;
+            self.open_add_connection_modal();
+            self.connection_modal_state.select_database_type(driver);
+        }
+    }
+
     /// Close the add connection modal
     pub fn close_add_connection_modal(&mut self) {
+        self.first_run_wizard = None;
         self.ui.return_to_main();
         self.connection_modal_state.clear(); // Clear any input
     }
@@ -501,10 +657,7 @@ impl AppState {
     pub fn table_down(&mut self) {
         crate::log_debug!("Moving table selection down");
         self.ui.table_selection_down();
-        // Clear metadata when selection changes (will load when Enter is pressed)
-        self.db.current_table_metadata = None;
-        // Reset details pane scroll position for new table
-        self.ui.details_viewport_offset = 0;
+        self.emit_table_changed();
 
         if let Some(table_name) = self.ui.get_selected_table_name() {
             crate::log_debug!("Selected table: {}", table_name);
@@ -517,10 +670,7 @@ impl AppState {
     pub fn table_up(&mut self) {
         crate::log_debug!("Moving table selection up");
         self.ui.table_selection_up();
-        // Clear metadata when selection changes (will load when Enter is pressed)
-        self.db.current_table_metadata = None;
-        // Reset details pane scroll position for new table
-        self.ui.details_viewport_offset = 0;
+        self.emit_table_changed();
 
         if let Some(table_name) = self.ui.get_selected_table_name() {
             crate::log_debug!("Selected table: {}", table_name);
@@ -529,6 +679,37 @@ impl AppState {
         }
     }
 
+    /// Rows of the object tree as it stands
+    pub fn object_tree_rows(&self) -> Vec<TreeRow> {
+        self.object_tree.rows(
+            &self.db.connections.connections,
+            self.db.database_objects.as_ref(),
+        )
+    }
+
+    /// Tell the panes the Tables pane selection moved
+    pub fn emit_table_changed(&mut self) {
+        let table = self.ui.get_selected_table_name();
+        self.session_events
+            .emit(SessionEvent::TableChanged { table });
+    }
+
+    /// Hand the session events emitted since the last dispatch to every
+    /// subscriber, oldest first. Returns whether there were any
+    pub fn dispatch_session_events(&mut self) -> bool {
+        let events = self.session_events.take();
+        for event in &events {
+            crate::log_debug!("Session event: {:?}", event);
+            self.ui.on_session_event(event);
+            self.db.on_session_event(event);
+            self.session_activity.on_session_event(event);
+            self.object_tree.on_session_event(event);
+            self.statement_timeouts.on_session_event(event);
+            self.read_only_guard.on_session_event(event);
+        }
+        !events.is_empty()
+    }
+
     /// Update table list state when tables change
     pub fn update_table_selection(&mut self) {
         // The unified selection system now handles this automatically
@@ -575,7 +756,7 @@ impl AppState {
             self.db.table_load_error = None;
 
             // Reset table viewer state when switching connections
-            self.table_viewer_state = TableViewerState::new();
+            self.table_viewer_state.reset();
 
             // Clear table metadata
             self.db.current_table_metadata = None;
@@ -589,6 +770,7 @@ impl AppState {
 
             // Update connection status based on result
             let connection_succeeded = result.is_ok();
+            let mut database_missing = false;
 
             if let Some(conn) = self.db.connections.connections.get_mut(selected_index) {
                 match result {
@@ -598,6 +780,8 @@ impl AppState {
                         self.db.tables = objects.tables.iter().map(|t| t.name.clone()).collect();
                         if let Some(ref error) = objects.error {
                             self.db.table_load_error = Some(error.clone());
+                            database_missing =
+                                MissingObject::from_error(error) == Some(MissingObject::Database);
                         }
                         // Update the selectable table items list
                         self.ui
@@ -612,7 +796,7 @@ impl AppState {
                         self.ui.build_selectable_table_items(&None);
 
                         // Reset table viewer state when connection fails
-                        self.table_viewer_state = TableViewerState::new();
+                        self.table_viewer_state.reset();
 
                         // Clear table metadata
                         self.db.current_table_metadata = None;
@@ -620,17 +804,26 @@ impl AppState {
                         // Reset query editor when connection fails
                         self.reset_query_editor();
 
-                        self.toast_manager
-                            .error(format!("Connection failed: {error_msg}"));
+                        database_missing =
+                            MissingObject::from_error(&error_msg) == Some(MissingObject::Database);
+                        if !database_missing {
+                            self.toast_manager
+                                .error(format!("Connection failed: {error_msg}"));
+                        }
                     }
                 }
             }
 
             // Handle post-connection tasks after mutable borrow ends
-            if connection_succeeded {
+            if database_missing {
+                self.forget_missing_database(selected_index).await;
+            } else if connection_succeeded {
                 self.update_table_selection();
                 self.toast_manager
                     .success(format!("Connected to {connection_name}"));
+                self.session_events.emit(SessionEvent::ConnectionOpened {
+                    connection_id: connection.id.clone(),
+                });
 
                 // Update active connection in app state database
                 if let Some(conn) = self.get_selected_connection() {
@@ -653,13 +846,53 @@ impl AppState {
         }
     }
 
+    /// Point the selected connection at the database named and connect to
+    /// it, for a user whose privileges don't let them list what they may
+    /// use. The connection keeps the database once connected; a failed
+    /// attempt puts the previous one back
+    pub async fn use_database(&mut self, database: &str) {
+        let Some(index) = self
+            .ui
+            .get_selected_connection_index(&self.db.connections.connections)
+        else {
+            return;
+        };
+        let Some(connection) = self.db.connections.connections.get_mut(index) else {
+            return;
+        };
+        let previous = connection.database.replace(database.to_string());
+        let name = connection.name.clone();
+
+        self.connect_to_selected_database().await;
+
+        let Some(connection) = self.db.connections.connections.get_mut(index) else {
+            return;
+        };
+        if connection.is_connected() {
+            let connection_id = connection.id.clone();
+            self.toast_manager
+                .info(format!("{name} now opens database '{database}'"));
+            self.session_events.emit(SessionEvent::DatabaseChanged {
+                connection_id,
+                database: database.to_string(),
+            });
+        } else {
+            connection.database = previous;
+            std::mem::drop(self.db.connections.save());
+        }
+    }
+
     /// Try to connect to a specific database and return database objects
     async fn try_connect_to_database(
         &mut self,
         connection: &ConnectionConfig,
     ) -> Result<crate::database::DatabaseObjectList, String> {
         self.db
-            .try_connect_to_database(connection, &self.connection_manager)
+            .try_connect_to_database(
+                connection,
+                &self.connection_manager,
+                self.ui.show_system_objects,
+            )
             .await
     }
 
@@ -683,6 +916,9 @@ impl AppState {
             .get_mut(self.ui.selected_connection)
         {
             connection.status = ConnectionStatus::Disconnected;
+            self.session_events.emit(SessionEvent::ConnectionClosed {
+                connection_id: connection.id.clone(),
+            });
             self.db.database_objects = None;
             self.db.tables.clear();
             self.db.table_load_error = None;
@@ -691,7 +927,7 @@ impl AppState {
             self.update_table_selection();
 
             // Reset table viewer state - close all tabs and reset to initial state
-            self.table_viewer_state = TableViewerState::new();
+            self.table_viewer_state.reset();
 
             // Clear table metadata
             self.db.current_table_metadata = None;
@@ -1298,8 +1534,24 @@ impl AppState {
         }
     }
 
-    /// Save current SQL file with connection-specific directory
+    /// Save current SQL file with connection-specific directory, under a
+    /// generated name when no file is loaded
     pub async fn save_sql_file_with_connection(&mut self) -> Result<(), String> {
+        let filename = if let Some(ref current_file) = self.ui.current_sql_file {
+            crate::log_info!("Using existing current file: {}", current_file);
+            current_file.clone()
+        } else {
+            let new_filename = format!("query_{}", chrono::Local::now().format("%Y%m%d_%H%M%S"));
+            crate::log_info!("No current file, generating new filename: {}", new_filename);
+            new_filename
+        };
+
+        self.save_sql_file_as(&filename).await
+    }
+
+    /// Save the query editor's content as `filename` in the selected
+    /// connection's directory and make it the current file
+    pub async fn save_sql_file_as(&mut self, filename: &str) -> Result<(), String> {
         crate::log_info!("=== SAVE SQL FILE DEBUG START ===");
 
         // Sync content from query editor before saving
@@ -1349,16 +1601,6 @@ impl AppState {
         let sql_dir = Config::sql_files_dir().join(&connection_name);
         crate::log_info!("SQL directory path: {:?}", sql_dir);
 
-        // Determine filename
-        let filename = if let Some(ref current_file) = self.ui.current_sql_file {
-            crate::log_info!("Using existing current file: {}", current_file);
-            current_file.clone()
-        } else {
-            let new_filename = format!("query_{}", chrono::Local::now().format("%Y%m%d_%H%M%S"));
-            crate::log_info!("No current file, generating new filename: {}", new_filename);
-            new_filename
-        };
-
         let file_path = sql_dir.join(format!("{}.sql", filename));
         crate::log_info!("Final file path: {:?}", file_path);
 
@@ -1383,11 +1625,17 @@ impl AppState {
 
         // Update state
         crate::log_info!("Updating state - setting current_sql_file to: {}", filename);
-        self.ui.current_sql_file = Some(filename.clone());
+        self.ui.current_sql_file = Some(filename.to_string());
         self.ui.query_modified = false;
+        self.query_editor
+            .set_current_file(Some(filename.to_string()));
+        self.query_editor.mark_saved();
 
         crate::log_info!("Calling refresh_sql_files()");
         self.refresh_sql_files().await;
+        if let Some(index) = self.saved_sql_files.iter().position(|f| f == filename) {
+            self.ui.selected_sql_file = index;
+        }
 
         crate::log_info!("=== SAVE SQL FILE DEBUG END - SUCCESS ===");
 
@@ -1683,8 +1931,10 @@ impl AppState {
         }
     }
 
-    /// Open a table for viewing
-    pub async fn open_table_for_viewing(&mut self) {
+    /// Start opening the selected table for viewing: add its tab, shown
+    /// loading, and hand back a copy to read the first page into in the
+    /// background
+    pub async fn start_table_preview(&mut self) -> Option<TableTab> {
         crate::log_info!("Attempting to open table for viewing");
 
         // Check connection health before attempting to open table
@@ -1692,154 +1942,946 @@ impl AppState {
             crate::log_warn!("Cannot open table: database connection is not available");
             self.toast_manager
                 .error("Cannot open table: database connection is not available");
-            return;
+            return None;
         }
 
-        if let Some(table_name) = self.ui.get_selected_table_name() {
-            crate::log_info!("Opening table '{}' for viewing", table_name);
-            // Add tab to viewer
-            let tab_idx = self.table_viewer_state.add_tab(table_name.clone());
-            crate::log_debug!(
-                "Created new tab with index {} for table '{}'",
-                tab_idx,
-                table_name
-            );
-
-            // Load table data
-            if let Err(e) = self.load_table_data(tab_idx).await {
-                crate::log_error!("Failed to load table data for '{}': {}", table_name, e);
-                if let Some(tab) = self.table_viewer_state.tabs.get_mut(tab_idx) {
-                    tab.error = Some(format!("Failed to load table: {e}"));
-                    tab.loading = false;
-                }
+        let Some(table_name) = self.ui.get_selected_table_name() else {
+            crate::log_warn!("Attempted to open table but no table is selected");
+            return None;
+        };
+        let tab_idx = self.add_table_tab(&table_name);
+        let tab = self.table_viewer_state.tabs.get_mut(tab_idx)?;
+        tab.loading = true;
+        tab.error = None;
+        let tab = tab.clone();
 
-                // Check if this was a connection issue and update status accordingly
-                if e.contains("connection") || e.contains("Connection") || e.contains("disconnect")
-                {
-                    crate::log_warn!("Connection issue detected while loading table data, checking connection health");
-                    let _ = self.check_connection_health().await;
-                }
-            } else {
-                crate::log_info!("Successfully loaded table data for '{}'", table_name);
-            }
+        self.ui.focused_pane = FocusedPane::TabularOutput;
+        Some(tab)
+    }
 
-            // Load table metadata for the details pane
-            if let Err(e) = self.load_table_metadata(&table_name).await {
-                crate::log_error!("Failed to load table metadata for '{}': {}", table_name, e);
-                self.toast_manager
-                    .error(format!("Failed to load table metadata: {e}"));
-            } else {
-                crate::log_debug!("Successfully loaded table metadata for '{}'", table_name);
-            }
+    /// Show the first page of a table read in the background, unless its
+    /// tab has been closed since
+    pub async fn table_preview_loaded(
+        &mut self,
+        table_name: &str,
+        result: Result<TableTab, String>,
+    ) {
+        let Some(tab) = self
+            .table_viewer_state
+            .tabs
+            .iter_mut()
+            .find(|tab| tab.table_name == table_name)
+        else {
+            crate::log_debug!("Tab for '{}' closed while it was loading", table_name);
+            return;
+        };
+        let result = result.map(|loaded| *tab = loaded);
+        self.finish_table_preview(table_name, result).await;
 
-            // Switch focus to tabular output
-            self.ui.focused_pane = FocusedPane::TabularOutput;
-            crate::log_debug!(
-                "Switched focus to tabular output for table '{}'",
-                table_name
-            );
-        } else {
-            crate::log_warn!("Attempted to open table but no table is selected");
+        // Go on to the query editor once the table is open
+        if self.ui.auto_advance_focus && self.ui.focused_pane == FocusedPane::TabularOutput {
+            self.ui.focused_pane = FocusedPane::QueryWindow;
         }
     }
 
-    /// Load table data for a specific tab
-    pub async fn load_table_data(&mut self, tab_idx: usize) -> Result<(), String> {
-        self.db
-            .load_table_data(
-                &mut self.table_viewer_state,
-                self.ui.selected_connection,
-                tab_idx,
-                &self.connection_manager,
-            )
-            .await
-    }
-
-    /// Load table metadata for the details pane
-    pub async fn load_table_metadata(&mut self, table_name: &str) -> Result<(), String> {
-        self.db
-            .load_table_metadata(
-                table_name,
-                self.ui.selected_connection,
-                &self.connection_manager,
-            )
-            .await
-    }
+    /// Open the table whose name is on the selected row of a column search
+    /// result, showing its structure
+    pub async fn open_column_search_match(&mut self) {
+        let Some(table_name) = self
+            .table_viewer_state
+            .current_tab()
+            .filter(|tab| tab.column_search)
+            .and_then(|tab| tab.rows.get(tab.selected_row))
+            .and_then(|row| row.first().cloned())
+        else {
+            return;
+        };
 
-    /// Check the health of the currently selected connection and update status
-    pub async fn check_connection_health(&mut self) -> bool {
-        if let Some(connection) = self.get_selected_connection() {
-            // Use ConnectionManager to check if connection is healthy
-            let is_healthy = self.connection_manager.is_connected(&connection.id).await;
+        if !self.check_connection_health().await {
+            self.toast_manager
+                .error("Cannot open table: database connection is not available");
+            return;
+        }
+        self.open_table(table_name).await;
 
-            // Update connection status based on health check
-            if let Some(conn) = self.get_selected_connection_mut() {
-                if !is_healthy && matches!(conn.status, ConnectionStatus::Connected) {
-                    // Connection was supposed to be connected but is not healthy
-                    conn.status = ConnectionStatus::Failed("Connection lost".to_string());
+        if let Some(tab) = self.table_viewer_state.current_tab_mut() {
+            tab.view_mode = crate::ui::components::TableViewMode::Schema;
+            tab.scroll_offset_y = 0;
+        }
+    }
 
-                    // Clear database objects and tables
-                    self.db.database_objects = None;
-                    self.db.tables.clear();
-                    self.db.table_load_error = Some("Connection lost".to_string());
-                    self.ui.build_selectable_table_items(&None);
+    /// Show the first rows of the partition selected in the structure view in
+    /// a results tab
+    pub async fn preview_selected_partition(&mut self) {
+        let Some((table_name, partition)) = self.table_viewer_state.current_tab().and_then(|tab| {
+            tab.selected_partition()
+                .map(|partition| (tab.table_name.clone(), partition.clone()))
+        }) else {
+            return;
+        };
+        let Some((connection_id, database_type)) = self
+            .get_selected_connection()
+            .filter(|connection| connection.is_connected())
+            .map(|connection| (connection.id.clone(), connection.database_type.clone()))
+        else {
+            self.toast_manager.error("Not connected to database");
+            return;
+        };
 
-                    // Show user feedback
-                    self.toast_manager.error("Database connection lost");
+        let query = partition_preview_sql(
+            &database_type,
+            &table_name,
+            &partition,
+            PARTITION_PREVIEW_ROWS,
+        );
+        match self
+            .connection_manager
+            .execute_limited_query(&connection_id, &query, self.result_limits)
+            .await
+        {
+            Ok(result) => {
+                let tab_idx = self
+                    .table_viewer_state
+                    .add_tab(format!("{table_name} › {}", partition.name));
+                if let Some(tab) = self.table_viewer_state.tabs.get_mut(tab_idx) {
+                    tab.set_query_result(result);
+                    tab.query = Some(query);
+                    tab.query_connection = Some(connection_id.clone());
+                }
+                self.ui.focused_pane = FocusedPane::TabularOutput;
+            }
+            Err(e) => {
+                crate::log_error!("Previewing partition '{}' failed: {}", partition.name, e);
+                self.toast_manager
+                    .error(format!("Failed to preview partition: {e}"));
+            }
+        }
+    }
 
-                    // Save updated connection status (fire-and-forget)
-                    std::mem::drop(self.db.connections.save());
+    /// Open the insert-row form on the table of the current preview, with a
+    /// field for each column the insert can set. With `copy_selected_row`
+    /// the fields start from the selected row, primary key cleared
+    pub async fn open_insert_row_form(&mut self, copy_selected_row: bool) {
+        let Some(table_name) = self
+            .table_viewer_state
+            .current_tab()
+            .filter(|tab| tab.is_table_preview())
+            .map(|tab| tab.table_name.clone())
+        else {
+            self.toast_manager
+                .info("Rows are inserted from a table preview; open a table first");
+            return;
+        };
+        let Some(connection_id) = self
+            .get_selected_connection()
+            .filter(|connection| connection.is_connected())
+            .map(|connection| connection.id.clone())
+        else {
+            self.toast_manager.error("Not connected to database");
+            return;
+        };
 
-                    return false;
+        match self
+            .connection_manager
+            .get_table_columns(&connection_id, &table_name)
+            .await
+        {
+            Ok(columns) => {
+                let mut form = InsertRowForm::new(table_name, &columns);
+                if copy_selected_row {
+                    // Cells cut short by the preview are copied in full
+                    if let Err(e) = self.load_full_cell_values(true).await {
+                        self.toast_manager
+                            .error(format!("Failed to read the row: {e}"));
+                        return;
+                    }
+                    let Some(tab) = self
+                        .table_viewer_state
+                        .current_tab()
+                        .filter(|tab| tab.selected_row < tab.rows.len())
+                    else {
+                        self.toast_manager.info("No row selected to duplicate");
+                        return;
+                    };
+                    let row: Vec<(String, String)> = tab
+                        .columns
+                        .iter()
+                        .enumerate()
+                        .map(|(idx, col)| {
+                            (col.name.clone(), tab.full_cell_value(tab.selected_row, idx))
+                        })
+                        .collect();
+                    let primary_key: Vec<String> = columns
+                        .iter()
+                        .filter(|col| col.is_primary_key)
+                        .map(|col| col.name.clone())
+                        .collect();
+                    form.copy_row(&row, &primary_key);
                 }
+                self.table_viewer_state.insert_form = Some(form);
+            }
+            Err(e) => {
+                crate::log_error!("Reading the columns of '{}' failed: {}", table_name, e);
+                self.toast_manager
+                    .error(format!("Failed to read the table's columns: {e}"));
             }
-
-            is_healthy
-        } else {
-            false
         }
     }
 
-    /// Update a cell in the database
-    pub async fn update_table_cell(
-        &mut self,
-        update: crate::ui::components::table_viewer::CellUpdate,
-    ) -> Result<(), String> {
-        self.db
-            .update_table_cell(
-                update,
-                self.ui.selected_connection,
-                &self.connection_manager,
-            )
+    /// Insert the row of the insert-row form, then refresh the preview and
+    /// show the inserted row in a results tab where the database returns it
+    pub async fn insert_row(&mut self) {
+        let Some(form) = self.table_viewer_state.insert_form.as_ref() else {
+            return;
+        };
+        let values = match form.values() {
+            Ok(values) => values,
+            Err(e) => {
+                self.toast_manager.warning(e);
+                return;
+            }
+        };
+        let table_name = form.table_name.clone();
+        let Some(connection_id) = self
+            .get_selected_connection()
+            .filter(|connection| connection.is_connected())
+            .map(|connection| connection.id.clone())
+        else {
+            self.toast_manager.error("Not connected to database");
+            return;
+        };
+        if let Err(e) = self.check_writable() {
+            self.toast_manager.error(e);
+            return;
+        }
+
+        let (columns, rows) = match self
+            .connection_manager
+            .insert_row(&connection_id, &table_name, &values)
             .await
+        {
+            Ok(inserted) => inserted,
+            Err(e) => {
+                // The form stays open to fix the values
+                crate::log_error!("Inserting into '{}' failed: {}", table_name, e);
+                self.toast_manager.error(format!("Insert failed: {e}"));
+                return;
+            }
+        };
+        self.table_viewer_state.insert_form = None;
+
+        let preview_idx = self.table_viewer_state.active_tab;
+        self.record_insert_undo(preview_idx, &table_name, &columns, &rows);
+        if let Err(e) = self.load_table_data(preview_idx).await {
+            crate::log_warn!("Refreshing '{}' after the insert failed: {}", table_name, e);
+        }
+        if !rows.is_empty() {
+            let tab_idx = self
+                .table_viewer_state
+                .add_tab(format!("Inserted into {table_name}"));
+            if let Some(tab) = self.table_viewer_state.tabs.get_mut(tab_idx) {
+                tab.set_query_result(QueryResult {
+                    columns,
+                    rows,
+                    ..QueryResult::default()
+                });
+            }
+        }
+        self.toast_manager
+            .success(format!("Inserted a row into {table_name}"));
     }
 
-    /// Delete a row from the database
-    pub async fn delete_table_row(
-        &mut self,
-        confirmation: crate::ui::components::table_viewer::DeleteConfirmation,
-    ) -> Result<(), String> {
+    /// Search every table for columns whose name contains the column search
+    /// query, listing the matches in a results tab
+    pub async fn search_columns(&mut self) {
+        let pattern = self.ui.column_search_query.trim().to_string();
+        if pattern.is_empty() {
+            self.toast_manager.warning("Enter part of a column name");
+            return;
+        }
+        let Some(connection_id) = self
+            .get_selected_connection()
+            .filter(|connection| connection.is_connected())
+            .map(|connection| connection.id.clone())
+        else {
+            self.toast_manager.error("Not connected to database");
+            return;
+        };
+
+        match self
+            .connection_manager
+            .search_columns(&connection_id, &pattern)
+            .await
+        {
+            Ok(matches) if matches.is_empty() => {
+                self.toast_manager
+                    .info(format!("No columns match '{pattern}'"));
+            }
+            Ok(matches) => {
+                let count = matches.len();
+                let tab_idx = self
+                    .table_viewer_state
+                    .add_tab(format!("Columns: {pattern}"));
+                if let Some(tab) = self.table_viewer_state.tabs.get_mut(tab_idx) {
+                    tab.set_query_result(QueryResult {
+                        columns: vec![
+                            "table".to_string(),
+                            "column".to_string(),
+                            "type".to_string(),
+                        ],
+                        rows: matches
+                            .into_iter()
+                            .map(|m| vec![m.table, m.column, m.data_type])
+                            .collect(),
+                        ..QueryResult::default()
+                    });
+                    tab.column_search = true;
+                }
+                self.ui.focused_pane = FocusedPane::TabularOutput;
+                self.toast_manager.success(format!(
+                    "{count} column{} match '{pattern}' (Enter opens the table structure)",
+                    if count == 1 { "" } else { "s" }
+                ));
+            }
+            Err(e) => {
+                crate::log_error!("Column search for '{}' failed: {}", pattern, e);
+                self.toast_manager
+                    .error(format!("Column search failed: {e}"));
+            }
+        }
+    }
+
+    /// List the columns of the table selected in the Tables pane in a
+    /// results tab, with their types, keys and the indexes covering them
+    pub async fn inspect_selected_table(&mut self) {
+        let Some(table_name) = self.ui.get_selected_table_name() else {
+            self.toast_manager.warning("Select a table to inspect");
+            return;
+        };
+        let Some(connection_id) = self
+            .get_selected_connection()
+            .filter(|connection| connection.is_connected())
+            .map(|connection| connection.id.clone())
+        else {
+            self.toast_manager.error("Not connected to database");
+            return;
+        };
+
+        let columns = match self
+            .connection_manager
+            .get_table_columns(&connection_id, &table_name)
+            .await
+        {
+            // A table dropped meanwhile has no columns rather than an error
+            Ok(columns) if columns.is_empty() => {
+                self.toast_manager
+                    .error(format!("Table {table_name} no longer exists"));
+                return;
+            }
+            Ok(columns) => columns,
+            Err(e) => {
+                crate::log_error!("Inspecting '{}' failed: {}", table_name, e);
+                self.toast_manager
+                    .error(format!("Failed to read the columns of {table_name}: {e}"));
+                return;
+            }
+        };
+        // The columns are still worth showing without their indexes
+        let indexes = self
+            .connection_manager
+            .get_table_indexes(&connection_id, &table_name)
+            .await
+            .unwrap_or_else(|e| {
+                crate::log_warn!("Failed to list indexes of {}: {}", table_name, e);
+                self.toast_manager
+                    .warning(format!("Failed to list the indexes of {table_name}: {e}"));
+                Vec::new()
+            });
+
+        let tab_idx = self
+            .table_viewer_state
+            .add_tab(format!("Structure: {table_name}"));
+        if let Some(tab) = self.table_viewer_state.tabs.get_mut(tab_idx) {
+            tab.set_query_result(crate::database::structure::structure_result(
+                &columns, &indexes,
+            ));
+            tab.structure_of = Some(table_name);
+        }
+        self.ui.focused_pane = FocusedPane::TabularOutput;
+    }
+
+    /// Open a table in a tab (or focus its existing tab), loading its data and
+    /// the details pane metadata
+    async fn open_table(&mut self, table_name: String) {
+        let tab_idx = self.add_table_tab(&table_name);
+        let result = self.load_table_data(tab_idx).await;
+        self.finish_table_preview(&table_name, result).await;
+    }
+
+    /// Add a tab for a table, or select its open one, recording the visit
+    fn add_table_tab(&mut self, table_name: &str) -> usize {
+        crate::log_info!("Opening table '{}' for viewing", table_name);
+        if let Some(connection_id) = self.get_selected_connection().map(|c| c.id.clone()) {
+            self.ui.record_recent_table(&connection_id, table_name);
+            self.ui.jumps.record(crate::state::jumps::Jump {
+                connection_id,
+                table_name: table_name.to_string(),
+            });
+        }
+
+        let tab_idx = self.table_viewer_state.add_tab(table_name.to_string());
+        crate::log_debug!(
+            "Created new tab with index {} for table '{}'",
+            tab_idx,
+            table_name
+        );
+        tab_idx
+    }
+
+    /// Show how reading a table's first page went, then load its details
+    async fn finish_table_preview(&mut self, table_name: &str, result: Result<(), String>) {
+        let tab_idx = self
+            .table_viewer_state
+            .tabs
+            .iter()
+            .position(|tab| tab.table_name == table_name);
+
+        if let Err(e) = result {
+            // Dropped by someone else since the tables list was loaded
+            match MissingObject::from_error(&e) {
+                Some(MissingObject::Database) => {
+                    self.forget_missing_database(self.ui.selected_connection)
+                        .await;
+                    return;
+                }
+                Some(MissingObject::Table) => {
+                    if let Some(tab_idx) = tab_idx {
+                        self.table_viewer_state.active_tab = tab_idx;
+                        self.table_viewer_state.close_current_tab();
+                    }
+                    self.forget_missing_table(table_name).await;
+                    return;
+                }
+                None => {}
+            }
+
+            crate::log_error!("Failed to load table data for '{}': {}", table_name, e);
+            if let Some(tab) = tab_idx.and_then(|idx| self.table_viewer_state.tabs.get_mut(idx)) {
+                tab.error = Some(format!("Failed to load table: {e}"));
+                tab.loading = false;
+            }
+
+            // Check if this was a connection issue and update status accordingly
+            if e.contains("connection") || e.contains("Connection") || e.contains("disconnect") {
+                crate::log_warn!("Connection issue detected while loading table data, checking connection health");
+                let _ = self.check_connection_health().await;
+            }
+        } else {
+            crate::log_info!("Successfully loaded table data for '{}'", table_name);
+        }
+
+        // Load table metadata for the details pane
+        if let Err(e) = self.load_table_metadata(table_name).await {
+            crate::log_error!("Failed to load table metadata for '{}': {}", table_name, e);
+            self.toast_manager
+                .error(format!("Failed to load table metadata: {e}"));
+        } else {
+            crate::log_debug!("Successfully loaded table metadata for '{}'", table_name);
+        }
+
+        // Switch focus to tabular output
+        self.ui.focused_pane = FocusedPane::TabularOutput;
+        crate::log_debug!(
+            "Switched focus to tabular output for table '{}'",
+            table_name
+        );
+    }
+
+    /// Select the table of a jump back or forward again, on its connection
+    /// once connected: in the tables list, its tab if still open, and its
+    /// details
+    pub async fn restore_jump(&mut self, jump: &crate::state::jumps::Jump) {
+        if !self.ui.select_table_by_name(&jump.table_name) {
+            self.toast_manager
+                .warning(format!("{} isn't in the tables list", jump.table_name));
+            return;
+        }
+        if let Some(tab_idx) = self
+            .table_viewer_state
+            .tabs
+            .iter()
+            .position(|tab| tab.table_name == jump.table_name)
+        {
+            self.table_viewer_state.active_tab = tab_idx;
+        }
+        if let Err(e) = self.load_table_metadata(&jump.table_name).await {
+            self.toast_manager
+                .error(format!("Failed to load table metadata: {e}"));
+        }
+        let (position, count) = self.ui.jumps.progress();
+        self.toast_manager
+            .info(format!("{} ({position}/{count})", jump.table_name));
+    }
+
+    /// Load table data for a specific tab
+    pub async fn load_table_data(&mut self, tab_idx: usize) -> Result<(), String> {
+        self.db
+            .load_table_data(
+                &mut self.table_viewer_state,
+                self.ui.selected_connection,
+                tab_idx,
+                &self.connection_manager,
+            )
+            .await
+    }
+
+    /// Read the full values of preview cells of the selected row cut short
+    /// by the cell limit: the selected cell, or the whole row
+    pub async fn load_full_cell_values(&mut self, whole_row: bool) -> Result<(), String> {
+        self.db
+            .load_full_cell_values(
+                &mut self.table_viewer_state,
+                self.ui.selected_connection,
+                &self.connection_manager,
+                whole_row,
+            )
+            .await
+    }
+
+    /// Read the cut short values of every loaded row of the current tab in
+    /// full, for copying the whole result
+    pub async fn load_all_full_cell_values(&mut self) -> Result<(), String> {
+        self.db
+            .load_all_full_cell_values(
+                &mut self.table_viewer_state,
+                self.ui.selected_connection,
+                &self.connection_manager,
+            )
+            .await
+    }
+
+    /// Load table metadata for the details pane
+    pub async fn load_table_metadata(&mut self, table_name: &str) -> Result<(), String> {
+        self.db
+            .load_table_metadata(
+                table_name,
+                self.ui.selected_connection,
+                &self.connection_manager,
+            )
+            .await
+    }
+
+    /// Check the health of the currently selected connection and update status
+    pub async fn check_connection_health(&mut self) -> bool {
+        if let Some(connection) = self.get_selected_connection() {
+            // Use ConnectionManager to check if connection is healthy
+            let is_healthy = self.connection_manager.is_connected(&connection.id).await;
+
+            // Update connection status based on health check
+            if let Some(conn) = self.get_selected_connection_mut() {
+                if !is_healthy && matches!(conn.status, ConnectionStatus::Connected) {
+                    // Connection was supposed to be connected but is not healthy
+                    conn.status = ConnectionStatus::Failed("Connection lost".to_string());
+
+                    // Clear database objects and tables
+                    self.db.database_objects = None;
+                    self.db.tables.clear();
+                    self.db.table_load_error = Some("Connection lost".to_string());
+                    self.ui.build_selectable_table_items(&None);
+
+                    // Show user feedback
+                    self.toast_manager.error("Database connection lost");
+
+                    // Save updated connection status (fire-and-forget)
+                    std::mem::drop(self.db.connections.save());
+
+                    return false;
+                }
+            }
+
+            is_healthy
+        } else {
+            false
+        }
+    }
+
+    /// The database behind a connection was dropped by someone else: drop the
+    /// connection and clear the panels that showed its contents
+    pub async fn forget_missing_database(&mut self, connection_index: usize) {
+        let Some(connection) = self.db.connections.connections.get_mut(connection_index) else {
+            return;
+        };
+        let message = format!(
+            "Database '{}' no longer exists",
+            connection.database.as_deref().unwrap_or(&connection.name)
+        );
+        crate::log_warn!("{}", message);
+        connection.status = ConnectionStatus::Failed(message.clone());
+        let connection_id = connection.id.clone();
+        let _ = self.connection_manager.disconnect(&connection_id).await;
+
+        self.db.database_objects = None;
+        self.db.tables.clear();
+        self.db.table_load_error = Some(message.clone());
+        self.db.current_table_metadata = None;
+        self.ui.build_selectable_table_items(&None);
+        self.table_viewer_state.reset();
+        self.toast_manager.error(message);
+
+        // Save updated connection status (fire-and-forget)
+        std::mem::drop(self.db.connections.save());
+    }
+
+    /// A table was dropped by someone else: say so and reload the tables list
+    /// so it disappears from the Tables pane
+    pub async fn forget_missing_table(&mut self, table_name: &str) {
+        crate::log_warn!("Table '{}' no longer exists", table_name);
+        self.toast_manager
+            .error(format!("Table '{table_name}' no longer exists"));
+        self.db.current_table_metadata = None;
+        self.reload_database_objects().await;
+    }
+
+    /// Show or hide system schemas and tables in the tables pane, listing the
+    /// selected connection's objects again
+    pub async fn toggle_system_objects(&mut self) {
+        self.ui.show_system_objects = !self.ui.show_system_objects;
+        self.toast_manager.info(if self.ui.show_system_objects {
+            "Showing system schemas and tables"
+        } else {
+            "Hiding system schemas and tables"
+        });
+        self.reload_database_objects().await;
+    }
+
+    /// List the selected connection's objects again without reconnecting
+    async fn reload_database_objects(&mut self) {
+        let connection_index = self.ui.selected_connection;
+        let Some(connection_id) = self
+            .get_selected_connection()
+            .filter(|connection| connection.is_connected())
+            .map(|connection| connection.id.clone())
+        else {
+            return;
+        };
+
+        let error = match self
+            .connection_manager
+            .list_database_objects(&connection_id, self.ui.show_system_objects)
+            .await
+        {
+            Ok(objects) => {
+                let error = objects.error.clone();
+                self.db.tables = objects.tables.iter().map(|t| t.name.clone()).collect();
+                self.db.table_load_error = error.clone();
+                self.db.database_objects = Some(objects);
+                self.update_table_selection();
+                error
+            }
+            Err(e) => Some(e.to_string()),
+        };
+
+        if let Some(error) = error {
+            if MissingObject::from_error(&error) == Some(MissingObject::Database) {
+                self.forget_missing_database(connection_index).await;
+            } else {
+                crate::log_warn!("Failed to reload database objects: {}", error);
+            }
+        }
+    }
+
+    /// Update a cell in the database, recording the statement setting the
+    /// previous value again in the undo log
+    pub async fn update_table_cell(
+        &mut self,
+        update: crate::ui::components::table_viewer::CellUpdate,
+    ) -> Result<(), String> {
+        self.check_writable()?;
+        // The key after the edit, which may have changed it
+        let undo = self.table_viewer_state.current_tab().map(|tab| {
+            let key = tab.key_cells(update.row_index);
+            let previous = tab
+                .columns
+                .iter()
+                .find(|column| column.name == update.column_name)
+                .map(|column| {
+                    crate::database::CellValue::from_grid(
+                        column.name.clone(),
+                        column.data_type.clone(),
+                        update.previous_value.clone(),
+                    )
+                });
+            (key, previous)
+        });
+        let table_name = update.table_name.clone();
+        let description = format!("Set {} in {}", update.column_name, table_name);
+
+        self.db
+            .update_table_cell(
+                update,
+                self.ui.selected_connection,
+                &self.connection_manager,
+            )
+            .await?;
+
+        if let (Some((key, Some(previous))), Some(database_type)) =
+            (undo, self.selected_database_type())
+        {
+            let inverse =
+                crate::database::undo::set_cell(&database_type, &table_name, &key, &previous);
+            self.record_undo(&table_name, &key, description, inverse);
+        }
+        Ok(())
+    }
+
+    /// Delete a row from the database, recording the statement inserting it
+    /// again in the undo log
+    pub async fn delete_table_row(
+        &mut self,
+        confirmation: crate::ui::components::table_viewer::DeleteConfirmation,
+    ) -> Result<(), String> {
+        self.check_writable()?;
+        // The row is inserted again in full, so read what the preview cut short
+        let row = match self.load_full_cell_values(true).await {
+            Ok(()) => self.table_viewer_state.current_tab().map(|tab| {
+                (
+                    tab.key_cells(confirmation.row_index),
+                    tab.row_cells(confirmation.row_index),
+                )
+            }),
+            Err(e) => {
+                crate::log_warn!("Reading the row to delete in full failed: {}", e);
+                None
+            }
+        };
+        let table_name = confirmation.table_name.clone();
+
         self.db
             .delete_table_row(
                 confirmation,
                 self.ui.selected_connection,
                 &self.connection_manager,
             )
-            .await
+            .await?;
+
+        match (row, self.selected_database_type()) {
+            (Some((key, row)), Some(database_type)) => {
+                let inverse =
+                    crate::database::undo::reinsert_row(&database_type, &table_name, &row);
+                self.record_undo(
+                    &table_name,
+                    &key,
+                    format!("Deleted a row of {table_name}"),
+                    inverse,
+                );
+            }
+            _ => self
+                .toast_manager
+                .warning("The deleted row couldn't be read in full; it isn't in the undo log"),
+        }
+        Ok(())
     }
 
-    /// Set a cell to NULL in the database
+    /// Set a cell to NULL in the database, recording the statement setting
+    /// the previous value again in the undo log
     pub async fn set_cell_to_null(
         &mut self,
         confirmation: crate::ui::components::table_viewer::SetNullConfirmation,
     ) -> Result<(), String> {
+        self.check_writable()?;
+        let undo = match self.load_full_cell_values(false).await {
+            Ok(()) => self.table_viewer_state.current_tab().map(|tab| {
+                (
+                    tab.key_cells(confirmation.row_index),
+                    tab.cell(confirmation.row_index, confirmation.col_index),
+                )
+            }),
+            Err(e) => {
+                crate::log_warn!("Reading the cell to set NULL in full failed: {}", e);
+                None
+            }
+        };
+        let table_name = confirmation.table_name.clone();
+        let description = format!("Set {} to NULL in {}", confirmation.column_name, table_name);
+
         self.db
             .set_cell_to_null(
                 confirmation,
                 self.ui.selected_connection,
                 &self.connection_manager,
             )
+            .await?;
+
+        match (undo, self.selected_database_type()) {
+            (Some((key, Some(previous))), Some(database_type)) => {
+                let inverse =
+                    crate::database::undo::set_cell(&database_type, &table_name, &key, &previous);
+                self.record_undo(&table_name, &key, description, inverse);
+            }
+            _ => self
+                .toast_manager
+                .warning("The previous value couldn't be read in full; it isn't in the undo log"),
+        }
+        Ok(())
+    }
+
+    /// Record the statement deleting an inserted row, keyed by the primary
+    /// key of the preview at `preview_idx` as the database returned it. A
+    /// database that doesn't return inserted rows (MySQL) leaves no entry
+    fn record_insert_undo(
+        &mut self,
+        preview_idx: usize,
+        table_name: &str,
+        columns: &[String],
+        rows: &[Vec<String>],
+    ) {
+        let (Some(tab), Some(row)) = (self.table_viewer_state.tabs.get(preview_idx), rows.first())
+        else {
+            return;
+        };
+        let key: Vec<crate::database::CellValue> = tab
+            .primary_key_columns
+            .iter()
+            .filter_map(|&col| tab.columns.get(col))
+            .filter_map(|column| {
+                let index = columns.iter().position(|name| *name == column.name)?;
+                Some(crate::database::CellValue {
+                    column: column.name.clone(),
+                    data_type: column.data_type.clone(),
+                    value: Some(row.get(index)?.clone()),
+                })
+            })
+            .collect();
+        if key.len() != tab.primary_key_columns.len() {
+            return;
+        }
+        if let Some(database_type) = self.selected_database_type() {
+            let inverse = crate::database::undo::delete_row(&database_type, table_name, &key);
+            self.record_undo(
+                table_name,
+                &key,
+                format!("Inserted a row into {table_name}"),
+                inverse,
+            );
+        }
+    }
+
+    /// Refuse a write to the selected connection while the read-only guard
+    /// is on for it, saying why and how to lift it
+    pub fn check_writable(&self) -> Result<(), String> {
+        use crate::database::server_role::ServerRole;
+
+        let Some(connection) = self.get_selected_connection() else {
+            return Ok(());
+        };
+        if !self.read_only_guard.is_guarded(&connection.id) {
+            return Ok(());
+        }
+        let reason = match self.read_only_guard.role(&connection.id) {
+            ServerRole::Replica => "is a read replica",
+            ServerRole::ReadOnly => "is a read-only server",
+            ServerRole::Primary => "has writes turned off",
+        };
+        Err(format!(
+            "{} {reason}; :readonly off in the query editor allows writes",
+            connection.name
+        ))
+    }
+
+    /// Type of the selected connection's database
+    fn selected_database_type(&self) -> Option<crate::database::DatabaseType> {
+        self.get_selected_connection()
+            .map(|connection| connection.database_type.clone())
+    }
+
+    /// Add the change to the undo log; one without a statement reversing
+    /// it, for a row without a primary key, is only logged
+    fn record_undo(
+        &mut self,
+        table_name: &str,
+        key: &[crate::database::CellValue],
+        description: String,
+        inverse: Option<String>,
+    ) {
+        let Some(connection_id) = self
+            .get_selected_connection()
+            .map(|connection| connection.id.clone())
+        else {
+            return;
+        };
+        let Some(inverse) = inverse else {
+            crate::log_warn!("Not in the undo log, no primary key: {}", description);
+            return;
+        };
+        let description = if key.is_empty() {
+            description
+        } else {
+            format!(
+                "{description} ({})",
+                crate::database::undo::describe_key(key)
+            )
+        };
+        self.undo_log.record(UndoEntry {
+            connection_id,
+            table_name: table_name.to_string(),
+            description,
+            inverse,
+            recorded_at: chrono::Local::now(),
+            applied: false,
+        });
+    }
+
+    /// Run the statement of undo log entry `index` on its connection, then
+    /// reload the current tab when it previews the same table
+    pub async fn apply_undo(&mut self, index: usize) {
+        let Some(entry) = self.undo_log.get(index).cloned() else {
+            return;
+        };
+        if entry.applied {
+            self.toast_manager
+                .info("That change has been undone already");
+            return;
+        }
+        match self.get_selected_connection() {
+            Some(connection)
+                if connection.id == entry.connection_id && connection.is_connected() => {}
+            _ => {
+                self.toast_manager
+                    .error("Select and connect to the connection the change was made on");
+                return;
+            }
+        }
+        if let Err(e) = self.check_writable() {
+            self.toast_manager.error(e);
+            return;
+        }
+
+        if let Err(e) = self
+            .connection_manager
+            .execute_raw_query(&entry.connection_id, &entry.inverse)
             .await
+        {
+            crate::log_error!("Undoing '{}' failed: {}", entry.description, e);
+            self.toast_manager.error(format!("Undo failed: {e}"));
+            return;
+        }
+        self.undo_log.mark_applied(index);
+        self.toast_manager
+            .success(format!("Undone: {}", entry.description));
+
+        let previews_table = self
+            .table_viewer_state
+            .current_tab()
+            .is_some_and(|tab| tab.is_table_preview() && tab.table_name == entry.table_name);
+        if previews_table {
+            if let Err(e) = self.reload_current_table_tab().await {
+                crate::log_warn!(
+                    "Refreshing '{}' after the undo failed: {}",
+                    entry.table_name,
+                    e
+                );
+            }
+        }
     }
 
     /// Reload current table tab data
@@ -1891,14 +2933,17 @@ impl AppState {
     }
 
     /// Check if Tables pane should be enabled
-    /// Returns true only if there is an active connected connection
+    /// Returns true only if there is an active connected connection, and
+    /// the object tree isn't shown in its place
     pub fn is_tables_pane_enabled(&self) -> bool {
-        self.db
-            .connections
-            .connections
-            .get(self.ui.selected_connection)
-            .map(|conn| conn.is_connected())
-            .unwrap_or(false)
+        self.ui.sidebar_mode == SidebarMode::Panels
+            && self
+                .db
+                .connections
+                .connections
+                .get(self.ui.selected_connection)
+                .map(|conn| conn.is_connected())
+                .unwrap_or(false)
     }
 
     /// Check if Details pane should be enabled
@@ -1973,6 +3018,13 @@ impl AppState {
         self.ui.query_modified = true;
     }
 
+    /// Insert text at the query editor's cursor
+    pub fn insert_into_query_editor(&mut self, text: &str) {
+        self.query_editor.insert_text(text);
+        self.query_content = self.query_editor.get_content().to_string();
+        self.ui.query_modified = true;
+    }
+
     /// Handle newline in query editor
     pub fn handle_query_editor_newline(&mut self) {
         self.query_editor.insert_newline();
@@ -2011,22 +3063,102 @@ impl AppState {
         Ok(())
     }
 
-    /// Execute the SQL statement at cursor position
-    pub async fn execute_query_at_cursor(&mut self) -> Result<(), String> {
-        // First, ensure we have a connected database
-        let selected_connection_idx = self.ui.selected_connection;
+    /// Restart the countdown of every watched query result
+    pub fn hold_query_watches(&mut self) {
+        let now = std::time::Instant::now();
+        for tab in &mut self.table_viewer_state.tabs {
+            if let Some(watch) = tab.watch.as_mut() {
+                watch.hold(now);
+            }
+        }
+    }
 
-        // Check if we have a valid connection
-        if selected_connection_idx >= self.db.connections.connections.len() {
-            self.toast_manager.error("No connection selected");
-            return Err("No connection selected".to_string());
+    /// Watched results whose query is due to run again, as (watch id,
+    /// connection id, query), each marked as refreshing. The query runs on
+    /// the connection that produced the result; a watch whose connection is
+    /// gone stops
+    pub fn due_query_watches(&mut self) -> Vec<(u64, String, String)> {
+        // Wait for a manual query to finish first
+        if self.fetch_progress.is_some() {
+            self.hold_query_watches();
+            return Vec::new();
         }
 
-        let connection = &self.db.connections.connections[selected_connection_idx];
-        if !connection.is_connected() {
-            self.toast_manager.error("Not connected to database");
-            return Err("Not connected to database".to_string());
+        let now = std::time::Instant::now();
+        let mut due = Vec::new();
+        for tab_idx in 0..self.table_viewer_state.tabs.len() {
+            let tab = &mut self.table_viewer_state.tabs[tab_idx];
+            let (Some(watch), Some(query)) = (tab.watch.as_mut(), tab.query.clone()) else {
+                continue;
+            };
+            if !watch.is_due(now) {
+                continue;
+            }
+            // Don't replace rows that are being edited or searched
+            if tab.in_edit_mode || tab.in_search_mode {
+                watch.hold(now);
+                continue;
+            }
+
+            let connected = tab.query_connection.as_ref().filter(|id| {
+                self.db
+                    .connections
+                    .connections
+                    .iter()
+                    .any(|connection| &connection.id == *id && connection.is_connected())
+            });
+            let Some(connection_id) = connected.cloned() else {
+                tab.watch = None;
+                self.toast_manager
+                    .warning("Stopped watching: the query's connection is closed");
+                continue;
+            };
+            watch.start_refresh();
+            due.push((watch.id(), connection_id, query));
+            self.spinner.start(operation::REFRESHING_QUERY);
+        }
+        due
+    }
+
+    /// Show the result of the background refresh of the watch with
+    /// `watch_id` in its tab, in place. A failed run stops the watch; a
+    /// result for a watch that has since stopped is dropped
+    pub fn finish_watch_refresh(&mut self, watch_id: u64, result: Result<QueryResult, String>) {
+        self.spinner.stop(operation::REFRESHING_QUERY);
+        let tab = self.table_viewer_state.tabs.iter_mut().find(|tab| {
+            tab.watch
+                .as_ref()
+                .is_some_and(|watch| watch.id() == watch_id)
+        });
+        if let Some(tab) = tab {
+            match result {
+                Ok(result) => {
+                    let previous = std::mem::take(&mut tab.rows);
+                    tab.set_query_result(result);
+                    if let Some(watch) = tab.watch.as_mut() {
+                        watch.record_refresh(&previous, &tab.rows);
+                        watch.end_refresh(std::time::Instant::now());
+                    }
+                }
+                Err(e) => {
+                    crate::log_warn!(
+                        "Watched query failed: {} | Query: {}",
+                        e,
+                        tab.query.as_deref().unwrap_or_default()
+                    );
+                    tab.watch = None;
+                    self.toast_manager
+                        .error(format!("Stopped watching: query failed: {e}"));
+                }
+            }
         }
+    }
+
+    /// The connection and the SQL statement at cursor position to run, or
+    /// None (with a toast) when there is nothing to run or a query is
+    /// already running
+    pub fn query_at_cursor(&mut self) -> Option<(String, String)> {
+        let connection_id = self.connection_to_query()?;
 
         // Get the SQL statement at cursor position
         let query = match self.query_editor.get_statement_at_cursor() {
@@ -2034,25 +3166,64 @@ impl AppState {
             None => {
                 self.toast_manager
                     .warning("No SQL statement found at cursor position");
-                return Err("No SQL statement found at cursor position".to_string());
+                return None;
             }
         };
 
         if query.is_empty() {
             self.toast_manager.warning("Empty query");
-            return Err("Empty query".to_string());
+            return None;
         }
 
-        // Get the active connection from the connection manager
-        let connection_id = &connection.id;
+        Some((connection_id, query))
+    }
+
+    /// The connection and the statements of the whole query editor, to run
+    /// one after another, or None (with a toast) when there are none or a
+    /// query is already running
+    pub fn script_to_run(&mut self) -> Option<(String, String, Vec<String>)> {
+        let connection_id = self.connection_to_query()?;
+        let database_type = self.get_selected_connection()?.database_type.clone();
+        let script = self.query_editor.get_content().trim().to_string();
+        let statements = script::split_statements(&script, &database_type);
+        if statements.is_empty() {
+            self.toast_manager
+                .warning("No SQL statements in the query editor");
+            return None;
+        }
+        Some((connection_id, script, statements))
+    }
+
+    /// The selected connection to run a query on, or None (with a toast)
+    /// when it isn't connected or a query is already running
+    fn connection_to_query(&mut self) -> Option<String> {
+        if self.fetch_progress.is_some() {
+            self.toast_manager.warning(format!(
+                "A query is already running ({} cancels it)",
+                self.keymap.cancel_query_key()
+            ));
+            return None;
+        }
+
+        let Some(connection) = self.get_selected_connection() else {
+            self.toast_manager.error("No connection selected");
+            return None;
+        };
+        if !connection.is_connected() {
+            self.toast_manager.error("Not connected to database");
+            return None;
+        }
+        Some(connection.id.clone())
+    }
 
-        // Execute the query
+    /// Mark `query` as running, returning the flag that stops its fetch
+    pub fn start_query(&mut self, query: &str) -> std::sync::Arc<std::sync::atomic::AtomicBool> {
         self.toast_manager.info(format!(
             "Executing query: {}",
             if query.len() > 50 {
                 format!("{}...", &query[..50])
             } else {
-                query.clone()
+                query.to_string()
             }
         ));
 
@@ -2063,74 +3234,191 @@ impl AppState {
             format!("Starting query execution: {}", query),
         );
 
-        match self
+        let progress = FetchProgress::new(std::time::Instant::now());
+        let stop = progress.stop_flag();
+        self.fetch_progress = Some(progress);
+        self.spinner.start(operation::RUNNING_QUERY);
+        self.session_events.emit(SessionEvent::QueryStarted);
+        stop
+    }
+
+    /// Show the rows an UPDATE would change in a results tab, then ask
+    /// whether to run it. An UPDATE that can't be rewritten into a SELECT,
+    /// or whose preview fails, is only confirmed
+    pub async fn preview_update(&mut self, connection_id: &str, query: String) {
+        let Some(database_type) = self
+            .get_selected_connection()
+            .map(|connection| connection.database_type.clone())
+        else {
+            self.toast_manager.error("No connection selected");
+            return;
+        };
+        let message = match update_preview::update_preview(&database_type, &query) {
+            Some(preview) => match self.read_update_preview(connection_id, &preview).await {
+                Ok(count) => {
+                    self.ui.focused_pane = FocusedPane::TabularOutput;
+                    format!(
+                        "Update {count} row{}? They're shown in the preview tab.",
+                        if count == 1 { "" } else { "s" }
+                    )
+                }
+                Err(e) => {
+                    crate::log_warn!("Previewing an UPDATE failed: {}", e);
+                    format!("The rows couldn't be previewed ({e}). Run the UPDATE anyway?")
+                }
+            },
+            None => "Only single-table UPDATEs can be previewed. Run this UPDATE?".to_string(),
+        };
+        self.ui.confirmation_modal = Some(crate::ui::ConfirmationModal {
+            title: "Confirm UPDATE".to_string(),
+            message,
+            action: crate::ui::ConfirmationAction::RunStatement(query),
+        });
+    }
+
+    /// Count the rows an UPDATE matches and show them in a results tab
+    async fn read_update_preview(
+        &mut self,
+        connection_id: &str,
+        preview: &update_preview::UpdatePreview,
+    ) -> Result<usize, String> {
+        let (_, count) = self
             .connection_manager
-            .execute_raw_query(connection_id, &query)
+            .execute_raw_query(connection_id, &preview.count)
             .await
-        {
-            Ok((columns, rows)) => {
-                // Create a new table tab or update existing one
-                let tab_name =
-                    format!("Query Result ({})", chrono::Local::now().format("%H:%M:%S"));
+            .map_err(|e| e.to_string())?;
+        let count = count
+            .first()
+            .and_then(|row| row.first())
+            .and_then(|count| count.parse().ok())
+            .unwrap_or(0);
+        let result = self
+            .connection_manager
+            .execute_limited_query(connection_id, &preview.select, self.result_limits)
+            .await
+            .map_err(|e| e.to_string())?;
 
-                let tab_index = self.table_viewer_state.add_tab(tab_name);
+        let tab_idx = self
+            .table_viewer_state
+            .add_tab(format!("UPDATE preview ({count} rows)"));
+        if let Some(tab) = self.table_viewer_state.tabs.get_mut(tab_idx) {
+            tab.set_query_result(result);
+            tab.query = Some(preview.select.clone());
+            tab.query_connection = Some(connection_id.to_string());
+        }
+        Ok(count)
+    }
 
-                if let Some(tab) = self.table_viewer_state.tabs.get_mut(tab_index) {
-                    // Convert string columns to ColumnInfo
-                    tab.columns = columns
-                        .iter()
-                        .map(|col_name| {
-                            crate::ui::components::ColumnInfo {
-                                name: col_name.clone(),
-                                data_type: "TEXT".to_string(), // Default type
-                                is_nullable: true,
-                                is_primary_key: false,
-                                max_display_width: col_name.len().clamp(10, 30),
-                            }
-                        })
-                        .collect();
+    /// Record the rows fetched so far by the running query
+    pub fn update_fetch_progress(&mut self, rows: usize) {
+        if let Some(progress) = self.fetch_progress.as_mut() {
+            progress.set_rows(rows);
+        }
+    }
 
-                    tab.rows = rows;
-                    tab.total_rows = tab.rows.len();
-                    tab.loading = false;
-                    tab.error = None;
+    /// Show the result of the query started by `start_query` on the
+    /// connection with `connection_id` in a new tab
+    pub fn finish_query(
+        &mut self,
+        connection_id: Option<String>,
+        query: String,
+        result: Result<QueryResult, String>,
+    ) {
+        self.end_query(result.as_ref().ok().map(|result| result.rows.len()));
+
+        match result {
+            Ok(result) => {
+                let column_count = result.columns.len();
+                let stopped = result.stopped;
+                let affected_rows = result.affected_rows.filter(|_| column_count == 0);
+                let elapsed = result
+                    .elapsed
+                    .map(|elapsed| {
+                        format!(
+                            " in {}",
+                            crate::ui::components::table_viewer::format_query_time(elapsed)
+                        )
+                    })
+                    .unwrap_or_default();
+                let result_sets = result.result_set_count();
+                let notice_toast = match result.notices.as_slice() {
+                    [] => None,
+                    [notice] => Some(notice.to_string()),
+                    [first, rest @ ..] => Some(format!(
+                        "{first} (+{} more server messages below the result)",
+                        rest.len()
+                    )),
+                };
+                let row_count = self.open_query_result(connection_id, &query, result);
+
+                if stopped {
+                    self.toast_manager
+                        .info(format!("Fetching stopped, kept {} rows", row_count));
+                } else {
+                    self.toast_manager.success(format!(
+                        "Query executed successfully ({}{elapsed}{}): {}",
+                        match affected_rows {
+                            Some(affected) => format!("{affected} rows affected"),
+                            None => format!("{row_count} rows returned"),
+                        },
+                        if result_sets > 1 {
+                            format!(" in the first of {result_sets} result sets, [ and ] switch")
+                        } else {
+                            String::new()
+                        },
+                        if query.len() > 40 {
+                            format!("{}...", &query[..40])
+                        } else {
+                            query.clone()
+                        }
+                    ));
                 }
 
-                // Switch focus to the results pane
-                self.ui.focused_pane = FocusedPane::TabularOutput;
-
-                let row_count = self
-                    .table_viewer_state
-                    .tabs
-                    .get(tab_index)
-                    .map(|t| t.total_rows)
-                    .unwrap_or(0);
-
-                self.toast_manager.success(format!(
-                    "Query executed successfully ({} rows returned): {}",
-                    row_count,
-                    if query.len() > 40 {
-                        format!("{}...", &query[..40])
-                    } else {
-                        query.clone()
-                    }
-                ));
+                if let Some(message) = notice_toast {
+                    self.toast_manager.warning(message);
+                }
 
                 // Add debug message for successful query execution
                 crate::logging::add_debug_message(
                     "INFO",
                     "query_execution",
                     format!(
-                        "Query executed successfully: {} rows returned, {} columns | Query: {}",
+                        "Query executed successfully: {} rows returned, {} columns{} | Query: {}",
                         row_count,
-                        columns.len(),
+                        column_count,
+                        if stopped { " (stopped early)" } else { "" },
                         query
                     ),
                 );
-
-                Ok(())
+            }
+            Err(e) if cancel::is_cancellation(&e) => {
+                self.toast_manager.info(if e.ends_with(SAVEPOINT_RECOVERED) {
+                    "Query cancelled, transaction still open"
+                } else {
+                    "Query cancelled"
+                });
+                crate::logging::add_debug_message(
+                    "INFO",
+                    "query_execution",
+                    format!("Query cancelled | Query: {}", query),
+                );
+            }
+            Err(e) if e.ends_with(SAVEPOINT_RECOVERED) => {
+                self.show_query_error(&query, &e);
+                // The transaction survived; say so rather than report a plain failure
+                self.toast_manager.warning(format!(
+                    "Statement failed, transaction still open (rolled back to savepoint): {}",
+                    e.trim_end_matches(SAVEPOINT_RECOVERED)
+                        .trim_end_matches("; ")
+                ));
+                crate::logging::add_debug_message(
+                    "WARN",
+                    "query_execution",
+                    format!("Query execution failed: {} | Query: {}", e, query),
+                );
             }
             Err(e) => {
+                self.show_query_error(&query, &e);
                 self.toast_manager.error(format!(
                     "Query execution failed: {} | Query: {}",
                     e,
@@ -2147,10 +3435,200 @@ impl AppState {
                     "query_execution",
                     format!("Query execution failed: {} | Query: {}", e, query),
                 );
+            }
+        }
+    }
+
+    /// Clear the running state `start_query` set, once the query ended with
+    /// `rows` rows or failed
+    fn end_query(&mut self, rows: Option<usize>) {
+        self.fetch_progress = None;
+        self.spinner.stop(operation::RUNNING_QUERY);
+        self.session_events
+            .emit(SessionEvent::QueryFinished { rows });
+
+        // A watched query waits for the manual one before its next refresh
+        self.hold_query_watches();
+    }
+
+    /// Show the result of `query` in a new results tab and focus it.
+    /// Returns the rows of the result set shown
+    fn open_query_result(
+        &mut self,
+        connection_id: Option<String>,
+        query: &str,
+        result: QueryResult,
+    ) -> usize {
+        let tab_name = format!("Query Result ({})", chrono::Local::now().format("%H:%M:%S"));
+        let tab_index = self.table_viewer_state.add_tab(tab_name);
+
+        if let Some(tab) = self.table_viewer_state.tabs.get_mut(tab_index) {
+            tab.set_query_result(result);
+            tab.query = Some(query.to_string());
+            tab.query_connection = connection_id;
+        }
+
+        // The editor content has now been run, so it no longer counts as unsaved work
+        self.query_editor.mark_saved();
+        self.ui.query_modified = false;
+
+        // Switch focus to the results pane
+        self.ui.focused_pane = FocusedPane::TabularOutput;
 
-                Err(e.to_string())
+        self.table_viewer_state
+            .tabs
+            .get(tab_index)
+            .map(|t| t.total_rows)
+            .unwrap_or(0)
+    }
+
+    /// Show the results of a script run statement by statement in one tab,
+    /// a result set per statement to switch between with `[` and `]`, after
+    /// a summary of the statements when there are several. A statement that
+    /// failed ended the script; the results of the ones before it stay, and
+    /// the summary marks them as already executed
+    pub fn finish_script(
+        &mut self,
+        connection_id: Option<String>,
+        script: String,
+        results: Vec<QueryResult>,
+        statements: Vec<String>,
+        failure: Option<script::StatementFailure>,
+    ) {
+        let count = statements.len();
+        if results.is_empty() && (count == 1 || failure.is_none()) {
+            // A lone statement that failed reads like a failed query
+            match failure {
+                Some(failure) => {
+                    let error = format!("statement 1/{count}: {}", failure.error);
+                    self.finish_query(connection_id, failure.statement, Err(error));
+                }
+                None => self.end_query(None),
             }
+            return;
         }
+        let ran = results
+            .last()
+            .and_then(|set| set.statement)
+            .map_or(0, |(position, _)| position);
+        let stopped = results.iter().any(|set| set.stopped);
+        let row_count = results.iter().map(|set| set.rows.len()).sum();
+        let summary = (count > 1).then(|| script_summary(&statements, &results, failure.as_ref()));
+        let elapsed: std::time::Duration = results
+            .iter()
+            .filter_map(|set| set.elapsed)
+            .chain(failure.as_ref().map(|failure| failure.elapsed))
+            .sum();
+
+        let mut sets = summary.into_iter().chain(results);
+        let Some(mut result) = sets.next() else {
+            return;
+        };
+        result.more_results = sets.collect();
+        let notices: Vec<_> = result
+            .more_results
+            .iter_mut()
+            .flat_map(|set| std::mem::take(&mut set.notices))
+            .collect();
+        result.notices.extend(notices);
+        let notice_count = result.notices.len();
+
+        self.end_query(Some(row_count));
+        self.open_query_result(connection_id, &script, result);
+        let summary = format!(
+            "{ran}/{count} statements in {}",
+            crate::ui::components::table_viewer::format_query_time(elapsed)
+        );
+
+        match failure {
+            Some(failure) if cancel::is_cancellation(&failure.error) => {
+                self.toast_manager.info(format!(
+                    "Script cancelled at statement {}/{count}; the {ran} before it had already executed",
+                    failure.position
+                ));
+            }
+            Some(failure) => {
+                self.toast_manager.error(format!(
+                    "Statement {}/{count} failed; the {ran} before it had already executed, as the summary shows: {}",
+                    failure.position, failure.error
+                ));
+                crate::logging::add_debug_message(
+                    "ERROR",
+                    "query_execution",
+                    format!(
+                        "Script statement {}/{count} failed: {} | Query: {}",
+                        failure.position, failure.error, failure.statement
+                    ),
+                );
+            }
+            None if stopped || ran < count => {
+                self.toast_manager
+                    .info(format!("Script stopped after {summary}"));
+            }
+            None => {
+                self.toast_manager.success(format!(
+                    "Script ran {summary}, [ and ] switch between the summary and their results"
+                ));
+            }
+        }
+        if notice_count > 0 {
+            self.toast_manager.warning(format!(
+                "The server sent {notice_count} messages, shown below the results"
+            ));
+        }
+        crate::logging::add_debug_message(
+            "INFO",
+            "query_execution",
+            format!("Script ran {summary} | Query: {script}"),
+        );
+    }
+
+    /// Show why a statement failed in a results tab of its own, where the
+    /// whole message can be read once the toast is gone
+    fn show_query_error(&mut self, query: &str, error: &str) {
+        let tab_name = format!("Query ({})", chrono::Local::now().format("%H:%M:%S"));
+        let tab_index = self.table_viewer_state.add_tab(tab_name);
+        if let Some(tab) = self.table_viewer_state.tabs.get_mut(tab_index) {
+            tab.loading = false;
+            tab.error = Some(error.to_string());
+            tab.query = Some(query.to_string());
+        }
+    }
+}
+
+/// Columns of the summary of a script's statements
+const SCRIPT_SUMMARY_COLUMNS: [&str; 6] =
+    ["#", "statement", "rows affected", "time", "status", "error"];
+
+/// The summary of a script's statements as a result set, a row each, shown
+/// before their results
+fn script_summary(
+    statements: &[String],
+    results: &[QueryResult],
+    failure: Option<&script::StatementFailure>,
+) -> QueryResult {
+    let format_time = crate::ui::components::table_viewer::format_query_time;
+    let lines = script::summarize(statements, results, failure);
+    QueryResult {
+        columns: SCRIPT_SUMMARY_COLUMNS.map(String::from).to_vec(),
+        elapsed: Some(lines.iter().filter_map(|line| line.elapsed).sum()),
+        rows: lines
+            .into_iter()
+            .map(|line| {
+                vec![
+                    line.position.to_string(),
+                    line.excerpt,
+                    line.affected_rows
+                        .map(|rows| rows.to_string())
+                        .unwrap_or_default(),
+                    line.elapsed.map(format_time).unwrap_or_default(),
+                    line.status.label().to_string(),
+                    line.error.unwrap_or_default(),
+                ]
+            })
+            .collect(),
+        statement: Some((0, statements.len())),
+        ..QueryResult::default()
     }
 }
 
@@ -2185,12 +3663,34 @@ impl Default for AppState {
             app_state_db: AppStateDb::new(),
             connection_manager: ConnectionManager::new(),
             connecting_in_progress: None,
-            connecting_animation_frame: 0,
             connection_start_time: None,
             connection_timeout_seconds: 30,
+            connect_retry: ConnectRetry::default(),
+            connect_attempt: None,
             test_connection_in_progress: false,
-            test_animation_frame: 0,
+            spinner: Spinner::new(),
             test_start_time: None,
+            first_run_wizard: None,
+            result_limits: ResultLimits::default(),
+            fetch_progress: None,
+            watch_interval: std::time::Duration::from_secs(5),
+            clipboard: Clipboard::default(),
+            notifications: NotificationsView::new(),
+            preview_updates: false,
+            last_query_connection: None,
+            statement_guard: Default::default(),
+            reachability: std::collections::HashMap::new(),
+            keymap: crate::app::keymap::Keymap::default(),
+            pending_jump: None,
+            session_events: SessionEvents::default(),
+            session_activity: SessionActivity::default(),
+            object_tree: ObjectTree::default(),
+            undo_log: UndoLog::default(),
+            statement_timeouts: StatementTimeouts::default(),
+            read_only_guard: ReadOnlyGuard::default(),
+            query_history: None,
+            query_history_limit: 100,
+            query_history_picker: None,
         }
     }
 }
@@ -2,24 +2,37 @@
 
 #![forbid(unsafe_code)]
 
+mod query_commands;
+mod seed_commands;
 mod theme_commands;
 
 use clap::{Parser, Subcommand, ValueEnum};
+pub use query_commands::{ExportArgs, QueryArgs};
+pub use seed_commands::SeedArgs;
 use std::path::PathBuf;
 pub use theme_commands::ThemeCommand;
 
 /// LazyTables - Terminal-based SQL database viewer and editor
 #[derive(Parser, Debug)]
 #[command(name = "lazytables")]
-#[command(author, version, about, long_about = None)]
+#[command(author, about, long_about = None, disable_version_flag = true)]
 pub struct Cli {
+    /// Print version information (add --verbose for build details)
+    #[arg(short = 'V', long)]
+    pub version: bool,
+
+    /// With --version, print the full build information
+    #[arg(long, requires = "version")]
+    pub verbose: bool,
+
     /// Path to configuration file
     #[arg(short, long, value_name = "FILE")]
     pub config: Option<PathBuf>,
 
-    /// Set logging level
-    #[arg(short, long, value_enum, default_value = "info")]
-    pub log_level: LogLevel,
+    /// Set logging level, optionally per area (e.g. "info" or "warn,db=debug,ui=warn").
+    /// Overrides the level from the config file
+    #[arg(short, long, value_name = "LEVEL")]
+    pub log_level: Option<String>,
 
     /// Connection string to connect immediately
     #[arg(long)]
@@ -37,9 +50,28 @@ pub struct Cli {
     #[arg(short = 'r', long)]
     pub read_only: bool,
 
-    /// Theme management commands
+    /// Skip the startup ping of saved connections (ping_on_startup)
+    #[arg(long)]
+    pub no_ping: bool,
+
+    /// Log every SQL statement run, the panels' own included, with its
+    /// duration and the feature that issued it (logging.trace_sql)
+    #[arg(long)]
+    pub trace_sql: bool,
+
+    /// Turn on debugging aids such as --metrics-addr
+    #[arg(long)]
+    pub debug: bool,
+
+    /// Serve query, metadata and render timings for profiling in the
+    /// Prometheus text format at /metrics on this loopback address (e.g. :9187).
+    /// Needs --debug
+    #[arg(long, value_name = "ADDR", requires = "debug")]
+    pub metrics_addr: Option<String>,
+
+    /// Subcommands (theme management, data migration, headless queries, exports and seeding)
     #[command(subcommand)]
-    pub theme: Option<Commands>,
+    pub command: Option<Commands>,
 }
 
 #[derive(Debug, Subcommand)]
@@ -49,6 +81,18 @@ pub enum Commands {
         #[command(subcommand)]
         command: ThemeCommand,
     },
+
+    /// Move data from the legacy ~/.lazytables directory into XDG directories
+    MigrateData,
+
+    /// Execute SQL against a saved connection or URL and print the result
+    Query(QueryArgs),
+
+    /// Export a table or query result to a file
+    Export(ExportArgs),
+
+    /// Create fixture tables filled with deterministic generated rows
+    Seed(SeedArgs),
 }
 
 #[derive(Debug, Clone, Copy, ValueEnum)]
@@ -60,6 +104,41 @@ pub enum LogLevel {
     Error,
 }
 
+impl LogLevel {
+    /// Names accepted when parsing a level from a string
+    pub const VALID_NAMES: [&'static str; 5] = ["trace", "debug", "info", "warn", "error"];
+
+    /// Lowercase name of the level
+    pub fn as_str(&self) -> &'static str {
+        match self {
+            LogLevel::Trace => "trace",
+            LogLevel::Debug => "debug",
+            LogLevel::Info => "info",
+            LogLevel::Warn => "warn",
+            LogLevel::Error => "error",
+        }
+    }
+}
+
+impl std::str::FromStr for LogLevel {
+    type Err = String;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match s.trim().to_lowercase().as_str() {
+            "trace" => Ok(LogLevel::Trace),
+            "debug" => Ok(LogLevel::Debug),
+            "info" => Ok(LogLevel::Info),
+            "warn" | "warning" => Ok(LogLevel::Warn),
+            "error" => Ok(LogLevel::Error),
+            other => Err(format!(
+                "Invalid log level '{}'. Valid options: {}",
+                other,
+                Self::VALID_NAMES.join(", ")
+            )),
+        }
+    }
+}
+
 impl From<LogLevel> for tracing::Level {
     fn from(level: LogLevel) -> Self {
         match level {
@@ -0,0 +1,317 @@
+// FilePath: src/cli/query_commands.rs
+
+#![forbid(unsafe_code)]
+
+use crate::{
+    config::Config,
+    core::error::{LazyTablesError, Result},
+    database::{
+        preview::quoted_table, AdapterFactory, ConnectionConfig, ConnectionManager,
+        ConnectionStorage, QueryAuditLog, RowSink, ServerNotice,
+    },
+    io::export::{ExportFormat, ResultWriter},
+};
+use clap::Args;
+use std::fs::File;
+use std::io::{BufWriter, IsTerminal, Read, Write};
+use std::path::PathBuf;
+
+/// Rows between progress updates on stderr during an export
+const EXPORT_PROGRESS_INTERVAL: usize = 10_000;
+
+/// Run a single SQL statement without starting the TUI
+#[derive(Debug, Args)]
+pub struct QueryArgs {
+    /// Saved connection name (or ID), or a connection URL
+    #[arg(long, value_name = "NAME|URL")]
+    pub connection: String,
+
+    /// Database to use instead of the connection's default
+    #[arg(short = 'd', long)]
+    pub database: Option<String>,
+
+    /// Output format. xlsx is binary and needs stdout redirected to a file
+    #[arg(long, value_enum, default_value_t = ExportFormat::Table)]
+    pub format: ExportFormat,
+
+    /// Read SQL from a file ("-" for stdin)
+    #[arg(short = 'f', long, value_name = "FILE", conflicts_with = "sql")]
+    pub file: Option<PathBuf>,
+
+    /// SQL to execute; read from stdin when omitted
+    pub sql: Option<String>,
+}
+
+impl QueryArgs {
+    /// Execute the query and write the result to stdout
+    pub async fn execute(&self, config: &Config) -> Result<()> {
+        let sql = self.read_sql()?;
+        if self.format.is_binary() && std::io::stdout().is_terminal() {
+            return Err(LazyTablesError::InvalidInput(format!(
+                "{} output isn't text; redirect stdout to a file or use `lazytables export --out`",
+                self.format.info().label
+            )));
+        }
+        let (manager, connection) =
+            connect_headless(&self.connection, self.database.as_deref(), config).await?;
+
+        let result = manager.execute_raw_query(&connection.id, &sql).await;
+        let _ = manager.disconnect_all().await;
+        let (columns, rows) = result?;
+
+        let stdout = std::io::stdout();
+        let mut writer = self.format.writer(stdout.lock());
+        writer.begin(&columns)?;
+        for row in &rows {
+            writer.write_row(row)?;
+        }
+        writer.finish()?;
+
+        Ok(())
+    }
+
+    /// Get the SQL from the argument, the file or stdin
+    fn read_sql(&self) -> Result<String> {
+        let sql = match (&self.sql, &self.file) {
+            (Some(sql), _) => sql.clone(),
+            (None, Some(path)) if path.as_os_str() != "-" => std::fs::read_to_string(path)?,
+            (None, _) => {
+                let mut sql = String::new();
+                std::io::stdin().read_to_string(&mut sql)?;
+                sql
+            }
+        };
+
+        let sql = sql.trim();
+        if sql.is_empty() {
+            return Err(LazyTablesError::InvalidInput(
+                "No SQL to execute".to_string(),
+            ));
+        }
+        Ok(sql.to_string())
+    }
+}
+
+/// Export a table or query result to a file without starting the TUI
+#[derive(Debug, Args)]
+#[command(group = clap::ArgGroup::new("source").required(true).args(["table", "query"]))]
+pub struct ExportArgs {
+    /// Saved connection name (or ID), or a connection URL
+    #[arg(long, value_name = "NAME|URL")]
+    pub connection: String,
+
+    /// Database to use instead of the connection's default
+    #[arg(short = 'd', long)]
+    pub database: Option<String>,
+
+    /// Table to export (schema-qualified names are accepted)
+    #[arg(long)]
+    pub table: Option<String>,
+
+    /// SQL query whose result to export
+    #[arg(long)]
+    pub query: Option<String>,
+
+    /// Output format. The table and xlsx formats hold every row in memory
+    /// until the end
+    #[arg(long, value_enum, default_value_t = ExportFormat::Csv)]
+    pub format: ExportFormat,
+
+    /// Output file; defaults to <table>.<format> for table exports
+    #[arg(short = 'o', long, value_name = "FILE")]
+    pub out: Option<PathBuf>,
+}
+
+impl ExportArgs {
+    /// Stream the rows into the output file, reporting progress on stderr
+    pub async fn execute(&self, config: &Config) -> Result<()> {
+        let out = match (&self.table, &self.query) {
+            (Some(table), _) => self
+                .out
+                .clone()
+                .unwrap_or_else(|| PathBuf::from(format!("{table}.{}", self.format.extension()))),
+            (None, Some(_)) => self.out.clone().ok_or_else(|| {
+                LazyTablesError::InvalidInput(
+                    "--out is required when exporting a query".to_string(),
+                )
+            })?,
+            (None, None) => {
+                return Err(LazyTablesError::InvalidInput(
+                    "Either --table or --query is required".to_string(),
+                ))
+            }
+        };
+
+        let (manager, connection) =
+            connect_headless(&self.connection, self.database.as_deref(), config).await?;
+        let sql = match (&self.table, &self.query) {
+            (Some(table), _) => format!(
+                "SELECT * FROM {}",
+                quoted_table(&connection.database_type, table)
+            ),
+            (None, query) => query.as_deref().unwrap_or_default().trim().to_string(),
+        };
+
+        let file = BufWriter::new(File::create(&out)?);
+        let mut sink = ExportSink::new(self.format.writer(file));
+        let result = manager
+            .stream_raw_query(&connection.id, &sql, &mut sink)
+            .await;
+        let _ = manager.disconnect_all().await;
+
+        let rows = match result {
+            Ok(rows) => rows,
+            Err(e) => {
+                // Don't leave a truncated export behind
+                drop(sink);
+                let _ = std::fs::remove_file(&out);
+                return Err(e);
+            }
+        };
+        sink.finish()?;
+        eprintln!("\rExported {} rows to {}", rows, out.display());
+
+        Ok(())
+    }
+}
+
+/// Row sink writing an export file and printing progress to stderr
+struct ExportSink<W: Write + Send> {
+    writer: Option<ResultWriter<W>>,
+    /// Whether the header has been written
+    begun: bool,
+    /// The file holds one result set; reading stops at the end of the first
+    first_set_done: bool,
+}
+
+impl<W: Write + Send> ExportSink<W> {
+    fn new(writer: ResultWriter<W>) -> Self {
+        Self {
+            writer: Some(writer),
+            begun: false,
+            first_set_done: false,
+        }
+    }
+
+    fn writer(&mut self) -> &mut ResultWriter<W> {
+        self.writer
+            .as_mut()
+            .expect("export writer used after finish")
+    }
+
+    /// Finish the file, with its header even when no column names came, so
+    /// the file is still well-formed in its format
+    fn finish(&mut self) -> Result<Option<W>> {
+        if !self.begun {
+            self.columns(&[])?;
+        }
+        match self.writer.take() {
+            Some(writer) => Ok(Some(writer.finish()?)),
+            None => Ok(None),
+        }
+    }
+}
+
+impl<W: Write + Send> RowSink for ExportSink<W> {
+    fn columns(&mut self, columns: &[String]) -> Result<()> {
+        if self.begun {
+            return Ok(());
+        }
+        self.begun = true;
+        Ok(self.writer().begin(columns)?)
+    }
+
+    fn row(&mut self, row: Vec<String>) -> Result<()> {
+        let writer = self.writer();
+        writer.write_row(&row)?;
+        if writer.rows_written() % EXPORT_PROGRESS_INTERVAL == 0 {
+            eprint!("\rExported {} rows...", writer.rows_written());
+        }
+        Ok(())
+    }
+
+    fn next_result_set(&mut self) -> Result<()> {
+        self.first_set_done = true;
+        Ok(())
+    }
+
+    fn notices(&mut self, notices: Vec<ServerNotice>) -> Result<()> {
+        for notice in notices {
+            eprintln!("\r{notice}");
+        }
+        Ok(())
+    }
+
+    fn is_full(&self) -> bool {
+        self.first_set_done
+    }
+}
+
+/// Resolve a saved connection (by name or ID) or a connection URL
+pub(crate) async fn resolve_connection(
+    target: &str,
+    database: Option<&str>,
+) -> Result<ConnectionConfig> {
+    let storage = ConnectionStorage::load().await?;
+
+    let mut connection = match storage
+        .connections
+        .iter()
+        .find(|c| c.name == target || c.id == target)
+        .or_else(|| {
+            storage
+                .connections
+                .iter()
+                .find(|c| c.name.eq_ignore_ascii_case(target))
+        }) {
+        Some(connection) => connection.clone(),
+        None if target.contains("://") => {
+            AdapterFactory::config_from_connection_string(target, "command line".to_string())?
+        }
+        None => return Err(LazyTablesError::ConnectionNotFound(target.to_string())),
+    };
+
+    if let Some(database) = database {
+        connection.database = Some(database.to_string());
+    }
+
+    Ok(connection)
+}
+
+/// Connect through the same ConnectionManager the TUI uses, with the audit
+/// log attached when one is configured
+pub(crate) async fn connect_headless(
+    target: &str,
+    database: Option<&str>,
+    config: &Config,
+) -> Result<(ConnectionManager, ConnectionConfig)> {
+    let connection = resolve_connection(target, database).await?;
+
+    let mut manager = ConnectionManager::new();
+    if let Some(path) = &config.logging.query_log {
+        manager.set_audit_log(QueryAuditLog::open(path, config.logging.query_log_verbose)?);
+    }
+    manager.set_trace_sql(config.logging.trace_sql);
+
+    manager.connect(&connection).await?;
+    Ok((manager, connection))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_empty_export_keeps_its_header() {
+        let mut sink = ExportSink::new(ExportFormat::Csv.writer(Vec::new()));
+        sink.columns(&["id".to_string(), "name".to_string()])
+            .unwrap();
+        let out = sink.finish().unwrap().unwrap();
+        assert_eq!(String::from_utf8(out).unwrap(), "id,name\n");
+
+        // Without column names the file is still well-formed
+        let mut sink = ExportSink::new(ExportFormat::Json.writer(Vec::new()));
+        let out = sink.finish().unwrap().unwrap();
+        assert_eq!(String::from_utf8(out).unwrap(), "[]\n");
+    }
+}
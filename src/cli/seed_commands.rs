@@ -0,0 +1,82 @@
+// FilePath: src/cli/seed_commands.rs
+
+#![forbid(unsafe_code)]
+
+use super::query_commands::connect_headless;
+use crate::{
+    config::Config,
+    core::error::{LazyTablesError, Result},
+    database::fixtures::FixtureSpec,
+};
+use clap::Args;
+
+/// Create fixture tables filled with generated rows without starting the TUI
+#[derive(Debug, Args)]
+pub struct SeedArgs {
+    /// Saved connection name (or ID), or a connection URL
+    #[arg(long, value_name = "NAME|URL")]
+    pub connection: String,
+
+    /// Database to use instead of the connection's default
+    #[arg(short = 'd', long)]
+    pub database: Option<String>,
+
+    /// Number of tables to create
+    #[arg(long, default_value_t = 3)]
+    pub tables: usize,
+
+    /// Rows per table
+    #[arg(long, default_value_t = 1000)]
+    pub rows: usize,
+
+    /// Seed of the generated values; the same seed gives the same rows
+    #[arg(long, default_value_t = 42)]
+    pub seed: u64,
+
+    /// Table name prefix; tables are named <prefix>1, <prefix>2, ...
+    #[arg(long, default_value = "fixture_")]
+    pub prefix: String,
+
+    /// Drop the tables first if they already exist
+    #[arg(long)]
+    pub drop: bool,
+}
+
+impl SeedArgs {
+    /// Create and fill the tables, reporting progress on stderr
+    pub async fn execute(&self, config: &Config) -> Result<()> {
+        if self.prefix.is_empty() {
+            return Err(LazyTablesError::InvalidInput(
+                "--prefix can't be empty".to_string(),
+            ));
+        }
+        let spec = FixtureSpec {
+            tables: self.tables,
+            rows: self.rows,
+            seed: self.seed,
+            prefix: self.prefix.clone(),
+            drop_existing: self.drop,
+        };
+
+        let (manager, connection) =
+            connect_headless(&self.connection, self.database.as_deref(), config).await?;
+        let result = async {
+            let statements = spec
+                .statements(&connection.database_type)
+                .map_err(LazyTablesError::InvalidInput)?;
+            for statement in &statements {
+                manager.execute_raw_query(&connection.id, statement).await?;
+            }
+            Ok::<(), LazyTablesError>(())
+        }
+        .await;
+        let _ = manager.disconnect_all().await;
+        result?;
+
+        eprintln!(
+            "Seeded {} tables named {}N with {} rows each (seed {})",
+            spec.tables, spec.prefix, spec.rows, spec.seed
+        );
+        Ok(())
+    }
+}
@@ -150,10 +150,7 @@ impl Command for SaveCommand {
                     let filename = format!("query_{timestamp}.sql");
 
                     // Save to sql_files directory
-                    let sql_dir = dirs::home_dir()
-                        .unwrap_or_else(|| std::path::PathBuf::from("."))
-                        .join(".lazytables")
-                        .join("sql_files");
+                    let sql_dir = crate::config::Config::sql_files_dir();
 
                     let filepath = sql_dir.join(&filename);
 
@@ -259,10 +256,7 @@ impl Command for OpenCommand {
                 // Load selected SQL file
                 {
                     let selected = context.state.ui.selected_sql_file;
-                    let sql_dir = dirs::home_dir()
-                        .unwrap_or_else(|| std::path::PathBuf::from("."))
-                        .join(".lazytables")
-                        .join("sql_files");
+                    let sql_dir = crate::config::Config::sql_files_dir();
 
                     // Use async file I/O with block_on (Command trait doesn't support async)
                     // TODO: Move to background task with event notification for truly non-blocking operation
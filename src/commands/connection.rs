@@ -54,7 +54,10 @@ impl Command for ConnectCommand {
                             context
                                 .state
                                 .connection_manager
-                                .list_database_objects(&connection_config.id)
+                                .list_database_objects(
+                                    &connection_config.id,
+                                    context.state.ui.show_system_objects,
+                                )
                                 .await
                                 .inspect(|objects| {
                                     // Update database state with loaded objects
@@ -62,15 +65,7 @@ impl Command for ConnectCommand {
                                     context.state.db.tables = objects
                                         .tables
                                         .iter()
-                                        .map(|t| {
-                                            if t.schema.as_deref() == Some("public")
-                                                || t.schema.is_none()
-                                            {
-                                                t.name.clone()
-                                            } else {
-                                                t.qualified_name()
-                                            }
-                                        })
+                                        .map(|t| objects.open_name(t))
                                         .collect();
                                 })
                         }
@@ -426,6 +421,7 @@ impl Command for TestConnectionCommand {
                         .try_connect_to_database(
                             &connection_config,
                             &context.state.connection_manager,
+                            context.state.ui.show_system_objects,
                         )
                         .await
                 });
@@ -122,10 +122,7 @@ impl Command for SaveQueryCommand {
         };
 
         // Save to sql_files directory
-        let sql_dir = dirs::home_dir()
-            .unwrap_or_else(|| std::path::PathBuf::from("."))
-            .join(".lazytables")
-            .join("sql_files");
+        let sql_dir = crate::config::Config::sql_files_dir();
 
         let filepath = sql_dir.join(&filename);
 
@@ -141,6 +138,7 @@ impl Command for SaveQueryCommand {
         // Update state
         context.state.ui.current_sql_file = Some(filename.clone());
         context.state.ui.query_modified = false;
+        context.state.query_editor.mark_saved();
 
         // Add success toast
         context
@@ -2,10 +2,13 @@
 
 #![forbid(unsafe_code)]
 
-use crate::core::error::Result;
-// Removed directories crate, using dirs crate for home_dir
+use crate::core::error::{LazyTablesError, Result};
 use serde::{Deserialize, Serialize};
-use std::{fs, path::PathBuf};
+use std::{
+    collections::BTreeMap,
+    fs, io,
+    path::{Path, PathBuf},
+};
 
 /// Application configuration
 #[derive(Debug, Clone, Serialize, Deserialize)]
@@ -18,6 +21,18 @@ pub struct Config {
     pub connections: ConnectionsConfig,
     /// Keybindings
     pub keybindings: KeybindingsConfig,
+    /// Logging preferences
+    #[serde(default)]
+    pub logging: LoggingConfig,
+    /// General application behaviour
+    #[serde(default)]
+    pub app: AppConfig,
+    /// Layout of the interface
+    #[serde(default)]
+    pub ui: UiConfig,
+    /// What happens to destructive statements, by default and per connection
+    #[serde(default)]
+    pub statement_guard: crate::database::statement_guard::StatementGuardConfig,
 }
 
 #[derive(Debug, Clone, Serialize, Deserialize)]
@@ -44,6 +59,313 @@ pub struct ConnectionsConfig {
 #[derive(Debug, Clone, Serialize, Deserialize)]
 pub struct KeybindingsConfig {
     pub leader_key: String,
+    /// Keys of each pane's main actions, by action name, e.g. `add = "n"`;
+    /// see `app::keymap` for the actions
+    #[serde(default)]
+    pub connections: BTreeMap<String, String>,
+    #[serde(default)]
+    pub tables: BTreeMap<String, String>,
+    #[serde(default)]
+    pub details: BTreeMap<String, String>,
+    #[serde(default)]
+    pub results: BTreeMap<String, String>,
+    #[serde(default)]
+    pub sql_files: BTreeMap<String, String>,
+    #[serde(default)]
+    pub query_editor: BTreeMap<String, String>,
+    /// Keys of the actions of the whole application: `next_pane`,
+    /// `previous_pane` and `cancel_query`
+    #[serde(default)]
+    pub global: BTreeMap<String, String>,
+    /// Panes Tab cycles through, in order, by their section names; empty
+    /// for all of them
+    #[serde(default)]
+    pub focus_order: Vec<String>,
+}
+
+impl Default for KeybindingsConfig {
+    fn default() -> Self {
+        Self {
+            leader_key: " ".to_string(),
+            connections: BTreeMap::new(),
+            tables: BTreeMap::new(),
+            details: BTreeMap::new(),
+            results: BTreeMap::new(),
+            sql_files: BTreeMap::new(),
+            query_editor: BTreeMap::new(),
+            global: BTreeMap::new(),
+            focus_order: Vec::new(),
+        }
+    }
+}
+
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct AppConfig {
+    /// Rows kept from a query editor result; further rows are skipped and the
+    /// result is marked truncated. 0 disables the cap
+    #[serde(default = "default_max_result_rows")]
+    pub max_result_rows: usize,
+    /// Bytes of a single cell kept for display; the full value stays available
+    /// for copying. 0 disables the cap
+    #[serde(default = "default_max_cell_bytes")]
+    pub max_cell_bytes: usize,
+    /// Characters of each cell a table preview reads; longer values are cut
+    /// on the server and read in full by primary key when copied or edited.
+    /// 0 reads whole values
+    #[serde(default = "default_preview_cell_chars")]
+    pub preview_cell_chars: usize,
+    /// Rows per page of a table preview; `n` and `p` read the next and
+    /// previous page
+    #[serde(default = "default_preview_page_rows")]
+    pub preview_page_rows: usize,
+    /// How copies reach the clipboard: "auto", "native" or "osc52"
+    #[serde(default)]
+    pub clipboard: crate::io::clipboard::ClipboardMode,
+    /// Seconds between runs of a watched query result
+    #[serde(default = "default_watch_interval_secs")]
+    pub watch_interval_secs: u64,
+    /// Times a failed connection attempt is retried before giving up
+    #[serde(default)]
+    pub connect_retries: u32,
+    /// Milliseconds before the first connection retry, doubled for each
+    /// further one
+    #[serde(default = "default_connect_backoff_ms")]
+    pub connect_backoff_ms: u64,
+    /// List system schemas and tables (pg_catalog, information_schema, MySQL's
+    /// system databases, SQLite's sqlite_ tables) in the tables pane
+    #[serde(default)]
+    pub show_system_objects: bool,
+    /// Run each statement of an open PostgreSQL transaction inside a
+    /// savepoint, so a failed statement is rolled back alone instead of
+    /// aborting the transaction
+    #[serde(default = "default_statement_savepoints")]
+    pub statement_savepoints: bool,
+    /// Seconds the server lets a PostgreSQL or MySQL statement run before
+    /// cancelling it, for connections that don't set their own. 0 for no
+    /// limit
+    #[serde(default)]
+    pub statement_timeout_secs: u64,
+    /// Refuse writes to a PostgreSQL replica or a read-only MySQL server
+    /// before sending them; `:readonly off` lifts it for a session
+    #[serde(default = "default_guard_read_only_servers")]
+    pub guard_read_only_servers: bool,
+    /// Show the rows an UPDATE would change, and ask, before running it
+    #[serde(default)]
+    pub preview_updates: bool,
+    /// Show a line of each pane's main keys under it
+    #[serde(default = "default_key_hints")]
+    pub key_hints: bool,
+    /// Move focus along as a selection is confirmed: from a connection to
+    /// its tables, and from an opened table to the query editor
+    #[serde(default)]
+    pub auto_advance_focus: bool,
+    /// Show the date and time in the status bar. Without it nothing is
+    /// redrawn while nothing is happening
+    #[serde(default = "default_show_clock")]
+    pub show_clock: bool,
+    /// strftime format of the status bar clock, e.g. "%H:%M"
+    #[serde(default = "default_clock_format")]
+    pub clock_format: String,
+    /// Emacs-style editing keys (Ctrl+A/E/W/U, Alt+B/F) in text inputs
+    #[serde(default = "default_readline_keys")]
+    pub readline_keys: bool,
+    /// Ping every saved connection's server in the background at startup
+    /// and mark it reachable or not in the Connections pane. `--no-ping`
+    /// skips it for one run
+    #[serde(default)]
+    pub ping_on_startup: bool,
+    /// Query editor statements kept in the history `Ctrl+R` recalls
+    #[serde(default = "default_query_history_limit")]
+    pub query_history_limit: usize,
+}
+
+impl Default for AppConfig {
+    fn default() -> Self {
+        Self {
+            max_result_rows: default_max_result_rows(),
+            max_cell_bytes: default_max_cell_bytes(),
+            preview_cell_chars: default_preview_cell_chars(),
+            preview_page_rows: default_preview_page_rows(),
+            clipboard: crate::io::clipboard::ClipboardMode::default(),
+            watch_interval_secs: default_watch_interval_secs(),
+            connect_retries: 0,
+            connect_backoff_ms: default_connect_backoff_ms(),
+            show_system_objects: false,
+            statement_savepoints: default_statement_savepoints(),
+            statement_timeout_secs: 0,
+            guard_read_only_servers: default_guard_read_only_servers(),
+            preview_updates: false,
+            key_hints: default_key_hints(),
+            auto_advance_focus: false,
+            show_clock: default_show_clock(),
+            clock_format: default_clock_format(),
+            readline_keys: default_readline_keys(),
+            ping_on_startup: false,
+            query_history_limit: default_query_history_limit(),
+        }
+    }
+}
+
+impl AppConfig {
+    /// Limits to apply when collecting query results
+    pub fn result_limits(&self) -> crate::database::ResultLimits {
+        crate::database::ResultLimits {
+            max_rows: self.max_result_rows,
+            max_cell_bytes: self.max_cell_bytes,
+        }
+    }
+
+    /// Format of the status bar clock; the default one when `clock_format`
+    /// isn't a valid strftime format, which chrono would panic on
+    pub fn clock_format(&self) -> String {
+        let invalid = chrono::format::StrftimeItems::new(&self.clock_format)
+            .any(|item| matches!(item, chrono::format::Item::Error));
+        if invalid {
+            crate::log_warn!(
+                "Invalid clock_format {:?}, using the default",
+                self.clock_format
+            );
+            return default_clock_format();
+        }
+        self.clock_format.clone()
+    }
+
+    /// How the initial connection to a database is retried
+    pub fn connect_retry(&self) -> crate::database::ConnectRetry {
+        crate::database::ConnectRetry {
+            retries: self.connect_retries,
+            backoff: std::time::Duration::from_millis(self.connect_backoff_ms),
+        }
+    }
+}
+
+fn default_max_result_rows() -> usize {
+    crate::database::ResultLimits::default().max_rows
+}
+
+fn default_max_cell_bytes() -> usize {
+    crate::database::ResultLimits::default().max_cell_bytes
+}
+
+fn default_preview_cell_chars() -> usize {
+    crate::database::DEFAULT_PREVIEW_CELL_CHARS
+}
+
+fn default_preview_page_rows() -> usize {
+    crate::database::DEFAULT_PREVIEW_PAGE_ROWS
+}
+
+fn default_watch_interval_secs() -> u64 {
+    5
+}
+
+fn default_statement_savepoints() -> bool {
+    true
+}
+
+fn default_guard_read_only_servers() -> bool {
+    true
+}
+
+fn default_connect_backoff_ms() -> u64 {
+    crate::database::ConnectRetry::default().backoff.as_millis() as u64
+}
+
+fn default_key_hints() -> bool {
+    true
+}
+
+fn default_show_clock() -> bool {
+    true
+}
+
+fn default_readline_keys() -> bool {
+    true
+}
+
+fn default_query_history_limit() -> usize {
+    100
+}
+
+fn default_clock_format() -> String {
+    "%b %d, %Y  %H:%M:%S".to_string()
+}
+
+/// How the left column shows connections and their objects
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq, Serialize, Deserialize)]
+#[serde(rename_all = "lowercase")]
+pub enum SidebarMode {
+    /// The Connections, Tables and Details panes stacked
+    #[default]
+    Panels,
+    /// One tree of connections, schemas and their objects above the
+    /// Details pane
+    Tree,
+}
+
+#[derive(Debug, Clone, Default, Serialize, Deserialize)]
+pub struct UiConfig {
+    /// "panels" or "tree"
+    #[serde(default)]
+    pub sidebar_mode: SidebarMode,
+    /// Spell out what colors alone would show - the focused pane, NULL
+    /// cells, connection states - raise contrast, and keep the busy
+    /// indicator still
+    #[serde(default)]
+    pub accessibility_mode: bool,
+}
+
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct LoggingConfig {
+    /// Log level, optionally with per-area overrides (e.g. "info,db=debug").
+    /// Unset means warn in release builds and info in development builds
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub level: Option<String>,
+    /// Size in megabytes at which lazytables.log is rotated
+    #[serde(default = "default_log_max_size_mb")]
+    pub max_size_mb: u64,
+    /// Number of rotated log files to keep
+    #[serde(default = "default_log_max_backups")]
+    pub max_backups: usize,
+    /// Days to keep log files from older versions before deleting them
+    #[serde(default = "default_log_retention_days")]
+    pub retention_days: u64,
+    /// Optional JSON lines file recording every executed statement
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub query_log: Option<PathBuf>,
+    /// Also record the metadata queries the panels issue in the query log
+    #[serde(default)]
+    pub query_log_verbose: bool,
+    /// Log every statement run, the panels' own included, with its duration
+    /// and the feature that issued it
+    #[serde(default)]
+    pub trace_sql: bool,
+}
+
+impl Default for LoggingConfig {
+    fn default() -> Self {
+        Self {
+            level: None,
+            max_size_mb: default_log_max_size_mb(),
+            max_backups: default_log_max_backups(),
+            retention_days: default_log_retention_days(),
+            query_log: None,
+            query_log_verbose: false,
+            trace_sql: false,
+        }
+    }
+}
+
+fn default_log_max_size_mb() -> u64 {
+    10
+}
+
+fn default_log_max_backups() -> usize {
+    5
+}
+
+fn default_log_retention_days() -> u64 {
+    14
 }
 
 impl Config {
@@ -91,20 +413,52 @@ impl Config {
         Ok(())
     }
 
-    /// Get default configuration path - uses ~/.config/lazytables/config.toml  
+    /// Get default configuration path - uses $XDG_CONFIG_HOME/lazytables/config.toml
     pub fn default_path() -> PathBuf {
-        dirs::config_dir()
-            .map(|config| config.join("lazytables").join("config.toml"))
-            .unwrap_or_else(|| PathBuf::from(".config/lazytables/config.toml"))
+        Self::config_dir().join("config.toml")
     }
 
-    /// Get data directory path - uses ~/.lazytables
-    pub fn data_dir() -> PathBuf {
-        dirs::home_dir()
+    /// Get configuration directory - uses $XDG_CONFIG_HOME/lazytables
+    pub fn config_dir() -> PathBuf {
+        xdg_env("XDG_CONFIG_HOME")
+            .or_else(platform_config_dir)
+            .map(|config| config.join("lazytables"))
+            .unwrap_or_else(|| PathBuf::from(".config/lazytables"))
+    }
+
+    /// Get the legacy data directory (~/.lazytables) used before XDG support
+    pub fn legacy_data_dir() -> PathBuf {
+        home_dir()
             .map(|home| home.join(".lazytables"))
             .unwrap_or_else(|| PathBuf::from(".lazytables"))
     }
 
+    /// Get data directory path - uses $XDG_DATA_HOME/lazytables, or the legacy
+    /// ~/.lazytables when it still exists so existing installs keep working
+    pub fn data_dir() -> PathBuf {
+        let legacy = Self::legacy_data_dir();
+        if legacy.is_dir() {
+            return legacy;
+        }
+
+        xdg_dir("XDG_DATA_HOME", ".local/share")
+            .map(|data| data.join("lazytables"))
+            .unwrap_or(legacy)
+    }
+
+    /// Get state directory path - uses $XDG_STATE_HOME/lazytables, falling back
+    /// to the data directory when the legacy layout is in use
+    pub fn state_dir() -> PathBuf {
+        let legacy = Self::legacy_data_dir();
+        if legacy.is_dir() {
+            return legacy;
+        }
+
+        xdg_dir("XDG_STATE_HOME", ".local/state")
+            .map(|state| state.join("lazytables"))
+            .unwrap_or_else(Self::data_dir)
+    }
+
     /// Get connections storage path
     pub fn connections_path() -> PathBuf {
         Self::data_dir().join("connections.json")
@@ -115,9 +469,9 @@ impl Config {
         Self::data_dir().join("sql_files")
     }
 
-    /// Get logs directory  
+    /// Get logs directory
     pub fn logs_dir() -> PathBuf {
-        Self::data_dir().join("logs")
+        Self::state_dir().join("logs")
     }
 
     /// Get backups directory
@@ -133,7 +487,7 @@ impl Config {
     /// Ensure all necessary directories exist
     pub fn ensure_directories() -> Result<()> {
         let data_dir = Self::data_dir();
-        let config_dir = Self::default_path().parent().unwrap().to_path_buf();
+        let config_dir = Self::config_dir();
 
         // Create main directories
         fs::create_dir_all(&config_dir)?;
@@ -150,13 +504,14 @@ impl Config {
 
 This directory contains all LazyTables application data:
 
-- `config.toml`: Main configuration file
 - `connections.json`: Database connection definitions  
 - `connections/`: Individual connection files
 - `sql_files/`: Saved SQL query files
-- `logs/`: Application log files
 - `backups/`: Backup files
 
+The configuration file lives in `$XDG_CONFIG_HOME/lazytables/config.toml` and
+logs are written to `$XDG_STATE_HOME/lazytables/logs/`.
+
 This directory is created automatically by LazyTables.
 ";
             fs::write(&readme_path, readme_content)?;
@@ -191,6 +546,175 @@ SELECT
 
         Ok(())
     }
+
+    /// Move files from the legacy ~/.lazytables directory into the XDG data
+    /// and state directories. Returns a description of every action taken,
+    /// including the entries that couldn't be moved
+    pub fn migrate_legacy_data_dir() -> Result<Vec<String>> {
+        let legacy = Self::legacy_data_dir();
+
+        if !legacy.is_dir() {
+            return Ok(vec![format!(
+                "Nothing to migrate: {} does not exist",
+                legacy.display()
+            )]);
+        }
+
+        let data_dir = xdg_dir("XDG_DATA_HOME", ".local/share")
+            .map(|data| data.join("lazytables"))
+            .ok_or_else(|| {
+                LazyTablesError::Config("Could not determine XDG data directory".to_string())
+            })?;
+        let state_dir = xdg_dir("XDG_STATE_HOME", ".local/state")
+            .map(|state| state.join("lazytables"))
+            .unwrap_or_else(|| data_dir.clone());
+
+        fs::create_dir_all(&data_dir)?;
+        let mut actions = migrate_entries(&legacy, &data_dir, &state_dir)?;
+
+        // Only remove the legacy directory once it is empty, otherwise it keeps
+        // taking precedence and the skipped entries stay reachable
+        if fs::read_dir(&legacy)?.next().is_none() {
+            fs::remove_dir(&legacy)?;
+            let action = format!("Removed empty legacy directory {}", legacy.display());
+            tracing::info!("{}", action);
+            actions.push(action);
+        } else {
+            let action = format!(
+                "Kept {} because some entries could not be migrated",
+                legacy.display()
+            );
+            tracing::warn!("{}", action);
+            actions.push(action);
+        }
+
+        Ok(actions)
+    }
+}
+
+/// Move each entry of the legacy directory into the data directory, logs
+/// into the state directory. An entry that fails is reported and left where
+/// it was, and the rest are still moved
+fn migrate_entries(legacy: &Path, data_dir: &Path, state_dir: &Path) -> Result<Vec<String>> {
+    let mut actions = Vec::new();
+    for entry in fs::read_dir(legacy)? {
+        let entry = entry?;
+        let name = entry.file_name();
+
+        // Logs are state, everything else is user data
+        let target_dir = if name == "logs" { state_dir } else { data_dir };
+        let target = target_dir.join(&name);
+
+        if target.exists() {
+            let action = format!(
+                "Skipped {}: {} already exists",
+                entry.path().display(),
+                target.display()
+            );
+            tracing::warn!("{}", action);
+            actions.push(action);
+            continue;
+        }
+
+        match fs::create_dir_all(target_dir).and_then(|()| move_entry(&entry.path(), &target)) {
+            Ok(()) => {
+                let action = format!("Moved {} -> {}", entry.path().display(), target.display());
+                tracing::info!("{}", action);
+                actions.push(action);
+            }
+            Err(e) => {
+                let action = format!(
+                    "Failed to move {} -> {}: {e}",
+                    entry.path().display(),
+                    target.display()
+                );
+                tracing::error!("{}", action);
+                actions.push(action);
+            }
+        }
+    }
+    Ok(actions)
+}
+
+/// Rename `from` to `to`, or copy it and remove the original when the
+/// rename fails, as it does between filesystems. Any rename error falls back
+/// to copying, since the error kind for crossing devices isn't available on
+/// our minimum Rust version. Nothing is copied over an existing `to`, and a
+/// copy that fails halfway is removed again, so the original stays the only
+/// one
+fn move_entry(from: &Path, to: &Path) -> io::Result<()> {
+    match fs::rename(from, to) {
+        Ok(()) => return Ok(()),
+        Err(e) if to.exists() => return Err(e),
+        Err(_) => {}
+    }
+    if let Err(e) = copy_entry(from, to) {
+        let _ = remove_entry(to);
+        return Err(e);
+    }
+    remove_entry(from)
+}
+
+/// Copy a file, or a directory with everything in it
+fn copy_entry(from: &Path, to: &Path) -> io::Result<()> {
+    if !fs::symlink_metadata(from)?.is_dir() {
+        return fs::copy(from, to).map(|_| ());
+    }
+    fs::create_dir(to)?;
+    for entry in fs::read_dir(from)? {
+        let entry = entry?;
+        copy_entry(&entry.path(), &to.join(entry.file_name()))?;
+    }
+    Ok(())
+}
+
+/// Remove a file, or a directory with everything in it
+fn remove_entry(path: &Path) -> io::Result<()> {
+    if fs::symlink_metadata(path)?.is_dir() {
+        fs::remove_dir_all(path)
+    } else {
+        fs::remove_file(path)
+    }
+}
+
+/// The home directory. Test builds use a temporary directory instead, and
+/// ignore the XDG variables, so tests never touch the user's files and never
+/// have to set environment variables, which other threads may be reading
+fn home_dir() -> Option<PathBuf> {
+    #[cfg(test)]
+    {
+        static HOME: std::sync::OnceLock<tempfile::TempDir> = std::sync::OnceLock::new();
+        let home = HOME.get_or_init(|| tempfile::tempdir().expect("temporary home directory"));
+        Some(home.path().to_path_buf())
+    }
+    #[cfg(not(test))]
+    dirs::home_dir()
+}
+
+/// The platform's configuration directory, e.g. ~/.config on Linux; under
+/// the temporary home directory in tests
+fn platform_config_dir() -> Option<PathBuf> {
+    if cfg!(test) {
+        return home_dir().map(|home| home.join(".config"));
+    }
+    dirs::config_dir()
+}
+
+/// Read an XDG base directory variable. Relative values are ignored as the
+/// XDG spec requires.
+fn xdg_env(var: &str) -> Option<PathBuf> {
+    if cfg!(test) {
+        return None;
+    }
+    std::env::var_os(var)
+        .map(PathBuf::from)
+        .filter(|path| path.is_absolute())
+}
+
+/// Resolve an XDG base directory, falling back to the given path under the
+/// home directory when the variable is unset
+fn xdg_dir(var: &str, home_fallback: &str) -> Option<PathBuf> {
+    xdg_env(var).or_else(|| home_dir().map(|home| home.join(home_fallback)))
 }
 
 impl Default for Config {
@@ -211,9 +735,101 @@ impl Default for Config {
                 connection_timeout: 5000,
                 max_connections: 10,
             },
-            keybindings: KeybindingsConfig {
-                leader_key: " ".to_string(),
-            },
+            keybindings: KeybindingsConfig::default(),
+            logging: LoggingConfig::default(),
+            app: AppConfig::default(),
+            ui: UiConfig::default(),
+            statement_guard: Default::default(),
         }
     }
 }
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_migration_keeps_existing_destinations() {
+        let dir = tempfile::tempdir().unwrap();
+        let (legacy, data, state) = (
+            dir.path().join("legacy"),
+            dir.path().join("data"),
+            dir.path().join("state"),
+        );
+        fs::create_dir_all(legacy.join("sql_files")).unwrap();
+        fs::write(legacy.join("sql_files/report.sql"), "SELECT 1").unwrap();
+        fs::write(legacy.join("connections.json"), "old").unwrap();
+        fs::create_dir_all(&data).unwrap();
+        fs::write(data.join("connections.json"), "new").unwrap();
+
+        let actions = migrate_entries(&legacy, &data, &state).unwrap();
+        assert_eq!(actions.len(), 2);
+        assert!(actions.iter().any(|action| action.starts_with("Skipped")));
+        assert_eq!(
+            fs::read_to_string(data.join("sql_files/report.sql")).unwrap(),
+            "SELECT 1"
+        );
+        // Neither copy of the existing file is overwritten
+        assert_eq!(
+            fs::read_to_string(data.join("connections.json")).unwrap(),
+            "new"
+        );
+        assert_eq!(
+            fs::read_to_string(legacy.join("connections.json")).unwrap(),
+            "old"
+        );
+    }
+
+    #[test]
+    fn test_partial_migration_moves_the_rest() {
+        let dir = tempfile::tempdir().unwrap();
+        let (legacy, data, state) = (
+            dir.path().join("legacy"),
+            dir.path().join("data"),
+            dir.path().join("state"),
+        );
+        fs::create_dir_all(legacy.join("logs")).unwrap();
+        fs::write(legacy.join("logs/lazytables.log"), "log").unwrap();
+        fs::write(legacy.join("connections.json"), "[]").unwrap();
+        fs::create_dir_all(&data).unwrap();
+        // The state directory can't be created, so the logs can't move
+        fs::write(&state, "not a directory").unwrap();
+
+        let actions = migrate_entries(&legacy, &data, &state).unwrap();
+        assert!(actions
+            .iter()
+            .any(|action| action.starts_with("Failed to move")));
+        assert!(data.join("connections.json").exists());
+        assert!(!legacy.join("connections.json").exists());
+        assert!(legacy.join("logs/lazytables.log").exists());
+    }
+
+    #[test]
+    fn test_copy_then_remove_moves_a_tree() {
+        let dir = tempfile::tempdir().unwrap();
+        let (from, to) = (dir.path().join("from"), dir.path().join("to"));
+        fs::create_dir_all(from.join("nested")).unwrap();
+        fs::write(from.join("nested/query.sql"), "SELECT 2").unwrap();
+
+        copy_entry(&from, &to).unwrap();
+        remove_entry(&from).unwrap();
+        assert!(!from.exists());
+        assert_eq!(
+            fs::read_to_string(to.join("nested/query.sql")).unwrap(),
+            "SELECT 2"
+        );
+    }
+
+    #[test]
+    fn test_failed_move_leaves_an_existing_target_alone() {
+        let dir = tempfile::tempdir().unwrap();
+        let (from, to) = (dir.path().join("from.sql"), dir.path().join("to"));
+        fs::write(&from, "SELECT 1").unwrap();
+        fs::create_dir_all(&to).unwrap();
+        fs::write(to.join("kept.sql"), "SELECT 2").unwrap();
+
+        assert!(move_entry(&from, &to).is_err());
+        assert!(from.exists());
+        assert!(to.join("kept.sql").exists());
+    }
+}
@@ -3,7 +3,7 @@
 #![forbid(unsafe_code)]
 
 /// Application version
-pub const VERSION: &str = env!("CARGO_PKG_VERSION");
+pub const VERSION: &str = crate::version::VERSION;
 
 /// Application name
 pub const APP_NAME: &str = "LazyTables";
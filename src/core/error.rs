@@ -31,6 +31,13 @@ pub enum LazyTablesError {
     #[error("Connection error: {0}")]
     Connection(String),
 
+    /// A database adapter couldn't open its connection pool
+    #[error("Connection error: Failed to connect to {database}: {source}")]
+    Connect {
+        database: &'static str,
+        source: sqlx::Error,
+    },
+
     #[error("Connection failed: {0}")]
     ConnectionFailed(ConnectionError),
 
@@ -68,6 +75,44 @@ impl From<toml::ser::Error> for LazyTablesError {
     }
 }
 
+impl LazyTablesError {
+    /// Whether the server connection went away under a statement: the server
+    /// restarted or ended the session, or the connection was reset or closed.
+    /// The same statement may well work on a new connection
+    pub fn is_connection_lost(&self) -> bool {
+        match self {
+            Self::Database(sqlx::Error::Io(_) | sqlx::Error::PoolClosed) => true,
+            // SQLSTATE class 08 is a connection exception; 57P01 to 57P03 are
+            // PostgreSQL shutting sessions down
+            Self::Database(sqlx::Error::Database(error)) => error.code().is_some_and(|code| {
+                code.starts_with("08") || matches!(&*code, "57P01" | "57P02" | "57P03")
+            }),
+            _ => false,
+        }
+    }
+
+    /// Whether a connection attempt failed for a reason that may pass by
+    /// itself: the server couldn't be reached or timed out, is still starting
+    /// or has no connections free. Wrong credentials, an unknown database or
+    /// bad settings fail the same way on every attempt
+    pub fn is_transient(&self) -> bool {
+        match self {
+            Self::Io(_) => true,
+            Self::Database(error) | Self::Connect { source: error, .. } => match error {
+                sqlx::Error::Io(_) | sqlx::Error::PoolTimedOut | sqlx::Error::PoolClosed => true,
+                // SQLSTATE class 08 is a connection exception, which MySQL
+                // also reports for too many connections; 57P03 is PostgreSQL
+                // still starting up and 53300 out of connections
+                sqlx::Error::Database(error) => error.code().is_some_and(|code| {
+                    code.starts_with("08") || matches!(&*code, "57P03" | "53300")
+                }),
+                _ => false,
+            },
+            _ => false,
+        }
+    }
+}
+
 /// Legacy type alias for backwards compatibility
 pub type Error = LazyTablesError;
 
@@ -0,0 +1,270 @@
+// FilePath: src/core/metrics.rs
+
+//! Debug metrics endpoint
+//!
+//! For profiling performance regressions against real workloads,
+//! `--debug --metrics-addr 127.0.0.1:9187` serves histograms of query,
+//! metadata and render durations and a count of LISTEN/NOTIFY notifications
+//! in the Prometheus text format at `/metrics`. Nothing is recorded or served
+//! without the flags, and the endpoint only binds to a loopback address.
+
+#![forbid(unsafe_code)]
+
+use std::net::{IpAddr, Ipv4Addr, SocketAddr};
+use std::sync::atomic::{AtomicBool, AtomicU64, Ordering};
+use std::time::Duration;
+use tokio::io::{AsyncReadExt, AsyncWriteExt};
+use tokio::net::TcpListener;
+
+/// Upper bounds of the histogram buckets, in seconds
+const BUCKETS: [f64; 12] = [
+    0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1.0, 2.5, 5.0, 10.0,
+];
+
+/// Wait after a failed accept, doubled while it keeps failing
+const ACCEPT_BACKOFF: Duration = Duration::from_millis(10);
+
+/// Longest wait between two accepts that fail
+const MAX_ACCEPT_BACKOFF: Duration = Duration::from_secs(1);
+
+/// What a duration is observed for
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum Timing {
+    /// Statements run by the user: query editor statements and data edits
+    Query,
+    /// Queries the panels issue on their own (row counts, columns, objects)
+    Metadata,
+    /// Drawing one frame
+    Render,
+}
+
+impl Timing {
+    const ALL: [Timing; 3] = [Timing::Query, Timing::Metadata, Timing::Render];
+
+    fn name(self) -> &'static str {
+        match self {
+            Timing::Query => "lazytables_query_duration_seconds",
+            Timing::Metadata => "lazytables_metadata_query_duration_seconds",
+            Timing::Render => "lazytables_render_duration_seconds",
+        }
+    }
+
+    fn help(self) -> &'static str {
+        match self {
+            Timing::Query => "Duration of statements run by the user",
+            Timing::Metadata => "Duration of queries the panels issue on their own",
+            Timing::Render => "Duration of drawing one frame",
+        }
+    }
+}
+
+/// Cumulative histogram of durations
+#[derive(Debug, Default)]
+struct Histogram {
+    buckets: [AtomicU64; BUCKETS.len()],
+    count: AtomicU64,
+    sum_micros: AtomicU64,
+}
+
+impl Histogram {
+    fn observe(&self, elapsed: Duration) {
+        let secs = elapsed.as_secs_f64();
+        for (bucket, bound) in self.buckets.iter().zip(BUCKETS) {
+            if secs <= bound {
+                bucket.fetch_add(1, Ordering::Relaxed);
+            }
+        }
+        self.count.fetch_add(1, Ordering::Relaxed);
+        self.sum_micros
+            .fetch_add(elapsed.as_micros() as u64, Ordering::Relaxed);
+    }
+
+    fn write(&self, name: &str, help: &str, out: &mut String) {
+        out.push_str(&format!("# HELP {name} {help}\n# TYPE {name} histogram\n"));
+        for (bucket, bound) in self.buckets.iter().zip(BUCKETS) {
+            out.push_str(&format!(
+                "{name}_bucket{{le=\"{bound}\"}} {}\n",
+                bucket.load(Ordering::Relaxed)
+            ));
+        }
+        let count = self.count.load(Ordering::Relaxed);
+        out.push_str(&format!("{name}_bucket{{le=\"+Inf\"}} {count}\n"));
+        out.push_str(&format!(
+            "{name}_sum {}\n",
+            self.sum_micros.load(Ordering::Relaxed) as f64 / 1_000_000.0
+        ));
+        out.push_str(&format!("{name}_count {count}\n"));
+    }
+}
+
+/// Everything the endpoint reports
+#[derive(Debug, Default)]
+struct Metrics {
+    enabled: AtomicBool,
+    queries: Histogram,
+    metadata: Histogram,
+    render: Histogram,
+    notifications: AtomicU64,
+}
+
+impl Metrics {
+    fn histogram(&self, timing: Timing) -> &Histogram {
+        match timing {
+            Timing::Query => &self.queries,
+            Timing::Metadata => &self.metadata,
+            Timing::Render => &self.render,
+        }
+    }
+
+    fn render(&self) -> String {
+        let mut out = String::new();
+        for timing in Timing::ALL {
+            self.histogram(timing)
+                .write(timing.name(), timing.help(), &mut out);
+        }
+        out.push_str(
+            "# HELP lazytables_notifications_total LISTEN/NOTIFY notifications received\n\
+             # TYPE lazytables_notifications_total counter\n",
+        );
+        out.push_str(&format!(
+            "lazytables_notifications_total {}\n",
+            self.notifications.load(Ordering::Relaxed)
+        ));
+        out
+    }
+}
+
+lazy_static::lazy_static! {
+    static ref METRICS: Metrics = Metrics::default();
+}
+
+/// Start recording; until then observations are dropped
+pub fn enable() {
+    METRICS.enabled.store(true, Ordering::Relaxed);
+}
+
+fn enabled() -> bool {
+    METRICS.enabled.load(Ordering::Relaxed)
+}
+
+/// Record how long something took
+pub fn observe(timing: Timing, elapsed: Duration) {
+    if enabled() {
+        METRICS.histogram(timing).observe(elapsed);
+    }
+}
+
+/// Count a received LISTEN/NOTIFY notification
+pub fn count_notification() {
+    if enabled() {
+        METRICS.notifications.fetch_add(1, Ordering::Relaxed);
+    }
+}
+
+/// The metrics in the Prometheus text exposition format
+pub fn render() -> String {
+    METRICS.render()
+}
+
+/// Address for `--metrics-addr`: `:9187` and `localhost:9187` bind to
+/// 127.0.0.1, and anything but a loopback address is refused
+pub fn parse_addr(addr: &str) -> Result<SocketAddr, String> {
+    let (host, port) = addr
+        .rsplit_once(':')
+        .ok_or_else(|| format!("Metrics address '{addr}' needs a port, e.g. :9187"))?;
+    let port: u16 = port
+        .parse()
+        .map_err(|_| format!("Invalid port in metrics address '{addr}'"))?;
+    let ip = match host.trim_start_matches('[').trim_end_matches(']') {
+        "" | "localhost" => IpAddr::V4(Ipv4Addr::LOCALHOST),
+        host => host
+            .parse()
+            .map_err(|_| format!("Invalid host in metrics address '{addr}'"))?,
+    };
+    if !ip.is_loopback() {
+        return Err(format!(
+            "Metrics address '{addr}' isn't a loopback address; the endpoint only binds to localhost"
+        ));
+    }
+    Ok(SocketAddr::new(ip, port))
+}
+
+/// Answer `GET /metrics` on the listener until the process exits
+pub async fn serve(listener: TcpListener) {
+    let mut backoff = ACCEPT_BACKOFF;
+    loop {
+        let mut stream = match listener.accept().await {
+            Ok((stream, _)) => {
+                backoff = ACCEPT_BACKOFF;
+                stream
+            }
+            Err(e) => {
+                // Out of file descriptors and the like: wait for it to pass
+                // rather than spinning on the error
+                crate::log_warn!("Metrics endpoint failed to accept a connection: {}", e);
+                tokio::time::sleep(backoff).await;
+                backoff = backoff.saturating_mul(2).min(MAX_ACCEPT_BACKOFF);
+                continue;
+            }
+        };
+        tokio::spawn(async move {
+            let mut request = [0u8; 1024];
+            let read = stream.read(&mut request).await.unwrap_or(0);
+            let request = String::from_utf8_lossy(&request[..read]);
+            let path = request.split_whitespace().nth(1).unwrap_or("");
+            let response = if request.starts_with("GET ") && path == "/metrics" {
+                let body = render();
+                format!(
+                    "HTTP/1.1 200 OK\r\nContent-Type: text/plain; version=0.0.4\r\nContent-Length: {}\r\nConnection: close\r\n\r\n{body}",
+                    body.len()
+                )
+            } else {
+                "HTTP/1.1 404 Not Found\r\nContent-Length: 0\r\nConnection: close\r\n\r\n"
+                    .to_string()
+            };
+            let _ = stream.write_all(response.as_bytes()).await;
+        });
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_parse_addr_is_loopback_only() {
+        assert_eq!(
+            parse_addr(":9187").unwrap(),
+            "127.0.0.1:9187".parse().unwrap()
+        );
+        assert_eq!(
+            parse_addr("localhost:9187").unwrap(),
+            "127.0.0.1:9187".parse().unwrap()
+        );
+        assert_eq!(
+            parse_addr("[::1]:9187").unwrap(),
+            "[::1]:9187".parse().unwrap()
+        );
+        assert!(parse_addr("0.0.0.0:9187").is_err());
+        assert!(parse_addr("192.168.1.5:9187").is_err());
+        assert!(parse_addr("9187").is_err());
+    }
+
+    #[test]
+    fn test_histogram_exposition() {
+        let metrics = Metrics::default();
+        metrics
+            .histogram(Timing::Query)
+            .observe(Duration::from_millis(30));
+        metrics
+            .histogram(Timing::Query)
+            .observe(Duration::from_millis(2));
+        let text = metrics.render();
+        assert!(text.contains("# TYPE lazytables_query_duration_seconds histogram"));
+        assert!(text.contains("lazytables_query_duration_seconds_bucket{le=\"0.005\"} 1\n"));
+        assert!(text.contains("lazytables_query_duration_seconds_bucket{le=\"0.05\"} 2\n"));
+        assert!(text.contains("lazytables_query_duration_seconds_bucket{le=\"+Inf\"} 2\n"));
+        assert!(text.contains("lazytables_query_duration_seconds_sum 0.032\n"));
+        assert!(text.contains("lazytables_notifications_total 0\n"));
+    }
+}
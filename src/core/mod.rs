@@ -3,3 +3,5 @@
 #![forbid(unsafe_code)]
 
 pub mod error;
+pub mod metrics;
+pub mod redact;
@@ -58,6 +58,13 @@ impl AppStateDb {
         Ok(app_db)
     }
 
+    /// Close the pool so pending writes reach disk before exit
+    pub async fn close(&mut self) {
+        if let Some(pool) = self.pool.take() {
+            pool.close().await;
+        }
+    }
+
     /// Get the path to the application state database
     pub fn database_path() -> PathBuf {
         Config::data_dir().join("app_state.db")
@@ -0,0 +1,152 @@
+// FilePath: src/database/audit_log.rs
+
+#![forbid(unsafe_code)]
+
+use crate::core::error::Result;
+use chrono::{DateTime, Utc};
+use serde::{Deserialize, Serialize};
+use std::fs::{File, OpenOptions};
+use std::io::Write;
+use std::path::{Path, PathBuf};
+use std::sync::Mutex;
+
+/// What issued a statement recorded in the audit log
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize, Deserialize)]
+#[serde(rename_all = "lowercase")]
+pub enum QueryKind {
+    /// SQL run by the user: query editor statements and data edits
+    Statement,
+    /// Queries the panels issue on their own (row counts, columns, object lists)
+    Metadata,
+}
+
+/// One line of the audit log
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct QueryAuditEntry {
+    pub timestamp: DateTime<Utc>,
+    pub connection: String,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub database: Option<String>,
+    pub kind: QueryKind,
+    pub query: String,
+    pub duration_ms: u64,
+    /// Rows returned by the statement (SELECT) or affected by it (DML)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub rows: Option<usize>,
+    pub success: bool,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub error: Option<String>,
+}
+
+/// Append-only JSON lines file recording every statement executed through
+/// the connection manager
+#[derive(Debug)]
+pub struct QueryAuditLog {
+    path: PathBuf,
+    file: Mutex<File>,
+    verbose: bool,
+}
+
+impl QueryAuditLog {
+    /// Open (or create) the audit log at the given path. Metadata queries are
+    /// only recorded when `verbose` is set
+    pub fn open(path: &Path, verbose: bool) -> Result<Self> {
+        if let Some(parent) = path.parent() {
+            std::fs::create_dir_all(parent)?;
+        }
+        let file = OpenOptions::new().create(true).append(true).open(path)?;
+
+        Ok(Self {
+            path: path.to_path_buf(),
+            file: Mutex::new(file),
+            verbose,
+        })
+    }
+
+    /// Path of the audit log file
+    pub fn path(&self) -> &Path {
+        &self.path
+    }
+
+    /// Whether entries of this kind should be written
+    pub fn records(&self, kind: QueryKind) -> bool {
+        kind == QueryKind::Statement || self.verbose
+    }
+
+    /// Append an entry. Failures are logged rather than surfaced so auditing
+    /// never breaks query execution
+    pub fn record(&self, entry: &QueryAuditEntry) {
+        if !self.records(entry.kind) {
+            return;
+        }
+
+        let line = match serde_json::to_string(entry) {
+            Ok(line) => line,
+            Err(e) => {
+                tracing::warn!("Failed to serialize audit log entry: {}", e);
+                return;
+            }
+        };
+
+        if let Ok(mut file) = self.file.lock() {
+            if let Err(e) = writeln!(file, "{}", line).and_then(|_| file.flush()) {
+                tracing::warn!("Failed to write audit log {:?}: {}", self.path, e);
+            }
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn entry(kind: QueryKind, success: bool) -> QueryAuditEntry {
+        QueryAuditEntry {
+            timestamp: Utc::now(),
+            connection: "Prod Postgres".to_string(),
+            database: Some("shop".to_string()),
+            kind,
+            query: "SELECT * FROM orders".to_string(),
+            duration_ms: 12,
+            rows: success.then_some(3),
+            success,
+            error: (!success).then(|| "relation does not exist".to_string()),
+        }
+    }
+
+    #[test]
+    fn test_writes_one_json_line_per_statement() {
+        let dir = tempfile::tempdir().unwrap();
+        let path = dir.path().join("audit").join("queries.jsonl");
+        let log = QueryAuditLog::open(&path, false).unwrap();
+
+        log.record(&entry(QueryKind::Statement, true));
+        log.record(&entry(QueryKind::Metadata, true));
+        log.record(&entry(QueryKind::Statement, false));
+
+        let content = std::fs::read_to_string(&path).unwrap();
+        let lines: Vec<&str> = content.lines().collect();
+        assert_eq!(lines.len(), 2);
+
+        let first: QueryAuditEntry = serde_json::from_str(lines[0]).unwrap();
+        assert_eq!(first.connection, "Prod Postgres");
+        assert_eq!(first.rows, Some(3));
+        assert!(first.success);
+
+        let second: QueryAuditEntry = serde_json::from_str(lines[1]).unwrap();
+        assert!(!second.success);
+        assert_eq!(second.error.as_deref(), Some("relation does not exist"));
+    }
+
+    #[test]
+    fn test_verbose_records_metadata_queries() {
+        let dir = tempfile::tempdir().unwrap();
+        let path = dir.path().join("queries.jsonl");
+        let log = QueryAuditLog::open(&path, true).unwrap();
+
+        log.record(&entry(QueryKind::Metadata, true));
+
+        let content = std::fs::read_to_string(&path).unwrap();
+        assert!(content.contains("\"kind\":\"metadata\""));
+    }
+}
@@ -0,0 +1,102 @@
+// FilePath: src/database/cancel.rs
+
+//! Cancelling the statement running on a session connection
+//!
+//! A running statement keeps the session connection busy until it finishes,
+//! so the cancel goes through another pooled connection naming the session's
+//! server-side id: `pg_cancel_backend` on PostgreSQL, `KILL QUERY` on MySQL
+//! and MariaDB. The statement then fails with a cancellation error and the
+//! session stays open. SQLite runs in-process and has no such request.
+
+#![forbid(unsafe_code)]
+
+use crate::core::error::Result;
+use sqlx::mysql::MySqlPool;
+use sqlx::postgres::PgPool;
+use std::sync::atomic::{AtomicI64, Ordering};
+use std::sync::Arc;
+
+/// Asks the server to stop whatever statement a session connection runs
+#[derive(Debug, Clone)]
+pub enum QueryCanceller {
+    Postgres {
+        pool: PgPool,
+        /// Backend pid of the session connection, 0 until known
+        backend_pid: Arc<AtomicI64>,
+    },
+    MySql {
+        pool: MySqlPool,
+        /// Connection id of the session connection, 0 until known
+        connection_id: Arc<AtomicI64>,
+    },
+}
+
+impl QueryCanceller {
+    /// Cancel the session's running statement. False when the session never
+    /// ran one, so there's nothing to cancel
+    pub async fn cancel(&self) -> Result<bool> {
+        match self {
+            Self::Postgres { pool, backend_pid } => {
+                let pid = backend_pid.load(Ordering::SeqCst);
+                if pid == 0 {
+                    return Ok(false);
+                }
+                let signalled: bool = sqlx::query_scalar("SELECT pg_cancel_backend($1)")
+                    .bind(pid as i32)
+                    .fetch_one(pool)
+                    .await?;
+                Ok(signalled)
+            }
+            Self::MySql {
+                pool,
+                connection_id,
+            } => {
+                let id = connection_id.load(Ordering::SeqCst);
+                if id == 0 {
+                    return Ok(false);
+                }
+                sqlx::raw_sql(&format!("KILL QUERY {id}"))
+                    .execute(pool)
+                    .await?;
+                Ok(true)
+            }
+        }
+    }
+}
+
+/// MySQL's and MariaDB's `ER_QUERY_INTERRUPTED`, which their statement
+/// timeouts extend with the limit that was hit
+const INTERRUPTED: &str = "Query execution was interrupted";
+
+/// Whether a query error is the server reporting a statement cancelled on
+/// request rather than by a statement timeout
+pub fn is_cancellation(error: &str) -> bool {
+    error.contains("canceling statement due to user request")
+        || error
+            .match_indices(INTERRUPTED)
+            .any(|(at, _)| !error[at + INTERRUPTED.len()..].starts_with([',', ' ']))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_cancellation_errors() {
+        assert!(is_cancellation(
+            "error returned from database: canceling statement due to user request"
+        ));
+        assert!(is_cancellation(
+            "error returned from database: 1317 (70100): Query execution was interrupted"
+        ));
+        assert!(!is_cancellation(
+            "error returned from database: canceling statement due to statement timeout"
+        ));
+        assert!(!is_cancellation(
+            "3024 (HY000): Query execution was interrupted, maximum statement execution time exceeded"
+        ));
+        assert!(!is_cancellation(
+            "1969 (70100): Query execution was interrupted (max_statement_time exceeded)"
+        ));
+    }
+}
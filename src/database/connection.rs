@@ -4,8 +4,10 @@
 
 use crate::config::Config;
 use crate::core::error::Result;
+use crate::database::DisplayTimeZone;
 use crate::security::{PasswordManager, PasswordSource};
 use serde::{Deserialize, Serialize};
+use std::path::{Path, PathBuf};
 // Removed: use std::fs; (now using async file I/O)
 
 /// Database type
@@ -98,6 +100,18 @@ pub struct ConnectionConfig {
     pub ssl_mode: SslMode,
     /// Connection timeout in seconds
     pub timeout: Option<u64>,
+    /// Schemas unqualified names resolve against, comma separated, e.g.
+    /// `app, public` (PostgreSQL). Applied with `SET search_path` after connect
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub search_path: Option<String>,
+    /// Zone the session runs in and timestamps are shown in: `UTC`, `local`,
+    /// an offset like `+05:30` or an IANA name like `Europe/Berlin`
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub time_zone: Option<String>,
+    /// Seconds a statement of the session may run before the server cancels
+    /// it, 0 for no limit. Overrides `[app] statement_timeout_secs`
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub statement_timeout: Option<u64>,
     /// Connection status (not persisted, always starts as Disconnected)
     #[serde(skip)]
     pub status: ConnectionStatus,
@@ -124,10 +138,89 @@ impl ConnectionConfig {
             password_source: None,
             ssl_mode: SslMode::default(),
             timeout: Some(30),
+            search_path: None,
+            time_zone: None,
+            statement_timeout: None,
             status: ConnectionStatus::default(),
         }
     }
 
+    /// Database the adapters connect to: the configured one, or the
+    /// server's default (`postgres`, `mysql`, an in-memory SQLite database)
+    pub fn database_or_default(&self) -> &str {
+        if let Some(database) = self.database.as_deref() {
+            return database;
+        }
+        match self.database_type {
+            DatabaseType::PostgreSQL => "postgres",
+            DatabaseType::MySQL | DatabaseType::MariaDB => "mysql",
+            _ => ":memory:",
+        }
+    }
+
+    /// Where statements run, as the query editor names it: the connection
+    /// and its database, e.g. `prod-replica ▸ shop`; a SQLite database by its
+    /// file name
+    pub fn target_label(&self) -> String {
+        let database = self.database_or_default();
+        let database = match self.database_type {
+            DatabaseType::SQLite => Path::new(database)
+                .file_name()
+                .and_then(|name| name.to_str())
+                .unwrap_or(database),
+            _ => database,
+        };
+        format!("{} ▸ {}", self.name, database)
+    }
+
+    /// Whether a password is configured, wherever it's kept
+    pub fn has_password(&self) -> bool {
+        self.password_source.is_some() || self.password.is_some()
+    }
+
+    /// Schemas of the configured search path, in order. Double-quoted entries
+    /// are unquoted and the rest fold to lower case, as PostgreSQL reads them
+    pub fn search_path_schemas(&self) -> Vec<String> {
+        self.search_path
+            .as_deref()
+            .unwrap_or_default()
+            .split(',')
+            .map(|schema| {
+                let schema = schema.trim();
+                match schema
+                    .strip_prefix('"')
+                    .and_then(|schema| schema.strip_suffix('"'))
+                {
+                    Some(quoted) => quoted.replace("\"\"", "\""),
+                    None => schema.to_lowercase(),
+                }
+            })
+            .filter(|schema| !schema.is_empty())
+            .collect()
+    }
+
+    /// Schema the tables panel lists names unqualified for: the first search
+    /// path entry other than `$user`, or `public`
+    pub fn default_schema(&self) -> String {
+        self.search_path_schemas()
+            .into_iter()
+            .find(|schema| schema != "$user")
+            .unwrap_or_else(|| "public".to_string())
+    }
+
+    /// Zone timestamps are shown in; UTC when none is set or the setting
+    /// doesn't parse
+    pub fn display_time_zone(&self) -> DisplayTimeZone {
+        match self.time_zone.as_deref().map(DisplayTimeZone::parse) {
+            Some(Ok(zone)) => zone,
+            Some(Err(e)) => {
+                crate::log_warn!("Connection '{}': {}; showing UTC", self.name, e);
+                DisplayTimeZone::Utc
+            }
+            None => DisplayTimeZone::Utc,
+        }
+    }
+
     /// Get connection display string (e.g., "jatayu (postgres)")
     pub fn display_string(&self) -> String {
         format!("{} ({})", self.name, self.database_type.display_name())
@@ -153,6 +246,16 @@ impl ConnectionConfig {
         }
     }
 
+    /// Status spelled out, for accessibility mode
+    pub fn status_label(&self) -> &str {
+        match &self.status {
+            ConnectionStatus::Disconnected => "[disconnected]",
+            ConnectionStatus::Connecting => "[connecting]",
+            ConnectionStatus::Connected => "[connected]",
+            ConnectionStatus::Failed(_) => "[failed]",
+        }
+    }
+
     /// Check if connection is currently connected
     pub fn is_connected(&self) -> bool {
         matches!(self.status, ConnectionStatus::Connected)
@@ -248,6 +351,10 @@ pub struct ConnectionStorage {
     /// Version for future migration compatibility
     #[serde(default = "default_version")]
     pub version: String,
+    /// Why saving is refused: the file couldn't be read or moved aside, and
+    /// a save would replace the connections it holds
+    #[serde(skip)]
+    pub save_blocked: Option<String>,
 }
 
 fn default_version() -> String {
@@ -257,10 +364,13 @@ fn default_version() -> String {
 impl ConnectionStorage {
     /// Load connections from storage asynchronously (non-blocking)
     pub async fn load() -> Result<Self> {
-        let path = Config::connections_path();
+        Self::load_from(&Config::connections_path()).await
+    }
 
+    /// Load connections from a file; none when it doesn't exist yet
+    pub async fn load_from(path: &Path) -> Result<Self> {
         if path.exists() {
-            let contents = crate::io::async_fs::read_to_string(&path).await?;
+            let contents = crate::io::async_fs::read_to_string(path).await?;
             let storage: ConnectionStorage = toml::from_str(&contents)?;
             Ok(storage)
         } else {
@@ -270,7 +380,16 @@ impl ConnectionStorage {
 
     /// Save connections to storage asynchronously (non-blocking)
     pub async fn save(&self) -> Result<()> {
-        let path = Config::connections_path();
+        self.save_to(&Config::connections_path()).await
+    }
+
+    /// Save connections to a file. They're written to a temporary file
+    /// renamed over the old one, so a save cut short, or racing another,
+    /// never leaves half a file behind
+    pub async fn save_to(&self, path: &Path) -> Result<()> {
+        if let Some(reason) = &self.save_blocked {
+            return Err(crate::core::error::LazyTablesError::Config(reason.clone()));
+        }
 
         // Create parent directory if it doesn't exist
         if let Some(parent) = path.parent() {
@@ -278,10 +397,31 @@ impl ConnectionStorage {
         }
 
         let contents = toml::to_string_pretty(self)?;
-        crate::io::async_fs::write(path, contents).await?;
+        let temp = path.with_extension(format!("json.{}.tmp", uuid::Uuid::new_v4()));
+        crate::io::async_fs::write(&temp, contents).await?;
+        if let Err(e) = crate::io::async_fs::rename(&temp, path).await {
+            let _ = crate::io::async_fs::remove_file(&temp).await;
+            return Err(e);
+        }
         Ok(())
     }
 
+    /// Move a connections file that can't be read out of the way, so the
+    /// next save doesn't overwrite it, returning where it went. The name
+    /// carries the time, and a count if that's taken, so a file set aside
+    /// earlier is never replaced
+    pub async fn set_aside(path: &Path) -> Result<PathBuf> {
+        let stamp = chrono::Local::now().format("%Y%m%d_%H%M%S");
+        let mut aside = path.with_extension(format!("json.{stamp}.unreadable"));
+        let mut count = 1;
+        while aside.exists() {
+            count += 1;
+            aside = path.with_extension(format!("json.{stamp}-{count}.unreadable"));
+        }
+        crate::io::async_fs::rename(path, &aside).await?;
+        Ok(aside)
+    }
+
     /// Add a new connection asynchronously
     pub async fn add_connection(&mut self, connection: ConnectionConfig) -> Result<()> {
         // Check for duplicate names
@@ -350,8 +490,12 @@ pub trait Connection: Send + Sync {
     /// List all tables in the current database
     async fn list_tables(&self) -> Result<Vec<String>>;
 
-    /// List all database objects (tables, views, etc.)
-    async fn list_database_objects(&self) -> Result<crate::database::DatabaseObjectList>;
+    /// List all database objects (tables, views, etc.); system schemas and
+    /// tables only when `include_system` is set
+    async fn list_database_objects(
+        &self,
+        include_system: bool,
+    ) -> Result<crate::database::DatabaseObjectList>;
 
     /// Get detailed metadata for a specific table
     async fn get_table_metadata(&self, table_name: &str) -> Result<crate::database::TableMetadata>;
@@ -467,3 +611,116 @@ pub struct FormattedError {
     pub is_syntax_error: bool,
     pub is_permission_error: bool,
 }
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn with_search_path(search_path: Option<&str>) -> ConnectionConfig {
+        let mut config = ConnectionConfig::new(
+            "app".to_string(),
+            DatabaseType::PostgreSQL,
+            "localhost".to_string(),
+            5432,
+            "postgres".to_string(),
+        );
+        config.search_path = search_path.map(str::to_string);
+        config
+    }
+
+    #[test]
+    fn test_search_path_schemas() {
+        let config = with_search_path(Some(r#" "$user", App ,"Billing ""EU""",, public"#));
+        assert_eq!(
+            config.search_path_schemas(),
+            vec!["$user", "app", r#"Billing "EU""#, "public"]
+        );
+        assert!(with_search_path(None).search_path_schemas().is_empty());
+    }
+
+    #[test]
+    fn test_default_schema_skips_user_entry() {
+        assert_eq!(
+            with_search_path(Some("\"$user\", app")).default_schema(),
+            "app"
+        );
+        assert_eq!(with_search_path(Some("$user")).default_schema(), "public");
+        assert_eq!(with_search_path(None).default_schema(), "public");
+    }
+
+    #[test]
+    fn test_target_label() {
+        let mut config = with_search_path(None);
+        config.name = "prod-replica".to_string();
+        assert_eq!(config.target_label(), "prod-replica ▸ postgres");
+        config.database = Some("shop".to_string());
+        assert_eq!(config.target_label(), "prod-replica ▸ shop");
+
+        config.database_type = DatabaseType::SQLite;
+        config.database = Some("/var/data/app.db".to_string());
+        assert_eq!(config.target_label(), "prod-replica ▸ app.db");
+    }
+
+    #[tokio::test]
+    async fn test_storage_round_trip() {
+        let dir = tempfile::tempdir().unwrap();
+        let path = dir.path().join("data").join("connections.json");
+        assert!(ConnectionStorage::load_from(&path)
+            .await
+            .unwrap()
+            .connections
+            .is_empty());
+
+        let mut storage = ConnectionStorage::default();
+        storage.connections.push(with_search_path(Some("app")));
+        storage.save_to(&path).await.unwrap();
+        storage.save_to(&path).await.unwrap();
+
+        let loaded = ConnectionStorage::load_from(&path).await.unwrap();
+        assert_eq!(loaded.connections.len(), 1);
+        assert_eq!(loaded.connections[0].id, storage.connections[0].id);
+        // Only the file itself is left behind
+        let files = std::fs::read_dir(path.parent().unwrap()).unwrap().count();
+        assert_eq!(files, 1);
+    }
+
+    #[tokio::test]
+    async fn test_unreadable_storage_set_aside() {
+        let dir = tempfile::tempdir().unwrap();
+        let path = dir.path().join("connections.json");
+        std::fs::write(&path, "connections = [ not toml").unwrap();
+        assert!(ConnectionStorage::load_from(&path).await.is_err());
+
+        let aside = ConnectionStorage::set_aside(&path).await.unwrap();
+        let name = aside.file_name().unwrap().to_string_lossy().into_owned();
+        assert!(name.starts_with("connections.json.") && name.ends_with(".unreadable"));
+        assert!(!path.exists());
+        assert!(aside.exists());
+
+        // A second unreadable file doesn't replace the first
+        std::fs::write(&path, "connections = [ still not toml").unwrap();
+        let second = ConnectionStorage::set_aside(&path).await.unwrap();
+        assert_ne!(second, aside);
+        assert_eq!(
+            std::fs::read_to_string(&aside).unwrap(),
+            "connections = [ not toml"
+        );
+    }
+
+    #[tokio::test]
+    async fn test_blocked_storage_keeps_the_file() {
+        let dir = tempfile::tempdir().unwrap();
+        let path = dir.path().join("connections.json");
+        std::fs::write(&path, "connections = [ not toml").unwrap();
+
+        let storage = ConnectionStorage {
+            save_blocked: Some("couldn't move it aside".to_string()),
+            ..Default::default()
+        };
+        assert!(storage.save_to(&path).await.is_err());
+        assert_eq!(
+            std::fs::read_to_string(&path).unwrap(),
+            "connections = [ not toml"
+        );
+    }
+}
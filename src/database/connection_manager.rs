@@ -3,11 +3,119 @@
 #![forbid(unsafe_code)]
 
 use crate::core::error::{LazyTablesError, Result};
+use crate::core::metrics;
+use crate::database::audit_log::{QueryAuditEntry, QueryAuditLog, QueryKind};
+use crate::database::cancel::QueryCanceller;
+use crate::database::result::{CappedCollector, QueryResult, ResultLimits};
+use crate::database::session_stats::SessionStats;
 use crate::database::{connection::Connection, ConnectionConfig};
 use std::collections::HashMap;
+use std::future::Future;
 use std::sync::Arc;
+use std::time::Duration;
 use tokio::sync::Mutex;
 
+/// Receiver for rows streamed from a query, one at a time
+pub trait RowSink: Send {
+    /// Called once with the column names before the first row
+    fn columns(&mut self, columns: &[String]) -> Result<()>;
+    /// Called for every row
+    fn row(&mut self, row: Vec<String>) -> Result<()>;
+    /// Called between result sets when a query returns more than one (MySQL
+    /// multi-statement queries and stored procedures); `columns` follows
+    fn next_result_set(&mut self) -> Result<()> {
+        Ok(())
+    }
+    /// Called after the rows with the notices and warnings the server sent
+    /// while running the query
+    fn notices(&mut self, _notices: Vec<crate::database::ServerNotice>) -> Result<()> {
+        Ok(())
+    }
+    /// Called after a statement that returned no rows with the number of
+    /// rows it inserted, updated or deleted
+    fn affected_rows(&mut self, _rows: u64) -> Result<()> {
+        Ok(())
+    }
+    /// Called after the last row read with the time from sending the query
+    /// until then, the wait for the connection left out
+    fn elapsed(&mut self, _elapsed: Duration) -> Result<()> {
+        Ok(())
+    }
+    /// Whether the sink wants no more rows; adapters then stop reading and
+    /// drop the stream, which closes the cursor
+    fn is_full(&self) -> bool {
+        false
+    }
+}
+
+/// Longest wait between two connection attempts
+const MAX_CONNECT_BACKOFF: Duration = Duration::from_secs(10);
+
+/// How the initial connection is retried, e.g. while the server restarts
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub struct ConnectRetry {
+    /// Attempts after the first one; 0 fails on the first error
+    pub retries: u32,
+    /// Wait before the first retry, doubled for every further one
+    pub backoff: Duration,
+}
+
+impl Default for ConnectRetry {
+    fn default() -> Self {
+        Self {
+            retries: 0,
+            backoff: Duration::from_millis(500),
+        }
+    }
+}
+
+impl ConnectRetry {
+    /// Total number of attempts, the first one included
+    pub fn attempts(&self) -> u32 {
+        self.retries.saturating_add(1)
+    }
+
+    /// Wait after the given number of failed attempts
+    pub fn delay(&self, failed_attempts: u32) -> Duration {
+        let factor = 2u32.saturating_pow(failed_attempts.saturating_sub(1));
+        self.backoff.saturating_mul(factor).min(MAX_CONNECT_BACKOFF)
+    }
+}
+
+/// Run a connection attempt until it succeeds, retrying the errors that may
+/// pass by themselves with backoff
+async fn retry_transient<Fut>(
+    retry: ConnectRetry,
+    name: &str,
+    mut attempt: impl FnMut() -> Fut,
+    mut on_retry: impl FnMut(u32, &LazyTablesError),
+) -> Result<()>
+where
+    Fut: Future<Output = Result<()>>,
+{
+    let mut failed_attempts = 0;
+    loop {
+        match attempt().await {
+            Ok(()) => return Ok(()),
+            Err(e) => {
+                failed_attempts += 1;
+                if failed_attempts >= retry.attempts() || !e.is_transient() {
+                    return Err(e);
+                }
+                crate::log_warn!(
+                    "Connection attempt {}/{} to '{}' failed: {}",
+                    failed_attempts,
+                    retry.attempts(),
+                    name,
+                    e
+                );
+                on_retry(failed_attempts + 1, &e);
+                tokio::time::sleep(retry.delay(failed_attempts)).await;
+            }
+        }
+    }
+}
+
 /// Type alias for the complex connection storage type
 type ConnectionStorage = Arc<Mutex<HashMap<String, Arc<Mutex<Box<dyn ManagedConnection>>>>>>;
 
@@ -19,25 +127,107 @@ type ConnectionStorage = Arc<Mutex<HashMap<String, Arc<Mutex<Box<dyn ManagedConn
 #[async_trait::async_trait]
 pub trait ManagedConnection: Send + Sync + std::fmt::Debug {
     async fn execute_raw_query(&self, query: &str) -> Result<(Vec<String>, Vec<Vec<String>>)>;
+    /// Execute a query, handing rows to the sink as they arrive instead of
+    /// collecting them. Returns the number of rows streamed
+    async fn stream_raw_query(&self, query: &str, sink: &mut dyn RowSink) -> Result<usize>;
+    /// Read a page of a table for its preview
     async fn get_table_data(
         &self,
         table_name: &str,
         limit: usize,
-        offset: usize,
+        paging: &crate::database::Paging,
+        filters: &[crate::database::ColumnFilter],
     ) -> Result<Vec<Vec<String>>>;
+    /// Count the rows of a table passing `filters`
+    async fn get_table_row_count(
+        &self,
+        table_name: &str,
+        filters: &[crate::database::ColumnFilter],
+    ) -> Result<usize>;
+    /// Insert a row, returning its columns and the inserted row where the
+    /// database can hand it back
+    async fn insert_row(
+        &self,
+        table_name: &str,
+        values: &[crate::database::InsertValue],
+    ) -> Result<(Vec<String>, Vec<Vec<String>>)>;
     async fn get_table_columns(
         &self,
         table_name: &str,
     ) -> Result<Vec<crate::database::TableColumn>>;
     async fn get_table_metadata(&self, table_name: &str) -> Result<crate::database::TableMetadata>;
-    async fn list_database_objects(&self) -> Result<crate::database::DatabaseObjectList>;
+    /// List tables, views and other objects; system schemas and tables only
+    /// when `include_system` is set
+    async fn list_database_objects(
+        &self,
+        include_system: bool,
+    ) -> Result<crate::database::DatabaseObjectList>;
+    /// Find columns whose name contains the pattern, across the database
+    async fn search_columns(&self, pattern: &str) -> Result<Vec<crate::database::ColumnMatch>>;
+    /// Partitions of a partitioned table, empty for other tables and for
+    /// databases without partitioning
+    async fn get_partitions(
+        &self,
+        _table_name: &str,
+    ) -> Result<Vec<crate::database::PartitionInfo>> {
+        Ok(Vec::new())
+    }
+    /// Indexes of a table with their columns in index order, the primary
+    /// key's first
+    async fn get_table_indexes(
+        &self,
+        _table_name: &str,
+    ) -> Result<Vec<crate::database::IndexInfo>> {
+        Ok(Vec::new())
+    }
+    /// Functions and procedures of a schema, empty for databases without
+    /// them
+    async fn list_routines(&self, _schema: &str) -> Result<Vec<crate::database::RoutineInfo>> {
+        Ok(Vec::new())
+    }
     fn is_connected(&self) -> bool;
+    /// Whether a transaction is open on this connection. Adapters that don't
+    /// keep a session open across statements never have one
+    fn in_transaction(&self) -> bool {
+        false
+    }
+    /// Roll back the open transaction, if any
+    async fn rollback(&self) -> Result<()> {
+        Ok(())
+    }
+    /// What cancels the statement running on this connection from outside
+    /// it; None where the server can't be asked to
+    fn query_canceller(&self) -> Option<QueryCanceller> {
+        None
+    }
+    /// Close the underlying pool, waiting for in-flight statements to finish
+    async fn close(&mut self) -> Result<()>;
 }
 
 #[derive(Debug, Clone)]
 pub struct ConnectionManager {
     /// Active connections keyed by connection ID
     connections: ConnectionStorage,
+    /// Connection name and database keyed by connection ID, for audit entries
+    targets: Arc<Mutex<HashMap<String, (String, Option<String>)>>>,
+    /// Cancellers of the statements running on connections keyed by
+    /// connection ID. Kept outside the connections, whose lock a running
+    /// statement holds
+    cancellers: Arc<std::sync::Mutex<HashMap<String, QueryCanceller>>>,
+    /// Optional audit log of executed statements
+    audit_log: Option<Arc<QueryAuditLog>>,
+    /// Whether PostgreSQL connections run statements of an open transaction
+    /// inside a savepoint
+    statement_savepoints: bool,
+    /// Characters of each cell table previews read; 0 reads whole values
+    preview_cell_chars: usize,
+    /// Statement timeout in seconds for connections setting none
+    statement_timeout_secs: u64,
+    /// Queries, rows and time connected per connection this session
+    session_stats: SessionStats,
+    /// Whether every statement is logged with its duration and the feature
+    /// that issued it
+    trace_sql: bool,
 }
 
 impl ConnectionManager {
@@ -45,23 +235,155 @@ impl ConnectionManager {
     pub fn new() -> Self {
         Self {
             connections: Arc::new(Mutex::new(HashMap::new())),
+            targets: Arc::new(Mutex::new(HashMap::new())),
+            cancellers: Arc::new(std::sync::Mutex::new(HashMap::new())),
+            audit_log: None,
+            statement_savepoints: true,
+            preview_cell_chars: crate::database::DEFAULT_PREVIEW_CELL_CHARS,
+            statement_timeout_secs: 0,
+            session_stats: SessionStats::default(),
+            trace_sql: false,
+        }
+    }
+
+    /// Record every executed statement in the given audit log
+    pub fn set_audit_log(&mut self, audit_log: QueryAuditLog) {
+        self.audit_log = Some(Arc::new(audit_log));
+    }
+
+    /// Log every statement run from now on, the panels' own included, with
+    /// its duration and the feature that issued it
+    pub fn set_trace_sql(&mut self, enabled: bool) {
+        self.trace_sql = enabled;
+    }
+
+    /// Turn statement savepoints in PostgreSQL transactions on or off for
+    /// connections made from now on
+    pub fn set_statement_savepoints(&mut self, enabled: bool) {
+        self.statement_savepoints = enabled;
+    }
+
+    /// Set how many characters of each cell table previews read, for
+    /// connections made from now on
+    pub fn set_preview_cell_chars(&mut self, max_chars: usize) {
+        self.preview_cell_chars = max_chars;
+    }
+
+    /// Set the statement timeout, in seconds, of connections made from now
+    /// on that don't set their own
+    pub fn set_statement_timeout(&mut self, seconds: u64) {
+        self.statement_timeout_secs = seconds;
+    }
+
+    /// Counters of the session's connections
+    pub fn session_stats(&self) -> &SessionStats {
+        &self.session_stats
+    }
+
+    /// Name and database of a connection, for audit entries and the trace
+    async fn target(&self, connection_id: &str) -> (String, Option<String>) {
+        self.targets
+            .lock()
+            .await
+            .get(connection_id)
+            .cloned()
+            .unwrap_or_else(|| (connection_id.to_string(), None))
+    }
+
+    /// Run an operation, counting it in the session stats, tracing it when
+    /// `--trace-sql` is on and recording it in the audit log when one is
+    /// configured. `feature` names what issued it, e.g. `tables_panel`
+    async fn audited<T, F>(
+        &self,
+        connection_id: &str,
+        kind: QueryKind,
+        feature: &'static str,
+        query: &str,
+        row_count: impl Fn(&T) -> usize,
+        operation: F,
+    ) -> Result<T>
+    where
+        F: Future<Output = Result<T>>,
+    {
+        let timestamp = chrono::Utc::now();
+        let start = std::time::Instant::now();
+        let result = operation.await;
+        let duration_ms = start.elapsed().as_millis() as u64;
+        metrics::observe(
+            match kind {
+                QueryKind::Statement => metrics::Timing::Query,
+                QueryKind::Metadata => metrics::Timing::Metadata,
+            },
+            start.elapsed(),
+        );
+        self.session_stats
+            .record_query(connection_id, result.as_ref().ok().map(&row_count));
+
+        if self.trace_sql {
+            let (connection, _) = self.target(connection_id).await;
+            let outcome = match &result {
+                Ok(value) => format!("{} rows", row_count(value)),
+                Err(e) => format!("failed: {e}"),
+            };
+            tracing::info!(
+                target: crate::logging::SQL_TRACE_TARGET,
+                "[{}] {} {}ms, {}: {}",
+                feature,
+                connection,
+                duration_ms,
+                outcome,
+                query.split_whitespace().collect::<Vec<_>>().join(" ")
+            );
         }
+
+        let Some(audit_log) = self.audit_log.as_ref().filter(|log| log.records(kind)) else {
+            return result;
+        };
+
+        let (connection, database) = self.target(connection_id).await;
+
+        audit_log.record(&QueryAuditEntry {
+            timestamp,
+            connection,
+            database,
+            kind,
+            query: query.to_string(),
+            duration_ms,
+            rows: result.as_ref().ok().map(&row_count),
+            success: result.is_ok(),
+            error: result.as_ref().err().map(|e| e.to_string()),
+        });
+
+        result
+    }
+
+    /// Establish a persistent connection, retrying with exponential backoff
+    /// while it fails for a reason that may pass, such as the server
+    /// restarting. `on_retry` is called with the number of the attempt about
+    /// to start and the error that failed the previous one. Wrong
+    /// credentials and other settings errors fail at once; otherwise the last
+    /// attempt's error is returned when every attempt fails
+    pub async fn connect_with_retry(
+        &self,
+        config: &ConnectionConfig,
+        retry: ConnectRetry,
+        on_retry: impl FnMut(u32, &LazyTablesError),
+    ) -> Result<()> {
+        retry_transient(retry, &config.name, || self.connect(config), on_retry).await
     }
 
     /// Establish a persistent connection to a database
     /// This replaces the problematic pattern of creating/destroying connections per operation
     pub async fn connect(&self, config: &ConnectionConfig) -> Result<()> {
-        let mut connections = self.connections.lock().await;
-
-        // Check if we already have an active connection
-        if let Some(existing_conn) = connections.get(&config.id) {
-            let conn = existing_conn.lock().await;
-            if conn.is_connected() {
+        // Check if we already have an active connection. The map isn't held
+        // while waiting on the connection, which a running query may hold
+        let existing = self.connections.lock().await.get(&config.id).cloned();
+        if let Some(existing_conn) = existing {
+            if existing_conn.lock().await.is_connected() {
                 return Ok(()); // Already connected
             }
             // Remove stale connection
-            drop(conn);
-            connections.remove(&config.id);
+            self.forget(&config.id, &existing_conn).await;
         }
 
         // Create new connection based on database type
@@ -69,12 +391,25 @@ impl ConnectionManager {
             crate::database::DatabaseType::PostgreSQL => {
                 let mut pg_conn =
                     crate::database::postgres::PostgresConnection::new(config.clone());
+                pg_conn.set_statement_savepoints(self.statement_savepoints);
+                pg_conn.set_preview_cell_chars(self.preview_cell_chars);
+                pg_conn.set_statement_timeout(
+                    config
+                        .statement_timeout
+                        .unwrap_or(self.statement_timeout_secs),
+                );
                 // Establish the connection
                 Connection::connect(&mut pg_conn).await?;
                 Box::new(pg_conn)
             }
             crate::database::DatabaseType::MySQL | crate::database::DatabaseType::MariaDB => {
                 let mut mysql_conn = crate::database::mysql::MySqlConnection::new(config.clone());
+                mysql_conn.set_preview_cell_chars(self.preview_cell_chars);
+                mysql_conn.set_statement_timeout(
+                    config
+                        .statement_timeout
+                        .unwrap_or(self.statement_timeout_secs),
+                );
                 // Establish the connection
                 Connection::connect(&mut mysql_conn).await?;
                 Box::new(mysql_conn)
@@ -82,6 +417,7 @@ impl ConnectionManager {
             crate::database::DatabaseType::SQLite => {
                 let mut sqlite_conn =
                     crate::database::sqlite::SqliteConnection::new(config.clone());
+                sqlite_conn.set_preview_cell_chars(self.preview_cell_chars);
                 // Establish the connection
                 Connection::connect(&mut sqlite_conn).await?;
                 Box::new(sqlite_conn)
@@ -94,17 +430,100 @@ impl ConnectionManager {
             }
         };
 
-        // Store the connected instance
-        tracing::debug!("Storing connection with ID: '{}'", config.id);
-        connections.insert(config.id.clone(), Arc::new(Mutex::new(connection)));
-        tracing::debug!(
-            "Connection manager now has {} connections",
-            connections.len()
+        self.targets.lock().await.insert(
+            config.id.clone(),
+            (config.name.clone(), config.database.clone()),
         );
 
+        self.session_stats
+            .record_connected(&config.id, &config.name);
+        self.set_canceller(&config.id, connection.query_canceller());
+
+        // Store the connected instance. The map was free while connecting,
+        // so a connection made meanwhile for the same ID is replaced and
+        // closed
+        tracing::debug!("Storing connection with ID: '{}'", config.id);
+        let replaced = {
+            let mut connections = self.connections.lock().await;
+            let replaced = connections.insert(config.id.clone(), Arc::new(Mutex::new(connection)));
+            tracing::debug!(
+                "Connection manager now has {} connections",
+                connections.len()
+            );
+            replaced
+        };
+        if let Some(replaced) = replaced {
+            if let Err(e) = replaced.lock().await.close().await {
+                tracing::warn!("Failed to close replaced connection '{}': {}", config.id, e);
+            }
+        }
+
         Ok(())
     }
 
+    /// Drop the entry of a connection if it's still this one
+    async fn forget(
+        &self,
+        connection_id: &str,
+        connection_ref: &Arc<Mutex<Box<dyn ManagedConnection>>>,
+    ) {
+        let mut connections = self.connections.lock().await;
+        if connections
+            .get(connection_id)
+            .is_some_and(|current| Arc::ptr_eq(current, connection_ref))
+        {
+            connections.remove(connection_id);
+            self.set_canceller(connection_id, None);
+        }
+    }
+
+    fn set_canceller(&self, connection_id: &str, canceller: Option<QueryCanceller>) {
+        if let Ok(mut cancellers) = self.cancellers.lock() {
+            match canceller {
+                Some(canceller) => cancellers.insert(connection_id.to_string(), canceller),
+                None => cancellers.remove(connection_id),
+            };
+        }
+    }
+
+    /// Whether the statement running on a connection can be cancelled
+    pub fn can_cancel(&self, connection_id: &str) -> bool {
+        self.cancellers
+            .lock()
+            .is_ok_and(|cancellers| cancellers.contains_key(connection_id))
+    }
+
+    /// Ask the server to cancel the statement running on a connection, which
+    /// then fails with a cancellation error. Doesn't wait for the connection,
+    /// which the statement keeps locked. False when nothing could be asked
+    pub async fn cancel_query(&self, connection_id: &str) -> Result<bool> {
+        let canceller = self
+            .cancellers
+            .lock()
+            .ok()
+            .and_then(|cancellers| cancellers.get(connection_id).cloned());
+        match canceller {
+            Some(canceller) => canceller.cancel().await,
+            None => Ok(false),
+        }
+    }
+
+    /// Lock an open connection for one operation. The connection may have
+    /// been closed while waiting for the lock, e.g. by a disconnect queued
+    /// ahead; it's then refused rather than used with a closed pool
+    async fn lock_open(
+        &self,
+        connection_id: &str,
+    ) -> Result<tokio::sync::OwnedMutexGuard<Box<dyn ManagedConnection>>> {
+        let connection = self.get_connection(connection_id).await?.lock_owned().await;
+        if !connection.is_connected() {
+            return Err(LazyTablesError::Connection(format!(
+                "Connection '{connection_id}' was closed"
+            )));
+        }
+        Ok(connection)
+    }
+
     /// Get a reference to an active connection
     pub async fn get_connection(
         &self,
@@ -129,36 +548,70 @@ impl ConnectionManager {
     }
 
     /// Disconnect from a specific database
+    /// The pool is closed once a statement running on it finishes; other
+    /// connections stay usable meanwhile
     pub async fn disconnect(&self, connection_id: &str) -> Result<()> {
-        let mut connections = self.connections.lock().await;
+        let removed = self.connections.lock().await.remove(connection_id);
 
-        if let Some(_connection_ref) = connections.remove(connection_id) {
-            // The connection will be dropped automatically when removed from the map
-            // Individual connection cleanup happens in the Drop trait
+        self.set_canceller(connection_id, None);
+        if let Some(connection_ref) = removed {
+            self.session_stats.record_disconnected(connection_id);
+            connection_ref.lock().await.close().await?;
         }
 
         Ok(())
     }
 
-    /// Disconnect from all databases
+    /// Disconnect from all databases, closing every pool. Close failures are
+    /// logged so one bad connection doesn't keep the others open
     pub async fn disconnect_all(&self) -> Result<()> {
-        let mut connections = self.connections.lock().await;
+        let drained: Vec<_> = self.connections.lock().await.drain().collect();
+        if let Ok(mut cancellers) = self.cancellers.lock() {
+            cancellers.clear();
+        }
 
-        // Simply clear all connections - they will be automatically dropped
-        connections.clear();
+        for (connection_id, connection_ref) in drained {
+            self.session_stats.record_disconnected(&connection_id);
+            if let Err(e) = connection_ref.lock().await.close().await {
+                tracing::warn!("Failed to close connection '{}': {}", connection_id, e);
+            }
+        }
 
         Ok(())
     }
 
+    /// Roll back every open transaction, logging each one. Called on shutdown
+    /// so quitting never commits or leaves behind a half-applied change
+    pub async fn rollback_open_transactions(&self) {
+        let connections: Vec<_> = self
+            .connections
+            .lock()
+            .await
+            .iter()
+            .map(|(connection_id, connection_ref)| (connection_id.clone(), connection_ref.clone()))
+            .collect();
+
+        for (connection_id, connection_ref) in connections {
+            let connection = connection_ref.lock().await;
+            if !connection.in_transaction() {
+                continue;
+            }
+
+            let (name, _) = self.target(&connection_id).await;
+            tracing::warn!("Rolling back open transaction on '{}' at shutdown", name);
+            if let Err(e) = connection.rollback().await {
+                tracing::error!("Failed to roll back transaction on '{}': {}", name, e);
+            }
+        }
+    }
+
     /// Check if a connection is active and healthy
     pub async fn is_connected(&self, connection_id: &str) -> bool {
-        let connections = self.connections.lock().await;
+        let connection_ref = self.connections.lock().await.get(connection_id).cloned();
 
-        if let Some(connection_ref) = connections.get(connection_id) {
-            let connection = connection_ref.lock().await;
-            connection.is_connected()
-        } else {
-            false
+        match connection_ref {
+            Some(connection_ref) => connection_ref.lock().await.is_connected(),
+            None => false,
         }
     }
 
@@ -168,22 +621,189 @@ impl ConnectionManager {
         connection_id: &str,
         query: &str,
     ) -> Result<(Vec<String>, Vec<Vec<String>>)> {
-        let connection_ref = self.get_connection(connection_id).await?;
-        let connection = connection_ref.lock().await;
-        connection.execute_raw_query(query).await
+        self.execute_query_as(connection_id, query, QueryKind::Statement, "user")
+            .await
+    }
+
+    /// Execute a query the panels issue on their own (row counts and similar),
+    /// which the audit log only records in verbose mode. `feature` names
+    /// what issued it in the SQL trace
+    pub async fn execute_metadata_query(
+        &self,
+        connection_id: &str,
+        feature: &'static str,
+        query: &str,
+    ) -> Result<(Vec<String>, Vec<Vec<String>>)> {
+        self.execute_query_as(connection_id, query, QueryKind::Metadata, feature)
+            .await
+    }
+
+    async fn execute_query_as(
+        &self,
+        connection_id: &str,
+        query: &str,
+        kind: QueryKind,
+        feature: &'static str,
+    ) -> Result<(Vec<String>, Vec<Vec<String>>)> {
+        let connection = self.lock_open(connection_id).await?;
+        self.audited(
+            connection_id,
+            kind,
+            feature,
+            query,
+            |(_, rows)| rows.len(),
+            connection.execute_raw_query(query),
+        )
+        .await
+    }
+
+    /// Execute a query, streaming rows into the sink without holding the
+    /// whole result in memory
+    pub async fn stream_raw_query(
+        &self,
+        connection_id: &str,
+        query: &str,
+        sink: &mut dyn RowSink,
+    ) -> Result<usize> {
+        let connection = self.lock_open(connection_id).await?;
+        self.audited(
+            connection_id,
+            QueryKind::Statement,
+            "user",
+            query,
+            |rows| *rows,
+            connection.stream_raw_query(query, sink),
+        )
+        .await
     }
 
-    /// Get table data using the persistent connection
+    /// Run a statement from the query editor like `stream_raw_query`, into a
+    /// sink made by `new_sink`. A read-only statement that fails outside a
+    /// transaction because the server connection was lost (server restart,
+    /// connection reset) gets the connection opened again from `config` and
+    /// runs once more, into a fresh sink. Writes never run twice. Returns the
+    /// sink and whether the connection was re-established
+    pub async fn stream_query_reconnecting<S: RowSink>(
+        &self,
+        config: &ConnectionConfig,
+        query: &str,
+        mut new_sink: impl FnMut() -> S,
+    ) -> (Result<S>, bool) {
+        let in_transaction = match self.get_connection(&config.id).await {
+            Ok(connection) => connection.lock().await.in_transaction(),
+            Err(_) => false,
+        };
+        let mut sink = new_sink();
+        let error = match self.stream_raw_query(&config.id, query, &mut sink).await {
+            Ok(_) => return (Ok(sink), false),
+            Err(e) => e,
+        };
+        if in_transaction
+            || !error.is_connection_lost()
+            || !crate::database::transaction::is_read_only(query)
+        {
+            return (Err(error), false);
+        }
+
+        crate::log_warn!(
+            "Connection to '{}' lost ({}), reconnecting to run the query again",
+            config.name,
+            error
+        );
+        // Closing the pool drops what's left of it; connecting re-applies
+        // the session settings
+        if let Err(e) = self.disconnect(&config.id).await {
+            crate::log_debug!("Closing the lost connection failed: {}", e);
+        }
+        if let Err(e) = self.connect(config).await {
+            crate::log_warn!("Reconnecting to '{}' failed: {}", config.name, e);
+            return (Err(error), false);
+        }
+        let mut sink = new_sink();
+        let result = self
+            .stream_raw_query(&config.id, query, &mut sink)
+            .await
+            .map(|_| sink);
+        (result, true)
+    }
+
+    /// Execute a statement from the query editor, collecting the result
+    /// within the given row and cell size limits
+    pub async fn execute_limited_query(
+        &self,
+        connection_id: &str,
+        query: &str,
+        limits: ResultLimits,
+    ) -> Result<QueryResult> {
+        let mut collector = CappedCollector::new(limits);
+        self.stream_raw_query(connection_id, query, &mut collector)
+            .await?;
+        Ok(collector.finish())
+    }
+
+    /// Get a page of table data using the persistent connection
     pub async fn get_table_data(
         &self,
         connection_id: &str,
         table_name: &str,
         limit: usize,
-        offset: usize,
+        paging: &crate::database::Paging,
+        filters: &[crate::database::ColumnFilter],
     ) -> Result<Vec<Vec<String>>> {
-        let connection_ref = self.get_connection(connection_id).await?;
-        let connection = connection_ref.lock().await;
-        connection.get_table_data(table_name, limit, offset).await
+        let connection = self.lock_open(connection_id).await?;
+        self.audited(
+            connection_id,
+            QueryKind::Metadata,
+            "table_viewer",
+            &format!(
+                "-- table data: {table_name}{} LIMIT {limit} {paging}",
+                filters_comment(filters)
+            ),
+            |rows| rows.len(),
+            connection.get_table_data(table_name, limit, paging, filters),
+        )
+        .await
+    }
+
+    /// Count the rows of a table passing `filters` using the persistent
+    /// connection
+    pub async fn get_table_row_count(
+        &self,
+        connection_id: &str,
+        table_name: &str,
+        filters: &[crate::database::ColumnFilter],
+    ) -> Result<usize> {
+        let connection = self.lock_open(connection_id).await?;
+        self.audited(
+            connection_id,
+            QueryKind::Metadata,
+            "table_viewer",
+            &format!("-- row count: {table_name}{}", filters_comment(filters)),
+            |_| 1,
+            connection.get_table_row_count(table_name, filters),
+        )
+        .await
+    }
+
+    /// Insert a row from the insert-row form using the persistent
+    /// connection
+    pub async fn insert_row(
+        &self,
+        connection_id: &str,
+        table_name: &str,
+        values: &[crate::database::InsertValue],
+    ) -> Result<(Vec<String>, Vec<Vec<String>>)> {
+        let connection = self.lock_open(connection_id).await?;
+        let columns: Vec<&str> = values.iter().map(|value| value.column.as_str()).collect();
+        self.audited(
+            connection_id,
+            QueryKind::Statement,
+            "insert_row",
+            &format!("-- insert row: {table_name} ({})", columns.join(", ")),
+            |_| 1,
+            connection.insert_row(table_name, values),
+        )
+        .await
     }
 
     /// Get table columns using the persistent connection
@@ -192,9 +812,16 @@ impl ConnectionManager {
         connection_id: &str,
         table_name: &str,
     ) -> Result<Vec<crate::database::TableColumn>> {
-        let connection_ref = self.get_connection(connection_id).await?;
-        let connection = connection_ref.lock().await;
-        connection.get_table_columns(table_name).await
+        let connection = self.lock_open(connection_id).await?;
+        self.audited(
+            connection_id,
+            QueryKind::Metadata,
+            "columns",
+            &format!("-- table columns: {table_name}"),
+            |columns| columns.len(),
+            connection.get_table_columns(table_name),
+        )
+        .await
     }
 
     /// Get table metadata using the persistent connection
@@ -203,24 +830,116 @@ impl ConnectionManager {
         connection_id: &str,
         table_name: &str,
     ) -> Result<crate::database::TableMetadata> {
-        let connection_ref = self.get_connection(connection_id).await?;
-        let connection = connection_ref.lock().await;
-        connection.get_table_metadata(table_name).await
+        let connection = self.lock_open(connection_id).await?;
+        self.audited(
+            connection_id,
+            QueryKind::Metadata,
+            "details",
+            &format!("-- table metadata: {table_name}"),
+            |_| 1,
+            connection.get_table_metadata(table_name),
+        )
+        .await
+    }
+
+    /// Get the indexes of a table using the persistent connection
+    pub async fn get_table_indexes(
+        &self,
+        connection_id: &str,
+        table_name: &str,
+    ) -> Result<Vec<crate::database::IndexInfo>> {
+        let connection = self.lock_open(connection_id).await?;
+        self.audited(
+            connection_id,
+            QueryKind::Metadata,
+            "tables_panel",
+            &format!("-- table indexes: {table_name}"),
+            |indexes| indexes.len(),
+            connection.get_table_indexes(table_name),
+        )
+        .await
+    }
+
+    /// Find columns whose name contains the pattern using the persistent
+    /// connection
+    pub async fn search_columns(
+        &self,
+        connection_id: &str,
+        pattern: &str,
+    ) -> Result<Vec<crate::database::ColumnMatch>> {
+        let connection = self.lock_open(connection_id).await?;
+        self.audited(
+            connection_id,
+            QueryKind::Metadata,
+            "column_search",
+            &format!("-- search columns: {pattern}"),
+            |matches| matches.len(),
+            connection.search_columns(pattern),
+        )
+        .await
+    }
+
+    /// Get the partitions of a table using the persistent connection
+    pub async fn get_partitions(
+        &self,
+        connection_id: &str,
+        table_name: &str,
+    ) -> Result<Vec<crate::database::PartitionInfo>> {
+        let connection = self.lock_open(connection_id).await?;
+        self.audited(
+            connection_id,
+            QueryKind::Metadata,
+            "details",
+            &format!("-- table partitions: {table_name}"),
+            |partitions| partitions.len(),
+            connection.get_partitions(table_name),
+        )
+        .await
+    }
+
+    /// List the functions and procedures of a schema using the persistent
+    /// connection
+    pub async fn list_routines(
+        &self,
+        connection_id: &str,
+        schema: &str,
+    ) -> Result<Vec<crate::database::RoutineInfo>> {
+        let connection = self.lock_open(connection_id).await?;
+        self.audited(
+            connection_id,
+            QueryKind::Metadata,
+            "object_tree",
+            &format!("-- routines: {schema}"),
+            |routines| routines.len(),
+            connection.list_routines(schema),
+        )
+        .await
     }
 
     /// List database objects using the persistent connection
     pub async fn list_database_objects(
         &self,
         connection_id: &str,
+        include_system: bool,
     ) -> Result<crate::database::DatabaseObjectList> {
-        let connection_ref = self.get_connection(connection_id).await?;
-        let connection = connection_ref.lock().await;
-        connection.list_database_objects().await
+        let connection = self.lock_open(connection_id).await?;
+        self.audited(
+            connection_id,
+            QueryKind::Metadata,
+            "tables_panel",
+            "-- list database objects",
+            |objects| objects.total_count,
+            connection.list_database_objects(include_system),
+        )
+        .await
     }
 
     /// Check if a connection is healthy by trying to execute a simple query
     pub async fn health_check(&self, connection_id: &str) -> Result<bool> {
-        match self.execute_raw_query(connection_id, "SELECT 1").await {
+        match self
+            .execute_metadata_query(connection_id, "health_check", "SELECT 1")
+            .await
+        {
             Ok(_) => Ok(true),
             Err(_) => Ok(false),
         }
@@ -232,3 +951,355 @@ impl Default for ConnectionManager {
         Self::new()
     }
 }
+
+/// ` WHERE a = 'x' AND b IS NULL` describing preview filters in the audit
+/// log; empty without filters
+fn filters_comment(filters: &[crate::database::ColumnFilter]) -> String {
+    if filters.is_empty() {
+        return String::new();
+    }
+    let conditions: Vec<String> = filters.iter().map(ToString::to_string).collect();
+    format!(" WHERE {}", conditions.join(" AND "))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::sync::Mutex as StdMutex;
+
+    /// Adapter that records the lifecycle calls made on it
+    #[derive(Debug)]
+    struct RecordingConnection {
+        calls: Arc<StdMutex<Vec<&'static str>>>,
+        in_transaction: bool,
+        closed: bool,
+    }
+
+    #[async_trait::async_trait]
+    impl ManagedConnection for RecordingConnection {
+        async fn execute_raw_query(&self, _query: &str) -> Result<(Vec<String>, Vec<Vec<String>>)> {
+            Ok((Vec::new(), Vec::new()))
+        }
+        async fn stream_raw_query(&self, _query: &str, _sink: &mut dyn RowSink) -> Result<usize> {
+            Ok(0)
+        }
+        async fn get_table_data(
+            &self,
+            _table_name: &str,
+            _limit: usize,
+            _paging: &crate::database::Paging,
+            _filters: &[crate::database::ColumnFilter],
+        ) -> Result<Vec<Vec<String>>> {
+            Ok(Vec::new())
+        }
+        async fn get_table_row_count(
+            &self,
+            _table_name: &str,
+            _filters: &[crate::database::ColumnFilter],
+        ) -> Result<usize> {
+            Ok(0)
+        }
+        async fn insert_row(
+            &self,
+            _table_name: &str,
+            _values: &[crate::database::InsertValue],
+        ) -> Result<(Vec<String>, Vec<Vec<String>>)> {
+            Ok((Vec::new(), Vec::new()))
+        }
+        async fn get_table_columns(
+            &self,
+            _table_name: &str,
+        ) -> Result<Vec<crate::database::TableColumn>> {
+            Ok(Vec::new())
+        }
+        async fn get_table_metadata(
+            &self,
+            _table_name: &str,
+        ) -> Result<crate::database::TableMetadata> {
+            Err(LazyTablesError::Other("not supported".to_string()))
+        }
+        async fn list_database_objects(
+            &self,
+            _include_system: bool,
+        ) -> Result<crate::database::DatabaseObjectList> {
+            Ok(crate::database::DatabaseObjectList::default())
+        }
+        async fn search_columns(
+            &self,
+            _pattern: &str,
+        ) -> Result<Vec<crate::database::ColumnMatch>> {
+            Ok(Vec::new())
+        }
+        fn is_connected(&self) -> bool {
+            !self.closed
+        }
+        fn in_transaction(&self) -> bool {
+            self.in_transaction
+        }
+        async fn rollback(&self) -> Result<()> {
+            self.calls.lock().unwrap().push("rollback");
+            Ok(())
+        }
+        async fn close(&mut self) -> Result<()> {
+            self.calls.lock().unwrap().push("close");
+            self.closed = true;
+            Ok(())
+        }
+    }
+
+    async fn manager_with(
+        connections: &[(&str, bool)],
+    ) -> (ConnectionManager, Arc<StdMutex<Vec<&'static str>>>) {
+        let manager = ConnectionManager::new();
+        let calls = Arc::new(StdMutex::new(Vec::new()));
+        for (id, in_transaction) in connections {
+            let connection: Box<dyn ManagedConnection> = Box::new(RecordingConnection {
+                calls: calls.clone(),
+                in_transaction: *in_transaction,
+                closed: false,
+            });
+            manager
+                .connections
+                .lock()
+                .await
+                .insert(id.to_string(), Arc::new(Mutex::new(connection)));
+        }
+        (manager, calls)
+    }
+
+    #[tokio::test]
+    async fn test_shutdown_rolls_back_before_closing() {
+        let (manager, calls) = manager_with(&[("pg", true)]).await;
+
+        manager.rollback_open_transactions().await;
+        manager.disconnect_all().await.unwrap();
+
+        assert_eq!(*calls.lock().unwrap(), vec!["rollback", "close"]);
+        assert!(!manager.is_connected("pg").await);
+    }
+
+    #[tokio::test]
+    async fn test_shutdown_only_rolls_back_open_transactions() {
+        let (manager, calls) = manager_with(&[("a", false), ("b", false)]).await;
+
+        manager.rollback_open_transactions().await;
+        manager.disconnect_all().await.unwrap();
+
+        assert_eq!(*calls.lock().unwrap(), vec!["close", "close"]);
+    }
+
+    #[tokio::test]
+    async fn test_disconnect_waiting_on_a_query_leaves_other_connections_usable() {
+        let (manager, calls) = manager_with(&[("busy", false), ("idle", false)]).await;
+        // A statement running on "busy" holds its connection
+        let running = manager
+            .get_connection("busy")
+            .await
+            .unwrap()
+            .lock_owned()
+            .await;
+        let disconnecting = tokio::spawn({
+            let manager = manager.clone();
+            async move { manager.disconnect("busy").await }
+        });
+        tokio::task::yield_now().await;
+
+        let idle = tokio::time::timeout(Duration::from_secs(1), async {
+            manager.execute_raw_query("idle", "SELECT 1").await?;
+            manager.disconnect("idle").await
+        })
+        .await;
+        assert!(matches!(idle, Ok(Ok(()))));
+        assert!(!disconnecting.is_finished());
+
+        drop(running);
+        disconnecting.await.unwrap().unwrap();
+        assert_eq!(*calls.lock().unwrap(), vec!["close", "close"]);
+    }
+
+    #[tokio::test]
+    async fn test_connection_closed_while_waiting_is_not_used() {
+        let (manager, _) = manager_with(&[("pg", false)]).await;
+        let mut running = manager
+            .get_connection("pg")
+            .await
+            .unwrap()
+            .lock_owned()
+            .await;
+        let query = tokio::spawn({
+            let manager = manager.clone();
+            async move { manager.execute_raw_query("pg", "SELECT 1").await }
+        });
+        tokio::task::yield_now().await;
+
+        // Closed under the waiting query, as a disconnect queued ahead of it
+        // would
+        running.close().await.unwrap();
+        drop(running);
+
+        let error = query.await.unwrap().unwrap_err();
+        assert!(error.to_string().contains("'pg' was closed"));
+    }
+
+    #[test]
+    fn test_connect_backoff_doubles_up_to_the_cap() {
+        let retry = ConnectRetry {
+            retries: 4,
+            backoff: Duration::from_millis(500),
+        };
+        assert_eq!(retry.attempts(), 5);
+        assert_eq!(retry.delay(1), Duration::from_millis(500));
+        assert_eq!(retry.delay(2), Duration::from_secs(1));
+        assert_eq!(retry.delay(3), Duration::from_secs(2));
+        assert_eq!(retry.delay(6), MAX_CONNECT_BACKOFF);
+        assert_eq!(retry.delay(40), MAX_CONNECT_BACKOFF);
+    }
+
+    /// Error a server answers a connection attempt with
+    #[derive(Debug, thiserror::Error)]
+    #[error("{message}")]
+    struct ServerError {
+        code: &'static str,
+        message: &'static str,
+    }
+
+    impl sqlx::error::DatabaseError for ServerError {
+        fn message(&self) -> &str {
+            self.message
+        }
+
+        fn code(&self) -> Option<std::borrow::Cow<'_, str>> {
+            Some(self.code.into())
+        }
+
+        fn as_error(&self) -> &(dyn std::error::Error + Send + Sync + 'static) {
+            self
+        }
+
+        fn as_error_mut(&mut self) -> &mut (dyn std::error::Error + Send + Sync + 'static) {
+            self
+        }
+
+        fn into_error(self: Box<Self>) -> Box<dyn std::error::Error + Send + Sync + 'static> {
+            self
+        }
+
+        fn kind(&self) -> sqlx::error::ErrorKind {
+            sqlx::error::ErrorKind::Other
+        }
+    }
+
+    fn connect_error(code: &'static str, message: &'static str) -> LazyTablesError {
+        LazyTablesError::Connect {
+            database: "PostgreSQL",
+            source: sqlx::Error::Database(Box::new(ServerError { code, message })),
+        }
+    }
+
+    fn refused() -> LazyTablesError {
+        LazyTablesError::Connect {
+            database: "PostgreSQL",
+            source: sqlx::Error::Io(std::io::ErrorKind::ConnectionRefused.into()),
+        }
+    }
+
+    /// Run the retry loop over errors failing the attempts in turn, then
+    /// success; returns the attempts retried and the result
+    async fn retry_over(errors: Vec<LazyTablesError>) -> (Vec<u32>, Result<()>) {
+        let retry = ConnectRetry {
+            retries: 2,
+            backoff: Duration::from_millis(1),
+        };
+        let mut errors = errors.into_iter();
+        let mut attempts = Vec::new();
+        let result = retry_transient(
+            retry,
+            "shop",
+            || {
+                let result = errors.next().map_or(Ok(()), Err);
+                async move { result }
+            },
+            |attempt, _| attempts.push(attempt),
+        )
+        .await;
+        (attempts, result)
+    }
+
+    #[tokio::test]
+    async fn test_connect_with_retry_reports_attempts_and_last_error() {
+        let (attempts, result) = retry_over(vec![refused(), refused(), refused()]).await;
+        assert_eq!(attempts, vec![2, 3]);
+        assert!(result.unwrap_err().to_string().contains("refused"));
+
+        // A server still starting up comes good
+        let (attempts, result) = retry_over(vec![connect_error(
+            "57P03",
+            "the database system is starting up",
+        )])
+        .await;
+        assert_eq!(attempts, vec![2]);
+        assert!(result.is_ok());
+    }
+
+    #[tokio::test]
+    async fn test_connect_fails_fast_on_wrong_password() {
+        let (attempts, result) = retry_over(vec![connect_error(
+            "28P01",
+            "password authentication failed for user \"app\"",
+        )])
+        .await;
+        assert!(attempts.is_empty());
+        assert!(result.unwrap_err().to_string().contains("password"));
+
+        // MySQL's access denied
+        let (attempts, _) = retry_over(vec![connect_error(
+            "28000",
+            "Access denied for user 'app'@'localhost'",
+        )])
+        .await;
+        assert!(attempts.is_empty());
+    }
+
+    #[tokio::test]
+    async fn test_connect_fails_fast_on_unknown_database() {
+        let (attempts, result) = retry_over(vec![connect_error(
+            "3D000",
+            "database \"shop\" does not exist",
+        )])
+        .await;
+        assert!(attempts.is_empty());
+        assert!(result.unwrap_err().to_string().contains("does not exist"));
+
+        // MySQL's unknown database
+        let (attempts, _) =
+            retry_over(vec![connect_error("42000", "Unknown database 'shop'")]).await;
+        assert!(attempts.is_empty());
+    }
+
+    #[tokio::test]
+    async fn test_connect_fails_fast_on_unsupported_database() {
+        let manager = ConnectionManager::new();
+        let config = ConnectionConfig::new(
+            "cache".to_string(),
+            crate::database::DatabaseType::Redis,
+            "localhost".to_string(),
+            6379,
+            String::new(),
+        );
+        let retry = ConnectRetry {
+            retries: 2,
+            backoff: Duration::from_millis(1),
+        };
+
+        let mut attempts = Vec::new();
+        let result = manager
+            .connect_with_retry(&config, retry, |attempt, _| attempts.push(attempt))
+            .await;
+
+        assert!(attempts.is_empty());
+        assert!(result
+            .unwrap_err()
+            .to_string()
+            .contains("redis not supported yet"));
+    }
+}
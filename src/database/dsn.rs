@@ -0,0 +1,229 @@
+// FilePath: src/database/dsn.rs
+
+//! Command lines opening a connection in the database's own client (`psql`,
+//! `mysql`, `sqlite3`), for what LazyTables can't do yet. They're built from
+//! the fields the adapters connect with. A copied command line leaves a
+//! configured password as a placeholder; a client launched from LazyTables
+//! gets it in its environment instead, never in its arguments
+
+#![forbid(unsafe_code)]
+
+use crate::database::{quote_ident, ConnectionConfig, DatabaseType};
+
+/// Stands in for the password in a copied command line
+pub const PASSWORD_PLACEHOLDER: &str = "<password>";
+
+/// Command line connecting the database's client the way the adapter
+/// connects; None for databases without an adapter
+pub fn client_command(config: &ConnectionConfig) -> Option<String> {
+    let database = config.database_or_default();
+    match config.database_type {
+        DatabaseType::PostgreSQL => {
+            let url = postgres_url(config, config.has_password());
+            Some(format!("psql {}", shell_quote(&url)))
+        }
+        DatabaseType::MySQL | DatabaseType::MariaDB => {
+            let client = mysql_client(&config.database_type);
+            let mut command = format!(
+                "{client} --host={} --port={} --user={}",
+                shell_quote(&config.host),
+                config.port,
+                shell_quote(&config.username)
+            );
+            if config.has_password() {
+                command.push_str(&format!(
+                    " --password={}",
+                    shell_quote(PASSWORD_PLACEHOLDER)
+                ));
+            }
+            command.push(' ');
+            command.push_str(&shell_quote(database));
+            Some(command)
+        }
+        DatabaseType::SQLite => Some(format!("sqlite3 {}", shell_quote(database))),
+        _ => None,
+    }
+}
+
+/// Program and arguments launching the database's client on the connection.
+/// The password isn't among them: it goes in the variable of
+/// [`password_variable`]
+pub fn client_args(config: &ConnectionConfig) -> Option<(&'static str, Vec<String>)> {
+    let database = config.database_or_default().to_string();
+    match config.database_type {
+        DatabaseType::PostgreSQL => Some(("psql", vec![postgres_url(config, false)])),
+        DatabaseType::MySQL | DatabaseType::MariaDB => Some((
+            mysql_client(&config.database_type),
+            vec![
+                format!("--host={}", config.host),
+                format!("--port={}", config.port),
+                format!("--user={}", config.username),
+                database,
+            ],
+        )),
+        DatabaseType::SQLite => Some(("sqlite3", vec![database])),
+        _ => None,
+    }
+}
+
+/// Environment variable the database's client reads a password from
+pub fn password_variable(database_type: &DatabaseType) -> Option<&'static str> {
+    match database_type {
+        DatabaseType::PostgreSQL => Some("PGPASSWORD"),
+        DatabaseType::MySQL | DatabaseType::MariaDB => Some("MYSQL_PWD"),
+        _ => None,
+    }
+}
+
+/// Connection URL for `psql`, with the password placeholder in it or not
+fn postgres_url(config: &ConnectionConfig, with_password: bool) -> String {
+    let password = if with_password {
+        format!(":{PASSWORD_PLACEHOLDER}")
+    } else {
+        String::new()
+    };
+    let host = if config.host.contains(':') {
+        format!("[{}]", config.host)
+    } else {
+        config.host.clone()
+    };
+    let mut url = format!(
+        "postgresql://{}{password}@{host}:{}/{}",
+        percent_encode(&config.username),
+        config.port,
+        percent_encode(config.database_or_default())
+    );
+    // The adapter sets the search path after connecting; psql takes it as a
+    // server option
+    let schemas = config.search_path_schemas();
+    if !schemas.is_empty() {
+        let schemas = schemas
+            .iter()
+            .map(|schema| quote_ident(&DatabaseType::PostgreSQL, &[schema.as_str()]))
+            .collect::<Vec<_>>()
+            .join(",")
+            .replace(' ', "\\ ");
+        url.push_str("?options=");
+        url.push_str(&percent_encode(&format!("-csearch_path={schemas}")));
+    }
+    url
+}
+
+/// The MariaDB client for MariaDB, the MySQL one otherwise
+fn mysql_client(database_type: &DatabaseType) -> &'static str {
+    if *database_type == DatabaseType::MariaDB {
+        "mariadb"
+    } else {
+        "mysql"
+    }
+}
+
+/// Percent-encode everything but the characters URLs leave unreserved
+fn percent_encode(text: &str) -> String {
+    let mut encoded = String::with_capacity(text.len());
+    for byte in text.bytes() {
+        if byte.is_ascii_alphanumeric() || matches!(byte, b'-' | b'.' | b'_' | b'~') {
+            encoded.push(byte as char);
+        } else {
+            encoded.push_str(&format!("%{byte:02X}"));
+        }
+    }
+    encoded
+}
+
+/// Quote a word for POSIX shells, unless it's plain enough to need none
+fn shell_quote(word: &str) -> String {
+    let plain = !word.is_empty()
+        && word.chars().all(|c| {
+            c.is_ascii_alphanumeric()
+                || matches!(c, '-' | '_' | '.' | '/' | ':' | '@' | '%' | '+' | '=' | ',')
+        });
+    if plain {
+        word.to_string()
+    } else {
+        format!("'{}'", word.replace('\'', "'\\''"))
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn config(database_type: DatabaseType, port: u16) -> ConnectionConfig {
+        let mut config = ConnectionConfig::new(
+            "Prod".to_string(),
+            database_type,
+            "db.example.com".to_string(),
+            port,
+            "app user".to_string(),
+        );
+        config.database = Some("shop".to_string());
+        config
+    }
+
+    #[test]
+    fn test_postgres_command() {
+        let mut config = config(DatabaseType::PostgreSQL, 5432);
+        assert_eq!(
+            client_command(&config).unwrap(),
+            "psql postgresql://app%20user@db.example.com:5432/shop"
+        );
+
+        config.set_plain_password("s3cret".to_string());
+        config.search_path = Some("App, \"Mixed Case\"".to_string());
+        let command = client_command(&config).unwrap();
+        assert!(!command.contains("s3cret"));
+        assert_eq!(
+            command,
+            "psql 'postgresql://app%20user:<password>@db.example.com:5432/shop\
+             ?options=-csearch_path%3D%22app%22%2C%22Mixed%5C%20Case%22'"
+        );
+    }
+
+    #[test]
+    fn test_mysql_command() {
+        let mut config = config(DatabaseType::MariaDB, 3306);
+        config.database = None;
+        config.set_plain_password("it's".to_string());
+        assert_eq!(
+            client_command(&config).unwrap(),
+            "mariadb --host=db.example.com --port=3306 --user='app user' \
+             --password='<password>' mysql"
+        );
+    }
+
+    #[test]
+    fn test_client_args() {
+        let mut config = config(DatabaseType::MySQL, 3306);
+        config.set_plain_password("s3cret".to_string());
+        let (program, args) = client_args(&config).unwrap();
+        assert_eq!(program, "mysql");
+        assert_eq!(
+            args,
+            [
+                "--host=db.example.com",
+                "--port=3306",
+                "--user=app user",
+                "shop"
+            ]
+        );
+        assert_eq!(password_variable(&config.database_type), Some("MYSQL_PWD"));
+
+        config.database_type = DatabaseType::PostgreSQL;
+        let (program, args) = client_args(&config).unwrap();
+        assert_eq!(program, "psql");
+        assert_eq!(args, ["postgresql://app%20user@db.example.com:3306/shop"]);
+        assert_eq!(password_variable(&DatabaseType::SQLite), None);
+    }
+
+    #[test]
+    fn test_sqlite_command() {
+        let mut config = config(DatabaseType::SQLite, 0);
+        config.database = Some("/tmp/my db.sqlite".to_string());
+        assert_eq!(
+            client_command(&config).unwrap(),
+            "sqlite3 '/tmp/my db.sqlite'"
+        );
+        assert_eq!(shell_quote("it's"), "'it'\\''s'");
+    }
+}
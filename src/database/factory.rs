@@ -78,6 +78,28 @@ impl AdapterFactory {
         Ok((db_type, connection))
     }
 
+    /// Build a ConnectionConfig from a connection URL, detecting the database type.
+    /// SQLite URLs (`sqlite:///path/to.db`) and bare `.db`/`.sqlite` paths keep the
+    /// file path as the database
+    pub fn config_from_connection_string(
+        connection_string: &str,
+        name: String,
+    ) -> Result<ConnectionConfig> {
+        let db_type = Self::detect_database_type(connection_string)?;
+
+        if db_type == DatabaseType::SQLite {
+            let path = connection_string
+                .trim()
+                .strip_prefix("sqlite://")
+                .unwrap_or(connection_string.trim());
+            let mut config = ConnectionConfig::new(name, db_type, String::new(), 0, String::new());
+            config.database = Some(path.to_string());
+            return Ok(config);
+        }
+
+        Self::parse_connection_string(connection_string, name, db_type)
+    }
+
     /// Parse connection string into ConnectionConfig
     /// Helper method for automatic configuration from connection strings
     fn parse_connection_string(
@@ -194,6 +216,22 @@ mod tests {
         assert!(AdapterFactory::detect_database_type("invalid://localhost").is_err());
     }
 
+    #[test]
+    fn test_config_from_sqlite_connection_string() {
+        let config = AdapterFactory::config_from_connection_string(
+            "sqlite:///var/data/app.db",
+            "cli".to_string(),
+        )
+        .unwrap();
+        assert_eq!(config.database_type, DatabaseType::SQLite);
+        assert_eq!(config.database.as_deref(), Some("/var/data/app.db"));
+
+        let config =
+            AdapterFactory::config_from_connection_string("./local.sqlite", "cli".to_string())
+                .unwrap();
+        assert_eq!(config.database.as_deref(), Some("./local.sqlite"));
+    }
+
     #[test]
     fn test_parse_connection_string() {
         let config = AdapterFactory::parse_connection_string(
@@ -0,0 +1,378 @@
+// FilePath: src/database/fixtures.rs
+
+//! Fixture tables for trying LazyTables out and for integration tests
+//!
+//! `lazytables seed` creates a handful of tables with typed columns - text
+//! with unicode and quotes, numbers, booleans, timestamps, JSON, bytes and
+//! NULLs in every nullable column - and fills them with rows generated from
+//! a seed. The same seed always produces the same statements, so snapshots
+//! taken against a seeded database stay stable.
+
+#![forbid(unsafe_code)]
+
+use super::literal::quote_literal;
+use super::{quote_ident, DatabaseType};
+
+/// Rows per INSERT statement
+const INSERT_BATCH_ROWS: usize = 500;
+
+/// One row in this many has NULL in a given nullable column
+const NULL_ONE_IN: u64 = 8;
+
+/// Text values, chosen to exercise wide characters, combining marks, emoji,
+/// right-to-left scripts and the characters SQL and JSON escape
+const WORDS: &[&str] = &[
+    "alpha",
+    "Zürich",
+    "naïve café",
+    "日本語のテキスト",
+    "北京",
+    "Ελληνικά",
+    "Привет",
+    "שלום",
+    "مرحبا",
+    "🦀 crab",
+    "👩‍💻",
+    "e\u{301}",
+    "O'Brien",
+    "say \"hi\"",
+    r"C:\temp\new",
+    "tab\there",
+    "line one\nline two",
+    "   padded   ",
+    "",
+    "NULL",
+];
+
+/// What `lazytables seed` creates
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct FixtureSpec {
+    /// Number of tables, named `<prefix>1`, `<prefix>2`, ...
+    pub tables: usize,
+    /// Rows per table
+    pub rows: usize,
+    /// Seed of the value generator
+    pub seed: u64,
+    /// Table name prefix
+    pub prefix: String,
+    /// Drop the tables first if they exist
+    pub drop_existing: bool,
+}
+
+impl Default for FixtureSpec {
+    fn default() -> Self {
+        Self {
+            tables: 3,
+            rows: 1000,
+            seed: 42,
+            prefix: "fixture_".to_string(),
+            drop_existing: false,
+        }
+    }
+}
+
+impl FixtureSpec {
+    /// Name of the table with 1-based `index`
+    pub fn table_name(&self, index: usize) -> String {
+        format!("{}{}", self.prefix, index)
+    }
+
+    /// Statements creating and filling the tables, in the order to run them.
+    /// Errors for databases that can't be seeded
+    pub fn statements(&self, database_type: &DatabaseType) -> Result<Vec<String>, String> {
+        if !matches!(
+            database_type,
+            DatabaseType::PostgreSQL
+                | DatabaseType::MySQL
+                | DatabaseType::MariaDB
+                | DatabaseType::SQLite
+        ) {
+            return Err(format!(
+                "Seeding isn't supported for {} connections",
+                database_type.display_name()
+            ));
+        }
+
+        let mut statements = Vec::new();
+        for index in 1..=self.tables {
+            let table = quote_ident(database_type, &[&self.table_name(index)]);
+            if self.drop_existing {
+                statements.push(format!("DROP TABLE IF EXISTS {table}"));
+            }
+            statements.push(create_table(database_type, &table));
+
+            // Each table draws from its own stream, so adding tables leaves
+            // the rows of the existing ones unchanged
+            let mut rng = SplitMix64::new(self.seed ^ (index as u64).wrapping_mul(GOLDEN_GAMMA));
+            let mut id = 1;
+            while id <= self.rows {
+                let last = (id + INSERT_BATCH_ROWS - 1).min(self.rows);
+                let rows = (id..=last)
+                    .map(|id| row_values(database_type, id, &mut rng))
+                    .collect::<Vec<_>>()
+                    .join(",\n");
+                statements.push(format!(
+                    "INSERT INTO {table} ({}) VALUES\n{rows}",
+                    column_list(database_type)
+                ));
+                id = last + 1;
+            }
+        }
+        Ok(statements)
+    }
+}
+
+/// Column names of a fixture table, in order
+const COLUMNS: [&str; 8] = [
+    "id",
+    "name",
+    "amount",
+    "active",
+    "created_at",
+    "payload",
+    "data",
+    "note",
+];
+
+fn column_list(database_type: &DatabaseType) -> String {
+    COLUMNS
+        .iter()
+        .map(|column| quote_ident(database_type, &[column]))
+        .collect::<Vec<_>>()
+        .join(", ")
+}
+
+/// `CREATE TABLE` of the fixture table `table` (already quoted)
+fn create_table(database_type: &DatabaseType, table: &str) -> String {
+    let types: [&str; 8] = match database_type {
+        DatabaseType::PostgreSQL => [
+            "INTEGER PRIMARY KEY",
+            "TEXT NOT NULL",
+            "NUMERIC(12,2)",
+            "BOOLEAN",
+            "TIMESTAMP",
+            "JSONB",
+            "BYTEA",
+            "TEXT",
+        ],
+        DatabaseType::MySQL | DatabaseType::MariaDB => [
+            "INT PRIMARY KEY",
+            "VARCHAR(255) NOT NULL",
+            "DECIMAL(12,2)",
+            "BOOLEAN",
+            "DATETIME",
+            "JSON",
+            "BLOB",
+            "TEXT",
+        ],
+        _ => [
+            "INTEGER PRIMARY KEY",
+            "TEXT NOT NULL",
+            "NUMERIC",
+            "BOOLEAN",
+            "TIMESTAMP",
+            "TEXT",
+            "BLOB",
+            "TEXT",
+        ],
+    };
+    let columns = COLUMNS
+        .iter()
+        .zip(types)
+        .map(|(column, data_type)| {
+            format!("  {} {data_type}", quote_ident(database_type, &[column]))
+        })
+        .collect::<Vec<_>>()
+        .join(",\n");
+    let options = match database_type {
+        DatabaseType::MySQL | DatabaseType::MariaDB => " DEFAULT CHARSET=utf8mb4",
+        _ => "",
+    };
+    format!("CREATE TABLE {table} (\n{columns}\n){options}")
+}
+
+/// `(...)` of the values of row `id`
+fn row_values(database_type: &DatabaseType, id: usize, rng: &mut SplitMix64) -> String {
+    let name = quote_literal(database_type, rng.pick(WORDS));
+
+    let amount = rng.nullable(|rng| {
+        let cents = rng.below(20_000_000) as i64 - 10_000_000;
+        let sign = if cents < 0 { "-" } else { "" };
+        format!("{sign}{}.{:02}", cents.abs() / 100, cents.abs() % 100)
+    });
+
+    let active = rng.nullable(|rng| {
+        let value = rng.below(2) == 1;
+        match database_type {
+            DatabaseType::SQLite => u8::from(value).to_string(),
+            _ => value.to_string().to_uppercase(),
+        }
+    });
+
+    let created_at = rng.nullable(|rng| {
+        format!(
+            "'2024-{:02}-{:02} {:02}:{:02}:{:02}'",
+            rng.below(12) + 1,
+            rng.below(28) + 1,
+            rng.below(24),
+            rng.below(60),
+            rng.below(60)
+        )
+    });
+
+    let payload = rng.nullable(|rng| {
+        let tags = (0..rng.below(4))
+            .map(|_| json_string(rng.pick(WORDS)))
+            .collect::<Vec<_>>()
+            .join(", ");
+        let json = format!(
+            r#"{{"n": {}, "label": {}, "tags": [{tags}], "nested": {{"ok": {}}}}}"#,
+            rng.below(1000),
+            json_string(rng.pick(WORDS)),
+            rng.below(2) == 1
+        );
+        quote_literal(database_type, &json)
+    });
+
+    let data = rng.nullable(|rng| {
+        let hex = (0..rng.below(17))
+            .map(|_| format!("{:02x}", rng.below(256)))
+            .collect::<String>();
+        match database_type {
+            DatabaseType::PostgreSQL => format!("decode('{hex}', 'hex')"),
+            _ => format!("X'{hex}'"),
+        }
+    });
+
+    // Mostly NULL, to have a sparse column
+    let note = if rng.below(4) == 0 {
+        quote_literal(database_type, rng.pick(WORDS))
+    } else {
+        "NULL".to_string()
+    };
+
+    format!("({id}, {name}, {amount}, {active}, {created_at}, {payload}, {data}, {note})")
+}
+
+/// `value` as a JSON string
+fn json_string(value: &str) -> String {
+    serde_json::to_string(value).unwrap_or_else(|_| "\"\"".to_string())
+}
+
+/// Increment of the SplitMix64 state, also used to spread table streams apart
+const GOLDEN_GAMMA: u64 = 0x9e37_79b9_7f4a_7c15;
+
+/// SplitMix64 - small, fast and the same on every platform, which is all
+/// fixture data needs
+struct SplitMix64 {
+    state: u64,
+}
+
+impl SplitMix64 {
+    fn new(seed: u64) -> Self {
+        Self { state: seed }
+    }
+
+    fn next(&mut self) -> u64 {
+        self.state = self.state.wrapping_add(GOLDEN_GAMMA);
+        let mut z = self.state;
+        z = (z ^ (z >> 30)).wrapping_mul(0xbf58_476d_1ce4_e5b9);
+        z = (z ^ (z >> 27)).wrapping_mul(0x94d0_49bb_1331_11eb);
+        z ^ (z >> 31)
+    }
+
+    /// Number in `0..bound`
+    fn below(&mut self, bound: u64) -> u64 {
+        self.next() % bound
+    }
+
+    fn pick<'a>(&mut self, items: &[&'a str]) -> &'a str {
+        items[self.below(items.len() as u64) as usize]
+    }
+
+    /// `NULL` for one row in `NULL_ONE_IN`, otherwise the literal `value` makes
+    fn nullable(&mut self, value: impl FnOnce(&mut Self) -> String) -> String {
+        if self.below(NULL_ONE_IN) == 0 {
+            "NULL".to_string()
+        } else {
+            value(self)
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn spec(tables: usize, rows: usize, seed: u64) -> FixtureSpec {
+        FixtureSpec {
+            tables,
+            rows,
+            seed,
+            ..FixtureSpec::default()
+        }
+    }
+
+    #[test]
+    fn test_same_seed_same_statements() {
+        let pg = DatabaseType::PostgreSQL;
+        assert_eq!(
+            spec(2, 50, 7).statements(&pg).unwrap(),
+            spec(2, 50, 7).statements(&pg).unwrap()
+        );
+        assert_ne!(
+            spec(2, 50, 7).statements(&pg).unwrap(),
+            spec(2, 50, 8).statements(&pg).unwrap()
+        );
+
+        // More tables leave the first table's rows alone
+        let one = spec(1, 50, 7).statements(&pg).unwrap();
+        let two = spec(2, 50, 7).statements(&pg).unwrap();
+        assert_eq!(one[..], two[..one.len()]);
+    }
+
+    #[test]
+    fn test_rows_are_batched() {
+        let statements = spec(1, 1201, 1).statements(&DatabaseType::SQLite).unwrap();
+        assert_eq!(statements.len(), 4);
+        assert!(statements[0].starts_with(r#"CREATE TABLE "fixture_1""#));
+        let rows: usize = statements[1..]
+            .iter()
+            .map(|insert| insert.matches("\n(").count())
+            .sum();
+        assert_eq!(rows, 1201);
+        assert!(statements[3].contains("\n(1201, "));
+
+        let empty = FixtureSpec {
+            drop_existing: true,
+            ..spec(1, 0, 1)
+        };
+        assert_eq!(
+            empty.statements(&DatabaseType::MySQL).unwrap()[0],
+            "DROP TABLE IF EXISTS `fixture_1`"
+        );
+        assert_eq!(empty.statements(&DatabaseType::MySQL).unwrap().len(), 2);
+    }
+
+    #[test]
+    fn test_values_use_each_dialect() {
+        let pg = spec(1, 200, 3)
+            .statements(&DatabaseType::PostgreSQL)
+            .unwrap();
+        assert!(pg[1].contains("decode('"));
+        assert!(pg[1].contains("TRUE"));
+        assert!(pg[1].contains("NULL"));
+        assert!(pg[1].contains("'O''Brien'"));
+        assert!(pg[0].contains("JSONB"));
+
+        let mysql = spec(1, 200, 3).statements(&DatabaseType::MySQL).unwrap();
+        assert!(mysql[1].contains("X'"));
+        assert!(mysql[1].contains(r"'C:\\temp\\new'"));
+        assert!(mysql[0].ends_with("DEFAULT CHARSET=utf8mb4"));
+
+        let sqlite = spec(1, 200, 3).statements(&DatabaseType::SQLite).unwrap();
+        assert!(!sqlite[1].contains("TRUE"));
+
+        assert!(spec(1, 1, 1).statements(&DatabaseType::Redis).is_err());
+    }
+}
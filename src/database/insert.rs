@@ -0,0 +1,145 @@
+// FilePath: src/database/insert.rs
+
+//! Inserting a row from the insert-row form
+//!
+//! Values are bound as parameters. PostgreSQL won't store a text parameter
+//! in a column of another type, so there the row goes in as one JSON object
+//! that `json_populate_record` turns into the table's row type, converting
+//! each field with its column's input function. Columns left out of the
+//! insert take their defaults.
+
+#![forbid(unsafe_code)]
+
+use super::preview::quoted_table;
+use super::{quote_ident, DatabaseType};
+
+/// Value of one column of a row to insert
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct InsertValue {
+    pub column: String,
+    /// None inserts NULL
+    pub value: Option<String>,
+}
+
+/// Whether the database hands back the inserted row with `RETURNING *`
+pub fn returns_row(database_type: &DatabaseType) -> bool {
+    matches!(
+        database_type,
+        DatabaseType::PostgreSQL | DatabaseType::SQLite
+    )
+}
+
+/// `INSERT` of `values` into `table_name`, with placeholders for the
+/// parameters of `insert_params`
+pub fn insert_query(
+    database_type: &DatabaseType,
+    table_name: &str,
+    values: &[InsertValue],
+) -> String {
+    let table = quoted_table(database_type, table_name);
+    let returning = if returns_row(database_type) {
+        " RETURNING *"
+    } else {
+        ""
+    };
+    if values.is_empty() {
+        return match database_type {
+            DatabaseType::MySQL | DatabaseType::MariaDB => {
+                format!("INSERT INTO {table} () VALUES ()")
+            }
+            _ => format!("INSERT INTO {table} DEFAULT VALUES{returning}"),
+        };
+    }
+
+    let columns = values
+        .iter()
+        .map(|value| quote_ident(database_type, &[&value.column]))
+        .collect::<Vec<_>>()
+        .join(", ");
+    match database_type {
+        DatabaseType::PostgreSQL => format!(
+            "INSERT INTO {table} ({columns}) \
+             SELECT {columns} FROM json_populate_record(NULL::{table}, $1::json){returning}"
+        ),
+        _ => format!(
+            "INSERT INTO {table} ({columns}) VALUES ({}){returning}",
+            vec!["?"; values.len()].join(", ")
+        ),
+    }
+}
+
+/// Parameters to bind to `insert_query`, in order; None binds NULL
+pub fn insert_params(database_type: &DatabaseType, values: &[InsertValue]) -> Vec<Option<String>> {
+    match database_type {
+        DatabaseType::PostgreSQL if !values.is_empty() => {
+            let record: serde_json::Map<String, serde_json::Value> = values
+                .iter()
+                .map(|value| {
+                    let field = value
+                        .value
+                        .clone()
+                        .map_or(serde_json::Value::Null, serde_json::Value::String);
+                    (value.column.clone(), field)
+                })
+                .collect();
+            vec![Some(serde_json::Value::Object(record).to_string())]
+        }
+        DatabaseType::PostgreSQL => Vec::new(),
+        _ => values.iter().map(|value| value.value.clone()).collect(),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn values() -> Vec<InsertValue> {
+        vec![
+            InsertValue {
+                column: "email".to_string(),
+                value: Some("a\"b@example.com".to_string()),
+            },
+            InsertValue {
+                column: "note".to_string(),
+                value: None,
+            },
+        ]
+    }
+
+    #[test]
+    fn test_postgres_inserts_a_json_record() {
+        let db = DatabaseType::PostgreSQL;
+        assert_eq!(
+            insert_query(&db, "crm.users", &values()),
+            "INSERT INTO \"crm\".\"users\" (\"email\", \"note\") \
+             SELECT \"email\", \"note\" FROM json_populate_record(NULL::\"crm\".\"users\", $1::json) \
+             RETURNING *"
+        );
+        assert_eq!(
+            insert_params(&db, &values()),
+            [Some(
+                r#"{"email":"a\"b@example.com","note":null}"#.to_string()
+            )]
+        );
+        assert_eq!(
+            insert_query(&db, "users", &[]),
+            "INSERT INTO \"users\" DEFAULT VALUES RETURNING *"
+        );
+    }
+
+    #[test]
+    fn test_other_databases_bind_each_value() {
+        assert_eq!(
+            insert_query(&DatabaseType::MySQL, "users", &values()),
+            "INSERT INTO `users` (`email`, `note`) VALUES (?, ?)"
+        );
+        assert_eq!(
+            insert_query(&DatabaseType::SQLite, "users", &values()),
+            "INSERT INTO \"users\" (\"email\", \"note\") VALUES (?, ?) RETURNING *"
+        );
+        assert_eq!(
+            insert_params(&DatabaseType::SQLite, &values()),
+            [Some("a\"b@example.com".to_string()), None]
+        );
+    }
+}
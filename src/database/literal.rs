@@ -0,0 +1,173 @@
+// FilePath: src/database/literal.rs
+
+//! Literal quoting for generated SQL
+//!
+//! Values copied out of a result back into SQL are written here: numbers and
+//! booleans of numeric and boolean columns go in as they are, everything else
+//! as a quoted string the database converts to the column's type.
+
+#![forbid(unsafe_code)]
+
+use super::{quote_ident, DatabaseType, NULL_CELL};
+
+/// Quote `value` as a string literal. Single quotes are doubled; MySQL also
+/// treats backslashes as escapes, so those are doubled there too
+pub fn quote_literal(database_type: &DatabaseType, value: &str) -> String {
+    let escaped = match database_type {
+        DatabaseType::MySQL | DatabaseType::MariaDB => {
+            value.replace('\\', "\\\\").replace('\'', "''")
+        }
+        _ => value.replace('\'', "''"),
+    };
+    format!("'{escaped}'")
+}
+
+/// Base name of a column type, e.g. `numeric` for `NUMERIC(10,2)` and
+/// `int` for `int(11) unsigned`
+fn base_type(data_type: &str) -> String {
+    let lower = data_type.trim().to_lowercase();
+    let name = lower.split('(').next().unwrap_or_default();
+    name.trim_end_matches(" unsigned").trim().to_string()
+}
+
+/// Whether values of the column type are numbers
+pub(crate) fn is_numeric_type(data_type: &str) -> bool {
+    matches!(
+        base_type(data_type).as_str(),
+        "int"
+            | "integer"
+            | "smallint"
+            | "bigint"
+            | "tinyint"
+            | "mediumint"
+            | "int2"
+            | "int4"
+            | "int8"
+            | "serial"
+            | "smallserial"
+            | "bigserial"
+            | "decimal"
+            | "numeric"
+            | "real"
+            | "float"
+            | "float4"
+            | "float8"
+            | "double"
+            | "double precision"
+    )
+}
+
+/// Whether values of the column type are bytes: `bytea`, or a MySQL or
+/// SQLite blob or binary string
+pub(crate) fn is_binary_type(data_type: &str) -> bool {
+    matches!(
+        base_type(data_type).as_str(),
+        "bytea" | "blob" | "tinyblob" | "mediumblob" | "longblob" | "binary" | "varbinary"
+    )
+}
+
+/// Whether values of the column type are booleans
+pub(crate) fn is_boolean_type(data_type: &str) -> bool {
+    matches!(base_type(data_type).as_str(), "bool" | "boolean")
+}
+
+/// Literal for a cell `value` of a column of type `data_type`. The value
+/// is always text, the text `NULL` included; callers write SQL NULL
+/// themselves
+pub fn sql_literal(database_type: &DatabaseType, data_type: &str, value: &str) -> String {
+    let bare = if is_numeric_type(data_type) {
+        value.parse::<f64>().is_ok_and(f64::is_finite)
+    } else if is_boolean_type(data_type) {
+        value.eq_ignore_ascii_case("true") || value.eq_ignore_ascii_case("false")
+    } else {
+        false
+    };
+    if bare {
+        value.to_string()
+    } else {
+        quote_literal(database_type, value)
+    }
+}
+
+/// Condition matching `column` against `values`, e.g. `"id" IN (3, 17, 42)`.
+/// Repeated values are listed once; NULL cells add an `IS NULL` alternative,
+/// since `IN (NULL)` never matches. `None` when there are no values
+pub fn in_clause(
+    database_type: &DatabaseType,
+    column: &str,
+    data_type: &str,
+    values: &[String],
+) -> Option<String> {
+    let column = quote_ident(database_type, &[column]);
+    let mut literals: Vec<String> = Vec::new();
+    let mut has_null = false;
+    for value in values {
+        if value == NULL_CELL {
+            has_null = true;
+            continue;
+        }
+        let literal = sql_literal(database_type, data_type, value);
+        if !literals.contains(&literal) {
+            literals.push(literal);
+        }
+    }
+
+    let is_null = format!("{column} IS NULL");
+    match (literals.is_empty(), has_null) {
+        (true, false) => None,
+        (true, true) => Some(is_null),
+        (false, false) => Some(format!("{column} IN ({})", literals.join(", "))),
+        (false, true) => Some(format!(
+            "({column} IN ({}) OR {is_null})",
+            literals.join(", ")
+        )),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn values(items: &[&str]) -> Vec<String> {
+        items.iter().map(|item| item.to_string()).collect()
+    }
+
+    #[test]
+    fn test_literals_follow_column_type() {
+        let pg = DatabaseType::PostgreSQL;
+        assert_eq!(sql_literal(&pg, "INTEGER", "42"), "42");
+        assert_eq!(sql_literal(&pg, "numeric(10,2)", "-3.50"), "-3.50");
+        assert_eq!(sql_literal(&pg, "int(11) unsigned", "7"), "7");
+        assert_eq!(sql_literal(&pg, "BOOLEAN", "true"), "true");
+        assert_eq!(sql_literal(&pg, "TEXT", "42"), "'42'");
+        assert_eq!(sql_literal(&pg, "INTEGER", "NaN"), "'NaN'");
+        assert_eq!(sql_literal(&pg, "TEXT", "NULL"), "'NULL'");
+        assert_eq!(sql_literal(&pg, "TEXT", "O'Brien"), "'O''Brien'");
+        assert_eq!(
+            sql_literal(&DatabaseType::MySQL, "varchar(20)", r"a\'b"),
+            r"'a\\''b'"
+        );
+    }
+
+    #[test]
+    fn test_in_clause() {
+        let pg = DatabaseType::PostgreSQL;
+        assert_eq!(
+            in_clause(&pg, "id", "BIGINT", &values(&["3", "17", "42", "17"])),
+            Some(r#""id" IN (3, 17, 42)"#.to_string())
+        );
+        assert_eq!(
+            in_clause(&pg, "status", "TEXT", &values(&["new", "NULL"])),
+            Some(r#"("status" IN ('new') OR "status" IS NULL)"#.to_string())
+        );
+        assert_eq!(
+            in_clause(&pg, "status", "TEXT", &values(&["NULL"])),
+            Some(r#""status" IS NULL"#.to_string())
+        );
+        assert_eq!(
+            in_clause(&DatabaseType::MySQL, "user id", "INT", &values(&["1"])),
+            Some("`user id` IN (1)".to_string())
+        );
+        assert_eq!(in_clause(&pg, "id", "INTEGER", &[]), None);
+    }
+}
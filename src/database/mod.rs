@@ -4,14 +4,37 @@
 #![forbid(unsafe_code)]
 
 pub mod app_state;
+pub mod audit_log;
+pub mod cancel;
 pub mod connection;
 pub mod connection_manager;
+pub mod dsn;
 pub mod factory;
+pub mod fixtures;
+pub mod ident;
+pub mod insert;
+pub mod literal;
 pub mod mysql;
+pub mod notices;
 pub mod objects;
+pub mod partitions;
 pub mod postgres;
+pub mod preview;
 pub mod query_history;
+pub mod reachability;
+pub mod result;
+pub mod script;
+pub mod server_role;
+pub mod session;
+pub mod session_stats;
+pub mod statement_guard;
+pub mod statement_timeout;
+pub mod structure;
 pub mod sqlite;
+pub mod time_zone;
+pub mod transaction;
+pub mod undo;
+pub mod update_preview;
 
 pub use connection::{
     ConnectionConfig, ConnectionStatus, ConnectionStorage, DatabaseCapabilities, DatabaseType,
@@ -25,10 +48,46 @@ pub use connection::Connection;
 pub use factory::AdapterFactory;
 
 // Re-export connection manager
-pub use connection_manager::ConnectionManager;
+pub use connection_manager::{ConnectRetry, ConnectionManager, RowSink};
+
+// Re-export identifier quoting
+pub use ident::quote_ident;
+
+// Re-export row inserts
+pub use insert::InsertValue;
+
+// Re-export literal quoting
+pub use literal::{in_clause, quote_literal, sql_literal};
 
 // Re-export database object types
-pub use objects::{DatabaseObject, DatabaseObjectList, DatabaseObjectType};
+pub use objects::{
+    is_system_object, system_schemas, ColumnMatch, DatabaseObject, DatabaseObjectList,
+    DatabaseObjectType, MissingObject, RoutineInfo,
+};
+
+// Re-export partition types
+pub use partitions::{partition_preview_sql, PartitionInfo};
+
+// Re-export audit log types
+pub use audit_log::{QueryAuditEntry, QueryAuditLog, QueryKind};
+
+// Re-export preview cell limits
+pub use preview::{
+    ColumnFilter, FilterOperator, KeyBound, Paging, PreviewSort, DEFAULT_PREVIEW_CELL_CHARS,
+    DEFAULT_PREVIEW_PAGE_ROWS, PARTIAL_CELL_MARKER,
+};
+
+// Re-export display time zone
+pub use time_zone::DisplayTimeZone;
+
+// Re-export undo log types
+pub use undo::{CellValue, UndoEntry, UndoLog};
+
+// Re-export server notices
+pub use notices::ServerNotice;
+
+// Re-export query result types
+pub use result::{ProgressCollector, QueryResult, ResultLimits, NULL_CELL};
 
 // Re-export query history types
 pub use query_history::{QueryHistoryEntry, QueryHistoryManager};
@@ -46,6 +105,32 @@ pub struct TableColumn {
     pub is_nullable: bool,
     pub default_value: Option<String>,
     pub is_primary_key: bool,
+    /// Identity, serial or auto_increment column numbered by the database
+    pub is_auto_increment: bool,
+    /// Set when the column's value is computed from other columns
+    pub generated: Option<GeneratedColumn>,
+    /// Comment on the column, when it has one
+    pub comment: Option<String>,
+}
+
+/// A generated (computed) column. Inserts and updates can't set it
+#[derive(Debug, Clone, PartialEq)]
+pub struct GeneratedColumn {
+    /// Stored on write, as opposed to computed when read
+    pub stored: bool,
+    /// Generation expression, when the database reports it
+    pub expression: Option<String>,
+}
+
+impl GeneratedColumn {
+    /// Short label for the structure view, e.g. "GENERATED STORED"
+    pub fn label(&self) -> &'static str {
+        if self.stored {
+            "GENERATED STORED"
+        } else {
+            "GENERATED VIRTUAL"
+        }
+    }
 }
 
 /// Column definition for table creation
@@ -79,6 +164,13 @@ pub enum DataType {
     Uuid,
     Bytea,
     Array(Box<DataType>),
+    /// Enumerated type with its labels in declaration order
+    Enum {
+        name: String,
+        labels: Vec<String>,
+    },
+    /// Any other named type (domains, composites, extension types)
+    Custom(String),
 }
 
 impl DataType {
@@ -114,6 +206,17 @@ impl DataType {
             DataType::Uuid => "UUID".to_string(),
             DataType::Bytea => "BYTEA".to_string(),
             DataType::Array(inner) => format!("{}[]", inner.to_sql()),
+            DataType::Enum { name, .. } => name.clone(),
+            DataType::Custom(name) => name.clone(),
+        }
+    }
+
+    /// Allowed labels of an enum type, or of an array's enum elements
+    pub fn enum_labels(&self) -> &[String] {
+        match self {
+            DataType::Enum { labels, .. } => labels,
+            DataType::Array(inner) => inner.enum_labels(),
+            _ => &[],
         }
     }
 }
@@ -3,48 +3,93 @@
 #![forbid(unsafe_code)]
 
 use crate::core::error::{LazyTablesError, Result};
+use crate::database::insert::{self, InsertValue};
+use crate::database::preview::{self, ColumnFilter, Paging, DEFAULT_PREVIEW_CELL_CHARS};
 use crate::database::{
-    connection::ConnectionConfig, Connection, DataType, TableColumn, TableMetadata,
+    cancel::QueryCanceller, connection::ConnectionConfig, is_system_object, objects::like_contains,
+    quote_ident, session::SessionConnection, statement_timeout, system_schemas, ColumnMatch,
+    Connection, DataType, DatabaseType, DisplayTimeZone, GeneratedColumn, IndexInfo, PartitionInfo,
+    RoutineInfo, ServerNotice, TableColumn, TableMetadata,
 };
 use async_trait::async_trait;
-use sqlx::mysql::{MySqlPool, MySqlPoolOptions};
-use sqlx::{Column, Row};
+use chrono::FixedOffset;
+use futures::TryStreamExt;
+use sqlx::mysql::{MySqlColumn, MySqlConnectOptions, MySqlPool, MySqlPoolOptions, MySqlRow};
+use sqlx::{Column, Either, Row, Statement as _, TypeInfo};
 
 /// MySQL database connection implementation
 #[derive(Debug)]
 pub struct MySqlConnection {
     config: ConnectionConfig,
     pool: Option<MySqlPool>,
+    /// Connection user queries run on, keeping their session state
+    session: SessionConnection<sqlx::MySql>,
+    /// Zone `TIMESTAMP` values are shown in
+    time_zone: DisplayTimeZone,
+    /// Offset sessions run at: the display zone's when the server takes it
+    /// as an offset, UTC otherwise
+    session_offset: FixedOffset,
+    /// Characters of each cell a table preview reads; 0 reads whole values
+    preview_cell_chars: usize,
+    /// Seconds the server lets a statement run; 0 for no limit
+    statement_timeout_secs: u64,
 }
 
 impl MySqlConnection {
     /// Create a new MySQL connection instance
     pub fn new(config: ConnectionConfig) -> Self {
-        Self { config, pool: None }
+        let time_zone = config.display_time_zone();
+        let session_offset = time_zone
+            .mysql_session_offset()
+            .unwrap_or_else(|| FixedOffset::east_opt(0).expect("zero offset is valid"));
+        Self {
+            config,
+            pool: None,
+            session: SessionConnection::default(),
+            time_zone,
+            session_offset,
+            preview_cell_chars: DEFAULT_PREVIEW_CELL_CHARS,
+            statement_timeout_secs: 0,
+        }
     }
 
-    /// Build MySQL connection string
-    fn build_connection_string(&self, encryption_key: Option<&str>) -> Result<String> {
-        let host = &self.config.host;
-        let port = self.config.port;
-        let database = self.config.database.as_deref().unwrap_or("mysql");
-        let username = &self.config.username;
+    /// Set how many characters of each cell table previews read
+    pub fn set_preview_cell_chars(&mut self, max_chars: usize) {
+        self.preview_cell_chars = max_chars;
+    }
 
-        // Try to resolve password from various sources
-        let password = self
-            .config
-            .resolve_password(encryption_key)
-            .unwrap_or_default();
+    /// Set the statement timeout sessions start with, in seconds
+    pub fn set_statement_timeout(&mut self, seconds: u64) {
+        self.statement_timeout_secs = seconds;
+    }
 
-        if !password.is_empty() {
-            Ok(format!(
-                "mysql://{username}:{password}@{host}:{port}/{database}"
-            ))
-        } else {
-            Ok(format!("mysql://{username}@{host}:{port}/{database}"))
+    /// Connection options for the pool. The password goes to the driver
+    /// directly rather than into a connection URL that errors and logs could
+    /// echo. The options' `Debug` output shows the password, so they are
+    /// never logged
+    fn connect_options(&self, encryption_key: Option<&str>) -> MySqlConnectOptions {
+        let options = MySqlConnectOptions::new()
+            .host(&self.config.host)
+            .port(self.config.port)
+            .username(&self.config.username)
+            .database(self.config.database_or_default())
+            .timezone(Some(self.session_offset.to_string()));
+
+        match self.connect_password(encryption_key) {
+            Some(password) => options.password(&password),
+            None => options,
         }
     }
 
+    /// The password `connect_options` gives the driver, resolved from the
+    /// sources the connection keeps it in; None when there is none
+    fn connect_password(&self, encryption_key: Option<&str>) -> Option<String> {
+        self.config
+            .resolve_password(encryption_key)
+            .ok()
+            .filter(|password| !password.is_empty())
+    }
+
     /// Parse SQLx error into structured ConnectionError with helpful suggestions
     pub fn parse_connection_error(
         &self,
@@ -161,19 +206,39 @@ impl Connection for MySqlConnection {
     }
 
     async fn connect_with_key(&mut self, encryption_key: Option<&str>) -> Result<()> {
-        let connection_string = self.build_connection_string(encryption_key)?;
+        let options = self.connect_options(encryption_key);
 
+        // Every pooled connection gets the statement timeout, MariaDB naming
+        // it differently from MySQL
+        let timeout = (self.statement_timeout_secs > 0)
+            .then(|| {
+                statement_timeout::set_sql(&self.config.database_type, self.statement_timeout_secs)
+            })
+            .flatten();
         let pool = MySqlPoolOptions::new()
             .max_connections(5)
-            .connect(&connection_string)
+            .after_connect(move |connection, _| {
+                let timeout = timeout.clone();
+                Box::pin(async move {
+                    if let Some(timeout) = timeout {
+                        sqlx::raw_sql(&timeout).execute(&mut *connection).await?;
+                    }
+                    Ok(())
+                })
+            })
+            .connect_with(options)
             .await
-            .map_err(|e| LazyTablesError::Connection(format!("Failed to connect to MySQL: {e}")))?;
+            .map_err(|source| LazyTablesError::Connect {
+                database: "MySQL",
+                source,
+            })?;
 
         self.pool = Some(pool);
         Ok(())
     }
 
     async fn disconnect(&mut self) -> Result<()> {
+        self.session.release();
         if let Some(pool) = self.pool.take() {
             pool.close().await;
         }
@@ -198,14 +263,25 @@ impl Connection for MySqlConnection {
         MySqlConnection::list_tables(self).await
     }
 
-    async fn list_database_objects(&self) -> Result<crate::database::DatabaseObjectList> {
-        MySqlConnection::list_database_objects(self).await
+    async fn list_database_objects(
+        &self,
+        include_system: bool,
+    ) -> Result<crate::database::DatabaseObjectList> {
+        MySqlConnection::list_database_objects(self, include_system).await
     }
 
     async fn get_table_metadata(&self, table_name: &str) -> Result<crate::database::TableMetadata> {
         MySqlConnection::get_table_metadata(self, table_name).await
     }
 
+    async fn insert_row(
+        &self,
+        table_name: &str,
+        values: &[InsertValue],
+    ) -> Result<(Vec<String>, Vec<Vec<String>>)> {
+        MySqlConnection::insert_row(self, table_name, values).await
+    }
+
     async fn get_table_columns(
         &self,
         table_name: &str,
@@ -219,7 +295,7 @@ impl Connection for MySqlConnection {
         limit: usize,
         offset: usize,
     ) -> Result<Vec<Vec<String>>> {
-        MySqlConnection::get_table_data(self, table_name, limit, offset).await
+        MySqlConnection::get_table_data(self, table_name, limit, &Paging::Offset(offset), &[]).await
     }
 
     // Database-specific capabilities (AC1 & AC2 requirement)
@@ -530,30 +606,43 @@ impl MySqlConnection {
         }
     }
 
-    /// List all database objects (tables, views) with comprehensive metadata
-    pub async fn list_database_objects(&self) -> Result<crate::database::DatabaseObjectList> {
+    /// List all database objects (tables, views) with comprehensive metadata,
+    /// adding the system databases' when `include_system` is set
+    pub async fn list_database_objects(
+        &self,
+        include_system: bool,
+    ) -> Result<crate::database::DatabaseObjectList> {
         use crate::database::{DatabaseObject, DatabaseObjectList, DatabaseObjectType};
 
         if let Some(pool) = &self.pool {
             let mut result = DatabaseObjectList::default();
 
             // Query for tables and views with comprehensive metadata
+            // information_schema's tables are SYSTEM VIEWs on MySQL 8
             let query = "
                 SELECT
+                    t.table_schema AS table_schema,
                     t.table_name,
                     t.table_type,
                     t.table_comment,
                     t.table_rows,
                     t.data_length + t.index_length AS total_size_bytes
                 FROM information_schema.tables t
-                WHERE t.table_schema = DATABASE()
-                    AND t.table_type IN ('BASE TABLE', 'VIEW')
-                ORDER BY t.table_type, t.table_name
+                WHERE (t.table_schema = DATABASE()
+                        OR (? AND FIND_IN_SET(t.table_schema, ?) > 0))
+                    AND t.table_type IN ('BASE TABLE', 'VIEW', 'SYSTEM VIEW')
+                ORDER BY t.table_schema <> DATABASE(), t.table_schema, t.table_type, t.table_name
             ";
 
-            match sqlx::query(query).fetch_all(pool).await {
+            match sqlx::query(query)
+                .bind(include_system)
+                .bind(system_schemas(&DatabaseType::MySQL).join(","))
+                .fetch_all(pool)
+                .await
+            {
                 Ok(rows) => {
                     for row in rows {
+                        let schema: String = row.get("table_schema");
                         let name: String = row.get("table_name");
                         let table_type: String = row.get("table_type");
                         let comment: Option<String> = row.get("table_comment");
@@ -563,25 +652,22 @@ impl MySqlConnection {
                         // Convert MySQL table types to our enum
                         let object_type = match table_type.as_str() {
                             "BASE TABLE" => DatabaseObjectType::Table,
-                            "VIEW" => DatabaseObjectType::View,
+                            "VIEW" | "SYSTEM VIEW" => DatabaseObjectType::View,
                             _ => continue,
                         };
 
                         // Filter out empty comments
                         let comment = comment.filter(|c| !c.is_empty());
 
+                        let system = is_system_object(&DatabaseType::MySQL, Some(&schema), &name);
                         let obj = DatabaseObject {
                             name,
-                            schema: Some(
-                                self.config
-                                    .database
-                                    .clone()
-                                    .unwrap_or_else(|| "default".to_string()),
-                            ),
+                            schema: Some(schema),
                             object_type: object_type.clone(),
                             row_count,
                             size_bytes,
                             comment,
+                            system,
                         };
 
                         // Sort into appropriate lists
@@ -742,6 +828,191 @@ impl MySqlConnection {
         }
     }
 
+    /// Find columns whose name contains `pattern` (case-insensitive) in the
+    /// current database
+    pub async fn search_columns(&self, pattern: &str) -> Result<Vec<ColumnMatch>> {
+        if let Some(pool) = &self.pool {
+            let query = "SELECT
+                table_schema AS table_schema,
+                table_name AS table_name,
+                column_name AS column_name,
+                column_type AS data_type
+                FROM information_schema.columns
+                WHERE table_schema = DATABASE()
+                AND LOWER(column_name) LIKE LOWER(?)
+                ORDER BY table_name, ordinal_position";
+
+            let rows = sqlx::query(query)
+                .bind(like_contains(pattern))
+                .fetch_all(pool)
+                .await?;
+
+            Ok(rows
+                .iter()
+                .map(|row| {
+                    let schema: String = row.get("table_schema");
+                    let table: String = row.get("table_name");
+                    ColumnMatch {
+                        table: format!("{schema}.{table}"),
+                        column: row.get("column_name"),
+                        data_type: row.get("data_type"),
+                    }
+                })
+                .collect())
+        } else {
+            Err(LazyTablesError::Connection(
+                "Not connected to database".to_string(),
+            ))
+        }
+    }
+
+    /// List the indexes of a table with their columns in index order, the
+    /// primary key's first. Functional index parts show as `(expression)`
+    pub async fn get_table_indexes(&self, table_name: &str) -> Result<Vec<IndexInfo>> {
+        if let Some(pool) = &self.pool {
+            let (schema, actual_table_name) = match table_name.split_once('.') {
+                Some((schema, table)) => (Some(schema), table),
+                None => (None, table_name),
+            };
+
+            let query = "SELECT
+                index_name AS index_name,
+                CAST(non_unique AS SIGNED) AS non_unique,
+                index_type AS index_type,
+                column_name AS column_name
+                FROM information_schema.statistics
+                WHERE table_schema = COALESCE(?, DATABASE())
+                AND table_name = ?
+                ORDER BY index_name = 'PRIMARY' DESC, index_name, seq_in_index";
+
+            let rows = sqlx::query(query)
+                .bind(schema)
+                .bind(actual_table_name)
+                .fetch_all(pool)
+                .await?;
+
+            // One row per indexed column
+            let mut indexes: Vec<IndexInfo> = Vec::new();
+            for row in &rows {
+                let name: String = row.get("index_name");
+                let column = row
+                    .get::<Option<String>, _>("column_name")
+                    .unwrap_or_else(|| "(expression)".to_string());
+                match indexes.last_mut() {
+                    Some(index) if index.name == name => index.columns.push(column),
+                    _ => indexes.push(IndexInfo {
+                        is_primary: name == "PRIMARY",
+                        name,
+                        columns: vec![column],
+                        is_unique: row.get::<i64, _>("non_unique") == 0,
+                        index_type: Some(row.get("index_type")),
+                        size: None,
+                    }),
+                }
+            }
+            Ok(indexes)
+        } else {
+            Err(LazyTablesError::Connection(
+                "Not connected to database".to_string(),
+            ))
+        }
+    }
+
+    /// List the partitions of a partitioned table in definition order;
+    /// empty when the table isn't partitioned. Subpartitions aren't listed
+    pub async fn get_partitions(&self, table_name: &str) -> Result<Vec<PartitionInfo>> {
+        if let Some(pool) = &self.pool {
+            let (schema, actual_table_name) = match table_name.split_once('.') {
+                Some((schema, table)) => (Some(schema), table),
+                None => (None, table_name),
+            };
+
+            let query = "SELECT
+                partition_name AS partition_name,
+                partition_method AS partition_method,
+                partition_expression AS partition_expression,
+                partition_description AS partition_description,
+                table_rows AS table_rows
+                FROM information_schema.partitions
+                WHERE table_schema = COALESCE(?, DATABASE())
+                AND table_name = ?
+                AND partition_name IS NOT NULL
+                AND (subpartition_ordinal_position IS NULL OR subpartition_ordinal_position = 1)
+                ORDER BY partition_ordinal_position";
+
+            let rows = sqlx::query(query)
+                .bind(schema)
+                .bind(actual_table_name)
+                .fetch_all(pool)
+                .await?;
+
+            Ok(rows
+                .iter()
+                .map(|row| {
+                    let method: Option<String> = row.get("partition_method");
+                    let expression: Option<String> = row.get("partition_expression");
+                    let description: Option<String> = row.get("partition_description");
+                    PartitionInfo {
+                        name: row.get("partition_name"),
+                        schema: None,
+                        bound: mysql_partition_bound(
+                            method.as_deref().unwrap_or_default(),
+                            expression.as_deref(),
+                            description.as_deref(),
+                        ),
+                        row_estimate: row.get("table_rows"),
+                    }
+                })
+                .collect())
+        } else {
+            Err(LazyTablesError::Connection(
+                "Not connected to database".to_string(),
+            ))
+        }
+    }
+
+    /// List the stored functions and procedures of a schema with their
+    /// parameters and return types
+    pub async fn list_routines(&self, schema: &str) -> Result<Vec<RoutineInfo>> {
+        if let Some(pool) = &self.pool {
+            let query = "SELECT
+                r.routine_name AS routine_name,
+                r.routine_type AS routine_type,
+                CAST(r.dtd_identifier AS CHAR) AS returns,
+                (SELECT CAST(GROUP_CONCAT(
+                    CONCAT_WS(' ', p.parameter_mode, p.parameter_name, p.dtd_identifier)
+                    ORDER BY p.ordinal_position SEPARATOR ', ') AS CHAR)
+                    FROM information_schema.parameters p
+                    WHERE p.specific_schema = r.routine_schema
+                    AND p.specific_name = r.specific_name
+                    AND p.ordinal_position > 0) AS arguments
+                FROM information_schema.routines r
+                WHERE r.routine_schema = ?
+                ORDER BY r.routine_name";
+
+            let rows = sqlx::query(query).bind(schema).fetch_all(pool).await?;
+
+            Ok(rows
+                .iter()
+                .map(|row| {
+                    let routine_type: String = row.get("routine_type");
+                    let procedure = routine_type.eq_ignore_ascii_case("PROCEDURE");
+                    RoutineInfo {
+                        name: row.get("routine_name"),
+                        schema: schema.to_string(),
+                        procedure,
+                        arguments: row.get("arguments"),
+                        returns: if procedure { None } else { row.get("returns") },
+                    }
+                })
+                .collect())
+        } else {
+            Err(LazyTablesError::Connection(
+                "Not connected to database".to_string(),
+            ))
+        }
+    }
+
     /// Get column information for a table
     pub async fn get_table_columns(&self, table_name: &str) -> Result<Vec<TableColumn>> {
         if let Some(pool) = &self.pool {
@@ -750,7 +1021,10 @@ impl MySqlConnection {
                 data_type,
                 is_nullable,
                 column_default,
-                column_key
+                column_key,
+                extra,
+                generation_expression,
+                column_comment
                 FROM information_schema.columns
                 WHERE table_schema = DATABASE()
                 AND table_name = ?
@@ -766,6 +1040,9 @@ impl MySqlConnection {
                     let is_nullable: String = row.get("is_nullable");
                     let column_default: Option<String> = row.get("column_default");
                     let column_key: String = row.get("column_key");
+                    let extra: String = row.get("extra");
+                    let generation_expression: Option<String> = row.get("generation_expression");
+                    let comment: String = row.get("column_comment");
 
                     TableColumn {
                         name: column_name,
@@ -773,6 +1050,9 @@ impl MySqlConnection {
                         is_nullable: is_nullable == "YES",
                         default_value: column_default,
                         is_primary_key: column_key == "PRI",
+                        is_auto_increment: extra.to_ascii_lowercase().contains("auto_increment"),
+                        generated: parse_mysql_generated(&extra, generation_expression),
+                        comment: (!comment.is_empty()).then_some(comment),
                     }
                 })
                 .collect();
@@ -785,13 +1065,25 @@ impl MySqlConnection {
         }
     }
 
-    /// Get the row count for a table
-    pub async fn get_table_row_count(&self, table_name: &str) -> Result<usize> {
+    /// Get the row count for a table, counting only rows passing `filters`
+    pub async fn get_table_row_count(
+        &self,
+        table_name: &str,
+        filters: &[ColumnFilter],
+    ) -> Result<usize> {
         if let Some(pool) = &self.pool {
             // Validate and escape table name
             let safe_name = validate_mysql_identifier(table_name)?;
-            let query = format!("SELECT COUNT(*) FROM {}", safe_name);
-            let row = sqlx::query(&query).fetch_one(pool).await?;
+            let query = format!(
+                "SELECT COUNT(*) FROM {} {}",
+                safe_name,
+                preview::where_clause(&DatabaseType::MySQL, filters, None)
+            );
+            let mut statement = sqlx::query(&query);
+            for value in preview::filter_values(filters) {
+                statement = statement.bind(value);
+            }
+            let row = statement.fetch_one(pool).await?;
             let count: i64 = row.get(0);
             Ok(count as usize)
         } else {
@@ -801,12 +1093,13 @@ impl MySqlConnection {
         }
     }
 
-    /// Get table data with pagination
+    /// Get a page of table data
     pub async fn get_table_data(
         &self,
         table_name: &str,
         limit: usize,
-        offset: usize,
+        paging: &Paging,
+        filters: &[ColumnFilter],
     ) -> Result<Vec<Vec<String>>> {
         if let Some(pool) = &self.pool {
             // Get column names first to maintain order using parameterized query
@@ -832,19 +1125,28 @@ impl MySqlConnection {
             // Validate and escape table name for the SELECT query
             let safe_table_name = validate_mysql_identifier(table_name)?;
 
-            // Build SELECT query with all columns - validate each column name too
+            // Build SELECT query with all columns - validate each column name
+            // too - read as text and cut to the preview cell limit
             let select_list = column_names
                 .iter()
                 .filter_map(|col| validate_mysql_identifier(col).ok())
+                .map(|col| {
+                    preview::preview_column(&DatabaseType::MySQL, &col, self.preview_cell_chars)
+                })
                 .collect::<Vec<_>>()
                 .join(", ");
 
             let query = format!(
-                "SELECT {select_list} FROM {} LIMIT {} OFFSET {}",
-                safe_table_name, limit, offset
+                "SELECT {select_list} FROM {} {}",
+                safe_table_name,
+                paging.clauses(&DatabaseType::MySQL, filters, limit)
             );
 
-            let rows = sqlx::query(&query).fetch_all(pool).await?;
+            let mut statement = sqlx::query(&query);
+            for value in preview::filter_values(filters) {
+                statement = statement.bind(value);
+            }
+            let rows = statement.fetch_all(pool).await?;
 
             let mut result = Vec::new();
             for row in rows {
@@ -852,10 +1154,16 @@ impl MySqlConnection {
                 for (idx, _col_name) in column_names.iter().enumerate() {
                     // Try to get the value as string, handle NULL values
                     let value: Option<String> = row.try_get(idx).ok();
-                    row_data.push(value.unwrap_or_else(|| "NULL".to_string()));
+                    row_data.push(match value {
+                        Some(value) => preview::mark_partial(value, self.preview_cell_chars),
+                        None => "NULL".to_string(),
+                    });
                 }
                 result.push(row_data);
             }
+            if paging.reads_backwards() {
+                result.reverse();
+            }
 
             Ok(result)
         } else {
@@ -942,11 +1250,183 @@ impl MySqlConnection {
         }
     }
 
-    /// Execute a raw SQL query and return columns and rows
+    /// Execute a raw SQL query, passing rows to the sink as they are fetched.
+    /// The text protocol runs multi-statement queries and stored procedures
+    /// whole: every result set with rows reaches the sink, the ones after the
+    /// first announced by `next_result_set`. Runs on the session connection,
+    /// so `SET` and the like carry over to the next query; the warnings of
+    /// the last statement follow the rows
+    pub async fn stream_raw_query(
+        &self,
+        query: &str,
+        sink: &mut dyn crate::database::RowSink,
+    ) -> Result<usize> {
+        let pool = self
+            .pool
+            .as_ref()
+            .ok_or_else(|| LazyTablesError::Connection("Not connected to database".to_string()))?;
+
+        let mut connection = self.session.lock(pool).await?;
+        if connection.server_id() == 0 {
+            // Read once per session, for cancelling its statements
+            match sqlx::query_scalar::<_, u64>("SELECT CONNECTION_ID()")
+                .fetch_one(&mut *connection)
+                .await
+            {
+                Ok(id) => connection.set_server_id(id as i64),
+                Err(e) => crate::log_warn!("Failed to read the session's connection id: {}", e),
+            }
+        }
+
+        let result: Result<usize> = async {
+            let start = std::time::Instant::now();
+            let mut results = sqlx::raw_sql(query).fetch_many(&mut *connection);
+            let mut count = 0;
+            let mut result_sets = 0;
+            let mut set_rows = 0;
+            while let Some(item) = results.try_next().await? {
+                let row = match item {
+                    // End of a statement's result set
+                    Either::Left(done) => {
+                        if set_rows == 0 {
+                            sink.affected_rows(done.rows_affected())?;
+                        }
+                        set_rows = 0;
+                        continue;
+                    }
+                    Either::Right(row) => row,
+                };
+                if set_rows == 0 {
+                    if result_sets > 0 {
+                        sink.next_result_set()?;
+                        if sink.is_full() {
+                            break;
+                        }
+                    }
+                    result_sets += 1;
+                    let column_names: Vec<String> = row
+                        .columns()
+                        .iter()
+                        .map(|col| col.name().to_string())
+                        .collect();
+                    sink.columns(&column_names)?;
+                }
+                let values = row
+                    .columns()
+                    .iter()
+                    .map(|col| self.extract_value(&row, col))
+                    .collect();
+                sink.row(values)?;
+                set_rows += 1;
+                count += 1;
+                if sink.is_full() {
+                    break;
+                }
+            }
+            sink.elapsed(start.elapsed())?;
+
+            if count == 0 {
+                // No row to take the names from; preparing the query names
+                // them without running it again, so an empty result keeps its
+                // header. Several statements can't be prepared and get none
+                drop(results);
+                let columns: Vec<String> =
+                    match sqlx::Executor::prepare(&mut *connection, query).await {
+                        Ok(statement) => statement
+                            .columns()
+                            .iter()
+                            .map(|col| col.name().to_string())
+                            .collect(),
+                        Err(_) => Vec::new(),
+                    };
+                sink.columns(&columns)?;
+            }
+            Ok(count)
+        }
+        .await;
+
+        if result.is_err() {
+            connection.discard_if_broken().await;
+            return result;
+        }
+        // Reading warnings would mean draining the rows left unread first
+        if !sink.is_full() {
+            sink.notices(Self::read_warnings(&mut connection).await)?;
+        }
+        result
+    }
+
+    /// Warnings left by the last statement run on `connection`. Failing to
+    /// read them only costs the warnings, so errors are logged and dropped
+    async fn read_warnings(connection: &mut sqlx::MySqlConnection) -> Vec<ServerNotice> {
+        let rows = match sqlx::raw_sql("SHOW WARNINGS")
+            .fetch_all(&mut *connection)
+            .await
+        {
+            Ok(rows) => rows,
+            Err(e) => {
+                crate::log_warn!("Failed to read MySQL warnings: {}", e);
+                return Vec::new();
+            }
+        };
+        rows.iter()
+            .map(|row| ServerNotice {
+                severity: row
+                    .try_get("Level")
+                    .unwrap_or_else(|_| "Warning".to_string()),
+                code: row
+                    .try_get::<u32, _>("Code")
+                    .ok()
+                    .map(|code| code.to_string()),
+                message: row.try_get("Message").unwrap_or_default(),
+            })
+            .collect()
+    }
+
+    /// A result cell as text. Date and time types and JSON are decoded as
+    /// such, with `TIMESTAMP` values, which the server reports in the
+    /// session's zone, shown in the display zone; everything else is read as
+    /// a string
+    fn extract_value(&self, row: &MySqlRow, col: &MySqlColumn) -> String {
+        let ordinal = col.ordinal();
+        let value = match col.type_info().name() {
+            "TIMESTAMP" => row
+                .try_get::<Option<chrono::NaiveDateTime>, _>(ordinal)
+                .ok()
+                .map(|v| v.map(|v| self.time_zone.format_session_time(v, self.session_offset))),
+            "DATETIME" => row
+                .try_get::<Option<chrono::NaiveDateTime>, _>(ordinal)
+                .ok()
+                .map(|v| v.map(|v| v.to_string())),
+            "DATE" => row
+                .try_get::<Option<chrono::NaiveDate>, _>(ordinal)
+                .ok()
+                .map(|v| v.map(|v| v.to_string())),
+            "JSON" => row
+                .try_get::<Option<serde_json::Value>, _>(ordinal)
+                .ok()
+                .map(|v| v.map(|v| v.to_string())),
+            _ => None,
+        };
+        value
+            .unwrap_or_else(|| row.try_get::<Option<String>, _>(ordinal).ok().flatten())
+            .unwrap_or_else(|| "NULL".to_string())
+    }
+
+    /// Execute a raw SQL query on the session connection and return columns
+    /// and rows
     pub async fn execute_raw_query(&self, query: &str) -> Result<(Vec<String>, Vec<Vec<String>>)> {
         if let Some(pool) = &self.pool {
             // Try to execute the query
-            let rows = sqlx::query(query).fetch_all(pool).await?;
+            let mut connection = self.session.lock(pool).await?;
+            let rows = match sqlx::query(query).fetch_all(&mut *connection).await {
+                Ok(rows) => rows,
+                Err(e) => {
+                    connection.discard_if_broken().await;
+                    return Err(e.into());
+                }
+            };
+            drop(connection);
 
             if rows.is_empty() {
                 return Ok((Vec::new(), Vec::new()));
@@ -964,9 +1444,7 @@ impl MySqlConnection {
             for row in &rows {
                 let mut row_data = Vec::new();
                 for col in columns {
-                    // Try to get value as string
-                    let value: Option<String> = row.try_get(col.ordinal()).ok();
-                    row_data.push(value.unwrap_or_else(|| "NULL".to_string()));
+                    row_data.push(self.extract_value(row, col));
                 }
                 result_rows.push(row_data);
             }
@@ -978,6 +1456,32 @@ impl MySqlConnection {
             ))
         }
     }
+
+    /// Insert a row on the session connection. MySQL has no `RETURNING`, so
+    /// nothing comes back
+    pub async fn insert_row(
+        &self,
+        table_name: &str,
+        values: &[InsertValue],
+    ) -> Result<(Vec<String>, Vec<Vec<String>>)> {
+        let Some(pool) = &self.pool else {
+            return Err(LazyTablesError::Connection(
+                "Not connected to database".to_string(),
+            ));
+        };
+        let query = insert::insert_query(&DatabaseType::MySQL, table_name, values);
+        let mut statement = sqlx::query(&query);
+        for param in insert::insert_params(&DatabaseType::MySQL, values) {
+            statement = statement.bind(param);
+        }
+
+        let mut connection = self.session.lock(pool).await?;
+        if let Err(e) = statement.execute(&mut *connection).await {
+            connection.discard_if_broken().await;
+            return Err(e.into());
+        }
+        Ok((Vec::new(), Vec::new()))
+    }
 }
 
 /// Validate and escape MySQL identifiers to prevent SQL injection
@@ -990,10 +1494,7 @@ fn validate_mysql_identifier(name: &str) -> Result<String> {
         ));
     }
 
-    // MySQL allows most characters in identifiers when properly quoted with backticks
-    // We escape any embedded backticks by doubling them
-    let escaped = name.replace('`', "``");
-    Ok(format!("`{}`", escaped))
+    Ok(quote_ident(&DatabaseType::MySQL, &[name]))
 }
 
 fn parse_mysql_type(type_str: &str) -> DataType {
@@ -1019,6 +1520,46 @@ fn parse_mysql_type(type_str: &str) -> DataType {
     }
 }
 
+/// Bound of a partition from information_schema.partitions, e.g.
+/// `VALUES LESS THAN (2025)` for RANGE and `VALUES IN (1,2)` for LIST.
+/// HASH and KEY partitions have no bound, so the expression is shown instead
+fn mysql_partition_bound(
+    method: &str,
+    expression: Option<&str>,
+    description: Option<&str>,
+) -> Option<String> {
+    let method = method.to_ascii_uppercase();
+    match description {
+        Some("MAXVALUE") if method.starts_with("RANGE") => {
+            Some("VALUES LESS THAN MAXVALUE".to_string())
+        }
+        Some(values) if method.starts_with("RANGE") => Some(format!("VALUES LESS THAN ({values})")),
+        Some(values) if method.starts_with("LIST") => Some(format!("VALUES IN ({values})")),
+        _ => expression.map(|expression| format!("{method} ({expression})")),
+    }
+}
+
+/// Generated column from the `extra` column of information_schema.columns:
+/// "VIRTUAL GENERATED" or "STORED GENERATED" (older MariaDB says "VIRTUAL"
+/// and "PERSISTENT"). "DEFAULT_GENERATED" only marks an expression default
+fn parse_mysql_generated(
+    extra: &str,
+    generation_expression: Option<String>,
+) -> Option<GeneratedColumn> {
+    let extra = extra.to_ascii_uppercase();
+    let stored = if extra.contains("STORED GENERATED") || extra.contains("PERSISTENT") {
+        true
+    } else if extra.contains("VIRTUAL") {
+        false
+    } else {
+        return None;
+    };
+    Some(GeneratedColumn {
+        stored,
+        expression: generation_expression.filter(|expression| !expression.is_empty()),
+    })
+}
+
 /// Parse MySQL data type string to internal DataType enum
 #[cfg(test)]
 mod tests {
@@ -1041,7 +1582,55 @@ mod tests {
     }
 
     #[test]
-    fn test_build_connection_string_with_password() {
+    fn test_partition_bounds() {
+        assert_eq!(
+            mysql_partition_bound("RANGE", Some("year(`created_at`)"), Some("2025")).as_deref(),
+            Some("VALUES LESS THAN (2025)")
+        );
+        assert_eq!(
+            mysql_partition_bound("RANGE COLUMNS", Some("`day`"), Some("MAXVALUE")).as_deref(),
+            Some("VALUES LESS THAN MAXVALUE")
+        );
+        assert_eq!(
+            mysql_partition_bound("LIST", Some("`region`"), Some("1,2,3")).as_deref(),
+            Some("VALUES IN (1,2,3)")
+        );
+        assert_eq!(
+            mysql_partition_bound("LINEAR HASH", Some("`id`"), None).as_deref(),
+            Some("LINEAR HASH (`id`)")
+        );
+        assert_eq!(mysql_partition_bound("KEY", None, None), None);
+    }
+
+    #[test]
+    fn test_generated_columns_from_extra() {
+        assert_eq!(
+            parse_mysql_generated("STORED GENERATED", Some("(`price` * `qty`)".to_string())),
+            Some(GeneratedColumn {
+                stored: true,
+                expression: Some("(`price` * `qty`)".to_string()),
+            })
+        );
+        assert_eq!(
+            parse_mysql_generated("VIRTUAL GENERATED", Some(String::new())),
+            Some(GeneratedColumn {
+                stored: false,
+                expression: None,
+            })
+        );
+        assert!(parse_mysql_generated("PERSISTENT", None).is_some_and(|g| g.stored));
+        assert_eq!(
+            parse_mysql_generated("auto_increment", Some(String::new())),
+            None
+        );
+        assert_eq!(
+            parse_mysql_generated("DEFAULT_GENERATED on update CURRENT_TIMESTAMP", None),
+            None
+        );
+    }
+
+    #[test]
+    fn test_connect_options_with_password() {
         let mut config = ConnectionConfig::new(
             "test".to_string(),
             DatabaseType::MySQL,
@@ -1053,16 +1642,20 @@ mod tests {
         config.database = Some("testdb".to_string());
 
         let connection = MySqlConnection::new(config);
-        let conn_str = connection.build_connection_string(None).unwrap();
-
-        assert!(conn_str.contains("mysql://"));
-        assert!(conn_str.contains("root:password123@"));
-        assert!(conn_str.contains("localhost:3306"));
-        assert!(conn_str.contains("/testdb"));
+        let options = connection.connect_options(None);
+
+        assert_eq!(options.get_host(), "localhost");
+        assert_eq!(options.get_port(), 3306);
+        assert_eq!(options.get_username(), "root");
+        assert_eq!(options.get_database(), Some("testdb"));
+        assert_eq!(
+            connection.connect_password(None).as_deref(),
+            Some("password123")
+        );
     }
 
     #[test]
-    fn test_build_connection_string_without_password() {
+    fn test_connect_options_without_password() {
         let config = ConnectionConfig::new(
             "test".to_string(),
             DatabaseType::MySQL,
@@ -1072,11 +1665,27 @@ mod tests {
         );
 
         let connection = MySqlConnection::new(config);
-        let conn_str = connection.build_connection_string(None).unwrap();
+        let options = connection.connect_options(None);
 
-        assert!(conn_str.contains("mysql://"));
-        assert!(conn_str.contains("root@"));
-        assert!(!conn_str.contains(":@")); // No empty password
+        assert_eq!(options.get_username(), "root");
+        assert_eq!(options.get_database(), Some("mysql"));
+        assert_eq!(connection.connect_password(None), None);
+    }
+
+    #[test]
+    fn test_connect_options_are_never_debug_formatted() {
+        // Their Debug output shows the password in clear
+        let debug = [":", "?"].concat();
+        let field = ["?", "options"].concat();
+        for source in [include_str!("mysql.rs"), include_str!("postgres.rs")] {
+            for line in source.lines().filter(|line| line.contains("options")) {
+                assert!(
+                    !line.contains(&debug) && !line.contains(&field),
+                    "connect options formatted with Debug: {}",
+                    line.trim()
+                );
+            }
+        }
     }
 
     #[test]
@@ -1200,13 +1809,30 @@ impl crate::database::connection_manager::ManagedConnection for MySqlConnection
         MySqlConnection::execute_raw_query(self, query).await
     }
 
+    async fn stream_raw_query(
+        &self,
+        query: &str,
+        sink: &mut dyn crate::database::RowSink,
+    ) -> Result<usize> {
+        MySqlConnection::stream_raw_query(self, query, sink).await
+    }
+
     async fn get_table_data(
         &self,
         table_name: &str,
         limit: usize,
-        offset: usize,
+        paging: &Paging,
+        filters: &[ColumnFilter],
     ) -> Result<Vec<Vec<String>>> {
-        MySqlConnection::get_table_data(self, table_name, limit, offset).await
+        MySqlConnection::get_table_data(self, table_name, limit, paging, filters).await
+    }
+
+    async fn get_table_row_count(
+        &self,
+        table_name: &str,
+        filters: &[ColumnFilter],
+    ) -> Result<usize> {
+        MySqlConnection::get_table_row_count(self, table_name, filters).await
     }
 
     async fn get_table_columns(
@@ -1220,13 +1846,43 @@ impl crate::database::connection_manager::ManagedConnection for MySqlConnection
         MySqlConnection::get_table_metadata(self, table_name).await
     }
 
-    async fn list_database_objects(&self) -> Result<crate::database::DatabaseObjectList> {
-        MySqlConnection::list_database_objects(self).await
+    async fn list_database_objects(
+        &self,
+        include_system: bool,
+    ) -> Result<crate::database::DatabaseObjectList> {
+        MySqlConnection::list_database_objects(self, include_system).await
+    }
+
+    async fn search_columns(&self, pattern: &str) -> Result<Vec<ColumnMatch>> {
+        MySqlConnection::search_columns(self, pattern).await
+    }
+
+    async fn get_partitions(&self, table_name: &str) -> Result<Vec<PartitionInfo>> {
+        MySqlConnection::get_partitions(self, table_name).await
+    }
+
+    async fn get_table_indexes(&self, table_name: &str) -> Result<Vec<IndexInfo>> {
+        MySqlConnection::get_table_indexes(self, table_name).await
+    }
+
+    async fn list_routines(&self, schema: &str) -> Result<Vec<RoutineInfo>> {
+        MySqlConnection::list_routines(self, schema).await
     }
 
     fn is_connected(&self) -> bool {
         Connection::is_connected(self)
     }
+
+    fn query_canceller(&self) -> Option<QueryCanceller> {
+        Some(QueryCanceller::MySql {
+            pool: self.pool.clone()?,
+            connection_id: self.session.server_id(),
+        })
+    }
+
+    async fn close(&mut self) -> Result<()> {
+        Connection::disconnect(self).await
+    }
 }
 
 // Drop implementation removed - connection pools are closed explicitly via disconnect() method
@@ -0,0 +1,178 @@
+// FilePath: src/database/notices.rs
+
+//! Notices and warnings the server sends alongside a result
+//!
+//! PostgreSQL delivers `RAISE NOTICE` output and warnings asynchronously on
+//! the connection; sqlx hands them to `tracing` under `NOTICE_TARGET` while
+//! the query that caused them is being read. `NoticeLayer` picks those events
+//! up for the task running inside `capture`, so each query gets its own
+//! notices no matter how many run at once. MySQL keeps its warnings for
+//! `SHOW WARNINGS`, which the adapter reads itself.
+
+#![forbid(unsafe_code)]
+
+use std::cell::RefCell;
+use std::fmt;
+use std::future::Future;
+use tracing::{Level, Subscriber};
+use tracing_subscriber::{filter::Targets, registry::LookupSpan, Layer};
+
+/// Target sqlx logs PostgreSQL notices under
+pub const NOTICE_TARGET: &str = "sqlx::postgres::notice";
+
+/// A notice or warning the server sent while running a query
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct ServerNotice {
+    /// `WARNING`, `NOTICE`, `Note` and the like, as the server names it
+    pub severity: String,
+    /// Server error code, when the server gives one
+    pub code: Option<String>,
+    pub message: String,
+}
+
+impl ServerNotice {
+    pub fn new(severity: impl Into<String>, message: impl Into<String>) -> Self {
+        Self {
+            severity: severity.into(),
+            code: None,
+            message: message.into(),
+        }
+    }
+
+    /// Whether the server flagged this as a warning rather than information
+    pub fn is_warning(&self) -> bool {
+        let severity = self.severity.to_ascii_uppercase();
+        severity.starts_with("WARN") || severity == "ERROR"
+    }
+}
+
+impl fmt::Display for ServerNotice {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match &self.code {
+            Some(code) => write!(f, "{} {}: {}", self.severity, code, self.message),
+            None => write!(f, "{}: {}", self.severity, self.message),
+        }
+    }
+}
+
+tokio::task_local! {
+    /// Notices collected for the query running on this task
+    static CAPTURED: RefCell<Vec<ServerNotice>>;
+}
+
+/// Run `future`, returning its output along with the PostgreSQL notices
+/// received while it ran
+pub async fn capture<F: Future>(future: F) -> (F::Output, Vec<ServerNotice>) {
+    CAPTURED
+        .scope(RefCell::new(Vec::new()), async move {
+            let output = future.await;
+            let notices = CAPTURED.with(|captured| captured.take());
+            (output, notices)
+        })
+        .await
+}
+
+/// Layer handing sqlx's notice events to the query capturing them. Events
+/// outside `capture` are left to the other layers
+pub struct NoticeLayer;
+
+impl NoticeLayer {
+    /// The layer with a filter of its own, so notices are seen whatever the
+    /// log level
+    pub fn filtered<S>() -> impl Layer<S>
+    where
+        S: Subscriber + for<'a> LookupSpan<'a>,
+    {
+        NoticeLayer.with_filter(Targets::new().with_target(NOTICE_TARGET, Level::TRACE))
+    }
+}
+
+impl<S: Subscriber> Layer<S> for NoticeLayer {
+    fn on_event(
+        &self,
+        event: &tracing::Event<'_>,
+        _ctx: tracing_subscriber::layer::Context<'_, S>,
+    ) {
+        if event.metadata().target() != NOTICE_TARGET {
+            return;
+        }
+        let mut visitor = MessageVisitor::default();
+        event.record(&mut visitor);
+        // sqlx maps the notice severity onto the event level
+        let severity = match *event.metadata().level() {
+            Level::ERROR => "ERROR",
+            Level::WARN => "WARNING",
+            Level::INFO => "NOTICE",
+            Level::DEBUG => "DEBUG",
+            Level::TRACE => "LOG",
+        };
+        let notice = ServerNotice::new(severity, visitor.message);
+        let _ = CAPTURED.try_with(|captured| captured.borrow_mut().push(notice));
+    }
+}
+
+/// Reads the `message` field of a notice event
+#[derive(Default)]
+struct MessageVisitor {
+    message: String,
+}
+
+impl tracing::field::Visit for MessageVisitor {
+    fn record_str(&mut self, field: &tracing::field::Field, value: &str) {
+        if field.name() == "message" {
+            self.message = value.to_string();
+        }
+    }
+
+    fn record_debug(&mut self, field: &tracing::field::Field, value: &dyn fmt::Debug) {
+        if field.name() == "message" {
+            self.message = format!("{:?}", value);
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use tracing_subscriber::prelude::*;
+
+    #[tokio::test]
+    async fn test_capture_collects_notices_of_its_task() {
+        let subscriber = tracing_subscriber::registry().with(NoticeLayer::filtered());
+        let _guard = tracing::subscriber::set_default(subscriber);
+
+        tracing::warn!(target: NOTICE_TARGET, message = "not captured");
+        let (output, notices) = capture(async {
+            tracing::info!(target: NOTICE_TARGET, message = "rows cleaned up");
+            tracing::warn!(target: NOTICE_TARGET, message = "value truncated");
+            tracing::warn!(target: "lazytables", message = "unrelated");
+            42
+        })
+        .await;
+
+        assert_eq!(output, 42);
+        assert_eq!(
+            notices,
+            vec![
+                ServerNotice::new("NOTICE", "rows cleaned up"),
+                ServerNotice::new("WARNING", "value truncated"),
+            ]
+        );
+        assert!(!notices[0].is_warning());
+        assert!(notices[1].is_warning());
+    }
+
+    #[test]
+    fn test_notice_display() {
+        let mut notice = ServerNotice::new("Warning", "Data truncated for column 'name'");
+        assert_eq!(
+            notice.to_string(),
+            "Warning: Data truncated for column 'name'"
+        );
+        notice.code = Some("1265".to_string());
+        assert_eq!(
+            notice.to_string(),
+            "Warning 1265: Data truncated for column 'name'"
+        );
+    }
+}
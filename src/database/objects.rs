@@ -2,8 +2,32 @@
 
 #![forbid(unsafe_code)]
 
+use super::DatabaseType;
 use serde::{Deserialize, Serialize};
 
+/// Prefix of SQLite's internal tables (sqlite_sequence, sqlite_stat1, ...)
+pub const SQLITE_SYSTEM_TABLE_PREFIX: &str = "sqlite_";
+
+/// Schemas (databases, on MySQL) holding the server's own catalogs, left out
+/// of listings unless system objects are shown
+pub fn system_schemas(database_type: &DatabaseType) -> &'static [&'static str] {
+    match database_type {
+        DatabaseType::PostgreSQL => &["pg_catalog", "information_schema"],
+        DatabaseType::MySQL | DatabaseType::MariaDB => {
+            &["information_schema", "mysql", "performance_schema", "sys"]
+        }
+        _ => &[],
+    }
+}
+
+/// Whether an object belongs to the database server rather than the user
+pub fn is_system_object(database_type: &DatabaseType, schema: Option<&str>, name: &str) -> bool {
+    match database_type {
+        DatabaseType::SQLite => name.starts_with(SQLITE_SYSTEM_TABLE_PREFIX),
+        _ => schema.is_some_and(|schema| system_schemas(database_type).contains(&schema)),
+    }
+}
+
 /// Type of database object
 #[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
 pub enum DatabaseObjectType {
@@ -47,6 +71,9 @@ pub struct DatabaseObject {
     pub row_count: Option<i64>,
     pub size_bytes: Option<i64>,
     pub comment: Option<String>,
+    /// Catalog or internal object, listed only when system objects are shown
+    #[serde(default)]
+    pub system: bool,
 }
 
 impl DatabaseObject {
@@ -60,13 +87,18 @@ impl DatabaseObject {
         self.name.clone()
     }
 
+    /// Name as listed for a connection whose default schema is
+    /// `default_schema`: bare inside it, schema-qualified elsewhere
+    pub fn name_in(&self, default_schema: &str) -> String {
+        match &self.schema {
+            Some(schema) if schema != default_schema => format!("{}.{}", schema, self.name),
+            _ => self.name.clone(),
+        }
+    }
+
     /// Check if this is a system object
     pub fn is_system(&self) -> bool {
-        matches!(self.object_type, DatabaseObjectType::SystemTable)
-            || self.name.starts_with("pg_")
-            || self.name.starts_with("information_schema")
-            || self.schema.as_deref() == Some("pg_catalog")
-            || self.schema.as_deref() == Some("information_schema")
+        self.system || matches!(self.object_type, DatabaseObjectType::SystemTable)
     }
 }
 
@@ -79,6 +111,8 @@ pub struct DatabaseObjectList {
     pub foreign_tables: Vec<DatabaseObject>,
     pub total_count: usize,
     pub error: Option<String>,
+    /// Schema unqualified names resolve to, first in each list (PostgreSQL)
+    pub default_schema: Option<String>,
 }
 
 impl DatabaseObjectList {
@@ -101,6 +135,21 @@ impl DatabaseObjectList {
             .collect()
     }
 
+    /// Name to open an object by: bare inside the default schema (`public`
+    /// unless the connection sets a search path), schema-qualified elsewhere
+    pub fn open_name(&self, object: &DatabaseObject) -> String {
+        object.name_in(self.default_schema.as_deref().unwrap_or("public"))
+    }
+
+    /// Name the Tables pane lists an object under: schema-qualified outside
+    /// the default schema when the adapter reports one, bare otherwise
+    pub fn listed_name(&self, object: &DatabaseObject) -> String {
+        match &self.default_schema {
+            Some(default_schema) => object.name_in(default_schema),
+            None => object.name.clone(),
+        }
+    }
+
     /// Check if the list is empty
     pub fn is_empty(&self) -> bool {
         self.tables.is_empty()
@@ -109,3 +158,156 @@ impl DatabaseObjectList {
             && self.foreign_tables.is_empty()
     }
 }
+
+/// A function or procedure of a schema
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct RoutineInfo {
+    pub name: String,
+    pub schema: String,
+    /// Set for a procedure, which returns nothing
+    pub procedure: bool,
+    /// Argument list, e.g. `customer_id integer, since date` (PostgreSQL)
+    pub arguments: Option<String>,
+    /// Return type of a function
+    pub returns: Option<String>,
+}
+
+impl RoutineInfo {
+    /// Name with its arguments and return type, e.g.
+    /// `order_total(order_id integer) → numeric`
+    pub fn signature(&self) -> String {
+        let mut signature = format!(
+            "{}({})",
+            self.name,
+            self.arguments.as_deref().unwrap_or_default()
+        );
+        if let Some(returns) = &self.returns {
+            signature.push_str(&format!(" → {returns}"));
+        }
+        signature
+    }
+}
+
+/// A column found by a schema-wide column search
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct ColumnMatch {
+    /// Table name as the Tables pane qualifies it (schema.table)
+    pub table: String,
+    pub column: String,
+    pub data_type: String,
+}
+
+/// LIKE pattern matching column names that contain `pattern`, with `%`, `_`
+/// and the escape character itself escaped by a backslash
+pub fn like_contains(pattern: &str) -> String {
+    let mut escaped = String::with_capacity(pattern.len() + 2);
+    escaped.push('%');
+    for ch in pattern.chars() {
+        if matches!(ch, '\\' | '%' | '_') {
+            escaped.push('\\');
+        }
+        escaped.push(ch);
+    }
+    escaped.push('%');
+    escaped
+}
+
+/// An object an adapter error reports as gone, e.g. because someone else
+/// dropped it while it was being browsed
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum MissingObject {
+    Database,
+    Table,
+}
+
+impl MissingObject {
+    /// Recognize the "does not exist" errors of PostgreSQL, MySQL and SQLite
+    pub fn from_error(error: &str) -> Option<Self> {
+        let error = error.to_lowercase();
+        if error.contains("unknown database")
+            || (error.contains("database \"") && error.contains("does not exist"))
+            || error.contains("unable to open database file")
+        {
+            Some(Self::Database)
+        } else if error.contains("no such table")
+            || (error.contains("relation \"") && error.contains("does not exist"))
+            || (error.contains("table '") && error.contains("doesn't exist"))
+        {
+            Some(Self::Table)
+        } else {
+            None
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_missing_object_from_adapter_errors() {
+        for error in [
+            r#"error returned from database: database "shop_test" does not exist"#,
+            "error returned from database: 1049 (42000): Unknown database 'shop_test'",
+            "error returned from database: (code: 14) unable to open database file",
+        ] {
+            assert_eq!(
+                MissingObject::from_error(error),
+                Some(MissingObject::Database)
+            );
+        }
+        for error in [
+            r#"error returned from database: relation "public.orders" does not exist"#,
+            "error returned from database: 1146 (42S02): Table 'shop.orders' doesn't exist",
+            "error returned from database: (code: 1) no such table: orders",
+        ] {
+            assert_eq!(MissingObject::from_error(error), Some(MissingObject::Table));
+        }
+        assert_eq!(MissingObject::from_error("connection refused"), None);
+        assert_eq!(
+            MissingObject::from_error(r#"column "total" does not exist"#),
+            None
+        );
+    }
+
+    #[test]
+    fn test_system_objects_per_database() {
+        let pg = DatabaseType::PostgreSQL;
+        assert!(is_system_object(&pg, Some("pg_catalog"), "pg_class"));
+        assert!(is_system_object(&pg, Some("information_schema"), "tables"));
+        assert!(!is_system_object(&pg, Some("public"), "pg_stats_archive"));
+        assert!(!is_system_object(&pg, Some("sys"), "audit"));
+
+        let mysql = DatabaseType::MySQL;
+        for schema in ["information_schema", "mysql", "performance_schema", "sys"] {
+            assert!(is_system_object(&mysql, Some(schema), "t"));
+        }
+        assert!(!is_system_object(&mysql, Some("shop"), "orders"));
+
+        let sqlite = DatabaseType::SQLite;
+        assert!(is_system_object(&sqlite, Some("main"), "sqlite_sequence"));
+        assert!(!is_system_object(&sqlite, Some("main"), "orders"));
+    }
+
+    #[test]
+    fn test_name_in_default_schema() {
+        let object = |schema: &str| DatabaseObject {
+            name: "orders".to_string(),
+            schema: Some(schema.to_string()),
+            object_type: DatabaseObjectType::Table,
+            row_count: None,
+            size_bytes: None,
+            comment: None,
+            system: false,
+        };
+        assert_eq!(object("app").name_in("app"), "orders");
+        assert_eq!(object("public").name_in("app"), "public.orders");
+    }
+
+    #[test]
+    fn test_like_contains_escapes_wildcards() {
+        assert_eq!(like_contains("customer_id"), "%customer\\_id%");
+        assert_eq!(like_contains("100%"), "%100\\%%");
+        assert_eq!(like_contains("a\\b"), "%a\\\\b%");
+    }
+}
@@ -0,0 +1,90 @@
+// FilePath: src/database/partitions.rs
+
+//! Partitions of partitioned tables
+//!
+//! PostgreSQL declarative partitions are tables of their own; MySQL partitions
+//! are parts of one table, read with a `PARTITION (...)` clause.
+
+#![forbid(unsafe_code)]
+
+use super::{quote_ident, DatabaseType};
+
+/// One partition of a partitioned table
+#[derive(Debug, Clone, PartialEq)]
+pub struct PartitionInfo {
+    pub name: String,
+    /// Schema of the partition's table (PostgreSQL)
+    pub schema: Option<String>,
+    /// Bound or expression, e.g. `FOR VALUES FROM ('2024-01-01') TO ('2024-02-01')`
+    pub bound: Option<String>,
+    /// The database's row estimate, when the partition has statistics
+    pub row_estimate: Option<u64>,
+}
+
+/// Statement reading the first `limit` rows of one partition of `table_name`
+pub fn partition_preview_sql(
+    database_type: &DatabaseType,
+    table_name: &str,
+    partition: &PartitionInfo,
+    limit: usize,
+) -> String {
+    match database_type {
+        DatabaseType::MySQL | DatabaseType::MariaDB => format!(
+            "SELECT * FROM {} PARTITION ({}) LIMIT {limit}",
+            quote_ident(database_type, &table_name.split('.').collect::<Vec<_>>()),
+            quote_ident(database_type, &[&partition.name]),
+        ),
+        _ => {
+            let schema = partition.schema.as_deref().unwrap_or("public");
+            format!(
+                "SELECT * FROM {} LIMIT {limit}",
+                quote_ident(database_type, &[schema, &partition.name])
+            )
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn partition(name: &str, schema: Option<&str>) -> PartitionInfo {
+        PartitionInfo {
+            name: name.to_string(),
+            schema: schema.map(str::to_string),
+            bound: None,
+            row_estimate: None,
+        }
+    }
+
+    #[test]
+    fn test_partition_preview_sql() {
+        assert_eq!(
+            partition_preview_sql(
+                &DatabaseType::PostgreSQL,
+                "events",
+                &partition("events_2024_01", Some("analytics")),
+                100
+            ),
+            r#"SELECT * FROM "analytics"."events_2024_01" LIMIT 100"#
+        );
+        assert_eq!(
+            partition_preview_sql(
+                &DatabaseType::PostgreSQL,
+                "events",
+                &partition("events_default", None),
+                20
+            ),
+            r#"SELECT * FROM "public"."events_default" LIMIT 20"#
+        );
+        assert_eq!(
+            partition_preview_sql(
+                &DatabaseType::MySQL,
+                "events",
+                &partition("p2024", None),
+                100
+            ),
+            "SELECT * FROM `events` PARTITION (`p2024`) LIMIT 100"
+        );
+    }
+}
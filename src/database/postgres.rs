@@ -3,13 +3,21 @@
 #![forbid(unsafe_code)]
 
 use crate::core::error::{LazyTablesError, Result};
+use crate::database::insert::{self, InsertValue};
+use crate::database::preview::{self, ColumnFilter, Paging, DEFAULT_PREVIEW_CELL_CHARS};
+use crate::database::transaction::{TransactionControl, SAVEPOINT_RECOVERED, STATEMENT_SAVEPOINT};
 use crate::database::{
-    connection::ConnectionConfig, Connection, DataType, TableColumn, TableMetadata,
+    cancel::QueryCanceller, connection::ConnectionConfig, is_system_object, notices,
+    objects::like_contains, quote_ident, session::SessionConnection, statement_timeout,
+    system_schemas, ColumnMatch, Connection, DataType, DatabaseType, DisplayTimeZone,
+    GeneratedColumn, IndexInfo, PartitionInfo, RoutineInfo, TableColumn, TableMetadata,
 };
 use async_trait::async_trait;
+use futures::TryStreamExt;
 use serde_json;
-use sqlx::postgres::{PgPool, PgPoolOptions};
-use sqlx::{Column, Row};
+use sqlx::postgres::{PgConnectOptions, PgListener, PgPool, PgPoolOptions};
+use sqlx::{Column, Either, Row, Statement as _};
+use std::sync::atomic::{AtomicBool, Ordering};
 use uuid;
 
 /// PostgreSQL database connection implementation
@@ -17,20 +25,62 @@ use uuid;
 pub struct PostgresConnection {
     config: ConnectionConfig,
     pub pool: Option<PgPool>,
+    /// Connection user queries run on, keeping their session state
+    session: SessionConnection<sqlx::Postgres>,
+    /// Zone sessions run in and `timestamptz` values are shown in
+    time_zone: DisplayTimeZone,
+    /// Whether the session connection is inside a transaction block
+    transaction_open: AtomicBool,
+    /// Run each statement of an open transaction inside a savepoint, so a
+    /// failed one doesn't abort the transaction
+    statement_savepoints: bool,
+    /// Characters of each cell a table preview reads; 0 reads whole values
+    preview_cell_chars: usize,
+    /// Seconds the server lets a statement run; 0 for no limit
+    statement_timeout_secs: u64,
 }
 
 impl PostgresConnection {
     /// Create a new PostgreSQL connection instance
     pub fn new(config: ConnectionConfig) -> Self {
-        Self { config, pool: None }
+        let time_zone = config.display_time_zone();
+        Self {
+            config,
+            pool: None,
+            session: SessionConnection::default(),
+            time_zone,
+            transaction_open: AtomicBool::new(false),
+            statement_savepoints: true,
+            preview_cell_chars: DEFAULT_PREVIEW_CELL_CHARS,
+            statement_timeout_secs: 0,
+        }
     }
 
-    /// Build PostgreSQL connection string
-    fn build_connection_string(&self, encryption_key: Option<&str>) -> Result<String> {
-        let host = &self.config.host;
-        let port = self.config.port;
-        let database = self.config.database.as_deref().unwrap_or("postgres");
-        let username = &self.config.username;
+    /// Turn statement savepoints inside transactions on or off; with them
+    /// off, a failed statement aborts the transaction as usual
+    pub fn set_statement_savepoints(&mut self, enabled: bool) {
+        self.statement_savepoints = enabled;
+    }
+
+    /// Set how many characters of each cell table previews read
+    pub fn set_preview_cell_chars(&mut self, max_chars: usize) {
+        self.preview_cell_chars = max_chars;
+    }
+
+    /// Set the statement timeout sessions start with, in seconds
+    pub fn set_statement_timeout(&mut self, seconds: u64) {
+        self.statement_timeout_secs = seconds;
+    }
+
+    /// Connection options for the pool. The password goes to the driver
+    /// directly rather than into a connection URL that errors and logs could
+    /// echo
+    fn connect_options(&self, encryption_key: Option<&str>) -> PgConnectOptions {
+        let options = PgConnectOptions::new()
+            .host(&self.config.host)
+            .port(self.config.port)
+            .username(&self.config.username)
+            .database(self.config.database_or_default());
 
         // Try to resolve password from various sources
         let password = self
@@ -38,15 +88,33 @@ impl PostgresConnection {
             .resolve_password(encryption_key)
             .unwrap_or_default();
 
-        if !password.is_empty() {
-            Ok(format!(
-                "postgresql://{username}:{password}@{host}:{port}/{database}"
-            ))
+        if password.is_empty() {
+            options
         } else {
-            Ok(format!("postgresql://{username}@{host}:{port}/{database}"))
+            options.password(&password)
         }
     }
 
+    /// `SET search_path` statement for the connection's configured search
+    /// path, if it has one
+    fn search_path_sql(&self) -> Result<Option<String>> {
+        let schemas = self.config.search_path_schemas();
+        if schemas.is_empty() {
+            return Ok(None);
+        }
+        if schemas.iter().any(|schema| schema.contains('\0')) {
+            return Err(LazyTablesError::Connection(
+                "Search path contains a NUL byte".to_string(),
+            ));
+        }
+        let schemas = schemas
+            .iter()
+            .map(|schema| quote_ident(&DatabaseType::PostgreSQL, &[schema.as_str()]))
+            .collect::<Vec<_>>()
+            .join(", ");
+        Ok(Some(format!("SET search_path TO {schemas}")))
+    }
+
     /// Parse SQLx error into structured ConnectionError with helpful suggestions
     pub fn parse_connection_error(
         &self,
@@ -166,21 +234,48 @@ impl Connection for PostgresConnection {
     }
 
     async fn connect_with_key(&mut self, encryption_key: Option<&str>) -> Result<()> {
-        let connection_string = self.build_connection_string(encryption_key)?;
+        let options = self.connect_options(encryption_key);
+
+        // Every pooled connection gets the search path, time zone and
+        // statement timeout, so metadata queries and the session connection
+        // agree
+        let timeout = (self.statement_timeout_secs > 0)
+            .then(|| {
+                statement_timeout::set_sql(&DatabaseType::PostgreSQL, self.statement_timeout_secs)
+            })
+            .flatten();
+        let setup: Vec<String> = self
+            .search_path_sql()?
+            .into_iter()
+            .chain(Some(self.time_zone.postgres_set_sql()))
+            .chain(timeout)
+            .collect();
+        let setup = setup.join("; ");
+        let pool_options =
+            PgPoolOptions::new()
+                .max_connections(5)
+                .after_connect(move |connection, _| {
+                    let setup = setup.clone();
+                    Box::pin(async move {
+                        sqlx::raw_sql(&setup).execute(&mut *connection).await?;
+                        Ok(())
+                    })
+                });
 
-        let pool = PgPoolOptions::new()
-            .max_connections(5)
-            .connect(&connection_string)
-            .await
-            .map_err(|e| {
-                LazyTablesError::Connection(format!("Failed to connect to PostgreSQL: {e}"))
-            })?;
+        let pool = pool_options.connect_with(options).await.map_err(|source| {
+            LazyTablesError::Connect {
+                database: "PostgreSQL",
+                source,
+            }
+        })?;
 
         self.pool = Some(pool);
         Ok(())
     }
 
     async fn disconnect(&mut self) -> Result<()> {
+        self.session.release();
+        self.transaction_open.store(false, Ordering::SeqCst);
         if let Some(pool) = self.pool.take() {
             pool.close().await;
         }
@@ -205,14 +300,25 @@ impl Connection for PostgresConnection {
         PostgresConnection::list_tables(self).await
     }
 
-    async fn list_database_objects(&self) -> Result<crate::database::DatabaseObjectList> {
-        PostgresConnection::list_database_objects(self).await
+    async fn list_database_objects(
+        &self,
+        include_system: bool,
+    ) -> Result<crate::database::DatabaseObjectList> {
+        PostgresConnection::list_database_objects(self, include_system).await
     }
 
     async fn get_table_metadata(&self, table_name: &str) -> Result<crate::database::TableMetadata> {
         PostgresConnection::get_table_metadata(self, table_name).await
     }
 
+    async fn insert_row(
+        &self,
+        table_name: &str,
+        values: &[InsertValue],
+    ) -> Result<(Vec<String>, Vec<Vec<String>>)> {
+        PostgresConnection::insert_row(self, table_name, values).await
+    }
+
     async fn get_table_columns(
         &self,
         table_name: &str,
@@ -226,7 +332,8 @@ impl Connection for PostgresConnection {
         limit: usize,
         offset: usize,
     ) -> Result<Vec<Vec<String>>> {
-        PostgresConnection::get_table_data(self, table_name, limit, offset).await
+        PostgresConnection::get_table_data(self, table_name, limit, &Paging::Offset(offset), &[])
+            .await
     }
 
     // Database-specific capabilities (AC1 & AC2 requirement)
@@ -474,10 +581,27 @@ impl PostgresConnection {
         }
     }
 
-    /// List all databases accessible to the user
-    pub async fn list_databases(&self) -> Result<Vec<String>> {
+    /// Start listening on the given NOTIFY channels. The listener holds a
+    /// connection of its own for as long as it lives
+    pub async fn listen(&self, channels: &[String]) -> Result<PgListener> {
+        let pool = self
+            .pool
+            .as_ref()
+            .ok_or_else(|| LazyTablesError::Connection("Not connected to database".to_string()))?;
+
+        let mut listener = PgListener::connect_with(pool).await?;
+        listener
+            .listen_all(channels.iter().map(String::as_str))
+            .await?;
+        Ok(listener)
+    }
+
+    /// List all databases accessible to the user; template databases only
+    /// when `include_system` is set
+    pub async fn list_databases(&self, include_system: bool) -> Result<Vec<String>> {
         if let Some(pool) = &self.pool {
-            let rows = sqlx::query("SELECT datname FROM pg_database WHERE datistemplate = false")
+            let rows = sqlx::query("SELECT datname FROM pg_database WHERE $1 OR NOT datistemplate")
+                .bind(include_system)
                 .fetch_all(pool)
                 .await
                 .map_err(|e| {
@@ -526,8 +650,12 @@ impl PostgresConnection {
         }
     }
 
-    /// List all database objects (tables, views, etc.)
-    pub async fn list_database_objects(&self) -> Result<crate::database::DatabaseObjectList> {
+    /// List all database objects (tables, views, etc.), including the catalog
+    /// schemas when `include_system` is set
+    pub async fn list_database_objects(
+        &self,
+        include_system: bool,
+    ) -> Result<crate::database::DatabaseObjectList> {
         use crate::database::{DatabaseObject, DatabaseObjectList, DatabaseObjectType};
 
         if let Some(pool) = &self.pool {
@@ -557,15 +685,22 @@ impl PostgresConnection {
                     FROM pg_catalog.pg_class c
                     LEFT JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
                     WHERE c.relkind IN ('r', 'v', 'm', 'f')
-                        AND n.nspname NOT IN ('pg_catalog', 'information_schema')
                         AND n.nspname NOT LIKE 'pg_toast%'
                         AND n.nspname NOT LIKE 'pg_temp%'
+                        AND ($1 OR NOT (n.nspname = ANY($2)))
                 )
                 SELECT * FROM object_info
-                ORDER BY schema_name, object_type, object_name
+                ORDER BY schema_name <> $3, schema_name, object_type, object_name
             ";
+            let default_schema = self.config.default_schema();
 
-            match sqlx::query(query).fetch_all(pool).await {
+            match sqlx::query(query)
+                .bind(include_system)
+                .bind(system_schemas(&DatabaseType::PostgreSQL))
+                .bind(&default_schema)
+                .fetch_all(pool)
+                .await
+            {
                 Ok(rows) => {
                     for row in rows {
                         let schema: String = row.get("schema_name");
@@ -583,6 +718,8 @@ impl PostgresConnection {
                             _ => continue,
                         };
 
+                        let system =
+                            is_system_object(&DatabaseType::PostgreSQL, Some(&schema), &name);
                         let obj = DatabaseObject {
                             name,
                             schema: Some(schema),
@@ -590,6 +727,7 @@ impl PostgresConnection {
                             row_count,
                             size_bytes,
                             comment,
+                            system,
                         };
 
                         // Sort into appropriate lists
@@ -608,6 +746,7 @@ impl PostgresConnection {
                         + result.views.len()
                         + result.materialized_views.len()
                         + result.foreign_tables.len();
+                    result.default_schema = Some(default_schema);
                 }
                 Err(e) => {
                     // Check for permission errors
@@ -631,21 +770,27 @@ impl PostgresConnection {
         }
     }
 
-    /// List all schemas in the database
-    pub async fn list_schemas(&self) -> Result<Vec<String>> {
+    /// List all schemas in the database, including the catalog schemas when
+    /// `include_system` is set
+    pub async fn list_schemas(&self, include_system: bool) -> Result<Vec<String>> {
         if let Some(pool) = &self.pool {
             let query = "
                 SELECT nspname AS schema_name
                 FROM pg_catalog.pg_namespace
-                WHERE nspname NOT IN ('pg_catalog', 'information_schema')
-                    AND nspname NOT LIKE 'pg_toast%'
+                WHERE nspname NOT LIKE 'pg_toast%'
                     AND nspname NOT LIKE 'pg_temp%'
+                    AND ($1 OR NOT (nspname = ANY($2)))
                 ORDER BY nspname
             ";
 
-            let rows = sqlx::query(query).fetch_all(pool).await.map_err(|e| {
-                LazyTablesError::Connection(format!("Failed to list schemas: {}", e))
-            })?;
+            let rows = sqlx::query(query)
+                .bind(include_system)
+                .bind(system_schemas(&DatabaseType::PostgreSQL))
+                .fetch_all(pool)
+                .await
+                .map_err(|e| {
+                    LazyTablesError::Connection(format!("Failed to list schemas: {}", e))
+                })?;
 
             let schemas = rows
                 .iter()
@@ -660,10 +805,12 @@ impl PostgresConnection {
         }
     }
 
-    /// List database objects filtered by schema
+    /// List database objects filtered by schema; without one, every schema
+    /// but the catalog schemas unless `include_system` is set
     pub async fn list_database_objects_in_schema(
         &self,
         schema_name: Option<&str>,
+        include_system: bool,
     ) -> Result<crate::database::DatabaseObjectList> {
         use crate::database::{DatabaseObject, DatabaseObjectList, DatabaseObjectType};
 
@@ -671,13 +818,12 @@ impl PostgresConnection {
             let mut result = DatabaseObjectList::default();
 
             // Build schema filter
-            let schema_filter = if let Some(schema) = schema_name {
-                format!("AND n.nspname = '{}'", schema.replace("'", "''"))
+            let schema_filter = if schema_name.is_some() {
+                "AND n.nspname = $1"
             } else {
-                "AND n.nspname NOT IN ('pg_catalog', 'information_schema')
-                 AND n.nspname NOT LIKE 'pg_toast%'
-                 AND n.nspname NOT LIKE 'pg_temp%'"
-                    .to_string()
+                "AND n.nspname NOT LIKE 'pg_toast%'
+                 AND n.nspname NOT LIKE 'pg_temp%'
+                 AND ($1 OR NOT (n.nspname = ANY($2)))"
             };
 
             let query = format!(
@@ -712,7 +858,14 @@ impl PostgresConnection {
                 schema_filter
             );
 
-            match sqlx::query(&query).fetch_all(pool).await {
+            let objects_query = match schema_name {
+                Some(schema) => sqlx::query(&query).bind(schema),
+                None => sqlx::query(&query)
+                    .bind(include_system)
+                    .bind(system_schemas(&DatabaseType::PostgreSQL)),
+            };
+
+            match objects_query.fetch_all(pool).await {
                 Ok(rows) => {
                     for row in rows {
                         let schema: String = row.get("schema_name");
@@ -730,6 +883,8 @@ impl PostgresConnection {
                             _ => continue,
                         };
 
+                        let system =
+                            is_system_object(&DatabaseType::PostgreSQL, Some(&schema), &name);
                         let obj = DatabaseObject {
                             name,
                             schema: Some(schema),
@@ -737,6 +892,7 @@ impl PostgresConnection {
                             row_count,
                             size_bytes,
                             comment,
+                            system,
                         };
 
                         // Sort into appropriate lists
@@ -782,11 +938,12 @@ impl PostgresConnection {
     pub async fn get_table_metadata(&self, table_name: &str) -> Result<TableMetadata> {
         if let Some(pool) = &self.pool {
             // Parse schema and table name
+            let default_schema = self.config.default_schema();
             let (schema, table) = if table_name.contains('.') {
                 let parts: Vec<&str> = table_name.splitn(2, '.').collect();
                 (parts[0], parts[1])
             } else {
-                ("public", table_name)
+                (default_schema.as_str(), table_name)
             };
 
             // First, determine the object type
@@ -977,15 +1134,194 @@ impl PostgresConnection {
         }
     }
 
+    /// Find columns whose name contains `pattern` (case-insensitive) in every
+    /// non-system schema
+    pub async fn search_columns(&self, pattern: &str) -> Result<Vec<ColumnMatch>> {
+        if let Some(pool) = &self.pool {
+            let query = "SELECT
+                table_schema::text AS table_schema,
+                table_name::text AS table_name,
+                column_name::text AS column_name,
+                data_type::text AS data_type
+                FROM information_schema.columns
+                WHERE table_schema NOT IN ('pg_catalog', 'information_schema')
+                AND column_name ILIKE $1 ESCAPE '\\'
+                ORDER BY table_schema, table_name, ordinal_position";
+
+            let rows = sqlx::query(query)
+                .bind(like_contains(pattern))
+                .fetch_all(pool)
+                .await?;
+
+            Ok(rows
+                .iter()
+                .map(|row| {
+                    let schema: String = row.get("table_schema");
+                    let table: String = row.get("table_name");
+                    ColumnMatch {
+                        table: format!("{schema}.{table}"),
+                        column: row.get("column_name"),
+                        data_type: row.get("data_type"),
+                    }
+                })
+                .collect())
+        } else {
+            Err(LazyTablesError::Connection(
+                "Not connected to database".to_string(),
+            ))
+        }
+    }
+
+    /// List the partitions of a declaratively partitioned table with their
+    /// bounds; empty when the table isn't partitioned
+    pub async fn get_partitions(&self, table_name: &str) -> Result<Vec<PartitionInfo>> {
+        if let Some(pool) = &self.pool {
+            let default_schema = self.config.default_schema();
+            let (schema, actual_table_name) = table_name
+                .split_once('.')
+                .unwrap_or((default_schema.as_str(), table_name));
+
+            let query = "SELECT
+                child_ns.nspname::text AS partition_schema,
+                child.relname::text AS partition_name,
+                pg_get_expr(child.relpartbound, child.oid) AS bound,
+                child.reltuples::bigint AS row_estimate
+                FROM pg_inherits i
+                JOIN pg_class parent ON parent.oid = i.inhparent
+                JOIN pg_namespace parent_ns ON parent_ns.oid = parent.relnamespace
+                JOIN pg_class child ON child.oid = i.inhrelid
+                JOIN pg_namespace child_ns ON child_ns.oid = child.relnamespace
+                WHERE parent.relkind = 'p'
+                AND parent_ns.nspname = $1
+                AND parent.relname = $2
+                ORDER BY child.relname";
+
+            let rows = sqlx::query(query)
+                .bind(schema)
+                .bind(actual_table_name)
+                .fetch_all(pool)
+                .await?;
+
+            Ok(rows
+                .iter()
+                .map(|row| {
+                    // reltuples is -1 (0 before PostgreSQL 14) until analyzed
+                    let row_estimate: i64 = row.get("row_estimate");
+                    PartitionInfo {
+                        name: row.get("partition_name"),
+                        schema: Some(row.get("partition_schema")),
+                        bound: row.get("bound"),
+                        row_estimate: u64::try_from(row_estimate).ok(),
+                    }
+                })
+                .collect())
+        } else {
+            Err(LazyTablesError::Connection(
+                "Not connected to database".to_string(),
+            ))
+        }
+    }
+
+    /// List the indexes of a table with their columns, expressions for
+    /// expression indexes, the primary key's first
+    pub async fn get_table_indexes(&self, table_name: &str) -> Result<Vec<IndexInfo>> {
+        if let Some(pool) = &self.pool {
+            let default_schema = self.config.default_schema();
+            let (schema, actual_table_name) = table_name
+                .split_once('.')
+                .unwrap_or((default_schema.as_str(), table_name));
+
+            let query = "SELECT
+                i.relname::text AS index_name,
+                ix.indisunique AS is_unique,
+                ix.indisprimary AS is_primary,
+                am.amname::text AS index_type,
+                pg_relation_size(i.oid) AS index_size,
+                ARRAY(
+                    SELECT pg_get_indexdef(ix.indexrelid, k + 1, true)
+                    FROM generate_subscripts(ix.indkey, 1) AS k
+                    ORDER BY k
+                ) AS column_names
+                FROM pg_index ix
+                JOIN pg_class t ON t.oid = ix.indrelid
+                JOIN pg_namespace n ON n.oid = t.relnamespace
+                JOIN pg_class i ON i.oid = ix.indexrelid
+                JOIN pg_am am ON am.oid = i.relam
+                WHERE n.nspname = $1
+                AND t.relname = $2
+                ORDER BY ix.indisprimary DESC, i.relname";
+
+            let rows = sqlx::query(query)
+                .bind(schema)
+                .bind(actual_table_name)
+                .fetch_all(pool)
+                .await?;
+
+            Ok(rows
+                .iter()
+                .map(|row| IndexInfo {
+                    name: row.get("index_name"),
+                    columns: row.get("column_names"),
+                    is_unique: row.get("is_unique"),
+                    is_primary: row.get("is_primary"),
+                    index_type: Some(row.get::<String, _>("index_type").to_uppercase()),
+                    size: row.get("index_size"),
+                })
+                .collect())
+        } else {
+            Err(LazyTablesError::Connection(
+                "Not connected to database".to_string(),
+            ))
+        }
+    }
+
+    /// List the functions and procedures of a schema with their arguments
+    /// and return types. Aggregates and window functions aren't listed
+    pub async fn list_routines(&self, schema: &str) -> Result<Vec<RoutineInfo>> {
+        if let Some(pool) = &self.pool {
+            let query = "SELECT
+                p.proname::text AS routine_name,
+                p.prokind = 'p' AS is_procedure,
+                pg_get_function_identity_arguments(p.oid) AS arguments,
+                CASE WHEN p.prokind = 'p' THEN NULL ELSE pg_get_function_result(p.oid) END AS returns
+                FROM pg_proc p
+                JOIN pg_namespace n ON n.oid = p.pronamespace
+                WHERE n.nspname = $1
+                AND p.prokind IN ('f', 'p')
+                ORDER BY p.proname, arguments";
+
+            let rows = sqlx::query(query).bind(schema).fetch_all(pool).await?;
+
+            Ok(rows
+                .iter()
+                .map(|row| {
+                    let arguments: Option<String> = row.get("arguments");
+                    RoutineInfo {
+                        name: row.get("routine_name"),
+                        schema: schema.to_string(),
+                        procedure: row.get("is_procedure"),
+                        arguments: arguments.filter(|arguments| !arguments.is_empty()),
+                        returns: row.get("returns"),
+                    }
+                })
+                .collect())
+        } else {
+            Err(LazyTablesError::Connection(
+                "Not connected to database".to_string(),
+            ))
+        }
+    }
+
     /// Get column information for a table
     pub async fn get_table_columns(&self, table_name: &str) -> Result<Vec<TableColumn>> {
         if let Some(pool) = &self.pool {
             // Parse schema and table name
+            let default_schema = self.config.default_schema();
             let (schema, actual_table_name) = if table_name.contains('.') {
                 let parts: Vec<&str> = table_name.splitn(2, '.').collect();
                 (parts[0], parts[1])
             } else {
-                ("public", table_name)
+                (default_schema.as_str(), table_name)
             };
 
             crate::log_debug!(
@@ -996,8 +1332,25 @@ impl PostgresConnection {
             let query = "SELECT
                 c.column_name,
                 c.data_type,
+                c.udt_name::text AS udt_name,
+                (
+                    SELECT array_agg(e.enumlabel::text ORDER BY e.enumsortorder)
+                    FROM pg_catalog.pg_type t
+                    JOIN pg_catalog.pg_namespace n ON n.oid = t.typnamespace
+                    JOIN pg_catalog.pg_enum e ON e.enumtypid =
+                        CASE WHEN t.typelem <> 0 THEN t.typelem ELSE t.oid END
+                    WHERE n.nspname = c.udt_schema
+                        AND t.typname = c.udt_name
+                ) AS enum_labels,
                 c.is_nullable,
                 c.column_default,
+                c.is_identity,
+                c.is_generated,
+                c.generation_expression,
+                pg_catalog.col_description(
+                    format('%I.%I', c.table_schema, c.table_name)::regclass,
+                    c.ordinal_position::int
+                ) AS column_comment,
                 CASE
                     WHEN pk.column_name IS NOT NULL THEN true
                     ELSE false
@@ -1037,16 +1390,37 @@ impl PostgresConnection {
                 .map(|row| {
                     let column_name: String = row.get("column_name");
                     let data_type_str: String = row.get("data_type");
+                    let udt_name: String = row.get("udt_name");
+                    let enum_labels: Option<Vec<String>> = row.get("enum_labels");
                     let is_nullable: String = row.get("is_nullable");
                     let column_default: Option<String> = row.get("column_default");
                     let is_primary_key: bool = row.get("is_primary_key");
+                    let is_identity: String = row.get("is_identity");
+                    let is_generated: String = row.get("is_generated");
+                    let generation_expression: Option<String> = row.get("generation_expression");
+                    let comment: Option<String> = row.get("column_comment");
+
+                    // serial columns are plain defaults drawing from a sequence
+                    let is_auto_increment = is_identity == "YES"
+                        || column_default
+                            .as_deref()
+                            .is_some_and(|default| default.starts_with("nextval("));
+                    // information_schema doesn't say how a column is generated;
+                    // PostgreSQL before 18 only has stored generated columns
+                    let generated = (is_generated == "ALWAYS").then(|| GeneratedColumn {
+                        stored: true,
+                        expression: generation_expression,
+                    });
 
                     TableColumn {
                         name: column_name,
-                        data_type: parse_postgres_type(&data_type_str),
+                        data_type: postgres_column_type(&data_type_str, &udt_name, enum_labels),
                         is_nullable: is_nullable == "YES",
                         default_value: column_default,
                         is_primary_key,
+                        is_auto_increment,
+                        generated,
+                        comment,
                     }
                 })
                 .collect();
@@ -1059,18 +1433,35 @@ impl PostgresConnection {
         }
     }
 
-    /// Get the row count for a table
-    pub async fn get_table_row_count(&self, table_name: &str) -> Result<usize> {
+    /// Quoted name of a table, in the default schema unless it names one
+    fn qualified_table_name(&self, table_name: &str) -> String {
+        match table_name.split_once('.') {
+            Some((schema, table)) => quote_ident(&DatabaseType::PostgreSQL, &[schema, table]),
+            None => quote_ident(
+                &DatabaseType::PostgreSQL,
+                &[self.config.default_schema().as_str(), table_name],
+            ),
+        }
+    }
+
+    /// Get the row count for a table, counting only rows passing `filters`
+    pub async fn get_table_row_count(
+        &self,
+        table_name: &str,
+        filters: &[ColumnFilter],
+    ) -> Result<usize> {
         if let Some(pool) = &self.pool {
-            // Use the same qualified name logic as get_table_data
-            let qualified_name = if table_name.contains('.') {
-                table_name.to_string()
-            } else {
-                format!("public.{}", table_name)
-            };
+            let qualified_name = self.qualified_table_name(table_name);
 
-            let query = format!("SELECT COUNT(*) FROM {qualified_name}");
-            let row = sqlx::query(&query).fetch_one(pool).await?;
+            let query = format!(
+                "SELECT COUNT(*) FROM {qualified_name} {}",
+                preview::where_clause(&DatabaseType::PostgreSQL, filters, None)
+            );
+            let mut statement = sqlx::query(&query);
+            for value in preview::filter_values(filters) {
+                statement = statement.bind(value);
+            }
+            let row = statement.fetch_one(pool).await?;
             let count: i64 = row.get(0);
             Ok(count as usize)
         } else {
@@ -1080,20 +1471,22 @@ impl PostgresConnection {
         }
     }
 
-    /// Get table data with pagination
+    /// Get a page of table data
     pub async fn get_table_data(
         &self,
         table_name: &str,
         limit: usize,
-        offset: usize,
+        paging: &Paging,
+        filters: &[ColumnFilter],
     ) -> Result<Vec<Vec<String>>> {
         if let Some(pool) = &self.pool {
             // Parse schema and table name
+            let default_schema = self.config.default_schema();
             let (schema, table) = if table_name.contains('.') {
                 let parts: Vec<&str> = table_name.splitn(2, '.').collect();
                 (parts[0], parts[1])
             } else {
-                ("public", table_name)
+                (default_schema.as_str(), table_name)
             };
 
             // Get column names first to maintain order
@@ -1130,34 +1523,48 @@ impl PostgresConnection {
                 return Ok(Vec::new());
             }
 
-            // Build SELECT query with all columns
+            // Build SELECT query with all columns, each cut to the preview
+            // cell limit on the server
             let select_list = column_names
                 .iter()
-                .map(|col| format!("\"{col}\"::text"))
+                .map(|col| {
+                    preview::preview_column(
+                        &DatabaseType::PostgreSQL,
+                        &quote_ident(&DatabaseType::PostgreSQL, &[col]),
+                        self.preview_cell_chars,
+                    )
+                })
                 .collect::<Vec<_>>()
                 .join(", ");
 
-            let qualified_name = if table_name.contains('.') {
-                table_name.to_string()
-            } else {
-                format!("public.{}", table_name)
-            };
+            let qualified_name = quote_ident(&DatabaseType::PostgreSQL, &[schema, table]);
 
             let query = format!(
-                "SELECT {select_list} FROM {qualified_name} ORDER BY 1 LIMIT {limit} OFFSET {offset}"
+                "SELECT {select_list} FROM {qualified_name} {}",
+                paging.clauses(&DatabaseType::PostgreSQL, filters, limit)
             );
 
-            let rows = sqlx::query(&query).fetch_all(pool).await?;
+            let mut statement = sqlx::query(&query);
+            for value in preview::filter_values(filters) {
+                statement = statement.bind(value);
+            }
+            let rows = statement.fetch_all(pool).await?;
 
             let mut result = Vec::new();
             for row in rows {
                 let mut row_data = Vec::new();
                 for (idx, _col_name) in column_names.iter().enumerate() {
                     let value: Option<String> = row.try_get(idx).ok();
-                    row_data.push(value.unwrap_or_else(|| "NULL".to_string()));
+                    row_data.push(match value {
+                        Some(value) => preview::mark_partial(value, self.preview_cell_chars),
+                        None => "NULL".to_string(),
+                    });
                 }
                 result.push(row_data);
             }
+            if paging.reads_backwards() {
+                result.reverse();
+            }
 
             Ok(result)
         } else {
@@ -1169,40 +1576,216 @@ impl PostgresConnection {
 }
 
 impl PostgresConnection {
-    /// Execute a raw SQL query and return columns and rows
-    pub async fn execute_raw_query(&self, query: &str) -> Result<(Vec<String>, Vec<Vec<String>>)> {
-        if let Some(pool) = &self.pool {
-            crate::log_debug!("execute_raw_query: Executing query: {}", query);
+    /// Execute a raw SQL query, passing rows to the sink as they are fetched.
+    /// Runs on the session connection, so `SET` and the like carry over to
+    /// the next query. Notices raised while it runs follow the rows
+    pub async fn stream_raw_query(
+        &self,
+        query: &str,
+        sink: &mut dyn crate::database::RowSink,
+    ) -> Result<usize> {
+        let pool = self
+            .pool
+            .as_ref()
+            .ok_or_else(|| LazyTablesError::Connection("Not connected to database".to_string()))?;
+        let mut connection = self.session.lock(pool).await?;
+        if connection.server_id() == 0 {
+            // Read once per session, for cancelling its statements
+            match sqlx::query_scalar::<_, i32>("SELECT pg_backend_pid()")
+                .fetch_one(&mut *connection)
+                .await
+            {
+                Ok(pid) => connection.set_server_id(pid.into()),
+                Err(e) => crate::log_warn!("Failed to read the session's backend pid: {}", e),
+            }
+        }
+        let control = TransactionControl::of(query);
+        let savepoint = self
+            .open_statement_savepoint(&mut connection, control)
+            .await;
+
+        let (result, notices): (Result<usize>, _) = notices::capture(async {
+            let start = std::time::Instant::now();
+            let mut results = sqlx::Executor::fetch_many(&mut *connection, sqlx::query(query));
+            let mut count = 0;
+            while let Some(item) = results.try_next().await? {
+                let row = match item {
+                    Either::Left(done) => {
+                        // A SELECT counts the rows it returned, not ones it changed
+                        if count == 0 {
+                            sink.affected_rows(done.rows_affected())?;
+                        }
+                        continue;
+                    }
+                    Either::Right(row) => row,
+                };
+                if count == 0 {
+                    let column_names: Vec<String> = row
+                        .columns()
+                        .iter()
+                        .map(|col| col.name().to_string())
+                        .collect();
+                    sink.columns(&column_names)?;
+                }
+                let values = row
+                    .columns()
+                    .iter()
+                    .map(|col| extract_postgres_value(&row, col, &self.time_zone))
+                    .collect();
+                sink.row(values)?;
+                count += 1;
+                if sink.is_full() {
+                    break;
+                }
+            }
+            sink.elapsed(start.elapsed())?;
+
+            if count == 0 {
+                // No row to take the names from; the statement sqlx prepared
+                // and cached for the query has them, so an empty result keeps
+                // its header
+                drop(results);
+                let columns: Vec<String> =
+                    match sqlx::Executor::prepare(&mut *connection, query).await {
+                        Ok(statement) => statement
+                            .columns()
+                            .iter()
+                            .map(|col| col.name().to_string())
+                            .collect(),
+                        Err(_) => Vec::new(),
+                    };
+                sink.columns(&columns)?;
+            }
+            Ok(count)
+        })
+        .await;
+
+        let result = self
+            .finish_statement(&mut connection, control, savepoint, result)
+            .await;
+        if result.is_err() {
+            if connection.discard_if_broken().await {
+                self.transaction_open.store(false, Ordering::SeqCst);
+            }
+            return result;
+        }
+        sink.notices(notices)?;
+        result
+    }
 
-            // Execute the query
-            let rows = sqlx::query(query).fetch_all(pool).await?;
+    /// Set the statement savepoint when a statement that takes one runs in
+    /// an open transaction. Returns whether it was set
+    async fn open_statement_savepoint(
+        &self,
+        connection: &mut sqlx::PgConnection,
+        control: TransactionControl,
+    ) -> bool {
+        if !self.statement_savepoints
+            || !control.takes_savepoint()
+            || !self.transaction_open.load(Ordering::SeqCst)
+        {
+            return false;
+        }
+        match sqlx::raw_sql(&format!("SAVEPOINT {STATEMENT_SAVEPOINT}"))
+            .execute(&mut *connection)
+            .await
+        {
+            Ok(_) => true,
+            Err(e) => {
+                // no_active_sql_transaction: something ended it behind our back
+                if e.as_database_error().and_then(|e| e.code()).as_deref() == Some("25P01") {
+                    self.transaction_open.store(false, Ordering::SeqCst);
+                }
+                crate::log_warn!("Failed to set statement savepoint: {}", e);
+                false
+            }
+        }
+    }
 
-            if rows.is_empty() {
-                crate::log_debug!("execute_raw_query: No rows returned");
-                return Ok((Vec::new(), Vec::new()));
+    /// Release the statement savepoint, or roll back to it when the
+    /// statement failed, and follow the transaction state
+    async fn finish_statement<T>(
+        &self,
+        connection: &mut sqlx::PgConnection,
+        control: TransactionControl,
+        savepoint: bool,
+        result: Result<T>,
+    ) -> Result<T> {
+        match control {
+            TransactionControl::Begin if result.is_ok() => {
+                self.transaction_open.store(true, Ordering::SeqCst)
             }
+            // A failed COMMIT rolls back, so the transaction ends either way
+            TransactionControl::End => self.transaction_open.store(false, Ordering::SeqCst),
+            _ => {}
+        }
+        if !savepoint {
+            return result;
+        }
 
-            // Get column information from the first row
-            let first_row = &rows[0];
-            let columns = first_row.columns();
+        let cleanup = if result.is_ok() {
+            format!("RELEASE SAVEPOINT {STATEMENT_SAVEPOINT}")
+        } else {
+            format!(
+                "ROLLBACK TO SAVEPOINT {STATEMENT_SAVEPOINT}; RELEASE SAVEPOINT {STATEMENT_SAVEPOINT}"
+            )
+        };
+        let cleaned_up = sqlx::raw_sql(&cleanup).execute(&mut *connection).await;
+        match (result, cleaned_up) {
+            (Ok(value), Ok(_)) => Ok(value),
+            (Err(e), Ok(_)) => Err(LazyTablesError::Other(format!(
+                "{e}; {SAVEPOINT_RECOVERED}"
+            ))),
+            (result, Err(e)) => {
+                crate::log_warn!("Failed to {}: {}", cleanup, e);
+                result
+            }
+        }
+    }
 
-            let column_names: Vec<String> =
-                columns.iter().map(|col| col.name().to_string()).collect();
+    /// Roll back the transaction open on the session connection
+    pub async fn rollback(&self) -> Result<()> {
+        let pool = self
+            .pool
+            .as_ref()
+            .ok_or_else(|| LazyTablesError::Connection("Not connected to database".to_string()))?;
+        let mut connection = self.session.lock(pool).await?;
+        sqlx::raw_sql("ROLLBACK").execute(&mut *connection).await?;
+        self.transaction_open.store(false, Ordering::SeqCst);
+        Ok(())
+    }
 
-            crate::log_debug!("execute_raw_query: Column names: {:?}", column_names);
-            crate::log_debug!("execute_raw_query: Number of rows: {}", rows.len());
+    /// Execute a raw SQL query on the session connection and return columns
+    /// and rows
+    pub async fn execute_raw_query(&self, query: &str) -> Result<(Vec<String>, Vec<Vec<String>>)> {
+        if let Some(pool) = &self.pool {
+            crate::log_debug!("execute_raw_query: Executing query: {}", query);
 
-            // Extract data from all rows with proper PostgreSQL type handling
-            let mut result_rows = Vec::new();
-            for row in &rows {
-                let mut row_data = Vec::new();
-                for col in columns {
-                    let value = extract_postgres_value(row, col);
-                    row_data.push(value);
+            // Execute the query
+            let mut connection = self.session.lock(pool).await?;
+            let control = TransactionControl::of(query);
+            let savepoint = self
+                .open_statement_savepoint(&mut connection, control)
+                .await;
+            let result = sqlx::query(query)
+                .fetch_all(&mut *connection)
+                .await
+                .map_err(LazyTablesError::from);
+            let rows = match self
+                .finish_statement(&mut connection, control, savepoint, result)
+                .await
+            {
+                Ok(rows) => rows,
+                Err(e) => {
+                    if connection.discard_if_broken().await {
+                        self.transaction_open.store(false, Ordering::SeqCst);
+                    }
+                    return Err(e);
                 }
-                result_rows.push(row_data);
-            }
+            };
+            drop(connection);
 
+            let (column_names, result_rows) = self.result_strings(&rows);
             crate::log_debug!(
                 "execute_raw_query: Returning {} columns and {} rows",
                 column_names.len(),
@@ -1215,6 +1798,77 @@ impl PostgresConnection {
             ))
         }
     }
+
+    /// Insert a row on the session connection, returning the inserted row
+    pub async fn insert_row(
+        &self,
+        table_name: &str,
+        values: &[InsertValue],
+    ) -> Result<(Vec<String>, Vec<Vec<String>>)> {
+        let Some(pool) = &self.pool else {
+            return Err(LazyTablesError::Connection(
+                "Not connected to database".to_string(),
+            ));
+        };
+        let table_name = if table_name.contains('.') {
+            table_name.to_string()
+        } else {
+            format!("{}.{table_name}", self.config.default_schema())
+        };
+        let query = insert::insert_query(&DatabaseType::PostgreSQL, &table_name, values);
+        let params = insert::insert_params(&DatabaseType::PostgreSQL, values);
+
+        let mut connection = self.session.lock(pool).await?;
+        let control = TransactionControl::of(&query);
+        let savepoint = self
+            .open_statement_savepoint(&mut connection, control)
+            .await;
+        let mut statement = sqlx::query(&query);
+        for param in params {
+            statement = statement.bind(param);
+        }
+        let result = statement
+            .fetch_all(&mut *connection)
+            .await
+            .map_err(LazyTablesError::from);
+        let rows = match self
+            .finish_statement(&mut connection, control, savepoint, result)
+            .await
+        {
+            Ok(rows) => rows,
+            Err(e) => {
+                if connection.discard_if_broken().await {
+                    self.transaction_open.store(false, Ordering::SeqCst);
+                }
+                return Err(e);
+            }
+        };
+        drop(connection);
+        Ok(self.result_strings(&rows))
+    }
+
+    /// Column names and values, as text, of rows read
+    fn result_strings(&self, rows: &[sqlx::postgres::PgRow]) -> (Vec<String>, Vec<Vec<String>>) {
+        let Some(first_row) = rows.first() else {
+            return (Vec::new(), Vec::new());
+        };
+
+        // Get column information from the first row
+        let columns = first_row.columns();
+        let column_names: Vec<String> = columns.iter().map(|col| col.name().to_string()).collect();
+
+        // Extract data from all rows with proper PostgreSQL type handling
+        let result_rows = rows
+            .iter()
+            .map(|row| {
+                columns
+                    .iter()
+                    .map(|col| extract_postgres_value(row, col, &self.time_zone))
+                    .collect()
+            })
+            .collect();
+        (column_names, result_rows)
+    }
 }
 
 /// Implement ManagedConnection trait for PostgresConnection to work with ConnectionManager
@@ -1224,13 +1878,30 @@ impl crate::database::connection_manager::ManagedConnection for PostgresConnecti
         PostgresConnection::execute_raw_query(self, query).await
     }
 
+    async fn stream_raw_query(
+        &self,
+        query: &str,
+        sink: &mut dyn crate::database::RowSink,
+    ) -> Result<usize> {
+        PostgresConnection::stream_raw_query(self, query, sink).await
+    }
+
     async fn get_table_data(
         &self,
         table_name: &str,
         limit: usize,
-        offset: usize,
+        paging: &Paging,
+        filters: &[ColumnFilter],
     ) -> Result<Vec<Vec<String>>> {
-        PostgresConnection::get_table_data(self, table_name, limit, offset).await
+        PostgresConnection::get_table_data(self, table_name, limit, paging, filters).await
+    }
+
+    async fn get_table_row_count(
+        &self,
+        table_name: &str,
+        filters: &[ColumnFilter],
+    ) -> Result<usize> {
+        PostgresConnection::get_table_row_count(self, table_name, filters).await
     }
 
     async fn get_table_columns(
@@ -1244,8 +1915,27 @@ impl crate::database::connection_manager::ManagedConnection for PostgresConnecti
         PostgresConnection::get_table_metadata(self, table_name).await
     }
 
-    async fn list_database_objects(&self) -> Result<crate::database::DatabaseObjectList> {
-        PostgresConnection::list_database_objects(self).await
+    async fn list_database_objects(
+        &self,
+        include_system: bool,
+    ) -> Result<crate::database::DatabaseObjectList> {
+        PostgresConnection::list_database_objects(self, include_system).await
+    }
+
+    async fn search_columns(&self, pattern: &str) -> Result<Vec<ColumnMatch>> {
+        PostgresConnection::search_columns(self, pattern).await
+    }
+
+    async fn get_partitions(&self, table_name: &str) -> Result<Vec<PartitionInfo>> {
+        PostgresConnection::get_partitions(self, table_name).await
+    }
+
+    async fn get_table_indexes(&self, table_name: &str) -> Result<Vec<IndexInfo>> {
+        PostgresConnection::get_table_indexes(self, table_name).await
+    }
+
+    async fn list_routines(&self, schema: &str) -> Result<Vec<RoutineInfo>> {
+        PostgresConnection::list_routines(self, schema).await
     }
 
     // Note: ManagedConnection trait doesn't have disconnect method anymore
@@ -1254,13 +1944,36 @@ impl crate::database::connection_manager::ManagedConnection for PostgresConnecti
     fn is_connected(&self) -> bool {
         Connection::is_connected(self)
     }
+
+    fn in_transaction(&self) -> bool {
+        self.transaction_open.load(Ordering::SeqCst)
+    }
+
+    fn query_canceller(&self) -> Option<QueryCanceller> {
+        Some(QueryCanceller::Postgres {
+            pool: self.pool.clone()?,
+            backend_pid: self.session.server_id(),
+        })
+    }
+
+    async fn rollback(&self) -> Result<()> {
+        PostgresConnection::rollback(self).await
+    }
+
+    async fn close(&mut self) -> Result<()> {
+        Connection::disconnect(self).await
+    }
 }
 
 // Drop implementation removed - connection pools are closed explicitly via close() method
 // to avoid spawning background tasks that may not complete before app shutdown
 
 /// Extract a PostgreSQL value from a row and column, handling different data types robustly
-fn extract_postgres_value(row: &sqlx::postgres::PgRow, col: &sqlx::postgres::PgColumn) -> String {
+fn extract_postgres_value(
+    row: &sqlx::postgres::PgRow,
+    col: &sqlx::postgres::PgColumn,
+    time_zone: &DisplayTimeZone,
+) -> String {
     use sqlx::{Column, Row, TypeInfo};
 
     let col_name = col.name();
@@ -1357,11 +2070,38 @@ fn extract_postgres_value(row: &sqlx::postgres::PgRow, col: &sqlx::postgres::PgC
             }
         }
 
-        // Date/time types
-        "DATE" | "TIME" | "TIMESTAMP" | "TIMESTAMPTZ" => {
-            // Try to get as string representation first
-            if let Ok(val) = row.try_get::<Option<String>, _>(col_ordinal) {
-                val.unwrap_or_else(|| "NULL".to_string())
+        // Date/time types; instants are shown in the connection's zone
+        "TIMESTAMPTZ" => {
+            if let Ok(val) = row.try_get::<Option<chrono::DateTime<chrono::Utc>>, _>(col_ordinal) {
+                val.map(|v| time_zone.format(v))
+                    .unwrap_or_else(|| "NULL".to_string())
+            } else {
+                "NULL".to_string()
+            }
+        }
+
+        "TIMESTAMP" => {
+            if let Ok(val) = row.try_get::<Option<chrono::NaiveDateTime>, _>(col_ordinal) {
+                val.map(|v| v.to_string())
+                    .unwrap_or_else(|| "NULL".to_string())
+            } else {
+                "NULL".to_string()
+            }
+        }
+
+        "DATE" => {
+            if let Ok(val) = row.try_get::<Option<chrono::NaiveDate>, _>(col_ordinal) {
+                val.map(|v| v.to_string())
+                    .unwrap_or_else(|| "NULL".to_string())
+            } else {
+                "NULL".to_string()
+            }
+        }
+
+        "TIME" => {
+            if let Ok(val) = row.try_get::<Option<chrono::NaiveTime>, _>(col_ordinal) {
+                val.map(|v| v.to_string())
+                    .unwrap_or_else(|| "NULL".to_string())
             } else {
                 "NULL".to_string()
             }
@@ -1426,16 +2166,88 @@ fn parse_postgres_type(type_str: &str) -> DataType {
         "boolean" | "bool" => DataType::Boolean,
         "text" => DataType::Text,
         "character varying" | "varchar" => DataType::Varchar(None),
-        "character" | "char" => DataType::Char(None),
+        "character" | "char" | "bpchar" => DataType::Char(None),
         "date" => DataType::Date,
         "time" | "time without time zone" => DataType::Time,
-        "timestamp" | "timestamp without time zone" | "timestamp with time zone" => {
-            DataType::Timestamp
-        }
+        "timestamp"
+        | "timestamptz"
+        | "timestamp without time zone"
+        | "timestamp with time zone" => DataType::Timestamp,
         "json" | "jsonb" => DataType::Json,
         "uuid" => DataType::Uuid,
         "bytea" => DataType::Bytea,
-        s if s.starts_with("ARRAY") => DataType::Text, // Simplified for now
         _ => DataType::Text,
     }
 }
+
+/// Type of a column from its information_schema `data_type` and `udt_name`.
+/// Arrays and user-defined types only say `ARRAY` / `USER-DEFINED` in
+/// `data_type`, so the element or type name comes from `udt_name` (which
+/// spells an array of `int4` as `_int4`); `enum_labels` is set when the type,
+/// or the array's element type, is an enum
+fn postgres_column_type(
+    data_type: &str,
+    udt_name: &str,
+    enum_labels: Option<Vec<String>>,
+) -> DataType {
+    match data_type {
+        "ARRAY" => {
+            let element = udt_name.strip_prefix('_').unwrap_or(udt_name);
+            DataType::Array(Box::new(postgres_named_type(element, enum_labels)))
+        }
+        "USER-DEFINED" => postgres_named_type(udt_name, enum_labels),
+        _ => parse_postgres_type(data_type),
+    }
+}
+
+/// Type of an internal type name such as `int4` or `my_status_enum`
+fn postgres_named_type(name: &str, enum_labels: Option<Vec<String>>) -> DataType {
+    if let Some(labels) = enum_labels {
+        return DataType::Enum {
+            name: name.to_string(),
+            labels,
+        };
+    }
+    match parse_postgres_type(name) {
+        DataType::Text if name != "text" => DataType::Custom(name.to_string()),
+        data_type => data_type,
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_array_and_enum_column_types() {
+        assert_eq!(
+            postgres_column_type("ARRAY", "_int4", None).to_sql(),
+            "INTEGER[]"
+        );
+        assert_eq!(
+            postgres_column_type("ARRAY", "_timestamptz", None).to_sql(),
+            "TIMESTAMP[]"
+        );
+        assert_eq!(
+            postgres_column_type("USER-DEFINED", "hstore", None),
+            DataType::Custom("hstore".to_string())
+        );
+
+        let labels = vec!["active".to_string(), "archived".to_string()];
+        let status = postgres_column_type("USER-DEFINED", "my_status_enum", Some(labels.clone()));
+        assert_eq!(status.to_sql(), "my_status_enum");
+        assert_eq!(status.enum_labels(), labels.as_slice());
+
+        let statuses = postgres_column_type("ARRAY", "_my_status_enum", Some(labels.clone()));
+        assert_eq!(statuses.to_sql(), "my_status_enum[]");
+        assert_eq!(statuses.enum_labels(), labels.as_slice());
+
+        assert_eq!(
+            postgres_column_type("character varying", "varchar", None),
+            DataType::Varchar(None)
+        );
+        assert!(postgres_column_type("integer", "int4", None)
+            .enum_labels()
+            .is_empty());
+    }
+}
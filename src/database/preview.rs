@@ -0,0 +1,652 @@
+// FilePath: src/database/preview.rs
+
+//! Reading table previews
+//!
+//! Browsing a table reads every column of a page of rows, so a few huge text
+//! or bytea values can make a single page megabytes. Previews read only the
+//! first characters of each value, cut on the server, and mark the cells
+//! that were cut; the full value is read by primary key when it's needed.
+//! Big tables are sized from the planner's estimate instead of a count, and
+//! paged by their key where they have a single-column one. Quick filters
+//! narrow the rows with conditions whose values are bound as parameters,
+//! and sorting by a column orders the rows on the server too.
+
+#![forbid(unsafe_code)]
+
+use super::literal::{is_boolean_type, is_numeric_type};
+use super::{quote_ident, quote_literal, sql_literal, DatabaseType};
+use std::fmt;
+
+/// Characters of each cell a table preview reads unless configured otherwise
+pub const DEFAULT_PREVIEW_CELL_CHARS: usize = 8192;
+
+/// Rows of a table preview page unless configured otherwise
+pub const DEFAULT_PREVIEW_PAGE_ROWS: usize = 200;
+
+/// Ends a preview cell the server had more of
+pub const PARTIAL_CELL_MARKER: &str = "…(more)";
+
+/// Tables the planner estimates at this many rows or more are paged with
+/// the estimate as their size, rather than counting every row
+pub const ESTIMATED_ROWS_FROM: usize = 100_000;
+
+/// `table_name`, `schema.table` or a bare table, quoted
+pub(crate) fn quoted_table(database_type: &DatabaseType, table_name: &str) -> String {
+    match table_name.split_once('.') {
+        Some((schema, table)) => quote_ident(database_type, &[schema, table]),
+        None => quote_ident(database_type, &[table_name]),
+    }
+}
+
+/// Query reading the planner's estimate of a table's row count, where the
+/// database keeps one. PostgreSQL reports -1 for a table never analyzed
+pub fn row_estimate_query(database_type: &DatabaseType, table_name: &str) -> Option<String> {
+    match database_type {
+        DatabaseType::PostgreSQL => Some(format!(
+            "SELECT reltuples::bigint FROM pg_class WHERE oid = to_regclass({})",
+            quote_literal(database_type, &quoted_table(database_type, table_name))
+        )),
+        _ => None,
+    }
+}
+
+/// Column a table preview is sorted by on the server
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct PreviewSort {
+    pub column: String,
+    pub descending: bool,
+}
+
+impl PreviewSort {
+    /// Arrow shown after the column's name in the header
+    pub fn arrow(&self) -> &'static str {
+        if self.descending {
+            "▼"
+        } else {
+            "▲"
+        }
+    }
+}
+
+/// How a table preview reads a page
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub enum Paging {
+    /// Skip this many rows, in the order of the first column. The server
+    /// still reads every row skipped, so far pages of big tables get slow
+    Offset(usize),
+    /// Skip this many rows in the order of a chosen column, ties broken by
+    /// the first column so pages don't overlap
+    Sorted { sort: PreviewSort, offset: usize },
+    /// Read from a value of a unique key column in key order, which an
+    /// index answers as quickly on the last page as on the first
+    Keyset {
+        key: String,
+        data_type: String,
+        bound: KeyBound,
+    },
+}
+
+/// Where a keyset page starts
+#[derive(Debug, Clone, PartialEq, Eq, Default)]
+pub enum KeyBound {
+    /// The first page
+    #[default]
+    First,
+    /// Rows with keys after this value
+    After(String),
+    /// The page of rows with keys before this value
+    Before(String),
+}
+
+impl Paging {
+    /// `WHERE`, `ORDER BY` and `LIMIT` of a page of `limit` rows passing
+    /// `filters`, whose values are left as placeholders to bind in order
+    /// (see `filter_values`). A page before a key is read backwards; see
+    /// `reads_backwards`
+    pub fn clauses(
+        &self,
+        database_type: &DatabaseType,
+        filters: &[ColumnFilter],
+        limit: usize,
+    ) -> String {
+        match self {
+            Self::Offset(offset) => format!(
+                "{}ORDER BY 1 LIMIT {limit} OFFSET {offset}",
+                where_clause(database_type, filters, None)
+            ),
+            Self::Sorted { sort, offset } => format!(
+                "{}ORDER BY {} {}, 1 LIMIT {limit} OFFSET {offset}",
+                where_clause(database_type, filters, None),
+                quote_ident(database_type, &[&sort.column]),
+                if sort.descending { "DESC" } else { "ASC" }
+            ),
+            Self::Keyset {
+                key,
+                data_type,
+                bound,
+            } => {
+                let key = quote_ident(database_type, &[key]);
+                // A primary key is never NULL, so a bound of `NULL` is text
+                let literal = |value: &str| sql_literal(database_type, data_type, value);
+                let (bound, order) = match bound {
+                    KeyBound::First => (None, "ASC"),
+                    KeyBound::After(value) => (Some(format!("{key} > {}", literal(value))), "ASC"),
+                    KeyBound::Before(value) => {
+                        (Some(format!("{key} < {}", literal(value))), "DESC")
+                    }
+                };
+                format!(
+                    "{}ORDER BY {key} {order} LIMIT {limit}",
+                    where_clause(database_type, filters, bound)
+                )
+            }
+        }
+    }
+
+    /// Whether the page's rows come last first and need reversing
+    pub fn reads_backwards(&self) -> bool {
+        matches!(
+            self,
+            Self::Keyset {
+                bound: KeyBound::Before(_),
+                ..
+            }
+        )
+    }
+
+    /// Strategy named in the preview's footer
+    pub fn strategy(&self) -> String {
+        match self {
+            Self::Offset(_) => "offset".to_string(),
+            Self::Sorted { sort, .. } => format!("offset, sorted by {}", sort.column),
+            Self::Keyset { key, .. } => format!("keyset on {key}"),
+        }
+    }
+}
+
+impl fmt::Display for Paging {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            Self::Offset(offset) => write!(f, "OFFSET {offset}"),
+            Self::Sorted { sort, offset } => write!(
+                f,
+                "ORDER BY {} {} OFFSET {offset}",
+                sort.column,
+                if sort.descending { "DESC" } else { "ASC" }
+            ),
+            Self::Keyset { key, bound, .. } => match bound {
+                KeyBound::First => write!(f, "BY {key}"),
+                KeyBound::After(value) => write!(f, "BY {key} AFTER {value}"),
+                KeyBound::Before(value) => write!(f, "BY {key} BEFORE {value}"),
+            },
+        }
+    }
+}
+
+/// Comparison of a quick filter
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum FilterOperator {
+    Equals,
+    NotEquals,
+    Like,
+    IsNull,
+    GreaterThan,
+    LessThan,
+}
+
+impl FilterOperator {
+    /// Every operator, in the order the filter form offers them
+    pub const ALL: [Self; 6] = [
+        Self::Equals,
+        Self::NotEquals,
+        Self::Like,
+        Self::IsNull,
+        Self::GreaterThan,
+        Self::LessThan,
+    ];
+
+    pub fn symbol(self) -> &'static str {
+        match self {
+            Self::Equals => "=",
+            Self::NotEquals => "!=",
+            Self::Like => "LIKE",
+            Self::IsNull => "IS NULL",
+            Self::GreaterThan => ">",
+            Self::LessThan => "<",
+        }
+    }
+
+    /// Whether the operator compares with a value
+    pub fn takes_value(self) -> bool {
+        self != Self::IsNull
+    }
+}
+
+/// Condition of a quick filter on a table preview, e.g. `status = 'open'`
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct ColumnFilter {
+    pub column: String,
+    pub data_type: String,
+    pub operator: FilterOperator,
+    /// Compared value; unused by `IS NULL`
+    pub value: String,
+}
+
+impl ColumnFilter {
+    /// The condition, its value as placeholder number `index` (counted from
+    /// 1; only PostgreSQL numbers them). PostgreSQL won't compare a text
+    /// parameter with other types, so the value is cast to the column's
+    /// number or boolean type, and other columns compare as text
+    fn condition(&self, database_type: &DatabaseType, index: usize) -> String {
+        let placeholder = match database_type {
+            DatabaseType::PostgreSQL => format!("${index}"),
+            _ => "?".to_string(),
+        };
+        self.compare(database_type, &placeholder)
+    }
+
+    /// The condition comparing with `placeholder`, a placeholder or literal
+    fn compare(&self, database_type: &DatabaseType, placeholder: &str) -> String {
+        let column = quote_ident(database_type, &[&self.column]);
+        let operator = match self.operator {
+            FilterOperator::IsNull => return format!("{column} IS NULL"),
+            FilterOperator::Like => {
+                return format!("{} LIKE {placeholder}", text_column(database_type, &column))
+            }
+            FilterOperator::NotEquals => "<>",
+            other => other.symbol(),
+        };
+        match database_type {
+            DatabaseType::PostgreSQL if is_numeric_type(&self.data_type) => {
+                format!("{column} {operator} {placeholder}::numeric")
+            }
+            DatabaseType::PostgreSQL if is_boolean_type(&self.data_type) => {
+                format!("{column} {operator} {placeholder}::boolean")
+            }
+            DatabaseType::PostgreSQL => format!("{column}::text {operator} {placeholder}"),
+            _ => format!("{column} {operator} {placeholder}"),
+        }
+    }
+}
+
+impl fmt::Display for ColumnFilter {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        if self.operator.takes_value() {
+            write!(
+                f,
+                "{} {} '{}'",
+                self.column,
+                self.operator.symbol(),
+                self.value
+            )
+        } else {
+            write!(f, "{} {}", self.column, self.operator.symbol())
+        }
+    }
+}
+
+/// `WHERE` clause, with a trailing space, of the filters ANDed together
+/// with `extra`; empty when there's no condition
+pub fn where_clause(
+    database_type: &DatabaseType,
+    filters: &[ColumnFilter],
+    extra: Option<String>,
+) -> String {
+    let mut index = 0;
+    let conditions: Vec<String> = filters
+        .iter()
+        .map(|filter| {
+            if filter.operator.takes_value() {
+                index += 1;
+            }
+            filter.condition(database_type, index)
+        })
+        .chain(extra)
+        .collect();
+    if conditions.is_empty() {
+        String::new()
+    } else {
+        format!("WHERE {} ", conditions.join(" AND "))
+    }
+}
+
+/// Query reading every row of `table_name` passing `filters`, in the
+/// preview's order, for exports. There's no LIMIT and the values are read
+/// whole. The filter values are written in as literals, since a streamed
+/// query binds no parameters
+pub fn export_query(
+    database_type: &DatabaseType,
+    table_name: &str,
+    filters: &[ColumnFilter],
+    sort: Option<&PreviewSort>,
+) -> String {
+    let conditions: Vec<String> = filters
+        .iter()
+        .map(|filter| filter.compare(database_type, &quote_literal(database_type, &filter.value)))
+        .collect();
+    let mut query = format!("SELECT * FROM {}", quoted_table(database_type, table_name));
+    if !conditions.is_empty() {
+        query.push_str(&format!(" WHERE {}", conditions.join(" AND ")));
+    }
+    match sort {
+        Some(sort) => query.push_str(&format!(
+            " ORDER BY {} {}, 1",
+            quote_ident(database_type, &[&sort.column]),
+            if sort.descending { "DESC" } else { "ASC" }
+        )),
+        None => query.push_str(" ORDER BY 1"),
+    }
+    query
+}
+
+/// Values to bind to the filters' placeholders, in order
+pub fn filter_values(filters: &[ColumnFilter]) -> Vec<&str> {
+    filters
+        .iter()
+        .filter(|filter| filter.operator.takes_value())
+        .map(|filter| filter.value.as_str())
+        .collect()
+}
+
+/// `column` (already quoted) read as text
+pub fn text_column(database_type: &DatabaseType, column: &str) -> String {
+    match database_type {
+        DatabaseType::MySQL | DatabaseType::MariaDB => format!("CAST({column} AS CHAR)"),
+        DatabaseType::SQLite => format!("CAST({column} AS TEXT)"),
+        _ => format!("{column}::text"),
+    }
+}
+
+/// Select-list item reading `column` (already quoted) as text for a
+/// preview. One character past `max_chars` is read, so a cut value can be
+/// told from one exactly `max_chars` long. Zero reads the whole value
+pub fn preview_column(database_type: &DatabaseType, column: &str, max_chars: usize) -> String {
+    let text = text_column(database_type, column);
+    if max_chars == 0 {
+        return text;
+    }
+    let keep = max_chars + 1;
+    match database_type {
+        DatabaseType::MySQL | DatabaseType::MariaDB => format!("LEFT({text}, {keep})"),
+        DatabaseType::SQLite => format!("substr({text}, 1, {keep})"),
+        _ => format!("left({text}, {keep})"),
+    }
+}
+
+/// Cut a value read with `preview_column` to `max_chars`, marking it when
+/// the server had more
+pub fn mark_partial(value: String, max_chars: usize) -> String {
+    if max_chars == 0 {
+        return value;
+    }
+    match value.char_indices().nth(max_chars) {
+        Some((end, _)) => format!("{}{PARTIAL_CELL_MARKER}", &value[..end]),
+        None => value,
+    }
+}
+
+/// Whether a preview cell was cut short
+pub fn is_partial(value: &str) -> bool {
+    value.ends_with(PARTIAL_CELL_MARKER)
+}
+
+/// Query reading `columns` of `table_name` in full, as text, from the row
+/// with the given primary key. The key is `(column, data type, value)`
+pub fn full_values_query(
+    database_type: &DatabaseType,
+    table_name: &str,
+    columns: &[&str],
+    key: &[(&str, &str, &str)],
+) -> String {
+    let table = quoted_table(database_type, table_name);
+    let select_list = columns
+        .iter()
+        .map(|column| text_column(database_type, &quote_ident(database_type, &[column])))
+        .collect::<Vec<_>>()
+        .join(", ");
+    let condition = key_condition(database_type, key);
+    format!("SELECT {select_list} FROM {table} WHERE {condition}")
+}
+
+/// Query reading the bytes of `column` of `table_name` in full, as hex,
+/// from the row with the given primary key
+pub fn hex_value_query(
+    database_type: &DatabaseType,
+    table_name: &str,
+    column: &str,
+    key: &[(&str, &str, &str)],
+) -> String {
+    let table = quoted_table(database_type, table_name);
+    let column = quote_ident(database_type, &[column]);
+    let hex = match database_type {
+        DatabaseType::MySQL | DatabaseType::MariaDB => format!("HEX({column})"),
+        DatabaseType::SQLite => format!("hex({column})"),
+        _ => format!("encode({column}, 'hex')"),
+    };
+    let condition = key_condition(database_type, key);
+    format!("SELECT {hex} FROM {table} WHERE {condition}")
+}
+
+/// Condition picking out the row with the given primary key
+fn key_condition(database_type: &DatabaseType, key: &[(&str, &str, &str)]) -> String {
+    key.iter()
+        .map(|(column, data_type, value)| {
+            format!(
+                "{} = {}",
+                quote_ident(database_type, &[column]),
+                sql_literal(database_type, data_type, value)
+            )
+        })
+        .collect::<Vec<_>>()
+        .join(" AND ")
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_preview_column_cuts_on_the_server() {
+        assert_eq!(
+            preview_column(&DatabaseType::PostgreSQL, "\"body\"", 8192),
+            "left(\"body\"::text, 8193)"
+        );
+        assert_eq!(
+            preview_column(&DatabaseType::MySQL, "`body`", 10),
+            "LEFT(CAST(`body` AS CHAR), 11)"
+        );
+        assert_eq!(
+            preview_column(&DatabaseType::SQLite, "\"body\"", 10),
+            "substr(CAST(\"body\" AS TEXT), 1, 11)"
+        );
+        assert_eq!(
+            preview_column(&DatabaseType::PostgreSQL, "\"body\"", 0),
+            "\"body\"::text"
+        );
+    }
+
+    #[test]
+    fn test_row_estimate_query() {
+        assert_eq!(
+            row_estimate_query(&DatabaseType::PostgreSQL, "sales.Orders").as_deref(),
+            Some(
+                "SELECT reltuples::bigint FROM pg_class \
+                 WHERE oid = to_regclass('\"sales\".\"Orders\"')"
+            )
+        );
+        assert_eq!(row_estimate_query(&DatabaseType::SQLite, "orders"), None);
+    }
+
+    #[test]
+    fn test_paging_clauses() {
+        let db = DatabaseType::PostgreSQL;
+        assert_eq!(
+            Paging::Offset(40).clauses(&db, &[], 20),
+            "ORDER BY 1 LIMIT 20 OFFSET 40"
+        );
+
+        let keyset = |bound| Paging::Keyset {
+            key: "id".to_string(),
+            data_type: "bigint".to_string(),
+            bound,
+        };
+        assert_eq!(
+            keyset(KeyBound::First).clauses(&db, &[], 20),
+            "ORDER BY \"id\" ASC LIMIT 20"
+        );
+        assert_eq!(
+            keyset(KeyBound::After("40".to_string())).clauses(&db, &[], 20),
+            "WHERE \"id\" > 40 ORDER BY \"id\" ASC LIMIT 20"
+        );
+        let before = keyset(KeyBound::Before("41".to_string()));
+        assert_eq!(
+            before.clauses(&DatabaseType::MySQL, &[], 20),
+            "WHERE `id` < 41 ORDER BY `id` DESC LIMIT 20"
+        );
+        assert!(before.reads_backwards());
+        assert_eq!(before.strategy(), "keyset on id");
+        assert_eq!(before.to_string(), "BY id BEFORE 41");
+
+        let code = Paging::Keyset {
+            key: "code".to_string(),
+            data_type: "text".to_string(),
+            bound: KeyBound::After("NULL".to_string()),
+        };
+        assert_eq!(
+            code.clauses(&db, &[], 20),
+            "WHERE \"code\" > 'NULL' ORDER BY \"code\" ASC LIMIT 20"
+        );
+    }
+
+    #[test]
+    fn test_filters_bind_their_values() {
+        let filter = |column: &str, data_type: &str, operator, value: &str| ColumnFilter {
+            column: column.to_string(),
+            data_type: data_type.to_string(),
+            operator,
+            value: value.to_string(),
+        };
+        let filters = [
+            filter("status", "text", FilterOperator::NotEquals, "closed"),
+            filter("deleted_at", "timestamp", FilterOperator::IsNull, ""),
+            filter(
+                "total",
+                "numeric(10,2)",
+                FilterOperator::GreaterThan,
+                "99.5",
+            ),
+            filter("title", "text", FilterOperator::Like, "%'; DROP%"),
+        ];
+        let keyset = Paging::Keyset {
+            key: "id".to_string(),
+            data_type: "integer".to_string(),
+            bound: KeyBound::After("7".to_string()),
+        };
+
+        assert_eq!(
+            keyset.clauses(&DatabaseType::PostgreSQL, &filters, 20),
+            "WHERE \"status\"::text <> $1 AND \"deleted_at\" IS NULL \
+             AND \"total\" > $2::numeric AND \"title\"::text LIKE $3 \
+             AND \"id\" > 7 ORDER BY \"id\" ASC LIMIT 20"
+        );
+        assert_eq!(
+            where_clause(&DatabaseType::SQLite, &filters[2..], None),
+            "WHERE \"total\" > ? AND CAST(\"title\" AS TEXT) LIKE ? "
+        );
+        let sorted = Paging::Sorted {
+            sort: PreviewSort {
+                column: "total".to_string(),
+                descending: true,
+            },
+            offset: 40,
+        };
+        assert_eq!(
+            sorted.clauses(&DatabaseType::MySQL, &filters[..1], 20),
+            "WHERE `status` <> ? ORDER BY `total` DESC, 1 LIMIT 20 OFFSET 40"
+        );
+        assert_eq!(sorted.strategy(), "offset, sorted by total");
+        assert_eq!(filter_values(&filters), ["closed", "99.5", "%'; DROP%"]);
+        assert_eq!(filters[0].to_string(), "status != 'closed'");
+        assert_eq!(filters[1].to_string(), "deleted_at IS NULL");
+    }
+
+    #[test]
+    fn test_export_query_writes_filter_values_in() {
+        let filters = [
+            ColumnFilter {
+                column: "title".to_string(),
+                data_type: "text".to_string(),
+                operator: FilterOperator::Like,
+                value: "it's%".to_string(),
+            },
+            ColumnFilter {
+                column: "total".to_string(),
+                data_type: "numeric".to_string(),
+                operator: FilterOperator::GreaterThan,
+                value: "10".to_string(),
+            },
+        ];
+        let sort = PreviewSort {
+            column: "total".to_string(),
+            descending: true,
+        };
+        assert_eq!(
+            export_query(
+                &DatabaseType::PostgreSQL,
+                "shop.orders",
+                &filters,
+                Some(&sort)
+            ),
+            "SELECT * FROM \"shop\".\"orders\" WHERE \"title\"::text LIKE 'it''s%' \
+             AND \"total\" > '10'::numeric ORDER BY \"total\" DESC, 1"
+        );
+        assert_eq!(
+            export_query(&DatabaseType::MySQL, "orders", &[], None),
+            "SELECT * FROM `orders` ORDER BY 1"
+        );
+    }
+
+    #[test]
+    fn test_mark_partial() {
+        assert_eq!(mark_partial("héllo".to_string(), 5), "héllo");
+        let cut = mark_partial("héllo!".to_string(), 5);
+        assert_eq!(cut, "héllo…(more)");
+        assert!(is_partial(&cut));
+        assert_eq!(mark_partial("héllo!".to_string(), 0), "héllo!");
+    }
+
+    #[test]
+    fn test_full_values_query_by_primary_key() {
+        assert_eq!(
+            full_values_query(
+                &DatabaseType::PostgreSQL,
+                "docs.pages",
+                &["body", "raw"],
+                &[("id", "integer", "42"), ("lang", "text", "it's")],
+            ),
+            "SELECT \"body\"::text, \"raw\"::text FROM \"docs\".\"pages\" \
+             WHERE \"id\" = 42 AND \"lang\" = 'it''s'"
+        );
+    }
+    #[test]
+    fn test_hex_value_query_by_primary_key() {
+        assert_eq!(
+            hex_value_query(
+                &DatabaseType::PostgreSQL,
+                "docs.files",
+                "content",
+                &[("id", "integer", "7")]
+            ),
+            "SELECT encode(\"content\", 'hex') FROM \"docs\".\"files\" WHERE \"id\" = 7"
+        );
+        assert_eq!(
+            hex_value_query(
+                &DatabaseType::MySQL,
+                "files",
+                "content",
+                &[("id", "int", "7")]
+            ),
+            "SELECT HEX(`content`) FROM `files` WHERE `id` = 7"
+        );
+    }
+}
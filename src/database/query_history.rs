@@ -1,12 +1,21 @@
 // FilePath: src/database/query_history.rs
 
+//! Query history
+//!
+//! Every statement the query editor runs successfully is kept in a SQLite
+//! database in the data directory, with the connection it ran on, so
+//! `Ctrl+R` can bring it back. Running the same statement on the same
+//! connection again only moves it to the top, and the oldest entries are
+//! dropped past `[app] query_history_limit`. SQLite writes each change in a
+//! transaction, so a crash mid-write leaves the history as it was.
+
 #![forbid(unsafe_code)]
 
 use crate::core::error::{LazyTablesError, Result};
 use crate::database::DatabaseType;
 use chrono::{DateTime, Utc};
 use serde::{Deserialize, Serialize};
-use sqlx::sqlite::SqliteConnectOptions;
+use sqlx::sqlite::{SqliteConnectOptions, SqliteRow};
 use sqlx::{Row, SqlitePool};
 use std::path::PathBuf;
 
@@ -15,6 +24,8 @@ use std::path::PathBuf;
 pub struct QueryHistoryEntry {
     pub id: i64,
     pub query_text: String,
+    /// Name of the connection the query ran on
+    pub connection_name: Option<String>,
     pub database_type: DatabaseType,
     pub database_name: Option<String>,
     #[serde(with = "chrono::serde::ts_seconds")]
@@ -25,6 +36,7 @@ pub struct QueryHistoryEntry {
 }
 
 /// Query history manager for local SQLite storage
+#[derive(Debug, Clone)]
 pub struct QueryHistoryManager {
     pool: Option<SqlitePool>,
     db_path: PathBuf,
@@ -33,14 +45,10 @@ pub struct QueryHistoryManager {
 impl QueryHistoryManager {
     /// Create a new query history manager
     pub fn new() -> Result<Self> {
-        let home_dir = dirs::home_dir().ok_or_else(|| {
-            LazyTablesError::Config("Could not determine home directory".to_string())
-        })?;
+        let data_dir = crate::config::Config::data_dir();
+        std::fs::create_dir_all(&data_dir)?;
 
-        let lazytables_dir = home_dir.join(".lazytables");
-        std::fs::create_dir_all(&lazytables_dir)?;
-
-        let db_path = lazytables_dir.join("query_history.db");
+        let db_path = data_dir.join("query_history.db");
 
         Ok(Self {
             pool: None,
@@ -73,6 +81,7 @@ impl QueryHistoryManager {
             CREATE TABLE IF NOT EXISTS query_history (
                 id INTEGER PRIMARY KEY AUTOINCREMENT,
                 query_text TEXT NOT NULL,
+                connection_name TEXT,
                 database_type TEXT NOT NULL,
                 database_name TEXT,
                 executed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
@@ -103,10 +112,14 @@ impl QueryHistoryManager {
         Ok(())
     }
 
-    /// Add a query to history
+    /// Add a query to history. The same query run again on the same
+    /// connection right after isn't added twice: its entry is moved to the
+    /// top instead
+    #[allow(clippy::too_many_arguments)]
     pub async fn add_query(
         &self,
         query_text: &str,
+        connection_name: Option<&str>,
         database_type: DatabaseType,
         database_name: Option<&str>,
         execution_time_ms: Option<i64>,
@@ -117,14 +130,41 @@ impl QueryHistoryManager {
             LazyTablesError::Config("Query history database not initialized".to_string())
         })?;
 
+        let latest = sqlx::query(
+            "SELECT id, query_text, connection_name FROM query_history ORDER BY executed_at DESC, id DESC LIMIT 1",
+        )
+        .fetch_optional(pool)
+        .await
+        .map_err(|e| LazyTablesError::Config(format!("Failed to read query history: {}", e)))?;
+        if let Some(latest) = latest.filter(|row| {
+            row.get::<String, _>("query_text") == query_text
+                && row.get::<Option<String>, _>("connection_name").as_deref() == connection_name
+        }) {
+            let id: i64 = latest.get("id");
+            sqlx::query(
+                "UPDATE query_history SET executed_at = CURRENT_TIMESTAMP, execution_time_ms = ?, success = ?, error_message = ? WHERE id = ?",
+            )
+            .bind(execution_time_ms)
+            .bind(success)
+            .bind(error_message)
+            .bind(id)
+            .execute(pool)
+            .await
+            .map_err(|e| {
+                LazyTablesError::Config(format!("Failed to add query to history: {}", e))
+            })?;
+            return Ok(id);
+        }
+
         let result = sqlx::query(
             r#"
             INSERT INTO query_history
-            (query_text, database_type, database_name, execution_time_ms, success, error_message)
-            VALUES (?, ?, ?, ?, ?, ?)
+            (query_text, connection_name, database_type, database_name, execution_time_ms, success, error_message)
+            VALUES (?, ?, ?, ?, ?, ?, ?)
             "#,
         )
         .bind(query_text)
+        .bind(connection_name)
         .bind(database_type.display_name())
         .bind(database_name)
         .bind(execution_time_ms)
@@ -149,11 +189,11 @@ impl QueryHistoryManager {
 
         let (query, params): (String, Vec<String>) = match database_type_filter {
             Some(db_type) => (
-                "SELECT * FROM query_history WHERE database_type = ? ORDER BY executed_at DESC LIMIT ?".to_string(),
+                "SELECT * FROM query_history WHERE database_type = ? ORDER BY executed_at DESC, id DESC LIMIT ?".to_string(),
                 vec![db_type.display_name().to_string(), limit.unwrap_or(50).to_string()]
             ),
             None => (
-                "SELECT * FROM query_history ORDER BY executed_at DESC LIMIT ?".to_string(),
+                "SELECT * FROM query_history ORDER BY executed_at DESC, id DESC LIMIT ?".to_string(),
                 vec![limit.unwrap_or(50).to_string()]
             ),
         };
@@ -167,41 +207,8 @@ impl QueryHistoryManager {
             LazyTablesError::Config(format!("Failed to fetch query history: {}", e))
         })?;
 
-        let mut entries = Vec::new();
-        for row in rows {
-            let database_type_str: String = row.get("database_type");
-            let database_type = match database_type_str.as_str() {
-                "postgres" => DatabaseType::PostgreSQL,
-                "mysql" => DatabaseType::MySQL,
-                "mariadb" => DatabaseType::MariaDB,
-                "sqlite" => DatabaseType::SQLite,
-                "oracle" => DatabaseType::Oracle,
-                "redis" => DatabaseType::Redis,
-                "mongodb" => DatabaseType::MongoDB,
-                _ => continue, // Skip unknown database types
-            };
-
-            let executed_at_str: String = row.get("executed_at");
-            let executed_at = DateTime::parse_from_rfc3339(&executed_at_str)
-                .unwrap_or_else(|_| {
-                    DateTime::parse_from_str(&executed_at_str, "%Y-%m-%d %H:%M:%S%.f")
-                        .unwrap_or_default()
-                })
-                .with_timezone(&Utc);
-
-            entries.push(QueryHistoryEntry {
-                id: row.get("id"),
-                query_text: row.get("query_text"),
-                database_type,
-                database_name: row.get("database_name"),
-                executed_at,
-                execution_time_ms: row.get("execution_time_ms"),
-                success: row.get("success"),
-                error_message: row.get("error_message"),
-            });
-        }
-
-        Ok(entries)
+        // Skip unknown database types
+        Ok(rows.iter().filter_map(entry_from_row).collect())
     }
 
     /// Get recent queries for a specific database type
@@ -227,7 +234,7 @@ impl QueryHistoryManager {
 
         let (query, params): (String, Vec<String>) = match database_type_filter {
             Some(db_type) => (
-                "SELECT * FROM query_history WHERE query_text LIKE ? AND database_type = ? ORDER BY executed_at DESC LIMIT ?".to_string(),
+                "SELECT * FROM query_history WHERE query_text LIKE ? AND database_type = ? ORDER BY executed_at DESC, id DESC LIMIT ?".to_string(),
                 vec![
                     format!("%{}%", search_term),
                     db_type.display_name().to_string(),
@@ -235,7 +242,7 @@ impl QueryHistoryManager {
                 ]
             ),
             None => (
-                "SELECT * FROM query_history WHERE query_text LIKE ? ORDER BY executed_at DESC LIMIT ?".to_string(),
+                "SELECT * FROM query_history WHERE query_text LIKE ? ORDER BY executed_at DESC, id DESC LIMIT ?".to_string(),
                 vec![
                     format!("%{}%", search_term),
                     limit.unwrap_or(50).to_string()
@@ -252,41 +259,7 @@ impl QueryHistoryManager {
             LazyTablesError::Config(format!("Failed to search query history: {}", e))
         })?;
 
-        let mut entries = Vec::new();
-        for row in rows {
-            let database_type_str: String = row.get("database_type");
-            let database_type = match database_type_str.as_str() {
-                "postgres" => DatabaseType::PostgreSQL,
-                "mysql" => DatabaseType::MySQL,
-                "mariadb" => DatabaseType::MariaDB,
-                "sqlite" => DatabaseType::SQLite,
-                "oracle" => DatabaseType::Oracle,
-                "redis" => DatabaseType::Redis,
-                "mongodb" => DatabaseType::MongoDB,
-                _ => continue,
-            };
-
-            let executed_at_str: String = row.get("executed_at");
-            let executed_at = DateTime::parse_from_rfc3339(&executed_at_str)
-                .unwrap_or_else(|_| {
-                    DateTime::parse_from_str(&executed_at_str, "%Y-%m-%d %H:%M:%S%.f")
-                        .unwrap_or_default()
-                })
-                .with_timezone(&Utc);
-
-            entries.push(QueryHistoryEntry {
-                id: row.get("id"),
-                query_text: row.get("query_text"),
-                database_type,
-                database_name: row.get("database_name"),
-                executed_at,
-                execution_time_ms: row.get("execution_time_ms"),
-                success: row.get("success"),
-                error_message: row.get("error_message"),
-            });
-        }
-
-        Ok(entries)
+        Ok(rows.iter().filter_map(entry_from_row).collect())
     }
 
     /// Remove duplicate queries (keep most recent)
@@ -322,7 +295,7 @@ impl QueryHistoryManager {
             DELETE FROM query_history
             WHERE id NOT IN (
                 SELECT id FROM query_history
-                ORDER BY executed_at DESC
+                ORDER BY executed_at DESC, id DESC
                 LIMIT ?
             )
             "#,
@@ -336,6 +309,44 @@ impl QueryHistoryManager {
     }
 }
 
+/// The entry a `query_history` row holds; None for an unknown database type
+fn entry_from_row(row: &SqliteRow) -> Option<QueryHistoryEntry> {
+    let database_type_str: String = row.get("database_type");
+    let database_type = match database_type_str.as_str() {
+        "postgres" => DatabaseType::PostgreSQL,
+        "mysql" => DatabaseType::MySQL,
+        "mariadb" => DatabaseType::MariaDB,
+        "sqlite" => DatabaseType::SQLite,
+        "oracle" => DatabaseType::Oracle,
+        "redis" => DatabaseType::Redis,
+        "mongodb" => DatabaseType::MongoDB,
+        _ => return None,
+    };
+
+    let executed_at_str: String = row.get("executed_at");
+    let executed_at = DateTime::parse_from_rfc3339(&executed_at_str)
+        .unwrap_or_else(|_| {
+            DateTime::parse_from_str(
+                &format!("{executed_at_str} +0000"),
+                "%Y-%m-%d %H:%M:%S%.f %z",
+            )
+            .unwrap_or_default()
+        })
+        .with_timezone(&Utc);
+
+    Some(QueryHistoryEntry {
+        id: row.get("id"),
+        query_text: row.get("query_text"),
+        connection_name: row.get("connection_name"),
+        database_type,
+        database_name: row.get("database_name"),
+        executed_at,
+        execution_time_ms: row.get("execution_time_ms"),
+        success: row.get("success"),
+        error_message: row.get("error_message"),
+    })
+}
+
 impl Default for QueryHistoryManager {
     fn default() -> Self {
         Self::new().unwrap_or_else(|_| Self {
@@ -365,6 +376,7 @@ mod tests {
         let id = manager
             .add_query(
                 "SELECT * FROM users",
+                Some("local"),
                 DatabaseType::PostgreSQL,
                 Some("test_db"),
                 Some(150),
@@ -379,6 +391,49 @@ mod tests {
         assert_eq!(history.len(), 1);
         assert_eq!(history[0].query_text, "SELECT * FROM users");
         assert_eq!(history[0].database_type, DatabaseType::PostgreSQL);
+        assert_eq!(history[0].connection_name.as_deref(), Some("local"));
+
+        Ok(())
+    }
+
+    #[tokio::test]
+    async fn test_repeated_query_is_added_once() -> Result<()> {
+        let temp_dir = tempdir().unwrap();
+        let mut manager = QueryHistoryManager {
+            pool: None,
+            db_path: temp_dir.path().join("test_repeat.db"),
+        };
+        manager.initialize().await?;
+
+        for (query, connection) in [
+            ("SELECT 1", "local"),
+            ("SELECT 1", "local"),
+            ("SELECT 1", "staging"),
+            ("SELECT 2", "staging"),
+        ] {
+            manager
+                .add_query(
+                    query,
+                    Some(connection),
+                    DatabaseType::PostgreSQL,
+                    None,
+                    Some(1),
+                    true,
+                    None,
+                )
+                .await?;
+        }
+        manager.clear_old_history(2).await?;
+
+        let history = manager.get_history(None, Some(10)).await?;
+        let queries: Vec<(&str, Option<&str>)> = history
+            .iter()
+            .map(|entry| (entry.query_text.as_str(), entry.connection_name.as_deref()))
+            .collect();
+        assert_eq!(
+            queries,
+            [("SELECT 2", Some("staging")), ("SELECT 1", Some("staging"))]
+        );
 
         Ok(())
     }
@@ -399,6 +454,7 @@ mod tests {
         manager
             .add_query(
                 "SELECT * FROM postgres_table",
+                None,
                 DatabaseType::PostgreSQL,
                 Some("pg_db"),
                 Some(100),
@@ -410,6 +466,7 @@ mod tests {
         manager
             .add_query(
                 "SELECT * FROM mysql_table",
+                None,
                 DatabaseType::MySQL,
                 Some("mysql_db"),
                 Some(200),
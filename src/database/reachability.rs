@@ -0,0 +1,109 @@
+// FilePath: src/database/reachability.rs
+
+//! Reachability of saved connections, checked at startup
+//!
+//! A ping opens a TCP connection to the server's port and closes it again,
+//! without logging in, so it needs no password and can't lock an account
+//! out. It tells a server that's down, or behind a VPN that isn't up, from
+//! one that answers, and how long it took to answer. A SQLite connection is
+//! reachable when its file exists.
+
+#![forbid(unsafe_code)]
+
+use super::{ConnectionConfig, DatabaseType};
+use std::path::Path;
+use std::time::{Duration, Instant};
+use tokio::net::TcpStream;
+
+/// Longest wait for a server to accept the connection
+pub const PING_TIMEOUT: Duration = Duration::from_secs(2);
+
+/// Servers pinged at the same time, so a long list doesn't open a burst of
+/// connections at once
+pub const PING_CONCURRENCY: usize = 4;
+
+/// What the last ping of a connection found
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub enum Reachability {
+    /// Waiting for its turn or an answer
+    Pinging,
+    /// Answered after this long
+    Reachable(Duration),
+    /// Didn't answer, for this reason
+    Unreachable(String),
+}
+
+impl Reachability {
+    /// Marker shown next to the connection, e.g. `● 12ms`
+    pub fn marker(&self) -> String {
+        match self {
+            Self::Pinging => "◌ pinging".to_string(),
+            Self::Reachable(latency) => format!("● {}ms", latency.as_millis()),
+            Self::Unreachable(_) => "○ unreachable".to_string(),
+        }
+    }
+}
+
+/// Ping the connection's server, giving up after `timeout`
+pub async fn ping(config: &ConnectionConfig, timeout: Duration) -> Reachability {
+    if config.database_type == DatabaseType::SQLite {
+        let path = config.database_or_default();
+        return if Path::new(path).exists() {
+            Reachability::Reachable(Duration::ZERO)
+        } else {
+            Reachability::Unreachable(format!("{path} doesn't exist"))
+        };
+    }
+    let started = Instant::now();
+    let address = (config.host.as_str(), config.port);
+    match tokio::time::timeout(timeout, TcpStream::connect(address)).await {
+        Ok(Ok(_stream)) => Reachability::Reachable(started.elapsed()),
+        Ok(Err(e)) => Reachability::Unreachable(e.to_string()),
+        Err(_) => Reachability::Unreachable(format!("no answer within {}s", timeout.as_secs())),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn config(database_type: DatabaseType, port: u16) -> ConnectionConfig {
+        ConnectionConfig::new(
+            "local".to_string(),
+            database_type,
+            "127.0.0.1".to_string(),
+            port,
+            "app".to_string(),
+        )
+    }
+
+    #[tokio::test]
+    async fn test_ping_listening_server() {
+        let listener = tokio::net::TcpListener::bind("127.0.0.1:0").await.unwrap();
+        let port = listener.local_addr().unwrap().port();
+        let reachability = ping(&config(DatabaseType::PostgreSQL, port), PING_TIMEOUT).await;
+        assert!(matches!(reachability, Reachability::Reachable(_)));
+
+        drop(listener);
+        let reachability = ping(&config(DatabaseType::PostgreSQL, port), PING_TIMEOUT).await;
+        assert!(matches!(reachability, Reachability::Unreachable(_)));
+        assert_eq!(reachability.marker(), "○ unreachable");
+    }
+
+    #[tokio::test]
+    async fn test_ping_sqlite_file() {
+        let file = tempfile::NamedTempFile::new().unwrap();
+        let mut sqlite = config(DatabaseType::SQLite, 0);
+        sqlite.database = Some(file.path().display().to_string());
+        assert_eq!(
+            ping(&sqlite, PING_TIMEOUT).await,
+            Reachability::Reachable(Duration::ZERO)
+        );
+
+        sqlite.database = Some("/nonexistent/app.db".to_string());
+        assert!(matches!(
+            ping(&sqlite, PING_TIMEOUT).await,
+            Reachability::Unreachable(_)
+        ));
+    }
+}
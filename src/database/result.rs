@@ -0,0 +1,495 @@
+// FilePath: src/database/result.rs
+
+#![forbid(unsafe_code)]
+
+use crate::core::error::Result;
+use crate::database::{RowSink, ServerNotice, TableMetadata};
+use std::collections::{BTreeMap, HashMap, HashSet};
+use std::sync::{
+    atomic::{AtomicBool, Ordering},
+    Arc,
+};
+use std::time::Duration;
+
+/// Cell text the grid uses for SQL NULL; every driver writes NULL values
+/// into results this way
+pub const NULL_CELL: &str = "NULL";
+
+/// Rows read between progress reports from a `ProgressCollector`
+pub const PROGRESS_EVERY_ROWS: usize = 500;
+
+/// Limits applied while collecting a query result, so a single unbounded
+/// SELECT can't exhaust memory. Zero disables a limit
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub struct ResultLimits {
+    /// Rows to keep before the cursor is closed
+    pub max_rows: usize,
+    /// Bytes of a single cell value kept for display
+    pub max_cell_bytes: usize,
+}
+
+impl Default for ResultLimits {
+    fn default() -> Self {
+        Self {
+            max_rows: 10_000,
+            max_cell_bytes: 64 * 1024,
+        }
+    }
+}
+
+/// A collected query result
+#[derive(Debug, Clone, Default)]
+pub struct QueryResult {
+    pub columns: Vec<String>,
+    /// Rows for display; oversized cells end with a "…(+N MB)" marker
+    pub rows: Vec<Vec<String>>,
+    /// Set when the row limit was reached and the remaining rows were skipped
+    pub truncated: bool,
+    /// Set when the fetch was stopped by the user before the last row
+    pub stopped: bool,
+    /// Cells shortened for display, as (row, column); their full values
+    /// aren't kept and are read again with a `CellReader` when needed
+    pub cut_cells: HashSet<(usize, usize)>,
+    /// Full values of cut cells kept anyway, by (row, column): only the
+    /// lone cell of a result with one row and one column, which is shown as
+    /// a JSON document or query plan and has to be parsed whole
+    pub full_values: HashMap<(usize, usize), String>,
+    /// Result sets after this one, from multi-statement queries and stored
+    /// procedures
+    pub more_results: Vec<QueryResult>,
+    /// Notices and warnings the server sent while running the query; kept
+    /// on the first result set only
+    pub notices: Vec<ServerNotice>,
+    /// Rows changed by the statements of this result set that returned none
+    pub affected_rows: Option<u64>,
+    /// Time the server took to run the statement and send every row read;
+    /// kept on the statement's first result set only
+    pub elapsed: Option<Duration>,
+    /// Position of the statement that returned the result set in a script
+    /// run statement by statement, and the script's number of statements.
+    /// Position 0 is the summary of the script's statements
+    pub statement: Option<(usize, usize)>,
+}
+
+impl QueryResult {
+    /// Number of result sets, this one included
+    pub fn result_set_count(&self) -> usize {
+        1 + self.more_results.len()
+    }
+}
+
+/// Row sink that collects a result within the given limits
+#[derive(Debug)]
+pub struct CappedCollector {
+    limits: ResultLimits,
+    result: QueryResult,
+    /// Result sets completed before the current one
+    finished: Vec<QueryResult>,
+    notices: Vec<ServerNotice>,
+    elapsed: Option<Duration>,
+}
+
+impl CappedCollector {
+    pub fn new(limits: ResultLimits) -> Self {
+        Self {
+            limits,
+            result: QueryResult::default(),
+            finished: Vec::new(),
+            notices: Vec::new(),
+            elapsed: None,
+        }
+    }
+
+    /// The collected result, with any further result sets in `more_results`
+    pub fn finish(self) -> QueryResult {
+        let mut sets = self.finished.into_iter();
+        let mut result = match sets.next() {
+            Some(mut first) => {
+                first.more_results = sets.chain(std::iter::once(self.result)).collect();
+                first
+            }
+            None => self.result,
+        };
+        result.notices = self.notices;
+        result.elapsed = self.elapsed;
+        result
+    }
+}
+
+impl RowSink for CappedCollector {
+    fn columns(&mut self, columns: &[String]) -> Result<()> {
+        self.result.columns = columns.to_vec();
+        Ok(())
+    }
+
+    fn row(&mut self, mut row: Vec<String>) -> Result<()> {
+        if self.limits.max_rows > 0 && self.result.rows.len() >= self.limits.max_rows {
+            // One row past the cap proves there was more to read
+            self.result.truncated = true;
+            self.result.full_values.clear();
+            return Ok(());
+        }
+
+        let row_index = self.result.rows.len();
+        // A second row means the first one's cell no longer stands alone
+        if row_index == 1 {
+            self.result.full_values.clear();
+        }
+        let lone_cell = row_index == 0 && row.len() == 1;
+        for (col, value) in row.iter_mut().enumerate() {
+            if let Some(display) = truncate_cell(value, self.limits.max_cell_bytes) {
+                let full = std::mem::replace(value, display);
+                if lone_cell {
+                    self.result.full_values.insert((row_index, col), full);
+                }
+                self.result.cut_cells.insert((row_index, col));
+            }
+        }
+        self.result.rows.push(row);
+        Ok(())
+    }
+
+    fn next_result_set(&mut self) -> Result<()> {
+        self.finished.push(std::mem::take(&mut self.result));
+        Ok(())
+    }
+
+    fn notices(&mut self, notices: Vec<ServerNotice>) -> Result<()> {
+        self.notices.extend(notices);
+        Ok(())
+    }
+
+    fn affected_rows(&mut self, rows: u64) -> Result<()> {
+        *self.result.affected_rows.get_or_insert(0) += rows;
+        Ok(())
+    }
+
+    fn elapsed(&mut self, elapsed: Duration) -> Result<()> {
+        self.elapsed = Some(elapsed);
+        Ok(())
+    }
+
+    fn is_full(&self) -> bool {
+        self.result.truncated
+    }
+}
+
+/// Row sink reading chosen cells of one result set in full, for the cells a
+/// `CappedCollector` cut short. Rows are counted as the collector counted
+/// them, so the query has to return them in the same order; reading stops
+/// after the last row wanted
+#[derive(Debug)]
+pub struct CellReader {
+    result_set: usize,
+    /// Columns wanted by row
+    wanted: BTreeMap<usize, Vec<usize>>,
+    values: HashMap<(usize, usize), String>,
+    current_set: usize,
+    row: usize,
+}
+
+impl CellReader {
+    pub fn new(result_set: usize, cells: impl IntoIterator<Item = (usize, usize)>) -> Self {
+        let mut wanted: BTreeMap<usize, Vec<usize>> = BTreeMap::new();
+        for (row, col) in cells {
+            wanted.entry(row).or_default().push(col);
+        }
+        Self {
+            result_set,
+            wanted,
+            values: HashMap::new(),
+            current_set: 0,
+            row: 0,
+        }
+    }
+
+    /// The values read, keyed by (row, column); cells past the last row
+    /// returned are missing
+    pub fn finish(self) -> HashMap<(usize, usize), String> {
+        self.values
+    }
+}
+
+impl RowSink for CellReader {
+    fn columns(&mut self, _columns: &[String]) -> Result<()> {
+        Ok(())
+    }
+
+    fn row(&mut self, mut row: Vec<String>) -> Result<()> {
+        if self.current_set != self.result_set {
+            return Ok(());
+        }
+        if let Some(cols) = self.wanted.get(&self.row) {
+            for &col in cols {
+                if let Some(value) = row.get_mut(col) {
+                    self.values.insert((self.row, col), std::mem::take(value));
+                }
+            }
+        }
+        self.row += 1;
+        Ok(())
+    }
+
+    fn next_result_set(&mut self) -> Result<()> {
+        self.current_set += 1;
+        self.row = 0;
+        Ok(())
+    }
+
+    fn is_full(&self) -> bool {
+        if self.current_set != self.result_set {
+            return self.current_set > self.result_set;
+        }
+        let last_row = self.wanted.keys().next_back();
+        last_row.map_or(true, |&last| self.row > last)
+    }
+}
+
+/// Collector for a query running in the background: reports the number of
+/// rows read through `on_progress` every `PROGRESS_EVERY_ROWS` rows, and stops
+/// reading once `stop` is set, keeping the rows collected so far
+pub struct ProgressCollector<F: FnMut(usize) + Send> {
+    collector: CappedCollector,
+    stop: Arc<AtomicBool>,
+    on_progress: F,
+    rows_read: usize,
+}
+
+impl<F: FnMut(usize) + Send> ProgressCollector<F> {
+    pub fn new(limits: ResultLimits, stop: Arc<AtomicBool>, on_progress: F) -> Self {
+        Self {
+            collector: CappedCollector::new(limits),
+            stop,
+            on_progress,
+            rows_read: 0,
+        }
+    }
+
+    /// The collected result, marked stopped if the fetch ended early
+    pub fn finish(self) -> QueryResult {
+        let stopped = self.stop.load(Ordering::SeqCst) && !self.collector.is_full();
+        let mut result = self.collector.finish();
+        result.stopped = stopped;
+        result
+    }
+}
+
+impl<F: FnMut(usize) + Send> RowSink for ProgressCollector<F> {
+    fn columns(&mut self, columns: &[String]) -> Result<()> {
+        self.collector.columns(columns)
+    }
+
+    fn next_result_set(&mut self) -> Result<()> {
+        self.collector.next_result_set()
+    }
+
+    fn notices(&mut self, notices: Vec<ServerNotice>) -> Result<()> {
+        self.collector.notices(notices)
+    }
+
+    fn affected_rows(&mut self, rows: u64) -> Result<()> {
+        self.collector.affected_rows(rows)
+    }
+
+    fn elapsed(&mut self, elapsed: Duration) -> Result<()> {
+        self.collector.elapsed(elapsed)
+    }
+
+    fn row(&mut self, row: Vec<String>) -> Result<()> {
+        self.collector.row(row)?;
+        self.rows_read += 1;
+        if self.rows_read % PROGRESS_EVERY_ROWS == 0 {
+            (self.on_progress)(self.rows_read);
+        }
+        Ok(())
+    }
+
+    fn is_full(&self) -> bool {
+        self.collector.is_full() || self.stop.load(Ordering::SeqCst)
+    }
+}
+
+/// Shorten a value longer than `max_bytes` to at most that many bytes (on a
+/// character boundary) followed by a marker with the size left out. Returns
+/// None when the value fits
+pub fn truncate_cell(value: &str, max_bytes: usize) -> Option<String> {
+    if max_bytes == 0 || value.len() <= max_bytes {
+        return None;
+    }
+
+    let mut end = max_bytes;
+    while !value.is_char_boundary(end) {
+        end -= 1;
+    }
+    let omitted = (value.len() - end) as i64;
+
+    Some(format!(
+        "{}…(+{})",
+        &value[..end],
+        TableMetadata::format_size(omitted).replace(' ', "")
+    ))
+}
+
+/// The part of a cell value `truncate_cell` kept, without its marker
+pub fn kept_part(display: &str) -> &str {
+    display.rsplit_once("…(+").map_or(display, |(kept, _)| kept)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn collect(limits: ResultLimits, rows: usize) -> CappedCollector {
+        let mut collector = CappedCollector::new(limits);
+        collector.columns(&["id".to_string()]).unwrap();
+        for i in 0..rows {
+            collector.row(vec![i.to_string()]).unwrap();
+            if collector.is_full() {
+                break;
+            }
+        }
+        collector
+    }
+
+    #[test]
+    fn test_row_cap_marks_result_truncated() {
+        let limits = ResultLimits {
+            max_rows: 3,
+            max_cell_bytes: 0,
+        };
+
+        let result = collect(limits, 10).finish();
+        assert_eq!(result.rows.len(), 3);
+        assert!(result.truncated);
+
+        // Exactly at the cap with nothing left over is not truncated
+        let result = collect(limits, 3).finish();
+        assert_eq!(result.rows.len(), 3);
+        assert!(!result.truncated);
+    }
+
+    #[test]
+    fn test_oversized_cells_keep_only_their_position() {
+        let mut collector = CappedCollector::new(ResultLimits {
+            max_rows: 0,
+            max_cell_bytes: 4,
+        });
+        let big = "x".repeat(4 + 3 * 1024 * 1024);
+        collector.row(vec!["ok".to_string(), big.clone()]).unwrap();
+
+        let result = collector.finish();
+        assert_eq!(result.rows[0][0], "ok");
+        assert_eq!(result.rows[0][1], "xxxx…(+3.0MB)");
+        assert_eq!(kept_part(&result.rows[0][1]), "xxxx");
+        assert!(result.cut_cells.contains(&(0, 1)));
+        assert!(!result.cut_cells.contains(&(0, 0)));
+
+        // Read again on demand, the cell comes back in full
+        let mut reader = CellReader::new(0, result.cut_cells.iter().copied());
+        reader.row(vec!["ok".to_string(), big.clone()]).unwrap();
+        assert!(reader.is_full());
+        assert_eq!(reader.finish().get(&(0, 1)), Some(&big));
+    }
+
+    #[test]
+    fn test_lone_cell_is_kept_in_full() {
+        let limits = ResultLimits {
+            max_rows: 0,
+            max_cell_bytes: 4,
+        };
+        let big = "x".repeat(10);
+
+        let mut collector = CappedCollector::new(limits);
+        collector.row(vec![big.clone()]).unwrap();
+        let result = collector.finish();
+        assert_eq!(result.rows[0][0], "xxxx…(+6B)");
+        assert!(result.cut_cells.contains(&(0, 0)));
+        assert_eq!(result.full_values.get(&(0, 0)), Some(&big));
+
+        // With a second row it's a column like any other
+        let mut collector = CappedCollector::new(limits);
+        collector.row(vec![big.clone()]).unwrap();
+        collector.row(vec![big]).unwrap();
+        assert!(collector.finish().full_values.is_empty());
+    }
+
+    #[test]
+    fn test_cell_reader_counts_rows_of_its_result_set() {
+        let mut reader = CellReader::new(1, [(1, 0), (2, 1)]);
+        reader.row(vec!["first set".to_string()]).unwrap();
+        reader.next_result_set().unwrap();
+        for i in 0..2 {
+            assert!(!reader.is_full());
+            reader.row(vec![format!("a{i}"), format!("b{i}")]).unwrap();
+        }
+
+        // The query returns fewer rows than when it first ran
+        let values = reader.finish();
+        assert_eq!(values.get(&(1, 0)).map(String::as_str), Some("a1"));
+        assert!(!values.contains_key(&(2, 1)));
+        assert_eq!(values.len(), 1);
+    }
+
+    #[test]
+    fn test_result_sets_are_kept_in_order() {
+        let mut collector = CappedCollector::new(ResultLimits::default());
+        for (set, rows) in [1, 2, 3].into_iter().enumerate() {
+            if set > 0 {
+                collector.next_result_set().unwrap();
+            }
+            collector.columns(&[format!("set{set}")]).unwrap();
+            for i in 0..rows {
+                collector.row(vec![i.to_string()]).unwrap();
+            }
+        }
+        collector.affected_rows(2).unwrap();
+        collector
+            .notices(vec![ServerNotice::new("Note", "1 row affected")])
+            .unwrap();
+        collector.elapsed(Duration::from_millis(12)).unwrap();
+
+        let result = collector.finish();
+        assert_eq!(result.result_set_count(), 3);
+        assert_eq!(result.columns, vec!["set0"]);
+        assert_eq!(result.rows.len(), 1);
+        assert_eq!(result.more_results[0].columns, vec!["set1"]);
+        assert_eq!(result.more_results[1].rows.len(), 3);
+        assert!(result.more_results[1].more_results.is_empty());
+        assert_eq!(result.notices.len(), 1);
+        assert!(result.more_results[0].notices.is_empty());
+        assert_eq!(result.more_results[1].affected_rows, Some(2));
+        assert_eq!(result.affected_rows, None);
+        assert_eq!(result.elapsed, Some(Duration::from_millis(12)));
+        assert_eq!(result.more_results[0].elapsed, None);
+    }
+
+    #[test]
+    fn test_progress_collector_reports_and_stops() {
+        let stop = Arc::new(AtomicBool::new(false));
+        let mut reported = Vec::new();
+        let mut collector =
+            ProgressCollector::new(ResultLimits::default(), Arc::clone(&stop), |rows| {
+                reported.push(rows)
+            });
+
+        for i in 0..PROGRESS_EVERY_ROWS * 2 + 1 {
+            collector.row(vec![i.to_string()]).unwrap();
+        }
+        assert!(!collector.is_full());
+
+        stop.store(true, Ordering::SeqCst);
+        assert!(collector.is_full());
+
+        let result = collector.finish();
+        assert_eq!(result.rows.len(), PROGRESS_EVERY_ROWS * 2 + 1);
+        assert!(result.stopped);
+        assert!(!result.truncated);
+        assert_eq!(reported, vec![PROGRESS_EVERY_ROWS, PROGRESS_EVERY_ROWS * 2]);
+    }
+
+    #[test]
+    fn test_truncate_cell_respects_char_boundaries() {
+        assert_eq!(truncate_cell("héllo", 2).as_deref(), Some("h…(+5B)"));
+        assert_eq!(truncate_cell("short", 10), None);
+    }
+}
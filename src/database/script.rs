@@ -0,0 +1,464 @@
+// FilePath: src/database/script.rs
+
+//! Splitting a script into the statements it holds
+//!
+//! A `;` ends a statement except inside quoted strings and identifiers,
+//! comments, PostgreSQL dollar-quoted bodies (`$$ ... $$`, `$fn$ ... $fn$`)
+//! and bodies whose statements end in `;` themselves: SQLite triggers and
+//! PostgreSQL `BEGIN ATOMIC` functions, up to their `END`. MySQL and MariaDB
+//! also get backslash escapes in strings and `#` comments. Client commands,
+//! like the `DELIMITER` of the MySQL shell, are not understood.
+//!
+//! After a run, `summarize` tells what became of each statement, for the
+//! summary shown before the statements' results: which ran, what they
+//! changed and how long they took, and which failed or never ran.
+
+#![forbid(unsafe_code)]
+
+use super::{DatabaseType, QueryResult};
+use std::time::Duration;
+
+/// Leading words of a statement kept to recognize a trigger
+const HEAD_WORDS: usize = 4;
+
+/// Characters of a statement the summary shows
+const EXCERPT_CHARS: usize = 60;
+
+/// The statement of a script that failed, ending the script
+#[derive(Debug, Clone)]
+pub struct StatementFailure {
+    /// 1-based position of the statement in the script
+    pub position: usize,
+    pub statement: String,
+    pub error: String,
+    /// Time until the statement failed
+    pub elapsed: Duration,
+}
+
+/// What became of a statement of a script
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum StatementStatus {
+    /// Ran to the end; its changes stay even when a later statement fails
+    Executed,
+    Failed,
+    /// Never ran, after a failure or a stop
+    NotRun,
+}
+
+impl StatementStatus {
+    pub fn label(self) -> &'static str {
+        match self {
+            Self::Executed => "executed",
+            Self::Failed => "failed",
+            Self::NotRun => "not run",
+        }
+    }
+}
+
+/// A statement's line in the summary of a script run
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct StatementSummary {
+    /// 1-based position in the script
+    pub position: usize,
+    /// The statement's first characters, on one line
+    pub excerpt: String,
+    /// Rows changed, for statements returning none
+    pub affected_rows: Option<u64>,
+    pub elapsed: Option<Duration>,
+    pub status: StatementStatus,
+    pub error: Option<String>,
+}
+
+/// A line per statement of a script whose run produced `results`, each
+/// naming its statement, and ended with `failure` if one failed
+pub fn summarize(
+    statements: &[String],
+    results: &[QueryResult],
+    failure: Option<&StatementFailure>,
+) -> Vec<StatementSummary> {
+    statements
+        .iter()
+        .enumerate()
+        .map(|(index, statement)| {
+            let position = index + 1;
+            let sets: Vec<&QueryResult> = results
+                .iter()
+                .filter(|set| set.statement.is_some_and(|(at, _)| at == position))
+                .collect();
+            let mut line = StatementSummary {
+                position,
+                excerpt: excerpt(statement),
+                affected_rows: None,
+                elapsed: None,
+                status: StatementStatus::NotRun,
+                error: None,
+            };
+            match failure.filter(|failure| failure.position == position) {
+                Some(failure) => {
+                    line.status = StatementStatus::Failed;
+                    line.elapsed = Some(failure.elapsed);
+                    line.error = Some(failure.error.clone());
+                }
+                None if !sets.is_empty() => {
+                    line.status = StatementStatus::Executed;
+                    line.affected_rows = sets
+                        .iter()
+                        .filter_map(|set| set.affected_rows)
+                        .reduce(|total, rows| total + rows);
+                    line.elapsed = sets
+                        .iter()
+                        .filter_map(|set| set.elapsed)
+                        .reduce(|total, elapsed| total + elapsed);
+                }
+                None => {}
+            }
+            line
+        })
+        .collect()
+}
+
+/// The first `EXCERPT_CHARS` characters of `statement` with its whitespace
+/// collapsed, marked with `…` when cut short
+fn excerpt(statement: &str) -> String {
+    let words = statement.split_whitespace().collect::<Vec<_>>().join(" ");
+    if words.chars().count() > EXCERPT_CHARS {
+        let mut short: String = words.chars().take(EXCERPT_CHARS - 1).collect();
+        short.push('…');
+        short
+    } else {
+        words
+    }
+}
+
+/// The statements of `script` in order, trimmed and without their `;`.
+/// Stretches holding nothing but comments and whitespace are left out
+pub fn split_statements(script: &str, database_type: &DatabaseType) -> Vec<String> {
+    let mysql = matches!(database_type, DatabaseType::MySQL | DatabaseType::MariaDB);
+    let postgres = *database_type == DatabaseType::PostgreSQL;
+    let sqlite = *database_type == DatabaseType::SQLite;
+
+    let chars: Vec<(usize, char)> = script.char_indices().collect();
+    let mut statements = Vec::new();
+    let mut statement = Statement::default();
+    let mut start = 0;
+    let mut i = 0;
+    while i < chars.len() {
+        let (at, c) = chars[i];
+        let next = chars.get(i + 1).map(|&(_, c)| c);
+        match c {
+            '-' if next == Some('-') => i = skip_line(&chars, i),
+            '#' if mysql => i = skip_line(&chars, i),
+            '/' if next == Some('*') => i = skip_block_comment(&chars, i, postgres),
+            '\'' | '"' | '`' => {
+                // E'...' takes backslash escapes on PostgreSQL
+                let escape_string = postgres
+                    && c == '\''
+                    && statement.last_word_end == Some(i)
+                    && statement.last_word.eq_ignore_ascii_case("E");
+                let backslashes = (mysql && c != '`') || escape_string;
+                statement.has_code = true;
+                i = skip_quoted(&chars, i, c, backslashes);
+            }
+            '$' if postgres => {
+                statement.has_code = true;
+                i = match dollar_tag(&chars, i) {
+                    Some(tag) => skip_dollar_quoted(&chars, i, &tag),
+                    None => i + 1,
+                };
+            }
+            ';' if !statement.in_body(sqlite) => {
+                statement.push_to(&mut statements, &script[start..at]);
+                statement = Statement::default();
+                start = at + 1;
+                i += 1;
+            }
+            c if is_word_start(c) => {
+                let mut end = i + 1;
+                while end < chars.len() && is_word_char(chars[end].1, postgres) {
+                    end += 1;
+                }
+                let to = chars.get(end).map_or(script.len(), |&(at, _)| at);
+                statement.word(&script[at..to], end);
+                i = end;
+            }
+            c => {
+                statement.has_code |= !c.is_whitespace();
+                i += 1;
+            }
+        }
+    }
+    statement.push_to(&mut statements, &script[start..]);
+    statements
+}
+
+/// What the splitter knows of the statement being read
+#[derive(Debug, Default)]
+struct Statement {
+    /// Whether anything but comments and whitespace was read
+    has_code: bool,
+    /// First words, upper-cased
+    head: Vec<String>,
+    last_word: String,
+    /// Index of the character after the last word
+    last_word_end: Option<usize>,
+    /// `BEGIN` and `CASE` opened but not yet closed by `END`
+    depth: usize,
+    /// Whether a PostgreSQL `BEGIN ATOMIC` body was opened
+    atomic_body: bool,
+}
+
+impl Statement {
+    fn word(&mut self, word: &str, end: usize) {
+        let upper = word.to_ascii_uppercase();
+        match upper.as_str() {
+            "BEGIN" | "CASE" => self.depth += 1,
+            "END" => self.depth = self.depth.saturating_sub(1),
+            "ATOMIC" if self.last_word.eq_ignore_ascii_case("BEGIN") => self.atomic_body = true,
+            _ => {}
+        }
+        if self.head.len() < HEAD_WORDS {
+            self.head.push(upper);
+        }
+        self.has_code = true;
+        self.last_word = word.to_string();
+        self.last_word_end = Some(end);
+    }
+
+    /// Whether a `;` belongs to a body the statement hasn't closed yet
+    fn in_body(&self, sqlite: bool) -> bool {
+        let trigger = sqlite
+            && self.head.first().is_some_and(|word| word == "CREATE")
+            && self.head.iter().any(|word| word == "TRIGGER");
+        (trigger || self.atomic_body) && self.depth > 0
+    }
+
+    fn push_to(&self, statements: &mut Vec<String>, text: &str) {
+        if self.has_code {
+            statements.push(text.trim().to_string());
+        }
+    }
+}
+
+fn is_word_start(c: char) -> bool {
+    c.is_alphanumeric() || c == '_'
+}
+
+/// PostgreSQL identifiers may hold `$` after their first character
+fn is_word_char(c: char, postgres: bool) -> bool {
+    is_word_start(c) || (postgres && c == '$')
+}
+
+/// Index of the line break ending the comment starting at `i`
+fn skip_line(chars: &[(usize, char)], i: usize) -> usize {
+    chars[i..]
+        .iter()
+        .position(|&(_, c)| c == '\n')
+        .map_or(chars.len(), |offset| i + offset)
+}
+
+/// Index after the `/* */` comment starting at `i`. PostgreSQL nests them
+fn skip_block_comment(chars: &[(usize, char)], i: usize, nested: bool) -> usize {
+    let mut depth = 0;
+    let mut j = i;
+    while j < chars.len() {
+        let next = chars.get(j + 1).map(|&(_, c)| c);
+        match (chars[j].1, next) {
+            ('/', Some('*')) if depth == 0 || nested => {
+                depth += 1;
+                j += 2;
+            }
+            ('*', Some('/')) => {
+                depth -= 1;
+                j += 2;
+                if depth == 0 {
+                    return j;
+                }
+            }
+            _ => j += 1,
+        }
+    }
+    chars.len()
+}
+
+/// Index after the string or identifier quoted by `quote` starting at `i`,
+/// where a doubled quote, or a backslash when `backslashes`, escapes
+fn skip_quoted(chars: &[(usize, char)], i: usize, quote: char, backslashes: bool) -> usize {
+    let mut j = i + 1;
+    while j < chars.len() {
+        match chars[j].1 {
+            '\\' if backslashes => j += 2,
+            c if c == quote => {
+                if chars.get(j + 1).map(|&(_, c)| c) == Some(quote) {
+                    j += 2;
+                } else {
+                    return j + 1;
+                }
+            }
+            _ => j += 1,
+        }
+    }
+    chars.len()
+}
+
+/// The `$tag$` opening a dollar-quoted string at `i`; positional parameters
+/// like `$1` aren't one
+fn dollar_tag(chars: &[(usize, char)], i: usize) -> Option<String> {
+    let mut tag = String::from("$");
+    for (n, &(_, c)) in chars[i + 1..].iter().enumerate() {
+        match c {
+            '$' => {
+                tag.push('$');
+                return Some(tag);
+            }
+            c if c.is_ascii_digit() && n == 0 => return None,
+            c if is_word_start(c) => tag.push(c),
+            _ => return None,
+        }
+    }
+    None
+}
+
+/// Index after the dollar-quoted string opened by `tag` at `i`
+fn skip_dollar_quoted(chars: &[(usize, char)], i: usize, tag: &str) -> usize {
+    let tag: Vec<char> = tag.chars().collect();
+    let mut j = i + tag.len();
+    while j + tag.len() <= chars.len() {
+        if chars[j..j + tag.len()]
+            .iter()
+            .map(|&(_, c)| c)
+            .eq(tag.iter().copied())
+        {
+            return j + tag.len();
+        }
+        j += 1;
+    }
+    chars.len()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn split(script: &str, database_type: DatabaseType) -> Vec<String> {
+        split_statements(script, &database_type)
+    }
+
+    #[test]
+    fn test_split_skips_quotes_and_comments() {
+        let script = "SELECT 'a;b', \"odd;name\" FROM t; -- one; two\n\
+                      /* three; */ UPDATE t SET v = 'it''s;' WHERE id = 1;\n\
+                      ;  \n-- trailing comment only";
+        assert_eq!(
+            split(script, DatabaseType::PostgreSQL),
+            vec![
+                "SELECT 'a;b', \"odd;name\" FROM t",
+                "-- one; two\n/* three; */ UPDATE t SET v = 'it''s;' WHERE id = 1",
+            ]
+        );
+        assert_eq!(split("SELECT 1", DatabaseType::SQLite), vec!["SELECT 1"]);
+        assert!(split(" ; -- nothing\n", DatabaseType::SQLite).is_empty());
+    }
+
+    #[test]
+    fn test_split_postgres_bodies() {
+        let function = "CREATE FUNCTION f() RETURNS int AS $body$\n\
+                        BEGIN RETURN 1; END;\n$body$ LANGUAGE plpgsql";
+        let script =
+            format!("{function};\nSELECT $1::text, a$b$ FROM t; SELECT E'x\\';y'; SELECT 2");
+        assert_eq!(
+            split(&script, DatabaseType::PostgreSQL),
+            vec![
+                function,
+                "SELECT $1::text, a$b$ FROM t",
+                "SELECT E'x\\';y'",
+                "SELECT 2",
+            ]
+        );
+
+        let atomic = "CREATE FUNCTION g() RETURNS int LANGUAGE sql BEGIN ATOMIC\n\
+                      SELECT CASE WHEN true THEN 1 END; END";
+        assert_eq!(
+            split(&format!("{atomic}; SELECT 3"), DatabaseType::PostgreSQL),
+            vec![atomic, "SELECT 3"]
+        );
+        // Nested block comments
+        assert_eq!(
+            split(
+                "/* a /* b; */ c; */ SELECT 1; SELECT 2",
+                DatabaseType::PostgreSQL
+            )
+            .len(),
+            2
+        );
+    }
+
+    #[test]
+    fn test_split_mysql_and_sqlite_dialects() {
+        assert_eq!(
+            split(
+                "INSERT INTO t VALUES ('it\\'s; ok'); # note; here\nSELECT `a;b` FROM t",
+                DatabaseType::MySQL
+            ),
+            vec![
+                "INSERT INTO t VALUES ('it\\'s; ok')",
+                "# note; here\nSELECT `a;b` FROM t"
+            ]
+        );
+
+        let trigger = "CREATE TRIGGER log AFTER INSERT ON t BEGIN\n\
+                       INSERT INTO audit VALUES (new.id);\n\
+                       UPDATE n SET c = CASE WHEN c > 9 THEN 0 ELSE c + 1 END;\nEND";
+        assert_eq!(
+            split(&format!("BEGIN; {trigger}; COMMIT;"), DatabaseType::SQLite),
+            vec!["BEGIN", trigger, "COMMIT"]
+        );
+    }
+
+    #[test]
+    fn test_summary_marks_statements_run_before_a_failure() {
+        let statements: Vec<String> = [
+            "UPDATE orders\n   SET status = 'shipped'\n WHERE shipped_at IS NOT NULL AND status = 'paid'",
+            "SELECT 1",
+            "DELETE FROM missing",
+            "SELECT 2",
+        ]
+        .map(String::from)
+        .to_vec();
+        let set = |position, affected_rows, millis| QueryResult {
+            affected_rows,
+            elapsed: Some(Duration::from_millis(millis)),
+            statement: Some((position, 4)),
+            ..QueryResult::default()
+        };
+        let results = [set(1, Some(12), 30), set(2, None, 5)];
+        let failure = StatementFailure {
+            position: 3,
+            statement: statements[2].clone(),
+            error: "no such table: missing".to_string(),
+            elapsed: Duration::from_millis(2),
+        };
+
+        let lines = summarize(&statements, &results, Some(&failure));
+        assert_eq!(
+            lines.iter().map(|line| line.status).collect::<Vec<_>>(),
+            [
+                StatementStatus::Executed,
+                StatementStatus::Executed,
+                StatementStatus::Failed,
+                StatementStatus::NotRun,
+            ]
+        );
+        assert_eq!(
+            lines[0].excerpt,
+            "UPDATE orders SET status = 'shipped' WHERE shipped_at IS NO…"
+        );
+        assert_eq!(lines[0].excerpt.chars().count(), EXCERPT_CHARS);
+        assert_eq!(lines[0].affected_rows, Some(12));
+        assert_eq!(lines[0].elapsed, Some(Duration::from_millis(30)));
+        assert_eq!(lines[1].affected_rows, None);
+        assert_eq!(lines[2].error.as_deref(), Some("no such table: missing"));
+        assert_eq!(lines[2].elapsed, Some(Duration::from_millis(2)));
+        assert_eq!(lines[3].elapsed, None);
+        assert_eq!(lines[3].excerpt, "SELECT 2");
+    }
+}
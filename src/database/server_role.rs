@@ -0,0 +1,175 @@
+// FilePath: src/database/server_role.rs
+
+//! Read replicas and read-only servers
+//!
+//! Writing to a PostgreSQL hot standby or a MySQL server with `read_only` set
+//! fails with errors that don't say much about why. The role is read once
+//! after connecting, shown in the status bar, and while
+//! `[app] guard_read_only_servers` is on the query editor and the grid refuse
+//! to write to such a server before sending anything. `:readonly off` lifts
+//! the guard for the session, `:readonly on` puts it on any connection.
+
+#![forbid(unsafe_code)]
+
+use super::DatabaseType;
+use crate::app::session_events::{SessionEvent, SessionSubscriber};
+use std::collections::HashMap;
+
+/// Whether the server takes writes
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Default)]
+pub enum ServerRole {
+    #[default]
+    Primary,
+    /// A PostgreSQL standby in recovery
+    Replica,
+    /// A MySQL or MariaDB server with `read_only` set
+    ReadOnly,
+}
+
+impl ServerRole {
+    /// Query reading the role, one row and column; None where there's no
+    /// server to ask
+    pub fn query(database_type: &DatabaseType) -> Option<&'static str> {
+        match database_type {
+            DatabaseType::PostgreSQL => Some("SELECT pg_is_in_recovery()"),
+            DatabaseType::MySQL => Some("SELECT @@global.read_only OR @@global.super_read_only"),
+            DatabaseType::MariaDB => Some("SELECT @@global.read_only"),
+            _ => None,
+        }
+    }
+
+    /// The role a value read by `query` stands for
+    pub fn from_value(database_type: &DatabaseType, value: &str) -> Self {
+        let set = matches!(
+            value.trim().to_ascii_lowercase().as_str(),
+            "t" | "true" | "1" | "on"
+        );
+        match (database_type, set) {
+            (_, false) => Self::Primary,
+            (DatabaseType::PostgreSQL, true) => Self::Replica,
+            (_, true) => Self::ReadOnly,
+        }
+    }
+
+    /// Status bar badge; None for a server taking writes
+    pub fn badge(self) -> Option<&'static str> {
+        match self {
+            Self::Primary => None,
+            Self::Replica => Some("REPLICA"),
+            Self::ReadOnly => Some("READ-ONLY"),
+        }
+    }
+}
+
+/// Roles of the open connections and which of them refuse writes
+#[derive(Debug, Clone, Default)]
+pub struct ReadOnlyGuard {
+    /// `[app] guard_read_only_servers`: guard replicas and read-only servers
+    pub guard_read_only_servers: bool,
+    roles: HashMap<String, ServerRole>,
+    /// Set with `:readonly on|off` by connection ID, until the connection
+    /// closes
+    overrides: HashMap<String, bool>,
+}
+
+impl ReadOnlyGuard {
+    pub fn new(guard_read_only_servers: bool) -> Self {
+        Self {
+            guard_read_only_servers,
+            ..Self::default()
+        }
+    }
+
+    /// Note the role read after connecting
+    pub fn set_role(&mut self, connection_id: &str, role: ServerRole) {
+        self.roles.insert(connection_id.to_string(), role);
+    }
+
+    pub fn role(&self, connection_id: &str) -> ServerRole {
+        self.roles.get(connection_id).copied().unwrap_or_default()
+    }
+
+    /// Whether writes to the connection are refused
+    pub fn is_guarded(&self, connection_id: &str) -> bool {
+        self.overrides
+            .get(connection_id)
+            .copied()
+            .unwrap_or_else(|| {
+                self.guard_read_only_servers && self.role(connection_id) != ServerRole::Primary
+            })
+    }
+
+    /// Turn the guard on or off for the connection's session
+    pub fn set_override(&mut self, connection_id: &str, guarded: bool) {
+        self.overrides.insert(connection_id.to_string(), guarded);
+    }
+
+    /// Back to guarding the connection by its role
+    pub fn clear_override(&mut self, connection_id: &str) {
+        self.overrides.remove(connection_id);
+    }
+}
+
+impl SessionSubscriber for ReadOnlyGuard {
+    /// The role is read again on the next connect, and the guard starts
+    /// from the config
+    fn on_session_event(&mut self, event: &SessionEvent) {
+        match event {
+            SessionEvent::ConnectionOpened { connection_id } => {
+                self.clear_override(connection_id);
+            }
+            SessionEvent::ConnectionClosed { connection_id } => {
+                self.clear_override(connection_id);
+                self.roles.remove(connection_id);
+            }
+            _ => {}
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_role_from_value() {
+        assert_eq!(
+            ServerRole::from_value(&DatabaseType::PostgreSQL, "t"),
+            ServerRole::Replica
+        );
+        assert_eq!(
+            ServerRole::from_value(&DatabaseType::PostgreSQL, "false"),
+            ServerRole::Primary
+        );
+        assert_eq!(
+            ServerRole::from_value(&DatabaseType::MySQL, "1"),
+            ServerRole::ReadOnly
+        );
+        assert_eq!(
+            ServerRole::from_value(&DatabaseType::MariaDB, "0"),
+            ServerRole::Primary
+        );
+    }
+
+    #[test]
+    fn test_guard_follows_role_until_overridden() {
+        let mut guard = ReadOnlyGuard::new(true);
+        guard.set_role("standby", ServerRole::Replica);
+        assert!(guard.is_guarded("standby"));
+        assert!(!guard.is_guarded("primary"));
+
+        guard.set_override("standby", false);
+        guard.set_override("primary", true);
+        assert!(!guard.is_guarded("standby"));
+        assert!(guard.is_guarded("primary"));
+
+        guard.on_session_event(&SessionEvent::ConnectionClosed {
+            connection_id: "standby".to_string(),
+        });
+        assert!(!guard.is_guarded("standby"));
+        assert_eq!(guard.role("standby"), ServerRole::Primary);
+
+        let guard = ReadOnlyGuard::new(false);
+        assert!(!guard.is_guarded("standby"));
+    }
+}
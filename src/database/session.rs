@@ -0,0 +1,177 @@
+// FilePath: src/database/session.rs
+
+//! The pooled connection a user's queries run on
+//!
+//! Session state - `SET search_path`, `SET time_zone`, temporary tables, an
+//! open transaction - belongs to one server connection. Running each query on
+//! whichever pooled connection is free makes that state apply only by luck,
+//! so user queries share one connection held for the whole session while
+//! metadata queries keep using the pool.
+
+#![forbid(unsafe_code)]
+
+use crate::core::error::{LazyTablesError, Result};
+use sqlx::pool::PoolConnection;
+use sqlx::{Connection as _, Database, Pool};
+use std::fmt;
+use std::ops::{Deref, DerefMut};
+use std::sync::atomic::{AtomicI64, Ordering};
+use std::sync::Arc;
+use tokio::sync::{Mutex, MutexGuard};
+
+/// Pooled connection held for user queries, acquired on first use
+pub struct SessionConnection<DB: Database> {
+    connection: Mutex<Option<PoolConnection<DB>>>,
+    /// Server's id of the held connection - PostgreSQL's backend pid, MySQL's
+    /// connection id - for cancelling its statement from another connection;
+    /// 0 until the adapter reads it
+    server_id: Arc<AtomicI64>,
+}
+
+impl<DB: Database> Default for SessionConnection<DB> {
+    fn default() -> Self {
+        Self {
+            connection: Mutex::new(None),
+            server_id: Arc::new(AtomicI64::new(0)),
+        }
+    }
+}
+
+impl<DB: Database> fmt::Debug for SessionConnection<DB> {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.debug_struct("SessionConnection")
+            .field(
+                "held",
+                &self.connection.try_lock().map(|c| c.is_some()).ok(),
+            )
+            .finish()
+    }
+}
+
+impl<DB: Database> SessionConnection<DB> {
+    /// Lock the session connection, acquiring one from `pool` when none is
+    /// held yet (or the last one was lost)
+    pub async fn lock(&self, pool: &Pool<DB>) -> Result<SessionGuard<'_, DB>> {
+        let mut guard = self.connection.lock().await;
+        if guard.is_none() {
+            let connection = pool.acquire().await.map_err(|e| {
+                LazyTablesError::Connection(format!("Failed to open session connection: {e}"))
+            })?;
+            *guard = Some(connection);
+            self.server_id.store(0, Ordering::SeqCst);
+        }
+        Ok(SessionGuard {
+            connection: guard,
+            server_id: &self.server_id,
+        })
+    }
+
+    /// Give the held connection back to the pool. Must happen before the pool
+    /// is closed, which waits for every connection to come back
+    pub fn release(&mut self) {
+        self.connection.get_mut().take();
+        self.server_id.store(0, Ordering::SeqCst);
+    }
+
+    /// Server's id of whichever connection is held, shared so it can be read
+    /// while a statement keeps the session locked
+    pub fn server_id(&self) -> Arc<AtomicI64> {
+        Arc::clone(&self.server_id)
+    }
+}
+
+/// Locked session connection
+pub struct SessionGuard<'a, DB: Database> {
+    connection: MutexGuard<'a, Option<PoolConnection<DB>>>,
+    server_id: &'a AtomicI64,
+}
+
+impl<DB: Database> SessionGuard<'_, DB> {
+    /// Server's id of the connection, 0 when not read yet
+    pub fn server_id(&self) -> i64 {
+        self.server_id.load(Ordering::SeqCst)
+    }
+
+    /// Remember the server's id of the connection
+    pub fn set_server_id(&self, id: i64) {
+        self.server_id.store(id, Ordering::SeqCst);
+    }
+
+    /// After a failed query, drop the connection if it no longer answers, so
+    /// the next query starts a fresh session instead of failing again.
+    /// Returns whether it was dropped, taking any open transaction with it
+    pub async fn discard_if_broken(mut self) -> bool {
+        let broken = match self.connection.as_mut() {
+            Some(connection) => connection.ping().await.is_err(),
+            None => false,
+        };
+        if broken {
+            crate::log_warn!("Session connection lost; the next query opens a new session");
+            *self.connection = None;
+            self.server_id.store(0, Ordering::SeqCst);
+        }
+        broken
+    }
+}
+
+impl<DB: Database> Deref for SessionGuard<'_, DB> {
+    type Target = DB::Connection;
+
+    fn deref(&self) -> &Self::Target {
+        self.connection
+            .as_deref()
+            .expect("session connection is held while locked")
+    }
+}
+
+impl<DB: Database> DerefMut for SessionGuard<'_, DB> {
+    fn deref_mut(&mut self) -> &mut Self::Target {
+        self.connection
+            .as_deref_mut()
+            .expect("session connection is held while locked")
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use sqlx::sqlite::SqlitePoolOptions;
+
+    #[tokio::test]
+    async fn test_session_state_stays_on_one_connection() {
+        // Every in-memory SQLite connection is a database of its own
+        let pool = SqlitePoolOptions::new()
+            .max_connections(2)
+            .connect("sqlite::memory:")
+            .await
+            .unwrap();
+        let mut session = SessionConnection::default();
+        let count_sql = "SELECT count(*) FROM sqlite_temp_master WHERE name = 'scratch'";
+
+        {
+            let mut connection = session.lock(&pool).await.unwrap();
+            sqlx::query("CREATE TEMP TABLE scratch (id INTEGER)")
+                .execute(&mut *connection)
+                .await
+                .unwrap();
+        }
+
+        let mut connection = session.lock(&pool).await.unwrap();
+        let on_session: i64 = sqlx::query_scalar(count_sql)
+            .fetch_one(&mut *connection)
+            .await
+            .unwrap();
+        drop(connection);
+        let on_pool: i64 = sqlx::query_scalar(count_sql)
+            .fetch_one(&pool)
+            .await
+            .unwrap();
+        assert_eq!((on_session, on_pool), (1, 0));
+
+        // Closing the pool waits for the session connection to come back
+        session.release();
+        tokio::time::timeout(std::time::Duration::from_secs(5), pool.close())
+            .await
+            .unwrap();
+    }
+}
@@ -0,0 +1,178 @@
+// FilePath: src/database/session_stats.rs
+
+//! Per-connection counters for the session
+//!
+//! Every statement the connection manager runs, from the query editor or the
+//! panels, is counted here with the rows it returned or its error, along with
+//! how long each connection stayed open and how much was exported from it.
+//! `:stats` shows them and a summary line goes to the log on exit, so a
+//! session that fetched millions of rows or failed every other query shows
+//! it.
+
+#![forbid(unsafe_code)]
+
+use std::collections::HashMap;
+use std::sync::{Arc, Mutex};
+use std::time::{Duration, Instant};
+
+/// Counters of one connection
+#[derive(Debug, Clone, Default, PartialEq, Eq)]
+pub struct ConnectionStats {
+    pub name: String,
+    pub queries: u64,
+    pub errors: u64,
+    pub rows_fetched: u64,
+    pub bytes_exported: u64,
+    /// Time spent connected before the current connection
+    connected: Duration,
+    /// When the current connection was opened; None while disconnected
+    connected_since: Option<Instant>,
+}
+
+impl ConnectionStats {
+    /// Time spent connected this session, the open connection included
+    pub fn connected_for(&self) -> Duration {
+        self.connected
+            + self
+                .connected_since
+                .map(|since| since.elapsed())
+                .unwrap_or_default()
+    }
+
+    pub fn is_connected(&self) -> bool {
+        self.connected_since.is_some()
+    }
+}
+
+/// Counters of every connection used this session, shared by the clones of
+/// the connection manager
+#[derive(Debug, Clone, Default)]
+pub struct SessionStats {
+    connections: Arc<Mutex<HashMap<String, ConnectionStats>>>,
+}
+
+impl SessionStats {
+    fn update(&self, connection_id: &str, f: impl FnOnce(&mut ConnectionStats)) {
+        if let Ok(mut connections) = self.connections.lock() {
+            f(connections.entry(connection_id.to_string()).or_default());
+        }
+    }
+
+    /// Note a connection was opened
+    pub fn record_connected(&self, connection_id: &str, name: &str) {
+        self.update(connection_id, |stats| {
+            stats.name = name.to_string();
+            if stats.connected_since.is_none() {
+                stats.connected_since = Some(Instant::now());
+            }
+        });
+    }
+
+    /// Note a connection was closed
+    pub fn record_disconnected(&self, connection_id: &str) {
+        self.update(connection_id, |stats| {
+            if let Some(since) = stats.connected_since.take() {
+                stats.connected += since.elapsed();
+            }
+        });
+    }
+
+    /// Note a statement ran, with the rows it returned or None when it
+    /// failed
+    pub fn record_query(&self, connection_id: &str, rows: Option<usize>) {
+        self.update(connection_id, |stats| {
+            stats.queries += 1;
+            match rows {
+                Some(rows) => stats.rows_fetched += rows as u64,
+                None => stats.errors += 1,
+            }
+        });
+    }
+
+    /// Note rows were exported to the clipboard or a file
+    pub fn record_export(&self, connection_id: &str, bytes: u64) {
+        self.update(connection_id, |stats| stats.bytes_exported += bytes);
+    }
+
+    /// The counters of every connection used, by name
+    pub fn snapshot(&self) -> Vec<ConnectionStats> {
+        let mut connections: Vec<ConnectionStats> = self
+            .connections
+            .lock()
+            .map(|connections| connections.values().cloned().collect())
+            .unwrap_or_default();
+        connections.sort_by(|a, b| a.name.cmp(&b.name));
+        connections
+    }
+
+    /// One line adding up the session, for the log on exit
+    pub fn summary(&self) -> String {
+        let connections = self.snapshot();
+        let total = |f: fn(&ConnectionStats) -> u64| connections.iter().map(f).sum::<u64>();
+        format!(
+            "Session: {} connection{}, {} queries ({} failed), {} rows fetched, {} exported",
+            connections.len(),
+            if connections.len() == 1 { "" } else { "s" },
+            total(|stats| stats.queries),
+            total(|stats| stats.errors),
+            total(|stats| stats.rows_fetched),
+            format_bytes(total(|stats| stats.bytes_exported)),
+        )
+    }
+}
+
+/// Byte count as `512 B`, `12.3 KB` or `4.0 MB`
+pub fn format_bytes(bytes: u64) -> String {
+    const UNITS: [&str; 4] = ["KB", "MB", "GB", "TB"];
+    if bytes < 1024 {
+        return format!("{bytes} B");
+    }
+    let mut value = bytes as f64 / 1024.0;
+    let mut unit = 0;
+    while value >= 1024.0 && unit + 1 < UNITS.len() {
+        value /= 1024.0;
+        unit += 1;
+    }
+    format!("{value:.1} {}", UNITS[unit])
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_counters_add_up_per_connection() {
+        let stats = SessionStats::default();
+        stats.record_connected("a", "app");
+        stats.record_query("a", Some(40));
+        stats.record_query("a", Some(2));
+        stats.record_query("a", None);
+        stats.record_export("a", 2048);
+        stats.record_query("b", Some(1));
+        stats.record_disconnected("a");
+
+        let snapshot = stats.snapshot();
+        let app = snapshot.iter().find(|stats| stats.name == "app").unwrap();
+        assert_eq!(
+            (
+                app.queries,
+                app.errors,
+                app.rows_fetched,
+                app.bytes_exported
+            ),
+            (3, 1, 42, 2048)
+        );
+        assert!(!app.is_connected());
+        assert_eq!(
+            stats.summary(),
+            "Session: 2 connections, 4 queries (1 failed), 43 rows fetched, 2.0 KB exported"
+        );
+    }
+
+    #[test]
+    fn test_format_bytes() {
+        assert_eq!(format_bytes(512), "512 B");
+        assert_eq!(format_bytes(12_595), "12.3 KB");
+        assert_eq!(format_bytes(4 * 1024 * 1024), "4.0 MB");
+    }
+}
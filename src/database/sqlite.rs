@@ -3,12 +3,17 @@
 #![forbid(unsafe_code)]
 
 use crate::core::error::{LazyTablesError, Result};
+use crate::database::insert::{self, InsertValue};
+use crate::database::preview::{self, ColumnFilter, Paging, DEFAULT_PREVIEW_CELL_CHARS};
 use crate::database::{
-    connection::ConnectionConfig, Connection, DataType, TableColumn, TableMetadata,
+    connection::ConnectionConfig, is_system_object, objects::like_contains, quote_ident,
+    ColumnMatch, Connection, DataType, DatabaseType, GeneratedColumn, IndexInfo, TableColumn,
+    TableMetadata,
 };
 use async_trait::async_trait;
+use futures::TryStreamExt;
 use sqlx::sqlite::{SqlitePool, SqlitePoolOptions};
-use sqlx::{Column, Row};
+use sqlx::{Column, Either, Row, Statement as _};
 use std::path::Path;
 
 /// SQLite database connection implementation
@@ -16,18 +21,29 @@ use std::path::Path;
 pub struct SqliteConnection {
     config: ConnectionConfig,
     pool: Option<SqlitePool>,
+    /// Characters of each cell a table preview reads; 0 reads whole values
+    preview_cell_chars: usize,
 }
 
 impl SqliteConnection {
     /// Create a new SQLite connection instance
     pub fn new(config: ConnectionConfig) -> Self {
-        Self { config, pool: None }
+        Self {
+            config,
+            pool: None,
+            preview_cell_chars: DEFAULT_PREVIEW_CELL_CHARS,
+        }
+    }
+
+    /// Set how many characters of each cell table previews read
+    pub fn set_preview_cell_chars(&mut self, max_chars: usize) {
+        self.preview_cell_chars = max_chars;
     }
 
     /// Build SQLite connection string
     fn build_connection_string(&self) -> String {
         // For SQLite, we use the database field as the file path
-        let db_path = self.config.database.as_deref().unwrap_or(":memory:");
+        let db_path = self.config.database_or_default();
 
         // Ensure the path exists if it's not in-memory
         if db_path != ":memory:" {
@@ -48,7 +64,7 @@ impl SqliteConnection {
 
         let error_str = error.to_string();
         let error_lower = error_str.to_lowercase();
-        let db_path = self.config.database.as_deref().unwrap_or(":memory:");
+        let db_path = self.config.database_or_default();
 
         // Classify error and provide user-friendly message
         if error_lower.contains("unable to open database")
@@ -142,8 +158,9 @@ impl Connection for SqliteConnection {
             .max_connections(1) // SQLite works best with single connection
             .connect(&connection_string)
             .await
-            .map_err(|e| {
-                LazyTablesError::Connection(format!("Failed to connect to SQLite: {e}"))
+            .map_err(|source| LazyTablesError::Connect {
+                database: "SQLite",
+                source,
             })?;
 
         // Enable foreign key constraints
@@ -180,14 +197,25 @@ impl Connection for SqliteConnection {
         SqliteConnection::list_tables(self).await
     }
 
-    async fn list_database_objects(&self) -> Result<crate::database::DatabaseObjectList> {
-        SqliteConnection::list_database_objects(self).await
+    async fn list_database_objects(
+        &self,
+        include_system: bool,
+    ) -> Result<crate::database::DatabaseObjectList> {
+        SqliteConnection::list_database_objects(self, include_system).await
     }
 
     async fn get_table_metadata(&self, table_name: &str) -> Result<crate::database::TableMetadata> {
         SqliteConnection::get_table_metadata(self, table_name).await
     }
 
+    async fn insert_row(
+        &self,
+        table_name: &str,
+        values: &[InsertValue],
+    ) -> Result<(Vec<String>, Vec<Vec<String>>)> {
+        SqliteConnection::insert_row(self, table_name, values).await
+    }
+
     async fn get_table_columns(
         &self,
         table_name: &str,
@@ -201,7 +229,8 @@ impl Connection for SqliteConnection {
         limit: usize,
         offset: usize,
     ) -> Result<Vec<Vec<String>>> {
-        SqliteConnection::get_table_data(self, table_name, limit, offset).await
+        SqliteConnection::get_table_data(self, table_name, limit, &Paging::Offset(offset), &[])
+            .await
     }
 
     // Database-specific capabilities (AC1 & AC2 requirement)
@@ -473,8 +502,12 @@ impl SqliteConnection {
         }
     }
 
-    /// List all database objects (tables, views) with metadata
-    pub async fn list_database_objects(&self) -> Result<crate::database::DatabaseObjectList> {
+    /// List all database objects (tables, views) with metadata, adding
+    /// SQLite's internal `sqlite_` tables when `include_system` is set
+    pub async fn list_database_objects(
+        &self,
+        include_system: bool,
+    ) -> Result<crate::database::DatabaseObjectList> {
         use crate::database::{DatabaseObject, DatabaseObjectList, DatabaseObjectType};
 
         if let Some(pool) = &self.pool {
@@ -488,7 +521,6 @@ impl SqliteConnection {
                     sql
                 FROM sqlite_master
                 WHERE type IN ('table', 'view')
-                    AND name NOT LIKE 'sqlite_%'
                 ORDER BY type, name
             ";
 
@@ -496,6 +528,10 @@ impl SqliteConnection {
                 Ok(rows) => {
                     for row in rows {
                         let name: String = row.get("name");
+                        let system = is_system_object(&DatabaseType::SQLite, None, &name);
+                        if system && !include_system {
+                            continue;
+                        }
                         let obj_type: String = row.get("type");
                         let _sql: Option<String> = row.get("sql");
 
@@ -548,6 +584,7 @@ impl SqliteConnection {
                             row_count,
                             size_bytes,
                             comment: None, // SQLite doesn't have native table comments
+                            system,
                         };
 
                         // Sort into appropriate lists
@@ -660,31 +697,94 @@ impl SqliteConnection {
         }
     }
 
+    /// Find columns whose name contains `pattern` (case-insensitive for ASCII)
+    /// in every table and view
+    pub async fn search_columns(&self, pattern: &str) -> Result<Vec<ColumnMatch>> {
+        if let Some(pool) = &self.pool {
+            let query = "SELECT
+                m.name AS table_name,
+                p.name AS column_name,
+                p.type AS data_type
+                FROM sqlite_master m
+                JOIN pragma_table_info(m.name) p
+                WHERE m.type IN ('table', 'view')
+                AND m.name NOT LIKE 'sqlite\\_%' ESCAPE '\\'
+                AND p.name LIKE ? ESCAPE '\\'
+                ORDER BY m.name, p.cid";
+
+            let rows = sqlx::query(query)
+                .bind(like_contains(pattern))
+                .fetch_all(pool)
+                .await?;
+
+            Ok(rows
+                .iter()
+                .map(|row| {
+                    let table: String = row.get("table_name");
+                    ColumnMatch {
+                        // Qualified the way the Tables pane lists SQLite objects
+                        table: format!("main.{table}"),
+                        column: row.get("column_name"),
+                        data_type: row.get("data_type"),
+                    }
+                })
+                .collect())
+        } else {
+            Err(LazyTablesError::Connection(
+                "Not connected to database".to_string(),
+            ))
+        }
+    }
+
     /// Get column information for a table
     pub async fn get_table_columns(&self, table_name: &str) -> Result<Vec<TableColumn>> {
         if let Some(pool) = &self.pool {
             // Validate and escape table name
             let safe_name = validate_sqlite_identifier(table_name)?;
-            let query = format!("PRAGMA table_info({})", safe_name);
+            // table_xinfo also lists generated columns, marked in `hidden`
+            let query = format!("PRAGMA table_xinfo({})", safe_name);
 
             let rows = sqlx::query(&query).fetch_all(pool).await?;
+            let pk_count = rows
+                .iter()
+                .filter(|row| row.get::<i32, _>("pk") > 0)
+                .count();
 
             let columns = rows
                 .iter()
-                .map(|row| {
+                .filter_map(|row| {
                     let column_name: String = row.get("name");
                     let data_type_str: String = row.get("type");
                     let not_null: i32 = row.get("notnull");
                     let default_value: Option<String> = row.get("dflt_value");
                     let is_pk: i32 = row.get("pk");
-
-                    TableColumn {
+                    let hidden: i32 = row.get("hidden");
+
+                    // 1 is a hidden virtual table column, 2 and 3 are virtual
+                    // and stored generated columns
+                    let generated = match hidden {
+                        0 => None,
+                        1 => return None,
+                        _ => Some(GeneratedColumn {
+                            stored: hidden == 3,
+                            expression: None,
+                        }),
+                    };
+
+                    // A lone INTEGER PRIMARY KEY is the rowid, numbered by SQLite
+                    let is_auto_increment =
+                        is_pk > 0 && pk_count == 1 && data_type_str.eq_ignore_ascii_case("INTEGER");
+
+                    Some(TableColumn {
                         name: column_name,
                         data_type: parse_sqlite_type(&data_type_str),
                         is_nullable: not_null == 0,
                         default_value,
                         is_primary_key: is_pk > 0,
-                    }
+                        is_auto_increment,
+                        generated,
+                        comment: None,
+                    })
                 })
                 .collect();
 
@@ -696,13 +796,68 @@ impl SqliteConnection {
         }
     }
 
-    /// Get the row count for a table
-    pub async fn get_table_row_count(&self, table_name: &str) -> Result<usize> {
+    /// List the indexes of a table with their columns in index order, the
+    /// primary key's first. A lone INTEGER PRIMARY KEY is the rowid and has
+    /// no index; expression index parts show as `(expression)`
+    pub async fn get_table_indexes(&self, table_name: &str) -> Result<Vec<IndexInfo>> {
+        if let Some(pool) = &self.pool {
+            let query = "SELECT
+                il.name AS index_name,
+                il.\"unique\" AS is_unique,
+                il.origin AS origin,
+                ii.name AS column_name
+                FROM pragma_index_list(?) AS il
+                JOIN pragma_index_info(il.name) AS ii
+                ORDER BY il.origin = 'pk' DESC, il.name, ii.seqno";
+
+            let rows = sqlx::query(query).bind(table_name).fetch_all(pool).await?;
+
+            // One row per indexed column
+            let mut indexes: Vec<IndexInfo> = Vec::new();
+            for row in &rows {
+                let name: String = row.get("index_name");
+                let column = row
+                    .get::<Option<String>, _>("column_name")
+                    .unwrap_or_else(|| "(expression)".to_string());
+                match indexes.last_mut() {
+                    Some(index) if index.name == name => index.columns.push(column),
+                    _ => indexes.push(IndexInfo {
+                        name,
+                        columns: vec![column],
+                        is_unique: row.get::<i32, _>("is_unique") != 0,
+                        is_primary: row.get::<String, _>("origin") == "pk",
+                        index_type: None,
+                        size: None,
+                    }),
+                }
+            }
+            Ok(indexes)
+        } else {
+            Err(LazyTablesError::Connection(
+                "Not connected to database".to_string(),
+            ))
+        }
+    }
+
+    /// Get the row count for a table, counting only rows passing `filters`
+    pub async fn get_table_row_count(
+        &self,
+        table_name: &str,
+        filters: &[ColumnFilter],
+    ) -> Result<usize> {
         if let Some(pool) = &self.pool {
             // Validate and escape table name
             let safe_name = validate_sqlite_identifier(table_name)?;
-            let query = format!("SELECT COUNT(*) FROM {}", safe_name);
-            let row = sqlx::query(&query).fetch_one(pool).await?;
+            let query = format!(
+                "SELECT COUNT(*) FROM {} {}",
+                safe_name,
+                preview::where_clause(&DatabaseType::SQLite, filters, None)
+            );
+            let mut statement = sqlx::query(&query);
+            for value in preview::filter_values(filters) {
+                statement = statement.bind(value);
+            }
+            let row = statement.fetch_one(pool).await?;
             let count: i64 = row.get(0);
             Ok(count as usize)
         } else {
@@ -712,12 +867,13 @@ impl SqliteConnection {
         }
     }
 
-    /// Get table data with pagination
+    /// Get a page of table data
     pub async fn get_table_data(
         &self,
         table_name: &str,
         limit: usize,
-        offset: usize,
+        paging: &Paging,
+        filters: &[ColumnFilter],
     ) -> Result<Vec<Vec<String>>> {
         if let Some(pool) = &self.pool {
             // Validate and escape table name
@@ -736,19 +892,28 @@ impl SqliteConnection {
                 return Ok(Vec::new());
             }
 
-            // Build SELECT query with all columns - validate each column name too
+            // Build SELECT query with all columns - validate each column name
+            // too - read as text and cut to the preview cell limit
             let select_list = column_names
                 .iter()
                 .filter_map(|col| validate_sqlite_identifier(col).ok())
+                .map(|col| {
+                    preview::preview_column(&DatabaseType::SQLite, &col, self.preview_cell_chars)
+                })
                 .collect::<Vec<_>>()
                 .join(", ");
 
             let query = format!(
-                "SELECT {select_list} FROM {} LIMIT {} OFFSET {}",
-                safe_table_name, limit, offset
+                "SELECT {select_list} FROM {} {}",
+                safe_table_name,
+                paging.clauses(&DatabaseType::SQLite, filters, limit)
             );
 
-            let rows = sqlx::query(&query).fetch_all(pool).await?;
+            let mut statement = sqlx::query(&query);
+            for value in preview::filter_values(filters) {
+                statement = statement.bind(value);
+            }
+            let rows = statement.fetch_all(pool).await?;
 
             let mut result = Vec::new();
             for row in rows {
@@ -756,10 +921,16 @@ impl SqliteConnection {
                 for (idx, _col_name) in column_names.iter().enumerate() {
                     // Try to get the value as string, handle NULL values
                     let value: Option<String> = row.try_get(idx).ok();
-                    row_data.push(value.unwrap_or_else(|| "NULL".to_string()));
+                    row_data.push(match value {
+                        Some(value) => preview::mark_partial(value, self.preview_cell_chars),
+                        None => "NULL".to_string(),
+                    });
                 }
                 result.push(row_data);
             }
+            if paging.reads_backwards() {
+                result.reverse();
+            }
 
             Ok(result)
         } else {
@@ -769,36 +940,101 @@ impl SqliteConnection {
         }
     }
 
+    /// Execute a raw SQL query, passing rows to the sink as they are fetched
+    pub async fn stream_raw_query(
+        &self,
+        query: &str,
+        sink: &mut dyn crate::database::RowSink,
+    ) -> Result<usize> {
+        let pool = self
+            .pool
+            .as_ref()
+            .ok_or_else(|| LazyTablesError::Connection("Not connected to database".to_string()))?;
+
+        let start = std::time::Instant::now();
+        let mut results = sqlx::Executor::fetch_many(pool, sqlx::query(query));
+        let mut count = 0;
+        let mut statement_rows = 0;
+        while let Some(item) = results.try_next().await? {
+            let row = match item {
+                Either::Left(done) => {
+                    if statement_rows == 0 {
+                        sink.affected_rows(done.rows_affected())?;
+                    }
+                    statement_rows = 0;
+                    continue;
+                }
+                Either::Right(row) => row,
+            };
+            if count == 0 {
+                let column_names: Vec<String> = row
+                    .columns()
+                    .iter()
+                    .map(|col| col.name().to_string())
+                    .collect();
+                sink.columns(&column_names)?;
+            }
+            let values = row
+                .columns()
+                .iter()
+                .map(|col| {
+                    let value: Option<String> = row.try_get(col.ordinal()).ok();
+                    value.unwrap_or_else(|| "NULL".to_string())
+                })
+                .collect();
+            sink.row(values)?;
+            statement_rows += 1;
+            count += 1;
+            if sink.is_full() {
+                break;
+            }
+        }
+        sink.elapsed(start.elapsed())?;
+
+        if count == 0 {
+            // No row to take the names from; the prepared statement has them,
+            // so an empty result keeps its header
+            drop(results);
+            let columns: Vec<String> = match sqlx::Executor::prepare(pool, query).await {
+                Ok(statement) => statement
+                    .columns()
+                    .iter()
+                    .map(|col| col.name().to_string())
+                    .collect(),
+                Err(_) => Vec::new(),
+            };
+            sink.columns(&columns)?;
+        }
+        Ok(count)
+    }
+
     /// Execute a raw SQL query and return columns and rows
     pub async fn execute_raw_query(&self, query: &str) -> Result<(Vec<String>, Vec<Vec<String>>)> {
         if let Some(pool) = &self.pool {
             // Try to execute the query
             let rows = sqlx::query(query).fetch_all(pool).await?;
+            Ok(result_strings(&rows))
+        } else {
+            Err(LazyTablesError::Connection(
+                "Not connected to database".to_string(),
+            ))
+        }
+    }
 
-            if rows.is_empty() {
-                return Ok((Vec::new(), Vec::new()));
-            }
-
-            // Get column information from the first row
-            let first_row = &rows[0];
-            let columns = first_row.columns();
-
-            let column_names: Vec<String> =
-                columns.iter().map(|col| col.name().to_string()).collect();
-
-            // Extract data from all rows
-            let mut result_rows = Vec::new();
-            for row in &rows {
-                let mut row_data = Vec::new();
-                for col in columns {
-                    // Try to get value as string
-                    let value: Option<String> = row.try_get(col.ordinal()).ok();
-                    row_data.push(value.unwrap_or_else(|| "NULL".to_string()));
-                }
-                result_rows.push(row_data);
+    /// Insert a row, returning the inserted row
+    pub async fn insert_row(
+        &self,
+        table_name: &str,
+        values: &[InsertValue],
+    ) -> Result<(Vec<String>, Vec<Vec<String>>)> {
+        if let Some(pool) = &self.pool {
+            let query = insert::insert_query(&DatabaseType::SQLite, table_name, values);
+            let mut statement = sqlx::query(&query);
+            for param in insert::insert_params(&DatabaseType::SQLite, values) {
+                statement = statement.bind(param);
             }
-
-            Ok((column_names, result_rows))
+            let rows = statement.fetch_all(pool).await?;
+            Ok(result_strings(&rows))
         } else {
             Err(LazyTablesError::Connection(
                 "Not connected to database".to_string(),
@@ -807,6 +1043,32 @@ impl SqliteConnection {
     }
 }
 
+/// Column names and values, as text, of rows read
+fn result_strings(rows: &[sqlx::sqlite::SqliteRow]) -> (Vec<String>, Vec<Vec<String>>) {
+    let Some(first_row) = rows.first() else {
+        return (Vec::new(), Vec::new());
+    };
+
+    // Get column information from the first row
+    let columns = first_row.columns();
+    let column_names: Vec<String> = columns.iter().map(|col| col.name().to_string()).collect();
+
+    // Extract data from all rows, as strings where they read as one
+    let result_rows = rows
+        .iter()
+        .map(|row| {
+            columns
+                .iter()
+                .map(|col| {
+                    row.try_get::<String, _>(col.ordinal())
+                        .unwrap_or_else(|_| "NULL".to_string())
+                })
+                .collect()
+        })
+        .collect();
+    (column_names, result_rows)
+}
+
 /// Validate and escape SQLite identifiers to prevent SQL injection
 /// SQLite allows double quotes or brackets for identifiers
 fn validate_sqlite_identifier(name: &str) -> Result<String> {
@@ -817,10 +1079,7 @@ fn validate_sqlite_identifier(name: &str) -> Result<String> {
         ));
     }
 
-    // SQLite allows most characters in identifiers when properly quoted
-    // We'll use double quotes and escape any embedded quotes
-    let escaped = name.replace('"', "\"\"");
-    Ok(format!("\"{}\"", escaped))
+    Ok(quote_ident(&DatabaseType::SQLite, &[name]))
 }
 
 /// Parse SQLite data type string to internal DataType enum
@@ -863,13 +1122,30 @@ impl crate::database::connection_manager::ManagedConnection for SqliteConnection
         SqliteConnection::execute_raw_query(self, query).await
     }
 
+    async fn stream_raw_query(
+        &self,
+        query: &str,
+        sink: &mut dyn crate::database::RowSink,
+    ) -> Result<usize> {
+        SqliteConnection::stream_raw_query(self, query, sink).await
+    }
+
     async fn get_table_data(
         &self,
         table_name: &str,
         limit: usize,
-        offset: usize,
+        paging: &Paging,
+        filters: &[ColumnFilter],
     ) -> Result<Vec<Vec<String>>> {
-        SqliteConnection::get_table_data(self, table_name, limit, offset).await
+        SqliteConnection::get_table_data(self, table_name, limit, paging, filters).await
+    }
+
+    async fn get_table_row_count(
+        &self,
+        table_name: &str,
+        filters: &[ColumnFilter],
+    ) -> Result<usize> {
+        SqliteConnection::get_table_row_count(self, table_name, filters).await
     }
 
     async fn get_table_columns(
@@ -883,14 +1159,71 @@ impl crate::database::connection_manager::ManagedConnection for SqliteConnection
         SqliteConnection::get_table_metadata(self, table_name).await
     }
 
-    async fn list_database_objects(&self) -> Result<crate::database::DatabaseObjectList> {
-        SqliteConnection::list_database_objects(self).await
+    async fn list_database_objects(
+        &self,
+        include_system: bool,
+    ) -> Result<crate::database::DatabaseObjectList> {
+        SqliteConnection::list_database_objects(self, include_system).await
+    }
+
+    async fn search_columns(&self, pattern: &str) -> Result<Vec<ColumnMatch>> {
+        SqliteConnection::search_columns(self, pattern).await
+    }
+
+    async fn get_table_indexes(&self, table_name: &str) -> Result<Vec<IndexInfo>> {
+        SqliteConnection::get_table_indexes(self, table_name).await
     }
 
     fn is_connected(&self) -> bool {
         Connection::is_connected(self)
     }
+
+    async fn close(&mut self) -> Result<()> {
+        Connection::disconnect(self).await
+    }
 }
 
 // Drop implementation removed - connection pools are closed explicitly via disconnect() method
 // to avoid spawning background tasks that may not complete before app shutdown
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::database::result::CappedCollector;
+    use crate::database::{QueryResult, ResultLimits};
+
+    async fn run(connection: &SqliteConnection, query: &str) -> QueryResult {
+        let mut sink = CappedCollector::new(ResultLimits::default());
+        connection.stream_raw_query(query, &mut sink).await.unwrap();
+        sink.finish()
+    }
+
+    #[tokio::test]
+    async fn test_stream_reports_time_and_affected_rows() {
+        let mut config = ConnectionConfig::new(
+            "scratch".to_string(),
+            DatabaseType::SQLite,
+            String::new(),
+            0,
+            String::new(),
+        );
+        config.database = Some(":memory:".to_string());
+        let mut connection = SqliteConnection::new(config);
+        connection.connect().await.unwrap();
+
+        run(&connection, "CREATE TABLE items (id INTEGER)").await;
+        let insert = run(&connection, "INSERT INTO items VALUES (1), (2), (3)").await;
+        assert_eq!(insert.affected_rows, Some(3));
+        assert!(insert.rows.is_empty());
+        assert!(insert.elapsed.is_some_and(|elapsed| !elapsed.is_zero()));
+
+        let select = run(&connection, "SELECT id FROM items").await;
+        assert_eq!(select.rows.len(), 3);
+        assert!(select.elapsed.is_some_and(|elapsed| !elapsed.is_zero()));
+
+        // An empty result still names its columns
+        let empty = run(&connection, "SELECT id FROM items WHERE id > 3").await;
+        assert!(empty.rows.is_empty());
+        assert_eq!(empty.columns, vec!["id"]);
+    }
+}
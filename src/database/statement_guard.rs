@@ -0,0 +1,341 @@
+// FilePath: src/database/statement_guard.rs
+
+//! Guarding destructive statements
+//!
+//! Statements from the query editor that drop, empty or alter objects, or
+//! delete or update every row of a table, are checked against a policy before
+//! they run: confirm first, block, or allow. The policy is set in the
+//! `[statement_guard]` section of the config and can be overridden per
+//! connection name, so a production connection can block what a local one
+//! allows.
+
+#![forbid(unsafe_code)]
+
+use super::update_preview::{top_level_tokens, Token};
+use serde::{Deserialize, Serialize};
+use std::collections::BTreeMap;
+
+/// Kind of statement the guard checks
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum GuardedStatement {
+    Drop,
+    Truncate,
+    DeleteWithoutWhere,
+    UpdateWithoutWhere,
+    Alter,
+}
+
+impl GuardedStatement {
+    /// The first guarded statement among those in `sql`
+    pub fn of(sql: &str) -> Option<Self> {
+        Self::all(sql).into_iter().next()
+    }
+
+    /// Every guarded statement among those in `sql`, in order, read by
+    /// their top-level words so strings, comments and subqueries don't
+    /// count. The verb of a `WITH` statement is the one after its common
+    /// table expressions, whose own statements are checked first: a
+    /// `DELETE` in one runs whatever the statement after them does
+    pub fn all(sql: &str) -> Vec<Self> {
+        let Some(tokens) = top_level_tokens(sql) else {
+            return Vec::new();
+        };
+        let mut found = Vec::new();
+        for tokens in tokens.split(|token| token.text == ";") {
+            let words: Vec<&str> = tokens.iter().map(|token| token.text.as_str()).collect();
+            if words.first() == Some(&"WITH") {
+                found.extend(cte_bodies(sql, tokens).flat_map(Self::all));
+            }
+            found.extend(Self::of_words(&words));
+        }
+        found
+    }
+
+    /// The guarded statement a single statement's top-level words make
+    fn of_words(words: &[&str]) -> Option<Self> {
+        let verb = match *words.first()? {
+            "WITH" => *words
+                .iter()
+                .find(|word| matches!(**word, "SELECT" | "INSERT" | "UPDATE" | "DELETE"))?,
+            verb => verb,
+        };
+        let filtered = words.contains(&"WHERE");
+        match verb {
+            "DROP" => Some(Self::Drop),
+            "TRUNCATE" => Some(Self::Truncate),
+            "ALTER" => Some(Self::Alter),
+            "DELETE" if !filtered => Some(Self::DeleteWithoutWhere),
+            "UPDATE" if !filtered => Some(Self::UpdateWithoutWhere),
+            _ => None,
+        }
+    }
+
+    /// Name shown in confirmations and notifications
+    pub fn label(self) -> &'static str {
+        match self {
+            Self::Drop => "DROP",
+            Self::Truncate => "TRUNCATE",
+            Self::DeleteWithoutWhere => "DELETE without WHERE",
+            Self::UpdateWithoutWhere => "UPDATE without WHERE",
+            Self::Alter => "ALTER",
+        }
+    }
+}
+
+/// The statements of a `WITH` statement's common table expressions, the
+/// text between the parentheses after each `AS`
+fn cte_bodies<'a>(sql: &'a str, tokens: &'a [Token]) -> impl Iterator<Item = &'a str> + 'a {
+    tokens
+        .windows(2)
+        .take_while(|pair| {
+            !matches!(
+                pair[0].text.as_str(),
+                "SELECT" | "INSERT" | "UPDATE" | "DELETE"
+            )
+        })
+        .filter(|pair| matches!(pair[0].text.as_str(), "AS" | "MATERIALIZED"))
+        .filter_map(|pair| {
+            let body = sql[pair[0].end..pair[1].start]
+                .trim_start()
+                .strip_prefix('(')?;
+            Some(&body[..body.rfind(')')?])
+        })
+}
+
+/// What the guard does with a statement
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Default, Serialize, Deserialize)]
+#[serde(rename_all = "lowercase")]
+pub enum GuardAction {
+    /// Ask before running it
+    #[default]
+    Confirm,
+    /// Refuse to run it
+    Block,
+    /// Run it like any other statement
+    Allow,
+}
+
+impl GuardAction {
+    /// Order of the actions from allowing to blocking, so the strictest of
+    /// several statements' actions applies to them all
+    fn strictness(self) -> u8 {
+        match self {
+            Self::Allow => 0,
+            Self::Confirm => 1,
+            Self::Block => 2,
+        }
+    }
+}
+
+/// Actions for each guarded statement; unset ones fall back to the
+/// default policy, and from there to confirming
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+#[serde(default)]
+pub struct GuardRules {
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub drop: Option<GuardAction>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub truncate: Option<GuardAction>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub delete_without_where: Option<GuardAction>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub update_without_where: Option<GuardAction>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub alter: Option<GuardAction>,
+}
+
+impl GuardRules {
+    fn get(&self, statement: GuardedStatement) -> Option<GuardAction> {
+        match statement {
+            GuardedStatement::Drop => self.drop,
+            GuardedStatement::Truncate => self.truncate,
+            GuardedStatement::DeleteWithoutWhere => self.delete_without_where,
+            GuardedStatement::UpdateWithoutWhere => self.update_without_where,
+            GuardedStatement::Alter => self.alter,
+        }
+    }
+}
+
+/// The `[statement_guard]` config section: the default policy, and the
+/// policies of connections by name
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+#[serde(default)]
+pub struct StatementGuardConfig {
+    #[serde(flatten)]
+    pub rules: GuardRules,
+    #[serde(skip_serializing_if = "BTreeMap::is_empty")]
+    pub connections: BTreeMap<String, GuardRules>,
+}
+
+/// The policy that applies to a guarded statement
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct GuardVerdict {
+    pub statement: GuardedStatement,
+    pub action: GuardAction,
+    /// Connection whose own policy set the action; None for the default
+    pub connection: Option<String>,
+}
+
+impl GuardVerdict {
+    /// The policy that fired, e.g. `the DROP policy of connection "Prod"`
+    pub fn policy(&self) -> String {
+        match &self.connection {
+            Some(name) => format!(
+                "the {} policy of connection \"{name}\"",
+                self.statement.label()
+            ),
+            None => format!("the default {} policy", self.statement.label()),
+        }
+    }
+}
+
+impl StatementGuardConfig {
+    /// The effective policy for `sql` on the named connection; None when
+    /// no statement in it is guarded. Of several guarded statements, the
+    /// first with the strictest action decides, since they run together
+    pub fn check(&self, connection_name: &str, sql: &str) -> Option<GuardVerdict> {
+        GuardedStatement::all(sql)
+            .into_iter()
+            .map(|statement| self.verdict(connection_name, statement))
+            .reduce(|strictest, verdict| {
+                if verdict.action.strictness() > strictest.action.strictness() {
+                    verdict
+                } else {
+                    strictest
+                }
+            })
+    }
+
+    /// The policy for one guarded statement on the named connection
+    fn verdict(&self, connection_name: &str, statement: GuardedStatement) -> GuardVerdict {
+        let (action, connection) = match self
+            .connections
+            .get(connection_name)
+            .and_then(|rules| rules.get(statement))
+        {
+            Some(action) => (action, Some(connection_name.to_string())),
+            None => (self.rules.get(statement).unwrap_or_default(), None),
+        };
+        GuardVerdict {
+            statement,
+            action,
+            connection,
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_guarded_statements() {
+        use GuardedStatement::*;
+        for (sql, expected) in [
+            ("drop table orders", Some(Drop)),
+            ("-- clean up\nTRUNCATE orders;", Some(Truncate)),
+            ("ALTER TABLE orders ADD COLUMN note text", Some(Alter)),
+            ("DELETE FROM orders", Some(DeleteWithoutWhere)),
+            ("DELETE FROM orders WHERE id = 1", None),
+            (
+                "UPDATE orders SET note = 'WHERE' || (SELECT 1 WHERE true)",
+                Some(UpdateWithoutWhere),
+            ),
+            ("UPDATE orders SET note = '' WHERE id = 1", None),
+            (
+                "WITH old AS (SELECT id FROM orders WHERE id < 5) DELETE FROM orders",
+                Some(DeleteWithoutWhere),
+            ),
+            (
+                "WITH gone AS (DELETE FROM orders RETURNING *) SELECT * FROM gone",
+                Some(DeleteWithoutWhere),
+            ),
+            (
+                "with t as materialized (update orders set note = '' returning id) select 1",
+                Some(UpdateWithoutWhere),
+            ),
+            (
+                "WITH gone AS (DELETE FROM orders WHERE id = 1 RETURNING *) SELECT * FROM gone",
+                None,
+            ),
+            (
+                "WITH a AS (SELECT 1), b AS (WITH c AS (DELETE FROM orders RETURNING id) \
+                 SELECT id FROM c) SELECT * FROM b",
+                Some(DeleteWithoutWhere),
+            ),
+            ("SELECT 'drop table orders'", None),
+            ("SELECT 1; DROP TABLE orders", Some(Drop)),
+        ] {
+            assert_eq!(GuardedStatement::of(sql), expected, "{sql}");
+        }
+
+        assert_eq!(
+            GuardedStatement::all(
+                "TRUNCATE a; SELECT 1; WITH g AS (DELETE FROM b RETURNING *) \
+                 UPDATE c SET x = 1"
+            ),
+            vec![Truncate, DeleteWithoutWhere, UpdateWithoutWhere]
+        );
+    }
+
+    #[test]
+    fn test_strictest_statement_decides() {
+        let config: StatementGuardConfig = toml::from_str(
+            r#"
+            truncate = "allow"
+            drop = "block"
+            alter = "confirm"
+            "#,
+        )
+        .unwrap();
+
+        let verdict = config.check("Local", "TRUNCATE a; DROP TABLE b").unwrap();
+        assert_eq!(verdict.statement, GuardedStatement::Drop);
+        assert_eq!(verdict.action, GuardAction::Block);
+
+        let verdict = config
+            .check("Local", "TRUNCATE a; ALTER TABLE b ADD c int; DROP TABLE d")
+            .unwrap();
+        assert_eq!(verdict.action, GuardAction::Block);
+
+        let verdict = config
+            .check("Local", "ALTER TABLE b ADD c int; TRUNCATE a")
+            .unwrap();
+        assert_eq!(verdict.statement, GuardedStatement::Alter);
+        assert_eq!(verdict.action, GuardAction::Confirm);
+
+        let verdict = config.check("Local", "TRUNCATE a; TRUNCATE b").unwrap();
+        assert_eq!(verdict.action, GuardAction::Allow);
+    }
+
+    #[test]
+    fn test_connection_policy_overrides_default() {
+        let config: StatementGuardConfig = toml::from_str(
+            r#"
+            drop = "allow"
+            alter = "allow"
+
+            [connections.Production]
+            drop = "block"
+            "#,
+        )
+        .unwrap();
+
+        let verdict = config.check("Production", "DROP TABLE orders").unwrap();
+        assert_eq!(verdict.action, GuardAction::Block);
+        assert_eq!(
+            verdict.policy(),
+            "the DROP policy of connection \"Production\""
+        );
+
+        let verdict = config
+            .check("Production", "ALTER TABLE orders RENAME TO o")
+            .unwrap();
+        assert_eq!(verdict.action, GuardAction::Allow);
+        assert_eq!(verdict.policy(), "the default ALTER policy");
+
+        let verdict = config.check("Local", "TRUNCATE orders").unwrap();
+        assert_eq!(verdict.action, GuardAction::Confirm);
+        assert!(config.check("Local", "SELECT * FROM orders").is_none());
+    }
+}
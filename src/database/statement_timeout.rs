@@ -0,0 +1,137 @@
+// FilePath: src/database/statement_timeout.rs
+
+//! Server-side statement timeout
+//!
+//! Stopping a query from the client leaves it running on the server when the
+//! client dies or the cancel never gets through. With a statement timeout
+//! set on its sessions, the server cancels a query that runs too long by
+//! itself: `statement_timeout` on PostgreSQL, `max_statement_time` on
+//! MariaDB and `max_execution_time` on MySQL, which only limits `SELECT`s.
+//! SQLite has no server to set one on. A connection's own timeout overrides
+//! `[app] statement_timeout_secs`, and `:timeout` in the query editor
+//! changes it for the rest of the session. Zero means none.
+
+#![forbid(unsafe_code)]
+
+use super::{ConnectionConfig, DatabaseType};
+use crate::app::session_events::{SessionEvent, SessionSubscriber};
+use std::collections::HashMap;
+
+/// `SET` statement giving the session's statements `seconds` to finish, 0
+/// lifting the limit. None where the database has no such setting
+pub fn set_sql(database_type: &DatabaseType, seconds: u64) -> Option<String> {
+    let millis = seconds.saturating_mul(1000);
+    match database_type {
+        DatabaseType::PostgreSQL => Some(format!("SET statement_timeout = {millis}")),
+        DatabaseType::MySQL => Some(format!("SET SESSION max_execution_time = {millis}")),
+        DatabaseType::MariaDB => Some(format!("SET SESSION max_statement_time = {seconds}")),
+        _ => None,
+    }
+}
+
+/// Timeouts in effect for the session's connections
+#[derive(Debug, Clone, Default)]
+pub struct StatementTimeouts {
+    /// `[app] statement_timeout_secs`, for connections setting none
+    pub default_secs: u64,
+    /// Set with `:timeout` by connection ID, until the connection closes
+    overrides: HashMap<String, u64>,
+}
+
+impl StatementTimeouts {
+    pub fn new(default_secs: u64) -> Self {
+        Self {
+            default_secs,
+            overrides: HashMap::new(),
+        }
+    }
+
+    /// The timeout a connection's sessions start with, in seconds
+    pub fn configured(&self, connection: &ConnectionConfig) -> u64 {
+        connection.statement_timeout.unwrap_or(self.default_secs)
+    }
+
+    /// Seconds the connection's statements have now; None when there's no
+    /// timeout or the database can't have one
+    pub fn effective(&self, connection: &ConnectionConfig) -> Option<u64> {
+        set_sql(&connection.database_type, 0)?;
+        let seconds = self
+            .overrides
+            .get(&connection.id)
+            .copied()
+            .unwrap_or_else(|| self.configured(connection));
+        (seconds > 0).then_some(seconds)
+    }
+
+    /// Note the timeout `:timeout` set on the connection's session
+    pub fn set_override(&mut self, connection_id: &str, seconds: u64) {
+        self.overrides.insert(connection_id.to_string(), seconds);
+    }
+
+    /// Forget the `:timeout` of the connection, back to the configured one
+    pub fn clear_override(&mut self, connection_id: &str) {
+        self.overrides.remove(connection_id);
+    }
+}
+
+impl SessionSubscriber for StatementTimeouts {
+    /// A new session starts from the configured timeout
+    fn on_session_event(&mut self, event: &SessionEvent) {
+        match event {
+            SessionEvent::ConnectionOpened { connection_id }
+            | SessionEvent::ConnectionClosed { connection_id } => {
+                self.clear_override(connection_id);
+            }
+            _ => {}
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_set_sql() {
+        assert_eq!(
+            set_sql(&DatabaseType::PostgreSQL, 30).as_deref(),
+            Some("SET statement_timeout = 30000")
+        );
+        assert_eq!(
+            set_sql(&DatabaseType::MySQL, 0).as_deref(),
+            Some("SET SESSION max_execution_time = 0")
+        );
+        assert_eq!(
+            set_sql(&DatabaseType::MariaDB, 30).as_deref(),
+            Some("SET SESSION max_statement_time = 30")
+        );
+        assert_eq!(set_sql(&DatabaseType::SQLite, 30), None);
+    }
+
+    #[test]
+    fn test_effective_timeout() {
+        let mut connection = ConnectionConfig::new(
+            "app".to_string(),
+            DatabaseType::PostgreSQL,
+            "localhost".to_string(),
+            5432,
+            "app".to_string(),
+        );
+        let mut timeouts = StatementTimeouts::new(30);
+        assert_eq!(timeouts.effective(&connection), Some(30));
+
+        connection.statement_timeout = Some(0);
+        assert_eq!(timeouts.effective(&connection), None);
+
+        timeouts.set_override(&connection.id, 300);
+        assert_eq!(timeouts.effective(&connection), Some(300));
+        timeouts.on_session_event(&SessionEvent::ConnectionClosed {
+            connection_id: connection.id.clone(),
+        });
+        assert_eq!(timeouts.effective(&connection), None);
+
+        connection.database_type = DatabaseType::SQLite;
+        connection.statement_timeout = Some(30);
+        assert_eq!(timeouts.effective(&connection), None);
+    }
+}
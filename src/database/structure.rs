@@ -0,0 +1,140 @@
+// FilePath: src/database/structure.rs
+
+//! A table's columns as a result set, one row per column with its type,
+//! nullability, default, key and the indexes covering it. Shown by `i` in the
+//! Tables pane, where it can be searched and copied like any result
+
+#![forbid(unsafe_code)]
+
+use super::{IndexInfo, QueryResult, TableColumn};
+
+/// Columns of the structure result
+const STRUCTURE_COLUMNS: [&str; 7] = [
+    "column", "type", "nullable", "default", "key", "indexes", "comment",
+];
+
+/// The structure of a table with `columns` and `indexes` as a result set
+pub fn structure_result(columns: &[TableColumn], indexes: &[IndexInfo]) -> QueryResult {
+    let rows = columns
+        .iter()
+        .map(|column| {
+            let mut data_type = column.data_type.to_sql();
+            if column.is_auto_increment {
+                data_type.push_str(" (auto increment)");
+            }
+            if let Some(generated) = &column.generated {
+                data_type.push_str(&format!(" ({})", generated.label()));
+            }
+            vec![
+                column.name.clone(),
+                data_type,
+                if column.is_nullable { "YES" } else { "NO" }.to_string(),
+                column.default_value.clone().unwrap_or_default(),
+                key(column, indexes).to_string(),
+                covering_indexes(&column.name, indexes),
+                column.comment.clone().unwrap_or_default(),
+            ]
+        })
+        .collect();
+
+    QueryResult {
+        columns: STRUCTURE_COLUMNS.iter().map(|c| c.to_string()).collect(),
+        rows,
+        ..QueryResult::default()
+    }
+}
+
+/// `PRI` for a primary key column, `UNI` for one a unique index covers alone
+fn key(column: &TableColumn, indexes: &[IndexInfo]) -> &'static str {
+    if column.is_primary_key {
+        "PRI"
+    } else if indexes
+        .iter()
+        .any(|index| index.is_unique && index.columns == [column.name.as_str()])
+    {
+        "UNI"
+    } else {
+        ""
+    }
+}
+
+/// Indexes other than the primary key's that cover `column`, with the
+/// column's position in indexes of several columns: `ix_orders_customer (1/2)`
+fn covering_indexes(column: &str, indexes: &[IndexInfo]) -> String {
+    indexes
+        .iter()
+        .filter(|index| !index.is_primary)
+        .filter_map(|index| {
+            let position = index.columns.iter().position(|c| c == column)?;
+            let mut entry = index.name.clone();
+            if index.columns.len() > 1 {
+                entry.push_str(&format!(" ({}/{})", position + 1, index.columns.len()));
+            }
+            if index.is_unique {
+                entry.push_str(" unique");
+            }
+            Some(entry)
+        })
+        .collect::<Vec<_>>()
+        .join(", ")
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::database::DataType;
+
+    fn column(name: &str, data_type: DataType, is_primary_key: bool) -> TableColumn {
+        TableColumn {
+            name: name.to_string(),
+            data_type,
+            is_nullable: !is_primary_key,
+            default_value: None,
+            is_primary_key,
+            is_auto_increment: is_primary_key,
+            generated: None,
+            comment: None,
+        }
+    }
+
+    fn index(name: &str, columns: &[&str], is_unique: bool, is_primary: bool) -> IndexInfo {
+        IndexInfo {
+            name: name.to_string(),
+            columns: columns.iter().map(|c| c.to_string()).collect(),
+            is_unique,
+            is_primary,
+            index_type: None,
+            size: None,
+        }
+    }
+
+    #[test]
+    fn test_structure_rows_show_keys_and_indexes() {
+        let columns = [
+            column("id", DataType::BigInt, true),
+            column("email", DataType::Text, false),
+            column("customer_id", DataType::Integer, false),
+        ];
+        let indexes = [
+            index("orders_pkey", &["id"], true, true),
+            index("orders_email_key", &["email"], true, false),
+            index("ix_customer", &["customer_id", "email"], false, false),
+        ];
+        let result = structure_result(&columns, &indexes);
+
+        assert_eq!(result.columns[0], "column");
+        assert_eq!(result.rows.len(), 3);
+        assert_eq!(result.rows[0][2], "NO");
+        assert!(result.rows[0][1].ends_with("(auto increment)"));
+        assert_eq!(result.rows[0][4], "PRI");
+        assert_eq!(result.rows[0][5], "");
+
+        assert_eq!(result.rows[1][4], "UNI");
+        assert_eq!(
+            result.rows[1][5],
+            "orders_email_key unique, ix_customer (2/2)"
+        );
+        assert_eq!(result.rows[2][4], "");
+        assert_eq!(result.rows[2][5], "ix_customer (1/2)");
+    }
+}
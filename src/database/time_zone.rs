@@ -0,0 +1,225 @@
+// FilePath: src/database/time_zone.rs
+
+//! Time zone timestamps are shown in
+//!
+//! A connection can name the zone its session runs in and its timestamps are
+//! rendered in. The server is told where it accepts the zone (PostgreSQL
+//! always, MySQL for UTC and fixed offsets, since named zones there need the
+//! time zone tables loaded); values decoded as instants are converted
+//! client-side, so the grid shows the zone the status bar names either way.
+
+#![forbid(unsafe_code)]
+
+use super::{quote_literal, DatabaseType};
+use chrono::{DateTime, FixedOffset, Local, NaiveDateTime, Utc};
+use chrono_tz::Tz;
+use std::fmt;
+
+/// How zoned timestamps are written in the grid, e.g.
+/// `2024-03-01 14:05:00+01:00`
+const TIMESTAMP_FORMAT: &str = "%Y-%m-%d %H:%M:%S%.f%:z";
+
+/// Zone a connection's timestamps are displayed in
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Default)]
+pub enum DisplayTimeZone {
+    /// Coordinated Universal Time, used when a connection sets no zone
+    #[default]
+    Utc,
+    /// The zone of the machine LazyTables runs on
+    Local,
+    /// A fixed offset such as `+05:30`
+    Offset(FixedOffset),
+    /// An IANA zone such as `Europe/Berlin`
+    Named(Tz),
+}
+
+impl DisplayTimeZone {
+    /// Parse `UTC`, `local`, an offset like `+05:30` or `-08` or an IANA
+    /// zone name like `America/New_York`
+    pub fn parse(value: &str) -> Result<Self, String> {
+        let value = value.trim();
+        if value.eq_ignore_ascii_case("utc") || value == "Z" {
+            return Ok(Self::Utc);
+        }
+        if value.eq_ignore_ascii_case("local") {
+            return Ok(Self::Local);
+        }
+        if value.starts_with(['+', '-']) {
+            return parse_offset(value)
+                .map(Self::Offset)
+                .ok_or_else(|| format!("Invalid UTC offset '{value}', expected e.g. +05:30"));
+        }
+        value
+            .parse::<Tz>()
+            .map(Self::Named)
+            .map_err(|_| format!("Unknown time zone '{value}', expected e.g. Europe/Berlin"))
+    }
+
+    /// Write an instant as a timestamp in this zone
+    pub fn format(&self, instant: DateTime<Utc>) -> String {
+        match self {
+            Self::Utc => instant.format(TIMESTAMP_FORMAT).to_string(),
+            Self::Local => instant
+                .with_timezone(&Local)
+                .format(TIMESTAMP_FORMAT)
+                .to_string(),
+            Self::Offset(offset) => instant
+                .with_timezone(offset)
+                .format(TIMESTAMP_FORMAT)
+                .to_string(),
+            Self::Named(tz) => instant
+                .with_timezone(tz)
+                .format(TIMESTAMP_FORMAT)
+                .to_string(),
+        }
+    }
+
+    /// Write a wall-clock time read in a session running at `session_offset`
+    /// as a timestamp in this zone
+    pub fn format_session_time(&self, time: NaiveDateTime, session_offset: FixedOffset) -> String {
+        match time.and_local_timezone(session_offset).single() {
+            Some(local) => self.format(local.with_timezone(&Utc)),
+            None => time.to_string(),
+        }
+    }
+
+    /// Statement putting a PostgreSQL session in this zone. The local zone
+    /// has no name the server is sure to know, so it goes as today's offset
+    pub fn postgres_set_sql(&self) -> String {
+        match self {
+            Self::Utc => "SET TIME ZONE 'UTC'".to_string(),
+            Self::Local => Self::Offset(*Local::now().offset()).postgres_set_sql(),
+            // A bare '+05:30' would be read as a POSIX zone, west-positive
+            Self::Offset(offset) => format!("SET TIME ZONE INTERVAL '{offset}' HOUR TO MINUTE"),
+            Self::Named(tz) => format!(
+                "SET TIME ZONE {}",
+                quote_literal(&DatabaseType::PostgreSQL, tz.name())
+            ),
+        }
+    }
+
+    /// Offset a MySQL session can be put in without the server's time zone
+    /// tables; `None` keeps the session in UTC and leaves conversion to us
+    pub fn mysql_session_offset(&self) -> Option<FixedOffset> {
+        match self {
+            Self::Utc => FixedOffset::east_opt(0),
+            Self::Offset(offset) => Some(*offset),
+            Self::Local | Self::Named(_) => None,
+        }
+    }
+}
+
+impl fmt::Display for DisplayTimeZone {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            Self::Utc => write!(f, "UTC"),
+            Self::Local => write!(f, "local ({})", Local::now().offset()),
+            Self::Offset(offset) => write!(f, "UTC{offset}"),
+            Self::Named(tz) => write!(f, "{}", tz.name()),
+        }
+    }
+}
+
+/// Parse `+05:30`, `+0530` or `-08` into an offset east of UTC
+fn parse_offset(value: &str) -> Option<FixedOffset> {
+    let (sign, digits) = match value.split_at(1) {
+        ("+", rest) => (1, rest),
+        ("-", rest) => (-1, rest),
+        _ => return None,
+    };
+    let digits = digits.replace(':', "");
+    if !digits.chars().all(|c| c.is_ascii_digit()) {
+        return None;
+    }
+    let (hours, minutes) = match digits.len() {
+        1 | 2 => (digits.parse::<i32>().ok()?, 0),
+        4 => (
+            digits[..2].parse::<i32>().ok()?,
+            digits[2..].parse::<i32>().ok()?,
+        ),
+        _ => return None,
+    };
+    if hours > 14 || minutes >= 60 {
+        return None;
+    }
+    FixedOffset::east_opt(sign * (hours * 3600 + minutes * 60))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use chrono::TimeZone;
+
+    #[test]
+    fn test_parse_time_zones() {
+        assert_eq!(DisplayTimeZone::parse(" utc "), Ok(DisplayTimeZone::Utc));
+        assert_eq!(DisplayTimeZone::parse("Local"), Ok(DisplayTimeZone::Local));
+        assert_eq!(
+            DisplayTimeZone::parse("+05:30"),
+            Ok(DisplayTimeZone::Offset(
+                FixedOffset::east_opt(5 * 3600 + 30 * 60).unwrap()
+            ))
+        );
+        assert_eq!(
+            DisplayTimeZone::parse("-08"),
+            Ok(DisplayTimeZone::Offset(
+                FixedOffset::west_opt(8 * 3600).unwrap()
+            ))
+        );
+        assert_eq!(
+            DisplayTimeZone::parse("Europe/Berlin"),
+            Ok(DisplayTimeZone::Named(Tz::Europe__Berlin))
+        );
+        assert!(DisplayTimeZone::parse("+25:00").is_err());
+        assert!(DisplayTimeZone::parse("Mars/Olympus").is_err());
+    }
+
+    #[test]
+    fn test_format_converts_to_zone() {
+        let instant = Utc.with_ymd_and_hms(2024, 7, 1, 12, 0, 0).unwrap();
+        assert_eq!(
+            DisplayTimeZone::Utc.format(instant),
+            "2024-07-01 12:00:00+00:00"
+        );
+        assert_eq!(
+            DisplayTimeZone::parse("America/New_York")
+                .unwrap()
+                .format(instant),
+            "2024-07-01 08:00:00-04:00"
+        );
+
+        // A MySQL session at +02:00 read 14:00; in UTC that's noon
+        let session = FixedOffset::east_opt(2 * 3600).unwrap();
+        let wall_clock = instant.naive_utc() + chrono::Duration::hours(2);
+        assert_eq!(
+            DisplayTimeZone::Utc.format_session_time(wall_clock, session),
+            "2024-07-01 12:00:00+00:00"
+        );
+    }
+
+    #[test]
+    fn test_server_settings() {
+        assert_eq!(
+            DisplayTimeZone::parse("-03:30").unwrap().postgres_set_sql(),
+            "SET TIME ZONE INTERVAL '-03:30' HOUR TO MINUTE"
+        );
+        assert_eq!(
+            DisplayTimeZone::parse("Asia/Kolkata")
+                .unwrap()
+                .postgres_set_sql(),
+            "SET TIME ZONE 'Asia/Kolkata'"
+        );
+        assert_eq!(
+            DisplayTimeZone::Utc
+                .mysql_session_offset()
+                .map(|o| o.to_string()),
+            Some("+00:00".to_string())
+        );
+        assert_eq!(
+            DisplayTimeZone::parse("Asia/Kolkata")
+                .unwrap()
+                .mysql_session_offset(),
+            None
+        );
+    }
+}
@@ -0,0 +1,152 @@
+// FilePath: src/database/transaction.rs
+
+//! Transaction state of the session connection
+//!
+//! The driver doesn't say whether the session is inside a transaction block,
+//! so it's followed from the statements that open and close one. PostgreSQL
+//! aborts the whole transaction when one statement in it fails; with an open
+//! transaction, each statement runs inside `STATEMENT_SAVEPOINT` so a failure
+//! rolls back just that statement.
+
+#![forbid(unsafe_code)]
+
+/// Savepoint each statement of an open PostgreSQL transaction runs inside
+pub const STATEMENT_SAVEPOINT: &str = "lazytables_statement";
+
+/// Appended to the error of a statement rolled back to its savepoint
+pub const SAVEPOINT_RECOVERED: &str =
+    "statement failed, transaction still open (rolled back to savepoint)";
+
+/// What a statement does to the session's transaction
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum TransactionControl {
+    /// `BEGIN`, `START TRANSACTION`
+    Begin,
+    /// `COMMIT`, `END`, `ROLLBACK`, `ABORT`
+    End,
+    /// `SAVEPOINT`, `RELEASE`, `ROLLBACK TO`: work on the user's own
+    /// savepoints, which a statement savepoint would get in the way of
+    Savepoint,
+    /// Anything else
+    Statement,
+}
+
+impl TransactionControl {
+    /// Classify a statement by its leading keywords, skipping comments
+    pub fn of(statement: &str) -> Self {
+        let mut words = leading_words(statement);
+        let first = words.next().unwrap_or_default();
+        let second = words.next().unwrap_or_default();
+        match first.as_str() {
+            "BEGIN" => Self::Begin,
+            "START" if second == "TRANSACTION" => Self::Begin,
+            "COMMIT" | "END" | "ABORT" => Self::End,
+            "ROLLBACK" => match second.as_str() {
+                "TO" => Self::Savepoint,
+                // `ROLLBACK WORK TO s` / `ROLLBACK TRANSACTION TO s`
+                "WORK" | "TRANSACTION" if words.next().as_deref() == Some("TO") => Self::Savepoint,
+                _ => Self::End,
+            },
+            "SAVEPOINT" | "RELEASE" => Self::Savepoint,
+            _ => Self::Statement,
+        }
+    }
+
+    /// Whether the statement may run inside a statement savepoint
+    pub fn takes_savepoint(self) -> bool {
+        self == Self::Statement
+    }
+}
+
+/// Whether the statement only reads: `SELECT`, `SHOW`, `VALUES`, `TABLE`,
+/// `DESCRIBE` or a plain `EXPLAIN`, so running it again on a new connection
+/// is harmless. `SELECT ... INTO` writes, `EXPLAIN ANALYZE` runs what it
+/// explains and `WITH` can hide a write, so none of them count, and neither
+/// do several statements at once
+pub fn is_read_only(statement: &str) -> bool {
+    if statement.trim_end().trim_end_matches(';').contains(';') {
+        return false;
+    }
+    let words: Vec<String> = leading_words(statement).collect();
+    let reads = match words.first().map(String::as_str) {
+        Some("SELECT" | "SHOW" | "VALUES" | "TABLE" | "DESCRIBE" | "DESC") => true,
+        Some("EXPLAIN") => !words
+            .iter()
+            .any(|word| word == "ANALYZE" || word == "ANALYSE"),
+        _ => false,
+    };
+    reads && !words.iter().any(|word| word == "INTO")
+}
+
+/// Upper-cased words at the start of a statement, after whitespace and
+/// `--` / `/* */` comments
+fn leading_words(statement: &str) -> impl Iterator<Item = String> + '_ {
+    let mut rest = statement;
+    loop {
+        rest = rest.trim_start();
+        if let Some(line_comment) = rest.strip_prefix("--") {
+            rest = line_comment
+                .split_once('\n')
+                .map(|(_, after)| after)
+                .unwrap_or("");
+        } else if let Some(block_comment) = rest.strip_prefix("/*") {
+            rest = block_comment
+                .split_once("*/")
+                .map(|(_, after)| after)
+                .unwrap_or("");
+        } else {
+            break;
+        }
+    }
+    rest.split(|c: char| !c.is_ascii_alphanumeric() && c != '_')
+        .filter(|word| !word.is_empty())
+        .map(|word| word.to_ascii_uppercase())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_transaction_control_of_statements() {
+        use TransactionControl::*;
+        for (statement, expected) in [
+            ("begin", Begin),
+            ("  START TRANSACTION ISOLATION LEVEL SERIALIZABLE", Begin),
+            ("-- done\ncommit;", End),
+            ("/* undo */ ROLLBACK", End),
+            ("rollback to savepoint before_delete", Savepoint),
+            ("ROLLBACK WORK TO s1", Savepoint),
+            ("savepoint s1", Savepoint),
+            ("RELEASE s1", Savepoint),
+            ("select * from begin_dates", Statement),
+            ("start_job()", Statement),
+            ("", Statement),
+        ] {
+            assert_eq!(TransactionControl::of(statement), expected, "{statement}");
+        }
+    }
+
+    #[test]
+    fn test_is_read_only() {
+        for statement in [
+            "select * from orders;",
+            "-- recent\nSELECT id FROM orders WHERE total > 10",
+            "show search_path",
+            "EXPLAIN SELECT 1",
+            "table orders",
+        ] {
+            assert!(is_read_only(statement), "{statement}");
+        }
+        for statement in [
+            "update orders set total = 0",
+            "SELECT * INTO archive FROM orders",
+            "explain analyze delete from orders",
+            "with gone as (delete from orders returning *) select * from gone",
+            "select 1; delete from orders",
+            "",
+        ] {
+            assert!(!is_read_only(statement), "{statement}");
+        }
+    }
+}
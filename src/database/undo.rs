@@ -0,0 +1,270 @@
+// FilePath: src/database/undo.rs
+
+//! Undo log of the rows changed through the results grid
+//!
+//! Editing a cell, setting one to NULL, deleting a row and inserting one
+//! from the insert-row form each record the statement reversing the change,
+//! written from the values the grid held before it: the previous value set
+//! again, the deleted row inserted again, the inserted row deleted; the
+//! statements making the changes are written the same way. Statements
+//! typed in the query editor aren't recorded. The log lasts the session, and
+//! an entry is run again only after confirmation. It isn't a transaction: a
+//! change someone made to the row since is overwritten.
+
+#![forbid(unsafe_code)]
+
+use super::preview::quoted_table;
+use super::{quote_ident, sql_literal, DatabaseType, NULL_CELL};
+use chrono::{DateTime, Local};
+
+/// Entries kept; older ones are dropped
+pub const MAX_UNDO_ENTRIES: usize = 100;
+
+/// A column's value in a row, with the column's type to write it back as
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct CellValue {
+    pub column: String,
+    pub data_type: String,
+    /// Cell text, None for SQL NULL
+    pub value: Option<String>,
+}
+
+impl CellValue {
+    /// A value as the grid shows it. The grid shows SQL NULL as `NULL`, so
+    /// that text reads as NULL here; a value typed in is `Some` as it is
+    pub fn from_grid(column: String, data_type: String, text: String) -> Self {
+        Self {
+            column,
+            data_type,
+            value: (text != NULL_CELL).then_some(text),
+        }
+    }
+
+    /// The value as an SQL literal
+    fn literal(&self, database_type: &DatabaseType) -> String {
+        match &self.value {
+            Some(value) => sql_literal(database_type, &self.data_type, value),
+            None => NULL_CELL.to_string(),
+        }
+    }
+}
+
+/// A change made through the grid, and the statement reversing it
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct UndoEntry {
+    pub connection_id: String,
+    pub table_name: String,
+    /// What was done, e.g. `Set email in orders (id = 42)`
+    pub description: String,
+    /// Statement reversing the change
+    pub inverse: String,
+    pub recorded_at: DateTime<Local>,
+    /// Set once the inverse has been run
+    pub applied: bool,
+}
+
+/// The session's undo log, oldest entry first
+#[derive(Debug, Clone, Default)]
+pub struct UndoLog {
+    entries: Vec<UndoEntry>,
+    /// Entry selected in the log overlay
+    pub selected: usize,
+}
+
+impl UndoLog {
+    pub fn entries(&self) -> &[UndoEntry] {
+        &self.entries
+    }
+
+    pub fn get(&self, index: usize) -> Option<&UndoEntry> {
+        self.entries.get(index)
+    }
+
+    /// Add an entry, dropping the oldest past `MAX_UNDO_ENTRIES`
+    pub fn record(&mut self, entry: UndoEntry) {
+        self.entries.push(entry);
+        if self.entries.len() > MAX_UNDO_ENTRIES {
+            self.entries.remove(0);
+        }
+    }
+
+    /// Note that the entry's inverse has been run
+    pub fn mark_applied(&mut self, index: usize) {
+        if let Some(entry) = self.entries.get_mut(index) {
+            entry.applied = true;
+        }
+    }
+}
+
+/// Condition picking out the row of `key`, e.g. `"id" = 42`; None without
+/// a key
+fn key_condition(database_type: &DatabaseType, key: &[CellValue]) -> Option<String> {
+    if key.is_empty() {
+        return None;
+    }
+    let conditions: Vec<String> = key
+        .iter()
+        .map(|cell| {
+            let column = quote_ident(database_type, &[&cell.column]);
+            match cell.value {
+                Some(_) => format!("{column} = {}", cell.literal(database_type)),
+                None => format!("{column} IS NULL"),
+            }
+        })
+        .collect();
+    Some(conditions.join(" AND "))
+}
+
+/// The key as shown in a description, e.g. `id = 42`
+pub fn describe_key(key: &[CellValue]) -> String {
+    key.iter()
+        .map(|cell| {
+            let value = cell.value.as_deref().unwrap_or(NULL_CELL);
+            format!("{} = {value}", cell.column)
+        })
+        .collect::<Vec<_>>()
+        .join(", ")
+}
+
+/// `UPDATE` setting a cell of the row of `key` to `cell`: the edit itself,
+/// or with the previous value its inverse
+pub fn set_cell(
+    database_type: &DatabaseType,
+    table_name: &str,
+    key: &[CellValue],
+    cell: &CellValue,
+) -> Option<String> {
+    let condition = key_condition(database_type, key)?;
+    Some(format!(
+        "UPDATE {} SET {} = {} WHERE {condition}",
+        quoted_table(database_type, table_name),
+        quote_ident(database_type, &[&cell.column]),
+        cell.literal(database_type)
+    ))
+}
+
+/// `INSERT` of a deleted row again, every column as it was
+pub fn reinsert_row(
+    database_type: &DatabaseType,
+    table_name: &str,
+    row: &[CellValue],
+) -> Option<String> {
+    if row.is_empty() {
+        return None;
+    }
+    let columns: Vec<String> = row
+        .iter()
+        .map(|cell| quote_ident(database_type, &[&cell.column]))
+        .collect();
+    let values: Vec<String> = row.iter().map(|cell| cell.literal(database_type)).collect();
+    Some(format!(
+        "INSERT INTO {} ({}) VALUES ({})",
+        quoted_table(database_type, table_name),
+        columns.join(", "),
+        values.join(", ")
+    ))
+}
+
+/// `DELETE` of the row of `key`: a deleted one, or an inserted one undone
+pub fn delete_row(
+    database_type: &DatabaseType,
+    table_name: &str,
+    key: &[CellValue],
+) -> Option<String> {
+    let condition = key_condition(database_type, key)?;
+    Some(format!(
+        "DELETE FROM {} WHERE {condition}",
+        quoted_table(database_type, table_name)
+    ))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn cell(column: &str, data_type: &str, value: &str) -> CellValue {
+        CellValue {
+            column: column.to_string(),
+            data_type: data_type.to_string(),
+            value: Some(value.to_string()),
+        }
+    }
+
+    fn null(column: &str) -> CellValue {
+        CellValue {
+            column: column.to_string(),
+            data_type: "text".to_string(),
+            value: None,
+        }
+    }
+
+    #[test]
+    fn test_inverse_statements() {
+        let pg = DatabaseType::PostgreSQL;
+        let key = [cell("id", "integer", "42")];
+        assert_eq!(
+            set_cell(&pg, "sales.orders", &key, &cell("note", "text", "O'Brien")).unwrap(),
+            r#"UPDATE "sales"."orders" SET "note" = 'O''Brien' WHERE "id" = 42"#
+        );
+        assert_eq!(
+            set_cell(&pg, "orders", &key, &null("note")).unwrap(),
+            r#"UPDATE "orders" SET "note" = NULL WHERE "id" = 42"#
+        );
+        // The text NULL typed into a cell is text
+        assert_eq!(
+            set_cell(&pg, "orders", &key, &cell("note", "text", "NULL")).unwrap(),
+            r#"UPDATE "orders" SET "note" = 'NULL' WHERE "id" = 42"#
+        );
+        assert_eq!(
+            delete_row(&pg, "orders", &[null("region")]).unwrap(),
+            r#"DELETE FROM "orders" WHERE "region" IS NULL"#
+        );
+        let grid = |text: &str| CellValue::from_grid("note".into(), "text".into(), text.into());
+        assert_eq!(grid("NULL").value, None);
+        assert_eq!(grid("null").value.as_deref(), Some("null"));
+        assert_eq!(
+            reinsert_row(
+                &pg,
+                "orders",
+                &[cell("id", "integer", "42"), cell("paid", "boolean", "true")]
+            )
+            .unwrap(),
+            r#"INSERT INTO "orders" ("id", "paid") VALUES (42, true)"#
+        );
+        assert_eq!(
+            delete_row(
+                &DatabaseType::MySQL,
+                "orders",
+                &[cell("id", "int", "7"), cell("region", "varchar(8)", "eu")]
+            )
+            .unwrap(),
+            "DELETE FROM `orders` WHERE `id` = 7 AND `region` = 'eu'"
+        );
+        assert_eq!(
+            delete_row(&pg, "order items", &[cell("code", "text", "x' OR '1'='1")]).unwrap(),
+            r#"DELETE FROM "order items" WHERE "code" = 'x'' OR ''1''=''1'"#
+        );
+        assert_eq!(delete_row(&pg, "orders", &[]), None);
+        assert_eq!(describe_key(&key), "id = 42");
+    }
+
+    #[test]
+    fn test_log_keeps_latest_entries() {
+        let mut log = UndoLog::default();
+        for n in 0..MAX_UNDO_ENTRIES + 2 {
+            log.record(UndoEntry {
+                connection_id: "local".to_string(),
+                table_name: "orders".to_string(),
+                description: format!("change {n}"),
+                inverse: String::new(),
+                recorded_at: Local::now(),
+                applied: false,
+            });
+        }
+        assert_eq!(log.entries().len(), MAX_UNDO_ENTRIES);
+        assert_eq!(log.get(0).unwrap().description, "change 2");
+
+        log.mark_applied(0);
+        assert!(log.get(0).unwrap().applied);
+    }
+}
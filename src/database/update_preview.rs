@@ -0,0 +1,263 @@
+// FilePath: src/database/update_preview.rs
+
+//! Previewing the rows an UPDATE changes
+//!
+//! A single-table `UPDATE t SET a = x WHERE cond` is rewritten into a
+//! `SELECT` of the rows `cond` matches, showing each column being set next
+//! to its new value, plus a `COUNT(*)` of them. Anything more involved
+//! (joins, `FROM`, `ORDER BY`/`LIMIT`, CTEs, tuple assignments) isn't
+//! rewritten and gets a plain confirmation instead.
+
+#![forbid(unsafe_code)]
+
+use super::{quote_ident, DatabaseType};
+
+/// Queries previewing an UPDATE
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct UpdatePreview {
+    /// The matching rows: the columns set, their new values, then the rest
+    pub select: String,
+    /// The number of matching rows
+    pub count: String,
+}
+
+/// A top-level piece of a statement: a word or name (upper-cased) or
+/// punctuation, with its byte offsets. Strings, comments and anything in
+/// parentheses are skipped
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub(super) struct Token {
+    pub(super) text: String,
+    pub(super) start: usize,
+    pub(super) end: usize,
+}
+
+pub(super) fn top_level_tokens(sql: &str) -> Option<Vec<Token>> {
+    let bytes = sql.as_bytes();
+    let mut tokens = Vec::new();
+    let mut depth = 0usize;
+    let mut i = 0;
+    while i < bytes.len() {
+        let c = bytes[i];
+        match c {
+            b'\'' => {
+                i = quoted_end(bytes, i)?;
+                continue;
+            }
+            b'-' if bytes.get(i + 1) == Some(&b'-') => {
+                i = sql[i..].find('\n').map_or(bytes.len(), |n| i + n);
+                continue;
+            }
+            b'/' if bytes.get(i + 1) == Some(&b'*') => {
+                i = sql[i + 2..].find("*/").map(|n| i + n + 4)?;
+                continue;
+            }
+            b'(' => depth += 1,
+            b')' => depth = depth.checked_sub(1)?,
+            b'"' | b'`' if depth > 0 => {
+                i = quoted_end(bytes, i)?;
+                continue;
+            }
+            _ if depth > 0 => {}
+            b',' | b'=' | b';' => tokens.push(Token {
+                text: (c as char).to_string(),
+                start: i,
+                end: i + 1,
+            }),
+            _ if c.is_ascii_alphanumeric() || c == b'_' || c == b'"' || c == b'`' => {
+                // A word or a name, its parts quoted or not: `"sales".orders`
+                let start = i;
+                loop {
+                    if matches!(bytes.get(i), Some(b'"' | b'`')) {
+                        i = quoted_end(bytes, i)?;
+                    } else {
+                        while bytes
+                            .get(i)
+                            .is_some_and(|b| b.is_ascii_alphanumeric() || *b == b'_')
+                        {
+                            i += 1;
+                        }
+                    }
+                    if bytes.get(i) != Some(&b'.') {
+                        break;
+                    }
+                    i += 1;
+                }
+                tokens.push(Token {
+                    text: sql[start..i].to_uppercase(),
+                    start,
+                    end: i,
+                });
+                continue;
+            }
+            _ => {}
+        }
+        i += 1;
+    }
+    (depth == 0).then_some(tokens)
+}
+
+/// Offset just past the quoted text starting at `start`. Doubled quotes
+/// stay inside it, as do backslash escapes in strings
+fn quoted_end(bytes: &[u8], start: usize) -> Option<usize> {
+    let quote = bytes[start];
+    let mut i = start + 1;
+    loop {
+        match bytes.get(i) {
+            None => return None,
+            Some(&q) if q == quote && bytes.get(i + 1) == Some(&quote) => i += 2,
+            Some(&q) if q == quote => return Some(i + 1),
+            Some(b'\\') if quote == b'\'' => i += 2,
+            Some(_) => i += 1,
+        }
+    }
+}
+
+/// Whether the statement is an UPDATE
+pub fn is_update(statement: &str) -> bool {
+    top_level_tokens(statement)
+        .and_then(|tokens| tokens.into_iter().next())
+        .is_some_and(|token| token.text == "UPDATE")
+}
+
+/// Queries previewing a single-table UPDATE; None for any other statement
+pub fn update_preview(database_type: &DatabaseType, statement: &str) -> Option<UpdatePreview> {
+    let statement = statement.trim().trim_end_matches(';').trim_end();
+    let tokens = top_level_tokens(statement)?;
+    let word = |text: &str| tokens.iter().position(|token| token.text == text);
+    if tokens.first()?.text != "UPDATE" || tokens.iter().any(|token| token.text == ";") {
+        return None;
+    }
+    if ["FROM", "JOIN", "USING", "ORDER", "LIMIT"]
+        .iter()
+        .any(|text| word(text).is_some())
+    {
+        return None;
+    }
+
+    // The target: `t`, `t alias` or `t AS alias`, optionally `ONLY t`
+    let set = word("SET")?;
+    let target = &tokens[1..set];
+    let target = match target {
+        [only, rest @ ..] if only.text == "ONLY" => rest,
+        _ => target,
+    };
+    if target.is_empty()
+        || target.len() > 3
+        || target.iter().any(|token| token.text == ",")
+        || (target.len() == 3 && target[1].text != "AS")
+    {
+        return None;
+    }
+    let table_from = &statement[target[0].start..target[target.len() - 1].end];
+    let table_ref = &statement[target[target.len() - 1].start..target[target.len() - 1].end];
+
+    let where_at = word("WHERE");
+    let returning_at = word("RETURNING");
+    let assignments_end = where_at
+        .or(returning_at)
+        .map_or(statement.len(), |at| tokens[at].start);
+    let condition = where_at.map(|at| {
+        let end = returning_at.map_or(statement.len(), |at| tokens[at].start);
+        statement[tokens[at].end..end].trim()
+    });
+
+    // `a = x, b = y`: split on the commas between the assignments
+    let assignment_tokens: Vec<&Token> = tokens
+        .iter()
+        .filter(|token| token.start > tokens[set].start && token.end <= assignments_end)
+        .collect();
+    let mut assignments = Vec::new();
+    let mut from = tokens[set].end;
+    let commas = assignment_tokens.iter().filter(|token| token.text == ",");
+    for end in commas.map(|token| token.start).chain([assignments_end]) {
+        let (column, value) = statement[from..end].split_once('=')?;
+        let (column, value) = (column.trim(), value.trim());
+        if column.is_empty() || column.starts_with('(') || value.is_empty() {
+            return None;
+        }
+        assignments.push((column, value));
+        from = end + 1;
+    }
+
+    let mut select_list = Vec::new();
+    for (column, value) in &assignments {
+        select_list.push(column.to_string());
+        if !value.eq_ignore_ascii_case("DEFAULT") {
+            let name = column.rsplit('.').next().unwrap_or(column);
+            let name = name.trim_matches(|c| c == '"' || c == '`');
+            let label = quote_ident(database_type, &[&format!("{name} (new)")]);
+            select_list.push(format!("{value} AS {label}"));
+        }
+    }
+    select_list.push(format!("{table_ref}.*"));
+
+    let filter = condition.map_or(String::new(), |condition| format!(" WHERE {condition}"));
+    Some(UpdatePreview {
+        select: format!(
+            "SELECT {} FROM {table_from}{filter}",
+            select_list.join(", ")
+        ),
+        count: format!("SELECT COUNT(*) FROM {table_from}{filter}"),
+    })
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_single_table_update_is_previewed() {
+        let preview = update_preview(
+            &DatabaseType::PostgreSQL,
+            "UPDATE orders o SET status = 'closed', note = DEFAULT, \
+             total = (SELECT sum(x) FROM items i WHERE i.order_id = o.id)\n\
+             WHERE o.created_at < now() - interval '1 year' AND note <> 'keep, = open';",
+        )
+        .unwrap();
+        assert_eq!(
+            preview.select,
+            "SELECT status, 'closed' AS \"status (new)\", note, \
+             total, (SELECT sum(x) FROM items i WHERE i.order_id = o.id) AS \"total (new)\", o.* \
+             FROM orders o WHERE o.created_at < now() - interval '1 year' \
+             AND note <> 'keep, = open'"
+        );
+        assert_eq!(
+            preview.count,
+            "SELECT COUNT(*) FROM orders o WHERE o.created_at < now() - interval '1 year' \
+             AND note <> 'keep, = open'"
+        );
+    }
+
+    #[test]
+    fn test_update_without_where_covers_the_table() {
+        let preview = update_preview(
+            &DatabaseType::MySQL,
+            "-- reset\nUPDATE `shop`.`users` SET `active` = 0",
+        )
+        .unwrap();
+        assert_eq!(
+            preview.select,
+            "SELECT `active`, 0 AS `active (new)`, `shop`.`users`.* FROM `shop`.`users`"
+        );
+        assert_eq!(preview.count, "SELECT COUNT(*) FROM `shop`.`users`");
+    }
+
+    #[test]
+    fn test_complex_updates_are_not_rewritten() {
+        let db = DatabaseType::PostgreSQL;
+        for statement in [
+            "UPDATE a SET x = b.x FROM b WHERE a.id = b.id",
+            "UPDATE a JOIN b ON a.id = b.id SET a.x = b.x",
+            "UPDATE a, b SET a.x = b.x WHERE a.id = b.id",
+            "UPDATE a SET x = 1 ORDER BY id LIMIT 10",
+            "UPDATE a SET (x, y) = (1, 2)",
+            "WITH t AS (SELECT 1) UPDATE a SET x = 1",
+            "UPDATE a SET x = 1; DELETE FROM a",
+            "SELECT 1",
+        ] {
+            assert_eq!(update_preview(&db, statement), None, "{statement}");
+        }
+        assert!(is_update("  update a set x = 1"));
+        assert!(!is_update("WITH t AS (SELECT 1) UPDATE a SET x = 1"));
+    }
+}
@@ -5,11 +5,21 @@
 use crate::core::error::{Error, Result};
 use crossterm::event::{self, Event as CrosstermEvent, KeyEvent, MouseEvent};
 use std::{
-    sync::mpsc::{self, Receiver, RecvTimeoutError},
+    sync::{
+        atomic::{AtomicBool, Ordering},
+        mpsc::{self, Receiver, RecvTimeoutError},
+        Arc, Mutex, MutexGuard, PoisonError,
+    },
     thread,
     time::Duration,
 };
 
+/// How long `next` waits for input while ticks are stopped
+const IDLE_TIMEOUT: Duration = Duration::from_secs(1);
+
+/// How often a paused event thread checks whether it may read input again
+const PAUSED_WAIT: Duration = Duration::from_millis(20);
+
 /// Application events
 #[derive(Debug, Clone)]
 pub enum Event {
@@ -26,6 +36,13 @@ pub enum Event {
 /// Event handler that manages input events
 pub struct EventHandler {
     receiver: Receiver<Event>,
+    /// Whether ticks are sent; without them the app only wakes up for input
+    ticking: Arc<AtomicBool>,
+    /// Whether input is left unread, for a program run in the terminal
+    paused: Arc<AtomicBool>,
+    /// Held by the event thread while it polls, so pausing can wait for a
+    /// poll in progress to end
+    polling: Arc<Mutex<()>>,
     _handler: thread::JoinHandle<()>,
 }
 
@@ -33,17 +50,31 @@ impl EventHandler {
     /// Create a new event handler with specified tick rate
     pub fn new(tick_rate: Duration) -> Self {
         let (sender, receiver) = mpsc::channel();
+        let ticking = Arc::new(AtomicBool::new(true));
+        let thread_ticking = Arc::clone(&ticking);
+        let paused = Arc::new(AtomicBool::new(false));
+        let thread_paused = Arc::clone(&paused);
+        let polling = Arc::new(Mutex::new(()));
+        let thread_polling = Arc::clone(&polling);
 
         let handler = thread::spawn(move || {
             let mut last_tick = std::time::Instant::now();
 
             loop {
+                let _polling = lock(&thread_polling);
+                if thread_paused.load(Ordering::Acquire) {
+                    drop(_polling);
+                    thread::sleep(PAUSED_WAIT);
+                    last_tick = std::time::Instant::now();
+                    continue;
+                }
+
                 // Calculate remaining time until next tick
                 let timeout = tick_rate.saturating_sub(last_tick.elapsed());
 
                 // If tick is due, send it immediately without polling for events
                 if timeout.is_zero() {
-                    if sender.send(Event::Tick).is_err() {
+                    if thread_ticking.load(Ordering::Relaxed) && sender.send(Event::Tick).is_err() {
                         break;
                     }
                     last_tick = std::time::Instant::now();
@@ -74,7 +105,7 @@ impl EventHandler {
 
                 // Check again if tick is due after processing events
                 if last_tick.elapsed() >= tick_rate {
-                    if sender.send(Event::Tick).is_err() {
+                    if thread_ticking.load(Ordering::Relaxed) && sender.send(Event::Tick).is_err() {
                         break;
                     }
                     last_tick = std::time::Instant::now();
@@ -84,10 +115,31 @@ impl EventHandler {
 
         Self {
             receiver,
+            ticking,
+            paused,
+            polling,
             _handler: handler,
         }
     }
 
+    /// Send ticks or stop sending them. With ticks stopped only input
+    /// reaches the app, so an idle app isn't redrawn
+    pub fn set_ticking(&self, ticking: bool) {
+        self.ticking.store(ticking, Ordering::Relaxed);
+    }
+
+    /// Stop reading input, waiting for a poll in progress to end, so a
+    /// program run in the terminal gets every key
+    pub fn pause(&self) {
+        self.paused.store(true, Ordering::Release);
+        drop(lock(&self.polling));
+    }
+
+    /// Read input again after [`pause`](Self::pause)
+    pub fn resume(&self) {
+        self.paused.store(false, Ordering::Release);
+    }
+
     /// Start the event handler
     pub fn start(&self) -> Result<()> {
         Ok(())
@@ -96,8 +148,14 @@ impl EventHandler {
     /// Get the next event, blocking with timeout to allow CPU to idle
     pub fn next(&self) -> Result<Option<Event>> {
         // Use recv_timeout to block and allow CPU to enter idle states
-        // Timeout matches the tick rate to ensure timely UI updates
-        match self.receiver.recv_timeout(Duration::from_millis(250)) {
+        // Timeout matches the tick rate to ensure timely UI updates; without
+        // ticks it only lets termination signals in now and then
+        let timeout = if self.ticking.load(Ordering::Relaxed) {
+            Duration::from_millis(250)
+        } else {
+            IDLE_TIMEOUT
+        };
+        match self.receiver.recv_timeout(timeout) {
             Ok(event) => Ok(Some(event)),
             Err(RecvTimeoutError::Timeout) => Ok(None),
             Err(RecvTimeoutError::Disconnected) => {
@@ -106,3 +164,8 @@ impl EventHandler {
         }
     }
 }
+
+/// Lock the polling mutex. It guards no data, so a poisoned one is as good
+fn lock(polling: &Mutex<()>) -> MutexGuard<'_, ()> {
+    polling.lock().unwrap_or_else(PoisonError::into_inner)
+}
@@ -0,0 +1,191 @@
+// FilePath: src/io/clipboard.rs
+
+//! Clipboard access with an OSC52 fallback
+//!
+//! The native clipboard needs a local clipboard daemon, which a remote box
+//! reached over SSH doesn't have. OSC52 instead asks the user's terminal
+//! emulator to set its clipboard, which works across SSH and inside tmux or
+//! screen as long as the terminal supports it.
+
+#![forbid(unsafe_code)]
+
+use base64::{engine::general_purpose::STANDARD as BASE64, Engine};
+use serde::{Deserialize, Serialize};
+use std::io::Write;
+
+/// Largest base64 payload sent in one OSC52 sequence. Terminals drop longer
+/// sequences silently (xterm and hterm stop around 100 KB), so refuse instead
+pub const OSC52_MAX_ENCODED_BYTES: usize = 100_000;
+
+/// Exports larger than this go to the clipboard only after a second Enter:
+/// chat and ticket fields choke on them, and over OSC52 they wouldn't fit
+pub const LARGE_COPY_BYTES: usize = 64 * 1024;
+
+/// GNU screen truncates DCS strings, so the payload is split into chunks
+const SCREEN_CHUNK_BYTES: usize = 76;
+
+/// How clipboard copies are delivered
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq, Serialize, Deserialize)]
+#[serde(rename_all = "lowercase")]
+pub enum ClipboardMode {
+    /// Native clipboard locally; OSC52 over SSH or when the native one fails
+    #[default]
+    Auto,
+    /// Native clipboard only
+    Native,
+    /// Always OSC52
+    Osc52,
+}
+
+/// Which mechanism a copy went through
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum ClipboardBackend {
+    Native,
+    Osc52,
+}
+
+/// Terminal multiplexer the OSC52 sequence has to pass through
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum Multiplexer {
+    None,
+    Tmux,
+    Screen,
+}
+
+impl Multiplexer {
+    /// Detect the multiplexer from the environment
+    pub fn detect() -> Self {
+        if std::env::var_os("TMUX").is_some() {
+            Self::Tmux
+        } else if std::env::var("TERM").is_ok_and(|term| term.starts_with("screen")) {
+            Self::Screen
+        } else {
+            Self::None
+        }
+    }
+}
+
+/// Clipboard used by the copy commands
+#[derive(Debug, Clone, Copy, Default)]
+pub struct Clipboard {
+    mode: ClipboardMode,
+}
+
+impl Clipboard {
+    pub fn new(mode: ClipboardMode) -> Self {
+        Self { mode }
+    }
+
+    /// Copy text, returning the mechanism used
+    pub fn copy(&self, text: &str) -> Result<ClipboardBackend, String> {
+        match self.mode {
+            ClipboardMode::Native => copy_native(text).map(|_| ClipboardBackend::Native),
+            ClipboardMode::Osc52 => copy_osc52(text).map(|_| ClipboardBackend::Osc52),
+            ClipboardMode::Auto if is_ssh_session() => {
+                copy_osc52(text).map(|_| ClipboardBackend::Osc52)
+            }
+            ClipboardMode::Auto => match copy_native(text) {
+                Ok(()) => Ok(ClipboardBackend::Native),
+                Err(native_error) => {
+                    crate::log_debug!(
+                        "Native clipboard unavailable ({}), falling back to OSC52",
+                        native_error
+                    );
+                    copy_osc52(text).map(|_| ClipboardBackend::Osc52)
+                }
+            },
+        }
+    }
+}
+
+/// Whether we're running in an SSH session, where the native clipboard would
+/// be the remote machine's
+fn is_ssh_session() -> bool {
+    std::env::var_os("SSH_TTY").is_some() || std::env::var_os("SSH_CONNECTION").is_some()
+}
+
+fn copy_native(text: &str) -> Result<(), String> {
+    let mut clipboard =
+        arboard::Clipboard::new().map_err(|e| format!("Failed to access clipboard: {e}"))?;
+    clipboard
+        .set_text(text)
+        .map_err(|e| format!("Failed to copy to clipboard: {e}"))
+}
+
+fn copy_osc52(text: &str) -> Result<(), String> {
+    let sequence = osc52_sequence(text, Multiplexer::detect())?;
+    let mut stdout = std::io::stdout();
+    stdout
+        .write_all(sequence.as_bytes())
+        .and_then(|_| stdout.flush())
+        .map_err(|e| format!("Failed to write to terminal: {e}"))
+}
+
+/// Build the OSC52 escape sequence setting the clipboard to `text`, wrapped
+/// for the multiplexer so it reaches the outer terminal
+pub fn osc52_sequence(text: &str, multiplexer: Multiplexer) -> Result<String, String> {
+    let encoded = BASE64.encode(text);
+    if encoded.len() > OSC52_MAX_ENCODED_BYTES {
+        return Err(format!(
+            "Too large to copy through the terminal ({} KB, limit {} KB)",
+            text.len() / 1024,
+            OSC52_MAX_ENCODED_BYTES / 4 * 3 / 1024
+        ));
+    }
+
+    Ok(match multiplexer {
+        Multiplexer::None => format!("\x1b]52;c;{encoded}\x07"),
+        // tmux passes DCS content through when every ESC is doubled
+        Multiplexer::Tmux => format!("\x1bPtmux;\x1b\x1b]52;c;{encoded}\x07\x1b\\"),
+        Multiplexer::Screen => {
+            let chunks: Vec<&str> = encoded
+                .as_bytes()
+                .chunks(SCREEN_CHUNK_BYTES)
+                .map(|chunk| std::str::from_utf8(chunk).unwrap_or_default())
+                .collect();
+            format!("\x1bP\x1b]52;c;{}\x07\x1b\\", chunks.join("\x1b\\\x1bP"))
+        }
+    })
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_plain_sequence() {
+        assert_eq!(
+            osc52_sequence("hello", Multiplexer::None).unwrap(),
+            "\x1b]52;c;aGVsbG8=\x07"
+        );
+    }
+
+    #[test]
+    fn test_tmux_passthrough() {
+        assert_eq!(
+            osc52_sequence("hello", Multiplexer::Tmux).unwrap(),
+            "\x1bPtmux;\x1b\x1b]52;c;aGVsbG8=\x07\x1b\\"
+        );
+    }
+
+    #[test]
+    fn test_screen_chunks_payload() {
+        let text = "x".repeat(200);
+        let sequence = osc52_sequence(&text, Multiplexer::Screen).unwrap();
+
+        let payload = sequence
+            .strip_prefix("\x1bP\x1b]52;c;")
+            .and_then(|rest| rest.strip_suffix("\x07\x1b\\"))
+            .unwrap();
+        let chunks: Vec<&str> = payload.split("\x1b\\\x1bP").collect();
+        assert_eq!(chunks.len(), 4);
+        assert!(chunks.iter().all(|chunk| chunk.len() <= SCREEN_CHUNK_BYTES));
+        assert_eq!(chunks.concat(), BASE64.encode(&text));
+    }
+
+    #[test]
+    fn test_rejects_oversized_text() {
+        let text = "x".repeat(OSC52_MAX_ENCODED_BYTES);
+        assert!(osc52_sequence(&text, Multiplexer::None).is_err());
+    }
+}
@@ -0,0 +1,65 @@
+// FilePath: src/io/export/csv.rs
+
+//! Comma separated values with a header row
+
+#![forbid(unsafe_code)]
+
+use super::{ExportFormat, FormatInfo, Renderer};
+use std::io::{self, Write};
+
+pub(super) const FORMAT: FormatInfo = FormatInfo {
+    format: ExportFormat::Csv,
+    label: "CSV",
+    extension: "csv",
+    binary: false,
+    renderer: || Box::new(CsvRenderer),
+};
+
+/// Escape a single CSV field, quoting it when it contains a delimiter,
+/// quote or line break
+pub fn csv_escape(value: &str) -> String {
+    if value.contains(',') || value.contains('"') || value.contains('\n') || value.contains('\r') {
+        format!("\"{}\"", value.replace('"', "\"\""))
+    } else {
+        value.to_string()
+    }
+}
+
+/// Format one row as a CSV line (without the trailing newline)
+pub fn csv_line(values: &[String]) -> String {
+    values
+        .iter()
+        .map(|value| csv_escape(value))
+        .collect::<Vec<_>>()
+        .join(",")
+}
+
+#[derive(Debug)]
+struct CsvRenderer;
+
+impl Renderer for CsvRenderer {
+    fn begin(&mut self, out: &mut dyn Write, columns: &[String]) -> io::Result<()> {
+        writeln!(out, "{}", csv_line(columns))
+    }
+
+    fn row(&mut self, out: &mut dyn Write, row: &[String]) -> io::Result<()> {
+        writeln!(out, "{}", csv_line(row))
+    }
+
+    fn finish(&mut self, _out: &mut dyn Write) -> io::Result<()> {
+        Ok(())
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_csv_escapes_delimiters_and_quotes() {
+        assert_eq!(
+            String::from_utf8(super::super::render_sample(ExportFormat::Csv)).unwrap(),
+            "id,name\n1,Ada\n2,\"Smith, \"\"J\"\"\"\n"
+        );
+    }
+}
@@ -0,0 +1,169 @@
+// FilePath: src/io/export/json.rs
+
+//! JSON array of objects keyed by column name, and the typed objects the
+//! grid copies a row as
+
+#![forbid(unsafe_code)]
+
+use super::{ExportFormat, FormatInfo, Renderer, F64_DIGITS};
+use crate::database::literal::{is_boolean_type, is_numeric_type};
+use crate::database::NULL_CELL;
+use std::io::{self, Write};
+
+pub(super) const FORMAT: FormatInfo = FormatInfo {
+    format: ExportFormat::Json,
+    label: "JSON",
+    extension: "json",
+    binary: false,
+    renderer: || Box::<JsonRenderer>::default(),
+};
+
+/// One row as a compact JSON object of string values. Built by hand to keep
+/// the result's column order
+pub(super) fn json_object(columns: &[String], row: &[String]) -> String {
+    let fields = columns
+        .iter()
+        .zip(row)
+        .map(|(column, value)| {
+            format!(
+                "{}:{}",
+                serde_json::Value::from(column.as_str()),
+                serde_json::Value::from(value.as_str())
+            )
+        })
+        .collect::<Vec<_>>()
+        .join(",");
+    format!("{{{fields}}}")
+}
+
+/// Format one row as a pretty-printed JSON object of `(column, data type,
+/// value)` fields, in column order. Values are typed by their column: NULL,
+/// numbers, booleans and JSON documents are written as such, the rest as
+/// strings
+pub fn typed_json_object(fields: &[(&str, &str, &str)]) -> String {
+    if fields.is_empty() {
+        return "{}".to_string();
+    }
+    let fields = fields
+        .iter()
+        .map(|(column, data_type, value)| {
+            format!(
+                "  {}: {}",
+                serde_json::Value::from(*column),
+                typed_json_value(data_type, value)
+            )
+        })
+        .collect::<Vec<_>>()
+        .join(",\n");
+    format!("{{\n{fields}\n}}")
+}
+
+/// A cell `value` as JSON of its column's type, falling back to a string
+/// when the text doesn't read as that type
+fn typed_json_value(data_type: &str, value: &str) -> serde_json::Value {
+    use serde_json::Value;
+
+    if value == NULL_CELL {
+        return Value::Null;
+    }
+    let lower = data_type.trim().to_lowercase();
+    if is_numeric_type(&lower) {
+        if let Ok(int) = value.parse::<i64>() {
+            return Value::from(int);
+        }
+        if let Ok(int) = value.parse::<u64>() {
+            return Value::from(int);
+        }
+        let digits = value.chars().filter(char::is_ascii_digit).count();
+        if digits <= F64_DIGITS {
+            if let Some(number) = value
+                .parse::<f64>()
+                .ok()
+                .and_then(serde_json::Number::from_f64)
+            {
+                return Value::Number(number);
+            }
+        }
+    } else if is_boolean_type(&lower) {
+        match value.to_lowercase().as_str() {
+            "true" | "t" => return Value::Bool(true),
+            "false" | "f" => return Value::Bool(false),
+            _ => {}
+        }
+    } else if lower == "json" || lower == "jsonb" {
+        if let Ok(document) = serde_json::from_str(value) {
+            return document;
+        }
+    }
+    Value::from(value)
+}
+
+#[derive(Debug, Default)]
+struct JsonRenderer {
+    columns: Vec<String>,
+    rows: usize,
+}
+
+impl Renderer for JsonRenderer {
+    fn begin(&mut self, out: &mut dyn Write, columns: &[String]) -> io::Result<()> {
+        self.columns = columns.to_vec();
+        write!(out, "[")
+    }
+
+    fn row(&mut self, out: &mut dyn Write, row: &[String]) -> io::Result<()> {
+        let separator = if self.rows == 0 { "\n  " } else { ",\n  " };
+        self.rows += 1;
+        write!(out, "{}{}", separator, json_object(&self.columns, row))
+    }
+
+    fn finish(&mut self, out: &mut dyn Write) -> io::Result<()> {
+        if self.rows == 0 {
+            writeln!(out, "]")
+        } else {
+            writeln!(out, "\n]")
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_json_is_an_array_of_objects() {
+        let output = super::super::render_sample(ExportFormat::Json);
+        let parsed: serde_json::Value = serde_json::from_slice(&output).unwrap();
+        assert_eq!(parsed[0]["name"], "Ada");
+        assert_eq!(parsed[1]["id"], "2");
+        assert_eq!(parsed.as_array().unwrap().len(), 2);
+    }
+
+    #[test]
+    fn test_json_empty_result() {
+        let mut writer = ExportFormat::Json.writer(Vec::new());
+        writer.begin(&["id".to_string()]).unwrap();
+        let output = String::from_utf8(writer.finish().unwrap()).unwrap();
+        assert_eq!(output, "[]\n");
+    }
+
+    #[test]
+    fn test_typed_json_object() {
+        let object = typed_json_object(&[
+            ("id", "bigint", "42"),
+            ("total", "numeric(10,2)", "12.50"),
+            ("balance", "numeric", "12345678901234567890.5"),
+            ("active", "boolean", "true"),
+            ("meta", "jsonb", "{\"tags\": [\"a\"]}"),
+            ("note", "text", "NULL"),
+            ("zip", "text", "01234"),
+        ]);
+        assert!(object.starts_with("{\n  \"id\": 42,\n  \"total\": 12.5,"));
+        let parsed: serde_json::Value = serde_json::from_str(&object).unwrap();
+        assert_eq!(parsed["balance"], "12345678901234567890.5");
+        assert_eq!(parsed["active"], true);
+        assert_eq!(parsed["meta"]["tags"][0], "a");
+        assert!(parsed["note"].is_null());
+        assert_eq!(parsed["zip"], "01234");
+        assert_eq!(typed_json_object(&[]), "{}");
+    }
+}
@@ -0,0 +1,66 @@
+// FilePath: src/io/export/markdown.rs
+
+//! Markdown pipe table, for pasting into chat messages and tickets
+
+#![forbid(unsafe_code)]
+
+use super::{ExportFormat, FormatInfo, Renderer};
+use std::io::{self, Write};
+
+pub(super) const FORMAT: FormatInfo = FormatInfo {
+    format: ExportFormat::Markdown,
+    label: "Markdown",
+    extension: "md",
+    binary: false,
+    renderer: || Box::new(MarkdownRenderer),
+};
+
+/// Format one row as a Markdown table row. Pipes are escaped and line breaks
+/// become `<br>`, so a value stays in its cell
+pub fn markdown_line(values: &[String]) -> String {
+    let cells: Vec<String> = values
+        .iter()
+        .map(|value| {
+            value
+                .replace('|', "\\|")
+                .replace("\r\n", "<br>")
+                .replace(['\r', '\n'], "<br>")
+        })
+        .collect();
+    format!("| {} |", cells.join(" | "))
+}
+
+#[derive(Debug)]
+struct MarkdownRenderer;
+
+impl Renderer for MarkdownRenderer {
+    fn begin(&mut self, out: &mut dyn Write, columns: &[String]) -> io::Result<()> {
+        writeln!(out, "{}", markdown_line(columns))?;
+        writeln!(out, "|{}", " --- |".repeat(columns.len()))
+    }
+
+    fn row(&mut self, out: &mut dyn Write, row: &[String]) -> io::Result<()> {
+        writeln!(out, "{}", markdown_line(row))
+    }
+
+    fn finish(&mut self, _out: &mut dyn Write) -> io::Result<()> {
+        Ok(())
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_markdown_escapes_pipes_and_line_breaks() {
+        assert_eq!(
+            String::from_utf8(super::super::render_sample(ExportFormat::Markdown)).unwrap(),
+            "| id | name |\n| --- | --- |\n| 1 | Ada |\n| 2 | Smith, \"J\" |\n"
+        );
+        assert_eq!(
+            markdown_line(&["a|b".to_string(), "one\ntwo".to_string()]),
+            "| a\\|b | one<br>two |"
+        );
+    }
+}
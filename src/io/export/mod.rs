@@ -0,0 +1,201 @@
+// FilePath: src/io/export/mod.rs
+
+//! Result set serializers
+//!
+//! Shared by the headless `query` and `export` subcommands and the table
+//! viewer, so every output path formats values the same way. Each format is
+//! a `Renderer` in a file of its own, registered in `FORMATS` with its label
+//! and extension; adding one takes that file, its entry and a variant of
+//! `ExportFormat`. Renderers take rows one at a time; the aligned table and
+//! XLSX hold them back until the end.
+
+#![forbid(unsafe_code)]
+
+mod csv;
+mod json;
+mod markdown;
+mod ndjson;
+mod table;
+mod tsv;
+mod xlsx;
+
+pub use csv::{csv_escape, csv_line};
+pub use json::typed_json_object;
+pub use markdown::markdown_line;
+pub use tsv::tsv_line;
+
+use std::fmt;
+use std::io::{self, Write};
+
+/// Digits a number keeps through an `f64`; longer decimals stay strings so
+/// no digit is lost
+const F64_DIGITS: usize = 15;
+
+/// Output format for query results
+#[derive(Debug, Clone, Copy, PartialEq, Eq, clap::ValueEnum)]
+pub enum ExportFormat {
+    /// Comma separated values with a header row
+    Csv,
+    /// Tab separated values with a header row
+    Tsv,
+    /// JSON array of objects keyed by column name
+    Json,
+    /// One JSON object per line
+    Ndjson,
+    /// Aligned plain-text table
+    Table,
+    /// Markdown pipe table, for pasting into chat messages and tickets
+    Markdown,
+    /// Excel workbook with the rows on one sheet
+    Xlsx,
+}
+
+/// A format as the export form and the subcommands know it
+#[derive(Debug, Clone, Copy)]
+pub struct FormatInfo {
+    pub format: ExportFormat,
+    /// Name the export form shows
+    pub label: &'static str,
+    /// Conventional file extension
+    pub extension: &'static str,
+    /// Whether the output isn't text, so it can only go to a file
+    pub binary: bool,
+    /// A renderer for one result set
+    pub renderer: fn() -> Box<dyn Renderer>,
+}
+
+/// Every format, in the order the export form cycles through them
+pub static FORMATS: [FormatInfo; 7] = [
+    csv::FORMAT,
+    tsv::FORMAT,
+    json::FORMAT,
+    ndjson::FORMAT,
+    markdown::FORMAT,
+    table::FORMAT,
+    xlsx::FORMAT,
+];
+
+/// Writes one result set in a format: the header, then each row, then any
+/// trailer
+pub trait Renderer: fmt::Debug + Send {
+    /// Write the header for the given columns
+    fn begin(&mut self, out: &mut dyn Write, columns: &[String]) -> io::Result<()>;
+
+    /// Write a single row
+    fn row(&mut self, out: &mut dyn Write, row: &[String]) -> io::Result<()>;
+
+    /// Write the trailer and any rows held back
+    fn finish(&mut self, out: &mut dyn Write) -> io::Result<()>;
+}
+
+impl ExportFormat {
+    /// The format's entry in `FORMATS`
+    pub fn info(&self) -> &'static FormatInfo {
+        FORMATS
+            .iter()
+            .find(|info| info.format == *self)
+            .expect("export format not registered")
+    }
+
+    /// Conventional file extension for the format
+    pub fn extension(&self) -> &'static str {
+        self.info().extension
+    }
+
+    /// Whether the output can only go to a file
+    pub fn is_binary(&self) -> bool {
+        self.info().binary
+    }
+
+    /// Create a writer for this format on top of the given output
+    pub fn writer<W: Write>(&self, out: W) -> ResultWriter<W> {
+        ResultWriter {
+            renderer: (self.info().renderer)(),
+            out,
+            rows_written: 0,
+        }
+    }
+}
+
+/// Streaming writer for a result set: call `begin` with the column names,
+/// `write_row` for each row and `finish` at the end
+#[derive(Debug)]
+pub struct ResultWriter<W: Write> {
+    renderer: Box<dyn Renderer>,
+    out: W,
+    rows_written: usize,
+}
+
+impl<W: Write> ResultWriter<W> {
+    /// Write the header for the given columns
+    pub fn begin(&mut self, columns: &[String]) -> io::Result<()> {
+        self.renderer.begin(&mut self.out, columns)
+    }
+
+    /// Write a single row
+    pub fn write_row(&mut self, row: &[String]) -> io::Result<()> {
+        self.renderer.row(&mut self.out, row)?;
+        self.rows_written += 1;
+        Ok(())
+    }
+
+    /// Number of rows written so far
+    pub fn rows_written(&self) -> usize {
+        self.rows_written
+    }
+
+    /// Write any trailer and flush, returning the underlying output
+    pub fn finish(mut self) -> io::Result<W> {
+        self.renderer.finish(&mut self.out)?;
+        self.out.flush()?;
+        Ok(self.out)
+    }
+}
+
+/// The two sample rows the format tests render
+#[cfg(test)]
+fn render_sample(format: ExportFormat) -> Vec<u8> {
+    let columns = vec!["id".to_string(), "name".to_string()];
+    let rows = vec![
+        vec!["1".to_string(), "Ada".to_string()],
+        vec!["2".to_string(), "Smith, \"J\"".to_string()],
+    ];
+
+    let mut writer = format.writer(Vec::new());
+    writer.begin(&columns).unwrap();
+    for row in &rows {
+        writer.write_row(row).unwrap();
+    }
+    writer.finish().unwrap()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use clap::ValueEnum;
+
+    #[test]
+    fn test_every_format_is_registered_once() {
+        for format in ExportFormat::value_variants() {
+            let entries = FORMATS.iter().filter(|info| info.format == *format);
+            assert_eq!(entries.count(), 1, "{format:?}");
+        }
+        assert_eq!(FORMATS.len(), ExportFormat::value_variants().len());
+
+        let mut extensions: Vec<&str> = FORMATS.iter().map(|info| info.extension).collect();
+        extensions.sort_unstable();
+        extensions.dedup();
+        assert_eq!(extensions.len(), FORMATS.len());
+    }
+
+    #[test]
+    fn test_writer_counts_rows() {
+        let mut writer = ExportFormat::Csv.writer(Vec::new());
+        writer.begin(&["id".to_string()]).unwrap();
+        writer.write_row(&["1".to_string()]).unwrap();
+        writer.write_row(&["2".to_string()]).unwrap();
+        assert_eq!(writer.rows_written(), 2);
+        assert!(ExportFormat::Xlsx.is_binary());
+        assert!(!ExportFormat::Ndjson.is_binary());
+    }
+}
@@ -0,0 +1,53 @@
+// FilePath: src/io/export/ndjson.rs
+
+//! Newline-delimited JSON: one object per row and line, keyed by column
+//! name, for log tooling and `jq` that read records one at a time
+
+#![forbid(unsafe_code)]
+
+use super::json::json_object;
+use super::{ExportFormat, FormatInfo, Renderer};
+use std::io::{self, Write};
+
+pub(super) const FORMAT: FormatInfo = FormatInfo {
+    format: ExportFormat::Ndjson,
+    label: "NDJSON",
+    extension: "ndjson",
+    binary: false,
+    renderer: || Box::<NdjsonRenderer>::default(),
+};
+
+#[derive(Debug, Default)]
+struct NdjsonRenderer {
+    columns: Vec<String>,
+}
+
+impl Renderer for NdjsonRenderer {
+    fn begin(&mut self, _out: &mut dyn Write, columns: &[String]) -> io::Result<()> {
+        self.columns = columns.to_vec();
+        Ok(())
+    }
+
+    fn row(&mut self, out: &mut dyn Write, row: &[String]) -> io::Result<()> {
+        writeln!(out, "{}", json_object(&self.columns, row))
+    }
+
+    fn finish(&mut self, _out: &mut dyn Write) -> io::Result<()> {
+        Ok(())
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_ndjson_writes_an_object_per_line() {
+        let output = String::from_utf8(super::super::render_sample(ExportFormat::Ndjson)).unwrap();
+        let lines: Vec<&str> = output.lines().collect();
+        assert_eq!(lines[0], r#"{"id":"1","name":"Ada"}"#);
+        let parsed: serde_json::Value = serde_json::from_str(lines[1]).unwrap();
+        assert_eq!(parsed["name"], "Smith, \"J\"");
+        assert_eq!(lines.len(), 2);
+    }
+}
@@ -0,0 +1,90 @@
+// FilePath: src/io/export/table.rs
+
+//! Aligned plain-text table, the `query` subcommand's default. Rows are held
+//! back until the end, since the column widths depend on every value
+
+#![forbid(unsafe_code)]
+
+use super::{ExportFormat, FormatInfo, Renderer};
+use std::io::{self, Write};
+
+pub(super) const FORMAT: FormatInfo = FormatInfo {
+    format: ExportFormat::Table,
+    label: "Text table",
+    extension: "txt",
+    binary: false,
+    renderer: || Box::<TableRenderer>::default(),
+};
+
+#[derive(Debug, Default)]
+struct TableRenderer {
+    columns: Vec<String>,
+    rows: Vec<Vec<String>>,
+}
+
+impl Renderer for TableRenderer {
+    fn begin(&mut self, _out: &mut dyn Write, columns: &[String]) -> io::Result<()> {
+        self.columns = columns.to_vec();
+        Ok(())
+    }
+
+    fn row(&mut self, _out: &mut dyn Write, row: &[String]) -> io::Result<()> {
+        self.rows.push(row.to_vec());
+        Ok(())
+    }
+
+    fn finish(&mut self, out: &mut dyn Write) -> io::Result<()> {
+        let mut widths: Vec<usize> = self.columns.iter().map(|c| c.chars().count()).collect();
+        for row in &self.rows {
+            for (width, value) in widths.iter_mut().zip(row) {
+                *width = (*width).max(value.chars().count());
+            }
+        }
+
+        let line = |values: &[String]| {
+            values
+                .iter()
+                .zip(&widths)
+                .map(|(value, width)| format!("{:<width$}", value, width = *width))
+                .collect::<Vec<_>>()
+                .join(" | ")
+                .trim_end()
+                .to_string()
+        };
+
+        writeln!(out, "{}", line(&self.columns))?;
+        writeln!(
+            out,
+            "{}",
+            widths
+                .iter()
+                .map(|width| "-".repeat(*width))
+                .collect::<Vec<_>>()
+                .join("-+-")
+        )?;
+        for row in &self.rows {
+            writeln!(out, "{}", line(row))?;
+        }
+        writeln!(
+            out,
+            "({} row{})",
+            self.rows.len(),
+            if self.rows.len() == 1 { "" } else { "s" }
+        )
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_table_aligns_columns() {
+        let output = String::from_utf8(super::super::render_sample(ExportFormat::Table)).unwrap();
+        let lines: Vec<&str> = output.lines().collect();
+        assert_eq!(lines[0], "id | name");
+        assert_eq!(lines[1], "---+-----------");
+        assert_eq!(lines[2], "1  | Ada");
+        assert_eq!(lines[4], "(2 rows)");
+    }
+}
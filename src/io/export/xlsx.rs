@@ -0,0 +1,369 @@
+// FilePath: src/io/export/xlsx.rs
+
+//! Excel workbook with the rows on one sheet, below a frozen header row
+//!
+//! An XLSX file is a ZIP archive of XML parts. The sheet is built as rows
+//! arrive and the archive is written, uncompressed, at the end. Values that
+//! read as plain decimal numbers become number cells so they sum and sort;
+//! NULL leaves the cell empty, and everything else is text, so zip codes and
+//! ids with leading zeros keep them.
+
+#![forbid(unsafe_code)]
+
+use super::{ExportFormat, FormatInfo, Renderer, F64_DIGITS};
+use crate::database::NULL_CELL;
+use std::fmt::Write as _;
+use std::io::{self, Write};
+
+pub(super) const FORMAT: FormatInfo = FormatInfo {
+    format: ExportFormat::Xlsx,
+    label: "Excel (XLSX)",
+    extension: "xlsx",
+    binary: true,
+    renderer: || Box::<XlsxRenderer>::default(),
+};
+
+/// Rows of a sheet, the header included
+const MAX_ROWS: usize = 1_048_576;
+
+/// Columns of a sheet
+const MAX_COLUMNS: usize = 16_384;
+
+/// Characters of a cell; longer text is cut short
+const MAX_CELL_CHARS: usize = 32_767;
+
+const CONTENT_TYPES: &str = concat!(
+    r#"<?xml version="1.0" encoding="UTF-8" standalone="yes"?>"#,
+    r#"<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">"#,
+    r#"<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>"#,
+    r#"<Default Extension="xml" ContentType="application/xml"/>"#,
+    r#"<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>"#,
+    r#"<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>"#,
+    r#"</Types>"#,
+);
+
+const PACKAGE_RELS: &str = concat!(
+    r#"<?xml version="1.0" encoding="UTF-8" standalone="yes"?>"#,
+    r#"<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">"#,
+    r#"<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>"#,
+    r#"</Relationships>"#,
+);
+
+const WORKBOOK: &str = concat!(
+    r#"<?xml version="1.0" encoding="UTF-8" standalone="yes"?>"#,
+    r#"<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" "#,
+    r#"xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">"#,
+    r#"<sheets><sheet name="Result" sheetId="1" r:id="rId1"/></sheets>"#,
+    r#"</workbook>"#,
+);
+
+const WORKBOOK_RELS: &str = concat!(
+    r#"<?xml version="1.0" encoding="UTF-8" standalone="yes"?>"#,
+    r#"<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">"#,
+    r#"<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>"#,
+    r#"</Relationships>"#,
+);
+
+const SHEET_HEAD: &str = concat!(
+    r#"<?xml version="1.0" encoding="UTF-8" standalone="yes"?>"#,
+    r#"<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">"#,
+    r#"<sheetViews><sheetView workbookViewId="0">"#,
+    r#"<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>"#,
+    r#"</sheetView></sheetViews><sheetData>"#,
+);
+
+const SHEET_TAIL: &str = "</sheetData></worksheet>";
+
+#[derive(Debug, Default)]
+struct XlsxRenderer {
+    /// `<row>` elements so far
+    sheet: String,
+    rows: usize,
+}
+
+impl XlsxRenderer {
+    fn push_row(&mut self, values: &[String], header: bool) -> io::Result<()> {
+        if self.rows == MAX_ROWS {
+            return Err(io::Error::new(
+                io::ErrorKind::InvalidInput,
+                format!("an XLSX sheet holds at most {MAX_ROWS} rows"),
+            ));
+        }
+        self.rows += 1;
+        let row = self.rows;
+        let _ = write!(self.sheet, r#"<row r="{row}">"#);
+        for (index, value) in values.iter().enumerate() {
+            let cell = format!("{}{row}", column_name(index));
+            if header {
+                push_text_cell(&mut self.sheet, &cell, value);
+            } else if value == NULL_CELL {
+                continue;
+            } else if is_plain_number(value) {
+                let _ = write!(self.sheet, r#"<c r="{cell}"><v>{value}</v></c>"#);
+            } else {
+                push_text_cell(&mut self.sheet, &cell, value);
+            }
+        }
+        self.sheet.push_str("</row>");
+        Ok(())
+    }
+}
+
+impl Renderer for XlsxRenderer {
+    fn begin(&mut self, _out: &mut dyn Write, columns: &[String]) -> io::Result<()> {
+        if columns.len() > MAX_COLUMNS {
+            return Err(io::Error::new(
+                io::ErrorKind::InvalidInput,
+                format!("an XLSX sheet holds at most {MAX_COLUMNS} columns"),
+            ));
+        }
+        self.push_row(columns, true)
+    }
+
+    fn row(&mut self, _out: &mut dyn Write, row: &[String]) -> io::Result<()> {
+        self.push_row(row, false)
+    }
+
+    fn finish(&mut self, out: &mut dyn Write) -> io::Result<()> {
+        let sheet = format!(
+            "{SHEET_HEAD}{}{SHEET_TAIL}",
+            std::mem::take(&mut self.sheet)
+        );
+        let mut zip = StoredZip::new(out);
+        zip.add("[Content_Types].xml", CONTENT_TYPES.as_bytes())?;
+        zip.add("_rels/.rels", PACKAGE_RELS.as_bytes())?;
+        zip.add("xl/workbook.xml", WORKBOOK.as_bytes())?;
+        zip.add("xl/_rels/workbook.xml.rels", WORKBOOK_RELS.as_bytes())?;
+        zip.add("xl/worksheets/sheet1.xml", sheet.as_bytes())?;
+        zip.finish()
+    }
+}
+
+/// Spreadsheet name of the column with 0-based `index`: A, B, ..., Z, AA
+fn column_name(index: usize) -> String {
+    let mut name = Vec::new();
+    let mut n = index + 1;
+    while n > 0 {
+        n -= 1;
+        name.push(b'A' + (n % 26) as u8);
+        n /= 26;
+    }
+    name.reverse();
+    String::from_utf8(name).unwrap_or_default()
+}
+
+/// Whether `value` is a decimal a number cell holds exactly: an optional
+/// minus, digits without a leading zero and an optional fraction
+fn is_plain_number(value: &str) -> bool {
+    let unsigned = value.strip_prefix('-').unwrap_or(value);
+    let (whole, fraction) = match unsigned.split_once('.') {
+        Some((whole, fraction)) => (whole, Some(fraction)),
+        None => (unsigned, None),
+    };
+    let digits = |part: &str| !part.is_empty() && part.bytes().all(|b| b.is_ascii_digit());
+    digits(whole)
+        && (whole == "0" || !whole.starts_with('0'))
+        && fraction.map_or(true, digits)
+        && whole.len() + fraction.map_or(0, str::len) <= F64_DIGITS
+}
+
+/// An inline text cell. Characters XML can't hold are dropped
+fn push_text_cell(sheet: &mut String, cell: &str, value: &str) {
+    let _ = write!(
+        sheet,
+        r#"<c r="{cell}" t="inlineStr"><is><t xml:space="preserve">"#
+    );
+    for c in value.chars().take(MAX_CELL_CHARS) {
+        match c {
+            '&' => sheet.push_str("&amp;"),
+            '<' => sheet.push_str("&lt;"),
+            '>' => sheet.push_str("&gt;"),
+            '"' => sheet.push_str("&quot;"),
+            '\t' | '\n' | '\r' => sheet.push(c),
+            c if c < ' ' || c == '\u{fffe}' || c == '\u{ffff}' => {}
+            c => sheet.push(c),
+        }
+    }
+    sheet.push_str("</t></is></c>");
+}
+
+/// DOS date of 1980-01-01, the earliest a ZIP entry can carry
+const ZIP_DATE: u16 = (1 << 5) | 1;
+
+/// ZIP archive of uncompressed entries, written as they are added
+struct StoredZip<'a> {
+    out: &'a mut dyn Write,
+    /// Bytes written so far
+    offset: u32,
+    /// Central directory records of the entries written
+    directory: Vec<u8>,
+    entries: u16,
+}
+
+impl<'a> StoredZip<'a> {
+    fn new(out: &'a mut dyn Write) -> Self {
+        Self {
+            out,
+            offset: 0,
+            directory: Vec::new(),
+            entries: 0,
+        }
+    }
+
+    fn add(&mut self, name: &str, data: &[u8]) -> io::Result<()> {
+        let size = zip_size(data.len())?;
+        let crc = crc32(data);
+        let name_len = name.len() as u16;
+
+        let mut header = Vec::with_capacity(30 + name.len());
+        header.extend_from_slice(&0x0403_4b50u32.to_le_bytes());
+        header.extend_from_slice(&20u16.to_le_bytes()); // version needed
+        header.extend_from_slice(&0u16.to_le_bytes()); // flags
+        header.extend_from_slice(&0u16.to_le_bytes()); // stored
+        header.extend_from_slice(&0u16.to_le_bytes()); // time
+        header.extend_from_slice(&ZIP_DATE.to_le_bytes());
+        header.extend_from_slice(&crc.to_le_bytes());
+        header.extend_from_slice(&size.to_le_bytes());
+        header.extend_from_slice(&size.to_le_bytes());
+        header.extend_from_slice(&name_len.to_le_bytes());
+        header.extend_from_slice(&0u16.to_le_bytes()); // extra field
+        header.extend_from_slice(name.as_bytes());
+
+        let record = &mut self.directory;
+        record.extend_from_slice(&0x0201_4b50u32.to_le_bytes());
+        record.extend_from_slice(&20u16.to_le_bytes()); // version made by
+        record.extend_from_slice(&header[4..30]);
+        record.extend_from_slice(&0u16.to_le_bytes()); // comment
+        record.extend_from_slice(&0u16.to_le_bytes()); // disk
+        record.extend_from_slice(&0u16.to_le_bytes()); // internal attributes
+        record.extend_from_slice(&0u32.to_le_bytes()); // external attributes
+        record.extend_from_slice(&self.offset.to_le_bytes());
+        record.extend_from_slice(name.as_bytes());
+
+        self.out.write_all(&header)?;
+        self.out.write_all(data)?;
+        self.offset = self
+            .offset
+            .checked_add(zip_size(header.len())?)
+            .and_then(|offset| offset.checked_add(size))
+            .ok_or_else(too_large)?;
+        self.entries += 1;
+        Ok(())
+    }
+
+    /// Write the central directory closing the archive
+    fn finish(self) -> io::Result<()> {
+        let size = zip_size(self.directory.len())?;
+        self.offset.checked_add(size).ok_or_else(too_large)?;
+        self.out.write_all(&self.directory)?;
+
+        let mut end = Vec::with_capacity(22);
+        end.extend_from_slice(&0x0605_4b50u32.to_le_bytes());
+        end.extend_from_slice(&0u16.to_le_bytes()); // disk
+        end.extend_from_slice(&0u16.to_le_bytes()); // disk of the directory
+        end.extend_from_slice(&self.entries.to_le_bytes());
+        end.extend_from_slice(&self.entries.to_le_bytes());
+        end.extend_from_slice(&size.to_le_bytes());
+        end.extend_from_slice(&self.offset.to_le_bytes());
+        end.extend_from_slice(&0u16.to_le_bytes()); // comment
+        self.out.write_all(&end)
+    }
+}
+
+/// A length as a ZIP size field; archives past 4 GB need ZIP64, which this
+/// doesn't write
+fn zip_size(len: usize) -> io::Result<u32> {
+    u32::try_from(len).map_err(|_| too_large())
+}
+
+fn too_large() -> io::Error {
+    io::Error::new(
+        io::ErrorKind::InvalidInput,
+        "too many rows for an XLSX file",
+    )
+}
+
+/// CRC-32 lookup table of the ZIP polynomial
+const CRC_TABLE: [u32; 256] = {
+    let mut table = [0u32; 256];
+    let mut i = 0;
+    while i < 256 {
+        let mut crc = i as u32;
+        let mut bit = 0;
+        while bit < 8 {
+            crc = if crc & 1 == 1 {
+                (crc >> 1) ^ 0xedb8_8320
+            } else {
+                crc >> 1
+            };
+            bit += 1;
+        }
+        table[i] = crc;
+        i += 1;
+    }
+    table
+};
+
+fn crc32(data: &[u8]) -> u32 {
+    !data.iter().fold(!0u32, |crc, &byte| {
+        CRC_TABLE[((crc ^ byte as u32) & 0xff) as usize] ^ (crc >> 8)
+    })
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_cell_helpers() {
+        assert_eq!(column_name(0), "A");
+        assert_eq!(column_name(25), "Z");
+        assert_eq!(column_name(26), "AA");
+        assert_eq!(column_name(16_383), "XFD");
+
+        assert!(is_plain_number("42"));
+        assert!(is_plain_number("-0.5"));
+        assert!(is_plain_number("12.50"));
+        assert!(!is_plain_number("01234"));
+        assert!(!is_plain_number("1e5"));
+        assert!(!is_plain_number("12345678901234567890"));
+        assert!(!is_plain_number("1."));
+        assert!(!is_plain_number("-"));
+
+        assert_eq!(crc32(b"123456789"), 0xcbf4_3926);
+    }
+
+    #[test]
+    fn test_xlsx_is_a_zip_of_the_workbook_parts() {
+        let output = super::super::render_sample(ExportFormat::Xlsx);
+        assert!(output.starts_with(b"PK\x03\x04"));
+        // End of central directory: five entries, and the directory ends
+        // where the record starts
+        let end = &output[output.len() - 22..];
+        assert_eq!(end[..4], 0x0605_4b50u32.to_le_bytes());
+        assert_eq!(u16::from_le_bytes([end[10], end[11]]), 5);
+        let size = u32::from_le_bytes([end[12], end[13], end[14], end[15]]) as usize;
+        let offset = u32::from_le_bytes([end[16], end[17], end[18], end[19]]) as usize;
+        assert_eq!(offset + size, output.len() - 22);
+
+        let text = String::from_utf8_lossy(&output);
+        assert!(text.contains(r#"<c r="A2"><v>1</v></c>"#));
+        assert!(text.contains("Smith, &quot;J&quot;</t>"));
+        assert!(text.contains("xl/worksheets/sheet1.xml"));
+    }
+
+    #[test]
+    fn test_null_cells_stay_empty() {
+        let mut renderer = XlsxRenderer::default();
+        let mut out = Vec::new();
+        renderer
+            .begin(&mut out, &["a".to_string(), "b".to_string()])
+            .unwrap();
+        renderer
+            .row(&mut out, &["NULL".to_string(), "x<\u{1}y".to_string()])
+            .unwrap();
+        assert!(renderer
+            .sheet
+            .ends_with(r#"<row r="2"><c r="B2" t="inlineStr"><is><t xml:space="preserve">x&lt;y</t></is></c></row>"#));
+    }
+}
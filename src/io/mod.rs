@@ -8,5 +8,9 @@
 #![forbid(unsafe_code)]
 
 pub mod async_fs;
+pub mod blob;
+pub mod clipboard;
+pub mod export;
+pub mod opener;
 
 pub use async_fs::*;
@@ -0,0 +1,55 @@
+// FilePath: src/io/opener.rs
+
+//! Opening a folder in the desktop's file manager
+
+#![forbid(unsafe_code)]
+
+use std::path::Path;
+use std::process::{Command, Stdio};
+
+/// Program opening a path with the desktop's default application
+fn opener() -> &'static str {
+    if cfg!(target_os = "macos") {
+        "open"
+    } else if cfg!(windows) {
+        "explorer"
+    } else {
+        "xdg-open"
+    }
+}
+
+/// Folder holding the file at `path`, `.` for a bare file name
+pub fn containing_folder(path: &Path) -> &Path {
+    path.parent()
+        .filter(|parent| !parent.as_os_str().is_empty())
+        .unwrap_or(Path::new("."))
+}
+
+/// Open `dir` in the file manager without waiting for it. Errors when there's
+/// no opener, as on a server reached over SSH
+pub fn open_folder(dir: &Path) -> Result<(), String> {
+    let mut child = Command::new(opener())
+        .arg(dir)
+        .stdin(Stdio::null())
+        .stdout(Stdio::null())
+        .stderr(Stdio::null())
+        .spawn()
+        .map_err(|e| format!("Failed to open {}: {e}", dir.display()))?;
+    // Reap the opener once it exits, without holding up the UI
+    std::thread::spawn(move || child.wait());
+    Ok(())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_containing_folder() {
+        assert_eq!(
+            containing_folder(Path::new("/tmp/out/rows.csv")),
+            Path::new("/tmp/out")
+        );
+        assert_eq!(containing_folder(Path::new("rows.csv")), Path::new("."));
+    }
+}
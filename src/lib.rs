@@ -17,6 +17,7 @@ pub mod state;
 pub mod terminal;
 pub mod themes;
 pub mod ui;
+pub mod version;
 
 pub use app::App;
 pub use cli::Cli;
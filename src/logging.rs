@@ -2,14 +2,27 @@
 
 #![forbid(unsafe_code)]
 
-use crate::{cli::LogLevel, config::Config, core::error::Result};
+use crate::{
+    cli::LogLevel,
+    config::{Config, LoggingConfig},
+    core::{
+        error::{LazyTablesError, Result},
+        redact::scrub_secrets,
+    },
+    database::notices::NoticeLayer,
+};
 use std::{
     collections::VecDeque,
     fs,
+    io::{self, BufWriter, Write},
     path::{Path, PathBuf},
-    sync::{Arc, Mutex},
+    sync::{Arc, Mutex, OnceLock},
+    time::{Duration, SystemTime},
 };
-use tracing_subscriber::{prelude::*, EnvFilter, Layer};
+use tracing_subscriber::{fmt::MakeWriter, prelude::*, EnvFilter, Layer};
+
+/// Name of the active log file inside the logs directory
+pub const LOG_FILE_NAME: &str = "lazytables.log";
 
 /// Debug message entry for the debug view
 #[derive(Debug, Clone)]
@@ -86,7 +99,7 @@ where
             timestamp: chrono::Utc::now(),
             level: metadata.level().to_string(),
             target: metadata.target().to_string(),
-            message: visitor.message,
+            message: visitor.finish(),
             location: metadata.file().map(|file| {
                 if let Some(line) = metadata.line() {
                     format!("{}:{}", file, line)
@@ -100,20 +113,46 @@ where
     }
 }
 
-/// Visitor to extract the log message from the event
+/// Visitor to extract the log message from the event. Supports both calling
+/// styles: `info!("Retrieved {} tables", n)` and `info!(count = n, "Retrieved tables")`,
+/// where structured fields are appended as `key=value`
 #[derive(Default)]
 struct LogVisitor {
     message: String,
+    fields: Vec<String>,
+}
+
+impl LogVisitor {
+    /// Combine the message and any structured fields into a single line,
+    /// with credentials masked
+    fn finish(self) -> String {
+        let line = if self.fields.is_empty() {
+            self.message
+        } else if self.message.is_empty() {
+            self.fields.join(" ")
+        } else {
+            format!("{} {}", self.message, self.fields.join(" "))
+        };
+        scrub_secrets(&line).into_owned()
+    }
 }
 
 impl tracing::field::Visit for LogVisitor {
+    fn record_str(&mut self, field: &tracing::field::Field, value: &str) {
+        // Record strings as-is so they don't pick up Debug quoting and escapes
+        if field.name() == "message" {
+            self.message = value.to_string();
+        } else {
+            self.fields.push(format!("{}={}", field.name(), value));
+        }
+    }
+
     fn record_debug(&mut self, field: &tracing::field::Field, value: &dyn std::fmt::Debug) {
         if field.name() == "message" {
+            // Format arguments render without quotes through Debug
             self.message = format!("{:?}", value);
-            // Remove the quotes from the debug output
-            if self.message.starts_with('"') && self.message.ends_with('"') {
-                self.message = self.message[1..self.message.len() - 1].to_string();
-            }
+        } else {
+            self.fields.push(format!("{}={:?}", field.name(), value));
         }
     }
 }
@@ -128,19 +167,275 @@ pub fn clear_debug_messages() {
     DEBUG_LOG_STORAGE.clear();
 }
 
-/// Initialize the logging system based on mode and level
-pub fn init(level: LogLevel) -> Result<()> {
+/// Log level specification: an optional default level plus per-area overrides,
+/// parsed from strings like "info" or "warn,db=debug,ui=warn"
+#[derive(Debug, Clone)]
+pub struct LogSpec {
+    /// Level for the whole application (None keeps the mode default)
+    pub level: Option<LogLevel>,
+    /// Per-area overrides as (tracing target, level)
+    pub overrides: Vec<(String, LogLevel)>,
+}
+
+impl LogSpec {
+    /// Parse a level specification, failing with the list of valid levels
+    pub fn parse(spec: &str) -> Result<Self> {
+        let mut level = None;
+        let mut overrides = Vec::new();
+
+        for part in spec.split(',').map(str::trim).filter(|p| !p.is_empty()) {
+            if let Some((area, area_level)) = part.split_once('=') {
+                let area = area.trim();
+                if area.is_empty() {
+                    return Err(LazyTablesError::Config(format!(
+                        "Invalid log override '{}': missing area name",
+                        part
+                    )));
+                }
+                let area_level = area_level
+                    .parse::<LogLevel>()
+                    .map_err(LazyTablesError::Config)?;
+                overrides.push((Self::area_target(area), area_level));
+            } else {
+                level = Some(part.parse::<LogLevel>().map_err(LazyTablesError::Config)?);
+            }
+        }
+
+        Ok(Self { level, overrides })
+    }
+
+    /// Pick the effective specification: the command line flag wins over the config file
+    pub fn resolve(flag: Option<&str>, config: Option<&str>) -> Result<Option<Self>> {
+        flag.or(config).map(Self::parse).transpose()
+    }
+
+    /// Map a short area name to the tracing target it covers
+    fn area_target(area: &str) -> String {
+        match area {
+            "db" => "lazytables::database".to_string(),
+            "sqlx" => "sqlx".to_string(),
+            other if other.contains("::") => other.to_string(),
+            other => format!("lazytables::{}", other),
+        }
+    }
+
+    /// Build EnvFilter directives, using the given defaults for anything unspecified
+    fn directives(&self, default_level: LogLevel, sqlx_level: LogLevel) -> String {
+        let mut directives = vec![
+            format!(
+                "lazytables={}",
+                self.level.unwrap_or(default_level).as_str()
+            ),
+            format!("sqlx={}", sqlx_level.as_str()),
+        ];
+        directives.extend(
+            self.overrides
+                .iter()
+                .map(|(target, level)| format!("{}={}", target, level.as_str())),
+        );
+        directives.join(",")
+    }
+}
+
+/// Target of the statements `--trace-sql` logs, kept at info whatever the
+/// level so the trace shows in production logs too
+pub const SQL_TRACE_TARGET: &str = "lazytables::sql_trace";
+
+/// Build the filter for the given mode: an explicit spec wins, then RUST_LOG,
+/// then the mode defaults. `trace_sql` lets the SQL trace through on top
+fn build_filter(
+    spec: Option<&LogSpec>,
+    default_level: LogLevel,
+    sqlx_level: LogLevel,
+    trace_sql: bool,
+) -> EnvFilter {
+    let filter = match spec {
+        Some(spec) => EnvFilter::new(spec.directives(default_level, sqlx_level)),
+        None => EnvFilter::try_from_default_env().unwrap_or_else(|_| {
+            EnvFilter::new(format!(
+                "lazytables={},sqlx={}",
+                default_level.as_str(),
+                sqlx_level.as_str()
+            ))
+        }),
+    };
+    match format!("{SQL_TRACE_TARGET}=info").parse() {
+        Ok(directive) if trace_sql => filter.add_directive(directive),
+        _ => filter,
+    }
+}
+
+/// Size-rotated log file: lazytables.log plus lazytables.log.1 .. lazytables.log.N,
+/// where .1 is the most recent backup
+#[derive(Debug)]
+struct RotatingFile {
+    path: PathBuf,
+    file: BufWriter<fs::File>,
+    size: u64,
+    max_size: u64,
+    max_backups: usize,
+}
+
+impl RotatingFile {
+    fn open(path: PathBuf, max_size: u64, max_backups: usize) -> io::Result<Self> {
+        let file = fs::OpenOptions::new()
+            .create(true)
+            .append(true)
+            .open(&path)?;
+        let size = file.metadata()?.len();
+        Ok(Self {
+            path,
+            file: BufWriter::new(file),
+            size,
+            max_size,
+            max_backups,
+        })
+    }
+
+    fn backup_path(&self, index: usize) -> PathBuf {
+        let mut name = self.path.as_os_str().to_owned();
+        name.push(format!(".{}", index));
+        PathBuf::from(name)
+    }
+
+    /// Shift backups up by one, dropping the oldest, and start a fresh file
+    fn rotate(&mut self) -> io::Result<()> {
+        self.file.flush()?;
+
+        if self.max_backups == 0 {
+            fs::remove_file(&self.path)?;
+        } else {
+            let _ = fs::remove_file(self.backup_path(self.max_backups));
+            for index in (1..self.max_backups).rev() {
+                let from = self.backup_path(index);
+                if from.exists() {
+                    fs::rename(&from, self.backup_path(index + 1))?;
+                }
+            }
+            fs::rename(&self.path, self.backup_path(1))?;
+        }
+
+        let file = fs::OpenOptions::new()
+            .create(true)
+            .append(true)
+            .open(&self.path)?;
+        self.file = BufWriter::new(file);
+        self.size = 0;
+        Ok(())
+    }
+}
+
+impl Write for RotatingFile {
+    fn write(&mut self, buf: &[u8]) -> io::Result<usize> {
+        // Rotate between records so a single log line never spans two files
+        if self.max_size > 0 && self.size > 0 && self.size + buf.len() as u64 > self.max_size {
+            self.rotate()?;
+        }
+        let written = self.file.write(buf)?;
+        self.size += written as u64;
+        Ok(written)
+    }
+
+    fn flush(&mut self) -> io::Result<()> {
+        self.file.flush()
+    }
+}
+
+/// Shared handle to the active log file, usable as a tracing writer
+#[derive(Debug, Clone)]
+struct LogWriter(Arc<Mutex<RotatingFile>>);
+
+impl Write for LogWriter {
+    fn write(&mut self, buf: &[u8]) -> io::Result<usize> {
+        match self.0.lock() {
+            Ok(mut file) => file.write(buf),
+            Err(_) => Err(io::Error::new(
+                io::ErrorKind::Other,
+                "log file lock poisoned",
+            )),
+        }
+    }
+
+    fn write_all(&mut self, buf: &[u8]) -> io::Result<()> {
+        // Records arrive whole, so credentials a driver echoed into one can
+        // be masked before they reach the disk
+        let record = std::str::from_utf8(buf).map(scrub_secrets);
+        let buf = match &record {
+            Ok(record) => record.as_bytes(),
+            Err(_) => buf,
+        };
+        // Hold the lock for the whole record so concurrent lines don't interleave
+        match self.0.lock() {
+            Ok(mut file) => file.write_all(buf),
+            Err(_) => Err(io::Error::new(
+                io::ErrorKind::Other,
+                "log file lock poisoned",
+            )),
+        }
+    }
+
+    fn flush(&mut self) -> io::Result<()> {
+        match self.0.lock() {
+            Ok(mut file) => file.flush(),
+            Err(_) => Ok(()),
+        }
+    }
+}
+
+impl<'a> MakeWriter<'a> for LogWriter {
+    type Writer = LogWriter;
+
+    fn make_writer(&'a self) -> Self::Writer {
+        self.clone()
+    }
+}
+
+static ACTIVE_LOG: OnceLock<LogWriter> = OnceLock::new();
+
+/// Path of the log file currently being written, if logging is initialized
+pub fn log_file_path() -> Option<PathBuf> {
+    ACTIVE_LOG
+        .get()
+        .and_then(|writer| writer.0.lock().ok().map(|file| file.path.clone()))
+}
+
+/// Flush buffered log records to disk
+pub fn flush() {
+    if let Some(writer) = ACTIVE_LOG.get() {
+        let _ = writer.clone().flush();
+    }
+}
+
+/// Initialize the logging system based on mode, the resolved level spec and
+/// the rotation settings from the config file
+pub fn init(spec: Option<&LogSpec>, options: &LoggingConfig) -> Result<()> {
     let log_dir = get_log_dir()?;
     fs::create_dir_all(&log_dir)?;
 
+    let pruned = prune_old_logs(
+        &log_dir,
+        Duration::from_secs(options.retention_days * 24 * 60 * 60),
+    );
+
+    let file = RotatingFile::open(
+        log_dir.join(LOG_FILE_NAME),
+        options.max_size_mb * 1024 * 1024,
+        options.max_backups,
+    )?;
+    let writer = ACTIVE_LOG.get_or_init(|| LogWriter(Arc::new(Mutex::new(file))));
+
     let is_dev_mode = is_development_mode();
 
     if is_dev_mode {
-        init_development_logging(&log_dir, level)?;
-        tracing::info!("Development logging initialized with level: {:?}", level);
+        init_development_logging(writer.clone(), spec, options.trace_sql);
+        tracing::info!("Development logging initialized with spec: {:?}", spec);
     } else {
-        init_production_logging(&log_dir)?;
-        tracing::info!("Production logging initialized (warn/error only)");
+        init_production_logging(writer.clone(), spec, options.trace_sql);
+        tracing::info!("Production logging initialized with spec: {:?}", spec);
+    }
+
+    if pruned > 0 {
+        tracing::info!("Removed {} old log files", pruned);
     }
 
     log_startup_info(is_dev_mode);
@@ -148,6 +443,38 @@ pub fn init(level: LogLevel) -> Result<()> {
     Ok(())
 }
 
+/// Delete log files left by older versions (timestamped files, debug.log,
+/// error.log and their .old copies) that haven't been modified within the
+/// retention window. Returns the number of files removed
+fn prune_old_logs(log_dir: &Path, retention: Duration) -> usize {
+    let Ok(entries) = fs::read_dir(log_dir) else {
+        return 0;
+    };
+    let now = SystemTime::now();
+    let mut removed = 0;
+
+    for entry in entries.flatten() {
+        let name = entry.file_name().to_string_lossy().to_string();
+        let is_old_log = name.ends_with(".log") || name.ends_with(".log.old");
+        if !is_old_log || name.starts_with(LOG_FILE_NAME) {
+            continue;
+        }
+
+        let expired = entry
+            .metadata()
+            .and_then(|metadata| metadata.modified())
+            .ok()
+            .and_then(|modified| now.duration_since(modified).ok())
+            .is_some_and(|age| age > retention);
+
+        if expired && fs::remove_file(entry.path()).is_ok() {
+            removed += 1;
+        }
+    }
+
+    removed
+}
+
 /// Check if we're running in development mode
 fn is_development_mode() -> bool {
     // Check for debug build (most reliable indicator)
@@ -165,28 +492,13 @@ fn is_development_mode() -> bool {
 }
 
 /// Initialize logging for development mode
-fn init_development_logging(log_dir: &Path, level: LogLevel) -> Result<()> {
-    let debug_log_file = log_dir.join("debug.log");
-
-    // Rotate log file if it gets too large (>10MB)
-    rotate_log_file(&debug_log_file, 10 * 1024 * 1024)?;
-
-    let debug_file = std::fs::OpenOptions::new()
-        .create(true)
-        .append(true)
-        .open(&debug_log_file)?;
-
-    let filter = EnvFilter::try_from_default_env().unwrap_or_else(|_| {
-        EnvFilter::new(format!(
-            "lazytables={},sqlx=warn",
-            tracing::Level::from(level)
-        ))
-    });
+fn init_development_logging(writer: LogWriter, spec: Option<&LogSpec>, trace_sql: bool) {
+    let filter = build_filter(spec, LogLevel::Info, LogLevel::Warn, trace_sql);
 
     tracing_subscriber::registry()
         .with(
             tracing_subscriber::fmt::layer()
-                .with_writer(debug_file)
+                .with_writer(writer)
                 .with_ansi(false)
                 .with_target(true)
                 .with_thread_ids(true)
@@ -196,30 +508,18 @@ fn init_development_logging(log_dir: &Path, level: LogLevel) -> Result<()> {
                 .with_filter(filter.clone()),
         )
         .with(MemoryLogLayer.with_filter(filter))
+        .with(NoticeLayer::filtered())
         .init();
-
-    Ok(())
 }
 
 /// Initialize logging for production mode
-fn init_production_logging(log_dir: &Path) -> Result<()> {
-    let error_log_file = log_dir.join("error.log");
-
-    // Rotate log file if it gets too large (>5MB)
-    rotate_log_file(&error_log_file, 5 * 1024 * 1024)?;
-
-    let error_file = std::fs::OpenOptions::new()
-        .create(true)
-        .append(true)
-        .open(&error_log_file)?;
-
-    let filter = EnvFilter::try_from_default_env()
-        .unwrap_or_else(|_| EnvFilter::new("lazytables=warn,sqlx=error"));
+fn init_production_logging(writer: LogWriter, spec: Option<&LogSpec>, trace_sql: bool) {
+    let filter = build_filter(spec, LogLevel::Warn, LogLevel::Error, trace_sql);
 
     tracing_subscriber::registry()
         .with(
             tracing_subscriber::fmt::layer()
-                .with_writer(error_file)
+                .with_writer(writer)
                 .with_ansi(false)
                 .with_target(false)
                 .with_thread_ids(false)
@@ -228,30 +528,18 @@ fn init_production_logging(log_dir: &Path) -> Result<()> {
                 .with_line_number(false)
                 .with_filter(filter),
         )
+        .with(NoticeLayer::filtered())
         .init();
-
-    Ok(())
-}
-
-/// Rotate log file if it exceeds size limit
-fn rotate_log_file(log_file: &PathBuf, max_size: u64) -> Result<()> {
-    if let Ok(metadata) = std::fs::metadata(log_file) {
-        if metadata.len() > max_size {
-            let backup_file = log_file.with_extension("log.old");
-            let _ = std::fs::rename(log_file, backup_file);
-        }
-    }
-    Ok(())
 }
 
 /// Get the log directory path
 fn get_log_dir() -> Result<PathBuf> {
-    Ok(Config::data_dir().join("logs"))
+    Ok(Config::logs_dir())
 }
 
 /// Log startup information
 fn log_startup_info(is_dev_mode: bool) {
-    tracing::info!("LazyTables v{} starting up", env!("CARGO_PKG_VERSION"));
+    tracing::info!("Starting {}", crate::version::short());
     tracing::info!(
         "Build mode: {}",
         if is_dev_mode {
@@ -260,14 +548,21 @@ fn log_startup_info(is_dev_mode: bool) {
             "production"
         }
     );
-    if let Ok(log_dir) = get_log_dir() {
-        tracing::info!("Log directory: {:?}", log_dir);
+    if let Some(log_file) = log_file_path() {
+        tracing::info!("Log file: {:?}", log_file);
+    }
+    if Config::legacy_data_dir().is_dir() {
+        tracing::info!(
+            "Using legacy data directory {:?}; run `lazytables migrate-data` to move it to XDG directories",
+            Config::legacy_data_dir()
+        );
     }
 }
 
-/// Log shutdown information
+/// Log shutdown information and flush the log file
 pub fn log_shutdown() {
     tracing::info!("LazyTables shutting down gracefully");
+    flush();
 }
 
 /// Convenience macros for logging throughout the application
@@ -359,6 +654,195 @@ mod tests {
         println!("SUCCESS: Debug storage is working correctly");
     }
 
+    #[test]
+    fn test_log_spec_parse_level_and_overrides() {
+        let spec = LogSpec::parse("warn,db=debug,ui=warn").unwrap();
+        assert!(matches!(spec.level, Some(LogLevel::Warn)));
+        assert_eq!(spec.overrides.len(), 2);
+        assert_eq!(spec.overrides[0].0, "lazytables::database");
+        assert_eq!(spec.overrides[1].0, "lazytables::ui");
+
+        let directives = spec.directives(LogLevel::Info, LogLevel::Warn);
+        assert_eq!(
+            directives,
+            "lazytables=warn,sqlx=warn,lazytables::database=debug,lazytables::ui=warn"
+        );
+    }
+
+    #[test]
+    fn test_log_spec_overrides_only_keep_default_level() {
+        let spec = LogSpec::parse("db=trace").unwrap();
+        assert!(spec.level.is_none());
+        assert!(spec
+            .directives(LogLevel::Warn, LogLevel::Error)
+            .starts_with("lazytables=warn,sqlx=error"));
+    }
+
+    #[test]
+    fn test_log_spec_invalid_level_lists_options() {
+        let err = LogSpec::parse("verbose").unwrap_err().to_string();
+        assert!(err.contains("verbose"));
+        assert!(err.contains("trace, debug, info, warn, error"));
+
+        assert!(LogSpec::parse("db=loud").is_err());
+        assert!(LogSpec::parse("=debug").is_err());
+    }
+
+    #[test]
+    fn test_log_spec_flag_wins_over_config() {
+        let spec = LogSpec::resolve(Some("debug"), Some("error"))
+            .unwrap()
+            .unwrap();
+        assert!(matches!(spec.level, Some(LogLevel::Debug)));
+        assert!(LogSpec::resolve(None, None).unwrap().is_none());
+    }
+
+    #[test]
+    fn test_rotating_file_keeps_max_backups() {
+        let dir = tempfile::tempdir().unwrap();
+        let path = dir.path().join(LOG_FILE_NAME);
+        let mut file = RotatingFile::open(path.clone(), 10, 2).unwrap();
+
+        for line in [
+            "first line\n",
+            "second line\n",
+            "third line\n",
+            "fourth line\n",
+        ] {
+            file.write_all(line.as_bytes()).unwrap();
+        }
+        file.flush().unwrap();
+
+        assert_eq!(fs::read_to_string(&path).unwrap(), "fourth line\n");
+        assert_eq!(
+            fs::read_to_string(dir.path().join("lazytables.log.1")).unwrap(),
+            "third line\n"
+        );
+        assert_eq!(
+            fs::read_to_string(dir.path().join("lazytables.log.2")).unwrap(),
+            "second line\n"
+        );
+        assert!(!dir.path().join("lazytables.log.3").exists());
+    }
+
+    #[test]
+    fn test_prune_old_logs_keeps_active_files() {
+        let dir = tempfile::tempdir().unwrap();
+        for name in [
+            "lazytables.log",
+            "lazytables.log.1",
+            "lazytables_20250101.log",
+            "debug.log",
+            "notes.txt",
+        ] {
+            fs::write(dir.path().join(name), "x").unwrap();
+        }
+
+        // Nothing is older than a day yet
+        assert_eq!(prune_old_logs(dir.path(), Duration::from_secs(86400)), 0);
+
+        // A zero retention window expires every legacy log file
+        std::thread::sleep(Duration::from_millis(10));
+        assert_eq!(prune_old_logs(dir.path(), Duration::ZERO), 2);
+        assert!(dir.path().join("lazytables.log").exists());
+        assert!(dir.path().join("lazytables.log.1").exists());
+        assert!(dir.path().join("notes.txt").exists());
+        assert!(!dir.path().join("debug.log").exists());
+    }
+
+    /// Layer that renders events through LogVisitor into a local buffer
+    struct CaptureLayer(Arc<Mutex<Vec<String>>>);
+
+    impl<S: tracing::Subscriber> Layer<S> for CaptureLayer {
+        fn on_event(
+            &self,
+            event: &tracing::Event<'_>,
+            _ctx: tracing_subscriber::layer::Context<'_, S>,
+        ) {
+            let mut visitor = LogVisitor::default();
+            event.record(&mut visitor);
+            self.0.lock().unwrap().push(visitor.finish());
+        }
+    }
+
+    fn capture(f: impl FnOnce()) -> Vec<String> {
+        let lines = Arc::new(Mutex::new(Vec::new()));
+        let subscriber = tracing_subscriber::registry().with(CaptureLayer(lines.clone()));
+        tracing::subscriber::with_default(subscriber, f);
+        let captured = lines.lock().unwrap().clone();
+        captured
+    }
+
+    #[test]
+    fn test_visitor_format_style_is_clean() {
+        let tables = vec!["users", "orders"];
+        let err = "connection refused";
+        let lines = capture(|| {
+            crate::log_debug!("Retrieved {} tables", tables.len());
+            crate::log_error!("Failed to query tables: {}", err);
+            crate::log_info!("Quoted \"name\" stays intact");
+        });
+
+        assert_eq!(
+            lines,
+            vec![
+                "Retrieved 2 tables",
+                "Failed to query tables: connection refused",
+                "Quoted \"name\" stays intact",
+            ]
+        );
+    }
+
+    #[test]
+    fn test_visitor_field_style_is_clean() {
+        let lines = capture(|| {
+            crate::log_debug!(count = 2, "Retrieved tables");
+            crate::log_error!(error = "connection refused", "Failed to query tables");
+            crate::log_warn!(table = "users", rows = 10);
+        });
+
+        assert_eq!(
+            lines,
+            vec![
+                "Retrieved tables count=2",
+                "Failed to query tables error=connection refused",
+                "table=users rows=10",
+            ]
+        );
+        assert!(lines.iter().all(|line| !line.contains("EXTRA")));
+    }
+
+    #[test]
+    fn test_credentials_never_reach_the_log() {
+        const SENTINEL: &str = "s3ntinel-Pa55";
+        let dir = tempfile::tempdir().unwrap();
+        let path = dir.path().join(LOG_FILE_NAME);
+        let writer = LogWriter(Arc::new(Mutex::new(
+            RotatingFile::open(path.clone(), 1024 * 1024, 1).unwrap(),
+        )));
+        let subscriber = tracing_subscriber::fmt()
+            .with_writer(writer.clone())
+            .with_ansi(false)
+            .finish();
+
+        let dsn = format!("postgresql://app:{SENTINEL}@db:5432/shop");
+        let driver_error = format!("error with configuration: password={SENTINEL} sslmode=require");
+        tracing::subscriber::with_default(subscriber, || {
+            crate::log_error!("Failed to connect to {}: {}", dsn, driver_error);
+            crate::log_warn!(url = %dsn, "Connection attempt failed");
+        });
+        let visited = capture(|| crate::log_error!("Failed to connect to {}", dsn));
+        writer.clone().flush().unwrap();
+
+        let log = fs::read_to_string(&path).unwrap();
+        assert!(log.contains("postgresql://app:***@db:5432/shop"), "{log}");
+        assert!(!log.contains(SENTINEL), "{log}");
+        assert_eq!(
+            visited,
+            vec!["Failed to connect to postgresql://app:***@db:5432/shop"]
+        );
+    }
+
     #[test]
     fn test_debug_storage_limits() {
         // Clear messages
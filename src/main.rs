@@ -13,20 +13,87 @@ async fn main() -> color_eyre::Result<()> {
     // Parse command line arguments
     let cli = Cli::parse();
 
-    // Handle theme commands if present
-    if let Some(lazytables::cli::Commands::Theme { command }) = &cli.theme {
-        return command
-            .execute()
-            .map_err(|e| color_eyre::eyre::eyre!("Theme command failed: {}", e));
+    if cli.version {
+        if cli.verbose {
+            println!("{}", lazytables::version::long());
+        } else {
+            println!("{}", lazytables::version::short());
+        }
+        return Ok(());
     }
 
-    // Initialize logging
-    lazytables::logging::init(cli.log_level)
-        .map_err(|e| color_eyre::eyre::eyre!("Failed to init logging: {}", e))?;
+    // Handle subcommands if present
+    match &cli.command {
+        Some(lazytables::cli::Commands::Theme { command }) => {
+            return command
+                .execute()
+                .map_err(|e| color_eyre::eyre::eyre!("Theme command failed: {}", e));
+        }
+        Some(lazytables::cli::Commands::MigrateData) => {
+            let actions = Config::migrate_legacy_data_dir()
+                .map_err(|e| color_eyre::eyre::eyre!("Migration failed: {}", e))?;
+            for action in actions {
+                println!("{action}");
+            }
+            return Ok(());
+        }
+        // Headless commands run after config and logging are set up
+        Some(lazytables::cli::Commands::Query(_))
+        | Some(lazytables::cli::Commands::Export(_))
+        | Some(lazytables::cli::Commands::Seed(_))
+        | None => {}
+    }
 
     // Load configuration
-    let config = Config::load(cli.config)
+    let mut config = Config::load(cli.config.clone())
         .map_err(|e| color_eyre::eyre::eyre!("Failed to load config: {}", e))?;
+    if cli.no_ping {
+        config.app.ping_on_startup = false;
+    }
+    if cli.trace_sql {
+        config.logging.trace_sql = true;
+    }
+
+    // Initialize logging - the --log-level flag wins over the config file
+    let log_spec = lazytables::logging::LogSpec::resolve(
+        cli.log_level.as_deref(),
+        config.logging.level.as_deref(),
+    )
+    .map_err(|e| color_eyre::eyre::eyre!("{}", e))?;
+    lazytables::logging::init(log_spec.as_ref(), &config.logging)
+        .map_err(|e| color_eyre::eyre::eyre!("Failed to init logging: {}", e))?;
+
+    // Headless subcommands run to completion without starting the TUI
+    let headless_result = match &cli.command {
+        Some(lazytables::cli::Commands::Query(args)) => Some(args.execute(&config).await),
+        Some(lazytables::cli::Commands::Export(args)) => Some(args.execute(&config).await),
+        Some(lazytables::cli::Commands::Seed(args)) => Some(args.execute(&config).await),
+        _ => None,
+    };
+    if let Some(result) = headless_result {
+        lazytables::logging::flush();
+        if let Err(e) = result {
+            eprintln!(
+                "Error: {}",
+                lazytables::core::redact::scrub_secrets(&e.to_string())
+            );
+            std::process::exit(1);
+        }
+        return Ok(());
+    }
+
+    // Debug metrics endpoint, bound before the TUI starts so a taken port
+    // is reported on the console
+    if let Some(addr) = cli.metrics_addr.as_deref() {
+        let addr = lazytables::core::metrics::parse_addr(addr)
+            .map_err(|e| color_eyre::eyre::eyre!("{}", e))?;
+        let listener = tokio::net::TcpListener::bind(addr).await.map_err(|e| {
+            color_eyre::eyre::eyre!("Failed to bind metrics endpoint {}: {}", addr, e)
+        })?;
+        lazytables::core::metrics::enable();
+        lazytables::log_info!("Serving metrics at http://{}/metrics", addr);
+        tokio::spawn(lazytables::core::metrics::serve(listener));
+    }
 
     // Initialize terminal
     let terminal = lazytables::terminal::init()
@@ -51,5 +118,11 @@ async fn main() -> color_eyre::Result<()> {
     lazytables::terminal::restore()
         .map_err(|e| color_eyre::eyre::eyre!("Failed to restore terminal: {}", e))?;
 
+    if result.is_err() {
+        if let Some(log_file) = lazytables::logging::log_file_path() {
+            eprintln!("See {} for details", log_file.display());
+        }
+    }
+
     result
 }
@@ -3,12 +3,16 @@
 #![forbid(unsafe_code)]
 
 use crate::{
+    app::session_events::{SessionEvent, SessionSubscriber},
     database::{
         connection::{Connection, ConnectionStorage},
-        ConnectionConfig, ConnectionStatus, DatabaseObjectList, DatabaseType, TableMetadata,
+        preview,
+        result::{self, CellReader},
+        transaction, undo, CellValue, ConnectionConfig, ConnectionStatus, DatabaseObjectList,
+        DatabaseType, Paging, TableMetadata,
     },
     ui::components::{
-        table_viewer::{CellUpdate, ColumnInfo, DeleteConfirmation, SetNullConfirmation},
+        table_viewer::{CellUpdate, ColumnInfo, DeleteConfirmation, SetNullConfirmation, TableTab},
         TableViewerState,
     },
 };
@@ -30,13 +34,45 @@ pub struct DatabaseState {
     pub table_load_error: Option<String>,
     /// Current table metadata (for the details pane)
     pub current_table_metadata: Option<TableMetadata>,
+    /// Why the saved connections couldn't be read at startup
+    pub connections_error: Option<String>,
 }
 
 impl DatabaseState {
     /// Create a new database state
     pub async fn new() -> Self {
-        // Load connections asynchronously
-        let connections = ConnectionStorage::load().await.unwrap_or_default();
+        // Load connections asynchronously. A file that can't be read is
+        // moved aside rather than overwritten by the next save
+        let (connections, connections_error) = match ConnectionStorage::load().await {
+            Ok(connections) => (connections, None),
+            Err(e) => {
+                crate::log_error!("Failed to read saved connections: {}", e);
+                let path = crate::config::Config::connections_path();
+                let mut connections = ConnectionStorage::default();
+                let error = match ConnectionStorage::set_aside(&path).await {
+                    Ok(aside) => format!(
+                        "Couldn't read saved connections ({e}); kept them in {}",
+                        aside.display()
+                    ),
+                    // Saving would replace the file, so it's left alone
+                    // until it's fixed or moved
+                    Err(aside_error) => {
+                        crate::log_error!(
+                            "Failed to set aside {}: {}",
+                            path.display(),
+                            aside_error
+                        );
+                        let error = format!(
+                            "Couldn't read saved connections ({e}) or move {} aside; connections won't be saved until it's fixed or moved",
+                            path.display()
+                        );
+                        connections.save_blocked = Some(error.clone());
+                        error
+                    }
+                };
+                (connections, Some(error))
+            }
+        };
 
         Self {
             connections,
@@ -46,6 +82,7 @@ impl DatabaseState {
             selected_schema: None,
             table_load_error: None,
             current_table_metadata: None,
+            connections_error,
         }
     }
 
@@ -72,25 +109,16 @@ impl DatabaseState {
             {
                 match &connection.status {
                     ConnectionStatus::Connected => {
-                        // Load table data based on database type
-                        match connection.database_type {
-                            DatabaseType::PostgreSQL => {
-                                self.load_postgres_table_data(
-                                    &connection,
-                                    &table_name,
-                                    limit,
-                                    offset,
-                                    table_viewer_state,
-                                    tab_idx,
-                                    connection_manager,
-                                )
-                                .await
-                            }
-                            _ => Err(format!(
-                                "Database type {} not yet supported for table viewing",
-                                connection.database_type.display_name()
-                            )),
-                        }
+                        self.load_table_page(
+                            &connection,
+                            &table_name,
+                            limit,
+                            offset,
+                            table_viewer_state,
+                            tab_idx,
+                            connection_manager,
+                        )
+                        .await
                     }
                     ConnectionStatus::Connecting => {
                         Err("Connection is still in progress".to_string())
@@ -106,9 +134,10 @@ impl DatabaseState {
         }
     }
 
-    /// Load PostgreSQL table data using persistent ConnectionManager
+    /// Load a page of table data through the connection's adapter in the
+    /// persistent ConnectionManager
     #[allow(clippy::too_many_arguments)]
-    async fn load_postgres_table_data(
+    async fn load_table_page(
         &mut self,
         connection: &ConnectionConfig,
         table_name: &str,
@@ -136,22 +165,60 @@ impl DatabaseState {
             table_name
         );
 
-        // Get total row count using raw query
-        let count_query = format!("SELECT COUNT(*) FROM {table_name}");
-        let (_, count_rows) = connection_manager
-            .execute_raw_query(&connection.id, &count_query)
-            .await
-            .map_err(|e| format!("Failed to get row count: {e}"))?;
-
-        let total_rows = count_rows
-            .first()
-            .and_then(|row| row.first())
-            .and_then(|count_str| count_str.parse::<usize>().ok())
-            .unwrap_or(0);
+        let (filters, sort) = table_viewer_state
+            .tabs
+            .get(tab_idx)
+            .map(|tab| (tab.filters.clone(), tab.sort.clone()))
+            .unwrap_or_default();
+
+        // Turning the page reads the same rows, so their count still holds
+        let known_rows = table_viewer_state
+            .tabs
+            .get_mut(tab_idx)
+            .filter(|tab| std::mem::take(&mut tab.reuse_row_count))
+            .map(|tab| (tab.total_rows, tab.total_rows_estimated));
+
+        // Size big tables from the planner's estimate; counting every row
+        // would take longer than reading the page. The estimate is of the
+        // whole table, so filtered rows are always counted
+        let estimated_rows = if let Some((rows, estimated)) = known_rows {
+            estimated.then_some(rows)
+        } else if filters.is_empty() {
+            Self::estimate_row_count(connection, table_name, connection_manager)
+                .await
+                .filter(|&rows| rows >= preview::ESTIMATED_ROWS_FROM)
+        } else {
+            None
+        };
+
+        let total_rows = match (estimated_rows, known_rows) {
+            (Some(rows), _) | (None, Some((rows, _))) => rows,
+            (None, None) => connection_manager
+                .get_table_row_count(&connection.id, table_name, &filters)
+                .await
+                .map_err(|e| format!("Failed to get row count: {e}"))?,
+        };
+
+        // Page by the primary key when it's a single column, which makes it
+        // unique and indexed; OFFSET otherwise, and in a chosen sort order
+        let mut primary_key = columns.iter().filter(|col| col.is_primary_key);
+        let paging = match (sort, primary_key.next(), primary_key.next()) {
+            (Some(sort), _, _) => Paging::Sorted { sort, offset },
+            (None, Some(key), None) => Paging::Keyset {
+                key: key.name.clone(),
+                data_type: key.data_type.to_sql(),
+                bound: table_viewer_state
+                    .tabs
+                    .get(tab_idx)
+                    .map(|tab| tab.page_bound.clone())
+                    .unwrap_or_default(),
+            },
+            (None, _, _) => Paging::Offset(offset),
+        };
 
         // Get table data using persistent connection
         let rows = connection_manager
-            .get_table_data(&connection.id, table_name, limit, offset)
+            .get_table_data(&connection.id, table_name, limit, &paging, &filters)
             .await
             .map_err(|e| format!("Failed to retrieve data: {e}"))?;
 
@@ -161,6 +228,15 @@ impl DatabaseState {
             .await
             .ok(); // Don't fail if metadata can't be loaded
 
+        // Partitions for the structure view; most tables have none
+        let partitions = connection_manager
+            .get_partitions(&connection.id, table_name)
+            .await
+            .unwrap_or_else(|e| {
+                crate::log_warn!("Failed to list partitions of {}: {}", table_name, e);
+                Vec::new()
+            });
+
         // Update the tab with loaded data
         if let Some(tab) = table_viewer_state.tabs.get_mut(tab_idx) {
             // Convert columns to ColumnInfo
@@ -172,6 +248,11 @@ impl DatabaseState {
                     is_nullable: col.is_nullable,
                     is_primary_key: col.is_primary_key,
                     max_display_width: col.name.len().max(15),
+                    enum_labels: col.data_type.enum_labels().to_vec(),
+                    is_auto_increment: col.is_auto_increment,
+                    generated: col.generated.clone(),
+                    default_value: col.default_value.clone(),
+                    comment: col.comment.clone(),
                 })
                 .collect();
 
@@ -186,16 +267,214 @@ impl DatabaseState {
                 .collect();
 
             tab.rows = rows;
-            tab.total_rows = total_rows;
+            tab.full_cell_values.clear();
+            tab.clear_marks();
+            tab.paging = Some(paging);
+            tab.set_total_rows(total_rows, estimated_rows.is_some(), offset);
             tab.loading = false;
             tab.error = None;
             tab.table_metadata = metadata;
+            tab.set_partitions(partitions);
         }
 
         // Connection is kept alive by ConnectionManager
         Ok(())
     }
 
+    /// The planner's estimate of a table's row count, where the database
+    /// keeps one and the table has been analyzed
+    async fn estimate_row_count(
+        connection: &ConnectionConfig,
+        table_name: &str,
+        connection_manager: &crate::database::ConnectionManager,
+    ) -> Option<usize> {
+        let query = preview::row_estimate_query(&connection.database_type, table_name)?;
+        let (_, rows) = connection_manager
+            .execute_metadata_query(&connection.id, "table_viewer", &query)
+            .await
+            .map_err(|e| crate::log_warn!("Failed to estimate rows of {}: {}", table_name, e))
+            .ok()?;
+        // Negative until the table is first analyzed
+        rows.first()?.first()?.parse::<i64>().ok()?.try_into().ok()
+    }
+
+    /// Read the full values of cells of the selected row that were cut
+    /// short by the cell limit - the selected cell, or the whole row - by
+    /// the row's primary key in a table preview, or by running a query
+    /// result's query again
+    pub async fn load_full_cell_values(
+        &self,
+        table_viewer_state: &mut TableViewerState,
+        selected_connection: usize,
+        connection_manager: &crate::database::ConnectionManager,
+        whole_row: bool,
+    ) -> Result<(), String> {
+        let Some(tab) = table_viewer_state.current_tab_mut() else {
+            return Ok(());
+        };
+        let row = tab.selected_row;
+        let cut = tab.unread_partial_cells(row, (!whole_row).then_some(tab.selected_col));
+        if cut.is_empty() {
+            return Ok(());
+        }
+        if !tab.is_table_preview() {
+            let cells = cut.into_iter().map(|col| (row, col)).collect();
+            return Self::read_query_cells(tab, connection_manager, cells).await;
+        }
+        let connection = self.full_values_connection(tab, selected_connection)?;
+        Self::read_full_values(tab, connection, connection_manager, row, cut).await
+    }
+
+    /// Read the full values of every loaded row's cells cut short by the
+    /// cell limit, one query per row that has any in a table preview and a
+    /// single run of a query result's query
+    pub async fn load_all_full_cell_values(
+        &self,
+        table_viewer_state: &mut TableViewerState,
+        selected_connection: usize,
+        connection_manager: &crate::database::ConnectionManager,
+    ) -> Result<(), String> {
+        let Some(tab) = table_viewer_state.current_tab_mut() else {
+            return Ok(());
+        };
+        if !tab.is_table_preview() {
+            let cells: Vec<(usize, usize)> = (0..tab.rows.len())
+                .flat_map(|row| {
+                    let cut = tab.unread_partial_cells(row, None);
+                    cut.into_iter().map(move |col| (row, col))
+                })
+                .collect();
+            if cells.is_empty() {
+                return Ok(());
+            }
+            return Self::read_query_cells(tab, connection_manager, cells).await;
+        }
+        for row in 0..tab.rows.len() {
+            let cut = tab.unread_partial_cells(row, None);
+            if cut.is_empty() {
+                continue;
+            }
+            let connection = self.full_values_connection(tab, selected_connection)?;
+            Self::read_full_values(tab, connection, connection_manager, row, cut).await?;
+        }
+        Ok(())
+    }
+
+    /// The connection to read a tab's cut short values from by primary key
+    fn full_values_connection(
+        &self,
+        tab: &TableTab,
+        selected_connection: usize,
+    ) -> Result<&ConnectionConfig, String> {
+        if tab.primary_key_columns.is_empty() {
+            return Err(
+                "Value is cut short in the preview and the table has no primary key to read it in full by"
+                    .to_string(),
+            );
+        }
+        self.connections
+            .connections
+            .get(selected_connection)
+            .filter(|connection| matches!(connection.status, ConnectionStatus::Connected))
+            .ok_or_else(|| "No active database connection".to_string())
+    }
+
+    /// Read the `cut` columns of `row` by the row's primary key
+    async fn read_full_values(
+        tab: &mut TableTab,
+        connection: &ConnectionConfig,
+        connection_manager: &crate::database::ConnectionManager,
+        row: usize,
+        cut: Vec<usize>,
+    ) -> Result<(), String> {
+        let columns: Vec<&str> = cut
+            .iter()
+            .filter_map(|&col| tab.columns.get(col).map(|column| column.name.as_str()))
+            .collect();
+        let key: Vec<(&str, &str, &str)> = tab
+            .primary_key_columns
+            .iter()
+            .filter_map(|&col| {
+                let column = tab.columns.get(col)?;
+                let value = tab.rows.get(row)?.get(col)?;
+                Some((
+                    column.name.as_str(),
+                    column.data_type.as_str(),
+                    value.as_str(),
+                ))
+            })
+            .collect();
+        let query =
+            preview::full_values_query(&connection.database_type, &tab.table_name, &columns, &key);
+
+        let (_, rows) = connection_manager
+            .execute_metadata_query(&connection.id, "full_values", &query)
+            .await
+            .map_err(|e| format!("Failed to read full value: {e}"))?;
+        let values = rows
+            .into_iter()
+            .next()
+            .ok_or_else(|| "Row no longer exists; reload the table".to_string())?;
+        for (col, value) in cut.into_iter().zip(values) {
+            tab.full_cell_values.insert((row, col), value);
+        }
+        Ok(())
+    }
+
+    /// Read `cells` of a query result, cut short by the cell size limit, by
+    /// running its query again on the connection it ran on. Only a single
+    /// read-only statement is run again, and a value that doesn't start
+    /// with what the grid shows means the rows changed since
+    async fn read_query_cells(
+        tab: &mut TableTab,
+        connection_manager: &crate::database::ConnectionManager,
+        cells: Vec<(usize, usize)>,
+    ) -> Result<(), String> {
+        let (Some(query), Some(connection_id)) =
+            (tab.query.as_deref(), tab.query_connection.as_deref())
+        else {
+            return Err(
+                "Value is cut short and there is no query to read it again from".to_string(),
+            );
+        };
+        if tab.statement.is_some() {
+            return Err(
+                "Value is cut short; run its statement on its own to read it in full".to_string(),
+            );
+        }
+        if !transaction::is_read_only(query) {
+            return Err(
+                "Value is cut short and the query isn't read-only, so it isn't run again to read it in full"
+                    .to_string(),
+            );
+        }
+
+        let mut reader = CellReader::new(tab.result_set, cells.iter().copied());
+        connection_manager
+            .stream_raw_query(connection_id, query, &mut reader)
+            .await
+            .map_err(|e| format!("Failed to read full value: {e}"))?;
+        let mut values = reader.finish();
+        for cell in cells {
+            let shown = tab
+                .rows
+                .get(cell.0)
+                .and_then(|row| row.get(cell.1))
+                .map(|value| result::kept_part(value))
+                .unwrap_or_default();
+            match values.remove(&cell) {
+                Some(value) if value.starts_with(shown) => {
+                    tab.full_cell_values.insert(cell, value);
+                }
+                _ => return Err(
+                    "The query's rows changed since it ran; run it again to read the value in full"
+                        .to_string(),
+                ),
+            }
+        }
+        Ok(())
+    }
+
     /// Load table metadata for the details pane using persistent ConnectionManager
     pub async fn load_table_metadata(
         &mut self,
@@ -212,29 +491,20 @@ impl DatabaseState {
         {
             match &connection.status {
                 ConnectionStatus::Connected => {
-                    // Load metadata based on database type
-                    match connection.database_type {
-                        DatabaseType::PostgreSQL => {
-                            // Ensure we have a persistent connection
-                            connection_manager
-                                .connect(&connection)
-                                .await
-                                .map_err(|e| format!("Failed to ensure connection: {e}"))?;
-
-                            // Get table metadata using persistent connection
-                            let metadata = connection_manager
-                                .get_table_metadata(&connection.id, table_name)
-                                .await
-                                .map_err(|e| format!("Failed to retrieve metadata: {e}"))?;
-
-                            self.current_table_metadata = Some(metadata);
-                            Ok(())
-                        }
-                        _ => Err(format!(
-                            "Database type {} not yet supported for metadata",
-                            connection.database_type.display_name()
-                        )),
-                    }
+                    // Ensure we have a persistent connection
+                    connection_manager
+                        .connect(&connection)
+                        .await
+                        .map_err(|e| format!("Failed to ensure connection: {e}"))?;
+
+                    // Get table metadata using persistent connection
+                    let metadata = connection_manager
+                        .get_table_metadata(&connection.id, table_name)
+                        .await
+                        .map_err(|e| format!("Failed to retrieve metadata: {e}"))?;
+
+                    self.current_table_metadata = Some(metadata);
+                    Ok(())
                 }
                 _ => Err("No active database connection".to_string()),
             }
@@ -291,23 +561,19 @@ impl DatabaseState {
             .await
             .map_err(|e| format!("Failed to ensure connection: {e}"))?;
 
-        // Build UPDATE SQL
-        let mut where_clauses = Vec::new();
-        for (pk_col, pk_val) in &update.primary_key_values {
-            where_clauses.push(format!("{pk_col} = '{pk_val}'"));
-        }
-
-        if where_clauses.is_empty() {
-            return Err("Cannot update row without primary key".to_string());
-        }
-
-        let sql = format!(
-            "UPDATE {} SET {} = '{}' WHERE {}",
-            update.table_name,
-            update.column_name,
-            update.new_value.replace("'", "''"), // Escape single quotes
-            where_clauses.join(" AND ")
-        );
+        // Typed text is written as it is; only set-NULL writes NULL
+        let value = CellValue {
+            column: update.column_name,
+            data_type: update.data_type,
+            value: Some(update.new_value),
+        };
+        let sql = undo::set_cell(
+            &connection.database_type,
+            &update.table_name,
+            &update.primary_key,
+            &value,
+        )
+        .ok_or_else(|| "Cannot update row without primary key".to_string())?;
 
         // Execute the SQL update using persistent connection
         connection_manager
@@ -405,21 +671,12 @@ impl DatabaseState {
             .await
             .map_err(|e| format!("Failed to ensure connection: {e}"))?;
 
-        // Build DELETE SQL
-        let mut where_clauses = Vec::new();
-        for (pk_col, pk_val) in &confirmation.primary_key_values {
-            where_clauses.push(format!("{pk_col} = '{pk_val}'"));
-        }
-
-        if where_clauses.is_empty() {
-            return Err("Cannot delete row without primary key".to_string());
-        }
-
-        let sql = format!(
-            "DELETE FROM {} WHERE {}",
-            confirmation.table_name,
-            where_clauses.join(" AND ")
-        );
+        let sql = undo::delete_row(
+            &connection.database_type,
+            &confirmation.table_name,
+            &confirmation.primary_key,
+        )
+        .ok_or_else(|| "Cannot delete row without primary key".to_string())?;
 
         // Execute the delete query using persistent connection
         connection_manager
@@ -443,22 +700,18 @@ impl DatabaseState {
             .await
             .map_err(|e| format!("Failed to ensure connection: {e}"))?;
 
-        // Build UPDATE SQL to set NULL
-        let mut where_clauses = Vec::new();
-        for (pk_col, pk_val) in &confirmation.primary_key_values {
-            where_clauses.push(format!("{pk_col} = '{pk_val}'"));
-        }
-
-        if where_clauses.is_empty() {
-            return Err("Cannot update cell without primary key".to_string());
-        }
-
-        let sql = format!(
-            "UPDATE {} SET {} = NULL WHERE {}",
-            confirmation.table_name,
-            confirmation.column_name,
-            where_clauses.join(" AND ")
-        );
+        let null = CellValue {
+            column: confirmation.column_name,
+            data_type: String::new(),
+            value: None,
+        };
+        let sql = undo::set_cell(
+            &connection.database_type,
+            &confirmation.table_name,
+            &confirmation.primary_key,
+            &null,
+        )
+        .ok_or_else(|| "Cannot update cell without primary key".to_string())?;
 
         // Execute the update query using persistent connection
         connection_manager
@@ -531,6 +784,7 @@ impl DatabaseState {
         &mut self,
         connection: &ConnectionConfig,
         connection_manager: &crate::database::ConnectionManager,
+        include_system: bool,
     ) -> Result<DatabaseObjectList, String> {
         // Query database objects based on database type
         match connection.database_type {
@@ -543,42 +797,22 @@ impl DatabaseState {
 
                 // Get database objects using persistent connection
                 let objects = connection_manager
-                    .list_database_objects(&connection.id)
+                    .list_database_objects(&connection.id, include_system)
                     .await
                     .map_err(|e| format!("Failed to retrieve database objects: {e}"))?;
 
                 self.database_objects = Some(objects.clone());
 
-                // Update legacy tables list with qualified names for non-public schemas
+                // Update legacy tables list, qualifying names outside the
+                // default schema
                 self.tables = objects
                     .tables
                     .iter()
-                    .map(|t| {
-                        if t.schema.as_deref() == Some("public") || t.schema.is_none() {
-                            t.name.clone()
-                        } else {
-                            t.qualified_name()
-                        }
-                    })
+                    .chain(&objects.views)
+                    .chain(&objects.materialized_views)
+                    .map(|t| objects.open_name(t))
                     .collect();
 
-                // Also add views and materialized views to the tables list
-                for view in &objects.views {
-                    if view.schema.as_deref() == Some("public") || view.schema.is_none() {
-                        self.tables.push(view.name.clone());
-                    } else {
-                        self.tables.push(view.qualified_name());
-                    }
-                }
-
-                for mat_view in &objects.materialized_views {
-                    if mat_view.schema.as_deref() == Some("public") || mat_view.schema.is_none() {
-                        self.tables.push(mat_view.name.clone());
-                    } else {
-                        self.tables.push(mat_view.qualified_name());
-                    }
-                }
-
                 Ok(objects)
             }
             DatabaseType::MySQL | DatabaseType::MariaDB => {
@@ -606,6 +840,7 @@ impl DatabaseState {
                         row_count: None,
                         size_bytes: None,
                         comment: None,
+                        system: false,
                     });
                 }
                 objects.total_count = objects.tables.len();
@@ -640,6 +875,7 @@ impl DatabaseState {
                         row_count: None,
                         size_bytes: None,
                         comment: None,
+                        system: false,
                     });
                 }
                 objects.total_count = objects.tables.len();
@@ -656,3 +892,13 @@ impl DatabaseState {
         }
     }
 }
+
+impl SessionSubscriber for DatabaseState {
+    fn on_session_event(&mut self, event: &SessionEvent) {
+        // The details pane loads the metadata of the new table once the
+        // selection rests
+        if let SessionEvent::TableChanged { .. } = event {
+            self.current_table_metadata = None;
+        }
+    }
+}
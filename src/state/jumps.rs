@@ -0,0 +1,112 @@
+// FilePath: src/state/jumps.rs
+
+//! History of the tables opened, to go back and forward through like vim's
+//! jumplist
+
+#![forbid(unsafe_code)]
+
+/// Tables kept in the history
+const MAX_JUMPS: usize = 100;
+
+/// A table opened on a connection
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct Jump {
+    pub connection_id: String,
+    /// Qualified name of the table, as listed in the tables pane
+    pub table_name: String,
+}
+
+/// The tables opened, oldest first, and where going back and forward
+/// through them has got to
+#[derive(Debug, Clone, Default)]
+pub struct JumpList {
+    jumps: Vec<Jump>,
+    position: usize,
+}
+
+impl JumpList {
+    /// Record a table being opened. Tables gone forward to and back from
+    /// are dropped, as in a browser's history
+    pub fn record(&mut self, jump: Jump) {
+        if self.jumps.get(self.position) == Some(&jump) {
+            return;
+        }
+        self.jumps.truncate(self.position + 1);
+        self.jumps.push(jump);
+        if self.jumps.len() > MAX_JUMPS {
+            self.jumps.remove(0);
+        }
+        self.position = self.jumps.len() - 1;
+    }
+
+    /// The table opened before the current one
+    pub fn back(&mut self) -> Option<&Jump> {
+        if self.position == 0 {
+            return None;
+        }
+        self.position -= 1;
+        self.jumps.get(self.position)
+    }
+
+    /// The table opened after the current one, once gone back
+    pub fn forward(&mut self) -> Option<&Jump> {
+        if self.position + 1 >= self.jumps.len() {
+            return None;
+        }
+        self.position += 1;
+        self.jumps.get(self.position)
+    }
+
+    /// Position of the current table and the number of tables, for display
+    pub fn progress(&self) -> (usize, usize) {
+        (self.position + 1, self.jumps.len())
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn jump(table_name: &str) -> Jump {
+        Jump {
+            connection_id: "local".to_string(),
+            table_name: table_name.to_string(),
+        }
+    }
+
+    #[test]
+    fn test_back_and_forward() {
+        let mut jumps = JumpList::default();
+        assert_eq!(jumps.back(), None);
+        jumps.record(jump("users"));
+        jumps.record(jump("orders"));
+        jumps.record(jump("orders"));
+        jumps.record(jump("items"));
+        assert_eq!(jumps.progress(), (3, 3));
+
+        assert_eq!(jumps.back(), Some(&jump("orders")));
+        assert_eq!(jumps.back(), Some(&jump("users")));
+        assert_eq!(jumps.back(), None);
+        assert_eq!(jumps.forward(), Some(&jump("orders")));
+
+        // Opening another table drops the ones gone back from
+        jumps.record(jump("invoices"));
+        assert_eq!(jumps.forward(), None);
+        assert_eq!(jumps.back(), Some(&jump("orders")));
+        assert_eq!(jumps.progress(), (2, 3));
+    }
+
+    #[test]
+    fn test_history_is_capped() {
+        let mut jumps = JumpList::default();
+        for n in 0..MAX_JUMPS + 5 {
+            jumps.record(jump(&format!("t{n}")));
+        }
+        assert_eq!(jumps.progress(), (MAX_JUMPS, MAX_JUMPS));
+        for _ in 1..MAX_JUMPS {
+            jumps.back();
+        }
+        assert_eq!(jumps.back(), None);
+        assert_eq!(jumps.forward(), Some(&jump("t6")));
+    }
+}
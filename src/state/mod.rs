@@ -3,6 +3,7 @@
 #![forbid(unsafe_code)]
 
 pub mod database;
+pub mod jumps;
 pub mod ui;
 pub mod view;
 
@@ -2,11 +2,39 @@
 
 #![forbid(unsafe_code)]
 
+use crate::app::session_events::{SessionEvent, SessionSubscriber};
 use ratatui::widgets::ListState;
 use serde::{Deserialize, Serialize};
+use std::collections::HashMap;
 use std::fs;
 use std::path::PathBuf;
 
+/// How many recently opened tables are remembered per connection
+pub const RECENT_TABLES_PER_CONNECTION: usize = 10;
+
+/// Name for a saved SQL file from what was typed, a trailing `.sql`
+/// dropped; names that could leave the connection's directory or hide the
+/// file are refused
+pub fn sql_file_name(input: &str) -> Result<String, String> {
+    let name = input.trim();
+    let name = name.strip_suffix(".sql").unwrap_or(name).trim_end();
+    if name.is_empty() {
+        return Err("The file needs a name".to_string());
+    }
+    if name.starts_with('.') {
+        return Err(format!("'{name}' can't start with a dot"));
+    }
+    if let Some(c) = name
+        .chars()
+        .find(|c| !(c.is_alphanumeric() || matches!(c, '_' | '-' | '.' | ' ')))
+    {
+        return Err(format!(
+            "'{name}' can't contain '{c}'; use letters, digits, spaces, '-', '_' and '.'"
+        ));
+    }
+    Ok(name.to_string())
+}
+
 /// Check if a string contains all characters from query in sequence
 fn matches_sequence(text: &str, query: &str) -> bool {
     if query.is_empty() {
@@ -45,6 +73,8 @@ pub struct SelectableTableItem {
     pub is_selectable: bool,
     /// The index of this item in the display list
     pub display_index: usize,
+    /// System schema or table, drawn dimmed
+    pub is_system: bool,
 }
 
 impl SelectableTableItem {
@@ -63,9 +93,16 @@ impl SelectableTableItem {
             object_type,
             is_selectable: true,
             display_index,
+            is_system: false,
         }
     }
 
+    /// Mark the item as a system schema's object or a system table
+    pub fn system(mut self, is_system: bool) -> Self {
+        self.is_system = is_system;
+        self
+    }
+
     /// Create a non-selectable header item
     pub fn new_header(display_name: String, display_index: usize) -> Self {
         Self {
@@ -75,7 +112,19 @@ impl SelectableTableItem {
             object_type: crate::database::objects::DatabaseObjectType::Table,
             is_selectable: false,
             display_index,
+            is_system: false,
+        }
+    }
+
+    /// What identifies the item across rebuilds of the list: an object by
+    /// schema and name, a group header by its name without the arrow. None
+    /// for the blank lines between groups
+    pub fn key(&self) -> Option<(String, Option<String>)> {
+        if self.is_selectable {
+            return Some((self.object_name.clone(), self.schema.clone()));
         }
+        let group = self.display_name.trim_start_matches(['▼', '▶']).trim();
+        (!group.is_empty()).then(|| (group.to_string(), None))
     }
 
     /// Get the qualified name for database operations
@@ -217,6 +266,13 @@ pub struct UIState {
     pub help_left_scroll_offset: usize,
     /// Vertical scroll offset for right help pane
     pub help_right_scroll_offset: usize,
+    /// Filter over the commands of every scope; the help lists the matching
+    /// ones instead of its two columns while it's set
+    #[serde(skip)]
+    pub help_filter: String,
+    /// Whether keys are typed into the help filter
+    #[serde(skip)]
+    pub help_filter_active: bool,
 
     // Selection indices
     /// Selected connection index
@@ -280,9 +336,43 @@ pub struct UIState {
     pub tables_search_active: bool,
     /// Current search query for tables
     pub tables_search_query: String,
+    /// Item selected when the search started, selected again when it ends
+    /// without a match picked
+    #[serde(skip)]
+    tables_search_origin: Option<(String, Option<String>)>,
     /// Filtered table items based on search
     #[serde(skip)]
     pub filtered_table_items: Vec<SelectableTableItem>,
+    /// Whether the column search prompt is open in the tables pane
+    #[serde(skip)]
+    pub column_search_active: bool,
+    /// Column name being searched for across the database
+    #[serde(skip)]
+    pub column_search_query: String,
+    /// Database name being typed for the selected connection, for a user
+    /// who can't list what they may use
+    #[serde(skip)]
+    pub database_prompt: Option<String>,
+    /// Whether the tables pane lists system schemas and tables too; starts
+    /// from `[app] show_system_objects`
+    #[serde(skip)]
+    pub show_system_objects: bool,
+    /// Whether each pane shows a line of its main keys under it; starts from
+    /// `[app] key_hints`
+    #[serde(skip)]
+    pub show_key_hints: bool,
+    /// Whether confirming a selection moves focus on to the next pane of the
+    /// drill-down; from `[app] auto_advance_focus`
+    #[serde(skip)]
+    pub auto_advance_focus: bool,
+    /// Whether text inputs take Emacs-style editing keys; from
+    /// `[app] readline_keys`
+    #[serde(skip)]
+    pub readline_keys: bool,
+    /// Whether the left column is the panes or the object tree; from
+    /// `[ui] sidebar_mode`
+    #[serde(skip)]
+    pub sidebar_mode: crate::config::SidebarMode,
 
     // Vim navigation state
     /// Whether 'g' key was pressed and we're waiting for the second 'g' for gg command
@@ -312,6 +402,24 @@ pub struct UIState {
     /// New file name buffer during creation
     pub sql_files_create_buffer: String,
 
+    /// Tables opened most recently per connection id, newest first; their
+    /// columns are fetched ahead of time for query editor suggestions
+    #[serde(default)]
+    pub recent_tables: HashMap<String, Vec<String>>,
+    /// Tables opened this session, gone back and forward through with
+    /// Ctrl+O and Ctrl+I
+    #[serde(skip)]
+    pub jumps: crate::state::jumps::JumpList,
+    /// Whether the status bar shows the date and time, and in which
+    /// strftime format; from `[app] show_clock` and `clock_format`
+    #[serde(skip)]
+    pub show_clock: bool,
+    #[serde(skip)]
+    pub clock_format: String,
+    /// Operation selected in the background tasks overlay
+    #[serde(skip)]
+    pub tasks_selected: usize,
+
     // List UI states (not serialized)
     #[serde(skip)]
     pub connections_list_state: ListState,
@@ -333,6 +441,8 @@ impl UIState {
             help_pane_focus: HelpPaneFocus::Left,
             help_left_scroll_offset: 0,
             help_right_scroll_offset: 0,
+            help_filter: String::new(),
+            help_filter_active: false,
             selected_connection: 0,
             selected_table: 0,
             selected_sql_file: 0,
@@ -362,7 +472,16 @@ impl UIState {
             selected_table_item_index: 0,
             tables_search_active: false,
             tables_search_query: String::new(),
+            tables_search_origin: None,
             filtered_table_items: Vec::new(),
+            column_search_active: false,
+            column_search_query: String::new(),
+            database_prompt: None,
+            show_system_objects: false,
+            show_key_hints: true,
+            auto_advance_focus: false,
+            readline_keys: true,
+            sidebar_mode: crate::config::SidebarMode::Panels,
             pending_gg_command: false,
             connections_search_active: false,
             connections_search_query: String::new(),
@@ -373,11 +492,36 @@ impl UIState {
             sql_files_rename_buffer: String::new(),
             sql_files_create_mode: false,
             sql_files_create_buffer: String::new(),
+            recent_tables: HashMap::new(),
+            jumps: crate::state::jumps::JumpList::default(),
+            show_clock: true,
+            clock_format: "%b %d, %Y  %H:%M:%S".to_string(),
+            tasks_selected: 0,
             connections_list_state,
             tables_list_state: ListState::default(),
         }
     }
 
+    /// Remember a table as opened on a connection, moving it to the front of
+    /// that connection's recent tables
+    pub fn record_recent_table(&mut self, connection_id: &str, table_name: &str) {
+        let recent = self
+            .recent_tables
+            .entry(connection_id.to_string())
+            .or_default();
+        recent.retain(|name| name != table_name);
+        recent.insert(0, table_name.to_string());
+        recent.truncate(RECENT_TABLES_PER_CONNECTION);
+    }
+
+    /// Tables opened most recently on a connection, newest first
+    pub fn recent_tables_for(&self, connection_id: &str) -> &[String] {
+        self.recent_tables
+            .get(connection_id)
+            .map(Vec::as_slice)
+            .unwrap_or_default()
+    }
+
     /// Save UI state to disk
     pub fn save(&self) -> Result<(), Box<dyn std::error::Error>> {
         let state_file = Self::state_file_path()?;
@@ -417,102 +561,37 @@ impl UIState {
 
     /// Get the path to the UI state file
     fn state_file_path() -> Result<PathBuf, Box<dyn std::error::Error>> {
-        let config_dir = dirs::config_dir()
-            .ok_or("Could not find config directory")?
-            .join("lazytables");
+        let config_dir = crate::config::Config::config_dir();
 
         fs::create_dir_all(&config_dir)?;
         Ok(config_dir.join("ui_state.json"))
     }
 
-    /// Cycle focus to the next pane (connection-aware)
-    pub fn cycle_focus_forward(
+    /// Move focus to the next (or previous) pane of `order` that `enabled`
+    /// lets take focus. From a pane left out of the order, focus goes to its
+    /// first (or last) pane
+    pub fn cycle_focus(
         &mut self,
-        sql_panes_enabled: bool,
-        query_editor_enabled: bool,
-        tables_enabled: bool,
-        details_enabled: bool,
-        query_results_enabled: bool,
+        order: &[FocusedPane],
+        forward: bool,
+        enabled: impl Fn(FocusedPane) -> bool,
     ) {
-        let mut new_pane = self.focused_pane.next();
-        let start_pane = new_pane;
-        let mut iterations = 0;
-        let max_iterations = 10; // Safety limit
-
-        // Skip disabled panes
-        loop {
-            iterations += 1;
-            if iterations > max_iterations {
-                break;
-            }
-
-            let should_skip = match new_pane {
-                FocusedPane::Tables => !tables_enabled,
-                FocusedPane::Details => !details_enabled,
-                FocusedPane::TabularOutput => !query_results_enabled,
-                FocusedPane::QueryWindow => !query_editor_enabled,
-                FocusedPane::SqlFiles => !sql_panes_enabled,
-                FocusedPane::Connections => false, // Always enabled
-            };
-
-            if !should_skip {
-                break;
-            }
-
-            new_pane = new_pane.next();
-            if new_pane == start_pane {
-                // Cycled back to start, use current pane instead
-                new_pane = self.focused_pane;
-                break;
-            }
+        let len = order.len();
+        if len == 0 {
+            return;
         }
-
-        self.update_focus(new_pane);
-    }
-
-    /// Cycle focus to the previous pane (connection-aware)
-    pub fn cycle_focus_backward(
-        &mut self,
-        sql_panes_enabled: bool,
-        query_editor_enabled: bool,
-        tables_enabled: bool,
-        details_enabled: bool,
-        query_results_enabled: bool,
-    ) {
-        let mut new_pane = self.focused_pane.previous();
-        let start_pane = new_pane;
-        let mut iterations = 0;
-        let max_iterations = 10; // Safety limit
-
-        // Skip disabled panes
-        loop {
-            iterations += 1;
-            if iterations > max_iterations {
-                break;
-            }
-
-            let should_skip = match new_pane {
-                FocusedPane::Tables => !tables_enabled,
-                FocusedPane::Details => !details_enabled,
-                FocusedPane::TabularOutput => !query_results_enabled,
-                FocusedPane::QueryWindow => !query_editor_enabled,
-                FocusedPane::SqlFiles => !sql_panes_enabled,
-                FocusedPane::Connections => false, // Always enabled
-            };
-
-            if !should_skip {
-                break;
-            }
-
-            new_pane = new_pane.previous();
-            if new_pane == start_pane {
-                // Cycled back to start, use current pane instead
-                new_pane = self.focused_pane;
-                break;
-            }
+        let start = match order.iter().position(|pane| *pane == self.focused_pane) {
+            Some(index) => index,
+            None if forward => len - 1,
+            None => 0,
+        };
+        let step = if forward { 1 } else { len - 1 };
+        let next = (1..=len)
+            .map(|i| order[(start + step * i) % len])
+            .find(|pane| enabled(*pane));
+        if let Some(pane) = next {
+            self.update_focus(pane);
         }
-
-        self.update_focus(new_pane);
     }
 
     /// Move focus left (Ctrl+h) (connection-aware)
@@ -721,6 +800,12 @@ impl UIState {
         }
     }
 
+    /// Select the connection at `index` of the list
+    pub fn select_connection(&mut self, index: usize) {
+        self.selected_connection = index;
+        self.connections_list_state.select(Some(index));
+    }
+
     /// Move connection selection up
     pub fn connection_up(&mut self, max_count: usize) {
         if max_count > 0 {
@@ -814,6 +899,7 @@ impl UIState {
         &mut self,
         db_objects: &Option<crate::database::objects::DatabaseObjectList>,
     ) {
+        let selected = self.selected_table_key();
         self.selectable_table_items.clear();
 
         if let Some(ref objects) = db_objects {
@@ -832,14 +918,16 @@ impl UIState {
 
                 if is_expanded {
                     for table in &objects.tables {
-                        self.selectable_table_items
-                            .push(SelectableTableItem::new_selectable(
-                                format!("  📋 {}", table.name),
+                        self.selectable_table_items.push(
+                            SelectableTableItem::new_selectable(
+                                format!("  📋 {}", objects.listed_name(table)),
                                 table.name.clone(),
                                 table.schema.clone(),
                                 table.object_type.clone(),
                                 display_index,
-                            ));
+                            )
+                            .system(table.is_system()),
+                        );
                         display_index += 1;
                     }
                 }
@@ -867,14 +955,16 @@ impl UIState {
 
                 if is_expanded {
                     for view in &objects.views {
-                        self.selectable_table_items
-                            .push(SelectableTableItem::new_selectable(
-                                format!("  👁️ {}", view.name),
+                        self.selectable_table_items.push(
+                            SelectableTableItem::new_selectable(
+                                format!("  👁️ {}", objects.listed_name(view)),
                                 view.name.clone(),
                                 view.schema.clone(),
                                 view.object_type.clone(),
                                 display_index,
-                            ));
+                            )
+                            .system(view.is_system()),
+                        );
                         display_index += 1;
                     }
                 }
@@ -902,14 +992,16 @@ impl UIState {
 
                 if is_expanded {
                     for mv in &objects.materialized_views {
-                        self.selectable_table_items
-                            .push(SelectableTableItem::new_selectable(
-                                format!("  🔄 {}", mv.name),
+                        self.selectable_table_items.push(
+                            SelectableTableItem::new_selectable(
+                                format!("  🔄 {}", objects.listed_name(mv)),
                                 mv.name.clone(),
                                 mv.schema.clone(),
                                 mv.object_type.clone(),
                                 display_index,
-                            ));
+                            )
+                            .system(mv.is_system()),
+                        );
                         display_index += 1;
                     }
                 }
@@ -937,25 +1029,52 @@ impl UIState {
 
                 if is_expanded {
                     for ft in &objects.foreign_tables {
-                        self.selectable_table_items
-                            .push(SelectableTableItem::new_selectable(
-                                format!("  🔗 {}", ft.name),
+                        self.selectable_table_items.push(
+                            SelectableTableItem::new_selectable(
+                                format!("  🔗 {}", objects.listed_name(ft)),
                                 ft.name.clone(),
                                 ft.schema.clone(),
                                 ft.object_type.clone(),
                                 display_index,
-                            ));
+                            )
+                            .system(ft.is_system()),
+                        );
                         display_index += 1;
                     }
                 }
             }
         }
 
-        // Reset selection to first selectable item
-        self.selected_table_item_index = self.find_first_selectable_index();
+        // Keep the same item selected through a refresh or a group toggled,
+        // else start at the first one. The list keeps its scroll offset, so
+        // the view doesn't jump either
+        if !selected.is_some_and(|key| self.select_table_key(&key)) {
+            self.selected_table_item_index = self.find_first_selectable_index();
+        }
         self.update_tables_list_state_selection();
     }
 
+    /// Key of the item selected in the list shown
+    fn selected_table_key(&self) -> Option<(String, Option<String>)> {
+        self.get_display_table_items()
+            .get(self.selected_table_item_index)
+            .and_then(SelectableTableItem::key)
+    }
+
+    /// Select the item of the main list with this key. Returns whether
+    /// it's listed
+    fn select_table_key(&mut self, key: &(String, Option<String>)) -> bool {
+        let Some(index) = self
+            .selectable_table_items
+            .iter()
+            .position(|item| item.key().as_ref() == Some(key))
+        else {
+            return false;
+        };
+        self.selected_table_item_index = index;
+        true
+    }
+
     /// Find the index of the first selectable item
     fn find_first_selectable_index(&self) -> usize {
         for (idx, item) in self.selectable_table_items.iter().enumerate() {
@@ -1088,9 +1207,42 @@ impl UIState {
             .map(|item| item.qualified_name())
     }
 
+    /// Select a table by its qualified name, leaving search; false when
+    /// the tables list doesn't show it
+    pub fn select_table_by_name(&mut self, table_name: &str) -> bool {
+        if self.tables_search_active {
+            self.exit_tables_search();
+        }
+        let Some(index) = self
+            .selectable_table_items
+            .iter()
+            .position(|item| item.is_selectable && item.qualified_name() == table_name)
+        else {
+            return false;
+        };
+        self.selected_table_item_index = index;
+        self.update_tables_list_state_selection();
+        true
+    }
+
+    /// Select the object of this schema and name, leaving search; false
+    /// when its group is collapsed or it isn't listed
+    pub fn select_table_object(&mut self, name: &str, schema: Option<&str>) -> bool {
+        if self.tables_search_active {
+            self.exit_tables_search();
+        }
+        let key = (name.to_string(), schema.map(str::to_string));
+        if !self.select_table_key(&key) {
+            return false;
+        }
+        self.update_tables_list_state_selection();
+        true
+    }
+
     /// Enter search mode for tables pane
     pub fn enter_tables_search(&mut self) {
         crate::log_debug!("Entering tables search mode");
+        self.tables_search_origin = self.selected_table_key();
         self.tables_search_active = true;
         self.tables_search_query.clear();
         self.update_filtered_table_items();
@@ -1102,11 +1254,22 @@ impl UIState {
             "Exiting tables search mode (query was: '{}')",
             self.tables_search_query
         );
+        // Stay on the match picked, or go back to where the search started
+        let picked = if self.filtered_table_items.is_empty() {
+            None
+        } else {
+            self.selected_table_key()
+        };
+        let origin = self.tables_search_origin.take();
         self.tables_search_active = false;
         self.tables_search_query.clear();
         self.filtered_table_items.clear();
-        // Reset selection to the main list
-        self.selected_table_item_index = self.find_first_selectable_index();
+        if !picked
+            .or(origin)
+            .is_some_and(|key| self.select_table_key(&key))
+        {
+            self.selected_table_item_index = self.find_first_selectable_index();
+        }
         self.update_tables_list_state_selection();
     }
 
@@ -1620,6 +1783,8 @@ impl UIState {
         self.help_pane_focus = HelpPaneFocus::Left;
         self.help_left_scroll_offset = 0;
         self.help_right_scroll_offset = 0;
+        self.help_filter.clear();
+        self.help_filter_active = false;
     }
 
     /// Scroll the currently focused help pane down
@@ -1689,10 +1854,173 @@ impl Default for UIState {
     }
 }
 
+impl SessionSubscriber for UIState {
+    fn on_session_event(&mut self, event: &SessionEvent) {
+        match event {
+            // The details pane starts at the top of each table
+            SessionEvent::TableChanged { .. } => self.details_viewport_offset = 0,
+            // A database name typed for another connection no longer applies
+            SessionEvent::ConnectionOpened { .. }
+            | SessionEvent::ConnectionClosed { .. }
+            | SessionEvent::DatabaseChanged { .. } => self.database_prompt = None,
+            SessionEvent::QueryStarted | SessionEvent::QueryFinished { .. } => {}
+        }
+    }
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
 
+    #[test]
+    fn test_cycle_focus_follows_the_order_and_skips_disabled_panes() {
+        let mut ui_state = UIState::new();
+        let order = [
+            FocusedPane::Connections,
+            FocusedPane::Tables,
+            FocusedPane::QueryWindow,
+            FocusedPane::TabularOutput,
+        ];
+        let enabled = |pane| pane != FocusedPane::Tables;
+
+        ui_state.focused_pane = FocusedPane::Connections;
+        ui_state.cycle_focus(&order, true, enabled);
+        assert_eq!(ui_state.focused_pane, FocusedPane::QueryWindow);
+        ui_state.cycle_focus(&order, true, enabled);
+        assert_eq!(ui_state.focused_pane, FocusedPane::TabularOutput);
+        ui_state.cycle_focus(&order, true, enabled);
+        assert_eq!(ui_state.focused_pane, FocusedPane::Connections);
+        ui_state.cycle_focus(&order, false, enabled);
+        assert_eq!(ui_state.focused_pane, FocusedPane::TabularOutput);
+
+        // A pane outside the order goes to its first or last pane
+        ui_state.focused_pane = FocusedPane::SqlFiles;
+        ui_state.cycle_focus(&order, true, enabled);
+        assert_eq!(ui_state.focused_pane, FocusedPane::Connections);
+        ui_state.focused_pane = FocusedPane::SqlFiles;
+        ui_state.cycle_focus(&order, false, enabled);
+        assert_eq!(ui_state.focused_pane, FocusedPane::TabularOutput);
+    }
+
+    #[test]
+    fn test_sql_file_name_refuses_paths() {
+        assert_eq!(
+            sql_file_name(" monthly report.sql ").unwrap(),
+            "monthly report"
+        );
+        assert_eq!(
+            sql_file_name("v1.2-orders_by_day").unwrap(),
+            "v1.2-orders_by_day"
+        );
+        assert!(sql_file_name("").is_err());
+        assert!(sql_file_name(".sql").is_err());
+        assert!(sql_file_name("../../etc/passwd").is_err());
+        assert!(sql_file_name("reports/monthly").is_err());
+        assert!(sql_file_name("C:\\temp").is_err());
+        assert!(sql_file_name(".hidden").is_err());
+    }
+
+    #[test]
+    fn test_recent_tables_are_newest_first_and_capped() {
+        let mut ui_state = UIState::new();
+        for i in 0..RECENT_TABLES_PER_CONNECTION + 2 {
+            ui_state.record_recent_table("local", &format!("table_{i}"));
+        }
+        ui_state.record_recent_table("local", "table_5");
+        ui_state.record_recent_table("other", "users");
+
+        let recent = ui_state.recent_tables_for("local");
+        assert_eq!(recent.len(), RECENT_TABLES_PER_CONNECTION);
+        assert_eq!(recent[0], "table_5");
+        assert_eq!(recent[1], "table_11");
+        assert_eq!(recent.iter().filter(|name| *name == "table_5").count(), 1);
+        assert!(!recent.contains(&"table_0".to_string()));
+        assert_eq!(ui_state.recent_tables_for("other"), ["users"]);
+        assert!(ui_state.recent_tables_for("missing").is_empty());
+    }
+
+    fn objects(tables: &[&str]) -> Option<crate::database::objects::DatabaseObjectList> {
+        use crate::database::objects::{DatabaseObject, DatabaseObjectList, DatabaseObjectType};
+        let object = |name: &str| DatabaseObject {
+            name: name.to_string(),
+            schema: None,
+            object_type: DatabaseObjectType::Table,
+            row_count: None,
+            size_bytes: None,
+            comment: None,
+            system: false,
+        };
+        Some(DatabaseObjectList {
+            tables: tables.iter().map(|name| object(name)).collect(),
+            views: vec![DatabaseObject {
+                object_type: DatabaseObjectType::View,
+                ..object("active_users")
+            }],
+            ..Default::default()
+        })
+    }
+
+    fn selected_name(ui_state: &UIState) -> Option<String> {
+        ui_state
+            .get_selected_table_item()
+            .map(|item| item.object_name.clone())
+    }
+
+    #[test]
+    fn test_refresh_keeps_the_selected_table() {
+        let mut ui_state = UIState::new();
+        ui_state.build_selectable_table_items(&objects(&["accounts", "orders", "users"]));
+        assert_eq!(selected_name(&ui_state).as_deref(), Some("accounts"));
+        ui_state.table_selection_down();
+        ui_state.table_selection_down();
+        *ui_state.tables_list_state.offset_mut() = 2;
+
+        // Listed again with a table added before it
+        ui_state
+            .build_selectable_table_items(&objects(&["accounts", "invoices", "orders", "users"]));
+        assert_eq!(selected_name(&ui_state).as_deref(), Some("users"));
+        assert_eq!(ui_state.tables_list_state.selected(), Some(4));
+        assert_eq!(ui_state.tables_list_state.offset(), 2);
+
+        // Gone after the refresh: back to the first table
+        ui_state.build_selectable_table_items(&objects(&["accounts", "orders"]));
+        assert_eq!(selected_name(&ui_state).as_deref(), Some("accounts"));
+    }
+
+    #[test]
+    fn test_group_toggle_keeps_the_header_selected() {
+        let mut ui_state = UIState::new();
+        let objects = objects(&["accounts", "orders"]);
+        ui_state.build_selectable_table_items(&objects);
+        ui_state.selected_table_item_index = 4;
+        assert_eq!(ui_state.selectable_table_items[4].display_name, "▼ Views");
+
+        ui_state.toggle_object_group_expansion("Views");
+        ui_state.build_selectable_table_items(&objects);
+        assert_eq!(ui_state.selected_table_item_index, 4);
+        assert_eq!(ui_state.selectable_table_items[4].display_name, "▶ Views");
+    }
+
+    #[test]
+    fn test_search_returns_to_the_match_or_the_start() {
+        let mut ui_state = UIState::new();
+        ui_state.build_selectable_table_items(&objects(&["accounts", "orders", "users"]));
+        ui_state.table_selection_down();
+
+        ui_state.enter_tables_search();
+        for c in "use".chars() {
+            ui_state.add_to_tables_search(c);
+        }
+        assert_eq!(selected_name(&ui_state).as_deref(), Some("users"));
+        ui_state.exit_tables_search();
+        assert_eq!(selected_name(&ui_state).as_deref(), Some("users"));
+
+        ui_state.enter_tables_search();
+        ui_state.add_to_tables_search('z');
+        ui_state.exit_tables_search();
+        assert_eq!(selected_name(&ui_state).as_deref(), Some("users"));
+    }
+
     #[test]
     fn test_matches_sequence() {
         assert!(matches_sequence("users", "usr"));
@@ -1844,6 +2172,9 @@ mod tests {
                 password: None,
                 ssl_mode: crate::database::SslMode::Prefer,
                 timeout: None,
+                search_path: None,
+                time_zone: None,
+                statement_timeout: None,
                 status: ConnectionStatus::Disconnected,
             },
             ConnectionConfig {
@@ -1858,6 +2189,9 @@ mod tests {
                 password: None,
                 ssl_mode: crate::database::SslMode::Prefer,
                 timeout: None,
+                search_path: None,
+                time_zone: None,
+                statement_timeout: None,
                 status: ConnectionStatus::Disconnected,
             },
             ConnectionConfig {
@@ -1872,6 +2206,9 @@ mod tests {
                 password: None,
                 ssl_mode: crate::database::SslMode::Disable,
                 timeout: None,
+                search_path: None,
+                time_zone: None,
+                statement_timeout: None,
                 status: ConnectionStatus::Disconnected,
             },
         ];
@@ -2016,4 +2353,25 @@ mod tests {
         assert!(!ui_state.pending_gg_command);
         assert_eq!(ui_state.selected_table_item_index, 2); // Should stay in place
     }
+
+    #[test]
+    fn test_session_events_reset_details_and_database_prompt() {
+        let mut ui_state = UIState::new();
+        ui_state.details_viewport_offset = 7;
+        ui_state.database_prompt = Some("analytics".to_string());
+
+        ui_state.on_session_event(&SessionEvent::QueryStarted);
+        assert_eq!(ui_state.details_viewport_offset, 7);
+
+        ui_state.on_session_event(&SessionEvent::TableChanged {
+            table: Some("orders".to_string()),
+        });
+        assert_eq!(ui_state.details_viewport_offset, 0);
+        assert!(ui_state.database_prompt.is_some());
+
+        ui_state.on_session_event(&SessionEvent::ConnectionClosed {
+            connection_id: "local".to_string(),
+        });
+        assert_eq!(ui_state.database_prompt, None);
+    }
 }
@@ -29,6 +29,20 @@ pub enum OverlayView {
     DebugView,
     /// Help overlay
     Help,
+    /// First-run setup wizard (driver selection step)
+    Welcome,
+    /// Postgres LISTEN/NOTIFY watcher
+    Notifications,
+    /// Operations running in the background
+    Tasks,
+    /// Results pinned this session
+    Pins,
+    /// Grid edits, deletes and inserts made this session
+    UndoLog,
+    /// Queries, rows and time connected per connection this session
+    SessionStats,
+    /// Past query editor statements, to load one again
+    QueryHistory,
 }
 
 /// Connection form mode (Add new or Edit existing)
@@ -100,6 +114,41 @@ impl AppView {
     pub fn is_help(&self) -> bool {
         matches!(self, Self::Overlay(OverlayView::Help))
     }
+
+    /// Check if in the first-run wizard's driver selection
+    pub fn is_welcome(&self) -> bool {
+        matches!(self, Self::Overlay(OverlayView::Welcome))
+    }
+
+    /// Check if in the LISTEN/NOTIFY watcher
+    pub fn is_notifications(&self) -> bool {
+        matches!(self, Self::Overlay(OverlayView::Notifications))
+    }
+
+    /// Check if in the background tasks overlay
+    pub fn is_tasks(&self) -> bool {
+        matches!(self, Self::Overlay(OverlayView::Tasks))
+    }
+
+    /// Check if in the pinned results picker
+    pub fn is_pins(&self) -> bool {
+        matches!(self, Self::Overlay(OverlayView::Pins))
+    }
+
+    /// Check if in the undo log
+    pub fn is_undo_log(&self) -> bool {
+        matches!(self, Self::Overlay(OverlayView::UndoLog))
+    }
+
+    /// Check if in the session statistics
+    pub fn is_session_stats(&self) -> bool {
+        matches!(self, Self::Overlay(OverlayView::SessionStats))
+    }
+
+    /// Check if in the query history picker
+    pub fn is_query_history(&self) -> bool {
+        matches!(self, Self::Overlay(OverlayView::QueryHistory))
+    }
 }
 
 impl OverlayView {
@@ -110,6 +159,13 @@ impl OverlayView {
             Self::ConnectionForm(ConnectionFormMode::Edit(_)) => "Edit Connection",
             Self::DebugView => "Debug View",
             Self::Help => "Help",
+            Self::Welcome => "Welcome",
+            Self::Notifications => "Notifications",
+            Self::Tasks => "Background Tasks",
+            Self::Pins => "Pinned Results",
+            Self::UndoLog => "Undo Log",
+            Self::SessionStats => "Session Statistics",
+            Self::QueryHistory => "Query History",
         }
     }
 }
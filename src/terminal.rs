@@ -70,6 +70,12 @@ pub fn install_panic_hook() {
 
     std::panic::set_hook(Box::new(move |panic_info| {
         let _ = restore();
+        tracing::error!("Panic: {}\n{}", panic_info, crate::version::long());
+        crate::logging::flush();
         original_hook(panic_info);
+        eprintln!(
+            "\nPlease include this in bug reports:\n{}",
+            crate::version::long()
+        );
     }));
 }
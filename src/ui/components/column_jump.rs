@@ -0,0 +1,94 @@
+// FilePath: src/ui/components/column_jump.rs
+
+//! Jumping to a column of a wide result by typing part of its name
+//!
+//! `c` in the output panel starts typing; each keystroke selects the column
+//! matching best and scrolls it into view. A name equal to the typed text
+//! matches best, then one starting with it, then one containing it, then one
+//! holding its characters in order ("ordt" finds `order_date`).
+
+#![forbid(unsafe_code)]
+
+/// Column name being typed, and the column selected before typing started
+#[derive(Debug, Clone, Default)]
+pub struct ColumnJump {
+    pub query: String,
+    /// Selected again when the jump is cancelled
+    pub from: usize,
+}
+
+impl ColumnJump {
+    pub fn new(from: usize) -> Self {
+        Self {
+            query: String::new(),
+            from,
+        }
+    }
+}
+
+/// How well `name` matches `query`, lower being better: the kind of match
+/// first, then where in the name it starts or how far its characters spread
+fn match_rank(name: &str, query: &str) -> Option<(u8, usize)> {
+    let name = name.to_lowercase();
+    if name == query {
+        return Some((0, 0));
+    }
+    if let Some(at) = name.find(query) {
+        return Some(if at == 0 { (1, name.len()) } else { (2, at) });
+    }
+
+    // Every character of the query, in order
+    let mut chars = name.char_indices();
+    let mut first = None;
+    let mut last = 0;
+    for wanted in query.chars() {
+        let (at, _) = chars.find(|(_, c)| *c == wanted)?;
+        first.get_or_insert(at);
+        last = at;
+    }
+    Some((3, last - first.unwrap_or(0)))
+}
+
+/// Index of the column best matching `query`, the leftmost of equally good
+/// ones. None when nothing matches or nothing is typed
+pub fn best_column_match<'a>(
+    names: impl IntoIterator<Item = &'a str>,
+    query: &str,
+) -> Option<usize> {
+    let query = query.to_lowercase();
+    if query.is_empty() {
+        return None;
+    }
+    names
+        .into_iter()
+        .enumerate()
+        .filter_map(|(index, name)| Some((match_rank(name, &query)?, index)))
+        .min()
+        .map(|(_, index)| index)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_best_match_prefers_exact_then_prefix_then_substring() {
+        let names = [
+            "customer_id",
+            "order_id",
+            "order_date",
+            "id",
+            "shipped_order",
+        ];
+        let best = |query| best_column_match(names, query);
+
+        assert_eq!(best("ID"), Some(3));
+        assert_eq!(best("order"), Some(1));
+        assert_eq!(best("order_d"), Some(2));
+        assert_eq!(best("ped_or"), Some(4));
+        assert_eq!(best("ordt"), Some(2));
+        assert_eq!(best("cid"), Some(0));
+        assert_eq!(best("zzz"), None);
+        assert_eq!(best(""), None);
+    }
+}
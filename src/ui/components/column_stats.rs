@@ -0,0 +1,209 @@
+// FilePath: src/ui/components/column_stats.rs
+
+//! Quick statistics over the loaded values of one result column
+
+#![forbid(unsafe_code)]
+
+use crate::database::NULL_CELL;
+use crate::ui::theme::Theme;
+use ratatui::{
+    layout::{Alignment, Rect},
+    style::{Modifier, Style},
+    text::{Line, Span},
+    widgets::{Block, Borders, Clear, Paragraph},
+    Frame,
+};
+use std::collections::HashSet;
+
+/// Summary of a column over the rows currently in memory
+#[derive(Debug, Clone, PartialEq)]
+pub struct ColumnStats {
+    pub column: String,
+    /// Rows looked at, NULLs included
+    pub count: usize,
+    /// Distinct non-NULL values
+    pub distinct: usize,
+    pub nulls: usize,
+    pub min: Option<String>,
+    pub max: Option<String>,
+    /// Set when every non-NULL value is a number
+    pub mean: Option<f64>,
+    pub median: Option<f64>,
+}
+
+impl ColumnStats {
+    /// Compute the statistics of `values`. Min and max compare numerically
+    /// when the whole column is numeric and as text otherwise.
+    pub fn compute<'a>(column: &str, values: impl IntoIterator<Item = &'a str>) -> Self {
+        let mut count = 0;
+        let mut nulls = 0;
+        let mut present = Vec::new();
+        for value in values {
+            count += 1;
+            if value == NULL_CELL {
+                nulls += 1;
+            } else {
+                present.push(value);
+            }
+        }
+
+        let distinct = present.iter().collect::<HashSet<_>>().len();
+        let numbers: Option<Vec<f64>> = present
+            .iter()
+            .map(|value| value.trim().parse::<f64>().ok().filter(|n| n.is_finite()))
+            .collect();
+
+        match numbers.filter(|numbers| !numbers.is_empty()) {
+            Some(mut numbers) => {
+                numbers.sort_by(f64::total_cmp);
+                let min = numbers[0];
+                let max = numbers[numbers.len() - 1];
+                let mean = numbers.iter().sum::<f64>() / numbers.len() as f64;
+                let mid = numbers.len() / 2;
+                let median = if numbers.len() % 2 == 0 {
+                    (numbers[mid - 1] + numbers[mid]) / 2.0
+                } else {
+                    numbers[mid]
+                };
+                Self {
+                    column: column.to_string(),
+                    count,
+                    distinct,
+                    nulls,
+                    min: Some(format_number(min)),
+                    max: Some(format_number(max)),
+                    mean: Some(mean),
+                    median: Some(median),
+                }
+            }
+            None => Self {
+                column: column.to_string(),
+                count,
+                distinct,
+                nulls,
+                min: present.iter().min().map(|value| value.to_string()),
+                max: present.iter().max().map(|value| value.to_string()),
+                mean: None,
+                median: None,
+            },
+        }
+    }
+
+    /// Share of NULLs as a percentage of all rows
+    pub fn null_percent(&self) -> f64 {
+        if self.count == 0 {
+            0.0
+        } else {
+            self.nulls as f64 * 100.0 / self.count as f64
+        }
+    }
+}
+
+/// Whole numbers without a fraction, others to at most four decimals
+fn format_number(n: f64) -> String {
+    if n.fract() == 0.0 && n.abs() < 1e15 {
+        format!("{n:.0}")
+    } else {
+        let text = format!("{n:.4}");
+        text.trim_end_matches('0').trim_end_matches('.').to_string()
+    }
+}
+
+/// Longest min/max text shown before shortening
+const MAX_VALUE_WIDTH: usize = 40;
+
+fn shorten(value: &str) -> String {
+    if value.chars().count() > MAX_VALUE_WIDTH {
+        let head: String = value.chars().take(MAX_VALUE_WIDTH - 1).collect();
+        format!("{head}…")
+    } else {
+        value.to_string()
+    }
+}
+
+/// Render the statistics as a small centered popup
+pub fn render_column_stats(f: &mut Frame, stats: &ColumnStats, area: Rect, theme: &Theme) {
+    let label = Style::default().fg(theme.get_color("text_secondary"));
+    let value = Style::default().fg(theme.get_color("text_primary"));
+    let row = |name: &str, text: String| {
+        Line::from(vec![
+            Span::styled(format!("{name:<10}"), label),
+            Span::styled(text, value),
+        ])
+    };
+
+    let mut lines = vec![
+        row("Rows", stats.count.to_string()),
+        row("Distinct", stats.distinct.to_string()),
+        row(
+            "Nulls",
+            format!("{} ({:.1}%)", stats.nulls, stats.null_percent()),
+        ),
+        row("Min", stats.min.as_deref().map(shorten).unwrap_or_default()),
+        row("Max", stats.max.as_deref().map(shorten).unwrap_or_default()),
+    ];
+    if let (Some(mean), Some(median)) = (stats.mean, stats.median) {
+        lines.push(row("Mean", format_number(mean)));
+        lines.push(row("Median", format_number(median)));
+    }
+    lines.push(Line::from(""));
+    lines.push(Line::from(Span::styled(
+        "Loaded rows only · any key to close",
+        Style::default().fg(theme.get_color("text_muted")),
+    )));
+
+    let width = 52u16.min(area.width.saturating_sub(4));
+    let height = (lines.len() as u16 + 2).min(area.height);
+    let popup = Rect {
+        x: area.x + area.width.saturating_sub(width) / 2,
+        y: area.y + area.height.saturating_sub(height) / 2,
+        width,
+        height,
+    };
+
+    f.render_widget(Clear, popup);
+    let block = Block::default()
+        .borders(Borders::ALL)
+        .title(format!(" Stats: {} ", stats.column))
+        .title_alignment(Alignment::Center)
+        .border_style(
+            Style::default()
+                .fg(theme.get_color("accent"))
+                .add_modifier(Modifier::BOLD),
+        )
+        .style(Style::default().bg(theme.get_color("background")));
+    f.render_widget(Paragraph::new(lines).block(block), popup);
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_numeric_column_stats() {
+        let stats = ColumnStats::compute("amount", ["3", "1", "NULL", "2", "10", "2"]);
+        assert_eq!(stats.count, 6);
+        assert_eq!(stats.nulls, 1);
+        assert_eq!(stats.distinct, 4);
+        assert_eq!(stats.min.as_deref(), Some("1"));
+        assert_eq!(stats.max.as_deref(), Some("10"));
+        assert_eq!(stats.mean, Some(3.6));
+        assert_eq!(stats.median, Some(2.0));
+    }
+
+    #[test]
+    fn test_text_column_stats() {
+        let stats = ColumnStats::compute("name", ["bob", "alice", "10", "NULL", "NULL"]);
+        assert_eq!(stats.distinct, 3);
+        assert_eq!(stats.nulls, 2);
+        assert_eq!(stats.min.as_deref(), Some("10"));
+        assert_eq!(stats.max.as_deref(), Some("bob"));
+        assert_eq!(stats.mean, None);
+        assert_eq!(stats.null_percent(), 40.0);
+
+        let empty = ColumnStats::compute("x", ["NULL"]);
+        assert_eq!(empty.min, None);
+        assert_eq!(empty.mean, None);
+        assert_eq!(empty.null_percent(), 100.0);
+    }
+}
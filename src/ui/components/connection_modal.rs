@@ -3,7 +3,9 @@
 #![forbid(unsafe_code)]
 
 use crate::database::connection::{ConnectionConfig, DatabaseType, SslMode};
+use crate::database::DisplayTimeZone;
 use crate::security::PasswordSource;
+use crate::ui::components::path_input::{self, Completion, PathKind};
 use ratatui::{
     layout::{Alignment, Constraint, Direction, Layout, Margin, Rect},
     style::{Color, Modifier, Style},
@@ -42,6 +44,12 @@ pub struct ConnectionModalState {
     pub port_input: String,
     /// Database name input
     pub database: String,
+    /// Schema search path input (PostgreSQL)
+    pub search_path: String,
+    /// Session and display time zone input
+    pub time_zone: String,
+    /// Statement timeout input, in seconds
+    pub statement_timeout: String,
     /// Username input
     pub username: String,
     /// Password input (not stored in plain text)
@@ -85,6 +93,9 @@ pub enum ConnectionField {
     Host,
     Port,
     Database,
+    SearchPath,
+    TimeZone,
+    StatementTimeout,
     Username,
     Password,
     PasswordStorageType,
@@ -104,7 +115,10 @@ impl ConnectionField {
             match self {
                 Self::Name => Self::DatabaseType,
                 Self::DatabaseType => Self::ConnectionString,
-                Self::ConnectionString => Self::SslMode,
+                Self::ConnectionString => Self::SearchPath,
+                Self::SearchPath => Self::TimeZone,
+                Self::TimeZone => Self::StatementTimeout,
+                Self::StatementTimeout => Self::SslMode,
                 Self::SslMode => Self::Test,
                 Self::Test => Self::Save,
                 Self::Save => Self::Cancel,
@@ -118,7 +132,10 @@ impl ConnectionField {
                 Self::ConnectionString => Self::Host,
                 Self::Host => Self::Port,
                 Self::Port => Self::Database,
-                Self::Database => Self::Username,
+                Self::Database => Self::SearchPath,
+                Self::SearchPath => Self::TimeZone,
+                Self::TimeZone => Self::StatementTimeout,
+                Self::StatementTimeout => Self::Username,
                 Self::Username => Self::Password,
                 Self::Password => Self::PasswordStorageType,
                 Self::PasswordStorageType => Self::PasswordEnvVar,
@@ -140,7 +157,10 @@ impl ConnectionField {
                 Self::Name => Self::Cancel, // Loop back to end
                 Self::DatabaseType => Self::Name,
                 Self::ConnectionString => Self::DatabaseType,
-                Self::SslMode => Self::ConnectionString,
+                Self::SearchPath => Self::ConnectionString,
+                Self::TimeZone => Self::SearchPath,
+                Self::StatementTimeout => Self::TimeZone,
+                Self::SslMode => Self::StatementTimeout,
                 Self::Test => Self::SslMode,
                 Self::Save => Self::Test,
                 Self::Cancel => Self::Save,
@@ -154,7 +174,10 @@ impl ConnectionField {
                 Self::Host => Self::ConnectionString,
                 Self::Port => Self::Host,
                 Self::Database => Self::Port,
-                Self::Username => Self::Database,
+                Self::SearchPath => Self::Database,
+                Self::TimeZone => Self::SearchPath,
+                Self::StatementTimeout => Self::TimeZone,
+                Self::Username => Self::StatementTimeout,
                 Self::Password => Self::Username,
                 Self::PasswordStorageType => Self::Password,
                 Self::PasswordEnvVar => Self::PasswordStorageType,
@@ -177,6 +200,9 @@ impl ConnectionField {
             Self::Host => "Host",
             Self::Port => "Port",
             Self::Database => "Database",
+            Self::SearchPath => "Search Path",
+            Self::TimeZone => "Time Zone",
+            Self::StatementTimeout => "Statement Timeout",
             Self::Username => "Username",
             Self::Password => "Password",
             Self::PasswordStorageType => "Password Storage",
@@ -208,6 +234,9 @@ impl Default for ConnectionModalState {
             host: "localhost".to_string(),
             port_input: "5432".to_string(),
             database: String::new(),
+            search_path: String::new(),
+            time_zone: String::new(),
+            statement_timeout: String::new(),
             username: String::new(),
             password: String::new(),
             password_storage_type: PasswordStorageType::PlainText,
@@ -230,52 +259,40 @@ impl ConnectionModalState {
         Self::default()
     }
 
-    /// Get the next field considering conditional fields
-    pub fn get_smart_next_field(&self) -> ConnectionField {
-        let base_next = self.focused_field.next(self.using_connection_string);
-
-        // Skip fields based on password storage type
-        match base_next {
+    /// Whether a field is shown for the chosen database and password
+    /// storage type
+    fn is_field_shown(&self, field: ConnectionField) -> bool {
+        match field {
+            ConnectionField::SearchPath => self.database_type == DatabaseType::PostgreSQL,
+            ConnectionField::TimeZone | ConnectionField::StatementTimeout => {
+                self.database_type != DatabaseType::SQLite
+            }
             ConnectionField::PasswordEnvVar => {
-                if self.password_storage_type != PasswordStorageType::Environment {
-                    // Skip to next field
-                    return ConnectionField::EncryptionKey;
-                }
+                self.password_storage_type == PasswordStorageType::Environment
             }
             ConnectionField::EncryptionKey | ConnectionField::EncryptionHint => {
-                if self.password_storage_type != PasswordStorageType::Encrypted {
-                    // Skip to SSL mode
-                    return ConnectionField::SslMode;
-                }
+                self.password_storage_type == PasswordStorageType::Encrypted
             }
-            _ => {}
+            _ => true,
         }
+    }
 
-        base_next
+    /// Get the next field considering conditional fields
+    pub fn get_smart_next_field(&self) -> ConnectionField {
+        let mut next = self.focused_field.next(self.using_connection_string);
+        while !self.is_field_shown(next) {
+            next = next.next(self.using_connection_string);
+        }
+        next
     }
 
     /// Get the previous field considering conditional fields
     pub fn get_smart_previous_field(&self) -> ConnectionField {
-        let base_prev = self.focused_field.previous(self.using_connection_string);
-
-        // Skip fields based on password storage type
-        match base_prev {
-            ConnectionField::EncryptionHint | ConnectionField::EncryptionKey => {
-                if self.password_storage_type != PasswordStorageType::Encrypted {
-                    // Skip back to password storage type
-                    return ConnectionField::PasswordStorageType;
-                }
-            }
-            ConnectionField::PasswordEnvVar => {
-                if self.password_storage_type != PasswordStorageType::Environment {
-                    // Skip back to password storage type
-                    return ConnectionField::PasswordStorageType;
-                }
-            }
-            _ => {}
+        let mut previous = self.focused_field.previous(self.using_connection_string);
+        while !self.is_field_shown(previous) {
+            previous = previous.previous(self.using_connection_string);
         }
-
-        base_prev
+        previous
     }
 
     /// Move to next field
@@ -297,11 +314,37 @@ impl ConnectionModalState {
                 | ConnectionField::Host
                 | ConnectionField::Port
                 | ConnectionField::Database
+                | ConnectionField::SearchPath
+                | ConnectionField::TimeZone
+                | ConnectionField::StatementTimeout
                 | ConnectionField::Username
                 | ConnectionField::Password
         )
     }
 
+    /// Whether the focused field is the file path of a SQLite database
+    pub fn is_path_field(&self) -> bool {
+        self.focused_field == ConnectionField::Database
+            && self.database_type == DatabaseType::SQLite
+            && !self.using_connection_string
+    }
+
+    /// Complete the SQLite file path as far as the filesystem allows.
+    /// Returns the completion when it added to the path
+    pub fn complete_path(&mut self) -> Option<Completion> {
+        if !self.is_path_field() {
+            return None;
+        }
+        let completion = path_input::complete(&self.database)?;
+        if completion.text == self.database {
+            return None;
+        }
+        self.database = completion.text.clone();
+        self.error_message = None;
+        self.test_status = None;
+        Some(completion)
+    }
+
     /// Cycle through password storage types
     pub fn cycle_password_storage_type(&mut self) {
         self.password_storage_type = match self.password_storage_type {
@@ -340,6 +383,17 @@ impl ConnectionModalState {
                     self.database.push(c);
                 }
             }
+            ConnectionField::SearchPath => {
+                self.search_path.push(c);
+            }
+            ConnectionField::TimeZone => {
+                self.time_zone.push(c);
+            }
+            ConnectionField::StatementTimeout => {
+                if c.is_ascii_digit() {
+                    self.statement_timeout.push(c);
+                }
+            }
             ConnectionField::Username => {
                 if !self.using_connection_string {
                     self.username.push(c);
@@ -399,6 +453,15 @@ impl ConnectionModalState {
                     self.database.pop();
                 }
             }
+            ConnectionField::SearchPath => {
+                self.search_path.pop();
+            }
+            ConnectionField::TimeZone => {
+                self.time_zone.pop();
+            }
+            ConnectionField::StatementTimeout => {
+                self.statement_timeout.pop();
+            }
             ConnectionField::Username => {
                 if !self.using_connection_string {
                     self.username.pop();
@@ -422,6 +485,30 @@ impl ConnectionModalState {
         }
     }
 
+    /// Handle a readline edit for the current field. Fields are typed at
+    /// their end, so only the deleting keys change anything
+    pub fn handle_readline(&mut self, edit: super::readline::Edit) {
+        let text = match self.focused_field {
+            ConnectionField::Name => &self.name,
+            ConnectionField::ConnectionString => &self.connection_string,
+            ConnectionField::Host => &self.host,
+            ConnectionField::Port => &self.port_input,
+            ConnectionField::Database => &self.database,
+            ConnectionField::SearchPath => &self.search_path,
+            ConnectionField::TimeZone => &self.time_zone,
+            ConnectionField::StatementTimeout => &self.statement_timeout,
+            ConnectionField::Username => &self.username,
+            ConnectionField::Password => &self.password,
+            ConnectionField::PasswordEnvVar => &self.password_env_var,
+            ConnectionField::EncryptionKey => &self.encryption_key,
+            ConnectionField::EncryptionHint => &self.encryption_hint,
+            _ => return,
+        };
+        for _ in 0..edit.erased(text) {
+            self.handle_backspace();
+        }
+    }
+
     /// Clear individual connection fields
     fn clear_individual_fields(&mut self) {
         self.host = "localhost".to_string();
@@ -638,6 +725,9 @@ impl ConnectionModalState {
             }
 
             connection.ssl_mode = self.ssl_mode.clone();
+            connection.search_path = self.search_path_input();
+            connection.time_zone = self.time_zone_input()?;
+            connection.statement_timeout = self.statement_timeout_input()?;
             Ok(connection)
         } else {
             // Use individual fields
@@ -670,8 +760,12 @@ impl ConnectionModalState {
                 self.username.trim().to_string(),
             );
 
-            // Set optional fields
-            if !self.database.trim().is_empty() {
+            // Set optional fields; a SQLite file must exist, as it's opened
+            // without being created
+            if self.database_type == DatabaseType::SQLite {
+                let path = path_input::validate(&self.database, PathKind::Open)?;
+                connection.database = Some(path.display().to_string());
+            } else if !self.database.trim().is_empty() {
                 connection.database = Some(self.database.trim().to_string());
             }
 
@@ -713,11 +807,48 @@ impl ConnectionModalState {
             }
 
             connection.ssl_mode = self.ssl_mode.clone();
+            connection.search_path = self.search_path_input();
+            connection.time_zone = self.time_zone_input()?;
+            connection.statement_timeout = self.statement_timeout_input()?;
 
             Ok(connection)
         }
     }
 
+    /// Time zone to save, checked so a typo is caught here rather than
+    /// silently shown as UTC
+    fn time_zone_input(&self) -> Result<Option<String>, String> {
+        let time_zone = self.time_zone.trim();
+        if self.database_type == DatabaseType::SQLite || time_zone.is_empty() {
+            return Ok(None);
+        }
+        DisplayTimeZone::parse(time_zone)?;
+        Ok(Some(time_zone.to_string()))
+    }
+
+    /// Statement timeout to save in seconds; empty leaves it to
+    /// `[app] statement_timeout_secs`
+    fn statement_timeout_input(&self) -> Result<Option<u64>, String> {
+        let timeout = self.statement_timeout.trim();
+        if self.database_type == DatabaseType::SQLite || timeout.is_empty() {
+            return Ok(None);
+        }
+        timeout
+            .parse()
+            .map(Some)
+            .map_err(|_| "Invalid statement timeout".to_string())
+    }
+
+    /// Search path to save, for PostgreSQL connections that set one
+    fn search_path_input(&self) -> Option<String> {
+        let search_path = self.search_path.trim();
+        if self.database_type == DatabaseType::PostgreSQL && !search_path.is_empty() {
+            Some(search_path.to_string())
+        } else {
+            None
+        }
+    }
+
     /// Clear test status (called when fields change)
     pub fn clear_test_status(&mut self) {
         self.test_status = None;
@@ -794,6 +925,12 @@ impl ConnectionModalState {
         self.host = connection.host.clone();
         self.port_input = connection.port.to_string();
         self.database = connection.database.as_deref().unwrap_or("").to_string();
+        self.search_path = connection.search_path.clone().unwrap_or_default();
+        self.time_zone = connection.time_zone.clone().unwrap_or_default();
+        self.statement_timeout = connection
+            .statement_timeout
+            .map(|seconds| seconds.to_string())
+            .unwrap_or_default();
         self.username = connection.username.clone();
         self.ssl_mode = connection.ssl_mode.clone();
 
@@ -893,7 +1030,7 @@ pub fn render_connection_modal(
     modal_state: &ConnectionModalState,
     area: Rect,
     is_edit_mode: bool,
-    test_animation_frame: u8,
+    spinner_frame: &str,
     test_in_progress: bool,
     test_elapsed_seconds: u64,
     test_timeout_seconds: u64,
@@ -952,7 +1089,7 @@ pub fn render_connection_modal(
         f,
         modal_state,
         main_chunks[1],
-        test_animation_frame,
+        spinner_frame,
         test_in_progress,
         test_elapsed_seconds,
         test_timeout_seconds,
@@ -963,7 +1100,7 @@ pub fn render_connection_modal(
         f,
         modal_state,
         main_chunks[2],
-        test_animation_frame,
+        spinner_frame,
         test_in_progress,
         test_elapsed_seconds,
         test_timeout_seconds,
@@ -1060,7 +1197,7 @@ fn render_unified_form(
     f: &mut Frame,
     modal_state: &ConnectionModalState,
     area: Rect,
-    test_animation_frame: u8,
+    spinner_frame: &str,
     test_in_progress: bool,
     test_elapsed_seconds: u64,
     test_timeout_seconds: u64,
@@ -1101,7 +1238,7 @@ fn render_unified_form(
         f,
         modal_state,
         chunks[1],
-        test_animation_frame,
+        spinner_frame,
         test_in_progress,
         test_elapsed_seconds,
         test_timeout_seconds,
@@ -1113,7 +1250,7 @@ fn render_form_fields(
     f: &mut Frame,
     modal_state: &ConnectionModalState,
     area: Rect,
-    test_animation_frame: u8,
+    spinner_frame: &str,
     test_in_progress: bool,
     test_elapsed_seconds: u64,
     test_timeout_seconds: u64,
@@ -1121,7 +1258,7 @@ fn render_form_fields(
     // Count how many fields we need to display
     let field_count = if modal_state.using_connection_string {
         // Name, DB Type, Conn String, Validation Hint (if shown), SSL Mode, Button Bar, Status
-        let base_count = 8;
+        let base_count = 9;
         // Add 1 if validation hint will be shown
         if modal_state.validate_connection_string_format().is_some() {
             base_count + 1
@@ -1129,7 +1266,7 @@ fn render_form_fields(
             base_count
         }
     } else {
-        20 // All individual fields + Button Bar + Status
+        21 // All individual fields + Button Bar + Status
     };
 
     // Create layout: fields area + spacer + button bar (guaranteed at bottom)
@@ -1216,6 +1353,11 @@ fn render_form_fields(
         chunk_idx += 1;
     }
 
+    // Session settings sit with the connection string when one is used
+    if modal_state.using_connection_string {
+        chunk_idx += render_session_fields(f, modal_state, &chunks[chunk_idx..]);
+    }
+
     // Show individual fields only if not using connection string
     if !modal_state.using_connection_string {
         // Host
@@ -1243,7 +1385,11 @@ fn render_form_fields(
         // Database (optional) - moved before Username to match tab order
         render_label_value_field(
             f,
-            "Database (Optional)",
+            if modal_state.is_path_field() {
+                "Database file (Tab completes)"
+            } else {
+                "Database (Optional)"
+            },
             &modal_state.database,
             modal_state.focused_field == ConnectionField::Database,
             false,
@@ -1251,6 +1397,8 @@ fn render_form_fields(
         );
         chunk_idx += 1;
 
+        chunk_idx += render_session_fields(f, modal_state, &chunks[chunk_idx..]);
+
         // Username - moved after Database to match tab order
         render_label_value_field(
             f,
@@ -1348,13 +1496,55 @@ fn render_form_fields(
         f,
         modal_state,
         main_layout[2],
-        test_animation_frame,
+        spinner_frame,
         test_in_progress,
         test_elapsed_seconds,
         test_timeout_seconds,
     );
 }
 
+/// Render the search path, time zone and statement timeout fields the
+/// database has, one per row of `rows`; returns how many rows were used
+fn render_session_fields(
+    f: &mut Frame,
+    modal_state: &ConnectionModalState,
+    rows: &[Rect],
+) -> usize {
+    let fields = [
+        (
+            ConnectionField::SearchPath,
+            "Search Path (e.g. app, public)",
+            &modal_state.search_path,
+        ),
+        (
+            ConnectionField::TimeZone,
+            "Time Zone (e.g. UTC, Europe/Berlin)",
+            &modal_state.time_zone,
+        ),
+        (
+            ConnectionField::StatementTimeout,
+            "Statement Timeout (seconds, 0 for none)",
+            &modal_state.statement_timeout,
+        ),
+    ];
+    let mut used = 0;
+    for (field, label, value) in fields {
+        if !modal_state.is_field_shown(field) {
+            continue;
+        }
+        render_label_value_field(
+            f,
+            label,
+            value,
+            modal_state.focused_field == field,
+            false,
+            rows[used],
+        );
+        used += 1;
+    }
+    used
+}
+
 /// Get connection string example for database type
 fn get_connection_string_example(db_type: &DatabaseType) -> &'static str {
     match db_type {
@@ -1455,7 +1645,7 @@ fn render_button_bar(
     f: &mut Frame,
     modal_state: &ConnectionModalState,
     area: Rect,
-    _test_animation_frame: u8,
+    _spinner_frame: &str,
     _test_in_progress: bool,
     _test_elapsed_seconds: u64,
     _test_timeout_seconds: u64,
@@ -1551,7 +1741,7 @@ fn render_modal_status(
     f: &mut Frame,
     modal_state: &ConnectionModalState,
     area: Rect,
-    test_animation_frame: u8,
+    spinner_frame: &str,
     _test_in_progress: bool,
     test_elapsed_seconds: u64,
     test_timeout_seconds: u64,
@@ -1560,15 +1750,9 @@ fn render_modal_status(
     if let Some(test_status) = &modal_state.test_status {
         match test_status {
             TestConnectionStatus::Testing => {
-                let dots = match test_animation_frame {
-                    0 => "•",
-                    1 => "••",
-                    2 => "•••",
-                    _ => "•",
-                };
                 let message = format!(
                     "🔄 Testing connection {} {}/{}s",
-                    dots, test_elapsed_seconds, test_timeout_seconds
+                    spinner_frame, test_elapsed_seconds, test_timeout_seconds
                 );
                 let status_paragraph = Paragraph::new(message)
                     .style(
@@ -1792,6 +1976,80 @@ mod tests {
         assert_eq!(config.port, 5432);
         assert_eq!(config.username, "postgres");
         assert_eq!(config.database, Some("testdb".to_string()));
+        assert_eq!(config.search_path, None);
+    }
+
+    #[test]
+    fn test_search_path_only_for_postgres() {
+        let mut state = ConnectionModalState::new();
+        state.name = "App".to_string();
+        state.username = "postgres".to_string();
+        state.search_path = " app, public ".to_string();
+
+        let config = state.try_create_connection(&[], None).unwrap();
+        assert_eq!(config.search_path.as_deref(), Some("app, public"));
+
+        state.focused_field = ConnectionField::Database;
+        state.select_database_type(1);
+        assert_eq!(state.get_smart_next_field(), ConnectionField::TimeZone);
+        let config = state.try_create_connection(&[], None).unwrap();
+        assert_eq!(config.search_path, None);
+
+        state.select_database_type(3);
+        assert_eq!(state.get_smart_next_field(), ConnectionField::Username);
+    }
+
+    #[test]
+    fn test_time_zone_is_validated() {
+        let mut state = ConnectionModalState::new();
+        state.name = "App".to_string();
+        state.username = "postgres".to_string();
+
+        state.time_zone = "Europe/Berln".to_string();
+        assert!(state.try_create_connection(&[], None).is_err());
+
+        state.time_zone = "Europe/Berlin".to_string();
+        let config = state.try_create_connection(&[], None).unwrap();
+        assert_eq!(config.time_zone.as_deref(), Some("Europe/Berlin"));
+    }
+
+    #[test]
+    fn test_statement_timeout_takes_digits() {
+        let mut state = ConnectionModalState::new();
+        state.name = "App".to_string();
+        state.username = "postgres".to_string();
+        let config = state.try_create_connection(&[], None).unwrap();
+        assert_eq!(config.statement_timeout, None);
+
+        state.focused_field = ConnectionField::StatementTimeout;
+        for c in "3x0".chars() {
+            state.handle_char_input(c);
+        }
+        assert_eq!(state.statement_timeout, "30");
+        let config = state.try_create_connection(&[], None).unwrap();
+        assert_eq!(config.statement_timeout, Some(30));
+    }
+
+    #[test]
+    fn test_sqlite_file_is_completed_and_must_exist() {
+        let dir = tempfile::tempdir().unwrap();
+        std::fs::write(dir.path().join("app.db"), "").unwrap();
+        let mut state = ConnectionModalState::new();
+        state.name = "Local".to_string();
+        state.username = "app".to_string();
+        state.select_database_type(3);
+        state.focused_field = ConnectionField::Database;
+        assert!(state.is_path_field());
+
+        state.database = format!("{}/ap", dir.path().display());
+        assert!(state.complete_path().is_some());
+        assert_eq!(state.database, format!("{}/app.db", dir.path().display()));
+        assert!(state.complete_path().is_none());
+        let config = state.try_create_connection(&[], None).unwrap();
+        assert_eq!(config.database.as_deref(), Some(state.database.as_str()));
+
+        state.database = format!("{}/missing.db", dir.path().display());
+        assert!(state.try_create_connection(&[], None).is_err());
     }
 
     #[test]
@@ -1870,6 +2128,9 @@ mod tests {
             password: None,
             ssl_mode: SslMode::Prefer,
             timeout: None,
+            search_path: None,
+            time_zone: None,
+            statement_timeout: None,
             status: crate::database::ConnectionStatus::Disconnected,
         };
 
@@ -1897,6 +2158,9 @@ mod tests {
             password: None,
             ssl_mode: SslMode::Require,
             timeout: None,
+            search_path: None,
+            time_zone: None,
+            statement_timeout: None,
             status: crate::database::ConnectionStatus::Disconnected,
         };
 
@@ -1930,6 +2194,9 @@ mod tests {
             password: None,
             ssl_mode: SslMode::Disable,
             timeout: None,
+            search_path: None,
+            time_zone: None,
+            statement_timeout: None,
             status: crate::database::ConnectionStatus::Disconnected,
         };
 
@@ -1956,6 +2223,9 @@ mod tests {
             password: Some("legacy_pass".to_string()),
             ssl_mode: SslMode::Allow,
             timeout: None,
+            search_path: None,
+            time_zone: None,
+            statement_timeout: None,
             status: crate::database::ConnectionStatus::Disconnected,
         };
 
@@ -1987,6 +2257,9 @@ mod tests {
             password: None,
             ssl_mode: SslMode::Prefer,
             timeout: None,
+            search_path: None,
+            time_zone: None,
+            statement_timeout: None,
             status: crate::database::ConnectionStatus::Disconnected,
         };
 
@@ -2010,6 +2283,9 @@ mod tests {
             password: None,
             ssl_mode: SslMode::Require,
             timeout: None,
+            search_path: None,
+            time_zone: None,
+            statement_timeout: None,
             status: crate::database::ConnectionStatus::Disconnected,
         };
 
@@ -807,6 +807,9 @@ impl ConnectionMode {
             password_source: None,
             ssl_mode: self.form_state.ssl_mode.clone(),
             timeout: None,
+            search_path: None,
+            time_zone: None,
+            statement_timeout: None,
             status: crate::database::ConnectionStatus::Disconnected,
         })
     }
@@ -13,7 +13,13 @@ use ratatui::{
     },
     Frame,
 };
-use std::collections::HashMap;
+use std::{
+    collections::{HashMap, VecDeque},
+    time::{Duration, Instant},
+};
+
+/// Window the renders per minute are counted over
+const RENDER_WINDOW: Duration = Duration::from_secs(60);
 
 /// Debug view component for displaying logs and diagnostics
 #[derive(Debug, Clone)]
@@ -22,6 +28,8 @@ pub struct DebugView {
     pub performance_metrics: PerformanceMetrics,
     /// Cached statistics to prevent flickering
     cached_stats: Option<CachedStatistics>,
+    /// When each render of the last minute happened, oldest first
+    renders: VecDeque<Instant>,
 }
 
 /// Cached statistics to reduce flickering
@@ -39,7 +47,8 @@ pub struct PerformanceMetrics {
     pub cpu_usage_percent: f64,
     pub database_connections: usize,
     pub active_queries: usize,
-    pub fps: u32,
+    /// Frames drawn in the last minute; low when idle
+    pub renders_per_minute: usize,
     pub render_time_ms: f64,
 }
 
@@ -49,7 +58,29 @@ impl DebugView {
         Self {
             performance_metrics: PerformanceMetrics::default(),
             cached_stats: None,
+            renders: VecDeque::new(),
+        }
+    }
+
+    /// Record a frame drawn, which took from `started` until now
+    pub fn record_render(&mut self, started: Instant) {
+        let now = Instant::now();
+        self.performance_metrics.render_time_ms =
+            now.saturating_duration_since(started).as_secs_f64() * 1000.0;
+        self.count_render(now);
+    }
+
+    /// Count a render, forgetting those older than a minute
+    fn count_render(&mut self, now: Instant) {
+        self.renders.push_back(now);
+        while self
+            .renders
+            .front()
+            .is_some_and(|at| now.saturating_duration_since(*at) >= RENDER_WINDOW)
+        {
+            self.renders.pop_front();
         }
+        self.performance_metrics.renders_per_minute = self.renders.len();
     }
 
     /// Render the debug view as a full-screen overlay
@@ -132,7 +163,10 @@ impl DebugView {
                 "CPU Usage: {:.1}%",
                 self.performance_metrics.cpu_usage_percent
             ),
-            format!("FPS: {}", self.performance_metrics.fps),
+            format!(
+                "Renders/min: {}",
+                self.performance_metrics.renders_per_minute
+            ),
         ];
 
         let left_text = Text::from(
@@ -423,3 +457,22 @@ impl Default for DebugView {
         Self::new()
     }
 }
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_renders_per_minute() {
+        let mut view = DebugView::new();
+        let start = Instant::now();
+        for secs in [0, 10, 30, 59] {
+            view.count_render(start + Duration::from_secs(secs));
+        }
+        assert_eq!(view.performance_metrics.renders_per_minute, 4);
+
+        // The first two fall out of the window
+        view.count_render(start + Duration::from_secs(70));
+        assert_eq!(view.performance_metrics.renders_per_minute, 3);
+    }
+}
@@ -0,0 +1,394 @@
+// FilePath: src/ui/components/export_form.rs
+
+//! Form exporting the rows of a result tab: a format, and the clipboard or a
+//! file to write them to. A table preview narrowed by filters or sorted can
+//! export the rows loaded, or every row passing its filters read again from
+//! the server. From the structure view it exports the table's columns
+//! instead, as Markdown at first. Formats whose output isn't text, like
+//! XLSX, only go to a file
+
+#![forbid(unsafe_code)]
+
+use crate::database::TableMetadata;
+use crate::io::export::{ExportFormat, FORMATS};
+use crate::ui::theme::Theme;
+use ratatui::{
+    layout::{Alignment, Rect},
+    style::{Modifier, Style},
+    text::{Line, Span},
+    widgets::{Block, Borders, Clear, Paragraph},
+    Frame,
+};
+
+/// Field of the export form taking keys
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum ExportField {
+    Format,
+    Rows,
+    Destination,
+    Path,
+}
+
+/// Where the rows go
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum ExportDestination {
+    Clipboard,
+    File,
+}
+
+/// Which rows of a filtered preview are exported
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum ExportRows {
+    /// The rows loaded in the tab
+    Loaded,
+    /// Every row passing the filters, read again without a LIMIT
+    Filtered,
+}
+
+impl ExportRows {
+    pub fn label(self) -> &'static str {
+        match self {
+            Self::Loaded => "loaded rows only",
+            Self::Filtered => "filtered rows (server-side)",
+        }
+    }
+}
+
+/// Progress of a running export, as in `1,500/~12,000`: rows written of the
+/// preview's row count, marked `~` when that is an estimate. Past the count,
+/// which an estimate can fall short of, only the rows written
+pub fn export_progress(rows: usize, total: usize, estimated: bool) -> String {
+    let written = super::group_thousands(rows);
+    if rows > total {
+        return written;
+    }
+    format!(
+        "{written}/{}{}",
+        if estimated { "~" } else { "" },
+        super::group_thousands(total)
+    )
+}
+
+/// An export being set up
+#[derive(Debug, Clone)]
+pub struct ExportForm {
+    /// Index into `FORMATS`
+    pub format: usize,
+    /// Rows to export; None when the tab only has the rows loaded
+    pub rows: Option<ExportRows>,
+    /// Export the columns of the structure view rather than rows
+    pub structure: bool,
+    pub destination: ExportDestination,
+    pub path: String,
+    pub field: ExportField,
+    /// Size of a large clipboard copy waiting for Enter again
+    pub confirm_large: Option<usize>,
+    /// File name the path is made of until one is typed
+    stem: String,
+    path_edited: bool,
+}
+
+impl ExportForm {
+    /// Form for a tab's rows, to the clipboard as CSV at first. The path
+    /// starts as `<stem>.<extension>` and follows the format until edited
+    pub fn new(stem: &str) -> Self {
+        let stem: String = stem
+            .chars()
+            .map(|c| {
+                if c.is_alphanumeric() || matches!(c, '-' | '_' | '.') {
+                    c
+                } else {
+                    '_'
+                }
+            })
+            .collect();
+        let mut form = Self {
+            format: 0,
+            rows: None,
+            structure: false,
+            destination: ExportDestination::Clipboard,
+            path: String::new(),
+            field: ExportField::Format,
+            confirm_large: None,
+            stem,
+            path_edited: false,
+        };
+        form.update_path();
+        form
+    }
+
+    /// Form for the columns of a table's structure view, as Markdown until
+    /// another format is picked
+    pub fn for_structure(table: &str) -> Self {
+        let mut form = Self::new(&format!("{table}_structure"));
+        form.structure = true;
+        form.format = FORMATS
+            .iter()
+            .position(|info| info.format == ExportFormat::Markdown)
+            .unwrap_or_default();
+        form.update_path();
+        form
+    }
+
+    /// Offer the choice between the loaded rows and every row passing the
+    /// preview's filters
+    pub fn with_filtered_rows(mut self) -> Self {
+        self.rows = Some(ExportRows::Loaded);
+        self
+    }
+
+    pub fn format(&self) -> ExportFormat {
+        FORMATS[self.format].format
+    }
+
+    /// Whether the rows are read again from the server
+    pub fn exports_filtered(&self) -> bool {
+        self.rows == Some(ExportRows::Filtered)
+    }
+
+    /// Move to the next field, skipping the rows without a choice and the
+    /// path for the clipboard
+    pub fn next_field(&mut self) {
+        self.field = match self.field {
+            ExportField::Format if self.rows.is_some() => ExportField::Rows,
+            ExportField::Format | ExportField::Rows => ExportField::Destination,
+            ExportField::Destination if self.destination == ExportDestination::File => {
+                ExportField::Path
+            }
+            ExportField::Destination | ExportField::Path => ExportField::Format,
+        };
+    }
+
+    pub fn prev_field(&mut self) {
+        self.field = match self.field {
+            ExportField::Format if self.destination == ExportDestination::File => ExportField::Path,
+            ExportField::Format | ExportField::Path => ExportField::Destination,
+            ExportField::Destination if self.rows.is_some() => ExportField::Rows,
+            ExportField::Destination | ExportField::Rows => ExportField::Format,
+        };
+    }
+
+    /// Pick the next (or previous) format or destination, in the field
+    /// taking keys
+    pub fn cycle(&mut self, forward: bool) {
+        match self.field {
+            ExportField::Format => {
+                let count = FORMATS.len();
+                self.format = if forward {
+                    (self.format + 1) % count
+                } else {
+                    (self.format + count - 1) % count
+                };
+                if self.format().is_binary() {
+                    self.destination = ExportDestination::File;
+                }
+                self.update_path();
+            }
+            ExportField::Rows => {
+                self.rows = match self.rows {
+                    Some(ExportRows::Loaded) => Some(ExportRows::Filtered),
+                    _ => Some(ExportRows::Loaded),
+                };
+            }
+            ExportField::Destination => {
+                self.destination = match self.destination {
+                    ExportDestination::Clipboard => ExportDestination::File,
+                    ExportDestination::File if self.format().is_binary() => ExportDestination::File,
+                    ExportDestination::File => ExportDestination::Clipboard,
+                };
+            }
+            ExportField::Path => return,
+        }
+        self.confirm_large = None;
+    }
+
+    pub fn input(&mut self, c: char) {
+        if self.field == ExportField::Path {
+            self.path.push(c);
+            self.path_edited = true;
+        }
+    }
+
+    pub fn backspace(&mut self) {
+        if self.field == ExportField::Path {
+            self.path.pop();
+            self.path_edited = true;
+        }
+    }
+
+    /// Take the path Tab completed
+    pub fn complete_path(&mut self, path: String) {
+        self.path = path;
+        self.path_edited = true;
+        self.confirm_large = None;
+    }
+
+    fn update_path(&mut self) {
+        if !self.path_edited {
+            self.path = format!("{}.{}", self.stem, self.format().extension());
+        }
+    }
+}
+
+/// Render the form as a small centered popup
+pub fn render_export_form(f: &mut Frame, form: &ExportForm, area: Rect, theme: &Theme) {
+    let label = Style::default().fg(theme.get_color("text_secondary"));
+    let value = Style::default().fg(theme.get_color("text_primary"));
+    let muted = Style::default().fg(theme.get_color("text_muted"));
+    let active = Style::default()
+        .fg(theme.get_color("accent"))
+        .add_modifier(Modifier::BOLD);
+    let row = |name: &str, field: ExportField, text: String| {
+        let focused = form.field == field;
+        let text = match (focused, field) {
+            (true, ExportField::Path) => format!("{text}▏"),
+            (true, _) => format!("‹ {text} ›"),
+            (false, _) => text,
+        };
+        Line::from(vec![
+            Span::styled(format!("{name:<13}"), label),
+            Span::styled(text, if focused { active } else { value }),
+        ])
+    };
+
+    let format = form.format().info().label;
+    let destination = match form.destination {
+        ExportDestination::Clipboard => "Clipboard",
+        ExportDestination::File if form.format().is_binary() => "File (not text, so no clipboard)",
+        ExportDestination::File => "File",
+    };
+    let mut lines = vec![row("Format", ExportField::Format, format.to_string())];
+    if let Some(rows) = form.rows {
+        lines.push(row("Rows", ExportField::Rows, rows.label().to_string()));
+    }
+    lines.push(row(
+        "Destination",
+        ExportField::Destination,
+        destination.to_string(),
+    ));
+    if form.destination == ExportDestination::File {
+        lines.push(row("Path", ExportField::Path, form.path.clone()));
+    }
+    lines.push(Line::from(""));
+    if let Some(size) = form.confirm_large {
+        lines.push(Line::from(Span::styled(
+            format!(
+                "{} is a lot for a clipboard; Enter again to copy",
+                TableMetadata::format_size(size as i64)
+            ),
+            Style::default().fg(theme.get_color("warning")),
+        )));
+    }
+    lines.push(Line::from(Span::styled(
+        "Tab next field (completes a path) · ↑/↓ change · Enter export · Esc cancel",
+        muted,
+    )));
+
+    let width = 60u16.min(area.width.saturating_sub(4));
+    let height = (lines.len() as u16 + 2).min(area.height);
+    let popup = Rect {
+        x: area.x + area.width.saturating_sub(width) / 2,
+        y: area.y + area.height.saturating_sub(height) / 2,
+        width,
+        height,
+    };
+    f.render_widget(Clear, popup);
+    let block = Block::default()
+        .borders(Borders::ALL)
+        .title(if form.structure {
+            " Export structure "
+        } else {
+            " Export rows "
+        })
+        .title_alignment(Alignment::Center)
+        .border_style(
+            Style::default()
+                .fg(theme.get_color("accent"))
+                .add_modifier(Modifier::BOLD),
+        )
+        .style(Style::default().bg(theme.get_color("background")));
+    f.render_widget(Paragraph::new(lines).block(block), popup);
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_export_progress() {
+        assert_eq!(export_progress(1_500, 12_000, true), "1,500/~12,000");
+        assert_eq!(export_progress(500, 800, false), "500/800");
+        assert_eq!(export_progress(13_000, 12_000, true), "13,000");
+    }
+
+    #[test]
+    fn test_path_follows_format_until_edited() {
+        let mut form = ExportForm::new("public.orders (1)");
+        assert_eq!(form.path, "public.orders__1_.csv");
+        form.cycle(true);
+        assert_eq!(form.format(), ExportFormat::Tsv);
+        assert_eq!(form.path, "public.orders__1_.tsv");
+
+        form.field = ExportField::Path;
+        form.backspace();
+        form.cycle(true);
+        assert_eq!(form.path, "public.orders__1_.ts");
+    }
+
+    #[test]
+    fn test_binary_formats_only_go_to_files() {
+        let mut form = ExportForm::new("orders");
+        form.cycle(false);
+        assert_eq!(form.format(), ExportFormat::Xlsx);
+        assert_eq!(form.destination, ExportDestination::File);
+        assert_eq!(form.path, "orders.xlsx");
+
+        form.field = ExportField::Destination;
+        form.cycle(true);
+        assert_eq!(form.destination, ExportDestination::File);
+
+        form.field = ExportField::Format;
+        form.cycle(true);
+        form.field = ExportField::Destination;
+        form.cycle(true);
+        assert_eq!(form.destination, ExportDestination::Clipboard);
+    }
+
+    #[test]
+    fn test_path_field_only_for_files() {
+        let mut form = ExportForm::new("orders");
+        form.next_field();
+        form.next_field();
+        assert_eq!(form.field, ExportField::Format);
+
+        form.field = ExportField::Destination;
+        form.cycle(true);
+        assert_eq!(form.destination, ExportDestination::File);
+        form.next_field();
+        assert_eq!(form.field, ExportField::Path);
+    }
+
+    #[test]
+    fn test_structure_starts_as_markdown() {
+        let form = ExportForm::for_structure("public.orders");
+        assert!(form.structure);
+        assert_eq!(form.format(), ExportFormat::Markdown);
+        assert_eq!(form.path, "public.orders_structure.md");
+    }
+
+    #[test]
+    fn test_rows_field_only_for_filtered_previews() {
+        let mut form = ExportForm::new("orders").with_filtered_rows();
+        assert!(!form.exports_filtered());
+        form.next_field();
+        assert_eq!(form.field, ExportField::Rows);
+        form.cycle(true);
+        assert!(form.exports_filtered());
+        form.prev_field();
+        assert_eq!(form.field, ExportField::Format);
+        form.prev_field();
+        assert_eq!(form.field, ExportField::Destination);
+        form.prev_field();
+        assert_eq!(form.field, ExportField::Rows);
+    }
+}
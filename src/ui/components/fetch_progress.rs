@@ -0,0 +1,161 @@
+// FilePath: src/ui/components/fetch_progress.rs
+
+#![forbid(unsafe_code)]
+
+use super::table_viewer::group_thousands;
+use crate::ui::theme::Theme;
+use ratatui::{
+    layout::Rect,
+    style::{Modifier, Style},
+    text::{Line, Span},
+    widgets::Paragraph,
+    Frame,
+};
+use std::sync::{
+    atomic::{AtomicBool, Ordering},
+    Arc,
+};
+use std::time::{Duration, Instant};
+
+/// Progress of a query editor statement that is running or whose rows are
+/// still arriving. The stop flag is shared with the fetching task, which
+/// stops reading once it is set and keeps the rows loaded so far
+#[derive(Debug, Clone)]
+pub struct FetchProgress {
+    started_at: Instant,
+    rows: usize,
+    stop: Arc<AtomicBool>,
+    /// The server was asked to cancel the statement
+    cancelling: bool,
+}
+
+impl FetchProgress {
+    pub fn new(now: Instant) -> Self {
+        Self {
+            started_at: now,
+            rows: 0,
+            stop: Arc::new(AtomicBool::new(false)),
+            cancelling: false,
+        }
+    }
+
+    /// Flag to hand to the fetching task
+    pub fn stop_flag(&self) -> Arc<AtomicBool> {
+        Arc::clone(&self.stop)
+    }
+
+    /// Record the rows fetched so far
+    pub fn set_rows(&mut self, rows: usize) {
+        self.rows = rows;
+    }
+
+    /// Rows fetched so far
+    pub fn rows(&self) -> usize {
+        self.rows
+    }
+
+    /// Time since the statement started
+    pub fn elapsed(&self) -> Duration {
+        self.started_at.elapsed()
+    }
+
+    /// Ask the fetching task to stop after the current row
+    pub fn request_stop(&self) {
+        self.stop.store(true, Ordering::SeqCst);
+    }
+
+    /// Whether a stop has been requested
+    pub fn is_stopping(&self) -> bool {
+        self.stop.load(Ordering::SeqCst)
+    }
+
+    /// Note the server was asked to cancel the statement, which also stops
+    /// fetching
+    pub fn mark_cancelling(&mut self) {
+        self.cancelling = true;
+        self.request_stop();
+    }
+
+    /// Whether the server was asked to cancel the statement
+    pub fn is_cancelling(&self) -> bool {
+        self.cancelling
+    }
+
+    /// Badge text, e.g. "running · 3.2s" until the first row arrives and
+    /// "fetched 12,500 rows · 3.4s" after
+    pub fn badge(&self, now: Instant) -> String {
+        let elapsed = now.saturating_duration_since(self.started_at);
+        let state = if self.cancelling {
+            "cancelling · "
+        } else if self.is_stopping() {
+            "stopping · "
+        } else {
+            ""
+        };
+        let progress = if self.rows == 0 {
+            "running".to_string()
+        } else {
+            format!("fetched {} rows", group_thousands(self.rows))
+        };
+        format!("{state}{progress} · {:.1}s", elapsed.as_secs_f64())
+    }
+
+    /// Draw the badge right-aligned over the bottom border of the output
+    /// panel, with the keys cancelling the statement and stopping the fetch
+    pub fn render(&self, frame: &mut Frame, area: Rect, theme: &Theme, cancel_key: &str) {
+        if area.height < 2 || area.width < 4 {
+            return;
+        }
+        let footer = Rect::new(area.x + 1, area.bottom() - 1, area.width - 2, 1);
+        let badge = Line::from(vec![
+            Span::styled(
+                format!(" {} ", self.badge(Instant::now())),
+                Style::default()
+                    .fg(theme.get_color("warning"))
+                    .add_modifier(Modifier::BOLD),
+            ),
+            Span::styled(
+                format!("{cancel_key} cancel · ctrl+c stop "),
+                Style::default().fg(theme.get_color("text_muted")),
+            ),
+        ])
+        .right_aligned();
+        frame.render_widget(Paragraph::new(badge), footer);
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::time::Duration;
+
+    #[test]
+    fn test_badge_shows_rows_elapsed_and_stop() {
+        let start = Instant::now();
+        let mut progress = FetchProgress::new(start);
+        assert_eq!(
+            progress.badge(start + Duration::from_millis(3_200)),
+            "running · 3.2s"
+        );
+
+        progress.set_rows(12_500);
+        assert_eq!(
+            progress.badge(start + Duration::from_millis(3_400)),
+            "fetched 12,500 rows · 3.4s"
+        );
+
+        progress.stop_flag().store(true, Ordering::SeqCst);
+        assert!(progress.is_stopping());
+        assert_eq!(
+            progress.badge(start + Duration::from_secs(4)),
+            "stopping · fetched 12,500 rows · 4.0s"
+        );
+
+        progress.mark_cancelling();
+        assert!(progress.is_cancelling());
+        assert_eq!(
+            progress.badge(start + Duration::from_secs(5)),
+            "cancelling · fetched 12,500 rows · 5.0s"
+        );
+    }
+}
@@ -0,0 +1,231 @@
+// FilePath: src/ui/components/filter_form.rs
+
+//! Form building a quick filter on a table preview: a column, an operator
+//! and a value
+
+#![forbid(unsafe_code)]
+
+use crate::database::{ColumnFilter, FilterOperator};
+use crate::ui::theme::Theme;
+use ratatui::{
+    layout::{Alignment, Rect},
+    style::{Modifier, Style},
+    text::{Line, Span},
+    widgets::{Block, Borders, Clear, Paragraph},
+    Frame,
+};
+
+/// Field of the filter form taking keys
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum FilterField {
+    Column,
+    Operator,
+    Value,
+}
+
+/// A filter being built
+#[derive(Debug, Clone)]
+pub struct FilterForm {
+    /// Name and data type of each column of the table
+    pub columns: Vec<(String, String)>,
+    pub column: usize,
+    /// Index into `FilterOperator::ALL`
+    pub operator: usize,
+    pub value: String,
+    pub field: FilterField,
+}
+
+impl FilterForm {
+    /// Form on the table's columns, starting at the selected one
+    pub fn new(columns: Vec<(String, String)>, selected_col: usize) -> Self {
+        let column = selected_col.min(columns.len().saturating_sub(1));
+        Self {
+            columns,
+            column,
+            operator: 0,
+            value: String::new(),
+            field: FilterField::Value,
+        }
+    }
+
+    pub fn operator(&self) -> FilterOperator {
+        FilterOperator::ALL[self.operator]
+    }
+
+    /// Move to the next field, skipping the value for `IS NULL`
+    pub fn next_field(&mut self) {
+        self.field = match self.field {
+            FilterField::Column => FilterField::Operator,
+            FilterField::Operator if self.operator().takes_value() => FilterField::Value,
+            FilterField::Operator | FilterField::Value => FilterField::Column,
+        };
+    }
+
+    pub fn prev_field(&mut self) {
+        self.field = match self.field {
+            FilterField::Column if self.operator().takes_value() => FilterField::Value,
+            FilterField::Column | FilterField::Value => FilterField::Operator,
+            FilterField::Operator => FilterField::Column,
+        };
+    }
+
+    /// Pick the next (or previous) column or operator, in the field taking
+    /// keys
+    pub fn cycle(&mut self, forward: bool) {
+        let (choice, count) = match self.field {
+            FilterField::Column => (&mut self.column, self.columns.len()),
+            FilterField::Operator => (&mut self.operator, FilterOperator::ALL.len()),
+            FilterField::Value => return,
+        };
+        if count == 0 {
+            return;
+        }
+        *choice = if forward {
+            (*choice + 1) % count
+        } else {
+            (*choice + count - 1) % count
+        };
+    }
+
+    pub fn input(&mut self, c: char) {
+        if self.field == FilterField::Value {
+            self.value.push(c);
+        }
+    }
+
+    pub fn backspace(&mut self) {
+        if self.field == FilterField::Value {
+            self.value.pop();
+        }
+    }
+
+    /// The filter, or why it can't be applied yet
+    pub fn filter(&self) -> Result<ColumnFilter, String> {
+        let (column, data_type) = self
+            .columns
+            .get(self.column)
+            .ok_or_else(|| "The table has no columns to filter".to_string())?;
+        let operator = self.operator();
+        if operator.takes_value() && self.value.is_empty() {
+            return Err(format!("Enter a value to compare {column} with"));
+        }
+        Ok(ColumnFilter {
+            column: column.clone(),
+            data_type: data_type.clone(),
+            operator,
+            value: if operator.takes_value() {
+                self.value.clone()
+            } else {
+                String::new()
+            },
+        })
+    }
+}
+
+/// Render the form as a small centered popup
+pub fn render_filter_form(f: &mut Frame, form: &FilterForm, area: Rect, theme: &Theme) {
+    let label = Style::default().fg(theme.get_color("text_secondary"));
+    let value = Style::default().fg(theme.get_color("text_primary"));
+    let active = Style::default()
+        .fg(theme.get_color("accent"))
+        .add_modifier(Modifier::BOLD);
+    let row = |name: &str, field: FilterField, text: String| {
+        let focused = form.field == field;
+        let text = match (focused, field) {
+            (true, FilterField::Value) => format!("{text}▏"),
+            (true, _) => format!("‹ {text} ›"),
+            (false, _) => text,
+        };
+        Line::from(vec![
+            Span::styled(format!("{name:<10}"), label),
+            Span::styled(text, if focused { active } else { value }),
+        ])
+    };
+
+    let column = form
+        .columns
+        .get(form.column)
+        .map(|(name, data_type)| format!("{name} ({data_type})"))
+        .unwrap_or_default();
+    let mut lines = vec![
+        row("Column", FilterField::Column, column),
+        row(
+            "Operator",
+            FilterField::Operator,
+            form.operator().symbol().to_string(),
+        ),
+    ];
+    if form.operator().takes_value() {
+        lines.push(row("Value", FilterField::Value, form.value.clone()));
+    }
+    lines.push(Line::from(""));
+    lines.push(Line::from(Span::styled(
+        "Tab next field · ↑/↓ change · Enter apply · Esc cancel",
+        Style::default().fg(theme.get_color("text_muted")),
+    )));
+
+    let width = 60u16.min(area.width.saturating_sub(4));
+    let height = (lines.len() as u16 + 2).min(area.height);
+    let popup = Rect {
+        x: area.x + area.width.saturating_sub(width) / 2,
+        y: area.y + area.height.saturating_sub(height) / 2,
+        width,
+        height,
+    };
+    f.render_widget(Clear, popup);
+    let block = Block::default()
+        .borders(Borders::ALL)
+        .title(" Filter rows ")
+        .title_alignment(Alignment::Center)
+        .border_style(
+            Style::default()
+                .fg(theme.get_color("accent"))
+                .add_modifier(Modifier::BOLD),
+        )
+        .style(Style::default().bg(theme.get_color("background")));
+    f.render_widget(Paragraph::new(lines).block(block), popup);
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn form() -> FilterForm {
+        FilterForm::new(
+            vec![
+                ("id".to_string(), "INTEGER".to_string()),
+                ("email".to_string(), "TEXT".to_string()),
+            ],
+            1,
+        )
+    }
+
+    #[test]
+    fn test_filter_form_builds_a_filter() {
+        let mut form = form();
+        assert!(form.filter().is_err());
+        for c in "%@example.com".chars() {
+            form.input(c);
+        }
+        form.field = FilterField::Operator;
+        form.cycle(true);
+        form.cycle(true);
+        let filter = form.filter().unwrap();
+        assert_eq!(filter.column, "email");
+        assert_eq!(filter.operator, FilterOperator::Like);
+        assert_eq!(filter.value, "%@example.com");
+    }
+
+    #[test]
+    fn test_is_null_skips_the_value() {
+        let mut form = form();
+        form.field = FilterField::Operator;
+        form.cycle(false);
+        form.cycle(false);
+        form.cycle(false);
+        assert_eq!(form.operator(), FilterOperator::IsNull);
+        form.next_field();
+        assert_eq!(form.field, FilterField::Column);
+        assert_eq!(form.filter().unwrap().to_string(), "email IS NULL");
+    }
+}
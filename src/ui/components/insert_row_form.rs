@@ -0,0 +1,360 @@
+// FilePath: src/ui/components/insert_row_form.rs
+
+//! Form inserting a row into a table, one field per column an insert can
+//! set
+
+#![forbid(unsafe_code)]
+
+use crate::database::{InsertValue, TableColumn, NULL_CELL};
+use crate::ui::theme::Theme;
+use ratatui::{
+    layout::{Alignment, Rect},
+    style::{Modifier, Style},
+    text::{Line, Span},
+    widgets::{Block, Borders, Clear, Paragraph},
+    Frame,
+};
+
+/// One column of the row being inserted
+#[derive(Debug, Clone)]
+pub struct InsertField {
+    pub column: String,
+    pub data_type: String,
+    pub nullable: bool,
+    /// Default expression of the column
+    pub default: Option<String>,
+    pub value: String,
+    /// Left out of the insert, so the column takes its default or NULL
+    pub skipped: bool,
+}
+
+impl InsertField {
+    fn new(column: &TableColumn) -> Self {
+        let literal = column.default_value.as_deref().and_then(literal_default);
+        Self {
+            column: column.name.clone(),
+            data_type: column.data_type.to_sql(),
+            nullable: column.is_nullable,
+            default: column.default_value.clone(),
+            skipped: literal.is_none() && (column.is_nullable || column.default_value.is_some()),
+            value: literal.unwrap_or_default(),
+        }
+    }
+
+    /// Whether the insert can leave the column out
+    pub fn can_skip(&self) -> bool {
+        self.nullable || self.default.is_some()
+    }
+
+    /// What a skipped field stands for
+    fn skipped_label(&self) -> String {
+        match &self.default {
+            Some(default) => format!("DEFAULT {default}"),
+            None => "NULL".to_string(),
+        }
+    }
+}
+
+/// Value of a default that is a plain literal, e.g. `0` or
+/// `'draft'::character varying`; None for expressions such as `now()`,
+/// which are left for the database to evaluate
+fn literal_default(default: &str) -> Option<String> {
+    let mut literal = default.trim();
+    // PostgreSQL casts its defaults, sometimes more than once
+    while let Some((value, cast)) = literal.rsplit_once("::") {
+        if cast.contains('\'') {
+            break;
+        }
+        literal = value.trim();
+    }
+    if let Some(quoted) = literal
+        .strip_prefix('\'')
+        .and_then(|rest| rest.strip_suffix('\''))
+    {
+        return (!quoted.contains('\'') || quoted.contains("''"))
+            .then(|| quoted.replace("''", "'"));
+    }
+    let number = literal.strip_prefix('-').unwrap_or(literal);
+    let is_number = !number.is_empty()
+        && number.chars().all(|c| c.is_ascii_digit() || c == '.')
+        && number.chars().filter(|&c| c == '.').count() <= 1;
+    let is_boolean = matches!(literal.to_lowercase().as_str(), "true" | "false");
+    (is_number || is_boolean).then(|| literal.to_string())
+}
+
+/// A row being inserted into a table
+#[derive(Debug, Clone)]
+pub struct InsertRowForm {
+    pub table_name: String,
+    pub fields: Vec<InsertField>,
+    pub selected: usize,
+}
+
+impl InsertRowForm {
+    /// Form on the table's columns, leaving out the ones the database fills
+    /// in: identity and auto-increment columns, and generated ones
+    pub fn new(table_name: String, columns: &[TableColumn]) -> Self {
+        Self {
+            table_name,
+            fields: columns
+                .iter()
+                .filter(|col| !col.is_auto_increment && col.generated.is_none())
+                .map(InsertField::new)
+                .collect(),
+            selected: 0,
+        }
+    }
+
+    /// Start from the values of an existing row, by column, with its
+    /// primary key cleared for the database or the user to fill in. NULL
+    /// cells are left out, taking the column's default or NULL
+    pub fn copy_row(&mut self, row: &[(String, String)], primary_key: &[String]) {
+        for field in &mut self.fields {
+            if primary_key.contains(&field.column) {
+                field.value.clear();
+                field.skipped = field.can_skip();
+                continue;
+            }
+            let Some((_, value)) = row.iter().find(|(column, _)| column == &field.column) else {
+                continue;
+            };
+            if value == NULL_CELL && field.can_skip() {
+                field.value.clear();
+                field.skipped = true;
+            } else {
+                field.value = value.clone();
+                field.skipped = false;
+            }
+        }
+    }
+
+    pub fn next_field(&mut self) {
+        if !self.fields.is_empty() {
+            self.selected = (self.selected + 1) % self.fields.len();
+        }
+    }
+
+    pub fn prev_field(&mut self) {
+        if !self.fields.is_empty() {
+            self.selected = (self.selected + self.fields.len() - 1) % self.fields.len();
+        }
+    }
+
+    /// Type into the selected field, which then goes into the insert
+    pub fn input(&mut self, c: char) {
+        if let Some(field) = self.fields.get_mut(self.selected) {
+            field.skipped = false;
+            field.value.push(c);
+        }
+    }
+
+    pub fn backspace(&mut self) {
+        if let Some(field) = self.fields.get_mut(self.selected) {
+            field.value.pop();
+        }
+    }
+
+    /// Leave the selected column out of the insert, or put it back in.
+    /// Returns false for a column that has to be set
+    pub fn toggle_skip(&mut self) -> bool {
+        match self.fields.get_mut(self.selected) {
+            Some(field) if field.can_skip() => {
+                field.skipped = !field.skipped;
+                true
+            }
+            _ => false,
+        }
+    }
+
+    /// Values of the columns to insert, or why the row can't be inserted yet
+    pub fn values(&self) -> Result<Vec<InsertValue>, String> {
+        let mut values = Vec::new();
+        for field in &self.fields {
+            if field.skipped {
+                continue;
+            }
+            if field.value.is_empty() && !field.can_skip() {
+                return Err(format!("Enter a value for {}", field.column));
+            }
+            values.push(InsertValue {
+                column: field.column.clone(),
+                value: Some(field.value.clone()),
+            });
+        }
+        Ok(values)
+    }
+}
+
+/// Render the form as a centered popup, scrolled to the selected field
+pub fn render_insert_row_form(f: &mut Frame, form: &InsertRowForm, area: Rect, theme: &Theme) {
+    let label = Style::default().fg(theme.get_color("text_secondary"));
+    let value = Style::default().fg(theme.get_color("text_primary"));
+    let muted = Style::default().fg(theme.get_color("text_muted"));
+    let active = Style::default()
+        .fg(theme.get_color("accent"))
+        .add_modifier(Modifier::BOLD);
+
+    let width = 72u16.min(area.width.saturating_sub(4));
+    let height = (form.fields.len() as u16 + 4).min(area.height.saturating_sub(2));
+    let visible = (height as usize).saturating_sub(4).max(1);
+    let first = form.selected.saturating_sub(visible - 1);
+    let name_width = form
+        .fields
+        .iter()
+        .map(|field| field.column.len() + 2)
+        .max()
+        .unwrap_or(0)
+        .min(24);
+
+    let mut lines: Vec<Line> = form
+        .fields
+        .iter()
+        .enumerate()
+        .skip(first)
+        .take(visible)
+        .map(|(idx, field)| {
+            let focused = idx == form.selected;
+            let required = if field.can_skip() { " " } else { "*" };
+            let (text, style) = if field.skipped {
+                (field.skipped_label(), muted)
+            } else if focused {
+                (format!("{}▏", field.value), active)
+            } else {
+                (field.value.clone(), value)
+            };
+            Line::from(vec![
+                Span::styled(
+                    format!("{:<name_width$}", format!("{}{required}", field.column)),
+                    if focused { active } else { label },
+                ),
+                Span::styled(text, style),
+                Span::styled(format!("  {}", field.data_type), muted),
+            ])
+        })
+        .collect();
+    if form.fields.is_empty() {
+        lines.push(Line::from(Span::styled(
+            "Every column is filled in by the database",
+            muted,
+        )));
+    }
+    lines.push(Line::from(""));
+    lines.push(Line::from(Span::styled(
+        "Tab/↑/↓ field · Ctrl+N default/NULL · Enter insert · Esc cancel",
+        muted,
+    )));
+
+    let popup = Rect {
+        x: area.x + area.width.saturating_sub(width) / 2,
+        y: area.y + area.height.saturating_sub(height) / 2,
+        width,
+        height,
+    };
+    f.render_widget(Clear, popup);
+    let block = Block::default()
+        .borders(Borders::ALL)
+        .title(format!(" Insert into {} ", form.table_name))
+        .title_alignment(Alignment::Center)
+        .border_style(
+            Style::default()
+                .fg(theme.get_color("accent"))
+                .add_modifier(Modifier::BOLD),
+        )
+        .style(Style::default().bg(theme.get_color("background")));
+    f.render_widget(Paragraph::new(lines).block(block), popup);
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::database::{DataType, GeneratedColumn};
+
+    fn column(name: &str, nullable: bool, default: Option<&str>) -> TableColumn {
+        TableColumn {
+            name: name.to_string(),
+            data_type: DataType::Text,
+            is_nullable: nullable,
+            default_value: default.map(str::to_string),
+            is_primary_key: false,
+            is_auto_increment: false,
+            generated: None,
+            comment: None,
+        }
+    }
+
+    #[test]
+    fn test_literal_defaults() {
+        assert_eq!(
+            literal_default("'draft'::character varying").as_deref(),
+            Some("draft")
+        );
+        assert_eq!(literal_default("'it''s'::text").as_deref(), Some("it's"));
+        assert_eq!(literal_default("-1.5").as_deref(), Some("-1.5"));
+        assert_eq!(literal_default("(0)::numeric"), None);
+        assert_eq!(literal_default("now()"), None);
+        assert_eq!(literal_default("CURRENT_TIMESTAMP"), None);
+    }
+
+    #[test]
+    fn test_insert_form_fields() {
+        let mut id = column("id", false, Some("nextval('users_id_seq'::regclass)"));
+        id.is_auto_increment = true;
+        let mut search = column("search", true, None);
+        search.generated = Some(GeneratedColumn {
+            stored: true,
+            expression: None,
+        });
+        let columns = [
+            id,
+            column("email", false, None),
+            column("status", false, Some("'active'::text")),
+            column("created_at", false, Some("now()")),
+            column("note", true, None),
+            search,
+        ];
+        let mut form = InsertRowForm::new("users".to_string(), &columns);
+
+        let names: Vec<&str> = form.fields.iter().map(|f| f.column.as_str()).collect();
+        assert_eq!(names, ["email", "status", "created_at", "note"]);
+        assert_eq!(form.values().unwrap_err(), "Enter a value for email");
+        assert!(!form.toggle_skip());
+
+        for c in "a@example.com".chars() {
+            form.input(c);
+        }
+        let values = form.values().unwrap();
+        assert_eq!(values.len(), 2);
+        assert_eq!(values[1].column, "status");
+        assert_eq!(values[1].value.as_deref(), Some("active"));
+
+        form.selected = 3;
+        form.input('x');
+        assert!(form.toggle_skip());
+        assert_eq!(form.values().unwrap().len(), 2);
+    }
+
+    #[test]
+    fn test_copy_row_clears_the_primary_key() {
+        let mut code = column("code", false, None);
+        code.is_primary_key = true;
+        let columns = [
+            code,
+            column("name", false, None),
+            column("note", true, Some("'none'::text")),
+        ];
+        let mut form = InsertRowForm::new("products".to_string(), &columns);
+        let row = [
+            ("code".to_string(), "SKU-1".to_string()),
+            ("name".to_string(), "Mug".to_string()),
+            ("note".to_string(), "NULL".to_string()),
+        ];
+        form.copy_row(&row, &["code".to_string()]);
+
+        assert_eq!(form.values().unwrap_err(), "Enter a value for code");
+        form.input('2');
+        let values = form.values().unwrap();
+        assert_eq!(values.len(), 2);
+        assert_eq!(values[0].value.as_deref(), Some("2"));
+        assert_eq!(values[1].value.as_deref(), Some("Mug"));
+    }
+}
@@ -0,0 +1,453 @@
+// FilePath: src/ui/components/json_view.rs
+
+#![forbid(unsafe_code)]
+
+use crate::ui::theme::Theme;
+use ratatui::{
+    layout::Rect,
+    style::{Modifier, Style},
+    text::{Line, Span},
+    widgets::Paragraph,
+    Frame,
+};
+use serde::de::{Deserialize, Deserializer, MapAccess, SeqAccess, Visitor};
+use std::collections::HashSet;
+use std::fmt;
+
+/// Arrays with more items than this start folded
+pub const FOLD_ARRAY_ITEMS: usize = 20;
+
+/// Spaces per nesting level
+const INDENT: usize = 2;
+
+/// A JSON value that keeps object keys in document order, so a row_to_json
+/// result reads in column order
+#[derive(Debug, Clone, PartialEq)]
+pub enum JsonNode {
+    Null,
+    Bool(bool),
+    Number(serde_json::Number),
+    String(String),
+    Array(Vec<JsonNode>),
+    Object(Vec<(String, JsonNode)>),
+}
+
+impl<'de> Deserialize<'de> for JsonNode {
+    fn deserialize<D: Deserializer<'de>>(deserializer: D) -> Result<Self, D::Error> {
+        struct NodeVisitor;
+
+        impl<'de> Visitor<'de> for NodeVisitor {
+            type Value = JsonNode;
+
+            fn expecting(&self, f: &mut fmt::Formatter) -> fmt::Result {
+                f.write_str("a JSON value")
+            }
+
+            fn visit_unit<E>(self) -> Result<JsonNode, E> {
+                Ok(JsonNode::Null)
+            }
+
+            fn visit_bool<E>(self, v: bool) -> Result<JsonNode, E> {
+                Ok(JsonNode::Bool(v))
+            }
+
+            fn visit_i64<E>(self, v: i64) -> Result<JsonNode, E> {
+                Ok(JsonNode::Number(v.into()))
+            }
+
+            fn visit_u64<E>(self, v: u64) -> Result<JsonNode, E> {
+                Ok(JsonNode::Number(v.into()))
+            }
+
+            fn visit_f64<E>(self, v: f64) -> Result<JsonNode, E> {
+                Ok(serde_json::Number::from_f64(v).map_or(JsonNode::Null, JsonNode::Number))
+            }
+
+            fn visit_str<E>(self, v: &str) -> Result<JsonNode, E> {
+                Ok(JsonNode::String(v.to_string()))
+            }
+
+            fn visit_string<E>(self, v: String) -> Result<JsonNode, E> {
+                Ok(JsonNode::String(v))
+            }
+
+            fn visit_seq<A: SeqAccess<'de>>(self, mut seq: A) -> Result<JsonNode, A::Error> {
+                let mut items = Vec::new();
+                while let Some(item) = seq.next_element()? {
+                    items.push(item);
+                }
+                Ok(JsonNode::Array(items))
+            }
+
+            fn visit_map<A: MapAccess<'de>>(self, mut map: A) -> Result<JsonNode, A::Error> {
+                let mut entries = Vec::new();
+                while let Some(entry) = map.next_entry()? {
+                    entries.push(entry);
+                }
+                Ok(JsonNode::Object(entries))
+            }
+        }
+
+        deserializer.deserialize_any(NodeVisitor)
+    }
+}
+
+/// Kind of a piece of a rendered line, for coloring
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+enum Token {
+    Key,
+    String,
+    Number,
+    Literal,
+    Punctuation,
+    Fold,
+}
+
+/// A rendered line of the document
+#[derive(Debug, Clone)]
+struct JsonLine {
+    indent: usize,
+    parts: Vec<(String, Token)>,
+    /// Path of the container opened (or folded) on this line
+    container: Option<Vec<usize>>,
+}
+
+impl JsonLine {
+    #[cfg(test)]
+    fn text(&self) -> String {
+        let mut text = " ".repeat(self.indent);
+        for (part, _) in &self.parts {
+            text.push_str(part);
+        }
+        text
+    }
+}
+
+/// Pretty-printed, foldable view of a query result that is a single JSON
+/// document. Containers are addressed by the child indexes leading to them
+#[derive(Debug, Clone)]
+pub struct JsonView {
+    root: JsonNode,
+    folded: HashSet<Vec<usize>>,
+    lines: Vec<JsonLine>,
+    /// Line under the cursor
+    pub selected_line: usize,
+    /// First visible line
+    pub scroll_offset: usize,
+    /// Visible lines (updated on render)
+    pub viewport_height: usize,
+}
+
+impl JsonView {
+    /// View of `text` if it is a JSON object or array; scalars and invalid
+    /// JSON stay in the grid
+    pub fn parse(text: &str) -> Option<Self> {
+        let root: JsonNode = serde_json::from_str(text.trim()).ok()?;
+        if !matches!(root, JsonNode::Array(_) | JsonNode::Object(_)) {
+            return None;
+        }
+
+        let mut folded = HashSet::new();
+        collect_large_arrays(&root, &mut Vec::new(), &mut folded);
+        let mut view = Self {
+            root,
+            folded,
+            lines: Vec::new(),
+            selected_line: 0,
+            scroll_offset: 0,
+            viewport_height: 0,
+        };
+        view.rebuild();
+        Some(view)
+    }
+
+    /// Number of rendered lines
+    pub fn line_count(&self) -> usize {
+        self.lines.len()
+    }
+
+    /// Fold or unfold the container opened on the selected line
+    pub fn toggle_fold(&mut self) {
+        let Some(path) = self
+            .lines
+            .get(self.selected_line)
+            .and_then(|line| line.container.clone())
+        else {
+            return;
+        };
+        if !self.folded.remove(&path) {
+            self.folded.insert(path.clone());
+        }
+        self.rebuild();
+        // Keep the cursor on the container that was toggled
+        if let Some(index) = self
+            .lines
+            .iter()
+            .position(|line| line.container.as_ref() == Some(&path))
+        {
+            self.selected_line = index;
+        }
+    }
+
+    pub fn move_down(&mut self, lines: usize) {
+        self.selected_line = (self.selected_line + lines).min(self.lines.len().saturating_sub(1));
+    }
+
+    pub fn move_up(&mut self, lines: usize) {
+        self.selected_line = self.selected_line.saturating_sub(lines);
+    }
+
+    pub fn jump_to_top(&mut self) {
+        self.selected_line = 0;
+    }
+
+    pub fn jump_to_bottom(&mut self) {
+        self.selected_line = self.lines.len().saturating_sub(1);
+    }
+
+    fn rebuild(&mut self) {
+        let mut lines = Vec::new();
+        self.push_node(&self.root, &mut Vec::new(), 0, None, false, &mut lines);
+        self.lines = lines;
+        self.selected_line = self.selected_line.min(self.lines.len().saturating_sub(1));
+    }
+
+    fn push_node(
+        &self,
+        node: &JsonNode,
+        path: &mut Vec<usize>,
+        indent: usize,
+        key: Option<&str>,
+        comma: bool,
+        out: &mut Vec<JsonLine>,
+    ) {
+        let mut parts = Vec::new();
+        if let Some(key) = key {
+            parts.push((quote(key), Token::Key));
+            parts.push((": ".to_string(), Token::Punctuation));
+        }
+        let trailing = |parts: &mut Vec<(String, Token)>| {
+            if comma {
+                parts.push((",".to_string(), Token::Punctuation));
+            }
+        };
+
+        let (open, close, len) = match node {
+            JsonNode::Array(items) => ("[", "]", items.len()),
+            JsonNode::Object(entries) => ("{", "}", entries.len()),
+            scalar => {
+                parts.push(scalar_part(scalar));
+                trailing(&mut parts);
+                out.push(JsonLine {
+                    indent,
+                    parts,
+                    container: None,
+                });
+                return;
+            }
+        };
+
+        if len == 0 {
+            parts.push((format!("{open}{close}"), Token::Punctuation));
+            trailing(&mut parts);
+            out.push(JsonLine {
+                indent,
+                parts,
+                container: None,
+            });
+            return;
+        }
+
+        if self.folded.contains(path) {
+            parts.push((open.to_string(), Token::Punctuation));
+            let noun = if matches!(node, JsonNode::Array(_)) {
+                "item"
+            } else {
+                "key"
+            };
+            parts.push((
+                format!(" … {} {}{} ", len, noun, if len == 1 { "" } else { "s" }),
+                Token::Fold,
+            ));
+            parts.push((close.to_string(), Token::Punctuation));
+            trailing(&mut parts);
+            out.push(JsonLine {
+                indent,
+                parts,
+                container: Some(path.clone()),
+            });
+            return;
+        }
+
+        parts.push((open.to_string(), Token::Punctuation));
+        out.push(JsonLine {
+            indent,
+            parts,
+            container: Some(path.clone()),
+        });
+        match node {
+            JsonNode::Array(items) => {
+                for (i, item) in items.iter().enumerate() {
+                    path.push(i);
+                    self.push_node(item, path, indent + INDENT, None, i + 1 < len, out);
+                    path.pop();
+                }
+            }
+            JsonNode::Object(entries) => {
+                for (i, (key, value)) in entries.iter().enumerate() {
+                    path.push(i);
+                    self.push_node(value, path, indent + INDENT, Some(key), i + 1 < len, out);
+                    path.pop();
+                }
+            }
+            _ => {}
+        }
+        let mut parts = vec![(close.to_string(), Token::Punctuation)];
+        trailing(&mut parts);
+        out.push(JsonLine {
+            indent,
+            parts,
+            container: None,
+        });
+    }
+
+    /// Render the visible lines into `area`, keeping the cursor in view
+    pub fn render(&mut self, f: &mut Frame, area: Rect, theme: &Theme, is_focused: bool) {
+        self.viewport_height = area.height as usize;
+        if self.selected_line < self.scroll_offset {
+            self.scroll_offset = self.selected_line;
+        } else if self.viewport_height > 0
+            && self.selected_line >= self.scroll_offset + self.viewport_height
+        {
+            self.scroll_offset = self.selected_line + 1 - self.viewport_height;
+        }
+
+        let lines: Vec<Line> =
+            self.lines
+                .iter()
+                .enumerate()
+                .skip(self.scroll_offset)
+                .take(self.viewport_height)
+                .map(|(index, line)| {
+                    let mut spans = vec![Span::raw(" ".repeat(line.indent))];
+                    spans.extend(line.parts.iter().map(|(text, token)| {
+                        Span::styled(text.clone(), token_style(*token, theme))
+                    }));
+                    let rendered = Line::from(spans);
+                    if is_focused && index == self.selected_line {
+                        rendered.style(Style::default().bg(theme.get_color("selection_bg")))
+                    } else {
+                        rendered
+                    }
+                })
+                .collect();
+
+        f.render_widget(Paragraph::new(lines), area);
+    }
+}
+
+/// Fold every array with more than `FOLD_ARRAY_ITEMS` items
+fn collect_large_arrays(node: &JsonNode, path: &mut Vec<usize>, folded: &mut HashSet<Vec<usize>>) {
+    match node {
+        JsonNode::Array(items) => {
+            if items.len() > FOLD_ARRAY_ITEMS {
+                folded.insert(path.clone());
+            }
+            for (i, item) in items.iter().enumerate() {
+                path.push(i);
+                collect_large_arrays(item, path, folded);
+                path.pop();
+            }
+        }
+        JsonNode::Object(entries) => {
+            for (i, (_, value)) in entries.iter().enumerate() {
+                path.push(i);
+                collect_large_arrays(value, path, folded);
+                path.pop();
+            }
+        }
+        _ => {}
+    }
+}
+
+fn scalar_part(node: &JsonNode) -> (String, Token) {
+    match node {
+        JsonNode::Null => ("null".to_string(), Token::Literal),
+        JsonNode::Bool(b) => (b.to_string(), Token::Literal),
+        JsonNode::Number(n) => (n.to_string(), Token::Number),
+        JsonNode::String(s) => (quote(s), Token::String),
+        JsonNode::Array(_) | JsonNode::Object(_) => (String::new(), Token::Punctuation),
+    }
+}
+
+/// A string as a JSON literal, escaped
+fn quote(s: &str) -> String {
+    serde_json::to_string(s).unwrap_or_else(|_| format!("\"{s}\""))
+}
+
+fn token_style(token: Token, theme: &Theme) -> Style {
+    match token {
+        Token::Key => Style::default().fg(theme.get_color("syntax_function")),
+        Token::String => Style::default().fg(theme.get_color("syntax_string")),
+        Token::Number => Style::default().fg(theme.get_color("syntax_number")),
+        Token::Literal => Style::default().fg(theme.get_color("syntax_keyword")),
+        Token::Punctuation => Style::default().fg(theme.get_color("syntax_operator")),
+        Token::Fold => Style::default()
+            .fg(theme.get_color("text_muted"))
+            .add_modifier(Modifier::ITALIC),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn text(view: &JsonView) -> Vec<String> {
+        view.lines.iter().map(JsonLine::text).collect()
+    }
+
+    #[test]
+    fn test_only_documents_are_detected() {
+        assert!(JsonView::parse(r#"{"a": 1}"#).is_some());
+        assert!(JsonView::parse("[1, 2]").is_some());
+        assert!(JsonView::parse("42").is_none());
+        assert!(JsonView::parse(r#""text""#).is_none());
+        assert!(JsonView::parse("{not json").is_none());
+    }
+
+    #[test]
+    fn test_pretty_print_keeps_key_order() {
+        let view =
+            JsonView::parse(r#"{"id":7,"name":"a\"b","tags":[],"meta":{"ok":true,"x":null}}"#)
+                .unwrap();
+        assert_eq!(
+            text(&view),
+            vec![
+                "{",
+                r#"  "id": 7,"#,
+                r#"  "name": "a\"b","#,
+                r#"  "tags": [],"#,
+                r#"  "meta": {"#,
+                r#"    "ok": true,"#,
+                r#"    "x": null"#,
+                "  }",
+                "}",
+            ]
+        );
+    }
+
+    #[test]
+    fn test_large_arrays_start_folded_and_toggle() {
+        let items: Vec<String> = (0..FOLD_ARRAY_ITEMS + 1).map(|i| i.to_string()).collect();
+        let mut view = JsonView::parse(&format!(r#"{{"rows":[{}]}}"#, items.join(","))).unwrap();
+        assert_eq!(text(&view), vec!["{", r#"  "rows": [ … 21 items ]"#, "}"]);
+
+        view.move_down(1);
+        view.toggle_fold();
+        assert_eq!(view.line_count(), FOLD_ARRAY_ITEMS + 1 + 4);
+        assert_eq!(view.selected_line, 1);
+
+        view.toggle_fold();
+        assert_eq!(view.line_count(), 3);
+    }
+}
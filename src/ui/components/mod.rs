@@ -2,22 +2,62 @@
 
 #![forbid(unsafe_code)]
 
+pub mod column_jump;
+pub mod column_stats;
 pub mod connection_modal;
 pub mod connection_mode;
 pub mod debug_view;
+pub mod export_form;
+pub mod fetch_progress;
+pub mod filter_form;
+pub mod insert_row_form;
+pub mod json_view;
+pub mod notifications;
+pub mod object_tree;
+pub mod path_input;
+pub mod pins;
+pub mod plan_view;
 pub mod query_editor;
+pub mod query_history;
+pub mod query_watch;
+pub mod readline;
+pub mod save_cell_form;
+pub mod session_stats;
+pub mod spinner;
 pub mod sql_suggestions;
 pub mod suggestion_popup;
 pub mod table_viewer;
 pub mod tables_pane;
+pub mod tasks;
 pub mod toast;
+pub mod undo_log;
+pub mod welcome;
 
+pub use column_jump::*;
+pub use column_stats::*;
 pub use connection_modal::*;
 pub use connection_mode::*;
 pub use debug_view::*;
+pub use export_form::*;
+pub use fetch_progress::*;
+pub use filter_form::*;
+pub use insert_row_form::*;
+pub use json_view::*;
+pub use notifications::*;
+pub use object_tree::*;
+pub use pins::*;
+pub use plan_view::*;
 pub use query_editor::*;
+pub use query_history::*;
+pub use query_watch::*;
+pub use save_cell_form::*;
+pub use session_stats::*;
+pub use spinner::*;
 pub use sql_suggestions::*;
 pub use suggestion_popup::*;
 pub use table_viewer::*;
 pub use tables_pane::*;
+pub use tasks::*;
 pub use toast::*;
+pub use undo_log::*;
+pub use welcome::*;
@@ -0,0 +1,260 @@
+// FilePath: src/ui/components/notifications.rs
+
+#![forbid(unsafe_code)]
+
+use crate::ui::theme::Theme;
+use chrono::{DateTime, Local};
+use ratatui::{
+    layout::{Alignment, Constraint, Direction, Layout, Rect},
+    style::{Modifier, Style},
+    text::{Line, Span},
+    widgets::{Block, Borders, Clear, Paragraph},
+    Frame,
+};
+use std::collections::VecDeque;
+
+/// Notifications kept in the view; older ones are dropped
+pub const MAX_NOTIFICATIONS: usize = 1000;
+
+/// A notification received through LISTEN
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct Notification {
+    pub received_at: DateTime<Local>,
+    pub channel: String,
+    pub payload: String,
+}
+
+/// Postgres LISTEN/NOTIFY watcher: a channel dialog followed by a scrolling
+/// log of received notifications
+#[derive(Debug, Clone, Default)]
+pub struct NotificationsView {
+    /// Channel names being typed in the dialog
+    pub channel_input: String,
+    /// Whether the channel dialog is showing
+    pub editing_channels: bool,
+    /// Channels currently listened on; empty when not listening
+    pub channels: Vec<String>,
+    /// Connection the listener belongs to
+    pub connection_name: Option<String>,
+    /// Received notifications, oldest first
+    pub entries: VecDeque<Notification>,
+    /// Lines scrolled up from the newest notification
+    pub scroll_offset: usize,
+    /// Why the listener stopped, if it failed
+    pub error: Option<String>,
+}
+
+impl NotificationsView {
+    pub fn new() -> Self {
+        Self::default()
+    }
+
+    /// Whether a listener is running
+    pub fn is_listening(&self) -> bool {
+        !self.channels.is_empty()
+    }
+
+    /// Show the channel dialog, prefilled with the current channels
+    pub fn open_channel_dialog(&mut self) {
+        self.channel_input = self.channels.join(", ");
+        self.editing_channels = true;
+    }
+
+    /// Channel names from the dialog, split on commas and whitespace
+    pub fn parsed_channels(&self) -> Vec<String> {
+        let mut channels: Vec<String> = Vec::new();
+        for channel in self
+            .channel_input
+            .split(|c: char| c == ',' || c.is_whitespace())
+            .filter(|channel| !channel.is_empty())
+        {
+            if !channels.iter().any(|existing| existing == channel) {
+                channels.push(channel.to_string());
+            }
+        }
+        channels
+    }
+
+    /// Record that listening started
+    pub fn start(&mut self, connection_name: String, channels: Vec<String>) {
+        self.connection_name = Some(connection_name);
+        self.channels = channels;
+        self.editing_channels = false;
+        self.error = None;
+    }
+
+    /// Record that listening stopped, with the reason if it failed
+    pub fn stop(&mut self, error: Option<String>) {
+        self.channels.clear();
+        self.error = error;
+    }
+
+    /// Add a received notification. The view stays put when scrolled up
+    pub fn push(&mut self, notification: Notification) {
+        if self.entries.len() == MAX_NOTIFICATIONS {
+            self.entries.pop_front();
+        }
+        self.entries.push_back(notification);
+        if self.scroll_offset > 0 {
+            self.scroll_offset = (self.scroll_offset + 1).min(self.entries.len() - 1);
+        }
+    }
+
+    /// Scroll towards older notifications
+    pub fn scroll_up(&mut self, lines: usize) {
+        self.scroll_offset = (self.scroll_offset + lines).min(self.entries.len().saturating_sub(1));
+    }
+
+    /// Scroll towards the newest notification
+    pub fn scroll_down(&mut self, lines: usize) {
+        self.scroll_offset = self.scroll_offset.saturating_sub(lines);
+    }
+
+    /// Render the watcher as a full-screen overlay
+    pub fn render(&self, frame: &mut Frame, area: Rect, theme: &Theme) {
+        frame.render_widget(Clear, area);
+
+        let title = match (&self.connection_name, self.is_listening()) {
+            (Some(name), true) => format!(
+                " Notifications - {name} - LISTEN {} ",
+                self.channels.join(", ")
+            ),
+            _ => " Notifications ".to_string(),
+        };
+        let block = Block::default()
+            .borders(Borders::ALL)
+            .title(title)
+            .title_alignment(Alignment::Center)
+            .border_style(Style::default().fg(theme.get_color("active_border")))
+            .style(
+                Style::default()
+                    .bg(theme.get_color("background"))
+                    .fg(theme.get_color("foreground")),
+            );
+        let inner = block.inner(area);
+        frame.render_widget(block, area);
+
+        let chunks = Layout::default()
+            .direction(Direction::Vertical)
+            .constraints([
+                Constraint::Length(if self.editing_channels { 3 } else { 0 }),
+                Constraint::Min(1),
+                Constraint::Length(1),
+            ])
+            .split(inner);
+
+        if self.editing_channels {
+            let input = Paragraph::new(format!("{}█", self.channel_input)).block(
+                Block::default()
+                    .borders(Borders::ALL)
+                    .title(" Channels to LISTEN on (comma separated) ")
+                    .border_style(Style::default().fg(theme.get_color("warning"))),
+            );
+            frame.render_widget(input, chunks[0]);
+        }
+
+        self.render_entries(frame, chunks[1], theme);
+
+        let hints = if self.editing_channels {
+            vec![
+                Span::styled("Enter", Style::default().fg(theme.get_color("warning"))),
+                Span::raw(" listen  •  "),
+                Span::styled("Esc", Style::default().fg(theme.get_color("danger"))),
+                Span::raw(" close"),
+            ]
+        } else {
+            vec![
+                Span::styled("c", Style::default().fg(theme.get_color("warning"))),
+                Span::raw(" channels  •  "),
+                Span::styled("s", Style::default().fg(theme.get_color("warning"))),
+                Span::raw(" stop listening  •  "),
+                Span::styled("j/k", Style::default().fg(theme.get_color("warning"))),
+                Span::raw(" scroll  •  "),
+                Span::styled("x", Style::default().fg(theme.get_color("warning"))),
+                Span::raw(" clear  •  "),
+                Span::styled("Esc", Style::default().fg(theme.get_color("danger"))),
+                Span::raw(" close (keeps listening)"),
+            ]
+        };
+        frame.render_widget(
+            Paragraph::new(Line::from(hints)).alignment(Alignment::Center),
+            chunks[2],
+        );
+    }
+
+    fn render_entries(&self, frame: &mut Frame, area: Rect, theme: &Theme) {
+        let mut lines: Vec<Line> = Vec::new();
+        if let Some(error) = &self.error {
+            lines.push(Line::from(Span::styled(
+                format!("Listener stopped: {error}"),
+                Style::default().fg(theme.get_color("danger")),
+            )));
+        }
+        if self.entries.is_empty() {
+            lines.push(Line::from(Span::styled(
+                if self.is_listening() {
+                    "Waiting for notifications..."
+                } else {
+                    "Not listening. Press 'c' to choose channels."
+                },
+                Style::default().fg(theme.get_color("text_muted")),
+            )));
+        }
+
+        // Newest at the bottom; scrolling up reveals older ones
+        let visible = (area.height as usize).saturating_sub(lines.len());
+        let end = self.entries.len().saturating_sub(self.scroll_offset);
+        let start = end.saturating_sub(visible);
+        for notification in self.entries.range(start..end) {
+            lines.push(Line::from(vec![
+                Span::styled(
+                    notification.received_at.format("%H:%M:%S%.3f ").to_string(),
+                    Style::default().fg(theme.get_color("text_muted")),
+                ),
+                Span::styled(
+                    format!("{} ", notification.channel),
+                    Style::default()
+                        .fg(theme.get_color("primary_highlight"))
+                        .add_modifier(Modifier::BOLD),
+                ),
+                Span::raw(notification.payload.clone()),
+            ]));
+        }
+
+        frame.render_widget(Paragraph::new(lines), area);
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn notification(payload: &str) -> Notification {
+        Notification {
+            received_at: Local::now(),
+            channel: "jobs".to_string(),
+            payload: payload.to_string(),
+        }
+    }
+
+    #[test]
+    fn test_parsed_channels_split_and_dedupe() {
+        let mut view = NotificationsView::new();
+        view.channel_input = "jobs, cache_invalidation  jobs,,".to_string();
+        assert_eq!(view.parsed_channels(), vec!["jobs", "cache_invalidation"]);
+    }
+
+    #[test]
+    fn test_push_caps_entries_and_keeps_scroll_position() {
+        let mut view = NotificationsView::new();
+        for i in 0..MAX_NOTIFICATIONS + 5 {
+            view.push(notification(&i.to_string()));
+        }
+        assert_eq!(view.entries.len(), MAX_NOTIFICATIONS);
+        assert_eq!(view.entries.front().unwrap().payload, "5");
+
+        view.scroll_up(3);
+        view.push(notification("new"));
+        assert_eq!(view.scroll_offset, 4);
+    }
+}
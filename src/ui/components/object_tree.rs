@@ -0,0 +1,657 @@
+// FilePath: src/ui/components/object_tree.rs
+
+//! Object tree: the saved connections and, under the connected one, its
+//! schemas with their tables, views and functions, in one list that expands
+//! and collapses. With `[ui] sidebar_mode = "tree"` it takes the place of the
+//! Connections and Tables panes. A connection's children are listed once
+//! it's connected, and a schema's functions are read when their group is
+//! first expanded. Moving through the tree selects the connection or table
+//! the way the panes do, so the details pane follows it
+
+#![forbid(unsafe_code)]
+
+use crate::app::session_events::{SessionEvent, SessionSubscriber};
+use crate::database::{
+    ConnectionConfig, ConnectionStatus, DatabaseObject, DatabaseObjectList, DatabaseType,
+    RoutineInfo,
+};
+use crate::ui::theme::Theme;
+use ratatui::{
+    layout::Rect,
+    style::{Modifier, Style},
+    text::{Line, Span},
+    widgets::{Block, Borders, List, ListItem, ListState},
+    Frame,
+};
+use std::collections::{HashMap, HashSet};
+
+/// Kind of objects grouped under a schema
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash)]
+pub enum ObjectGroup {
+    Tables,
+    Views,
+    MaterializedViews,
+    ForeignTables,
+    Functions,
+}
+
+impl ObjectGroup {
+    /// Name of the group, the same as its group in the Tables pane
+    pub fn label(self) -> &'static str {
+        match self {
+            Self::Tables => "Tables",
+            Self::Views => "Views",
+            Self::MaterializedViews => "Materialized Views",
+            Self::ForeignTables => "Foreign Tables",
+            Self::Functions => "Functions",
+        }
+    }
+
+    fn icon(self) -> &'static str {
+        match self {
+            Self::Tables => "📋",
+            Self::Views => "👁️",
+            Self::MaterializedViews => "🔄",
+            Self::ForeignTables => "🔗",
+            Self::Functions => "ƒ",
+        }
+    }
+}
+
+/// A node of the tree
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub enum TreeNode {
+    /// Saved connection at this index
+    Connection {
+        index: usize,
+        id: String,
+    },
+    Schema {
+        connection_id: String,
+        schema: String,
+    },
+    Group {
+        connection_id: String,
+        schema: String,
+        group: ObjectGroup,
+    },
+    /// Table, view or other object the Tables pane lists
+    Object {
+        name: String,
+        schema: Option<String>,
+        group: ObjectGroup,
+        system: bool,
+    },
+    Routine(RoutineInfo),
+    /// Line standing in for children that aren't there: loading, none, or
+    /// the error reading them
+    Note(String),
+}
+
+/// What identifies an expandable node: its connection, and the schema and
+/// group under it
+type NodeKey = (String, Option<String>, Option<ObjectGroup>);
+
+impl TreeNode {
+    fn key(&self) -> Option<NodeKey> {
+        match self {
+            Self::Connection { id, .. } => Some((id.clone(), None, None)),
+            Self::Schema {
+                connection_id,
+                schema,
+            } => Some((connection_id.clone(), Some(schema.clone()), None)),
+            Self::Group {
+                connection_id,
+                schema,
+                group,
+            } => Some((connection_id.clone(), Some(schema.clone()), Some(*group))),
+            _ => None,
+        }
+    }
+}
+
+/// A line of the tree as shown
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct TreeRow {
+    pub node: TreeNode,
+    pub depth: usize,
+    /// Whether the node is expanded; None for a leaf
+    pub expanded: Option<bool>,
+}
+
+/// Functions of a schema, or the error reading them
+type Routines = Result<Vec<RoutineInfo>, String>;
+
+/// The tree's selection and what's expanded
+#[derive(Debug, Clone, Default)]
+pub struct ObjectTree {
+    /// Index of the selected row
+    pub selected: usize,
+    pub list_state: ListState,
+    /// Nodes expanded or collapsed against their default: a connection is
+    /// expanded once connected, the default schema (or the only one) and
+    /// its object groups are expanded, functions are collapsed
+    toggled: HashSet<NodeKey>,
+    /// Functions read, by connection ID and schema
+    routines: HashMap<(String, String), Routines>,
+    /// Connection whose objects were listed last, the ones the database
+    /// state holds
+    listed: Option<String>,
+}
+
+impl ObjectTree {
+    /// The rows shown for these connections, the one listed last holding
+    /// `objects`
+    pub fn rows(
+        &self,
+        connections: &[ConnectionConfig],
+        objects: Option<&DatabaseObjectList>,
+    ) -> Vec<TreeRow> {
+        let mut rows = Vec::new();
+        for (index, connection) in connections.iter().enumerate() {
+            let node = TreeNode::Connection {
+                index,
+                id: connection.id.clone(),
+            };
+            let expanded = connection.is_connected() && self.is_expanded(&node, true);
+            rows.push(TreeRow {
+                node,
+                depth: 0,
+                expanded: Some(expanded),
+            });
+            if !expanded {
+                continue;
+            }
+            if self.listed.as_ref() == Some(&connection.id) {
+                self.push_schemas(&mut rows, connection, objects);
+            } else {
+                // Another connection's objects are listed; Enter lists these
+                rows.push(TreeRow {
+                    node: TreeNode::Note("Enter to list its objects".to_string()),
+                    depth: 1,
+                    expanded: None,
+                });
+            }
+        }
+        rows
+    }
+
+    fn push_schemas(
+        &self,
+        rows: &mut Vec<TreeRow>,
+        connection: &ConnectionConfig,
+        objects: Option<&DatabaseObjectList>,
+    ) {
+        let note = |text: &str| TreeRow {
+            node: TreeNode::Note(text.to_string()),
+            depth: 1,
+            expanded: None,
+        };
+        let Some(objects) = objects else {
+            rows.push(note("loading…"));
+            return;
+        };
+        let schemas = schemas(objects);
+        if schemas.is_empty() {
+            rows.push(note(objects.error.as_deref().unwrap_or("no objects")));
+            return;
+        }
+        for schema in &schemas {
+            let node = TreeNode::Schema {
+                connection_id: connection.id.clone(),
+                schema: schema.clone(),
+            };
+            let default = schemas.len() == 1 || objects.default_schema.as_ref() == Some(schema);
+            let expanded = self.is_expanded(&node, default);
+            rows.push(TreeRow {
+                node,
+                depth: 1,
+                expanded: Some(expanded),
+            });
+            if !expanded {
+                continue;
+            }
+            for (group, listed) in [
+                (ObjectGroup::Tables, &objects.tables),
+                (ObjectGroup::Views, &objects.views),
+                (ObjectGroup::MaterializedViews, &objects.materialized_views),
+                (ObjectGroup::ForeignTables, &objects.foreign_tables),
+            ] {
+                let members: Vec<&DatabaseObject> = listed
+                    .iter()
+                    .filter(|object| schema_of(object) == schema.as_str())
+                    .collect();
+                if members.is_empty() {
+                    continue;
+                }
+                let expanded = self.push_group(rows, connection, schema, group);
+                if expanded {
+                    rows.extend(members.into_iter().map(|object| TreeRow {
+                        node: TreeNode::Object {
+                            name: object.name.clone(),
+                            schema: object.schema.clone(),
+                            group,
+                            system: object.is_system(),
+                        },
+                        depth: 3,
+                        expanded: None,
+                    }));
+                }
+            }
+            // SQLite has no stored functions
+            if connection.database_type == DatabaseType::SQLite {
+                continue;
+            }
+            if self.push_group(rows, connection, schema, ObjectGroup::Functions) {
+                let key = (connection.id.clone(), schema.clone());
+                let leaf = |node| TreeRow {
+                    node,
+                    depth: 3,
+                    expanded: None,
+                };
+                match self.routines.get(&key) {
+                    None => rows.push(leaf(TreeNode::Note("loading…".to_string()))),
+                    Some(Ok(routines)) if routines.is_empty() => {
+                        rows.push(leaf(TreeNode::Note("no functions".to_string())))
+                    }
+                    Some(Ok(routines)) => rows.extend(
+                        routines
+                            .iter()
+                            .map(|routine| leaf(TreeNode::Routine(routine.clone()))),
+                    ),
+                    Some(Err(error)) => rows.push(leaf(TreeNode::Note(error.clone()))),
+                }
+            }
+        }
+    }
+
+    /// Push a group's row, returning whether it's expanded
+    fn push_group(
+        &self,
+        rows: &mut Vec<TreeRow>,
+        connection: &ConnectionConfig,
+        schema: &str,
+        group: ObjectGroup,
+    ) -> bool {
+        let node = TreeNode::Group {
+            connection_id: connection.id.clone(),
+            schema: schema.to_string(),
+            group,
+        };
+        let expanded = self.is_expanded(&node, group != ObjectGroup::Functions);
+        rows.push(TreeRow {
+            node,
+            depth: 2,
+            expanded: Some(expanded),
+        });
+        expanded
+    }
+
+    fn is_expanded(&self, node: &TreeNode, default: bool) -> bool {
+        node.key()
+            .is_some_and(|key| default != self.toggled.contains(&key))
+    }
+
+    /// Whether the objects listed are this connection's
+    pub fn lists(&self, connection_id: &str) -> bool {
+        self.listed.as_deref() == Some(connection_id)
+    }
+
+    /// Expand a collapsed row or collapse an expanded one
+    pub fn toggle(&mut self, row: &TreeRow) {
+        if let Some(key) = row.node.key() {
+            if !self.toggled.remove(&key) {
+                self.toggled.insert(key);
+            }
+        }
+    }
+
+    /// Select the row at `index`, kept within the `len` rows shown
+    pub fn select(&mut self, index: usize, len: usize) {
+        self.selected = index.min(len.saturating_sub(1));
+        self.list_state.select((len > 0).then_some(self.selected));
+    }
+
+    /// Row of the node holding the one at `index`
+    pub fn parent(rows: &[TreeRow], index: usize) -> Option<usize> {
+        let depth = rows.get(index)?.depth;
+        rows[..index].iter().rposition(|row| row.depth < depth)
+    }
+
+    /// Connection and schema of an expanded Functions group not read yet
+    pub fn routines_to_load(&self, rows: &[TreeRow]) -> Option<(String, String)> {
+        rows.iter().find_map(|row| match &row.node {
+            TreeNode::Group {
+                connection_id,
+                schema,
+                group: ObjectGroup::Functions,
+            } if row.expanded == Some(true) => {
+                let key = (connection_id.clone(), schema.clone());
+                (!self.routines.contains_key(&key)).then_some(key)
+            }
+            _ => None,
+        })
+    }
+
+    /// Keep the functions read for a schema
+    pub fn set_routines(&mut self, connection_id: String, schema: String, routines: Routines) {
+        self.routines.insert((connection_id, schema), routines);
+    }
+
+    fn forget_connection(&mut self, connection_id: &str) {
+        self.routines.retain(|(id, _), _| id != connection_id);
+    }
+}
+
+impl SessionSubscriber for ObjectTree {
+    fn on_session_event(&mut self, event: &SessionEvent) {
+        match event {
+            // A connection made shows its objects, read afresh
+            SessionEvent::ConnectionOpened { connection_id } => {
+                self.forget_connection(connection_id);
+                self.toggled.remove(&(connection_id.clone(), None, None));
+                self.listed = Some(connection_id.clone());
+            }
+            SessionEvent::ConnectionClosed { connection_id } => {
+                self.forget_connection(connection_id);
+                if self.listed.as_ref() == Some(connection_id) {
+                    self.listed = None;
+                }
+            }
+            // Another database has other schemas
+            SessionEvent::DatabaseChanged { connection_id, .. } => {
+                self.forget_connection(connection_id);
+                self.toggled
+                    .retain(|(id, schema, _)| id != connection_id || schema.is_none());
+            }
+            SessionEvent::TableChanged { .. }
+            | SessionEvent::QueryStarted
+            | SessionEvent::QueryFinished { .. } => {}
+        }
+    }
+}
+
+fn schema_of(object: &DatabaseObject) -> &str {
+    object.schema.as_deref().unwrap_or_default()
+}
+
+/// Schemas holding the listed objects, the default one first and the rest
+/// by name
+fn schemas(objects: &DatabaseObjectList) -> Vec<String> {
+    let mut schemas: Vec<String> = objects
+        .all_objects()
+        .into_iter()
+        .map(|object| schema_of(object).to_string())
+        .collect::<HashSet<_>>()
+        .into_iter()
+        .collect();
+    let default = objects.default_schema.as_deref();
+    schemas.sort_by_key(|schema| (Some(schema.as_str()) != default, schema.clone()));
+    schemas
+}
+
+/// Render the tree in the left column
+pub fn render_object_tree(
+    f: &mut Frame,
+    tree: &mut ObjectTree,
+    rows: &[TreeRow],
+    connections: &[ConnectionConfig],
+    area: Rect,
+    theme: &Theme,
+    focused: bool,
+) {
+    let primary = Style::default().fg(theme.get_color("text_primary"));
+    let muted = Style::default().fg(theme.get_color("text_muted"));
+    let items: Vec<ListItem> = if rows.is_empty() {
+        vec![ListItem::new(Line::from(Span::styled(
+            "No connections yet. Press a to add one",
+            muted,
+        )))]
+    } else {
+        rows.iter()
+            .map(|row| {
+                let arrow = match row.expanded {
+                    Some(true) => "▼ ",
+                    Some(false) => "▶ ",
+                    None => "  ",
+                };
+                let mut spans = vec![Span::styled(
+                    format!("{}{arrow}", "  ".repeat(row.depth)),
+                    muted,
+                )];
+                match &row.node {
+                    TreeNode::Connection { index, .. } => {
+                        let Some(connection) = connections.get(*index) else {
+                            return ListItem::new("");
+                        };
+                        let status = match connection.status {
+                            ConnectionStatus::Connected => "success",
+                            ConnectionStatus::Connecting => "warning",
+                            ConnectionStatus::Failed(_) => "error",
+                            ConnectionStatus::Disconnected => "text_muted",
+                        };
+                        let symbol = if theme.accessible {
+                            connection.status_label()
+                        } else {
+                            connection.status_symbol()
+                        };
+                        spans.push(Span::styled(
+                            format!("{symbol} "),
+                            Style::default().fg(theme.get_color(status)),
+                        ));
+                        spans.push(Span::styled(
+                            connection.name.clone(),
+                            primary.add_modifier(Modifier::BOLD),
+                        ));
+                        spans.push(Span::styled(
+                            format!(
+                                "  {} · {}",
+                                connection.database_type.display_name(),
+                                connection.database_or_default()
+                            ),
+                            muted,
+                        ));
+                    }
+                    TreeNode::Schema { schema, .. } => {
+                        spans.push(Span::styled(schema.clone(), primary));
+                    }
+                    TreeNode::Group { group, .. } => {
+                        spans.push(Span::styled(
+                            group.label(),
+                            Style::default().fg(theme.get_color("text_secondary")),
+                        ));
+                    }
+                    TreeNode::Object {
+                        name,
+                        group,
+                        system,
+                        ..
+                    } => {
+                        spans.push(Span::styled(
+                            format!("{} {name}", group.icon()),
+                            if *system { muted } else { primary },
+                        ));
+                    }
+                    TreeNode::Routine(routine) => {
+                        let icon = if routine.procedure { "⚙" } else { "ƒ" };
+                        spans.push(Span::styled(
+                            format!("{icon} {}", routine.signature()),
+                            primary,
+                        ));
+                    }
+                    TreeNode::Note(text) => {
+                        spans.push(Span::styled(text.clone(), muted));
+                    }
+                }
+                ListItem::new(Line::from(spans))
+            })
+            .collect()
+    };
+
+    let border = if focused { "active_border" } else { "border" };
+    let list = List::new(items)
+        .block(
+            Block::default()
+                .title(" [1] Objects ")
+                .borders(Borders::ALL)
+                .border_style(Style::default().fg(theme.get_color(border))),
+        )
+        .highlight_style(
+            Style::default()
+                .bg(theme.get_color("selection_bg"))
+                .add_modifier(Modifier::BOLD),
+        );
+    tree.select(tree.selected, rows.len());
+    f.render_stateful_widget(list, area, &mut tree.list_state);
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::database::DatabaseObjectType;
+
+    fn connection(id: &str, connected: bool) -> ConnectionConfig {
+        let mut connection = ConnectionConfig::new(
+            id.to_string(),
+            DatabaseType::PostgreSQL,
+            "localhost".to_string(),
+            5432,
+            "app".to_string(),
+        );
+        connection.id = id.to_string();
+        if connected {
+            connection.status = ConnectionStatus::Connected;
+        }
+        connection
+    }
+
+    fn object(name: &str, schema: &str, object_type: DatabaseObjectType) -> DatabaseObject {
+        DatabaseObject {
+            name: name.to_string(),
+            schema: Some(schema.to_string()),
+            object_type,
+            row_count: None,
+            size_bytes: None,
+            comment: None,
+            system: false,
+        }
+    }
+
+    fn objects() -> DatabaseObjectList {
+        DatabaseObjectList {
+            tables: vec![
+                object("orders", "public", DatabaseObjectType::Table),
+                object("events", "audit", DatabaseObjectType::Table),
+            ],
+            views: vec![object("open_orders", "public", DatabaseObjectType::View)],
+            default_schema: Some("public".to_string()),
+            ..DatabaseObjectList::default()
+        }
+    }
+
+    /// Tree with the objects of this connection listed
+    fn listing(connection_id: &str) -> ObjectTree {
+        let mut tree = ObjectTree::default();
+        tree.on_session_event(&SessionEvent::ConnectionOpened {
+            connection_id: connection_id.to_string(),
+        });
+        tree
+    }
+
+    fn labels(rows: &[TreeRow]) -> Vec<String> {
+        rows.iter()
+            .map(|row| {
+                let label = match &row.node {
+                    TreeNode::Connection { id, .. } => id.clone(),
+                    TreeNode::Schema { schema, .. } => schema.clone(),
+                    TreeNode::Group { group, .. } => group.label().to_string(),
+                    TreeNode::Object { name, .. } => name.clone(),
+                    TreeNode::Routine(routine) => routine.signature(),
+                    TreeNode::Note(text) => text.clone(),
+                };
+                format!("{}{label}", "  ".repeat(row.depth))
+            })
+            .collect()
+    }
+
+    #[test]
+    fn test_connected_connection_lists_default_schema_expanded() {
+        let tree = listing("local");
+        let connections = [
+            connection("local", true),
+            connection("staging", true),
+            connection("prod", false),
+        ];
+        let rows = tree.rows(&connections, Some(&objects()));
+        assert_eq!(
+            labels(&rows),
+            [
+                "local",
+                "  public",
+                "    Tables",
+                "      orders",
+                "    Views",
+                "      open_orders",
+                "    Functions",
+                "  audit",
+                "staging",
+                "  Enter to list its objects",
+                "prod",
+            ]
+        );
+        assert_eq!(rows[10].expanded, Some(false));
+        assert_eq!(ObjectTree::parent(&rows, 3), Some(2));
+        assert_eq!(ObjectTree::parent(&rows, 2), Some(1));
+        assert_eq!(ObjectTree::parent(&rows, 0), None);
+    }
+
+    #[test]
+    fn test_functions_load_when_expanded() {
+        let mut tree = listing("local");
+        let connections = [connection("local", true)];
+        let rows = tree.rows(&connections, Some(&objects()));
+        assert_eq!(tree.routines_to_load(&rows), None);
+
+        tree.toggle(&rows[6]);
+        let rows = tree.rows(&connections, Some(&objects()));
+        assert_eq!(labels(&rows)[7], "      loading…");
+        let load = tree.routines_to_load(&rows).unwrap();
+        assert_eq!(load, ("local".to_string(), "public".to_string()));
+
+        let routine = RoutineInfo {
+            name: "order_total".to_string(),
+            schema: "public".to_string(),
+            procedure: false,
+            arguments: Some("order_id integer".to_string()),
+            returns: Some("numeric".to_string()),
+        };
+        tree.set_routines(load.0, load.1, Ok(vec![routine]));
+        let rows = tree.rows(&connections, Some(&objects()));
+        assert_eq!(
+            labels(&rows)[7],
+            "      order_total(order_id integer) → numeric"
+        );
+        assert_eq!(tree.routines_to_load(&rows), None);
+
+        // Connecting again reads them afresh
+        tree.on_session_event(&SessionEvent::ConnectionOpened {
+            connection_id: "local".to_string(),
+        });
+        let rows = tree.rows(&connections, Some(&objects()));
+        assert!(tree.routines_to_load(&rows).is_some());
+    }
+
+    #[test]
+    fn test_collapsed_connection_expands_when_connected_again() {
+        let mut tree = listing("local");
+        let connections = [connection("local", true)];
+        let rows = tree.rows(&connections, Some(&objects()));
+        tree.toggle(&rows[0]);
+        assert_eq!(tree.rows(&connections, Some(&objects())).len(), 1);
+
+        tree.on_session_event(&SessionEvent::ConnectionOpened {
+            connection_id: "local".to_string(),
+        });
+        assert!(tree.rows(&connections, Some(&objects())).len() > 1);
+    }
+}
@@ -0,0 +1,199 @@
+// FilePath: src/ui/components/path_input.rs
+
+//! Dialog fields holding a file path: the SQLite file of a connection and
+//! the destination of an export. Tab completes the typed path against the
+//! filesystem the way a shell does, `~/` stands for the home directory, and
+//! the path is checked before it's used: a file to open must exist, and a
+//! file to save must go in a directory that exists
+
+#![forbid(unsafe_code)]
+
+use std::path::{Path, PathBuf};
+
+/// What the path is for
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum PathKind {
+    /// An existing file read from
+    Open,
+    /// A file written, created when missing
+    Save,
+}
+
+/// What Tab made of a typed path
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct Completion {
+    /// The path with the part every match shares added; a directory
+    /// matched alone gets its `/`
+    pub text: String,
+    /// Names of the entries still matching when more than one does
+    pub candidates: Vec<String>,
+}
+
+/// Matches listed in a notification
+const HINT_CANDIDATES: usize = 8;
+
+impl Completion {
+    /// The matches left to pick from, e.g. `app.db  app.sqlite`; None when
+    /// the completion is whole
+    pub fn hint(&self) -> Option<String> {
+        if self.candidates.is_empty() {
+            return None;
+        }
+        let mut hint = self
+            .candidates
+            .iter()
+            .take(HINT_CANDIDATES)
+            .map(String::as_str)
+            .collect::<Vec<_>>()
+            .join("  ");
+        if self.candidates.len() > HINT_CANDIDATES {
+            hint.push_str(&format!(
+                "  … {} more",
+                self.candidates.len() - HINT_CANDIDATES
+            ));
+        }
+        Some(hint)
+    }
+}
+
+/// Path typed in a dialog, `~/` standing for the home directory
+pub fn expand_home(typed: &str) -> PathBuf {
+    let typed = typed.trim();
+    typed
+        .strip_prefix("~/")
+        .and_then(|rest| dirs::home_dir().map(|home| home.join(rest)))
+        .unwrap_or_else(|| PathBuf::from(typed))
+}
+
+/// Complete the last part of `typed` against the entries of its directory.
+/// Hidden entries only match a part starting with a dot. None when nothing
+/// matches
+pub fn complete(typed: &str) -> Option<Completion> {
+    let typed = if typed == "~" { "~/" } else { typed };
+    let (dir, prefix) = match typed.rfind('/') {
+        Some(slash) => typed.split_at(slash + 1),
+        None => ("", typed),
+    };
+    let search = if dir.is_empty() {
+        PathBuf::from(".")
+    } else {
+        expand_home(dir)
+    };
+    let mut matches: Vec<(String, bool)> = std::fs::read_dir(search)
+        .ok()?
+        .filter_map(|entry| {
+            let entry = entry.ok()?;
+            let name = entry.file_name().into_string().ok()?;
+            let hidden = name.starts_with('.') && !prefix.starts_with('.');
+            (name.starts_with(prefix) && !hidden).then(|| (name, entry.path().is_dir()))
+        })
+        .collect();
+    matches.sort();
+
+    let (first, _) = matches.first()?;
+    let shared = matches[1..].iter().fold(first.len(), |shared, (name, _)| {
+        first
+            .char_indices()
+            .zip(name.chars())
+            .find(|((_, a), b)| a != b)
+            .map_or(name.len(), |((at, _), _)| at)
+            .min(shared)
+    });
+    let mut text = format!("{dir}{}", &first[..shared]);
+    let candidates = if matches.len() == 1 {
+        if matches[0].1 {
+            text.push('/');
+        }
+        Vec::new()
+    } else {
+        matches.into_iter().map(|(name, _)| name).collect()
+    };
+    Some(Completion { text, candidates })
+}
+
+/// Check a typed path for its use, returning it with `~/` expanded
+pub fn validate(typed: &str, kind: PathKind) -> Result<PathBuf, String> {
+    if typed.trim().is_empty() {
+        return Err("Enter a file path".to_string());
+    }
+    let path = expand_home(typed);
+    if path.is_dir() {
+        return Err(format!("{} is a directory", path.display()));
+    }
+    match kind {
+        PathKind::Open if !path.exists() => Err(format!("{} doesn't exist", path.display())),
+        PathKind::Save => {
+            let parent = path
+                .parent()
+                .filter(|parent| !parent.as_os_str().is_empty())
+                .unwrap_or(Path::new("."));
+            if parent.is_dir() {
+                Ok(path)
+            } else {
+                Err(format!("Directory {} doesn't exist", parent.display()))
+            }
+        }
+        PathKind::Open => Ok(path),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn tree() -> tempfile::TempDir {
+        let dir = tempfile::tempdir().unwrap();
+        std::fs::create_dir(dir.path().join("exports")).unwrap();
+        std::fs::write(dir.path().join("app.db"), "").unwrap();
+        std::fs::write(dir.path().join("app.sqlite"), "").unwrap();
+        std::fs::write(dir.path().join(".hidden.db"), "").unwrap();
+        dir
+    }
+
+    #[test]
+    fn test_complete_shared_part_and_directories() {
+        let dir = tree();
+        let root = format!("{}/", dir.path().display());
+
+        let completion = complete(&format!("{root}a")).unwrap();
+        assert_eq!(completion.text, format!("{root}app."));
+        assert_eq!(completion.candidates, vec!["app.db", "app.sqlite"]);
+        assert_eq!(completion.hint().unwrap(), "app.db  app.sqlite");
+
+        let completion = complete(&format!("{root}ex")).unwrap();
+        assert_eq!(completion.text, format!("{root}exports/"));
+        assert!(completion.candidates.is_empty());
+
+        let completion = complete(&format!("{root}.h")).unwrap();
+        assert_eq!(completion.text, format!("{root}.hidden.db"));
+        assert!(complete(&format!("{root}zzz")).is_none());
+        assert!(complete(&format!("{root}missing/a")).is_none());
+    }
+
+    #[test]
+    fn test_validate_by_kind() {
+        let dir = tree();
+        let root = dir.path().display();
+
+        let db = format!("{root}/app.db");
+        assert_eq!(validate(&db, PathKind::Open).unwrap(), PathBuf::from(&db));
+        assert!(validate(&format!("{root}/new.db"), PathKind::Open).is_err());
+        assert!(validate(&format!("{root}/exports"), PathKind::Open).is_err());
+
+        assert!(validate(&format!("{root}/exports/orders.csv"), PathKind::Save).is_ok());
+        assert!(validate("orders.csv", PathKind::Save).is_ok());
+        assert_eq!(
+            validate(&format!("{root}/missing/orders.csv"), PathKind::Save),
+            Err(format!("Directory {root}/missing doesn't exist"))
+        );
+        assert!(validate("  ", PathKind::Save).is_err());
+    }
+
+    #[test]
+    fn test_expand_home() {
+        if let Some(home) = dirs::home_dir() {
+            assert_eq!(expand_home("~/orders.csv"), home.join("orders.csv"));
+        }
+        assert_eq!(expand_home(" /tmp/a.csv "), PathBuf::from("/tmp/a.csv"));
+    }
+}
@@ -0,0 +1,234 @@
+// FilePath: src/ui/components/pins.rs
+
+//! Results pinned under a name for the rest of the session, to compare the
+//! data before and after a change. A pin is a copy of the tab's rows; opening
+//! it shows them as they were, and nothing reloads or edits them
+
+#![forbid(unsafe_code)]
+
+use super::TableTab;
+use crate::ui::theme::Theme;
+use chrono::{DateTime, Local};
+use ratatui::{
+    layout::{Alignment, Rect},
+    style::{Modifier, Style},
+    text::{Line, Span},
+    widgets::{Block, Borders, Clear, Paragraph},
+    Frame,
+};
+
+/// A result pinned under a name
+#[derive(Debug, Clone)]
+pub struct Pin {
+    pub name: String,
+    pub tab: TableTab,
+    pub pinned_at: DateTime<Local>,
+}
+
+/// The session's pins, and the picker and name prompt over them
+#[derive(Debug, Clone, Default)]
+pub struct Pins {
+    pins: Vec<Pin>,
+    /// Pin selected in the picker
+    pub selected: usize,
+    /// Name being typed for a new pin
+    pub naming: Option<String>,
+}
+
+impl Pins {
+    pub fn pins(&self) -> &[Pin] {
+        &self.pins
+    }
+
+    pub fn get(&self, index: usize) -> Option<&Pin> {
+        self.pins.get(index)
+    }
+
+    /// First `pin N` name not taken yet, offered in the name prompt
+    pub fn default_name(&self) -> String {
+        (1..)
+            .map(|n| format!("pin {n}"))
+            .find(|name| self.pins.iter().all(|pin| pin.name != *name))
+            .unwrap_or_default()
+    }
+
+    /// Pin a copy of the tab's rows, replacing a pin of the same name.
+    /// Returns whether one was replaced
+    pub fn pin(&mut self, name: String, tab: &TableTab) -> bool {
+        let mut snapshot = tab.clone();
+        snapshot.table_name = format!("📌 {name}");
+        snapshot.pin = Some(name.clone());
+        snapshot.watch = None;
+        snapshot.in_edit_mode = false;
+        snapshot.in_search_mode = false;
+        snapshot.column_jump = None;
+        snapshot.loading = false;
+        snapshot.clear_marks();
+        let pin = Pin {
+            name,
+            tab: snapshot,
+            pinned_at: Local::now(),
+        };
+        match self.pins.iter_mut().find(|old| old.name == pin.name) {
+            Some(old) => {
+                *old = pin;
+                true
+            }
+            None => {
+                self.pins.push(pin);
+                false
+            }
+        }
+    }
+
+    /// Drop the pin at `index`, keeping the selection on the list
+    pub fn remove(&mut self, index: usize) -> Option<Pin> {
+        if index >= self.pins.len() {
+            return None;
+        }
+        let pin = self.pins.remove(index);
+        self.selected = self.selected.min(self.pins.len().saturating_sub(1));
+        Some(pin)
+    }
+}
+
+/// Render the prompt naming a new pin as a small centered popup
+pub fn render_pin_name(f: &mut Frame, name: &str, area: Rect, theme: &Theme) {
+    let lines = vec![
+        Line::from(vec![
+            Span::styled(
+                "Name  ",
+                Style::default().fg(theme.get_color("text_secondary")),
+            ),
+            Span::styled(
+                format!("{name}▏"),
+                Style::default()
+                    .fg(theme.get_color("accent"))
+                    .add_modifier(Modifier::BOLD),
+            ),
+        ]),
+        Line::from(""),
+        Line::from(Span::styled(
+            "Enter pin · Esc cancel",
+            Style::default().fg(theme.get_color("text_muted")),
+        )),
+    ];
+    render_popup(f, lines, " Pin result ", 48, area, theme);
+}
+
+/// Render the pins as a centered popup, the selected one highlighted
+pub fn render_pins(f: &mut Frame, pins: &Pins, area: Rect, theme: &Theme) {
+    let text = Style::default().fg(theme.get_color("text_primary"));
+    let muted = Style::default().fg(theme.get_color("text_muted"));
+    let active = Style::default()
+        .fg(theme.get_color("accent"))
+        .add_modifier(Modifier::BOLD);
+
+    let mut lines: Vec<Line> = pins
+        .pins()
+        .iter()
+        .enumerate()
+        .map(|(idx, pin)| {
+            let focused = idx == pins.selected;
+            let rows = pin.tab.rows.len();
+            Line::from(vec![
+                Span::styled(if focused { "▶ " } else { "  " }, active),
+                Span::styled(
+                    format!("{:<20}", pin.name),
+                    if focused { active } else { text },
+                ),
+                Span::styled(
+                    format!(
+                        "{:>7} row{}  {}",
+                        rows,
+                        if rows == 1 { " " } else { "s" },
+                        pin.pinned_at.format("%H:%M:%S")
+                    ),
+                    muted,
+                ),
+            ])
+        })
+        .collect();
+    if lines.is_empty() {
+        lines.push(Line::from(Span::styled(
+            "Nothing pinned; press m on a result to pin it",
+            muted,
+        )));
+    }
+    lines.push(Line::from(""));
+    lines.push(Line::from(Span::styled(
+        "j/k select · Enter open · d drop · Esc close",
+        muted,
+    )));
+    render_popup(f, lines, " Pinned results ", 56, area, theme);
+}
+
+fn render_popup(
+    f: &mut Frame,
+    lines: Vec<Line>,
+    title: &str,
+    width: u16,
+    area: Rect,
+    theme: &Theme,
+) {
+    let width = width.min(area.width.saturating_sub(4));
+    let height = (lines.len() as u16 + 2).min(area.height);
+    let popup = Rect {
+        x: area.x + area.width.saturating_sub(width) / 2,
+        y: area.y + area.height.saturating_sub(height) / 2,
+        width,
+        height,
+    };
+    f.render_widget(Clear, popup);
+    let block = Block::default()
+        .borders(Borders::ALL)
+        .title(title)
+        .title_alignment(Alignment::Center)
+        .border_style(
+            Style::default()
+                .fg(theme.get_color("accent"))
+                .add_modifier(Modifier::BOLD),
+        )
+        .style(Style::default().bg(theme.get_color("background")));
+    f.render_widget(Paragraph::new(lines).block(block), popup);
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn tab(rows: usize) -> TableTab {
+        let mut tab = TableTab::new("orders".to_string());
+        tab.rows = vec![vec!["1".to_string()]; rows];
+        tab
+    }
+
+    #[test]
+    fn test_pin_snapshots_and_replaces_by_name() {
+        let mut pins = Pins::default();
+        assert_eq!(pins.default_name(), "pin 1");
+        assert!(!pins.pin("before".to_string(), &tab(2)));
+        assert_eq!(pins.default_name(), "pin 1");
+        assert!(!pins.pin("pin 1".to_string(), &tab(1)));
+        assert_eq!(pins.default_name(), "pin 2");
+
+        assert!(pins.pin("before".to_string(), &tab(3)));
+        assert_eq!(pins.pins().len(), 2);
+        let before = pins.get(0).unwrap();
+        assert_eq!(before.tab.rows.len(), 3);
+        assert_eq!(before.tab.pin.as_deref(), Some("before"));
+        assert_eq!(before.tab.table_name, "📌 before");
+        assert!(!before.tab.is_table_preview());
+    }
+
+    #[test]
+    fn test_remove_keeps_selection_in_range() {
+        let mut pins = Pins::default();
+        pins.pin("before".to_string(), &tab(1));
+        pins.pin("after".to_string(), &tab(1));
+        pins.selected = 1;
+        assert_eq!(pins.remove(1).unwrap().name, "after");
+        assert_eq!(pins.selected, 0);
+        assert!(pins.remove(5).is_none());
+    }
+}
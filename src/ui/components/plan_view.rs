@@ -0,0 +1,841 @@
+// FilePath: src/ui/components/plan_view.rs
+
+#![forbid(unsafe_code)]
+
+use super::json_view::JsonNode;
+use crate::ui::theme::Theme;
+use ratatui::{
+    layout::Rect,
+    style::{Modifier, Style},
+    text::{Line, Span},
+    widgets::Paragraph,
+    Frame,
+};
+
+/// Width of the bar showing a node's share of the whole plan
+const BAR_WIDTH: usize = 10;
+
+/// Actual rows this many times off the estimate (either way) are flagged
+const MISESTIMATE_FACTOR: f64 = 10.0;
+
+/// Rows a filtered sequential scan reads before an index is suggested
+const INDEX_HINT_ROWS: f64 = 10_000.0;
+
+/// Columns an index suggestion names at most
+const INDEX_HINT_COLUMNS: usize = 3;
+
+/// MySQL plan keys that stand for a step of their own; the rest of the
+/// document only carries details
+const MYSQL_OPERATIONS: &[&str] = &[
+    "query_block",
+    "ordering_operation",
+    "grouping_operation",
+    "duplicates_removal",
+    "windowing",
+    "union_result",
+    "materialized_from_subquery",
+    "attached_subqueries",
+    "optimized_away_subqueries",
+    "query_specifications",
+];
+
+/// One step of a query plan
+#[derive(Debug, Clone, PartialEq)]
+pub struct PlanNode {
+    /// "Seq Scan on users u", "orders (ref on idx_user)"
+    pub label: String,
+    pub depth: usize,
+    /// Rows the planner expected, per loop
+    pub estimated_rows: Option<f64>,
+    /// Rows the node produced, per loop (EXPLAIN ANALYZE only)
+    pub actual_rows: Option<f64>,
+    pub loops: Option<f64>,
+    /// Time spent in this node alone, children excluded, in milliseconds
+    pub self_time_ms: Option<f64>,
+    /// Cost of this node alone, children excluded
+    pub self_cost: f64,
+    /// Conditions and similar details, shown for the selected node
+    pub details: Vec<String>,
+    /// Guessed index for a sequential scan filtering out most of what it
+    /// reads, e.g. "orders(status, created_at)"
+    pub index_hint: Option<String>,
+}
+
+impl PlanNode {
+    fn new(label: String, depth: usize) -> Self {
+        Self {
+            label,
+            depth,
+            estimated_rows: None,
+            actual_rows: None,
+            loops: None,
+            self_time_ms: None,
+            self_cost: 0.0,
+            details: Vec::new(),
+            index_hint: None,
+        }
+    }
+
+    /// Whether the actual rows are off the estimate by `MISESTIMATE_FACTOR`
+    pub fn is_misestimated(&self) -> bool {
+        match (self.actual_rows, self.estimated_rows) {
+            (Some(actual), Some(estimated)) => {
+                let (actual, estimated) = (actual.max(1.0), estimated.max(1.0));
+                actual / estimated >= MISESTIMATE_FACTOR || estimated / actual >= MISESTIMATE_FACTOR
+            }
+            _ => false,
+        }
+    }
+}
+
+/// Tree view of an `EXPLAIN (FORMAT JSON)` result from PostgreSQL or an
+/// `EXPLAIN FORMAT=JSON` one from MySQL. Each node gets a bar with its share
+/// of the plan's time when the plan was analyzed, of its cost otherwise
+#[derive(Debug, Clone)]
+pub struct PlanView {
+    /// Nodes in tree order
+    pub nodes: Vec<PlanNode>,
+    /// Whether shares are of measured time rather than estimated cost
+    pub timed: bool,
+    pub planning_ms: Option<f64>,
+    pub execution_ms: Option<f64>,
+    /// Node with the largest share
+    pub hottest: Option<usize>,
+    /// Node under the cursor
+    pub selected_line: usize,
+    /// First visible node
+    pub scroll_offset: usize,
+    /// Visible lines (updated on render)
+    pub viewport_height: usize,
+}
+
+impl PlanView {
+    /// View of `text` if it is a PostgreSQL or MySQL JSON query plan
+    pub fn parse(text: &str) -> Option<Self> {
+        let root: JsonNode = serde_json::from_str(text.trim()).ok()?;
+        let mut view = Self {
+            nodes: Vec::new(),
+            timed: false,
+            planning_ms: None,
+            execution_ms: None,
+            hottest: None,
+            selected_line: 0,
+            scroll_offset: 0,
+            viewport_height: 0,
+        };
+
+        // PostgreSQL: [{"Plan": {...}, "Planning Time": .., "Execution Time": ..}]
+        let postgres = match &root {
+            JsonNode::Array(items) if items.len() == 1 => Some(&items[0]),
+            _ => None,
+        };
+        if let Some(plan) = postgres.and_then(|explain| get(explain, "Plan")) {
+            let explain = postgres?;
+            view.planning_ms = get(explain, "Planning Time").and_then(number);
+            view.execution_ms = get(explain, "Execution Time").and_then(number);
+            view.timed = get(plan, "Actual Total Time").is_some();
+            push_postgres_node(plan, 0, &[], &mut view.nodes);
+        } else if get(&root, "query_block").is_some() {
+            push_mysql_node("", &root, 0, &mut view.nodes);
+        } else {
+            return None;
+        }
+        if view.nodes.is_empty() {
+            return None;
+        }
+
+        view.hottest = (0..view.nodes.len())
+            .filter(|&i| view.weight(i) > 0.0)
+            .max_by(|&a, &b| view.weight(a).total_cmp(&view.weight(b)));
+        Some(view)
+    }
+
+    /// What a node's share is measured in: its own time or its own cost
+    fn weight(&self, index: usize) -> f64 {
+        let node = &self.nodes[index];
+        if self.timed {
+            node.self_time_ms.unwrap_or(0.0)
+        } else {
+            node.self_cost
+        }
+    }
+
+    /// A node's share of the whole plan, 0.0 to 1.0
+    pub fn share(&self, index: usize) -> f64 {
+        let total: f64 = (0..self.nodes.len()).map(|i| self.weight(i)).sum();
+        if total > 0.0 {
+            self.weight(index) / total
+        } else {
+            0.0
+        }
+    }
+
+    /// Summary for the view's title, e.g. "planning 0.2 ms · execution 14.8 ms"
+    pub fn summary(&self) -> String {
+        let mut parts = Vec::new();
+        if let Some(ms) = self.planning_ms {
+            parts.push(format!("planning {}", format_ms(ms)));
+        }
+        if let Some(ms) = self.execution_ms {
+            parts.push(format!("execution {}", format_ms(ms)));
+        }
+        if !self.timed {
+            parts.push("estimated cost shares, EXPLAIN ANALYZE for timings".to_string());
+        }
+        parts.join(" · ")
+    }
+
+    /// Index suggestions of the plan's scans, labeled as the guesses they
+    /// are
+    pub fn index_hints(&self) -> Vec<String> {
+        self.nodes
+            .iter()
+            .filter_map(|node| node.index_hint.as_ref())
+            .map(|hint| format!("Hint (heuristic): consider an index on {hint}"))
+            .collect()
+    }
+
+    /// Details of the selected node, e.g. its filter
+    pub fn selected_details(&self) -> String {
+        self.nodes
+            .get(self.selected_line)
+            .map(|node| node.details.join(" · "))
+            .unwrap_or_default()
+    }
+
+    pub fn move_down(&mut self, lines: usize) {
+        self.selected_line = (self.selected_line + lines).min(self.nodes.len().saturating_sub(1));
+    }
+
+    pub fn move_up(&mut self, lines: usize) {
+        self.selected_line = self.selected_line.saturating_sub(lines);
+    }
+
+    pub fn jump_to_top(&mut self) {
+        self.selected_line = 0;
+    }
+
+    pub fn jump_to_bottom(&mut self) {
+        self.selected_line = self.nodes.len().saturating_sub(1);
+    }
+
+    /// Put the cursor on the node with the largest share
+    pub fn jump_to_hottest(&mut self) {
+        if let Some(hottest) = self.hottest {
+            self.selected_line = hottest;
+        }
+    }
+
+    /// Render the visible nodes into `area`, keeping the cursor in view
+    pub fn render(&mut self, f: &mut Frame, area: Rect, theme: &Theme, is_focused: bool) {
+        self.viewport_height = area.height as usize;
+        if self.selected_line < self.scroll_offset {
+            self.scroll_offset = self.selected_line;
+        } else if self.viewport_height > 0
+            && self.selected_line >= self.scroll_offset + self.viewport_height
+        {
+            self.scroll_offset = self.selected_line + 1 - self.viewport_height;
+        }
+
+        let lines: Vec<Line> = (0..self.nodes.len())
+            .skip(self.scroll_offset)
+            .take(self.viewport_height)
+            .map(|index| {
+                let line = self.render_node(index, theme);
+                if is_focused && index == self.selected_line {
+                    line.style(Style::default().bg(theme.get_color("selection_bg")))
+                } else {
+                    line
+                }
+            })
+            .collect();
+
+        f.render_widget(Paragraph::new(lines), area);
+    }
+
+    fn render_node(&self, index: usize, theme: &Theme) -> Line<'static> {
+        let node = &self.nodes[index];
+        let share = self.share(index);
+        let hottest = self.hottest == Some(index);
+        let accent = if hottest {
+            Style::default()
+                .fg(theme.get_color("danger"))
+                .add_modifier(Modifier::BOLD)
+        } else {
+            Style::default().fg(theme.get_color("accent"))
+        };
+        let muted = Style::default().fg(theme.get_color("text_muted"));
+
+        let mut spans = vec![
+            Span::styled(share_bar(share), accent),
+            Span::styled(format!(" {:>5.1}% ", share * 100.0), accent),
+        ];
+        if self.timed {
+            spans.push(Span::styled(
+                format!(
+                    "{:>11} ",
+                    node.self_time_ms.map(format_ms).unwrap_or_default()
+                ),
+                muted,
+            ));
+        }
+        spans.push(Span::styled(
+            format!("{:<24} ", rows_text(node)),
+            if node.is_misestimated() {
+                Style::default().fg(theme.get_color("warning"))
+            } else {
+                muted
+            },
+        ));
+        let branch = if node.depth == 0 { "" } else { "└─ " };
+        spans.push(Span::raw(format!(
+            "{}{}",
+            "   ".repeat(node.depth.saturating_sub(1)),
+            branch
+        )));
+        spans.push(Span::styled(
+            node.label.clone(),
+            if hottest {
+                accent
+            } else {
+                Style::default().fg(theme.get_color("text_primary"))
+            },
+        ));
+        Line::from(spans)
+    }
+}
+
+/// Add a PostgreSQL plan node and its children; `sort_key` is the one of
+/// the nearest Sort above it
+fn push_postgres_node(plan: &JsonNode, depth: usize, sort_key: &[String], out: &mut Vec<PlanNode>) {
+    let text = |key| get(plan, key).and_then(string);
+    let mut label = text("Node Type").unwrap_or_else(|| "?".to_string());
+    if let Some(strategy) = text("Strategy").filter(|s| s != "Plain") {
+        label = format!("{strategy} {label}");
+    }
+    if let Some(join) = text("Join Type") {
+        label.push_str(&format!(" ({join})"));
+    }
+    if let Some(index) = text("Index Name") {
+        label.push_str(&format!(" using {index}"));
+    }
+    if let Some(relation) = text("Relation Name") {
+        label.push_str(&format!(" on {relation}"));
+        if let Some(alias) = text("Alias").filter(|alias| *alias != relation) {
+            label.push_str(&format!(" {alias}"));
+        }
+    }
+
+    let mut node = PlanNode::new(label, depth);
+    node.estimated_rows = get(plan, "Plan Rows").and_then(number);
+    node.actual_rows = get(plan, "Actual Rows").and_then(number);
+    node.loops = get(plan, "Actual Loops").and_then(number);
+    for key in [
+        "Index Cond",
+        "Recheck Cond",
+        "Hash Cond",
+        "Merge Cond",
+        "Join Filter",
+        "Filter",
+        "Sort Key",
+        "Group Key",
+    ] {
+        if let Some(value) = get(plan, key) {
+            let value = match value {
+                JsonNode::Array(items) => items
+                    .iter()
+                    .filter_map(string)
+                    .collect::<Vec<_>>()
+                    .join(", "),
+                other => string(other).unwrap_or_default(),
+            };
+            node.details.push(format!("{key}: {value}"));
+        }
+    }
+    if let Some(removed) = get(plan, "Rows Removed by Filter").and_then(number) {
+        node.details
+            .push(format!("Rows Removed by Filter: {}", format_rows(removed)));
+    }
+
+    // Times are per loop and include the children, as does the cost
+    let total_time = |plan: &JsonNode| {
+        let time = get(plan, "Actual Total Time").and_then(number)?;
+        Some(time * get(plan, "Actual Loops").and_then(number).unwrap_or(1.0))
+    };
+    let cost = |plan: &JsonNode| get(plan, "Total Cost").and_then(number).unwrap_or(0.0);
+    let children: &[JsonNode] = match get(plan, "Plans") {
+        Some(JsonNode::Array(children)) => children,
+        _ => &[],
+    };
+    node.self_time_ms = total_time(plan).map(|time| {
+        let child_time: f64 = children.iter().filter_map(total_time).sum();
+        // Parallel workers' times overlap; never count below zero
+        (time - child_time).max(0.0)
+    });
+    node.self_cost = (cost(plan) - children.iter().map(cost).sum::<f64>()).max(0.0);
+    node.index_hint = postgres_index_hint(plan, sort_key);
+
+    let sort_key = match get(plan, "Sort Key") {
+        Some(JsonNode::Array(keys)) => keys.iter().filter_map(string).collect(),
+        _ => sort_key.to_vec(),
+    };
+    out.push(node);
+    for child in children {
+        push_postgres_node(child, depth + 1, &sort_key, out);
+    }
+}
+
+/// Index worth trying for an analyzed sequential scan that read at least
+/// `INDEX_HINT_ROWS` rows and dropped most of them by its filter: the
+/// columns compared with `=` first, then those in ranges, then the sort's.
+/// Only the filter's text is read, so it's a guess, e.g. "orders(status,
+/// created_at)"
+fn postgres_index_hint(plan: &JsonNode, sort_key: &[String]) -> Option<String> {
+    let text = |key| get(plan, key).and_then(string);
+    if text("Node Type")? != "Seq Scan" {
+        return None;
+    }
+    let relation = text("Relation Name")?;
+    let filter = text("Filter")?;
+    let kept = get(plan, "Actual Rows").and_then(number)?;
+    let removed = get(plan, "Rows Removed by Filter")
+        .and_then(number)
+        .unwrap_or(0.0);
+    let loops = get(plan, "Actual Loops").and_then(number).unwrap_or(1.0);
+    if (kept + removed) * loops < INDEX_HINT_ROWS || removed < kept {
+        return None;
+    }
+
+    let owners = [Some(relation.clone()), text("Alias")];
+    let own_column = |name: &str| match name.rsplit_once('.') {
+        Some((owner, column)) => owners
+            .iter()
+            .flatten()
+            .any(|own| own == owner)
+            .then(|| column.to_string()),
+        None => Some(name.to_string()),
+    };
+    let (equal, range) = filter_columns(&filter);
+    let sorted = sort_key
+        .iter()
+        .filter_map(|key| key.split_whitespace().next())
+        .filter(|key| key.starts_with(|c: char| c == '"' || c == '_' || c.is_alphabetic()));
+    let mut columns: Vec<String> = Vec::new();
+    for column in equal.iter().chain(&range).map(String::as_str).chain(sorted) {
+        if let Some(column) = own_column(column) {
+            if !columns.contains(&column) && columns.len() < INDEX_HINT_COLUMNS {
+                columns.push(column);
+            }
+        }
+    }
+    (!columns.is_empty()).then(|| format!("{relation}({})", columns.join(", ")))
+}
+
+/// Columns of a PostgreSQL filter compared for equality (`=`, `= ANY`,
+/// `IS NULL`) and in ranges (`<`, `>=` and so on), read naively from the
+/// expression: a column counts when the operator follows it, past a cast
+/// such as `((status)::text = 'paid'::text)`
+fn filter_columns(filter: &str) -> (Vec<String>, Vec<String>) {
+    let tokens = filter_tokens(filter);
+    let (mut equal, mut range) = (Vec::new(), Vec::new());
+    for (at, token) in tokens.iter().enumerate() {
+        let starts_name = token
+            .chars()
+            .next()
+            .is_some_and(|c| c == '"' || c == '_' || c.is_alphabetic());
+        let cast = at > 0 && tokens[at - 1] == "::";
+        // A function's argument, as in lower(email), needs an index on the
+        // expression rather than the column
+        let argument = at > 1
+            && tokens[at - 1] == "("
+            && tokens[at - 2].starts_with(char::is_alphabetic)
+            && !is_keyword(&tokens[at - 2]);
+        if !starts_name || is_keyword(token) || cast || argument {
+            continue;
+        }
+        let mut next = at + 1;
+        loop {
+            match tokens.get(next).map(String::as_str) {
+                Some(")") => next += 1,
+                Some("::") => {
+                    next += 1;
+                    // Type names can be several words, e.g. character varying
+                    while tokens.get(next).is_some_and(|word| {
+                        word.starts_with(char::is_alphabetic) && !word.eq_ignore_ascii_case("is")
+                    }) {
+                        next += 1;
+                    }
+                }
+                _ => break,
+            }
+        }
+        let next = tokens.get(next).map(|next| next.to_ascii_uppercase());
+        match next.as_deref() {
+            Some("=" | "IS") => equal.push(token.clone()),
+            Some("<" | ">" | "<=" | ">=") => range.push(token.clone()),
+            _ => {}
+        }
+    }
+    (equal, range)
+}
+
+fn is_keyword(word: &str) -> bool {
+    matches!(
+        word.to_ascii_uppercase().as_str(),
+        "AND" | "OR" | "NOT" | "IS" | "NULL" | "TRUE" | "FALSE" | "ANY" | "ALL"
+    )
+}
+
+/// Words, quoted names, string literals, operators and brackets of a filter
+fn filter_tokens(filter: &str) -> Vec<String> {
+    let mut tokens = Vec::new();
+    let mut chars = filter.chars().peekable();
+    while let Some(c) = chars.next() {
+        let mut token = c.to_string();
+        if c.is_whitespace() {
+            continue;
+        } else if c == '\'' || c == '"' {
+            // A doubled quote is one inside the literal or name
+            while let Some(next) = chars.next() {
+                token.push(next);
+                if next == c {
+                    if chars.next_if_eq(&c).is_none() {
+                        break;
+                    }
+                    token.push(c);
+                }
+            }
+            // A qualified name goes on after the quote
+            while let Some(next) =
+                chars.next_if(|next| *next == '.' || *next == '_' || next.is_alphanumeric())
+            {
+                token.push(next);
+            }
+        } else if c == '_' || c.is_alphanumeric() {
+            while let Some(next) = chars.next_if(|next| {
+                *next == '.' || *next == '_' || *next == '"' || next.is_alphanumeric()
+            }) {
+                token.push(next);
+            }
+        } else if "=<>!~:".contains(c) {
+            while let Some(next) = chars.next_if(|next| "=<>!~:".contains(*next)) {
+                token.push(next);
+            }
+        }
+        tokens.push(token);
+    }
+    tokens
+}
+
+/// Add the steps found in a MySQL plan document: tables, and operations such
+/// as sorting and grouping with the tables they work on nested underneath
+fn push_mysql_node(key: &str, value: &JsonNode, depth: usize, out: &mut Vec<PlanNode>) {
+    let entries = match value {
+        JsonNode::Array(items) => {
+            for item in items {
+                push_mysql_node(key, item, depth, out);
+            }
+            return;
+        }
+        JsonNode::Object(entries) => entries,
+        _ => return,
+    };
+
+    let text = |key| get(value, key).and_then(string);
+    let cost = |key| {
+        get(value, "cost_info")
+            .and_then(|info| get(info, key))
+            .and_then(number)
+    };
+    let mut depth = depth;
+    if let Some(table) = text("table_name") {
+        let mut label = table;
+        match (text("access_type"), text("key")) {
+            (Some(access), Some(index)) => label.push_str(&format!(" ({access} on {index})")),
+            (Some(access), None) => label.push_str(&format!(" ({access})")),
+            _ => {}
+        }
+        let mut node = PlanNode::new(label, depth);
+        node.estimated_rows = get(value, "rows_examined_per_scan").and_then(number);
+        node.self_cost = cost("read_cost").unwrap_or(0.0) + cost("eval_cost").unwrap_or(0.0);
+        if let Some(condition) = text("attached_condition") {
+            node.details.push(format!("Condition: {condition}"));
+        }
+        if let Some(filtered) = get(value, "filtered").and_then(number) {
+            node.details.push(format!("Filtered: {filtered}%"));
+        }
+        out.push(node);
+        depth += 1;
+    } else if MYSQL_OPERATIONS.contains(&key) {
+        let mut label = match key {
+            "query_block" => match get(value, "select_id").and_then(number) {
+                Some(id) => format!("Query block #{id}"),
+                None => "Query block".to_string(),
+            },
+            other => {
+                let words = other.replace('_', " ");
+                let mut chars = words.chars();
+                chars
+                    .next()
+                    .map(|first| first.to_uppercase().chain(chars).collect())
+                    .unwrap_or_default()
+            }
+        };
+        if get(value, "using_filesort").and_then(boolean) == Some(true) {
+            label.push_str(" (filesort)");
+        }
+        if get(value, "using_temporary_table").and_then(boolean) == Some(true) {
+            label.push_str(" (temporary table)");
+        }
+        let mut node = PlanNode::new(label, depth);
+        node.self_cost = cost("sort_cost").unwrap_or(0.0);
+        out.push(node);
+        depth += 1;
+    }
+
+    for (child_key, child) in entries {
+        if child_key != "cost_info" {
+            push_mysql_node(child_key, child, depth, out);
+        }
+    }
+}
+
+/// Value under `key` of an object
+fn get<'a>(node: &'a JsonNode, key: &str) -> Option<&'a JsonNode> {
+    match node {
+        JsonNode::Object(entries) => entries
+            .iter()
+            .find(|(name, _)| name == key)
+            .map(|(_, value)| value),
+        _ => None,
+    }
+}
+
+/// A number, also when written as a string (MySQL's costs are)
+fn number(node: &JsonNode) -> Option<f64> {
+    match node {
+        JsonNode::Number(n) => n.as_f64(),
+        JsonNode::String(s) => s.parse().ok(),
+        _ => None,
+    }
+}
+
+fn string(node: &JsonNode) -> Option<String> {
+    match node {
+        JsonNode::String(s) => Some(s.clone()),
+        JsonNode::Number(n) => Some(n.to_string()),
+        _ => None,
+    }
+}
+
+fn boolean(node: &JsonNode) -> Option<bool> {
+    match node {
+        JsonNode::Bool(b) => Some(*b),
+        _ => None,
+    }
+}
+
+/// "rows 1,000 (est 980) ×3" or "est 980"
+fn rows_text(node: &PlanNode) -> String {
+    let estimated = node.estimated_rows.map(format_rows);
+    let text = match (node.actual_rows.map(format_rows), estimated) {
+        (Some(actual), Some(estimated)) => format!("rows {actual} (est {estimated})"),
+        (Some(actual), None) => format!("rows {actual}"),
+        (None, Some(estimated)) => format!("est {estimated} rows"),
+        (None, None) => String::new(),
+    };
+    match node.loops {
+        Some(loops) if loops > 1.0 => format!("{text} ×{loops}"),
+        _ => text,
+    }
+}
+
+fn format_rows(rows: f64) -> String {
+    super::table_viewer::group_thousands(rows.round().max(0.0) as usize)
+}
+
+fn format_ms(ms: f64) -> String {
+    if ms >= 1000.0 {
+        format!("{:.2} s", ms / 1000.0)
+    } else {
+        format!("{ms:.3} ms")
+    }
+}
+
+/// Bar `BAR_WIDTH` cells wide, filled to `share` in eighths of a cell
+fn share_bar(share: f64) -> String {
+    const PARTIAL: [char; 8] = [' ', '▏', '▎', '▍', '▌', '▋', '▊', '▉'];
+    let eighths = (share.clamp(0.0, 1.0) * (BAR_WIDTH * 8) as f64).round() as usize;
+    let mut bar = "█".repeat(eighths / 8);
+    if eighths % 8 > 0 {
+        bar.push(PARTIAL[eighths % 8]);
+    }
+    let filled = bar.chars().count();
+    bar.push_str(&"·".repeat(BAR_WIDTH - filled));
+    bar
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const POSTGRES_ANALYZE: &str = r#"[{
+        "Plan": {
+            "Node Type": "Hash Join", "Join Type": "Inner",
+            "Total Cost": 120.0, "Plan Rows": 100,
+            "Actual Total Time": 10.0, "Actual Rows": 5000, "Actual Loops": 1,
+            "Hash Cond": "(o.user_id = u.id)",
+            "Plans": [
+                {"Node Type": "Seq Scan", "Relation Name": "orders", "Alias": "o",
+                 "Total Cost": 80.0, "Plan Rows": 5000,
+                 "Actual Total Time": 7.5, "Actual Rows": 5000, "Actual Loops": 1},
+                {"Node Type": "Hash", "Total Cost": 20.0, "Plan Rows": 50,
+                 "Actual Total Time": 1.5, "Actual Rows": 50, "Actual Loops": 1,
+                 "Plans": [
+                    {"Node Type": "Index Scan", "Index Name": "users_pkey",
+                     "Relation Name": "users", "Alias": "u",
+                     "Total Cost": 18.0, "Plan Rows": 50,
+                     "Actual Total Time": 0.5, "Actual Rows": 50, "Actual Loops": 2,
+                     "Filter": "(active)"}
+                 ]}
+            ]
+        },
+        "Planning Time": 0.25,
+        "Execution Time": 10.5
+    }]"#;
+
+    #[test]
+    fn test_postgres_plan_tree_and_shares() {
+        let view = PlanView::parse(POSTGRES_ANALYZE).unwrap();
+        let labels: Vec<(usize, &str)> = view
+            .nodes
+            .iter()
+            .map(|node| (node.depth, node.label.as_str()))
+            .collect();
+        assert_eq!(
+            labels,
+            vec![
+                (0, "Hash Join (Inner)"),
+                (1, "Seq Scan on orders o"),
+                (1, "Hash"),
+                (2, "Index Scan using users_pkey on users u"),
+            ]
+        );
+        assert!(view.timed);
+        // Own time: join 10 - 7.5 - 1.5, seq scan 7.5, hash 1.5 - 0.5×2
+        let own: Vec<f64> = view.nodes.iter().map(|n| n.self_time_ms.unwrap()).collect();
+        assert_eq!(own, vec![1.0, 7.5, 0.5, 1.0]);
+        assert_eq!(view.hottest, Some(1));
+        assert!((view.share(1) - 0.75).abs() < 1e-9);
+        assert!(view.nodes[0].is_misestimated());
+        assert!(!view.nodes[1].is_misestimated());
+        assert_eq!(view.nodes[3].details, vec!["Filter: (active)"]);
+        assert_eq!(view.summary(), "planning 0.250 ms · execution 10.500 ms");
+    }
+
+    #[test]
+    fn test_plain_explain_uses_cost() {
+        let view = PlanView::parse(
+            r#"[{"Plan": {"Node Type": "Limit", "Total Cost": 10.0, "Plan Rows": 10,
+                "Plans": [{"Node Type": "Seq Scan", "Relation Name": "t", "Alias": "t",
+                           "Total Cost": 9.0, "Plan Rows": 1000}]}}]"#,
+        )
+        .unwrap();
+        assert!(!view.timed);
+        assert_eq!(view.hottest, Some(1));
+        assert!((view.share(0) - 0.1).abs() < 1e-9);
+        assert_eq!(rows_text(&view.nodes[1]), "est 1,000 rows");
+    }
+
+    #[test]
+    fn test_mysql_plan_tree() {
+        let view = PlanView::parse(
+            r#"{"query_block": {"select_id": 1, "cost_info": {"query_cost": "12.50"},
+                "ordering_operation": {"using_filesort": true,
+                    "nested_loop": [
+                        {"table": {"table_name": "u", "access_type": "ALL",
+                                   "rows_examined_per_scan": 40,
+                                   "cost_info": {"read_cost": "2.00", "eval_cost": "4.00"}}},
+                        {"table": {"table_name": "o", "access_type": "ref", "key": "idx_user",
+                                   "rows_examined_per_scan": 3,
+                                   "cost_info": {"read_cost": "1.00", "eval_cost": "1.00"},
+                                   "attached_condition": "(o.total > 10)"}}
+                    ]}}}"#,
+        )
+        .unwrap();
+        let labels: Vec<(usize, &str)> = view
+            .nodes
+            .iter()
+            .map(|node| (node.depth, node.label.as_str()))
+            .collect();
+        assert_eq!(
+            labels,
+            vec![
+                (0, "Query block #1"),
+                (1, "Ordering operation (filesort)"),
+                (2, "u (ALL)"),
+                (2, "o (ref on idx_user)"),
+            ]
+        );
+        assert_eq!(view.hottest, Some(2));
+        assert_eq!(view.nodes[3].details, vec!["Condition: (o.total > 10)"]);
+    }
+
+    #[test]
+    fn test_index_hint_for_filtered_seq_scan() {
+        let view = PlanView::parse(
+            r#"[{"Plan": {"Node Type": "Sort", "Sort Key": ["o.created_at DESC"],
+                "Total Cost": 900.0, "Actual Total Time": 40.0, "Actual Rows": 40,
+                "Actual Loops": 1,
+                "Plans": [{"Node Type": "Seq Scan", "Relation Name": "orders", "Alias": "o",
+                           "Total Cost": 850.0, "Actual Total Time": 38.0,
+                           "Actual Rows": 40, "Actual Loops": 1,
+                           "Filter": "(((o.status)::text = 'new'::text) AND (o.total > '10'::numeric))",
+                           "Rows Removed by Filter": 199960}]}}]"#,
+        )
+        .unwrap();
+        assert_eq!(
+            view.nodes[1].index_hint.as_deref(),
+            Some("orders(status, total, created_at)")
+        );
+        assert_eq!(
+            view.index_hints(),
+            vec!["Hint (heuristic): consider an index on orders(status, total, created_at)"]
+        );
+
+        // Small tables and filters keeping most rows aren't worth an index
+        assert!(PlanView::parse(POSTGRES_ANALYZE)
+            .unwrap()
+            .index_hints()
+            .is_empty());
+    }
+
+    #[test]
+    fn test_filter_columns() {
+        assert_eq!(
+            filter_columns(r#"((deleted_at IS NULL) AND ("Kind" = ANY ('{a,b}'::text[])))"#),
+            (
+                vec!["deleted_at".to_string(), "\"Kind\"".to_string()],
+                vec![]
+            )
+        );
+        assert_eq!(
+            filter_columns("((created_at >= '2024-01-01'::date) AND (lower(email) = 'x'::text))"),
+            (vec![], vec!["created_at".to_string()])
+        );
+    }
+
+    #[test]
+    fn test_other_json_is_not_a_plan() {
+        assert!(PlanView::parse(r#"[{"id": 1}]"#).is_none());
+        assert!(PlanView::parse(r#"{"Plan": 1}"#).is_none());
+        assert!(PlanView::parse("QUERY PLAN").is_none());
+    }
+
+    #[test]
+    fn test_share_bar() {
+        assert_eq!(share_bar(0.0), "··········");
+        assert_eq!(share_bar(1.0), "██████████");
+        assert_eq!(share_bar(0.25), "██▌·······");
+    }
+}
@@ -3,7 +3,7 @@
 #![forbid(unsafe_code)]
 
 use super::{SqlSuggestionEngine, SuggestionPopup};
-use crate::database::DatabaseType;
+use crate::database::{ConnectionConfig, DatabaseType};
 use ratatui::{
     layout::Rect,
     style::{Color, Modifier, Style},
@@ -18,6 +18,26 @@ use syntect::{
     parsing::{SyntaxReference, SyntaxSet},
 };
 
+/// Colors a connection label is drawn in, picked by its name so each
+/// connection keeps its own
+const TARGET_COLORS: [Color; 6] = [
+    Color::Rgb(255, 170, 90),
+    Color::Rgb(120, 200, 255),
+    Color::Rgb(150, 230, 130),
+    Color::Rgb(240, 130, 200),
+    Color::Rgb(230, 220, 110),
+    Color::Rgb(180, 160, 255),
+];
+
+/// Accent color of the connection named `name`, the same in every session
+fn target_color(name: &str) -> Color {
+    // FNV-1a: stable across runs, unlike the standard library's hasher
+    let hash = name.bytes().fold(0xcbf2_9ce4_8422_2325_u64, |hash, byte| {
+        (hash ^ u64::from(byte)).wrapping_mul(0x0100_0000_01b3)
+    });
+    TARGET_COLORS[(hash % TARGET_COLORS.len() as u64) as usize]
+}
+
 #[derive(Debug)]
 pub struct QueryEditor {
     content: String,
@@ -49,6 +69,9 @@ pub struct QueryEditor {
     is_command_mode: bool,
     /// Command buffer for : commands
     command_buffer: String,
+    /// Connection and database statements run on, shown in the border in
+    /// the connection's color
+    target: Option<(String, Color)>,
 }
 
 impl Clone for QueryEditor {
@@ -73,6 +96,7 @@ impl Clone for QueryEditor {
             pending_command: None,
             is_command_mode: false,
             command_buffer: String::new(),
+            target: self.target.clone(),
         }
     }
 }
@@ -105,6 +129,7 @@ impl QueryEditor {
             pending_command: None,
             is_command_mode: false,
             command_buffer: String::new(),
+            target: None,
         }
     }
 
@@ -145,6 +170,13 @@ impl QueryEditor {
         self.database_type.clone()
     }
 
+    /// Name the connection and database statements run on in the border,
+    /// e.g. `prod-replica ▸ shop`; None while not connected
+    pub fn set_target(&mut self, connection: Option<&ConnectionConfig>) {
+        self.target = connection
+            .map(|connection| (connection.target_label(), target_color(&connection.name)));
+    }
+
     pub fn set_focused(&mut self, focused: bool) {
         self.is_focused = focused;
     }
@@ -630,6 +662,26 @@ impl QueryEditor {
         self.update_suggestions();
     }
 
+    /// Insert a single line of text at the cursor, whatever the mode, leaving
+    /// the cursor after it
+    pub fn insert_text(&mut self, text: &str) {
+        let mut lines: Vec<String> = self.content.lines().map(|s| s.to_string()).collect();
+        while self.cursor_line >= lines.len() {
+            lines.push(String::new());
+        }
+
+        let line = &mut lines[self.cursor_line];
+        let mut col = self.cursor_col.min(line.len());
+        if !line.is_char_boundary(col) {
+            col = line.len();
+        }
+        line.insert_str(col, text);
+        self.cursor_col = col + text.len();
+        self.is_modified = true;
+
+        self.content = lines.join("\n");
+    }
+
     pub fn insert_newline(&mut self) {
         if !self.is_insert_mode {
             return;
@@ -675,6 +727,7 @@ impl QueryEditor {
                 if self.cursor_col <= line.len() {
                     line.remove(self.cursor_col - 1);
                     self.cursor_col -= 1;
+                    self.is_modified = true;
                 }
             }
 
@@ -690,6 +743,7 @@ impl QueryEditor {
                     self.cursor_col = new_lines[self.cursor_line].len();
                     new_lines[self.cursor_line].push_str(&current_line);
                 }
+                self.is_modified = true;
             }
 
             self.adjust_scroll();
@@ -697,6 +751,26 @@ impl QueryEditor {
         }
     }
 
+    /// Apply a readline edit (Ctrl+A, Ctrl+W, ...) to the cursor's line
+    pub fn readline(&mut self, edit: super::readline::Edit) {
+        if !self.is_insert_mode {
+            return;
+        }
+
+        let mut lines: Vec<String> = self.content.lines().map(|s| s.to_string()).collect();
+        if self.cursor_line >= lines.len() {
+            return;
+        }
+        let line = &mut lines[self.cursor_line];
+        let before = line.len();
+        edit.apply(line, &mut self.cursor_col);
+        if line.len() != before {
+            self.is_modified = true;
+            self.content = lines.join("\n");
+        }
+        self.hide_suggestions();
+    }
+
     pub fn get_statement_at_cursor(&self) -> Option<String> {
         let lines: Vec<&str> = self.content.lines().collect();
         if lines.is_empty() || self.cursor_line >= lines.len() {
@@ -781,6 +855,12 @@ impl QueryEditor {
         self.suggestion_engine.set_table_columns(table, columns);
     }
 
+    /// Forget every table's columns, e.g. after switching databases
+    pub fn clear_table_columns(&mut self) {
+        self.table_columns.clear();
+        self.suggestion_engine.clear_table_columns();
+    }
+
     /// Set current file name
     pub fn set_current_file(&mut self, filename: Option<String>) {
         self.current_file = filename;
@@ -796,11 +876,16 @@ impl QueryEditor {
         self.is_modified
     }
 
-    /// Mark content as saved (not modified)
+    /// Mark content as saved or executed (not modified)
     pub fn mark_saved(&mut self) {
         self.is_modified = false;
     }
 
+    /// Mark content as modified after an edit made outside the editor
+    pub fn mark_modified(&mut self) {
+        self.is_modified = true;
+    }
+
     /// Update suggestions based on current cursor position
     fn update_suggestions(&mut self) {
         if !self.is_insert_mode || !self.is_focused {
@@ -1134,9 +1219,18 @@ impl QueryEditor {
             }
         );
 
-        // Create editor block
-        let block = Block::default()
-            .title(title)
+        // Create editor block, naming where statements run in the border
+        let mut block = Block::default().title(title);
+        if let Some((target, color)) = &self.target {
+            block = block.title(
+                Line::from(Span::styled(
+                    format!(" {target} "),
+                    Style::default().fg(*color).add_modifier(Modifier::BOLD),
+                ))
+                .right_aligned(),
+            );
+        }
+        let block = block
             .borders(Borders::ALL)
             .border_style(if self.is_focused {
                 Style::default().fg(Color::Cyan)
@@ -1293,6 +1387,20 @@ mod tests {
         assert_eq!(editor.get_content(), content);
     }
 
+    #[test]
+    fn test_insert_text_at_cursor() {
+        let mut editor = QueryEditor::new();
+        editor.set_content("SELECT * FROM users WHERE \nLIMIT 10".to_string());
+        editor.cursor_col = 26;
+        editor.insert_text("\"id\" IN (3, 17)");
+        assert_eq!(
+            editor.get_content(),
+            "SELECT * FROM users WHERE \"id\" IN (3, 17)\nLIMIT 10"
+        );
+        assert_eq!(editor.cursor_col, 41);
+        assert!(editor.is_modified);
+    }
+
     #[test]
     fn test_database_type_setting() {
         let mut editor = QueryEditor::new();
@@ -1510,4 +1618,26 @@ mod tests {
         assert_eq!(editor.cursor_col, 0); // Cursor should be at beginning
         assert!(editor.is_modified());
     }
+
+    #[test]
+    fn test_backspace_marks_modified() {
+        let mut editor = QueryEditor::new();
+        editor.set_content("ab\ncd".to_string());
+        editor.set_insert_mode(true);
+
+        // At the start of the buffer there is nothing to delete
+        editor.backspace();
+        assert!(!editor.is_modified());
+
+        editor.cursor_line = 1;
+        editor.cursor_col = 0;
+        editor.backspace();
+        assert_eq!(editor.get_content(), "abcd");
+        assert!(editor.is_modified());
+
+        editor.mark_saved();
+        editor.backspace();
+        assert_eq!(editor.get_content(), "acd");
+        assert!(editor.is_modified());
+    }
 }
@@ -0,0 +1,234 @@
+// FilePath: src/ui/components/query_history.rs
+
+//! Picker over the query editor's history: typing filters it by query text
+//! and connection, and the selected query is loaded into the editor
+
+#![forbid(unsafe_code)]
+
+use crate::database::QueryHistoryEntry;
+use crate::ui::theme::Theme;
+use chrono::Local;
+use ratatui::{
+    layout::{Alignment, Rect},
+    style::{Modifier, Style},
+    text::{Line, Span},
+    widgets::{Block, Borders, Clear, Paragraph},
+    Frame,
+};
+
+/// History entries, newest first, and the filter typed over them
+#[derive(Debug, Clone, Default)]
+pub struct QueryHistoryPicker {
+    entries: Vec<QueryHistoryEntry>,
+    pub filter: String,
+    /// Index into the entries matching the filter
+    pub selected: usize,
+}
+
+impl QueryHistoryPicker {
+    pub fn new(entries: Vec<QueryHistoryEntry>) -> Self {
+        Self {
+            entries,
+            ..Self::default()
+        }
+    }
+
+    /// Entries whose query or connection contains every word of the filter,
+    /// ignoring case
+    pub fn matches(&self) -> Vec<&QueryHistoryEntry> {
+        let words: Vec<String> = self
+            .filter
+            .split_whitespace()
+            .map(str::to_lowercase)
+            .collect();
+        self.entries
+            .iter()
+            .filter(|entry| {
+                let haystack = format!(
+                    "{} {}",
+                    entry.query_text,
+                    entry.connection_name.as_deref().unwrap_or("")
+                )
+                .to_lowercase();
+                words.iter().all(|word| haystack.contains(word))
+            })
+            .collect()
+    }
+
+    pub fn selected_entry(&self) -> Option<&QueryHistoryEntry> {
+        self.matches().get(self.selected).copied()
+    }
+
+    pub fn push_filter(&mut self, c: char) {
+        self.filter.push(c);
+        self.selected = 0;
+    }
+
+    pub fn pop_filter(&mut self) {
+        self.filter.pop();
+        self.selected = 0;
+    }
+
+    pub fn move_down(&mut self) {
+        if self.selected + 1 < self.matches().len() {
+            self.selected += 1;
+        }
+    }
+
+    pub fn move_up(&mut self) {
+        self.selected = self.selected.saturating_sub(1);
+    }
+}
+
+/// A query on one line, its whitespace runs collapsed
+fn one_line(query: &str) -> String {
+    query.split_whitespace().collect::<Vec<_>>().join(" ")
+}
+
+/// Render the picker as a centered popup, the selected entry highlighted
+/// and kept in view
+pub fn render_query_history(f: &mut Frame, picker: &QueryHistoryPicker, area: Rect, theme: &Theme) {
+    let text = Style::default().fg(theme.get_color("text_primary"));
+    let muted = Style::default().fg(theme.get_color("text_muted"));
+    let active = Style::default()
+        .fg(theme.get_color("accent"))
+        .add_modifier(Modifier::BOLD);
+
+    let width = 96u16.min(area.width.saturating_sub(4));
+    let height = 24u16.min(area.height);
+    // Borders, the filter line and the hint with a blank line above it
+    let visible = height.saturating_sub(5).max(1) as usize;
+    let query_width = (width as usize).saturating_sub(36).max(10);
+
+    let matches = picker.matches();
+    let first = picker.selected.saturating_sub(visible - 1);
+    let mut lines = vec![Line::from(vec![
+        Span::styled("Filter  ", muted),
+        Span::styled(format!("{}▏", picker.filter), active),
+    ])];
+    lines.extend(
+        matches
+            .iter()
+            .enumerate()
+            .skip(first)
+            .take(visible)
+            .map(|(idx, entry)| {
+                let focused = idx == picker.selected;
+                let query: String = one_line(&entry.query_text)
+                    .chars()
+                    .take(query_width)
+                    .collect();
+                let connection: String = entry
+                    .connection_name
+                    .as_deref()
+                    .unwrap_or(entry.database_type.display_name())
+                    .chars()
+                    .take(14)
+                    .collect();
+                Line::from(vec![
+                    Span::styled(if focused { "▶ " } else { "  " }, active),
+                    Span::styled(
+                        format!("{query:<query_width$}"),
+                        if focused { active } else { text },
+                    ),
+                    Span::styled(
+                        format!(
+                            "  {connection:<14}  {}",
+                            entry
+                                .executed_at
+                                .with_timezone(&Local)
+                                .format("%m-%d %H:%M")
+                        ),
+                        muted,
+                    ),
+                ])
+            }),
+    );
+    if matches.is_empty() {
+        lines.push(Line::from(Span::styled(
+            if picker.filter.is_empty() {
+                "  No queries run yet"
+            } else {
+                "  No query matches the filter"
+            },
+            muted,
+        )));
+    }
+    lines.push(Line::from(""));
+    lines.push(Line::from(Span::styled(
+        "type to filter · ↑/↓ select · Enter load into the editor · Esc close",
+        muted,
+    )));
+
+    let height = (lines.len() as u16 + 2).min(height);
+    let popup = Rect {
+        x: area.x + area.width.saturating_sub(width) / 2,
+        y: area.y + area.height.saturating_sub(height) / 2,
+        width,
+        height,
+    };
+    f.render_widget(Clear, popup);
+    let block = Block::default()
+        .borders(Borders::ALL)
+        .title(format!(" Query history ({}) ", matches.len()))
+        .title_alignment(Alignment::Center)
+        .border_style(active)
+        .style(Style::default().bg(theme.get_color("background")));
+    f.render_widget(Paragraph::new(lines).block(block), popup);
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::database::DatabaseType;
+
+    fn entry(query: &str, connection: &str) -> QueryHistoryEntry {
+        QueryHistoryEntry {
+            id: 0,
+            query_text: query.to_string(),
+            connection_name: Some(connection.to_string()),
+            database_type: DatabaseType::PostgreSQL,
+            database_name: None,
+            executed_at: chrono::Utc::now(),
+            execution_time_ms: None,
+            success: true,
+            error_message: None,
+        }
+    }
+
+    #[test]
+    fn test_filter_matches_query_and_connection() {
+        let mut picker = QueryHistoryPicker::new(vec![
+            entry("SELECT * FROM orders", "prod"),
+            entry("select count(*) from users", "local"),
+            entry("SELECT * FROM users", "prod"),
+        ]);
+        assert_eq!(picker.matches().len(), 3);
+
+        picker.move_down();
+        for c in "USERS prod".chars() {
+            picker.push_filter(c);
+        }
+        assert_eq!(picker.selected, 0);
+        let matches = picker.matches();
+        assert_eq!(matches.len(), 1);
+        assert_eq!(matches[0].query_text, "SELECT * FROM users");
+
+        picker.move_down();
+        assert_eq!(picker.selected, 0);
+        assert_eq!(
+            picker
+                .selected_entry()
+                .map(|entry| entry.query_text.as_str()),
+            Some("SELECT * FROM users")
+        );
+    }
+
+    #[test]
+    fn test_one_line() {
+        assert_eq!(
+            one_line("SELECT *\n  FROM orders\n\tWHERE id = 1"),
+            "SELECT * FROM orders WHERE id = 1"
+        );
+    }
+}
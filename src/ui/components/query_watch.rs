@@ -0,0 +1,223 @@
+// FilePath: src/ui/components/query_watch.rs
+
+#![forbid(unsafe_code)]
+
+use std::collections::HashMap;
+use std::sync::atomic::{AtomicU64, Ordering};
+use std::time::{Duration, Instant};
+
+/// Source of watch ids
+static NEXT_WATCH_ID: AtomicU64 = AtomicU64::new(1);
+
+/// Refreshes a changed cell stays highlighted for, counting the one that
+/// changed it
+pub const CHANGE_HIGHLIGHT_REFRESHES: u8 = 3;
+
+/// Auto-refresh of a query result tab: the tab's query is run again every
+/// interval, in the background. The countdown restarts while the tab is
+/// being navigated so the rows don't move under the cursor
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct QueryWatch {
+    /// Tells the result of a background refresh which watch it belongs to,
+    /// even after tabs are closed or the watch is restarted
+    id: u64,
+    interval: Duration,
+    next_run: Instant,
+    /// Whether a refresh is running in the background
+    refreshing: bool,
+    /// Cells changed by recent refreshes, keyed by (row, column), with the
+    /// refreshes left before they stop being highlighted
+    changes: HashMap<(usize, usize), u8>,
+}
+
+impl QueryWatch {
+    pub fn new(interval: Duration, now: Instant) -> Self {
+        Self {
+            id: NEXT_WATCH_ID.fetch_add(1, Ordering::Relaxed),
+            interval,
+            next_run: now + interval,
+            refreshing: false,
+            changes: HashMap::new(),
+        }
+    }
+
+    pub fn id(&self) -> u64 {
+        self.id
+    }
+
+    /// Whether the query should run again; not while a refresh is running
+    pub fn is_due(&self, now: Instant) -> bool {
+        !self.refreshing && now >= self.next_run
+    }
+
+    /// Mark a background refresh as started
+    pub fn start_refresh(&mut self) {
+        self.refreshing = true;
+    }
+
+    pub fn is_refreshing(&self) -> bool {
+        self.refreshing
+    }
+
+    /// Mark the background refresh as done, restarting the countdown
+    pub fn end_refresh(&mut self, now: Instant) {
+        self.refreshing = false;
+        self.hold(now);
+    }
+
+    /// Restart the countdown, after a refresh or while the user is busy
+    pub fn hold(&mut self, now: Instant) {
+        self.next_run = now + self.interval;
+    }
+
+    /// Whole seconds until the next refresh, rounded up
+    pub fn seconds_left(&self, now: Instant) -> u64 {
+        let left = self.next_run.saturating_duration_since(now);
+        left.as_secs() + u64::from(left.subsec_nanos() > 0)
+    }
+
+    /// Compare a refreshed result with the previous one: cells whose value
+    /// changed and rows that appeared are highlighted afresh, older highlights
+    /// follow their row and fade by one refresh
+    pub fn record_refresh(&mut self, previous: &[Vec<String>], current: &[Vec<String>]) {
+        let matches = match_rows(previous, current);
+        let mut changes = HashMap::new();
+        for (row, cells) in current.iter().enumerate() {
+            for (col, value) in cells.iter().enumerate() {
+                let left = match matches[row] {
+                    Some(prev) if previous[prev].get(col) == Some(value) => self
+                        .changes
+                        .get(&(prev, col))
+                        .map_or(0, |left| left.saturating_sub(1)),
+                    _ => CHANGE_HIGHLIGHT_REFRESHES,
+                };
+                if left > 0 {
+                    changes.insert((row, col), left);
+                }
+            }
+        }
+        self.changes = changes;
+    }
+
+    /// Refreshes left for a cell's change highlight, if it changed recently
+    pub fn change(&self, row: usize, col: usize) -> Option<u8> {
+        self.changes.get(&(row, col)).copied()
+    }
+
+    /// Footer text, e.g. "watching every 5s · refresh in 3s"
+    pub fn footer(&self, now: Instant) -> String {
+        if self.refreshing {
+            return format!("watching every {}s · refreshing", self.interval.as_secs());
+        }
+        format!(
+            "watching every {}s · refresh in {}s",
+            self.interval.as_secs(),
+            self.seconds_left(now)
+        )
+    }
+}
+
+/// Previous row for each current row. Rows are matched by their first value
+/// when it is unique in both results (so an inserted row doesn't shift the
+/// rest), otherwise by position
+fn match_rows(previous: &[Vec<String>], current: &[Vec<String>]) -> Vec<Option<usize>> {
+    fn unique_keys(rows: &[Vec<String>]) -> Option<HashMap<&str, usize>> {
+        let mut keys = HashMap::with_capacity(rows.len());
+        for (idx, row) in rows.iter().enumerate() {
+            if keys.insert(row.first()?.as_str(), idx).is_some() {
+                return None;
+            }
+        }
+        Some(keys)
+    }
+
+    match (unique_keys(previous), unique_keys(current)) {
+        (Some(previous_keys), Some(_)) => current
+            .iter()
+            .map(|row| previous_keys.get(row[0].as_str()).copied())
+            .collect(),
+        _ => (0..current.len())
+            .map(|row| (row < previous.len()).then_some(row))
+            .collect(),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_countdown_and_hold() {
+        let start = Instant::now();
+        let mut watch = QueryWatch::new(Duration::from_secs(5), start);
+
+        assert!(!watch.is_due(start));
+        assert_eq!(
+            watch.footer(start + Duration::from_millis(2500)),
+            "watching every 5s · refresh in 3s"
+        );
+        assert!(watch.is_due(start + Duration::from_secs(5)));
+
+        // Navigating at 4s pushes the refresh back to 9s
+        watch.hold(start + Duration::from_secs(4));
+        assert!(!watch.is_due(start + Duration::from_secs(5)));
+        assert_eq!(watch.seconds_left(start + Duration::from_secs(9)), 0);
+
+        // Not due again while the refresh runs; the countdown restarts after
+        watch.start_refresh();
+        assert!(!watch.is_due(start + Duration::from_secs(20)));
+        assert_eq!(
+            watch.footer(start + Duration::from_secs(20)),
+            "watching every 5s · refreshing"
+        );
+        watch.end_refresh(start + Duration::from_secs(20));
+        assert!(!watch.is_refreshing());
+        assert!(watch.is_due(start + Duration::from_secs(25)));
+        assert_ne!(
+            watch.id(),
+            QueryWatch::new(Duration::from_secs(5), start).id()
+        );
+    }
+
+    fn rows(rows: &[&[&str]]) -> Vec<Vec<String>> {
+        rows.iter()
+            .map(|row| row.iter().map(|cell| cell.to_string()).collect())
+            .collect()
+    }
+
+    #[test]
+    fn test_changes_highlight_and_fade() {
+        let mut watch = QueryWatch::new(Duration::from_secs(5), Instant::now());
+        let first = rows(&[&["active", "10"], &["idle", "4"]]);
+        let second = rows(&[&["active", "12"], &["idle", "4"]]);
+        watch.record_refresh(&first, &second);
+        assert_eq!(watch.change(0, 1), Some(CHANGE_HIGHLIGHT_REFRESHES));
+        assert_eq!(watch.change(0, 0), None);
+        assert_eq!(watch.change(1, 1), None);
+
+        // A new row in front: matched by the first column, the rest keep their
+        // rows and the change fades
+        let third = rows(&[&["aborted", "1"], &["active", "12"], &["idle", "4"]]);
+        watch.record_refresh(&second, &third);
+        assert_eq!(watch.change(0, 0), Some(CHANGE_HIGHLIGHT_REFRESHES));
+        assert_eq!(watch.change(0, 1), Some(CHANGE_HIGHLIGHT_REFRESHES));
+        assert_eq!(watch.change(1, 1), Some(CHANGE_HIGHLIGHT_REFRESHES - 1));
+        assert_eq!(watch.change(2, 1), None);
+
+        for _ in 0..CHANGE_HIGHLIGHT_REFRESHES {
+            watch.record_refresh(&third, &third);
+        }
+        assert_eq!(watch.change(0, 0), None);
+        assert_eq!(watch.change(1, 1), None);
+    }
+
+    #[test]
+    fn test_duplicate_keys_match_by_position() {
+        let previous = rows(&[&["a", "1"], &["a", "2"]]);
+        let current = rows(&[&["a", "1"], &["a", "3"], &["b", "4"]]);
+        assert_eq!(
+            match_rows(&previous, &current),
+            vec![Some(0), Some(1), None]
+        );
+    }
+}
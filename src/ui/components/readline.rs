@@ -0,0 +1,146 @@
+// FilePath: src/ui/components/readline.rs
+
+//! Emacs-style editing keys shared by the text inputs: Ctrl+A and Ctrl+E go
+//! to the start and end of the line, Alt+B and Alt+F back and forward a word,
+//! Ctrl+W deletes the word before the cursor and Ctrl+U the line before it
+
+#![forbid(unsafe_code)]
+
+use crossterm::event::{KeyCode, KeyEvent, KeyModifiers};
+
+/// An edit made with a readline key. Words are runs of non-whitespace, as
+/// for the shell's Ctrl+W
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum Edit {
+    LineStart,
+    LineEnd,
+    WordBack,
+    WordForward,
+    DeleteWordBack,
+    KillLine,
+}
+
+impl Edit {
+    /// The edit a key stands for. Alt+Backspace deletes a word as well
+    pub fn from_key(key: &KeyEvent) -> Option<Self> {
+        match (key.modifiers, key.code) {
+            (KeyModifiers::CONTROL, KeyCode::Char('a')) => Some(Self::LineStart),
+            (KeyModifiers::CONTROL, KeyCode::Char('e')) => Some(Self::LineEnd),
+            (KeyModifiers::ALT, KeyCode::Char('b')) => Some(Self::WordBack),
+            (KeyModifiers::ALT, KeyCode::Char('f')) => Some(Self::WordForward),
+            (KeyModifiers::CONTROL, KeyCode::Char('w'))
+            | (KeyModifiers::ALT, KeyCode::Backspace) => Some(Self::DeleteWordBack),
+            (KeyModifiers::CONTROL, KeyCode::Char('u')) => Some(Self::KillLine),
+            _ => None,
+        }
+    }
+
+    /// Apply the edit to a line with the cursor at byte offset `cursor`
+    pub fn apply(self, line: &mut String, cursor: &mut usize) {
+        let mut at = (*cursor).min(line.len());
+        while !line.is_char_boundary(at) {
+            at -= 1;
+        }
+        match self {
+            Self::LineStart => *cursor = 0,
+            Self::LineEnd => *cursor = line.len(),
+            Self::WordBack => *cursor = word_start(line, at),
+            Self::WordForward => *cursor = word_end(line, at),
+            Self::DeleteWordBack => {
+                let start = word_start(line, at);
+                line.replace_range(start..at, "");
+                *cursor = start;
+            }
+            Self::KillLine => {
+                line.replace_range(..at, "");
+                *cursor = 0;
+            }
+        }
+    }
+
+    /// Characters the edit erases from text typed only at its end, for the
+    /// inputs without a cursor. Moving erases none
+    pub fn erased(self, text: &str) -> usize {
+        match self {
+            Self::DeleteWordBack => text[word_start(text, text.len())..].chars().count(),
+            Self::KillLine => text.chars().count(),
+            _ => 0,
+        }
+    }
+}
+
+/// Start of the word before `at`, skipping whitespace just before it
+fn word_start(line: &str, at: usize) -> usize {
+    let before = line[..at].trim_end();
+    before
+        .char_indices()
+        .rev()
+        .find(|(_, c)| c.is_whitespace())
+        .map_or(0, |(idx, c)| idx + c.len_utf8())
+}
+
+/// End of the word after `at`, skipping whitespace just after it
+fn word_end(line: &str, at: usize) -> usize {
+    let after = &line[at..];
+    let skipped = after.len() - after.trim_start().len();
+    let rest = &after[skipped..];
+    at + skipped + rest.find(char::is_whitespace).unwrap_or(rest.len())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn apply(edit: Edit, line: &str, cursor: usize) -> (String, usize) {
+        let mut line = line.to_string();
+        let mut cursor = cursor;
+        edit.apply(&mut line, &mut cursor);
+        (line, cursor)
+    }
+
+    #[test]
+    fn test_from_key() {
+        let key = |modifiers, c| KeyEvent::new(KeyCode::Char(c), modifiers);
+        assert_eq!(
+            Edit::from_key(&key(KeyModifiers::CONTROL, 'w')),
+            Some(Edit::DeleteWordBack)
+        );
+        assert_eq!(
+            Edit::from_key(&key(KeyModifiers::ALT, 'f')),
+            Some(Edit::WordForward)
+        );
+        assert_eq!(Edit::from_key(&key(KeyModifiers::NONE, 'a')), None);
+    }
+
+    #[test]
+    fn test_motions() {
+        let line = "select id  from users";
+        assert_eq!(apply(Edit::LineStart, line, 9), (line.to_string(), 0));
+        assert_eq!(apply(Edit::LineEnd, line, 0).1, line.len());
+        assert_eq!(apply(Edit::WordBack, line, 11).1, 7);
+        assert_eq!(apply(Edit::WordBack, line, 7).1, 0);
+        assert_eq!(apply(Edit::WordForward, line, 9).1, 15);
+        assert_eq!(apply(Edit::WordForward, line, 15).1, line.len());
+    }
+
+    #[test]
+    fn test_deletions() {
+        assert_eq!(
+            apply(Edit::DeleteWordBack, "select id  from", 11),
+            ("select from".to_string(), 7)
+        );
+        assert_eq!(apply(Edit::KillLine, "select id", 7), ("id".to_string(), 0));
+        // Cursors inside a character are taken back to its start
+        assert_eq!(
+            apply(Edit::DeleteWordBack, "naïve", 3),
+            ("ïve".to_string(), 0)
+        );
+    }
+
+    #[test]
+    fn test_erased() {
+        assert_eq!(Edit::DeleteWordBack.erased("users café  "), 6);
+        assert_eq!(Edit::KillLine.erased("café"), 4);
+        assert_eq!(Edit::LineStart.erased("café"), 0);
+    }
+}
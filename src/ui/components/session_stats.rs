@@ -0,0 +1,111 @@
+// FilePath: src/ui/components/session_stats.rs
+
+//! Overlay with the session's counters per connection: queries run and
+//! failed, rows fetched, bytes exported and time connected
+
+#![forbid(unsafe_code)]
+
+use crate::database::session_stats::{format_bytes, SessionStats};
+use crate::ui::theme::Theme;
+use ratatui::{
+    layout::{Alignment, Rect},
+    style::{Modifier, Style},
+    text::{Line, Span},
+    widgets::{Block, Borders, Clear, Paragraph},
+    Frame,
+};
+use std::time::Duration;
+
+/// Time connected as `12s`, `3m 05s` or `2h 07m`
+fn format_connected(connected: Duration) -> String {
+    let secs = connected.as_secs();
+    if secs < 60 {
+        format!("{secs}s")
+    } else if secs < 3600 {
+        format!("{}m {:02}s", secs / 60, secs % 60)
+    } else {
+        format!("{}h {:02}m", secs / 3600, secs % 3600 / 60)
+    }
+}
+
+/// Render the counters as a centered popup, one line per connection used
+pub fn render_session_stats(f: &mut Frame, stats: &SessionStats, area: Rect, theme: &Theme) {
+    let text = Style::default().fg(theme.get_color("text_primary"));
+    let muted = Style::default().fg(theme.get_color("text_muted"));
+    let active = Style::default()
+        .fg(theme.get_color("accent"))
+        .add_modifier(Modifier::BOLD);
+
+    let connections = stats.snapshot();
+    let mut lines = vec![Line::from(Span::styled(
+        format!(
+            "  {:<20}{:>10}{:>8}{:>8}{:>12}{:>10}",
+            "Connection", "Connected", "Queries", "Failed", "Rows", "Exported"
+        ),
+        muted,
+    ))];
+    lines.extend(connections.iter().map(|connection| {
+        let name: String = connection.name.chars().take(19).collect();
+        Line::from(vec![
+            Span::styled(
+                if connection.is_connected() {
+                    "● "
+                } else {
+                    "  "
+                },
+                active,
+            ),
+            Span::styled(
+                format!(
+                    "{name:<20}{:>10}{:>8}{:>8}{:>12}{:>10}",
+                    format_connected(connection.connected_for()),
+                    connection.queries,
+                    connection.errors,
+                    connection.rows_fetched,
+                    format_bytes(connection.bytes_exported)
+                ),
+                text,
+            ),
+        ])
+    }));
+    if connections.is_empty() {
+        lines.push(Line::from(Span::styled(
+            "  No connection opened yet",
+            muted,
+        )));
+    }
+    lines.push(Line::from(""));
+    lines.push(Line::from(Span::styled(
+        "● connected · queries include the panels' own · Esc close",
+        muted,
+    )));
+
+    let width = 74u16.min(area.width.saturating_sub(4));
+    let height = (lines.len() as u16 + 2).min(area.height);
+    let popup = Rect {
+        x: area.x + area.width.saturating_sub(width) / 2,
+        y: area.y + area.height.saturating_sub(height) / 2,
+        width,
+        height,
+    };
+    f.render_widget(Clear, popup);
+    let block = Block::default()
+        .borders(Borders::ALL)
+        .title(" Session statistics ")
+        .title_alignment(Alignment::Center)
+        .border_style(active)
+        .style(Style::default().bg(theme.get_color("background")));
+    f.render_widget(Paragraph::new(lines).block(block), popup);
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_format_connected() {
+        assert_eq!(format_connected(Duration::from_secs(12)), "12s");
+        assert_eq!(format_connected(Duration::from_secs(185)), "3m 05s");
+        assert_eq!(format_connected(Duration::from_secs(7_620)), "2h 07m");
+    }
+}
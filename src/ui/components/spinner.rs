@@ -0,0 +1,222 @@
+// FilePath: src/ui/components/spinner.rs
+
+#![forbid(unsafe_code)]
+
+use std::collections::HashMap;
+use std::time::{Duration, Instant};
+
+/// Braille frames, advanced once per tick
+const FRAMES: [&str; 10] = ["⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"];
+
+/// Shown instead of the frames by a still spinner
+const STILL_FRAME: &str = "…";
+
+/// Labels of the operations that drive the spinner
+pub mod operation {
+    pub const CONNECTING: &str = "connecting";
+    pub const TESTING_CONNECTION: &str = "testing connection";
+    pub const RUNNING_QUERY: &str = "running query";
+    pub const REFRESHING_QUERY: &str = "refreshing query";
+    pub const EXPORTING_ROWS: &str = "exporting rows";
+    pub const PINGING_CONNECTIONS: &str = "pinging connections";
+
+    /// Whether the operation can be stopped from the tasks overlay
+    pub fn can_cancel(label: &str) -> bool {
+        matches!(
+            label,
+            CONNECTING | TESTING_CONNECTION | RUNNING_QUERY | EXPORTING_ROWS | PINGING_CONNECTIONS
+        )
+    }
+}
+
+/// Busy indicator shared by every async operation. Operations are started and
+/// stopped by label; while any is running the status bar shows it, or how
+/// many are running ("⠋ 2 tasks"), the tasks overlay lists them with their
+/// elapsed time and progress, and panels can show the current frame in their
+/// title
+#[derive(Debug, Clone, Default)]
+pub struct Spinner {
+    frame: usize,
+    /// Running operations in start order, with when they started; a label
+    /// appears once per start
+    operations: Vec<(String, Instant)>,
+    /// How far operations that report it have got, by label
+    progress: HashMap<String, String>,
+    /// Show a fixed frame instead of animating ([ui] accessibility_mode)
+    still: bool,
+}
+
+impl Spinner {
+    pub fn new() -> Self {
+        Self::default()
+    }
+
+    /// Record that an operation has started
+    pub fn start(&mut self, label: impl Into<String>) {
+        if self.operations.is_empty() {
+            self.frame = 0;
+        }
+        self.operations.push((label.into(), Instant::now()));
+    }
+
+    /// Record that one run of an operation has finished. Stopping an operation
+    /// that isn't running is a no-op, so every exit path can call this
+    pub fn stop(&mut self, label: &str) {
+        if let Some(index) = self.operations.iter().position(|(op, _)| op == label) {
+            self.operations.remove(index);
+        }
+        if !self.is_running(label) {
+            self.progress.remove(label);
+        }
+    }
+
+    /// Record how far a running operation has got, as in `1,500/~12,000`
+    pub fn set_progress(&mut self, label: &str, progress: impl Into<String>) {
+        if self.is_running(label) {
+            self.progress.insert(label.to_string(), progress.into());
+        }
+    }
+
+    /// The operation with its progress, if it reports any
+    pub fn describe(&self, label: &str) -> String {
+        match self.progress.get(label) {
+            Some(progress) => format!("{label} {progress}"),
+            None => label.to_string(),
+        }
+    }
+
+    /// Stop animating, so running operations don't redraw the screen on
+    /// every tick
+    pub fn set_still(&mut self, still: bool) {
+        self.still = still;
+    }
+
+    /// Whether running operations change what's drawn on every tick
+    pub fn is_animating(&self) -> bool {
+        self.is_active() && !self.still
+    }
+
+    /// Advance the animation; called from the app tick
+    pub fn tick(&mut self) {
+        if self.is_animating() {
+            self.frame = (self.frame + 1) % FRAMES.len();
+        }
+    }
+
+    /// Whether any operation is running
+    pub fn is_active(&self) -> bool {
+        !self.operations.is_empty()
+    }
+
+    /// Whether the given operation is running
+    pub fn is_running(&self, label: &str) -> bool {
+        self.operations.iter().any(|(op, _)| op == label)
+    }
+
+    /// Running operations in start order, with how long each has run
+    pub fn tasks(&self) -> Vec<(&str, Duration)> {
+        self.operations
+            .iter()
+            .map(|(op, started)| (op.as_str(), started.elapsed()))
+            .collect()
+    }
+
+    /// Current animation frame
+    pub fn frame(&self) -> &'static str {
+        if self.still {
+            STILL_FRAME
+        } else {
+            FRAMES[self.frame]
+        }
+    }
+
+    /// Text for the status bar: the running operation, or how many are
+    /// running
+    pub fn status_text(&self) -> Option<String> {
+        match self.operations.as_slice() {
+            [] => None,
+            [(op, _)] => Some(format!("{} {}", self.frame(), self.describe(op))),
+            operations => Some(format!("{} {} tasks", self.frame(), operations.len())),
+        }
+    }
+
+    /// Suffix for a panel title, " ⠋" while the given operation is running
+    pub fn title_suffix(&self, label: &str) -> String {
+        if self.is_running(label) {
+            format!(" {}", self.frame())
+        } else {
+            String::new()
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_concurrent_operations_in_status_text() {
+        let mut spinner = Spinner::new();
+        assert_eq!(spinner.status_text(), None);
+
+        spinner.start(operation::RUNNING_QUERY);
+        spinner.start("fetching tables");
+        assert_eq!(spinner.status_text().as_deref(), Some("⠋ 2 tasks"));
+        let labels: Vec<&str> = spinner.tasks().iter().map(|(op, _)| *op).collect();
+        assert_eq!(labels, [operation::RUNNING_QUERY, "fetching tables"]);
+
+        spinner.stop(operation::RUNNING_QUERY);
+        spinner.tick();
+        assert_eq!(spinner.status_text().as_deref(), Some("⠙ fetching tables"));
+    }
+
+    #[test]
+    fn test_still_spinner_does_not_animate() {
+        let mut spinner = Spinner::new();
+        spinner.set_still(true);
+        spinner.start(operation::RUNNING_QUERY);
+        assert!(!spinner.is_animating());
+
+        spinner.tick();
+        assert_eq!(spinner.status_text().as_deref(), Some("… running query"));
+    }
+
+    #[test]
+    fn test_progress_lasts_while_running() {
+        let mut spinner = Spinner::new();
+        spinner.set_progress(operation::EXPORTING_ROWS, "500");
+        assert_eq!(
+            spinner.describe(operation::EXPORTING_ROWS),
+            "exporting rows"
+        );
+
+        spinner.start(operation::EXPORTING_ROWS);
+        spinner.set_progress(operation::EXPORTING_ROWS, "1,500/~12,000");
+        assert_eq!(
+            spinner.status_text().as_deref(),
+            Some("⠋ exporting rows 1,500/~12,000")
+        );
+
+        spinner.stop(operation::EXPORTING_ROWS);
+        spinner.start(operation::EXPORTING_ROWS);
+        assert_eq!(
+            spinner.describe(operation::EXPORTING_ROWS),
+            "exporting rows"
+        );
+    }
+
+    #[test]
+    fn test_repeated_starts_need_matching_stops() {
+        let mut spinner = Spinner::new();
+        spinner.start(operation::CONNECTING);
+        spinner.start(operation::CONNECTING);
+        assert_eq!(spinner.status_text().as_deref(), Some("⠋ 2 tasks"));
+
+        spinner.stop(operation::CONNECTING);
+        assert!(spinner.is_running(operation::CONNECTING));
+        spinner.stop(operation::CONNECTING);
+        spinner.stop(operation::CONNECTING);
+        assert!(!spinner.is_active());
+        assert_eq!(spinner.title_suffix(operation::CONNECTING), "");
+    }
+}
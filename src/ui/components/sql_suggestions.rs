@@ -183,6 +183,11 @@ impl SqlSuggestionEngine {
         self.table_columns.insert(table, columns);
     }
 
+    /// Forget every table's columns, e.g. after switching databases
+    pub fn clear_table_columns(&mut self) {
+        self.table_columns.clear();
+    }
+
     /// Get suggestions based on current SQL content and cursor position
     pub fn get_suggestions(
         &self,
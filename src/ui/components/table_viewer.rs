@@ -2,6 +2,10 @@
 
 #![forbid(unsafe_code)]
 
+use crate::database::preview::is_partial;
+use crate::database::{CellValue, NULL_CELL};
+use crate::io::clipboard::{Clipboard, ClipboardBackend};
+use crate::io::export::ExportFormat;
 use crate::ui::theme::Theme;
 use ratatui::{
     layout::{Alignment, Constraint, Direction, Layout, Rect},
@@ -10,7 +14,7 @@ use ratatui::{
     widgets::{Block, Borders, Cell as TableCell, Clear, Paragraph, Row, Table, Tabs, Wrap},
     Frame,
 };
-use std::collections::HashMap;
+use std::collections::{BTreeSet, HashMap, HashSet};
 
 /// View mode for the table viewer
 #[derive(Debug, Clone, Copy, PartialEq, Eq)]
@@ -26,8 +30,21 @@ pub struct TableTab {
     pub columns: Vec<ColumnInfo>,
     pub rows: Vec<Vec<String>>,
     pub total_rows: usize,
+    /// Whether `total_rows` is the planner's estimate rather than a count
+    pub total_rows_estimated: bool,
     pub current_page: usize,
     pub rows_per_page: usize,
+    /// The next load of a table preview reads another page of the same
+    /// rows, so the row count of the last load still holds
+    pub reuse_row_count: bool,
+    /// How the page shown was read, for a table preview
+    pub paging: Option<crate::database::Paging>,
+    /// Key value the next load of a preview paged by key starts from
+    pub page_bound: crate::database::KeyBound,
+    /// Quick filters narrowing a table preview, ANDed together
+    pub filters: Vec<crate::database::ColumnFilter>,
+    /// Column a table preview is sorted by on the server
+    pub sort: Option<crate::database::PreviewSort>,
     pub selected_row: usize,
     pub selected_col: usize,
     pub scroll_offset_x: usize,
@@ -42,8 +59,63 @@ pub struct TableTab {
     pub search_results: Vec<(usize, usize)>,
     pub current_search_result: usize,
     pub in_search_mode: bool,
+    /// Column name being typed to jump to (`c`)
+    pub column_jump: Option<super::ColumnJump>,
     pub view_mode: TableViewMode,
     pub table_metadata: Option<crate::database::TableMetadata>,
+    /// Row cap that cut the result short, if any
+    pub truncated_at: Option<usize>,
+    /// Full values of cells shortened for display that have been read,
+    /// keyed by (row, column)
+    pub full_cell_values: HashMap<(usize, usize), String>,
+    /// Cells of the shown query result set shortened by the cell size
+    /// limit, as (row, column)
+    pub cut_cells: HashSet<(usize, usize)>,
+    /// Whether fetching was stopped early, keeping the rows loaded until then
+    pub fetch_stopped: bool,
+    /// SQL behind a query result tab
+    pub query: Option<String>,
+    /// Connection the query behind a query result tab ran on
+    pub query_connection: Option<String>,
+    /// Whether the tab lists column search matches, whose rows open tables
+    pub column_search: bool,
+    /// Table whose columns the tab lists, one row each (`i` in the Tables
+    /// pane)
+    pub structure_of: Option<String>,
+    /// Document view of a result that is a single JSON cell
+    pub json_view: Option<super::JsonView>,
+    /// Plan tree of a result that is a single JSON EXPLAIN cell
+    pub plan_view: Option<super::PlanView>,
+    /// Show the grid instead of the JSON document view
+    pub show_raw_grid: bool,
+    /// Auto-refresh of the query, when watching
+    pub watch: Option<super::QueryWatch>,
+    /// Partitions of a partitioned table, for the structure view
+    pub partitions: Vec<crate::database::PartitionInfo>,
+    /// Partition picked for previewing in the structure view
+    pub selected_partition: Option<usize>,
+    /// Scroll the structure view to the selected partition on the next draw
+    reveal_partition: bool,
+    /// Rows whose cell in `marked_col` is marked for an IN clause
+    pub marked_rows: BTreeSet<usize>,
+    pub marked_col: usize,
+    /// Row where a visual range mark started, while extending one
+    pub mark_anchor: Option<usize>,
+    /// Every result set of a query that returned more than one
+    pub result_sets: Vec<crate::database::QueryResult>,
+    /// Result set shown from `result_sets`
+    pub result_set: usize,
+    /// Notices and warnings the server sent with the query result
+    pub notices: Vec<crate::database::ServerNotice>,
+    /// Time the query behind a result tab took on the server
+    pub elapsed: Option<std::time::Duration>,
+    /// Rows the statements of the result set shown changed
+    pub affected_rows: Option<u64>,
+    /// Position of the statement behind the result set shown in a script,
+    /// 0 for the script's summary, and the script's number of statements
+    pub statement: Option<(usize, usize)>,
+    /// Name of the pin the tab shows; its rows are a snapshot
+    pub pin: Option<String>,
 }
 
 #[derive(Debug, Clone)]
@@ -53,6 +125,16 @@ pub struct ColumnInfo {
     pub is_nullable: bool,
     pub is_primary_key: bool,
     pub max_display_width: usize,
+    /// Allowed values when the column is an enum (or an array of one)
+    pub enum_labels: Vec<String>,
+    /// Numbered by the database (identity, serial, auto_increment)
+    pub is_auto_increment: bool,
+    /// Computed by the database; inserts can't set it
+    pub generated: Option<crate::database::GeneratedColumn>,
+    /// Default expression of a table column
+    pub default_value: Option<String>,
+    /// Comment on a table column
+    pub comment: Option<String>,
 }
 
 impl TableTab {
@@ -62,8 +144,14 @@ impl TableTab {
             columns: Vec::new(),
             rows: Vec::new(),
             total_rows: 0,
+            total_rows_estimated: false,
             current_page: 0,
             rows_per_page: 20,
+            reuse_row_count: false,
+            paging: None,
+            page_bound: crate::database::KeyBound::First,
+            filters: Vec::new(),
+            sort: None,
             selected_row: 0,
             selected_col: 0,
             scroll_offset_x: 0,
@@ -78,11 +166,263 @@ impl TableTab {
             search_results: Vec::new(),
             current_search_result: 0,
             in_search_mode: false,
+            column_jump: None,
             view_mode: TableViewMode::Data,
             table_metadata: None,
+            truncated_at: None,
+            full_cell_values: HashMap::new(),
+            cut_cells: HashSet::new(),
+            fetch_stopped: false,
+            query: None,
+            query_connection: None,
+            column_search: false,
+            structure_of: None,
+            json_view: None,
+            plan_view: None,
+            show_raw_grid: false,
+            watch: None,
+            partitions: Vec::new(),
+            selected_partition: None,
+            reveal_partition: false,
+            marked_rows: BTreeSet::new(),
+            marked_col: 0,
+            mark_anchor: None,
+            result_sets: Vec::new(),
+            result_set: 0,
+            notices: Vec::new(),
+            elapsed: None,
+            affected_rows: None,
+            statement: None,
+            pin: None,
         }
     }
 
+    /// Whether the tab previews a table, paged from the server, rather than
+    /// showing a query result
+    pub fn is_table_preview(&self) -> bool {
+        self.query.is_none()
+            && !self.column_search
+            && self.structure_of.is_none()
+            && self.pin.is_none()
+    }
+
+    /// Record the table's size after loading the page at row `offset`. An
+    /// estimate is corrected by what the page shows: a short page is the last
+    pub fn set_total_rows(&mut self, total_rows: usize, estimated: bool, offset: usize) {
+        let seen = offset + self.rows.len();
+        // Read backwards, a short page is the first one instead
+        let last_page = self.rows.len() < self.rows_per_page
+            && !self
+                .paging
+                .as_ref()
+                .is_some_and(|paging| paging.reads_backwards());
+        if estimated && last_page {
+            self.total_rows = seen;
+            self.total_rows_estimated = false;
+        } else {
+            self.total_rows = total_rows.max(seen);
+            self.total_rows_estimated = estimated;
+        }
+    }
+
+    /// `Page 3/~5,001`, and the table's row count, prefixed with `~` when
+    /// it's an estimate
+    pub fn page_position(&self) -> (String, String) {
+        let approx = if self.total_rows_estimated { "~" } else { "" };
+        let pages = self.total_rows.saturating_sub(1) / self.rows_per_page.max(1) + 1;
+        (
+            format!(
+                "Page {}/{approx}{}",
+                self.current_page + 1,
+                group_thousands(pages)
+            ),
+            format!("{approx}{}", group_thousands(self.total_rows)),
+        )
+    }
+
+    /// Narrow the preview with another filter, back on its first page
+    pub fn add_filter(&mut self, filter: crate::database::ColumnFilter) {
+        self.filters.push(filter);
+        self.rewind();
+    }
+
+    /// Drop the preview's filters, back on its first page. Returns whether
+    /// there were any
+    pub fn clear_filters(&mut self) -> bool {
+        if self.filters.is_empty() {
+            return false;
+        }
+        self.filters.clear();
+        self.rewind();
+        true
+    }
+
+    /// Sort the preview by the selected column, ascending first and then
+    /// flipping between ascending and descending, back on its first page.
+    /// Returns the sort, or None when there's no column to sort by
+    pub fn toggle_sort(&mut self) -> Option<&crate::database::PreviewSort> {
+        let column = self.columns.get(self.selected_col)?.name.clone();
+        let descending = self
+            .sort
+            .as_ref()
+            .is_some_and(|sort| sort.column == column && !sort.descending);
+        self.sort = Some(crate::database::PreviewSort { column, descending });
+        self.rewind();
+        self.sort.as_ref()
+    }
+
+    /// Go back to the first page, for a reload of different rows
+    fn rewind(&mut self) {
+        self.reuse_row_count = false;
+        self.current_page = 0;
+        self.page_bound = crate::database::KeyBound::First;
+        self.selected_row = 0;
+        self.scroll_offset_y = 0;
+    }
+
+    /// Key value of `row` when the preview pages by key
+    fn page_key(&self, row: usize) -> Option<String> {
+        let Some(crate::database::Paging::Keyset { key, .. }) = &self.paging else {
+            return None;
+        };
+        let col = self.columns.iter().position(|col| &col.name == key)?;
+        self.rows.get(row)?.get(col).cloned()
+    }
+
+    /// Whether the tab shows its JSON document view rather than the grid
+    pub fn shows_json(&self) -> bool {
+        self.json_view.is_some() && !self.show_raw_grid && self.view_mode == TableViewMode::Data
+    }
+
+    /// Whether the tab shows its query plan tree rather than the grid
+    pub fn shows_plan(&self) -> bool {
+        self.plan_view.is_some() && !self.show_raw_grid && self.view_mode == TableViewMode::Data
+    }
+
+    /// Show a query result, keeping the selection where it was as far as the
+    /// new rows allow (so a refresh updates the tab in place). A result with
+    /// several result sets keeps them all and shows the one shown before
+    pub fn set_query_result(&mut self, mut result: crate::database::QueryResult) {
+        self.notices = std::mem::take(&mut result.notices);
+        self.elapsed = result.elapsed;
+        let more_results = std::mem::take(&mut result.more_results);
+        if more_results.is_empty() {
+            self.result_sets.clear();
+            self.result_set = 0;
+            self.show_result_set(result);
+            return;
+        }
+        self.result_sets = std::iter::once(result).chain(more_results).collect();
+        self.result_set = self.result_set.min(self.result_sets.len() - 1);
+        self.show_result_set(self.result_sets[self.result_set].clone());
+    }
+
+    /// Switch to the next (or previous) result set of a multi-set result.
+    /// Returns false when there is none in that direction
+    pub fn cycle_result_set(&mut self, forward: bool) -> bool {
+        let target = if forward {
+            self.result_set + 1
+        } else {
+            match self.result_set.checked_sub(1) {
+                Some(target) => target,
+                None => return false,
+            }
+        };
+        let Some(result) = self.result_sets.get(target).cloned() else {
+            return false;
+        };
+        self.result_set = target;
+        self.selected_row = 0;
+        self.selected_col = 0;
+        self.search_results.clear();
+        self.current_search_result = 0;
+        self.show_result_set(result);
+        true
+    }
+
+    fn show_result_set(&mut self, result: crate::database::QueryResult) {
+        self.columns = result
+            .columns
+            .iter()
+            .map(|col_name| {
+                ColumnInfo {
+                    name: col_name.clone(),
+                    data_type: "TEXT".to_string(), // Default type
+                    is_nullable: true,
+                    is_primary_key: false,
+                    max_display_width: col_name.len().clamp(10, 30),
+                    enum_labels: Vec::new(),
+                    is_auto_increment: false,
+                    generated: None,
+                    default_value: None,
+                    comment: None,
+                }
+            })
+            .collect();
+
+        self.rows = result.rows;
+        self.total_rows = self.rows.len();
+        self.truncated_at = result.truncated.then_some(self.total_rows);
+        self.cut_cells = result.cut_cells;
+        self.full_cell_values = result.full_values;
+        self.fetch_stopped = result.stopped;
+        self.affected_rows = result.affected_rows;
+        self.statement = result.statement;
+        // Each statement of a script is timed on its own
+        if result.elapsed.is_some() {
+            self.elapsed = result.elapsed;
+        }
+        self.loading = false;
+        self.error = None;
+        self.clear_marks();
+
+        // One cell holding a JSON object or array reads better as a document,
+        // or as a tree when it's a query plan. It's parsed whole, even when
+        // too long to show in the grid
+        let cell = match self.rows.as_slice() {
+            [row] if self.columns.len() == 1 => self.full_cell_values.get(&(0, 0)).or(row.first()),
+            _ => None,
+        };
+        self.plan_view = cell.and_then(|cell| super::PlanView::parse(cell));
+        self.json_view = cell
+            .filter(|_| self.plan_view.is_none())
+            .and_then(|cell| super::JsonView::parse(cell));
+
+        self.selected_row = self.selected_row.min(self.total_rows.saturating_sub(1));
+        self.selected_col = self.selected_col.min(self.columns.len().saturating_sub(1));
+        self.scroll_offset_y = self.scroll_offset_y.min(self.selected_row);
+        self.scroll_offset_x = self.scroll_offset_x.min(self.selected_col);
+    }
+
+    /// What the query behind the tab did and how long it took: "42 rows
+    /// returned in 128ms", or "3 rows affected in 12ms" for a statement
+    /// returning none, after "statement 3/5: " in a script's results. None
+    /// for tabs not showing a query result
+    pub fn run_summary(&self) -> Option<String> {
+        let elapsed = format_query_time(self.elapsed?);
+        if let Some((0, statements)) = self.statement {
+            return Some(format!("{statements} statements in {elapsed}"));
+        }
+        let statement = self
+            .statement
+            .map(|(position, statements)| format!("statement {position}/{statements}: "))
+            .unwrap_or_default();
+        let rows = |count: u64, done: &str| {
+            let noun = if count == 1 { "row" } else { "rows" };
+            format!(
+                "{} {noun} {done} in {elapsed}",
+                group_thousands(count as usize)
+            )
+        };
+        let outcome = match self.affected_rows {
+            Some(affected) if self.columns.is_empty() => rows(affected, "affected"),
+            // The count of a cut-short result is in the truncation notice
+            _ if self.fetch_stopped || self.truncated_at.is_some() => format!("read in {elapsed}"),
+            _ => rows(self.total_rows as u64, "returned"),
+        };
+        Some(statement + &outcome)
+    }
+
     /// Toggle between data and schema view
     pub fn toggle_view_mode(&mut self) {
         self.view_mode = match self.view_mode {
@@ -94,6 +434,91 @@ impl TableTab {
         self.selected_col = 0;
     }
 
+    /// Start marking in the selected column, dropping marks made in another
+    fn mark_in_selected_column(&mut self) {
+        if self.marked_col != self.selected_col {
+            self.clear_marks();
+            self.marked_col = self.selected_col;
+        }
+    }
+
+    /// Add the rows of a visual range in progress to the marks
+    fn commit_mark_range(&mut self) {
+        if let Some(anchor) = self.mark_anchor.take() {
+            let (start, end) = if anchor <= self.selected_row {
+                (anchor, self.selected_row)
+            } else {
+                (self.selected_row, anchor)
+            };
+            self.marked_rows.extend(start..=end);
+        }
+    }
+
+    /// Mark or unmark the selected cell, or finish a visual range
+    pub fn toggle_mark(&mut self) {
+        if self.rows.is_empty() {
+            return;
+        }
+        if self.mark_anchor.is_some() {
+            self.commit_mark_range();
+            return;
+        }
+        self.mark_in_selected_column();
+        if !self.marked_rows.remove(&self.selected_row) {
+            self.marked_rows.insert(self.selected_row);
+        }
+    }
+
+    /// Start a visual range at the selected cell, or finish the current one
+    pub fn toggle_mark_range(&mut self) {
+        if self.rows.is_empty() {
+            return;
+        }
+        if self.mark_anchor.is_some() {
+            self.commit_mark_range();
+        } else {
+            self.mark_in_selected_column();
+            self.mark_anchor = Some(self.selected_row);
+        }
+    }
+
+    pub fn clear_marks(&mut self) {
+        self.marked_rows.clear();
+        self.mark_anchor = None;
+    }
+
+    pub fn has_marks(&self) -> bool {
+        !self.marked_rows.is_empty() || self.mark_anchor.is_some()
+    }
+
+    /// Whether a cell is marked, counting a visual range in progress
+    pub fn is_marked(&self, row: usize, col: usize) -> bool {
+        if col != self.marked_col {
+            return false;
+        }
+        self.marked_rows.contains(&row)
+            || self.mark_anchor.is_some_and(|anchor| {
+                (anchor.min(self.selected_row)..=anchor.max(self.selected_row)).contains(&row)
+            })
+    }
+
+    /// Column and full values of the marked cells in row order, or of the
+    /// selected cell when nothing is marked
+    pub fn marked_values(&self) -> Option<(&ColumnInfo, Vec<String>)> {
+        if !self.has_marks() {
+            let column = self.columns.get(self.selected_col)?;
+            self.rows.get(self.selected_row)?;
+            let value = self.full_cell_value(self.selected_row, self.selected_col);
+            return Some((column, vec![value]));
+        }
+        let column = self.columns.get(self.marked_col)?;
+        let values = (0..self.rows.len())
+            .filter(|&row| self.is_marked(row, self.marked_col))
+            .map(|row| self.full_cell_value(row, self.marked_col))
+            .collect();
+        Some((column, values))
+    }
+
     /// Get the current cell value (including any modifications)
     pub fn get_cell_value(&self, row: usize, col: usize) -> String {
         if let Some(modified) = self.modified_cells.get(&(row, col)) {
@@ -105,11 +530,134 @@ impl TableTab {
         }
     }
 
+    /// Get the untruncated value of a cell: the same as `get_cell_value`
+    /// except for cells shortened by the result size limit
+    pub fn full_cell_value(&self, row: usize, col: usize) -> String {
+        if self.modified_cells.contains_key(&(row, col)) {
+            return self.get_cell_value(row, col);
+        }
+        self.full_cell_values
+            .get(&(row, col))
+            .cloned()
+            .unwrap_or_else(|| self.get_cell_value(row, col))
+    }
+
+    /// The cell at `row` and `col` with its column, in full where it was read
+    pub fn cell(&self, row: usize, col: usize) -> Option<CellValue> {
+        self.rows.get(row)?;
+        let column = self.columns.get(col)?;
+        Some(CellValue::from_grid(
+            column.name.clone(),
+            column.data_type.clone(),
+            self.full_cell_value(row, col),
+        ))
+    }
+
+    /// The primary key columns of `row`. Key columns can't be NULL, so a
+    /// `NULL` in one is the text
+    pub fn key_cells(&self, row: usize) -> Vec<CellValue> {
+        self.primary_key_columns
+            .iter()
+            .filter_map(|&col| {
+                self.rows.get(row)?;
+                let column = self.columns.get(col)?;
+                Some(CellValue {
+                    column: column.name.clone(),
+                    data_type: column.data_type.clone(),
+                    value: Some(self.full_cell_value(row, col)),
+                })
+            })
+            .collect()
+    }
+
+    /// Every column of `row`
+    pub fn row_cells(&self, row: usize) -> Vec<CellValue> {
+        (0..self.columns.len())
+            .filter_map(|col| self.cell(row, col))
+            .collect()
+    }
+
+    /// Columns of `row` cut short by the table preview's cell limit, or the
+    /// query result's cell size limit, whose full value hasn't been read
+    /// yet: just `col`, or every one of the row
+    pub fn unread_partial_cells(&self, row: usize, col: Option<usize>) -> Vec<usize> {
+        let Some(row_data) = self.rows.get(row) else {
+            return Vec::new();
+        };
+        // A query result's cells are whatever the query returned, markers
+        // included; only the collector knows which ones it shortened
+        let preview = self.is_table_preview();
+        row_data
+            .iter()
+            .enumerate()
+            .filter(|(idx, _)| col.is_none() || col == Some(*idx))
+            .filter(|(idx, value)| {
+                if preview {
+                    is_partial(value)
+                } else {
+                    self.cut_cells.contains(&(row, *idx))
+                }
+            })
+            .filter(|(idx, _)| !self.full_cell_values.contains_key(&(row, *idx)))
+            .map(|(idx, _)| idx)
+            .collect()
+    }
+
+    /// The loaded rows written in `format`, with how many of their values
+    /// are still cut short by the preview's cell limit
+    pub fn export(&self, format: ExportFormat) -> std::io::Result<(Vec<u8>, usize)> {
+        let columns: Vec<String> = self.columns.iter().map(|col| col.name.clone()).collect();
+        let mut writer = format.writer(Vec::new());
+        writer.begin(&columns)?;
+        let mut shortened = 0;
+        for row in 0..self.rows.len() {
+            shortened += self.unread_partial_cells(row, None).len();
+            let values: Vec<String> = (0..columns.len())
+                .map(|col| self.full_cell_value(row, col))
+                .collect();
+            writer.write_row(&values)?;
+        }
+        Ok((writer.finish()?, shortened))
+    }
+
+    /// Write the columns the structure view lists (name, type, nullable,
+    /// default, primary key and comment) in `format`, for schema
+    /// documentation
+    pub fn export_structure(&self, format: ExportFormat) -> std::io::Result<Vec<u8>> {
+        let header = ["Column", "Type", "Nullable", "Default", "PK", "Comment"].map(String::from);
+        let mut writer = format.writer(Vec::new());
+        writer.begin(&header)?;
+        for col in &self.columns {
+            writer.write_row(&[
+                col.name.clone(),
+                col.data_type.clone(),
+                if col.is_nullable { "YES" } else { "NO" }.to_string(),
+                col.default_value.clone().unwrap_or_default(),
+                if col.is_primary_key { "YES" } else { "" }.to_string(),
+                col.comment.clone().unwrap_or_default(),
+            ])?;
+        }
+        writer.finish()
+    }
+
+    /// Statistics of the selected column over the loaded rows
+    pub fn selected_column_stats(&self) -> Option<super::ColumnStats> {
+        let column = self.columns.get(self.selected_col)?;
+        let values: Vec<String> = (0..self.rows.len())
+            .map(|row| self.full_cell_value(row, self.selected_col))
+            .collect();
+        Some(super::ColumnStats::compute(
+            &column.name,
+            values.iter().map(String::as_str),
+        ))
+    }
+
     /// Start editing the current cell
     pub fn start_edit(&mut self) {
         if !self.in_edit_mode && !self.rows.is_empty() {
             self.in_edit_mode = true;
-            self.edit_buffer = self.get_cell_value(self.selected_row, self.selected_col);
+            // Edit the full value so saving never writes the truncation marker
+            self.edit_buffer = self.full_cell_value(self.selected_row, self.selected_col);
         }
     }
 
@@ -129,8 +677,10 @@ impl TableTab {
         let col_idx = self.selected_col;
         let new_value = self.edit_buffer.clone();
 
-        // Get the original value
-        let original_value = if let Some(row_data) = self.rows.get(row_idx) {
+        // Get the original value, in full when the cell was shortened
+        let original_value = if let Some(full) = self.full_cell_values.get(&(row_idx, col_idx)) {
+            full.clone()
+        } else if let Some(row_data) = self.rows.get(row_idx) {
             row_data.get(col_idx).cloned().unwrap_or_default()
         } else {
             String::new()
@@ -138,6 +688,9 @@ impl TableTab {
 
         // Only save if value changed
         if new_value != original_value {
+            // As the grid shows it, earlier edits included
+            let previous_value = self.full_cell_value(row_idx, col_idx);
+            let primary_key = self.key_cells(row_idx);
             self.modified_cells
                 .insert((row_idx, col_idx), new_value.clone());
 
@@ -145,9 +698,11 @@ impl TableTab {
             let update = CellUpdate {
                 table_name: self.table_name.clone(),
                 column_name: self.columns[col_idx].name.clone(),
+                data_type: self.columns[col_idx].data_type.clone(),
                 new_value,
+                previous_value,
                 row_index: row_idx,
-                primary_key_values: self.get_primary_key_values(row_idx),
+                primary_key,
             };
 
             self.in_edit_mode = false;
@@ -161,23 +716,6 @@ impl TableTab {
         }
     }
 
-    /// Get primary key values for a row
-    fn get_primary_key_values(&self, row_idx: usize) -> Vec<(String, String)> {
-        let mut pk_values = Vec::new();
-
-        if let Some(row_data) = self.rows.get(row_idx) {
-            for &pk_col_idx in &self.primary_key_columns {
-                if let Some(column) = self.columns.get(pk_col_idx) {
-                    if let Some(value) = row_data.get(pk_col_idx) {
-                        pk_values.push((column.name.clone(), value.clone()));
-                    }
-                }
-            }
-        }
-
-        pk_values
-    }
-
     /// Ensure the selected row is visible within the current viewport
     pub fn ensure_selection_visible(&mut self) {
         self.ensure_selection_visible_with_height(20); // More accurate default height estimate
@@ -229,9 +767,15 @@ impl TableTab {
             self.current_page,
             max_page
         );
-        if self.current_page < max_page {
+        // An estimate can fall short; a full page may be followed by more
+        let more_past_estimate = self.total_rows_estimated && self.rows.len() >= self.rows_per_page;
+        if self.current_page < max_page || more_past_estimate {
+            if let Some(last) = self.page_key(self.rows.len().saturating_sub(1)) {
+                self.page_bound = crate::database::KeyBound::After(last);
+            }
             self.current_page += 1;
             self.selected_row = 0;
+            self.reuse_row_count = true;
             crate::log_debug!("next_page: Moving to page {}", self.current_page);
             true // Need to reload data
         } else {
@@ -250,7 +794,12 @@ impl TableTab {
         );
         if self.current_page > 0 {
             self.current_page -= 1;
+            self.page_bound = match self.page_key(0) {
+                Some(first) if self.current_page > 0 => crate::database::KeyBound::Before(first),
+                _ => crate::database::KeyBound::First,
+            };
             self.selected_row = 0;
+            self.reuse_row_count = true;
             crate::log_debug!("prev_page: Moving to page {}", self.current_page);
             true // Need to reload data
         } else {
@@ -368,6 +917,45 @@ impl TableTab {
         self.scroll_offset_y = self.scroll_offset_y.saturating_sub(10);
     }
 
+    /// Replace the partitions list, keeping the selection if it still exists
+    pub fn set_partitions(&mut self, partitions: Vec<crate::database::PartitionInfo>) {
+        self.selected_partition = self
+            .selected_partition
+            .filter(|&idx| idx < partitions.len());
+        self.partitions = partitions;
+    }
+
+    /// Select the next partition in the structure view, wrapping around
+    pub fn select_next_partition(&mut self) {
+        if self.partitions.is_empty() {
+            return;
+        }
+        self.selected_partition = Some(match self.selected_partition {
+            Some(idx) => (idx + 1) % self.partitions.len(),
+            None => 0,
+        });
+        self.reveal_partition = true;
+    }
+
+    /// Select the previous partition in the structure view, wrapping around
+    pub fn select_prev_partition(&mut self) {
+        if self.partitions.is_empty() {
+            return;
+        }
+        let last = self.partitions.len() - 1;
+        self.selected_partition = Some(match self.selected_partition {
+            Some(0) | None => last,
+            Some(idx) => idx - 1,
+        });
+        self.reveal_partition = true;
+    }
+
+    /// The partition picked in the structure view
+    pub fn selected_partition(&self) -> Option<&crate::database::PartitionInfo> {
+        self.selected_partition
+            .and_then(|idx| self.partitions.get(idx))
+    }
+
     /// Jump to top of schema view
     pub fn jump_to_top_schema(&mut self) {
         self.scroll_offset_y = 0;
@@ -447,6 +1035,35 @@ impl TableTab {
         visible_columns
     }
 
+    /// Start typing the name of a column to jump to
+    pub fn start_column_jump(&mut self) {
+        self.column_jump = Some(super::ColumnJump::new(self.selected_col));
+    }
+
+    /// Select the column best matching the name typed so far, back at the
+    /// column the jump started from while nothing matches
+    pub fn update_column_jump(&mut self, query: String) {
+        let Some(jump) = self.column_jump.as_mut() else {
+            return;
+        };
+        jump.query = query;
+        self.selected_col = super::best_column_match(
+            self.columns.iter().map(|column| column.name.as_str()),
+            &jump.query,
+        )
+        .unwrap_or(jump.from);
+    }
+
+    /// Stop typing, staying on the column jumped to or going back to the one
+    /// the jump started from
+    pub fn finish_column_jump(&mut self, keep: bool) {
+        if let Some(jump) = self.column_jump.take() {
+            if !keep {
+                self.selected_col = jump.from;
+            }
+        }
+    }
+
     /// Start search mode
     pub fn start_search(&mut self) {
         self.in_search_mode = true;
@@ -523,9 +1140,14 @@ impl TableTab {
 pub struct CellUpdate {
     pub table_name: String,
     pub column_name: String,
+    /// Type of the column, to write the new value as
+    pub data_type: String,
     pub new_value: String,
+    /// Value the cell had before the edit
+    pub previous_value: String,
     pub row_index: usize,
-    pub primary_key_values: Vec<(String, String)>,
+    /// Primary key columns of the row, as they were before the edit
+    pub primary_key: Vec<CellValue>,
 }
 
 /// State for the table viewer
@@ -536,8 +1158,22 @@ pub struct TableViewerState {
     pub show_help: bool,
     pub delete_confirmation: Option<DeleteConfirmation>,
     pub set_null_confirmation: Option<SetNullConfirmation>,
+    /// Statistics popup for the selected column
+    pub column_stats: Option<super::ColumnStats>,
+    /// Quick filter being built for the current table preview
+    pub filter_form: Option<super::FilterForm>,
+    /// Row being inserted into the current table
+    pub insert_form: Option<super::InsertRowForm>,
+    /// Export of the current tab's rows being set up
+    pub export_form: Option<super::ExportForm>,
+    /// Results pinned this session, and the prompt naming a new one
+    pub pins: super::Pins,
+    /// Binary cell being saved to a file
+    pub save_cell_form: Option<super::SaveCellForm>,
     pub last_d_press: Option<std::time::Instant>,
     pub last_y_press: Option<std::time::Instant>,
+    /// Rows per page of newly opened table previews (`[app] preview_page_rows`)
+    pub rows_per_page: usize,
 }
 
 /// Delete confirmation dialog state
@@ -545,7 +1181,8 @@ pub struct TableViewerState {
 pub struct DeleteConfirmation {
     pub row_index: usize,
     pub table_name: String,
-    pub primary_key_values: Vec<(String, String)>,
+    /// Primary key columns of the row
+    pub primary_key: Vec<CellValue>,
 }
 
 /// Set NULL confirmation dialog state
@@ -557,7 +1194,8 @@ pub struct SetNullConfirmation {
     pub column_name: String,
     pub is_nullable: bool,
     pub current_value: String,
-    pub primary_key_values: Vec<(String, String)>,
+    /// Primary key columns of the row
+    pub primary_key: Vec<CellValue>,
 }
 
 impl TableViewerState {
@@ -568,12 +1206,27 @@ impl TableViewerState {
             show_help: false,
             delete_confirmation: None,
             set_null_confirmation: None,
+            column_stats: None,
+            filter_form: None,
+            insert_form: None,
+            export_form: None,
+            pins: super::Pins::default(),
+            save_cell_form: None,
             last_d_press: None,
             last_y_press: None,
+            rows_per_page: crate::database::DEFAULT_PREVIEW_PAGE_ROWS,
         }
     }
 
     /// Add a new table tab
+    /// Close every tab, keeping the configured page size
+    pub fn reset(&mut self) {
+        *self = Self {
+            rows_per_page: self.rows_per_page,
+            ..Self::new()
+        };
+    }
+
     pub fn add_tab(&mut self, table_name: String) -> usize {
         // Check if tab already exists
         for (idx, tab) in self.tabs.iter().enumerate() {
@@ -584,7 +1237,9 @@ impl TableViewerState {
         }
 
         // Add new tab
-        self.tabs.push(TableTab::new(table_name));
+        let mut tab = TableTab::new(table_name);
+        tab.rows_per_page = self.rows_per_page;
+        self.tabs.push(tab);
         self.active_tab = self.tabs.len() - 1;
         self.active_tab
     }
@@ -637,31 +1292,77 @@ impl TableViewerState {
         self.show_help = !self.show_help;
     }
 
+    /// Open the quick filter form on the current table preview, at the
+    /// selected column. Returns whether it opened
+    pub fn open_filter_form(&mut self) -> bool {
+        let Some(tab) = self
+            .current_tab()
+            .filter(|tab| tab.is_table_preview() && !tab.columns.is_empty())
+        else {
+            return false;
+        };
+        let columns = tab
+            .columns
+            .iter()
+            .map(|col| (col.name.clone(), col.data_type.clone()))
+            .collect();
+        self.filter_form = Some(super::FilterForm::new(columns, tab.selected_col));
+        true
+    }
+
+    /// Open the export form on the current tab, for its structure when that
+    /// is shown. Returns whether it opened
+    pub fn open_export_form(&mut self) -> bool {
+        let Some(tab) = self.current_tab().filter(|tab| !tab.columns.is_empty()) else {
+            return false;
+        };
+        let stem = tab.pin.as_deref().unwrap_or(&tab.table_name);
+        if tab.view_mode == TableViewMode::Schema {
+            self.export_form = Some(super::ExportForm::for_structure(stem));
+            return true;
+        }
+        let mut form = super::ExportForm::new(stem);
+        if tab.is_table_preview() && (!tab.filters.is_empty() || tab.sort.is_some()) {
+            form = form.with_filtered_rows();
+        }
+        self.export_form = Some(form);
+        true
+    }
+
+    /// Open the pin at `index` in a tab, or switch to the tab showing it
+    pub fn open_pin(&mut self, index: usize) -> bool {
+        let Some(pin) = self.pins.get(index) else {
+            return false;
+        };
+        match self
+            .tabs
+            .iter()
+            .position(|tab| tab.pin.as_deref() == Some(pin.name.as_str()))
+        {
+            Some(open) => {
+                // A pin replaced since it was opened shows its new rows
+                self.tabs[open] = pin.tab.clone();
+                self.active_tab = open;
+            }
+            None => {
+                self.tabs.push(pin.tab.clone());
+                self.active_tab = self.tabs.len() - 1;
+            }
+        }
+        true
+    }
+
     /// Copy current row to clipboard in CSV format
-    pub fn copy_row_csv(&self) -> Result<(), String> {
+    pub fn copy_row_csv(&self, clipboard: &Clipboard) -> Result<ClipboardBackend, String> {
         if let Some(tab) = self.current_tab() {
             if let Some(row_data) = tab.rows.get(tab.selected_row) {
+                let values: Vec<String> = (0..row_data.len())
+                    .map(|col| tab.full_cell_value(tab.selected_row, col))
+                    .collect();
                 // Escape CSV values that contain commas, quotes, or newlines
-                let csv_row = row_data
-                    .iter()
-                    .map(|cell| {
-                        if cell.contains(',') || cell.contains('"') || cell.contains('\n') {
-                            format!("\"{}\"", cell.replace('"', "\"\""))
-                        } else {
-                            cell.clone()
-                        }
-                    })
-                    .collect::<Vec<_>>()
-                    .join(",");
-
-                // Copy to clipboard
-                let mut clipboard = arboard::Clipboard::new()
-                    .map_err(|e| format!("Failed to access clipboard: {e}"))?;
-                clipboard
-                    .set_text(csv_row)
-                    .map_err(|e| format!("Failed to copy to clipboard: {e}"))?;
-
-                Ok(())
+                let csv_row = crate::io::export::csv_line(&values);
+
+                clipboard.copy(&csv_row)
             } else {
                 Err("No row selected".to_string())
             }
@@ -670,24 +1371,77 @@ impl TableViewerState {
         }
     }
 
+    /// Copy current row to clipboard as tab separated values, for pasting
+    /// into a spreadsheet
+    pub fn copy_row_tsv(&self, clipboard: &Clipboard) -> Result<ClipboardBackend, String> {
+        let tab = self.current_tab().ok_or("No table open")?;
+        if tab.selected_row >= tab.rows.len() {
+            return Err("No row selected".to_string());
+        }
+        let values: Vec<String> = (0..tab.columns.len())
+            .map(|col| tab.full_cell_value(tab.selected_row, col))
+            .collect();
+        clipboard.copy(&crate::io::export::tsv_line(&values))
+    }
+
+    /// Copy every loaded row to clipboard as tab separated values under a
+    /// header of the column names, returning how many rows were copied
+    pub fn copy_rows_tsv(
+        &self,
+        clipboard: &Clipboard,
+    ) -> Result<(usize, ClipboardBackend), String> {
+        let tab = self.current_tab().ok_or("No table open")?;
+        if tab.rows.is_empty() {
+            return Err("No data in table".to_string());
+        }
+        let header: Vec<String> = tab.columns.iter().map(|col| col.name.clone()).collect();
+        let mut lines = vec![crate::io::export::tsv_line(&header)];
+        for row in 0..tab.rows.len() {
+            let values: Vec<String> = (0..tab.columns.len())
+                .map(|col| tab.full_cell_value(row, col))
+                .collect();
+            lines.push(crate::io::export::tsv_line(&values));
+        }
+        let backend = clipboard.copy(&lines.join("\n"))?;
+        Ok((tab.rows.len(), backend))
+    }
+
+    /// Copy current row to clipboard as a JSON object keyed by column name,
+    /// its values typed by the columns
+    pub fn copy_row_json(&self, clipboard: &Clipboard) -> Result<ClipboardBackend, String> {
+        let tab = self.current_tab().ok_or("No table open")?;
+        if tab.selected_row >= tab.rows.len() {
+            return Err("No row selected".to_string());
+        }
+        let values: Vec<String> = (0..tab.columns.len())
+            .map(|col| tab.full_cell_value(tab.selected_row, col))
+            .collect();
+        let fields: Vec<(&str, &str, &str)> = tab
+            .columns
+            .iter()
+            .zip(&values)
+            .map(|(column, value)| {
+                (
+                    column.name.as_str(),
+                    column.data_type.as_str(),
+                    value.as_str(),
+                )
+            })
+            .collect();
+        clipboard.copy(&crate::io::export::typed_json_object(&fields))
+    }
+
     /// Copy current cell to clipboard (raw value)
-    pub fn copy_cell(&self) -> Result<(), String> {
+    pub fn copy_cell(&self, clipboard: &Clipboard) -> Result<ClipboardBackend, String> {
         if let Some(tab) = self.current_tab() {
             if tab.rows.is_empty() {
                 return Err("No data in table".to_string());
             }
 
-            // Get the current cell value (including any modifications)
-            let cell_value = tab.get_cell_value(tab.selected_row, tab.selected_col);
+            // Get the full cell value (including any modifications)
+            let cell_value = tab.full_cell_value(tab.selected_row, tab.selected_col);
 
-            // Copy to clipboard
-            let mut clipboard = arboard::Clipboard::new()
-                .map_err(|e| format!("Failed to access clipboard: {e}"))?;
-            clipboard
-                .set_text(cell_value)
-                .map_err(|e| format!("Failed to copy to clipboard: {e}"))?;
-
-            Ok(())
+            clipboard.copy(&cell_value)
         } else {
             Err("No table open".to_string())
         }
@@ -697,19 +1451,8 @@ impl TableViewerState {
     pub fn prepare_delete_confirmation(&mut self) -> Option<DeleteConfirmation> {
         if let Some(tab) = self.current_tab() {
             if tab.selected_row < tab.rows.len() {
-                // Get primary key values for the row
-                let mut primary_key_values = Vec::new();
-                for &pk_idx in &tab.primary_key_columns {
-                    if let Some(pk_col) = tab.columns.get(pk_idx) {
-                        if let Some(row) = tab.rows.get(tab.selected_row) {
-                            if let Some(value) = row.get(pk_idx) {
-                                primary_key_values.push((pk_col.name.clone(), value.clone()));
-                            }
-                        }
-                    }
-                }
-
-                if primary_key_values.is_empty() {
+                let primary_key = tab.key_cells(tab.selected_row);
+                if primary_key.is_empty() {
                     // Can't delete without primary key
                     return None;
                 }
@@ -717,7 +1460,7 @@ impl TableViewerState {
                 Some(DeleteConfirmation {
                     row_index: tab.selected_row,
                     table_name: tab.table_name.clone(),
-                    primary_key_values,
+                    primary_key,
                 })
             } else {
                 None
@@ -742,19 +1485,8 @@ impl TableViewerState {
                 // Get current cell value
                 let current_value = tab.get_cell_value(tab.selected_row, tab.selected_col);
 
-                // Get primary key values for the row
-                let mut primary_key_values = Vec::new();
-                for &pk_idx in &tab.primary_key_columns {
-                    if let Some(pk_col) = tab.columns.get(pk_idx) {
-                        if let Some(row) = tab.rows.get(tab.selected_row) {
-                            if let Some(value) = row.get(pk_idx) {
-                                primary_key_values.push((pk_col.name.clone(), value.clone()));
-                            }
-                        }
-                    }
-                }
-
-                if primary_key_values.is_empty() {
+                let primary_key = tab.key_cells(tab.selected_row);
+                if primary_key.is_empty() {
                     // Can't update without primary key
                     return None;
                 }
@@ -766,7 +1498,7 @@ impl TableViewerState {
                     column_name: column.name.clone(),
                     is_nullable: column.is_nullable,
                     current_value,
-                    primary_key_values,
+                    primary_key,
                 })
             } else {
                 None
@@ -838,6 +1570,36 @@ pub fn render_table_viewer(
     if let Some(confirmation) = &state.set_null_confirmation {
         render_set_null_confirmation(f, confirmation, f.area(), theme);
     }
+
+    // Render column statistics popup if open
+    if let Some(stats) = &state.column_stats {
+        super::render_column_stats(f, stats, f.area(), theme);
+    }
+
+    // Render the quick filter form if open
+    if let Some(form) = &state.filter_form {
+        super::render_filter_form(f, form, f.area(), theme);
+    }
+
+    // Render the insert-row form if open
+    if let Some(form) = &state.insert_form {
+        super::render_insert_row_form(f, form, f.area(), theme);
+    }
+
+    // Render the export form if open
+    if let Some(form) = &state.export_form {
+        super::render_export_form(f, form, f.area(), theme);
+    }
+
+    // Render the prompt naming a pin if open
+    if let Some(name) = &state.pins.naming {
+        super::render_pin_name(f, name, f.area(), theme);
+    }
+
+    // Render the prompt saving a binary cell if open
+    if let Some(form) = &state.save_cell_form {
+        super::render_save_cell_form(f, form, f.area(), theme);
+    }
 }
 
 fn render_delete_confirmation(
@@ -1188,18 +1950,160 @@ fn render_table_content(
                     .title(format!(" {} - Error ", tab.table_name))
                     .border_style(Style::default().fg(theme.get_color("danger"))),
             )
-            .alignment(Alignment::Center);
+            .alignment(Alignment::Center)
+            .wrap(Wrap { trim: true });
         f.render_widget(error_text, area);
         return;
     }
 
+    // Server notices go beneath the result they came with
+    let area = if tab.notices.is_empty() || tab.view_mode != TableViewMode::Data {
+        area
+    } else {
+        let height = tab.notices.len().min(MAX_SHOWN_NOTICES) as u16 + 2;
+        let chunks = Layout::default()
+            .direction(Direction::Vertical)
+            .constraints([Constraint::Min(5), Constraint::Length(height)])
+            .split(area);
+        render_notices(f, &tab.notices, chunks[1], theme);
+        chunks[0]
+    };
+
     // Render based on view mode
     match tab.view_mode {
+        TableViewMode::Data if tab.shows_plan() => {
+            render_plan_view(f, tab, area, theme, is_focused)
+        }
+        TableViewMode::Data if tab.shows_json() => {
+            render_json_view(f, tab, area, theme, is_focused)
+        }
         TableViewMode::Data => render_data_view(f, tab, area, theme, is_focused),
         TableViewMode::Schema => render_schema_view(f, tab, area, theme, is_focused),
     }
 }
 
+/// Notices listed beneath a result; the panel title counts the rest
+const MAX_SHOWN_NOTICES: usize = 5;
+
+/// List the notices and warnings the server sent with the result
+fn render_notices(
+    f: &mut Frame,
+    notices: &[crate::database::ServerNotice],
+    area: Rect,
+    theme: &Theme,
+) {
+    let lines: Vec<Line> = notices
+        .iter()
+        .take(MAX_SHOWN_NOTICES)
+        .map(|notice| {
+            let color = if notice.is_warning() {
+                "warning"
+            } else {
+                "text_secondary"
+            };
+            Line::from(Span::styled(
+                notice.to_string(),
+                Style::default().fg(theme.get_color(color)),
+            ))
+        })
+        .collect();
+    let title = if notices.len() > MAX_SHOWN_NOTICES {
+        format!(
+            " Server messages ({} of {}) ",
+            MAX_SHOWN_NOTICES,
+            notices.len()
+        )
+    } else {
+        format!(" Server messages ({}) ", notices.len())
+    };
+    let panel = Paragraph::new(lines).block(
+        Block::default()
+            .borders(Borders::ALL)
+            .title(title)
+            .border_style(Style::default().fg(theme.get_color("warning"))),
+    );
+    f.render_widget(panel, area);
+}
+
+/// Render a single JSON document result as a pretty-printed document
+fn render_json_view(
+    f: &mut Frame,
+    tab: &mut TableTab,
+    area: Rect,
+    theme: &Theme,
+    is_focused: bool,
+) {
+    let block = Block::default()
+        .borders(Borders::ALL)
+        .title(format!(
+            " {} - JSON document [J] Grid [Enter] Fold ",
+            tab.table_name
+        ))
+        .title_bottom(truncation_notice(tab, theme))
+        .title_bottom(run_footer(tab, theme))
+        .title_bottom(watch_footer(tab, theme))
+        .border_style(if is_focused {
+            Style::default().fg(theme.get_color("active_border"))
+        } else {
+            Style::default().fg(theme.get_color("border"))
+        });
+    let inner = block.inner(area);
+    f.render_widget(block, area);
+
+    if let Some(view) = tab.json_view.as_mut() {
+        view.render(f, inner, theme, is_focused);
+    }
+}
+
+/// Render a query plan result as a tree with each node's share of the plan
+fn render_plan_view(
+    f: &mut Frame,
+    tab: &mut TableTab,
+    area: Rect,
+    theme: &Theme,
+    is_focused: bool,
+) {
+    let Some(view) = tab.plan_view.as_mut() else {
+        return;
+    };
+    let block = Block::default()
+        .borders(Borders::ALL)
+        .title(format!(
+            " {} - Query plan ({}) [J] Grid [Enter] Hottest node ",
+            tab.table_name,
+            view.summary()
+        ))
+        .title_bottom(Line::from(Span::styled(
+            format!(" {} ", view.selected_details()),
+            Style::default().fg(theme.get_color("text_secondary")),
+        )))
+        .border_style(if is_focused {
+            Style::default().fg(theme.get_color("active_border"))
+        } else {
+            Style::default().fg(theme.get_color("border"))
+        });
+    let inner = block.inner(area);
+    f.render_widget(block, area);
+
+    // Index suggestions go under the tree, one line each
+    let hints = view.index_hints();
+    let chunks = Layout::default()
+        .direction(Direction::Vertical)
+        .constraints([Constraint::Min(1), Constraint::Length(hints.len() as u16)])
+        .split(inner);
+    view.render(f, chunks[0], theme, is_focused);
+    let hints: Vec<Line> = hints
+        .into_iter()
+        .map(|hint| {
+            Line::from(Span::styled(
+                hint,
+                Style::default().fg(theme.get_color("warning")),
+            ))
+        })
+        .collect();
+    f.render_widget(Paragraph::new(hints), chunks[1]);
+}
+
 fn render_data_view(
     f: &mut Frame,
     tab: &mut TableTab,
@@ -1228,11 +2132,15 @@ fn render_data_view(
                 Style::default().fg(theme.get_color("text_primary"))
             };
 
-            let name = if col.is_primary_key {
+            let mut name = if col.is_primary_key {
                 format!(" 🔑 {} ", col.name)
             } else {
                 format!(" {} ", col.name)
             };
+            if let Some(sort) = tab.sort.as_ref().filter(|sort| sort.column == col.name) {
+                name.push_str(sort.arrow());
+                name.push(' ');
+            }
 
             TableCell::from(name).style(style)
         })
@@ -1267,6 +2175,11 @@ fn render_data_view(
                     let is_selected = *row_idx == tab.selected_row && col_idx == tab.selected_col;
                     let is_modified = tab.modified_cells.contains_key(&(*row_idx, col_idx));
                     let is_search_match = tab.search_results.contains(&(*row_idx, col_idx));
+                    let is_marked = tab.is_marked(*row_idx, col_idx);
+                    let change = tab
+                        .watch
+                        .as_ref()
+                        .and_then(|watch| watch.change(*row_idx, col_idx));
                     let is_current_search = tab.search_results.get(tab.current_search_result)
                         == Some(&(*row_idx, col_idx));
 
@@ -1279,6 +2192,9 @@ fn render_data_view(
                             .cloned()
                             .unwrap_or_else(|| value.clone());
                         format!(" {val} ")
+                    } else if theme.accessible && value == NULL_CELL {
+                        // Not told apart from text by dimming alone
+                        " <null> ".to_string()
                     } else {
                         format!(" {value} ")
                     };
@@ -1304,6 +2220,18 @@ fn render_data_view(
                         Style::default()
                             .fg(theme.get_color("selected_text"))
                             .bg(theme.get_color("selected_bg"))
+                    } else if is_marked {
+                        base_style
+                            .fg(theme.get_color("accent"))
+                            .add_modifier(Modifier::REVERSED)
+                    } else if let Some(left) = change {
+                        // Fresh changes stand out, older ones fade back
+                        let style = base_style.fg(theme.get_color("warning"));
+                        if left == super::CHANGE_HIGHLIGHT_REFRESHES {
+                            style.add_modifier(Modifier::BOLD)
+                        } else {
+                            style
+                        }
                     } else if is_search_match {
                         base_style
                             .fg(theme.get_color("search_match"))
@@ -1312,7 +2240,7 @@ fn render_data_view(
                         base_style
                             .fg(theme.get_color("modified_cell"))
                             .add_modifier(Modifier::ITALIC)
-                    } else if value == "NULL" || value.is_empty() {
+                    } else if value == NULL_CELL || value.is_empty() {
                         base_style.fg(theme.get_color("null_value"))
                     } else {
                         base_style
@@ -1335,17 +2263,17 @@ fn render_data_view(
         })
         .collect();
 
+    let (page, total_rows) = tab.page_position();
     let table = Table::new(rows, widths)
         .header(header)
         .block(
             Block::default()
                 .borders(Borders::ALL)
                 .title(format!(
-                    " {} - Data - Page {}/{} ({} rows, {} cols) {} [t] Toggle View{} ",
+                    " {} - Data - {} ({} rows, {} cols) {} [t] Toggle View{}{} ",
                     tab.table_name,
-                    tab.current_page + 1,
-                    (tab.total_rows.saturating_sub(1)) / tab.rows_per_page + 1,
-                    tab.total_rows,
+                    page,
+                    total_rows,
                     tab.columns.len(),
                     if visible_column_indices.len() < tab.columns.len() {
                         format!(
@@ -1357,6 +2285,19 @@ fn render_data_view(
                     } else {
                         String::new()
                     },
+                    if let Some((0, statements)) = tab.statement {
+                        format!(" | Summary of {statements} statements [/]")
+                    } else if let Some((position, statements)) = tab.statement {
+                        format!(" | Statement {position}/{statements} [/]")
+                    } else if tab.result_sets.len() > 1 {
+                        format!(
+                            " | Result set {}/{} [/]",
+                            tab.result_set + 1,
+                            tab.result_sets.len()
+                        )
+                    } else {
+                        String::new()
+                    },
                     if tab.in_search_mode {
                         format!(
                             " | Search: '{}' ({}/{})",
@@ -1378,6 +2319,12 @@ fn render_data_view(
                         String::new()
                     }
                 ))
+                .title_bottom(truncation_notice(tab, theme))
+                .title_bottom(run_footer(tab, theme))
+                .title_bottom(paging_footer(tab, theme))
+                .title_bottom(watch_footer(tab, theme))
+                .title_bottom(marks_footer(tab, theme))
+                .title_bottom(column_footer(tab, visible_column_indices.len(), theme))
                 .border_style(if tab.in_edit_mode {
                     Style::default().fg(theme.get_color("edit_mode_border"))
                 } else if tab.in_search_mode {
@@ -1394,6 +2341,177 @@ fn render_data_view(
     f.render_widget(table, area);
 }
 
+/// Footer line shown when the result was cut off at the row cap or fetching
+/// was stopped early
+fn truncation_notice(tab: &TableTab, theme: &Theme) -> Line<'static> {
+    if tab.fetch_stopped {
+        return Line::from(Span::styled(
+            format!(
+                " fetching stopped after {} rows ",
+                group_thousands(tab.total_rows)
+            ),
+            Style::default().fg(theme.get_color("warning")),
+        ));
+    }
+    match tab.truncated_at {
+        Some(rows) => Line::from(Span::styled(
+            format!(
+                " results truncated at {} rows — refine your query or export server-side ",
+                group_thousands(rows)
+            ),
+            Style::default().fg(theme.get_color("warning")),
+        )),
+        None => Line::default(),
+    }
+}
+
+/// Footer with the row count and time of the query behind the tab
+fn run_footer(tab: &TableTab, theme: &Theme) -> Line<'static> {
+    match tab.run_summary() {
+        Some(summary) => Line::from(Span::styled(
+            format!(" {summary} "),
+            Style::default().fg(theme.get_color("text_muted")),
+        )),
+        None => Line::default(),
+    }
+}
+
+/// Footer of a table preview: its filters, the rows of the page shown, out
+/// of the table's count or estimate, and the keys fetching the pages around
+/// it
+fn paging_footer(tab: &TableTab, theme: &Theme) -> Line<'static> {
+    if !tab.is_table_preview() {
+        return Line::default();
+    }
+    let mut spans = Vec::new();
+    if !tab.filters.is_empty() {
+        let filters: Vec<String> = tab.filters.iter().map(ToString::to_string).collect();
+        spans.push(Span::styled(
+            format!(" where {} [F] clear ", filters.join(" and ")),
+            Style::default().fg(theme.get_color("accent")),
+        ));
+    }
+    if tab.rows.is_empty() {
+        return Line::from(spans);
+    }
+    let first = tab.current_page * tab.rows_per_page + 1;
+    let (_, total_rows) = tab.page_position();
+    spans.push(Span::styled(
+        format!(
+            " rows {}–{} of {} · {} · [n] next [p] previous page ",
+            group_thousands(first),
+            group_thousands(first + tab.rows.len() - 1),
+            total_rows,
+            tab.paging
+                .as_ref()
+                .map_or_else(|| "offset".to_string(), |paging| paging.strategy())
+        ),
+        Style::default().fg(theme.get_color("text_muted")),
+    ));
+    Line::from(spans)
+}
+
+/// Right-aligned footer counting down to the next run of a watched query
+fn watch_footer(tab: &TableTab, theme: &Theme) -> Line<'static> {
+    match &tab.watch {
+        Some(watch) => Line::from(Span::styled(
+            format!(" {} ", watch.footer(std::time::Instant::now())),
+            Style::default().fg(theme.get_color("primary_highlight")),
+        ))
+        .right_aligned(),
+        None => Line::default(),
+    }
+}
+
+/// Right-aligned footer with the selected column's position when not every
+/// column fits, or the column name being typed to jump to
+fn column_footer(tab: &TableTab, visible_columns: usize, theme: &Theme) -> Line<'static> {
+    let position = format!(
+        "column {}/{}",
+        group_thousands(tab.selected_col + 1),
+        group_thousands(tab.columns.len())
+    );
+    let text = match &tab.column_jump {
+        Some(jump) => format!(
+            " jump to column: {}▌ · {position} · Enter keep · Esc back ",
+            jump.query
+        ),
+        None if visible_columns < tab.columns.len() => format!(" {position} · c jump "),
+        None => return Line::default(),
+    };
+    Line::from(Span::styled(
+        text,
+        Style::default().fg(theme.get_color("text_muted")),
+    ))
+    .right_aligned()
+}
+
+/// Centered footer counting the cells marked for an IN clause
+fn marks_footer(tab: &TableTab, theme: &Theme) -> Line<'static> {
+    if !tab.has_marks() {
+        return Line::default();
+    }
+    let marked = (0..tab.rows.len())
+        .filter(|&row| tab.is_marked(row, tab.marked_col))
+        .count();
+    let mode = if tab.mark_anchor.is_some() {
+        "VISUAL · "
+    } else {
+        ""
+    };
+    Line::from(Span::styled(
+        format!(" {mode}{marked} marked · W copy IN clause · I insert into query "),
+        Style::default().fg(theme.get_color("accent")),
+    ))
+    .centered()
+}
+
+/// Query time as `420µs`, `128ms`, `3.2s` or `2m 05s`
+pub(crate) fn format_query_time(elapsed: std::time::Duration) -> String {
+    let micros = elapsed.as_micros();
+    let secs = elapsed.as_secs();
+    if micros == 0 {
+        "<1µs".to_string()
+    } else if micros < 1_000 {
+        format!("{micros}µs")
+    } else if micros < 1_000_000 {
+        format!("{}ms", micros / 1_000)
+    } else if secs < 60 {
+        format!("{secs}.{}s", elapsed.subsec_millis() / 100)
+    } else {
+        format!("{}m {:02}s", secs / 60, secs % 60)
+    }
+}
+
+/// Format a count with thousands separators (10000 -> "10,000")
+pub(crate) fn group_thousands(n: usize) -> String {
+    let digits = n.to_string();
+    let mut grouped = String::with_capacity(digits.len() + digits.len() / 3);
+    for (i, digit) in digits.chars().enumerate() {
+        if i > 0 && (digits.len() - i) % 3 == 0 {
+            grouped.push(',');
+        }
+        grouped.push(digit);
+    }
+    grouped
+}
+
+/// Partitions listed at once in the structure view
+const MAX_LISTED_PARTITIONS: usize = 50;
+
+/// Range of partitions to list, `max` long and keeping the selected one in
+/// the middle where possible
+fn partition_window(count: usize, selected: Option<usize>, max: usize) -> (usize, usize) {
+    if count <= max {
+        return (0, count);
+    }
+    let start = selected
+        .map(|idx| idx.saturating_sub(max / 2))
+        .unwrap_or(0)
+        .min(count - max);
+    (start, start + max)
+}
+
 fn render_schema_view(
     f: &mut Frame,
     tab: &mut TableTab,
@@ -1428,7 +2546,7 @@ fn render_schema_view(
         let pk_marker = if col.is_primary_key { " 🔑" } else { "" };
         let nullable = if col.is_nullable { "NULL" } else { "NOT NULL" };
 
-        lines.push(Line::from(vec![
+        let mut spans = vec![
             Span::styled("  • ", Style::default().fg(theme.get_color("success"))),
             Span::styled(
                 format!("{}{}", col.name, pk_marker),
@@ -1447,7 +2565,140 @@ fn render_schema_view(
                     theme.get_color("text_secondary")
                 }),
             ),
-        ]));
+        ];
+        let badge_style = Style::default()
+            .fg(theme.get_color("accent"))
+            .add_modifier(Modifier::BOLD);
+        if col.is_auto_increment {
+            spans.push(Span::raw("  "));
+            spans.push(Span::styled("AUTO INCREMENT", badge_style));
+        }
+        if let Some(generated) = &col.generated {
+            spans.push(Span::raw("  "));
+            spans.push(Span::styled(generated.label(), badge_style));
+        }
+        lines.push(Line::from(spans));
+        if let Some(expression) = col
+            .generated
+            .as_ref()
+            .and_then(|generated| generated.expression.as_deref())
+        {
+            lines.push(Line::from(vec![
+                Span::raw("      as: "),
+                Span::styled(
+                    expression.to_string(),
+                    Style::default().fg(theme.get_color("text_secondary")),
+                ),
+            ]));
+        }
+
+        for (label, value) in [("default", &col.default_value), ("comment", &col.comment)] {
+            if let Some(value) = value {
+                lines.push(Line::from(vec![
+                    Span::raw(format!("      {label}: ")),
+                    Span::styled(
+                        value.clone(),
+                        Style::default().fg(theme.get_color("text_secondary")),
+                    ),
+                ]));
+            }
+        }
+
+        if !col.enum_labels.is_empty() {
+            let values = col
+                .enum_labels
+                .iter()
+                .map(|label| format!("'{}'", label.replace('\'', "''")))
+                .collect::<Vec<_>>()
+                .join(", ");
+            lines.push(Line::from(vec![
+                Span::raw("      values: "),
+                Span::styled(
+                    values,
+                    Style::default().fg(theme.get_color("text_secondary")),
+                ),
+            ]));
+        }
+    }
+
+    // Partitions of a partitioned table; p/P pick one, Enter previews it
+    if !tab.partitions.is_empty() {
+        let count = tab.partitions.len();
+        lines.push(Line::from(""));
+        lines.push(Line::from(""));
+        lines.push(Line::from(vec![Span::styled(
+            format!("━━━ PARTITIONS ({}) ━━━", group_thousands(count)),
+            Style::default()
+                .fg(theme.get_color("accent"))
+                .add_modifier(Modifier::BOLD),
+        )]));
+        lines.push(Line::from(Span::styled(
+            "  [p/P] Select  [Enter] Preview rows",
+            Style::default().fg(theme.get_color("text_muted")),
+        )));
+        lines.push(Line::from(""));
+
+        let (start, end) = partition_window(count, tab.selected_partition, MAX_LISTED_PARTITIONS);
+        let mut selected_line = None;
+        if start > 0 {
+            lines.push(Line::from(Span::styled(
+                format!("  … {} more above", group_thousands(start)),
+                Style::default().fg(theme.get_color("text_muted")),
+            )));
+        }
+        for (idx, partition) in tab.partitions.iter().enumerate().take(end).skip(start) {
+            let selected = tab.selected_partition == Some(idx);
+            if selected {
+                selected_line = Some(lines.len());
+            }
+
+            let name_style = if selected {
+                Style::default()
+                    .fg(theme.get_color("selected_text"))
+                    .bg(theme.get_color("selected_bg"))
+                    .add_modifier(Modifier::BOLD)
+            } else {
+                Style::default()
+                    .fg(theme.get_color("text_primary"))
+                    .add_modifier(Modifier::BOLD)
+            };
+            let mut spans = vec![
+                Span::styled(
+                    if selected { "  ▶ " } else { "  • " },
+                    Style::default().fg(theme.get_color("success")),
+                ),
+                Span::styled(partition.name.clone(), name_style),
+            ];
+            if let Some(bound) = &partition.bound {
+                spans.push(Span::raw("  "));
+                spans.push(Span::styled(
+                    bound.clone(),
+                    Style::default().fg(theme.get_color("info")),
+                ));
+            }
+            if let Some(rows) = partition.row_estimate {
+                spans.push(Span::styled(
+                    format!("  ~{} rows", group_thousands(rows as usize)),
+                    Style::default().fg(theme.get_color("text_secondary")),
+                ));
+            }
+            lines.push(Line::from(spans));
+        }
+        if end < count {
+            lines.push(Line::from(Span::styled(
+                format!("  … {} more below", group_thousands(count - end)),
+                Style::default().fg(theme.get_color("text_muted")),
+            )));
+        }
+
+        // Bring a newly selected partition into view
+        if let Some(line) = selected_line.filter(|_| tab.reveal_partition) {
+            let height = area.height.saturating_sub(2) as usize;
+            if line < tab.scroll_offset_y || line >= tab.scroll_offset_y + height {
+                tab.scroll_offset_y = line.saturating_sub(height / 2);
+            }
+            tab.reveal_partition = false;
+        }
     }
 
     // Section 2: INDEXES (if metadata available)
@@ -1772,3 +3023,507 @@ fn render_help(f: &mut Frame, area: Rect, theme: &Theme) {
 
     f.render_widget(help, area);
 }
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_full_cell_value_prefers_edits_then_full_value() {
+        let mut tab = TableTab::new("Query Result".to_string());
+        tab.rows = vec![vec!["abc…(+1.0MB)".to_string(), "1".to_string()]];
+        tab.full_cell_values.insert((0, 0), "abc".repeat(1000));
+
+        assert_eq!(tab.full_cell_value(0, 0).len(), 3000);
+        assert_eq!(tab.full_cell_value(0, 1), "1");
+
+        tab.modified_cells.insert((0, 0), "edited".to_string());
+        assert_eq!(tab.full_cell_value(0, 0), "edited");
+    }
+
+    #[test]
+    fn test_unread_partial_cells_of_a_preview() {
+        let mut tab = TableTab::new("documents".to_string());
+        tab.rows = vec![vec![
+            "7".to_string(),
+            "long body…(more)".to_string(),
+            "raw…(more)".to_string(),
+        ]];
+
+        assert_eq!(tab.unread_partial_cells(0, None), vec![1, 2]);
+        assert_eq!(tab.unread_partial_cells(0, Some(2)), vec![2]);
+        assert!(tab.unread_partial_cells(0, Some(0)).is_empty());
+
+        tab.full_cell_values
+            .insert((0, 1), "long body, every word of it".to_string());
+        assert_eq!(tab.unread_partial_cells(0, None), vec![2]);
+
+        // A query result's cells are whatever the query returned
+        tab.query = Some("SELECT 'raw…(more)'".to_string());
+        assert!(tab.unread_partial_cells(0, None).is_empty());
+
+        // save for the ones the cell size limit shortened
+        tab.cut_cells.insert((0, 1));
+        tab.full_cell_values.clear();
+        assert_eq!(tab.unread_partial_cells(0, None), vec![1]);
+        assert!(tab.unread_partial_cells(0, Some(2)).is_empty());
+    }
+
+    #[test]
+    fn test_paging_past_a_row_estimate() {
+        let mut tab = TableTab::new("events".to_string());
+        tab.rows = vec![vec!["1".to_string()]; tab.rows_per_page];
+        tab.set_total_rows(30, true, 0);
+        assert_eq!(
+            tab.page_position(),
+            ("Page 1/~2".to_string(), "~30".to_string())
+        );
+
+        // The estimate is stale; full pages keep going past it
+        assert!(tab.next_page());
+        assert!(tab.next_page());
+        assert_eq!(tab.current_page, 2);
+
+        // A short page is the last one, and settles the count
+        tab.rows.truncate(5);
+        tab.set_total_rows(30, true, 40);
+        assert_eq!(
+            tab.page_position(),
+            ("Page 3/3".to_string(), "45".to_string())
+        );
+        assert!(!tab.next_page());
+    }
+
+    #[test]
+    fn test_keyset_pages_start_from_the_rows_shown() {
+        use crate::database::{KeyBound, Paging};
+
+        let mut tab = TableTab::new("events".to_string());
+        tab.columns = vec![ColumnInfo {
+            name: "id".to_string(),
+            data_type: "bigint".to_string(),
+            is_nullable: false,
+            is_primary_key: true,
+            max_display_width: 10,
+            enum_labels: Vec::new(),
+            is_auto_increment: true,
+            generated: None,
+            default_value: None,
+            comment: None,
+        }];
+        tab.primary_key_columns = vec![0];
+        tab.paging = Some(Paging::Keyset {
+            key: "id".to_string(),
+            data_type: "bigint".to_string(),
+            bound: KeyBound::First,
+        });
+        tab.rows = (1..=20).map(|id| vec![id.to_string()]).collect();
+        tab.set_total_rows(60, false, 0);
+
+        assert!(tab.next_page());
+        assert_eq!(tab.page_bound, KeyBound::After("20".to_string()));
+        tab.rows = (21..=40).map(|id| vec![id.to_string()]).collect();
+        assert!(tab.next_page());
+        assert_eq!(tab.page_bound, KeyBound::After("40".to_string()));
+
+        tab.rows = (41..=60).map(|id| vec![id.to_string()]).collect();
+        assert!(tab.prev_page());
+        assert_eq!(tab.page_bound, KeyBound::Before("41".to_string()));
+        tab.rows = (21..=40).map(|id| vec![id.to_string()]).collect();
+        assert!(tab.prev_page());
+        assert_eq!(tab.page_bound, KeyBound::First);
+    }
+
+    #[test]
+    fn test_pages_keep_the_row_count() {
+        let mut state = TableViewerState::new();
+        state.rows_per_page = 50;
+        state.reset();
+        let idx = state.add_tab("events".to_string());
+        let tab = &mut state.tabs[idx];
+        assert_eq!(tab.rows_per_page, 50);
+
+        tab.rows = vec![vec!["1".to_string()]; 50];
+        tab.set_total_rows(120, false, 0);
+        assert!(tab.next_page());
+        assert!(tab.reuse_row_count);
+
+        // A new sort reads different rows, which are counted again
+        tab.columns = vec![ColumnInfo {
+            name: "id".to_string(),
+            data_type: "bigint".to_string(),
+            is_nullable: false,
+            is_primary_key: true,
+            max_display_width: 10,
+            enum_labels: Vec::new(),
+            is_auto_increment: true,
+            generated: None,
+            default_value: None,
+            comment: None,
+        }];
+        tab.toggle_sort();
+        assert!(!tab.reuse_row_count);
+    }
+
+    #[test]
+    fn test_export_structure_as_markdown() {
+        let mut tab = TableTab::new("orders".to_string());
+        tab.columns = vec![ColumnInfo {
+            name: "id".to_string(),
+            data_type: "bigint".to_string(),
+            is_nullable: false,
+            is_primary_key: true,
+            max_display_width: 10,
+            enum_labels: Vec::new(),
+            is_auto_increment: true,
+            generated: None,
+            default_value: None,
+            comment: Some("Order number | public".to_string()),
+        }];
+        tab.columns.push(ColumnInfo {
+            name: "status".to_string(),
+            data_type: "text".to_string(),
+            is_nullable: true,
+            is_primary_key: false,
+            default_value: Some("'draft'::text".to_string()),
+            comment: None,
+            ..tab.columns[0].clone()
+        });
+
+        assert_eq!(
+            String::from_utf8(tab.export_structure(ExportFormat::Markdown).unwrap()).unwrap(),
+            "| Column | Type | Nullable | Default | PK | Comment |\n\
+             | --- | --- | --- | --- | --- | --- |\n\
+             | id | bigint | NO |  | YES | Order number \\| public |\n\
+             | status | text | YES | 'draft'::text |  |  |\n"
+        );
+    }
+
+    #[test]
+    fn test_sort_toggles_and_rewinds() {
+        let mut tab = TableTab::new("events".to_string());
+        tab.columns = ["id", "created_at"]
+            .iter()
+            .map(|name| ColumnInfo {
+                name: name.to_string(),
+                data_type: "bigint".to_string(),
+                is_nullable: false,
+                is_primary_key: false,
+                max_display_width: 10,
+                enum_labels: Vec::new(),
+                is_auto_increment: false,
+                generated: None,
+                default_value: None,
+                comment: None,
+            })
+            .collect();
+        tab.selected_col = 1;
+        tab.current_page = 3;
+
+        assert!(!tab.toggle_sort().unwrap().descending);
+        assert_eq!(tab.current_page, 0);
+        assert!(tab.toggle_sort().unwrap().descending);
+        assert_eq!(tab.sort.as_ref().unwrap().arrow(), "▼");
+        assert!(!tab.toggle_sort().unwrap().descending);
+
+        tab.selected_col = 0;
+        let sort = tab.toggle_sort().unwrap();
+        assert_eq!(sort.column, "id");
+        assert!(!sort.descending);
+    }
+
+    #[test]
+    fn test_marks_stay_in_one_column() {
+        let mut tab = TableTab::new("Query Result".to_string());
+        tab.columns = vec![ColumnInfo {
+            name: "id".to_string(),
+            data_type: "INTEGER".to_string(),
+            is_nullable: false,
+            is_primary_key: true,
+            max_display_width: 10,
+            enum_labels: Vec::new(),
+            is_auto_increment: false,
+            generated: None,
+            default_value: None,
+            comment: None,
+        }];
+        tab.columns.push(ColumnInfo {
+            name: "name".to_string(),
+            data_type: "TEXT".to_string(),
+            ..tab.columns[0].clone()
+        });
+        tab.rows = (1..=5)
+            .map(|n| vec![n.to_string(), format!("user{n}")])
+            .collect();
+
+        // Nothing marked: the selected cell
+        let (column, values) = tab.marked_values().unwrap();
+        assert_eq!(
+            (column.name.as_str(), values),
+            ("id", vec!["1".to_string()])
+        );
+
+        tab.toggle_mark();
+        tab.selected_row = 1;
+        tab.toggle_mark_range();
+        tab.selected_row = 3;
+        assert!(tab.is_marked(2, 0));
+        tab.toggle_mark();
+        assert_eq!(tab.mark_anchor, None);
+        tab.toggle_mark();
+        let (_, values) = tab.marked_values().unwrap();
+        assert_eq!(values, vec!["1", "2", "3"]);
+
+        // Marking in another column starts over
+        tab.selected_col = 1;
+        tab.toggle_mark();
+        let (column, values) = tab.marked_values().unwrap();
+        assert_eq!(
+            (column.name.as_str(), values),
+            ("name", vec!["user4".to_string()])
+        );
+    }
+
+    #[test]
+    fn test_result_sets_switch_in_place() {
+        let set = |column: &str, rows: usize| crate::database::QueryResult {
+            columns: vec![column.to_string()],
+            rows: (0..rows).map(|i| vec![i.to_string()]).collect(),
+            ..Default::default()
+        };
+        let mut result = set("first", 2);
+        result.more_results = vec![set("second", 5)];
+
+        let mut tab = TableTab::new("Query Result".to_string());
+        tab.set_query_result(result.clone());
+        assert_eq!(tab.result_sets.len(), 2);
+        assert_eq!(tab.columns[0].name, "first");
+        assert!(!tab.cycle_result_set(false));
+
+        assert!(tab.cycle_result_set(true));
+        assert_eq!(
+            (tab.columns[0].name.as_str(), tab.total_rows),
+            ("second", 5)
+        );
+        assert!(!tab.cycle_result_set(true));
+
+        // A refresh keeps showing the same set
+        tab.set_query_result(result);
+        assert_eq!(tab.columns[0].name, "second");
+
+        tab.set_query_result(set("only", 1));
+        assert!(tab.result_sets.is_empty());
+        assert_eq!(tab.result_set, 0);
+    }
+
+    #[test]
+    fn test_notices_stay_with_the_result() {
+        let mut result = crate::database::QueryResult {
+            columns: vec!["id".to_string()],
+            ..Default::default()
+        };
+        result.notices = vec![crate::database::ServerNotice::new(
+            "NOTICE",
+            "table \"audit\" does not exist, skipping",
+        )];
+
+        let mut tab = TableTab::new("Query Result".to_string());
+        tab.set_query_result(result);
+        assert_eq!(tab.notices.len(), 1);
+
+        // A refresh without notices clears them
+        tab.set_query_result(crate::database::QueryResult::default());
+        assert!(tab.notices.is_empty());
+    }
+
+    #[test]
+    fn test_large_json_cell_is_parsed_whole() {
+        use crate::database::{result::CappedCollector, ResultLimits, RowSink};
+
+        let items: Vec<String> = (0..5_000)
+            .map(|i| format!("{{\"id\":{i},\"name\":\"item {i}\"}}"))
+            .collect();
+        let document = format!("[{}]", items.join(","));
+        assert!(document.len() > ResultLimits::default().max_cell_bytes);
+
+        let mut collector = CappedCollector::new(ResultLimits::default());
+        collector.columns(&["doc".to_string()]).unwrap();
+        collector.row(vec![document.clone()]).unwrap();
+
+        let mut tab = TableTab::new("Query Result".to_string());
+        tab.set_query_result(collector.finish());
+        assert!(tab.json_view.is_some());
+        assert!(tab.cut_cells.contains(&(0, 0)));
+        assert_eq!(tab.full_cell_values.get(&(0, 0)), Some(&document));
+    }
+
+    #[test]
+    fn test_partition_window_follows_selection() {
+        assert_eq!(partition_window(12, Some(11), 50), (0, 12));
+        assert_eq!(partition_window(200, None, 50), (0, 50));
+        assert_eq!(partition_window(200, Some(10), 50), (0, 50));
+        assert_eq!(partition_window(200, Some(100), 50), (75, 125));
+        assert_eq!(partition_window(200, Some(199), 50), (150, 200));
+    }
+
+    /// A synthetic 500-column result, as a SELECT * on a wide table returns
+    fn wide_result_tab() -> TableTab {
+        let columns: Vec<String> = (1..=500).map(|n| format!("col{n:03}")).collect();
+        let mut tab = TableTab::new("Query Result".to_string());
+        tab.set_query_result(crate::database::QueryResult {
+            rows: (0..200)
+                .map(|row| {
+                    (0..columns.len())
+                        .map(|col| (row * col).to_string())
+                        .collect()
+                })
+                .collect(),
+            columns,
+            ..crate::database::QueryResult::default()
+        });
+        tab
+    }
+
+    #[test]
+    fn test_wide_result_draws_visible_columns_only() {
+        use ratatui::{backend::TestBackend, Terminal};
+
+        let mut tab = wide_result_tab();
+        let draw = |tab: &mut TableTab| {
+            let mut terminal = Terminal::new(TestBackend::new(120, 20)).unwrap();
+            terminal
+                .draw(|f| render_data_view(f, tab, f.area(), &Theme::default(), true))
+                .unwrap();
+            let buffer = terminal.backend().buffer();
+            buffer
+                .content()
+                .iter()
+                .map(|cell| cell.symbol())
+                .collect::<String>()
+        };
+
+        let screen = draw(&mut tab);
+        assert!(tab.calculate_visible_columns(120).len() < 10);
+        assert!(screen.contains("col001"));
+        assert!(!screen.contains("col050"));
+        assert!(screen.contains("column 1/500"));
+
+        tab.start_column_jump();
+        tab.update_column_jump("col4".to_string());
+        let screen = draw(&mut tab);
+        assert!(screen.contains("col400"));
+        assert!(screen.contains("column 400/500"));
+
+        tab.finish_column_jump(false);
+        assert_eq!(tab.selected_col, 0);
+        assert!(tab.column_jump.is_none());
+    }
+
+    /// Frame time of a 500-column result while moving across its columns.
+    /// A timing check rather than a test of behaviour, so it only runs when
+    /// asked: `cargo test --release wide_result_render_time -- --ignored`
+    #[test]
+    #[ignore = "timing check; run with --release and --ignored"]
+    fn test_wide_result_render_time() {
+        use ratatui::{backend::TestBackend, Terminal};
+        use std::time::{Duration, Instant};
+
+        let mut tab = wide_result_tab();
+        let mut terminal = Terminal::new(TestBackend::new(200, 50)).unwrap();
+        let theme = Theme::default();
+
+        let frames = 500;
+        let started = Instant::now();
+        for frame in 0..frames {
+            tab.selected_col = frame % tab.columns.len();
+            terminal
+                .draw(|f| render_data_view(f, &mut tab, f.area(), &theme, true))
+                .unwrap();
+        }
+        let per_frame = started.elapsed() / frames as u32;
+
+        eprintln!("500-column result: {per_frame:?} per frame");
+        // Well within a 60 fps frame
+        assert!(
+            per_frame < Duration::from_millis(4),
+            "{per_frame:?} per frame"
+        );
+    }
+
+    #[test]
+    fn test_group_thousands() {
+        assert_eq!(group_thousands(0), "0");
+        assert_eq!(group_thousands(999), "999");
+        assert_eq!(group_thousands(10_000), "10,000");
+        assert_eq!(group_thousands(1_234_567), "1,234,567");
+    }
+
+    #[test]
+    fn test_format_query_time() {
+        use std::time::Duration;
+        assert_eq!(format_query_time(Duration::from_nanos(420_500)), "420µs");
+        assert_eq!(format_query_time(Duration::from_nanos(800)), "<1µs");
+        assert_eq!(format_query_time(Duration::from_micros(128_900)), "128ms");
+        assert_eq!(format_query_time(Duration::from_millis(3_240)), "3.2s");
+        assert_eq!(format_query_time(Duration::from_millis(59_990)), "59.9s");
+        assert_eq!(format_query_time(Duration::from_secs(125)), "2m 05s");
+    }
+
+    #[test]
+    fn test_run_summary() {
+        let mut tab = TableTab::new("Query Result".to_string());
+        tab.set_query_result(crate::database::QueryResult {
+            columns: vec!["id".to_string()],
+            rows: vec![vec!["1".to_string()]; 42],
+            elapsed: Some(std::time::Duration::from_millis(128)),
+            ..Default::default()
+        });
+        assert_eq!(
+            tab.run_summary().as_deref(),
+            Some("42 rows returned in 128ms")
+        );
+
+        tab.set_query_result(crate::database::QueryResult {
+            affected_rows: Some(1),
+            elapsed: Some(std::time::Duration::from_millis(12)),
+            ..Default::default()
+        });
+        assert_eq!(tab.run_summary().as_deref(), Some("1 row affected in 12ms"));
+
+        tab.set_query_result(crate::database::QueryResult::default());
+        assert_eq!(tab.run_summary(), None);
+    }
+
+    #[test]
+    fn test_script_results_name_their_statement() {
+        let set = |position: usize, rows: usize, millis: u64| crate::database::QueryResult {
+            columns: vec!["n".to_string()],
+            rows: vec![vec!["1".to_string()]; rows],
+            elapsed: Some(std::time::Duration::from_millis(millis)),
+            statement: Some((position, 3)),
+            ..Default::default()
+        };
+        let mut script = set(1, 10, 5);
+        script.more_results = vec![set(3, 2, 40)];
+
+        let mut tab = TableTab::new("Query Result".to_string());
+        tab.set_query_result(script);
+        assert_eq!(
+            tab.run_summary().as_deref(),
+            Some("statement 1/3: 10 rows returned in 5ms")
+        );
+        assert!(tab.cycle_result_set(true));
+        assert_eq!(
+            tab.run_summary().as_deref(),
+            Some("statement 3/3: 2 rows returned in 40ms")
+        );
+
+        // The summary of the statements comes first
+        let mut summary = set(0, 3, 45);
+        summary.more_results = vec![set(1, 10, 5)];
+        tab.set_query_result(summary);
+        assert_eq!(tab.run_summary().as_deref(), Some("3 statements in 45ms"));
+        assert!(tab.cycle_result_set(true));
+        assert_eq!(tab.statement, Some((1, 3)));
+    }
+}
@@ -102,14 +102,71 @@ fn get_no_tables_message(state: &AppState) -> Vec<ListItem<'static>> {
         "No connection selected"
     };
 
-    vec![ListItem::new(Line::from(vec![Span::styled(
+    let mut items = vec![ListItem::new(Line::from(vec![Span::styled(
         message,
         Style::default().fg(if message.contains("failed") {
             Color::Red
         } else {
             Color::Yellow
         }),
-    )]))]
+    )]))];
+    items.extend(get_restricted_user_hint(state));
+    if let Some(prompt) = get_database_prompt(&state.ui) {
+        items.push(ListItem::new(""));
+        items.push(prompt);
+    }
+    items
+}
+
+/// Say why a connected server may list nothing, as a locked-down user
+/// isn't allowed to see the tables of the database they landed in, and how
+/// to name another
+fn get_restricted_user_hint(state: &AppState) -> Vec<ListItem<'static>> {
+    let Some(connection) = state
+        .get_selected_connection()
+        .filter(|connection| connection.is_connected())
+        .filter(|connection| connection.database_type != crate::database::DatabaseType::SQLite)
+    else {
+        return Vec::new();
+    };
+    let reason = format!(
+        "Your user may not see the tables of '{}'.",
+        connection.database_or_default()
+    );
+    let muted = Style::default().fg(Color::Gray);
+    vec![
+        ListItem::new(""),
+        ListItem::new(Line::from(Span::styled(reason, muted))),
+        ListItem::new(Line::from(vec![
+            Span::styled("Press ", muted),
+            Span::styled(
+                "u",
+                Style::default()
+                    .fg(Color::Cyan)
+                    .add_modifier(Modifier::BOLD),
+            ),
+            Span::styled(" to enter a database name", muted),
+        ])),
+    ]
+}
+
+/// The prompt for a database name to use, when open
+fn get_database_prompt(ui_state: &crate::state::ui::UIState) -> Option<ListItem<'static>> {
+    let name = ui_state.database_prompt.as_ref()?;
+    Some(ListItem::new(Line::from(vec![
+        Span::styled(
+            "Use database: ",
+            Style::default()
+                .fg(Color::Cyan)
+                .add_modifier(Modifier::BOLD),
+        ),
+        Span::styled(
+            format!("{name}_"),
+            Style::default()
+                .fg(Color::White)
+                .add_modifier(Modifier::UNDERLINED),
+        ),
+    ])))
 }
 
 /// Get list items from selectable table items
@@ -132,9 +189,15 @@ fn get_selectable_items_list(
             } else {
                 Color::DarkGray
             };
+            // System schemas and tables are dimmed so they stand apart
+            let style = if item.is_system {
+                Style::default().fg(text_color).add_modifier(Modifier::DIM)
+            } else {
+                Style::default().fg(text_color)
+            };
             items.push(ListItem::new(Line::from(vec![Span::styled(
                 item.display_name.clone(),
-                Style::default().fg(text_color),
+                style,
             )])));
         } else {
             // Group header
@@ -171,6 +234,30 @@ fn get_selectable_items_list(
         ])));
     }
 
+    // Show the column search prompt
+    if ui_state.column_search_active {
+        items.push(ListItem::new(""));
+        items.push(ListItem::new(Line::from(vec![
+            Span::styled(
+                "Find column: ",
+                Style::default()
+                    .fg(Color::Cyan)
+                    .add_modifier(Modifier::BOLD),
+            ),
+            Span::styled(
+                format!("{}_", ui_state.column_search_query),
+                Style::default()
+                    .fg(Color::White)
+                    .add_modifier(Modifier::UNDERLINED),
+            ),
+        ])));
+    }
+
+    if let Some(prompt) = get_database_prompt(ui_state) {
+        items.push(ListItem::new(""));
+        items.push(prompt);
+    }
+
     items
 }
 
@@ -207,6 +294,9 @@ fn get_adaptive_title(
         if !counts.is_empty() {
             title_parts.push(counts.join(", "));
         }
+        if ui_state.show_system_objects {
+            title_parts.push("+system".to_string());
+        }
 
         let base_title = if !title_parts.is_empty() {
             format!(" [2] Tables/Views ({}) ", title_parts.join(" | "))
@@ -324,12 +414,14 @@ mod tests {
                 row_count: None,
                 size_bytes: None,
                 comment: None,
+                system: false,
             }],
             views: vec![],
             materialized_views: vec![],
             foreign_tables: vec![],
             total_count: 1,
             error: None,
+            default_schema: None,
         };
 
         let db_state = crate::state::DatabaseState::new().await;
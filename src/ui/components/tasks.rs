@@ -0,0 +1,102 @@
+// FilePath: src/ui/components/tasks.rs
+
+//! Overlay listing the operations running in the background, how long each
+//! has run, and which can be stopped
+
+#![forbid(unsafe_code)]
+
+use crate::ui::components::spinner::{operation, Spinner};
+use crate::ui::theme::Theme;
+use ratatui::{
+    layout::{Alignment, Rect},
+    style::{Modifier, Style},
+    text::{Line, Span},
+    widgets::{Block, Borders, Clear, Paragraph},
+    Frame,
+};
+use std::time::Duration;
+
+/// Elapsed time as `12s` or `3m 05s`
+fn format_elapsed(elapsed: Duration) -> String {
+    let secs = elapsed.as_secs();
+    if secs < 60 {
+        format!("{secs}s")
+    } else {
+        format!("{}m {:02}s", secs / 60, secs % 60)
+    }
+}
+
+/// Render the running operations as a centered popup, the selected one
+/// highlighted
+pub fn render_tasks(f: &mut Frame, spinner: &Spinner, selected: usize, area: Rect, theme: &Theme) {
+    let text = Style::default().fg(theme.get_color("text_primary"));
+    let muted = Style::default().fg(theme.get_color("text_muted"));
+    let active = Style::default()
+        .fg(theme.get_color("accent"))
+        .add_modifier(Modifier::BOLD);
+
+    let tasks = spinner.tasks();
+    let mut lines: Vec<Line> = tasks
+        .iter()
+        .enumerate()
+        .map(|(idx, (label, elapsed))| {
+            let focused = idx == selected;
+            Line::from(vec![
+                Span::styled(if focused { "▶ " } else { "  " }, active),
+                Span::styled(
+                    format!("{:<24}", spinner.describe(label)),
+                    if focused { active } else { text },
+                ),
+                Span::styled(format!("{:>8}", format_elapsed(*elapsed)), muted),
+                Span::styled(
+                    if operation::can_cancel(label) {
+                        ""
+                    } else {
+                        "  (can't be stopped)"
+                    },
+                    muted,
+                ),
+            ])
+        })
+        .collect();
+    if tasks.is_empty() {
+        lines.push(Line::from(Span::styled("Nothing is running", muted)));
+    }
+    lines.push(Line::from(""));
+    lines.push(Line::from(Span::styled(
+        "j/k select · x stop · Esc close",
+        muted,
+    )));
+
+    let width = 56u16.min(area.width.saturating_sub(4));
+    let height = (lines.len() as u16 + 2).min(area.height);
+    let popup = Rect {
+        x: area.x + area.width.saturating_sub(width) / 2,
+        y: area.y + area.height.saturating_sub(height) / 2,
+        width,
+        height,
+    };
+    f.render_widget(Clear, popup);
+    let block = Block::default()
+        .borders(Borders::ALL)
+        .title(format!(" {} Background tasks ", spinner.frame()))
+        .title_alignment(Alignment::Center)
+        .border_style(
+            Style::default()
+                .fg(theme.get_color("accent"))
+                .add_modifier(Modifier::BOLD),
+        )
+        .style(Style::default().bg(theme.get_color("background")));
+    f.render_widget(Paragraph::new(lines).block(block), popup);
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_format_elapsed() {
+        assert_eq!(format_elapsed(Duration::from_millis(4_900)), "4s");
+        assert_eq!(format_elapsed(Duration::from_secs(185)), "3m 05s");
+    }
+}
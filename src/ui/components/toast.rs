@@ -2,7 +2,7 @@
 
 #![forbid(unsafe_code)]
 
-use crate::ui::theme::Theme;
+use crate::{core::redact::scrub_secrets, ui::theme::Theme};
 use ratatui::{
     layout::{Alignment, Rect},
     style::{Color, Modifier, Style},
@@ -31,10 +31,12 @@ pub struct Toast {
 }
 
 impl Toast {
-    /// Create a new toast notification
+    /// Create a new toast notification. Credentials a driver error may have
+    /// echoed are masked
     pub fn new(message: impl Into<String>, toast_type: ToastType) -> Self {
+        let message = message.into();
         Self {
-            message: message.into(),
+            message: scrub_secrets(&message).into_owned(),
             toast_type,
             created_at: Instant::now(),
             duration: Duration::from_secs(3), // Default 3 seconds
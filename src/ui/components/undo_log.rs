@@ -0,0 +1,78 @@
+// FilePath: src/ui/components/undo_log.rs
+
+//! The undo log overlay: the session's grid edits, deletes and inserts,
+//! newest first, to pick one and run the statement reversing it
+
+#![forbid(unsafe_code)]
+
+use crate::database::UndoLog;
+use crate::ui::theme::Theme;
+use ratatui::{
+    layout::{Alignment, Rect},
+    style::{Modifier, Style},
+    text::{Line, Span},
+    widgets::{Block, Borders, Clear, Paragraph},
+    Frame,
+};
+
+/// Draw the undo log over `area`
+pub fn render_undo_log(f: &mut Frame, log: &UndoLog, area: Rect, theme: &Theme) {
+    let text = Style::default().fg(theme.get_color("text_primary"));
+    let muted = Style::default().fg(theme.get_color("text_muted"));
+    let active = Style::default()
+        .fg(theme.get_color("accent"))
+        .add_modifier(Modifier::BOLD);
+
+    let mut lines: Vec<Line> = log
+        .entries()
+        .iter()
+        .enumerate()
+        .rev()
+        .map(|(idx, entry)| {
+            let focused = idx == log.selected;
+            Line::from(vec![
+                Span::styled(if focused { "▶ " } else { "  " }, active),
+                Span::styled(entry.recorded_at.format("%H:%M:%S  ").to_string(), muted),
+                Span::styled(
+                    entry.description.clone(),
+                    if entry.applied {
+                        muted.add_modifier(Modifier::CROSSED_OUT)
+                    } else if focused {
+                        active
+                    } else {
+                        text
+                    },
+                ),
+                Span::styled(if entry.applied { "  undone" } else { "" }, muted),
+            ])
+        })
+        .collect();
+    if lines.is_empty() {
+        lines.push(Line::from(Span::styled(
+            "Nothing to undo; grid edits, deletes and inserts are listed here",
+            muted,
+        )));
+    }
+    lines.push(Line::from(""));
+    lines.push(Line::from(Span::styled(
+        "j/k select · Enter undo (asks first) · Esc close",
+        muted,
+    )));
+
+    let width = 76.min(area.width.saturating_sub(4));
+    let height = (lines.len() as u16 + 2).min(area.height);
+    let popup = Rect {
+        x: area.x + area.width.saturating_sub(width) / 2,
+        y: area.y + area.height.saturating_sub(height) / 2,
+        width,
+        height,
+    };
+    f.render_widget(Clear, popup);
+    let block = Block::default()
+        .borders(Borders::ALL)
+        .title(" Undo log ")
+        .title_alignment(Alignment::Center)
+        .border_style(active)
+        .style(Style::default().bg(theme.get_color("background")));
+    f.render_widget(Paragraph::new(lines).block(block), popup);
+}
@@ -0,0 +1,170 @@
+// FilePath: src/ui/components/welcome.rs
+
+#![forbid(unsafe_code)]
+
+use crate::{database::DatabaseType, ui::theme::Theme};
+use ratatui::{
+    layout::{Alignment, Constraint, Direction, Layout, Rect},
+    style::{Modifier, Style},
+    text::{Line, Span},
+    widgets::{Block, Borders, Clear, List, ListItem, ListState, Paragraph, Wrap},
+    Frame,
+};
+
+/// Drivers offered by the wizard, in the same order as the connection form's
+/// database type dropdown so the choice can be handed over by index
+pub const WIZARD_DRIVERS: [(DatabaseType, &str, &str); 4] = [
+    (
+        DatabaseType::PostgreSQL,
+        "PostgreSQL",
+        "Host and credentials, or a postgres:// URL",
+    ),
+    (
+        DatabaseType::MySQL,
+        "MySQL",
+        "Host and credentials, or a mysql:// URL",
+    ),
+    (
+        DatabaseType::MariaDB,
+        "MariaDB",
+        "Host and credentials, or a mysql:// URL",
+    ),
+    (DatabaseType::SQLite, "SQLite", "Path to a database file"),
+];
+
+/// First-run setup wizard: pick a driver, then fill in and test the
+/// connection in the regular connection form, then connect to it
+#[derive(Debug, Clone, Default)]
+pub struct FirstRunWizard {
+    /// Index into WIZARD_DRIVERS
+    pub selected_driver: usize,
+}
+
+impl FirstRunWizard {
+    /// Create a wizard with the first driver selected
+    pub fn new() -> Self {
+        Self::default()
+    }
+
+    /// Move the driver selection down, wrapping around
+    pub fn next_driver(&mut self) {
+        self.selected_driver = (self.selected_driver + 1) % WIZARD_DRIVERS.len();
+    }
+
+    /// Move the driver selection up, wrapping around
+    pub fn previous_driver(&mut self) {
+        self.selected_driver =
+            (self.selected_driver + WIZARD_DRIVERS.len() - 1) % WIZARD_DRIVERS.len();
+    }
+
+    /// Render the driver selection step as a full-screen overlay
+    pub fn render(&self, frame: &mut Frame, area: Rect, theme: &Theme) {
+        frame.render_widget(Clear, area);
+
+        let block = Block::default()
+            .borders(Borders::ALL)
+            .title(" Welcome to LazyTables ")
+            .title_alignment(Alignment::Center)
+            .border_style(Style::default().fg(theme.get_color("active_border")))
+            .style(
+                Style::default()
+                    .bg(theme.get_color("background"))
+                    .fg(theme.get_color("foreground")),
+            );
+        let inner = block.inner(area);
+        frame.render_widget(block, area);
+
+        let chunks = Layout::default()
+            .direction(Direction::Vertical)
+            .margin(2)
+            .constraints([
+                Constraint::Length(4), // Intro
+                Constraint::Min(6),    // Driver list
+                Constraint::Length(2), // Key hints
+            ])
+            .split(inner);
+
+        let intro = Paragraph::new(vec![
+            Line::from(Span::styled(
+                "Let's set up your first connection.",
+                Style::default()
+                    .fg(theme.get_color("primary_highlight"))
+                    .add_modifier(Modifier::BOLD),
+            )),
+            Line::from(""),
+            Line::from(Span::styled(
+                "Pick a database driver. Next you'll fill in the connection details, \
+                 test them with 't' and save with 's'; LazyTables connects right away.",
+                Style::default().fg(theme.get_color("text_secondary")),
+            )),
+        ])
+        .wrap(Wrap { trim: true });
+        frame.render_widget(intro, chunks[0]);
+
+        let items: Vec<ListItem> = WIZARD_DRIVERS
+            .iter()
+            .map(|(_, name, description)| {
+                ListItem::new(Line::from(vec![
+                    Span::styled(
+                        format!("{name:<12}"),
+                        Style::default().add_modifier(Modifier::BOLD),
+                    ),
+                    Span::styled(
+                        *description,
+                        Style::default().fg(theme.get_color("text_muted")),
+                    ),
+                ]))
+            })
+            .collect();
+        let list = List::new(items)
+            .block(
+                Block::default()
+                    .borders(Borders::ALL)
+                    .title(" Driver ")
+                    .border_style(Style::default().fg(theme.get_color("border"))),
+            )
+            .highlight_style(
+                Style::default()
+                    .bg(theme.get_color("selection_bg"))
+                    .add_modifier(Modifier::BOLD),
+            )
+            .highlight_symbol("▶ ");
+        let mut list_state = ListState::default();
+        list_state.select(Some(self.selected_driver));
+        frame.render_stateful_widget(list, chunks[1], &mut list_state);
+
+        let hints = Paragraph::new(Line::from(vec![
+            Span::styled("j/k", Style::default().fg(theme.get_color("warning"))),
+            Span::raw(" choose  •  "),
+            Span::styled("Enter", Style::default().fg(theme.get_color("warning"))),
+            Span::raw(" continue  •  "),
+            Span::styled("Esc", Style::default().fg(theme.get_color("danger"))),
+            Span::raw(" skip setup"),
+        ]))
+        .alignment(Alignment::Center);
+        frame.render_widget(hints, chunks[2]);
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_driver_selection_wraps() {
+        let mut wizard = FirstRunWizard::new();
+        wizard.previous_driver();
+        assert_eq!(wizard.selected_driver, WIZARD_DRIVERS.len() - 1);
+        wizard.next_driver();
+        assert_eq!(wizard.selected_driver, 0);
+    }
+
+    #[test]
+    fn test_drivers_match_connection_form_order() {
+        for (index, (database_type, _, _)) in WIZARD_DRIVERS.iter().enumerate() {
+            let mut form = crate::ui::components::ConnectionModalState::new();
+            form.select_database_type(index);
+            assert_eq!(&form.database_type, database_type);
+        }
+    }
+}
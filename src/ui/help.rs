@@ -12,13 +12,34 @@ use ratatui::{
 
 use crate::app::state::HelpMode;
 
+/// A command listed in the help, with the scope it works in
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct HelpEntry {
+    pub scope: &'static str,
+    pub key: String,
+    pub description: String,
+}
+
+/// Lines of a help column, and the commands among them for searching
+#[derive(Default)]
+struct HelpLines {
+    lines: Vec<Line<'static>>,
+    commands: Vec<(String, String)>,
+}
+
+impl HelpLines {
+    fn push(&mut self, line: Line<'static>) {
+        self.lines.push(line);
+    }
+}
+
 /// Help content for each pane
 pub struct HelpSystem;
 
 impl HelpSystem {
     /// Create the left column content (current pane + global)
     pub fn create_left_column(mode: HelpMode) -> Vec<Line<'static>> {
-        let mut lines = vec![];
+        let mut lines = HelpLines::default();
 
         // Current pane header
         let pane_name = match mode {
@@ -59,30 +80,41 @@ impl HelpSystem {
                 .add_modifier(Modifier::BOLD | Modifier::UNDERLINED),
         )]));
         lines.push(Line::from(""));
+        Self::add_global_commands(&mut lines);
 
-        // Global commands
-        Self::add_command(&mut lines, "q", "Quit LazyTables");
-        Self::add_command(&mut lines, "?", "Toggle help");
-        Self::add_command(&mut lines, "C-B", "Toggle debug view");
-        lines.push(Line::from(""));
-        Self::add_command(&mut lines, "1-6", "Jump to pane (by number)");
-        Self::add_command(&mut lines, "Tab", "Next pane");
-        Self::add_command(&mut lines, "S-Tab", "Previous pane");
+        lines.lines
+    }
 
-        lines
+    /// Global commands listed under the current pane's
+    fn add_global_commands(lines: &mut HelpLines) {
+        Self::add_command(lines, "q", "Quit LazyTables");
+        Self::add_command(lines, "?", "Toggle help");
+        Self::add_command(lines, "/", "Search the help (in help)");
+        Self::add_command(lines, "C-B", "Toggle debug view");
+        Self::add_command(lines, "C-T", "Background tasks");
+        Self::add_command(lines, "M-O", "Open the last export's folder");
+        lines.push(Line::from(""));
+        Self::add_command(lines, "1-6", "Jump to pane (by number)");
+        Self::add_command(lines, "Tab", "Next pane");
+        Self::add_command(lines, "S-Tab", "Previous pane");
+        Self::add_command(lines, "C-O/C-I", "Back/forward through tables opened");
     }
 
     /// Create the right column content (global commands)
     pub fn create_right_column(_current_mode: HelpMode) -> Vec<Line<'static>> {
-        let mut lines = vec![
-            Line::from(vec![Span::styled(
-                "🌐 Global Commands",
-                Style::default()
-                    .fg(Color::Rgb(255, 150, 200))
-                    .add_modifier(Modifier::BOLD | Modifier::UNDERLINED),
-            )]),
-            Line::from(""),
-        ];
+        let mut lines = HelpLines::default();
+        Self::add_right_column(&mut lines);
+        lines.lines
+    }
+
+    fn add_right_column(lines: &mut HelpLines) {
+        lines.push(Line::from(vec![Span::styled(
+            "🌐 Global Commands",
+            Style::default()
+                .fg(Color::Rgb(255, 150, 200))
+                .add_modifier(Modifier::BOLD | Modifier::UNDERLINED),
+        )]));
+        lines.push(Line::from(""));
 
         // Application-level commands
         lines.push(Line::from(vec![Span::styled(
@@ -92,9 +124,9 @@ impl HelpSystem {
                 .add_modifier(Modifier::BOLD | Modifier::UNDERLINED),
         )]));
         lines.push(Line::from(""));
-        Self::add_command(&mut lines, "q", "Quit LazyTables");
-        Self::add_command(&mut lines, "?", "Toggle help guide");
-        Self::add_command(&mut lines, "C-B", "Toggle debug view");
+        Self::add_command(lines, "q", "Quit LazyTables");
+        Self::add_command(lines, "?", "Toggle help guide");
+        Self::add_command(lines, "C-B", "Toggle debug view");
         lines.push(Line::from(""));
 
         // Navigation commands
@@ -105,15 +137,15 @@ impl HelpSystem {
                 .add_modifier(Modifier::BOLD | Modifier::UNDERLINED),
         )]));
         lines.push(Line::from(""));
-        Self::add_command(&mut lines, "1", "[1] Connections pane");
-        Self::add_command(&mut lines, "2", "[2] Tables pane");
-        Self::add_command(&mut lines, "3", "[3] Table Details pane");
-        Self::add_command(&mut lines, "4", "[4] Query Results pane");
-        Self::add_command(&mut lines, "5", "[5] SQL Query Editor pane");
-        Self::add_command(&mut lines, "6", "[6] SQL Files pane");
+        Self::add_command(lines, "1", "[1] Connections pane");
+        Self::add_command(lines, "2", "[2] Tables pane");
+        Self::add_command(lines, "3", "[3] Table Details pane");
+        Self::add_command(lines, "4", "[4] Query Results pane");
+        Self::add_command(lines, "5", "[5] SQL Query Editor pane");
+        Self::add_command(lines, "6", "[6] SQL Files pane");
         lines.push(Line::from(""));
-        Self::add_command(&mut lines, "Tab", "Next pane");
-        Self::add_command(&mut lines, "S-Tab", "Previous pane");
+        Self::add_command(lines, "Tab", "Next pane");
+        Self::add_command(lines, "S-Tab", "Previous pane");
         lines.push(Line::from(""));
 
         // Data operations
@@ -124,10 +156,12 @@ impl HelpSystem {
                 .add_modifier(Modifier::BOLD | Modifier::UNDERLINED),
         )]));
         lines.push(Line::from(""));
-        Self::add_command(&mut lines, "C-Enter", "Execute SQL at cursor");
-        Self::add_command(&mut lines, "C-S", "Save current query");
-        Self::add_command(&mut lines, "C-O", "Refresh current view");
-        Self::add_command(&mut lines, "C-N", "New timestamped query");
+        Self::add_command(lines, "C-Enter", "Execute SQL at cursor");
+        Self::add_command(lines, "R", "Run every statement in the editor");
+        Self::add_command(lines, "C-x", "Cancel running query on the server");
+        Self::add_command(lines, "C-c", "Stop fetching rows of running query");
+        Self::add_command(lines, "C-S", "Save current query");
+        Self::add_command(lines, "C-N", "New timestamped query");
         lines.push(Line::from(""));
 
         // Quick reference
@@ -158,12 +192,49 @@ impl HelpSystem {
             Span::styled("• ", Style::default().fg(Color::Rgb(100, 220, 180))),
             Span::raw("All changes require connection to database"),
         ]));
+    }
 
-        lines
+    /// Commands of every scope whose key or description contains `query`,
+    /// ignoring case
+    pub fn search(query: &str) -> Vec<HelpEntry> {
+        let scopes: [(&'static str, fn(&mut HelpLines)); 7] = [
+            ("global", |lines| {
+                Self::add_global_commands(lines);
+                Self::add_right_column(lines);
+            }),
+            ("connections", Self::add_connections_commands),
+            ("tables", Self::add_tables_commands),
+            ("details", Self::add_details_commands),
+            ("results", Self::add_tabular_commands),
+            ("query", Self::add_query_window_commands),
+            ("sql files", Self::add_sql_files_commands),
+        ];
+        let query = query.to_lowercase();
+        let mut entries: Vec<HelpEntry> = Vec::new();
+        for (scope, add) in scopes {
+            let mut lines = HelpLines::default();
+            add(&mut lines);
+            for (key, description) in lines.commands {
+                let matches = key.to_lowercase().contains(&query)
+                    || description.to_lowercase().contains(&query);
+                let listed = entries.iter().any(|entry| {
+                    entry.scope == scope && entry.key == key && entry.description == description
+                });
+                if matches && !listed {
+                    entries.push(HelpEntry {
+                        scope,
+                        key,
+                        description,
+                    });
+                }
+            }
+        }
+        entries
     }
 
     /// Helper to add a command line with proper formatting
-    fn add_command(lines: &mut Vec<Line<'static>>, key: &str, desc: &str) {
+    fn add_command(lines: &mut HelpLines, key: &str, desc: &str) {
+        lines.commands.push((key.to_string(), desc.to_string()));
         lines.push(Line::from(vec![
             Span::raw("  "),
             Span::styled(
@@ -179,11 +250,14 @@ impl HelpSystem {
         ]));
     }
 
-    fn add_connections_commands(lines: &mut Vec<Line<'static>>) {
+    fn add_connections_commands(lines: &mut HelpLines) {
         // Basic Navigation
         Self::add_command(lines, "j/k", "Navigate up/down connections");
         Self::add_command(lines, "Enter/Space", "Connect to selected database");
+        Self::add_command(lines, "Esc", "Stop connecting");
         Self::add_command(lines, "x", "Disconnect current connection");
+        Self::add_command(lines, "t", "Test connection (log in, then close)");
+        Self::add_command(lines, "n", "Watch LISTEN/NOTIFY (PostgreSQL)");
         lines.push(Line::from(""));
 
         // Connection Management
@@ -196,6 +270,8 @@ impl HelpSystem {
         Self::add_command(lines, "a", "Add new connection");
         Self::add_command(lines, "e", "Edit selected connection");
         Self::add_command(lines, "d", "Delete connection (with confirmation)");
+        Self::add_command(lines, "y", "Copy psql/mysql command (no password)");
+        Self::add_command(lines, "o", "Open in psql/mysql, back on exit");
         lines.push(Line::from(""));
 
         // Search Functions
@@ -271,12 +347,13 @@ impl HelpSystem {
         ]));
     }
 
-    fn add_tables_commands(lines: &mut Vec<Line<'static>>) {
+    fn add_tables_commands(lines: &mut HelpLines) {
         // Basic Navigation
         Self::add_command(lines, "j/k", "Navigate up/down tables");
         Self::add_command(lines, "gg/G", "Jump to first/last table");
         Self::add_command(lines, "C-d/C-u", "Page down/up (half page)");
         Self::add_command(lines, "Enter/Space", "Open table for viewing");
+        Self::add_command(lines, "i", "Inspect columns, keys and indexes");
         Self::add_command(lines, "Tab", "Toggle group expansion (on headers)");
         lines.push(Line::from(""));
 
@@ -301,6 +378,9 @@ impl HelpSystem {
         Self::add_command(lines, "ESC", "Exit search mode");
         Self::add_command(lines, "↑/↓", "Navigate search results");
         Self::add_command(lines, "Enter", "Open selected search result");
+        Self::add_command(lines, "c", "Find a column name in every table");
+        Self::add_command(lines, "u", "Enter a database name to use");
+        Self::add_command(lines, ".", "Show/hide system schemas and tables");
         lines.push(Line::from(""));
 
         // Database Objects Info
@@ -357,7 +437,7 @@ impl HelpSystem {
         ]));
     }
 
-    fn add_details_commands(lines: &mut Vec<Line<'static>>) {
+    fn add_details_commands(lines: &mut HelpLines) {
         // Basic Navigation
         Self::add_command(lines, "j/k", "Scroll up/down");
         Self::add_command(lines, "↑/↓", "Scroll up/down (arrows)");
@@ -393,7 +473,7 @@ impl HelpSystem {
         lines.push(Line::from(Span::raw("• No table selected")));
     }
 
-    fn add_tabular_commands(lines: &mut Vec<Line<'static>>) {
+    fn add_tabular_commands(lines: &mut HelpLines) {
         // Basic Navigation
         lines.push(Line::from(vec![Span::styled(
             "🧭 Table Navigation",
@@ -405,7 +485,9 @@ impl HelpSystem {
         Self::add_command(lines, "Arrow Keys", "Alternative cell navigation");
         Self::add_command(lines, "gg/G", "Jump to first/last row");
         Self::add_command(lines, "0/$", "Jump to first/last column");
+        Self::add_command(lines, "c", "Jump to a column by name");
         Self::add_command(lines, "Ctrl+D/U", "Page down/up through data");
+        Self::add_command(lines, "n/p", "Fetch next/previous page of a table");
         lines.push(Line::from(""));
 
         // Cell Editing
@@ -430,6 +512,18 @@ impl HelpSystem {
         )]));
         Self::add_command(lines, "/", "Start search mode");
         Self::add_command(lines, "n/N", "Navigate to next/previous match");
+        Self::add_command(lines, "f", "Filter table rows (column, operator, value)");
+        Self::add_command(lines, "F", "Clear table filters");
+        Self::add_command(lines, "e", "Export rows, or Schema view columns");
+        Self::add_command(lines, "m", "Pin result under a name");
+        Self::add_command(lines, "'", "Open a pinned result");
+        Self::add_command(lines, "o", "Sort table by column on the server (asc/desc)");
+        Self::add_command(lines, "a", "Insert a row (form from the table's columns)");
+        Self::add_command(
+            lines,
+            "A",
+            "Duplicate the selected row into the insert form",
+        );
         Self::add_command(lines, "ESC", "Exit search mode");
         lines.push(Line::from(""));
 
@@ -442,6 +536,11 @@ impl HelpSystem {
         )]));
         Self::add_command(lines, "dd", "Delete current row (with confirmation)");
         Self::add_command(lines, "yy", "Copy row data to clipboard (CSV format)");
+        Self::add_command(lines, "yj", "Copy row as a JSON object with typed values");
+        Self::add_command(lines, "Y", "Copy row as tab separated values");
+        Self::add_command(lines, "Ctrl+Y", "Copy all loaded rows as TSV with a header");
+        Self::add_command(lines, "B", "Save a bytea/blob cell to a file, with SHA-256");
+        Self::add_command(lines, "U", "Undo log of grid edits, deletes and inserts");
         lines.push(Line::from(""));
 
         // View Controls
@@ -452,7 +551,17 @@ impl HelpSystem {
                 .add_modifier(Modifier::BOLD | Modifier::UNDERLINED),
         )]));
         Self::add_command(lines, "t", "Toggle between Data and Schema view");
+        Self::add_command(lines, "p/P", "Select next/previous partition (Schema view)");
+        Self::add_command(lines, "Enter", "Preview selected partition (Schema view)");
+        Self::add_command(lines, "J", "Toggle JSON document or plan / raw grid");
+        Self::add_command(lines, "Enter", "Jump to the costliest plan node");
         Self::add_command(lines, "r", "Refresh/reload current table data");
+        Self::add_command(lines, "w", "Watch query result (re-run every few seconds)");
+        Self::add_command(lines, "s", "Column statistics over loaded rows");
+        Self::add_command(lines, "Space/v", "Mark cell / mark a range in the column");
+        Self::add_command(lines, "W/I", "Copy / insert into query: col IN (marked)");
+        Self::add_command(lines, "Esc", "Clear marked cells");
+        Self::add_command(lines, "[ / ]", "Previous/next result set");
         lines.push(Line::from(""));
 
         // Tab Management
@@ -487,6 +596,9 @@ impl HelpSystem {
         lines.push(Line::from(vec![Span::raw(
             "      • Indexes (type, uniqueness, size)",
         )]));
+        lines.push(Line::from(vec![Span::raw(
+            "      • Partitions (bounds, row estimates)",
+        )]));
         lines.push(Line::from(vec![Span::raw(
             "      • Foreign keys (relationships, ON DELETE/UPDATE)",
         )]));
@@ -499,7 +611,7 @@ impl HelpSystem {
         lines.push(Line::from(""));
     }
 
-    fn add_sql_files_commands(lines: &mut Vec<Line<'static>>) {
+    fn add_sql_files_commands(lines: &mut HelpLines) {
         // Basic Navigation
         Self::add_command(lines, "j/k", "Navigate up/down files");
         Self::add_command(lines, "Enter/Space", "Load selected SQL file");
@@ -587,7 +699,7 @@ impl HelpSystem {
         )]));
         lines.push(Line::from(vec![
             Span::styled("  📂 ", Style::default().fg(Color::Cyan)),
-            Span::raw("Files stored in ~/.lazytables/sql_files/"),
+            Span::raw("Files stored in the data directory under sql_files/"),
         ]));
         lines.push(Line::from(vec![
             Span::styled("  📊 ", Style::default().fg(Color::Blue)),
@@ -620,7 +732,7 @@ impl HelpSystem {
         ]));
     }
 
-    fn add_query_window_commands(lines: &mut Vec<Line<'static>>) {
+    fn add_query_window_commands(lines: &mut HelpLines) {
         // Query Execution
         lines.push(Line::from(vec![Span::styled(
             "⚡ Query Execution",
@@ -629,6 +741,7 @@ impl HelpSystem {
                 .add_modifier(Modifier::BOLD | Modifier::UNDERLINED),
         )]));
         Self::add_command(lines, "Ctrl+Enter", "Execute query at cursor position");
+        Self::add_command(lines, "Ctrl+R", "Pick a query from the history");
         lines.push(Line::from(""));
 
         // Query Mode Navigation & Editing
@@ -683,6 +796,9 @@ impl HelpSystem {
         ]));
         Self::add_command(lines, "Enter", "Insert new line");
         Self::add_command(lines, "Backspace", "Delete character before cursor");
+        Self::add_command(lines, "C-A/C-E", "Line start/Line end");
+        Self::add_command(lines, "M-B/M-F", "Word back/Word forward");
+        Self::add_command(lines, "C-W/C-U", "Delete word/line before cursor");
         Self::add_command(lines, "←/→/↑/↓", "Move cursor in insert mode");
         lines.push(Line::from(""));
 
@@ -696,7 +812,8 @@ impl HelpSystem {
                 .fg(Color::Rgb(255, 200, 100))
                 .add_modifier(Modifier::BOLD | Modifier::UNDERLINED),
         )]));
-        Self::add_command(lines, "Ctrl+S", "Save query to current file");
+        Self::add_command(lines, "Ctrl+S", "Save query (asks a name for a new one)");
+        Self::add_command(lines, ":w <name>", "Save query as a named file");
         Self::add_command(lines, "Ctrl+O", "Refresh SQL file list");
         Self::add_command(lines, "Ctrl+N", "Create new timestamped query");
         lines.push(Line::from(""));
@@ -788,6 +905,42 @@ impl HelpSystem {
             ])
             .split(inner_area);
 
+        let searching = ui_state.help_filter_active || !ui_state.help_filter.is_empty();
+        if searching {
+            Self::render_search(f, ui_state, main_layout[1]);
+        } else {
+            Self::render_columns(f, ui_state, main_layout[1]);
+        }
+
+        // Add elegant footer with instructions
+        let hint = if ui_state.help_filter_active {
+            "💡 Type to filter • Enter to scroll the matches • Esc to clear"
+        } else if searching {
+            "💡 Press ? to close • / to change the filter • Esc to clear • ↑/↓ or j/k to scroll"
+        } else {
+            "💡 Press ? to close • / to search • ←/→ or Tab to switch panes • ↑/↓ or j/k to scroll • PgUp/PgDown for faster scrolling"
+        };
+        let footer_text = format!("{hint}\n{}", crate::version::short());
+        let footer = Paragraph::new(footer_text)
+            .style(
+                Style::default()
+                    .fg(Color::Rgb(140, 160, 200))
+                    .add_modifier(Modifier::ITALIC),
+            )
+            .alignment(Alignment::Center)
+            .block(
+                Block::default()
+                    .borders(Borders::TOP)
+                    .border_style(Style::default().fg(Color::Rgb(80, 100, 150)))
+                    .style(Style::default().bg(Color::Rgb(12, 15, 18))),
+            );
+
+        f.render_widget(footer, main_layout[2]);
+    }
+
+    /// Render the current pane's and the global commands side by side
+    fn render_columns(f: &mut Frame, ui_state: &crate::state::ui::UIState, area: Rect) {
+        let help_mode = ui_state.help_mode;
         let columns = Layout::default()
             .direction(Direction::Horizontal)
             .constraints([
@@ -795,7 +948,7 @@ impl HelpSystem {
                 Constraint::Length(4),      // More separator space for padding
                 Constraint::Percentage(50), // Right column
             ])
-            .split(main_layout[1]);
+            .split(area);
 
         // Left column - current pane commands + global
         let left_content = Self::create_left_column(help_mode);
@@ -885,24 +1038,85 @@ impl HelpSystem {
             .style(Style::default().fg(Color::Rgb(80, 95, 140)))
             .alignment(Alignment::Center);
         f.render_widget(separator_paragraph, columns[1]);
+    }
 
-        // Add elegant footer with instructions
-        let footer_text = "💡 Press ? to close • ←/→ or Tab to switch panes • ↑/↓ or j/k to scroll • PgUp/PgDown for faster scrolling";
-        let footer = Paragraph::new(footer_text)
-            .style(
+    /// Render the filter box and the commands of every scope matching it
+    fn render_search(f: &mut Frame, ui_state: &crate::state::ui::UIState, area: Rect) {
+        let layout = Layout::default()
+            .direction(Direction::Vertical)
+            .constraints([Constraint::Length(3), Constraint::Min(0)])
+            .split(area);
+
+        let entries = Self::search(&ui_state.help_filter);
+        let cursor = if ui_state.help_filter_active {
+            "▏"
+        } else {
+            ""
+        };
+        let filter = Paragraph::new(Line::from(vec![
+            Span::styled("🔍 ", Style::default().fg(Color::Rgb(120, 180, 255))),
+            Span::styled(
+                format!("{}{cursor}", ui_state.help_filter),
                 Style::default()
-                    .fg(Color::Rgb(140, 160, 200))
+                    .fg(Color::Rgb(240, 245, 250))
+                    .add_modifier(Modifier::BOLD),
+            ),
+            Span::styled(
+                format!("  {} matching", entries.len()),
+                Style::default().fg(Color::Rgb(140, 160, 200)),
+            ),
+        ]))
+        .block(
+            Block::default()
+                .title(" Search ")
+                .borders(Borders::ALL)
+                .border_type(ratatui::widgets::BorderType::Rounded)
+                .border_style(Style::default().fg(Color::Rgb(120, 180, 255)))
+                .style(Style::default().bg(Color::Rgb(18, 22, 26))),
+        );
+        f.render_widget(filter, layout[0]);
+
+        let mut lines: Vec<Line> = entries
+            .into_iter()
+            .map(|entry| {
+                Line::from(vec![
+                    Span::raw("  "),
+                    Span::styled(
+                        format!("{:<13}", entry.scope),
+                        Style::default().fg(Color::Rgb(100, 220, 180)),
+                    ),
+                    Span::styled(
+                        format!("{:<14}", entry.key),
+                        Style::default()
+                            .fg(Color::Rgb(170, 220, 255))
+                            .add_modifier(Modifier::BOLD),
+                    ),
+                    Span::styled(
+                        entry.description,
+                        Style::default().fg(Color::Rgb(240, 245, 250)),
+                    ),
+                ])
+            })
+            .collect();
+        if lines.is_empty() {
+            lines.push(Line::from(Span::styled(
+                "  No commands match",
+                Style::default()
+                    .fg(Color::Gray)
                     .add_modifier(Modifier::ITALIC),
-            )
-            .alignment(Alignment::Center)
+            )));
+        }
+        let results = Paragraph::new(lines)
+            .scroll((ui_state.help_left_scroll_offset as u16, 0))
             .block(
                 Block::default()
-                    .borders(Borders::TOP)
+                    .title(" Matching Commands ")
+                    .borders(Borders::ALL)
+                    .border_type(ratatui::widgets::BorderType::Rounded)
                     .border_style(Style::default().fg(Color::Rgb(80, 100, 150)))
-                    .style(Style::default().bg(Color::Rgb(12, 15, 18))),
+                    .style(Style::default().bg(Color::Rgb(18, 22, 26))),
             );
-
-        f.render_widget(footer, main_layout[2]);
+        f.render_widget(results, layout[1]);
     }
 }
 
@@ -926,3 +1140,31 @@ fn centered_rect(percent_x: u16, percent_y: u16, r: Rect) -> Rect {
         ])
         .split(popup_layout[1])[1]
 }
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_search_across_scopes() {
+        let entries = HelpSystem::search("DEBUG");
+        assert!(entries
+            .iter()
+            .any(|entry| entry.scope == "global" && entry.key == "C-B"));
+        // Listed in both global columns, shown once
+        assert_eq!(
+            entries
+                .iter()
+                .filter(|entry| entry.description == "Toggle debug view")
+                .count(),
+            1
+        );
+
+        let scopes: Vec<&str> = HelpSystem::search("")
+            .iter()
+            .map(|entry| entry.scope)
+            .collect();
+        assert!(scopes.contains(&"results") && scopes.contains(&"query"));
+        assert!(HelpSystem::search("no such command").is_empty());
+    }
+}
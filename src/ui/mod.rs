@@ -7,7 +7,7 @@ use crate::{
     config::Config,
     constants,
     core::error::Result,
-    database::ConnectionStatus,
+    database::{reachability::Reachability, ConnectionStatus, DatabaseType, DisplayTimeZone},
     state::OverlayView,
 };
 use ratatui::{
@@ -24,6 +24,7 @@ pub mod layout;
 pub mod theme;
 pub mod widgets;
 
+use components::operation;
 use layout::LayoutManager;
 use theme::Theme;
 
@@ -38,11 +39,29 @@ pub struct ConfirmationModal {
 /// Actions that can be confirmed
 #[derive(Debug, Clone)]
 pub enum ConfirmationAction {
-    DeleteConnection(usize),
+    /// Delete the connection with this ID, closing it first when open
+    DeleteConnection(String),
     DeleteTable(String),
     DeleteSqlFile(usize),
+    /// Load the named SQL file, discarding unsaved query editor changes
+    LoadSqlFile(String),
+    /// Save the query editor's content as the named SQL file, replacing it
+    SaveSqlFileAs(String),
     ExitApplication,
     QuitQueryEditor,
+    /// Run the statement, an UPDATE shown in a preview first
+    RunStatement(String),
+    /// Run the statement at the cursor, or the whole script when `script`,
+    /// on the connection selected since the last statement ran on another one
+    RunOnOtherConnection { script: bool },
+    /// Run the statements of a script, one a statement guard holds back
+    /// among them
+    RunScript {
+        script: String,
+        statements: Vec<String>,
+    },
+    /// Run the statement of the undo log entry at this index
+    ApplyUndo(usize),
     // Add more actions as needed
 }
 
@@ -73,6 +92,11 @@ impl UI {
         } else {
             Theme::default()
         };
+        let theme = if config.ui.accessibility_mode {
+            theme.accessible()
+        } else {
+            theme
+        };
 
         Ok(Self {
             layout_manager,
@@ -183,22 +207,54 @@ impl UI {
         // Clear the frame to prevent artifacts
         frame.render_widget(ratatui::widgets::Clear, frame.area());
 
-        let areas = self.layout_manager.calculate_layout(frame.area());
+        let mut areas = self.layout_manager.calculate_layout(frame.area());
+        // The object tree takes the place of the Connections and Tables panes
+        let tree = state.ui.sidebar_mode == crate::config::SidebarMode::Tree;
+        if tree {
+            areas.connections.height += areas.tables.height;
+            areas.tables.height = 0;
+        }
+
+        // Take the bottom row of each pane for its key hints
+        let mut key_hints = Vec::new();
+        if state.ui.show_key_hints {
+            for (pane, area) in [
+                (FocusedPane::Connections, &mut areas.connections),
+                (FocusedPane::Tables, &mut areas.tables),
+                (FocusedPane::Details, &mut areas.details),
+                (FocusedPane::TabularOutput, &mut areas.tabular_output),
+                (FocusedPane::SqlFiles, &mut areas.sql_files),
+                (FocusedPane::QueryWindow, &mut areas.query_window),
+            ] {
+                if area.height > 3 {
+                    area.height -= 1;
+                    key_hints.push((pane, Rect::new(area.x, area.bottom(), area.width, 1)));
+                }
+            }
+        }
 
         // Draw header
         self.draw_header(frame, areas.header, state);
 
-        // Draw connections pane
-        self.draw_connections_pane(frame, areas.connections, state);
+        if tree {
+            self.draw_object_tree(frame, areas.connections, state);
+        } else {
+            // Draw connections pane
+            self.draw_connections_pane(frame, areas.connections, state);
 
-        // Draw tables pane
-        self.draw_tables_pane(frame, areas.tables, state);
+            // Draw tables pane
+            self.draw_tables_pane(frame, areas.tables, state);
+        }
 
         // Draw details pane
         self.draw_details_pane(frame, areas.details, state);
 
         // Draw tabular output area
         self.draw_tabular_output(frame, areas.tabular_output, state);
+        if let Some(progress) = &state.fetch_progress {
+            let cancel_key = state.keymap.cancel_query_key().to_string();
+            progress.render(frame, areas.tabular_output, &self.theme, &cancel_key);
+        }
 
         // Draw SQL files browser
         self.draw_sql_files_pane(frame, areas.sql_files, state);
@@ -206,6 +262,24 @@ impl UI {
         // Draw query window area
         self.draw_query_window(frame, areas.query_window, state);
 
+        // Without color, only the thicker border and its marker show focus
+        if self.theme.accessible {
+            let focused = match state.ui.focused_pane {
+                FocusedPane::Connections => areas.connections,
+                FocusedPane::Tables if tree => areas.connections,
+                FocusedPane::Tables => areas.tables,
+                FocusedPane::Details => areas.details,
+                FocusedPane::TabularOutput => areas.tabular_output,
+                FocusedPane::SqlFiles => areas.sql_files,
+                FocusedPane::QueryWindow => areas.query_window,
+            };
+            mark_focused_pane(frame.buffer_mut(), focused);
+        }
+
+        for (pane, area) in key_hints {
+            self.draw_key_hints(frame, area, state, pane);
+        }
+
         // Draw status bar
         self.draw_status_bar(frame, areas.status_bar, state);
 
@@ -234,7 +308,7 @@ impl UI {
                 &state.connection_modal_state,
                 frame.area(),
                 state.ui.current_view.is_connection_form(), // Pass edit mode flag
-                state.test_animation_frame,
+                state.spinner.frame(),
                 state.test_connection_in_progress,
                 state
                     .test_start_time
@@ -268,6 +342,63 @@ impl UI {
                 state.ui.debug_view_scroll_offset,
             );
         }
+
+        // Draw the first-run wizard's driver selection (full-screen overlay)
+        if state.ui.current_view.is_welcome() {
+            if let Some(wizard) = &state.first_run_wizard {
+                wizard.render(frame, frame.area(), &self.theme);
+            }
+        }
+
+        // Draw the LISTEN/NOTIFY watcher (full-screen overlay)
+        if state.ui.current_view.is_notifications() {
+            state.notifications.render(frame, frame.area(), &self.theme);
+        }
+
+        // Draw the background tasks overlay
+        if state.ui.current_view.is_tasks() {
+            components::render_tasks(
+                frame,
+                &state.spinner,
+                state.ui.tasks_selected,
+                frame.area(),
+                &self.theme,
+            );
+        }
+
+        // Draw the pinned results picker
+        if state.ui.current_view.is_pins() {
+            components::render_pins(
+                frame,
+                &state.table_viewer_state.pins,
+                frame.area(),
+                &self.theme,
+            );
+        }
+
+        // Draw the undo log
+        if state.ui.current_view.is_undo_log() {
+            components::render_undo_log(frame, &state.undo_log, frame.area(), &self.theme);
+        }
+
+        // Draw the session statistics
+        if state.ui.current_view.is_session_stats() {
+            components::render_session_stats(
+                frame,
+                state.connection_manager.session_stats(),
+                frame.area(),
+                &self.theme,
+            );
+        }
+
+        // Draw the query history picker
+        if let Some(picker) = state
+            .query_history_picker
+            .as_ref()
+            .filter(|_| state.ui.current_view.is_query_history())
+        {
+            components::render_query_history(frame, picker, frame.area(), &self.theme);
+        }
     }
 
     /// Draw the header bar
@@ -284,6 +415,25 @@ impl UI {
         frame.render_widget(header, area);
     }
 
+    /// Draw a line of the pane's main keys, as bound in the keymap
+    fn draw_key_hints(&self, frame: &mut Frame, area: Rect, state: &AppState, pane: FocusedPane) {
+        let key_style = if state.ui.focused_pane == pane {
+            Style::default().fg(self.theme.get_color("accent"))
+        } else {
+            Style::default().fg(self.theme.get_color("text_secondary"))
+        };
+        let muted = Style::default().fg(self.theme.get_color("text_muted"));
+        let mut spans = vec![Span::raw(" ")];
+        for (i, (key, action)) in state.keymap.hints(pane).into_iter().enumerate() {
+            if i > 0 {
+                spans.push(Span::styled(" · ", muted));
+            }
+            spans.push(Span::styled(key.to_string(), key_style));
+            spans.push(Span::styled(format!(" {action}"), muted));
+        }
+        frame.render_widget(Paragraph::new(Line::from(spans)), area);
+    }
+
     /// Draw the connections pane
     fn draw_connections_pane(&self, frame: &mut Frame, area: Rect, state: &mut AppState) {
         let is_focused = state.ui.focused_pane == FocusedPane::Connections;
@@ -349,7 +499,7 @@ impl UI {
                 let db_name = connection.database.as_deref().unwrap_or("default");
                 let db_type_name = connection.database_type.display_name();
 
-                let line = Line::from(vec![
+                let mut line = Line::from(vec![
                     Span::styled(
                         format!("{} ", db_type_icon),
                         Style::default().fg(Color::Cyan),
@@ -373,21 +523,45 @@ impl UI {
                         if matches!(connection.status, ConnectionStatus::Connecting)
                             && state.connecting_in_progress == Some(index)
                         {
-                            let dots = match state.connecting_animation_frame {
-                                0 => "•",
-                                1 => "••",
-                                2 => "•••",
-                                _ => "•",
-                            };
                             let elapsed = state.get_connection_elapsed_seconds();
                             let timeout = state.connection_timeout_seconds;
-                            format!("Connecting {} {}/{}s", dots, elapsed, timeout)
+                            let attempt = state
+                                .connect_attempt
+                                .map(|(attempt, attempts)| {
+                                    format!(" · attempt {attempt}/{attempts}")
+                                })
+                                .unwrap_or_default();
+                            format!(
+                                "Connecting {} {}/{}s{}",
+                                state.spinner.frame(),
+                                elapsed,
+                                timeout,
+                                attempt
+                            )
                         } else {
                             connection.status_text().to_string()
                         },
                         text_style,
                     ),
                 ]);
+                // What the startup ping found, until the connection is used
+                let unused = matches!(
+                    connection.status,
+                    ConnectionStatus::Disconnected | ConnectionStatus::Failed(_)
+                );
+                if let Some(reachability) =
+                    state.reachability.get(&connection.id).filter(|_| unused)
+                {
+                    let color = match reachability {
+                        Reachability::Pinging => Color::DarkGray,
+                        Reachability::Reachable(_) => Color::Green,
+                        Reachability::Unreachable(_) => Color::Red,
+                    };
+                    line.push_span(Span::styled(
+                        format!("  {}", reachability.marker()),
+                        Style::default().fg(color),
+                    ));
+                }
 
                 ListItem::new(line)
             })
@@ -410,6 +584,14 @@ impl UI {
                 .connections
                 .get(state.ui.selected_connection)
             {
+                let unreachable = match state.reachability.get(&connection.id) {
+                    Some(Reachability::Unreachable(reason))
+                        if matches!(connection.status, ConnectionStatus::Disconnected) =>
+                    {
+                        Some(reason.as_str())
+                    }
+                    _ => None,
+                };
                 if let Some(error) = connection.get_error() {
                     items.push(ListItem::new(""));
                     items.push(ListItem::new(Line::from(vec![
@@ -419,6 +601,15 @@ impl UI {
                         ),
                         Span::styled(error, Style::default().fg(Color::Red)),
                     ])));
+                } else if let Some(reason) = unreachable {
+                    items.push(ListItem::new(""));
+                    items.push(ListItem::new(Line::from(vec![
+                        Span::styled(
+                            "Unreachable at startup: ",
+                            Style::default().fg(Color::Red).add_modifier(Modifier::BOLD),
+                        ),
+                        Span::styled(reason, Style::default().fg(Color::Red)),
+                    ])));
                 }
             }
         }
@@ -430,7 +621,10 @@ impl UI {
                 state.ui.connections_search_query
             )
         } else {
-            " [1] Connections ".to_string()
+            format!(
+                " [1] Connections{} ",
+                state.spinner.title_suffix(operation::CONNECTING)
+            )
         };
 
         let connections = List::new(items)
@@ -454,6 +648,24 @@ impl UI {
         state.ui.connections_list_state = list_state;
     }
 
+    /// Draw the object tree in place of the connections and tables panes
+    fn draw_object_tree(&self, frame: &mut Frame, area: Rect, state: &mut AppState) {
+        let focused = matches!(
+            state.ui.focused_pane,
+            FocusedPane::Connections | FocusedPane::Tables
+        );
+        let rows = state.object_tree_rows();
+        components::render_object_tree(
+            frame,
+            &mut state.object_tree,
+            &rows,
+            &state.db.connections.connections,
+            area,
+            &self.theme,
+            focused,
+        );
+    }
+
     /// Draw the tables/views pane
     fn draw_tables_pane(&self, frame: &mut Frame, area: Rect, state: &mut AppState) {
         // Use the dedicated TablesPane component with database-adaptive features
@@ -1254,15 +1466,27 @@ impl UI {
                 .query_editor
                 .set_database_type(Some(connection.database_type.clone()));
         }
+        // Name where statements run, so they don't run on the wrong one
+        let target = state
+            .db
+            .connections
+            .connections
+            .get(state.ui.selected_connection)
+            .filter(|connection| connection.is_connected());
+        state.query_editor.set_target(target);
 
         // Set file info
         state
             .query_editor
             .set_current_file(state.ui.current_sql_file.clone());
 
-        // Sync content between legacy state and QueryEditor
+        // Sync content between legacy state and QueryEditor, keeping the dirty
+        // flag when the change came from a legacy edit path
         if state.query_editor.get_content() != state.query_content {
             state.query_editor.set_content(state.query_content.clone());
+            if state.ui.query_modified {
+                state.query_editor.mark_modified();
+            }
         }
 
         // Set available tables and columns for suggestions
@@ -1310,9 +1534,33 @@ impl UI {
 
             match &connection.status {
                 ConnectionStatus::Connected => {
+                    // Name the zone timestamps are rendered in
+                    let time_zone = if connection.database_type == DatabaseType::SQLite {
+                        String::new()
+                    } else {
+                        let zone = connection
+                            .time_zone
+                            .as_deref()
+                            .and_then(|zone| DisplayTimeZone::parse(zone).ok())
+                            .unwrap_or_default();
+                        format!(" • TZ {zone}")
+                    };
+                    // And how long the server lets a statement run
+                    let timeout = state
+                        .statement_timeouts
+                        .effective(connection)
+                        .map(|seconds| format!(" • timeout {seconds}s"))
+                        .unwrap_or_default();
+                    // A replica or read-only server, or writes turned off
+                    let guard = &state.read_only_guard;
+                    let role = match guard.role(&connection.id).badge() {
+                        Some(badge) => format!(" • {badge}"),
+                        None if guard.is_guarded(&connection.id) => " • WRITES OFF".to_string(),
+                        None => String::new(),
+                    };
                     format!(
-                        "{}:{} • {} • Connected",
-                        connection.host, connection.port, database
+                        "{}:{} • {} • Connected{}{}{}",
+                        connection.host, connection.port, database, role, time_zone, timeout
                     )
                 }
                 ConnectionStatus::Connecting => {
@@ -1366,9 +1614,14 @@ impl UI {
             FocusedPane::Details => "[DETAILS] Table Details".to_string(),
         };
 
-        // Get current date and time
-        let now = chrono::Local::now();
-        let datetime_text = now.format("%b %d, %Y  %H:%M:%S").to_string();
+        // Get current date and time, unless the clock is turned off
+        let datetime_text = if state.ui.show_clock {
+            chrono::Local::now()
+                .format(&state.ui.clock_format)
+                .to_string()
+        } else {
+            String::new()
+        };
 
         // Add help hint when not showing help
         let help_hint = if state.ui.help_mode == crate::app::state::HelpMode::None {
@@ -1377,14 +1630,32 @@ impl UI {
             ""
         };
 
+        // Running operations sit right-aligned next to the date/time
+        let activity_text = state
+            .spinner
+            .status_text()
+            .map(|text| format!("{text}  "))
+            .unwrap_or_default();
+        // Otherwise how the last query went
+        let last_query_text = if activity_text.is_empty() {
+            state
+                .session_activity
+                .status_text()
+                .map(|text| format!("{text}  "))
+                .unwrap_or_default()
+        } else {
+            String::new()
+        };
+
         // Calculate the width of left side content
         let left_content = format!("{brand} | {connection_text} | {position_text}{help_hint}");
 
         // Calculate padding needed to right-align the date/time
         let available_width = area.width as usize;
         let left_width = left_content.len();
-        let datetime_width = datetime_text.len();
-        let padding_width = available_width.saturating_sub(left_width + datetime_width + 2); // 2 for margins
+        let right_width =
+            activity_text.chars().count() + last_query_text.chars().count() + datetime_text.len();
+        let padding_width = available_width.saturating_sub(left_width + right_width + 2); // 2 for margins
 
         let status_line = Line::from(vec![
             Span::styled(
@@ -1399,6 +1670,14 @@ impl UI {
             Span::raw(&position_text),
             Span::raw(help_hint),
             Span::raw(" ".repeat(padding_width)),
+            Span::styled(
+                activity_text,
+                Style::default().fg(self.theme.get_color("warning")),
+            ),
+            Span::styled(
+                last_query_text,
+                Style::default().fg(self.theme.get_color("text_muted")),
+            ),
             Span::styled(
                 datetime_text,
                 Style::default()
@@ -1416,3 +1695,79 @@ impl UI {
         frame.render_widget(status_bar, area);
     }
 }
+
+/// Border line characters and their thick counterparts
+const THICK_BORDER: [(&str, &str); 6] = [
+    ("─", "━"),
+    ("│", "┃"),
+    ("┌", "┏"),
+    ("┐", "┓"),
+    ("└", "┗"),
+    ("┘", "┛"),
+];
+
+/// Marker written into the focused pane's bottom border in accessibility
+/// mode, where it can't cover the title
+const FOCUS_MARKER: &str = "[FOCUS]";
+
+/// Thicken the border drawn around `area` and write the focus marker into
+/// its bottom right, leaving the title and anything else on the border as is
+fn mark_focused_pane(buffer: &mut ratatui::buffer::Buffer, area: Rect) {
+    let area = area.intersection(buffer.area);
+    if area.width < 2 || area.height < 2 {
+        return;
+    }
+    let top = area.top();
+    let bottom = area.bottom() - 1;
+    let left = area.left();
+    let right = area.right() - 1;
+    let edges = (left..=right)
+        .flat_map(|x| [(x, top), (x, bottom)])
+        .chain((top..=bottom).flat_map(|y| [(left, y), (right, y)]));
+    for position in edges {
+        if let Some(cell) = buffer.cell_mut(position) {
+            if let Some((_, thick)) = THICK_BORDER.iter().find(|(thin, _)| cell.symbol() == *thin) {
+                cell.set_symbol(thick);
+            }
+        }
+    }
+
+    let width = FOCUS_MARKER.len() as u16;
+    if area.width >= width + 4 {
+        buffer.set_string(
+            right - 1 - width,
+            bottom,
+            FOCUS_MARKER,
+            Style::default().add_modifier(Modifier::BOLD | Modifier::REVERSED),
+        );
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use ratatui::{buffer::Buffer, widgets::Widget};
+
+    #[test]
+    fn test_focused_pane_is_marked_without_color() {
+        let area = Rect::new(0, 0, 20, 3);
+        let mut buffer = Buffer::empty(area);
+        Block::default()
+            .title(" [1] Conn ")
+            .borders(Borders::ALL)
+            .render(area, &mut buffer);
+
+        mark_focused_pane(&mut buffer, area);
+        let lines: Vec<String> = (0..3)
+            .map(|y| (0..20).map(|x| buffer[(x, y)].symbol()).collect())
+            .collect();
+        assert_eq!(
+            lines,
+            [
+                "┏ [1] Conn ━━━━━━━━┓",
+                "┃                  ┃",
+                "┗━━━━━━━━━━[FOCUS]━┛",
+            ]
+        );
+    }
+}
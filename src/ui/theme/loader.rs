@@ -14,10 +14,8 @@ impl ThemeLoader {
     pub fn theme_directories() -> Vec<PathBuf> {
         let mut dirs = Vec::new();
 
-        // User themes directory (~/.config/lazytables/themes/)
-        if let Some(config_dir) = dirs::config_dir() {
-            dirs.push(config_dir.join("lazytables").join("themes"));
-        }
+        // User themes directory ($XDG_CONFIG_HOME/lazytables/themes/)
+        dirs.push(crate::config::Config::config_dir().join("themes"));
 
         // User data directory (~/.local/share/lazytables/themes/)
         if let Some(data_dir) = dirs::data_dir() {
@@ -77,10 +75,7 @@ impl ThemeLoader {
         let theme = Theme::load_from_file(theme_path)?;
 
         // Get user themes directory
-        let user_themes_dir = dirs::config_dir()
-            .ok_or("Could not determine config directory")?
-            .join("lazytables")
-            .join("themes");
+        let user_themes_dir = crate::config::Config::config_dir().join("themes");
 
         // Create themes directory if it doesn't exist
         fs::create_dir_all(&user_themes_dir)?;
@@ -17,6 +17,9 @@ pub struct Theme {
     pub name: String,
     pub author: String,
     pub colors: ThemeColors,
+    /// Spell out what colors alone would show ([ui] accessibility_mode)
+    #[serde(skip)]
+    pub accessible: bool,
 }
 
 #[derive(Debug, Clone, Serialize, Deserialize)]
@@ -216,6 +219,27 @@ impl Default for Theme {
     }
 }
 
+impl Theme {
+    /// The theme for accessibility mode: dimmed borders, placeholders,
+    /// comments and line numbers take the foreground color, and renderers
+    /// add text markers where they would rely on color
+    pub fn accessible(mut self) -> Self {
+        let foreground = self.colors.foreground.clone();
+        for color in [
+            &mut self.colors.border,
+            &mut self.colors.inactive_pane,
+            &mut self.colors.input_border,
+            &mut self.colors.input_placeholder,
+            &mut self.colors.editor_line_number,
+            &mut self.colors.syntax_comment,
+        ] {
+            color.clone_from(&foreground);
+        }
+        self.accessible = true;
+        self
+    }
+}
+
 impl Theme {
     pub fn dark_theme() -> Self {
         Self {
@@ -300,6 +324,7 @@ impl Theme {
                 help_key: "#74c7ec".to_string(),
                 help_description: "#bac2de".to_string(),
             },
+            accessible: false,
         }
     }
 
@@ -386,6 +411,7 @@ impl Theme {
                 help_key: "#1e66f5".to_string(),
                 help_description: "#5c5f77".to_string(),
             },
+            accessible: false,
         }
     }
 
@@ -0,0 +1,57 @@
+// FilePath: src/version.rs
+
+#![forbid(unsafe_code)]
+
+//! Build information embedded by build.rs
+
+/// Crate version from Cargo.toml
+pub const VERSION: &str = env!("CARGO_PKG_VERSION");
+
+/// Git commit the binary was built from ("unknown" outside a git checkout)
+pub const GIT_COMMIT: &str = env!("LAZYTABLES_GIT_COMMIT");
+
+/// Build date (YYYY-MM-DD, UTC)
+pub const BUILD_DATE: &str = env!("LAZYTABLES_BUILD_DATE");
+
+/// Compiler used for the build
+pub const RUSTC_VERSION: &str = env!("LAZYTABLES_RUSTC_VERSION");
+
+/// Target triple of the build
+pub const TARGET: &str = env!("LAZYTABLES_TARGET");
+
+/// One-line version: "lazytables 0.2.3 (abc1234 2025-10-20)"
+pub fn short() -> String {
+    format!("lazytables {VERSION} ({GIT_COMMIT} {BUILD_DATE})")
+}
+
+/// Full build information block for `--version --verbose` and crash reports
+pub fn long() -> String {
+    format!(
+        "lazytables {VERSION}\n\
+         commit:     {GIT_COMMIT}\n\
+         built:      {BUILD_DATE}\n\
+         rustc:      {RUSTC_VERSION}\n\
+         target:     {TARGET}\n\
+         profile:    {}",
+        if cfg!(debug_assertions) {
+            "debug"
+        } else {
+            "release"
+        }
+    )
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_long_contains_every_field() {
+        let info = long();
+        assert!(info.starts_with(&format!("lazytables {VERSION}\n")));
+        for field in ["commit:", "built:", "rustc:", "target:", "profile:"] {
+            assert!(info.contains(field), "missing {field}");
+        }
+        assert!(short().contains(GIT_COMMIT));
+    }
+}